@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+const (
+	credDefault          = "default"
+	credEnvironment      = "environment"
+	credManagedIdentity  = "managed-identity"
+	credAzureCLI         = "cli"
+	credOIDC             = "oidc"
+	credWorkloadIdentity = "workload-identity"
+)
+
+// credChainNames are the credentials that can be specified in the `--cred-chain`.
+var credChainNames = []string{credEnvironment, credManagedIdentity, credAzureCLI, credOIDC, credWorkloadIdentity}
+
+// newCredential builds the credential of the name.
+func newCredential(name string, fset FlagSet, clientOpt *arm.ClientOptions) (azcore.TokenCredential, error) {
+	tenantId := os.Getenv("ARM_TENANT_ID")
+	switch name {
+	case credEnvironment:
+		cred, err := azidentity.NewEnvironmentCredential(&azidentity.EnvironmentCredentialOptions{
+			ClientOptions: clientOpt.ClientOptions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to new Environment credential: %v", err)
+		}
+		return cred, nil
+	case credManagedIdentity:
+		cred, err := azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{
+			ClientOptions: clientOpt.ClientOptions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to new Managed Identity credential: %v", err)
+		}
+		return cred, nil
+	case credAzureCLI:
+		cred, err := azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{
+			TenantID: tenantId,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to new Azure CLI credential: %v", err)
+		}
+		return cred, nil
+	case credOIDC:
+		cred, err := NewOidcCredential(&OidcCredentialOptions{
+			ClientOptions: clientOpt.ClientOptions,
+			TenantID:      tenantId,
+			ClientID:      os.Getenv("ARM_CLIENT_ID"),
+			RequestToken:  fset.flagOIDCRequestToken,
+			RequestUrl:    fset.flagOIDCRequestURL,
+			Token:         fset.flagOIDCToken,
+			TokenFilePath: fset.flagOIDCTokenFilePath,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to new OIDC credential: %v", err)
+		}
+		return cred, nil
+	case credWorkloadIdentity:
+		// The client id, tenant id and token file default to the AZURE_CLIENT_ID, AZURE_TENANT_ID and AZURE_FEDERATED_TOKEN_FILE set by the workload identity webhook.
+		cred, err := azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientOptions: clientOpt.ClientOptions,
+			TenantID:      tenantId,
+			ClientID:      os.Getenv("ARM_CLIENT_ID"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to new Workload Identity credential: %v", err)
+		}
+		return cred, nil
+	case credDefault:
+		cred, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			ClientOptions: clientOpt.ClientOptions,
+			TenantID:      tenantId,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to new Default credential: %v", err)
+		}
+		return cred, nil
+	}
+	return nil, fmt.Errorf("unknown credential %q", name)
+}
+
+var _ azcore.TokenCredential = &chainCredential{}
+
+// chainCredential tries the credentials in order until one of them gets a token, which is then used for the later tokens.
+// The chosen credential is logged, as the fallback isn't obvious otherwise.
+type chainCredential struct {
+	names []string
+	creds []azcore.TokenCredential
+	// errs are the errors of building the credentials, whose credentials are nil
+	errs []error
+
+	mu       sync.Mutex
+	selected int
+}
+
+func newChainCredential(names []string, creds []azcore.TokenCredential, errs []error) *chainCredential {
+	return &chainCredential{names: names, creds: creds, errs: errs, selected: -1}
+}
+
+func (c *chainCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.mu.Lock()
+	selected := c.selected
+	c.mu.Unlock()
+	if selected != -1 {
+		return c.creds[selected].GetToken(ctx, opts)
+	}
+
+	var msgs []string
+	for i, cred := range c.creds {
+		if cred == nil {
+			log.Printf("[DEBUG] Skipping the %s credential: %v", c.names[i], c.errs[i])
+			msgs = append(msgs, fmt.Sprintf("%s: %v", c.names[i], c.errs[i]))
+			continue
+		}
+		token, err := cred.GetToken(ctx, opts)
+		if err != nil {
+			log.Printf("[DEBUG] The %s credential failed to get a token: %v", c.names[i], err)
+			if len(c.creds) == 1 {
+				return azcore.AccessToken{}, err
+			}
+			msgs = append(msgs, fmt.Sprintf("%s: %v", c.names[i], err))
+			continue
+		}
+		log.Printf("[INFO] Authenticated via the %s credential", c.names[i])
+		c.mu.Lock()
+		c.selected = i
+		c.mu.Unlock()
+		return token, nil
+	}
+	return azcore.AccessToken{}, fmt.Errorf("none of the credentials in the chain gets a token:\n%s", strings.Join(msgs, "\n"))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCredential struct {
+	token string
+	err   error
+	calls int
+}
+
+func (c *fakeCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.calls++
+	if c.err != nil {
+		return azcore.AccessToken{}, c.err
+	}
+	return azcore.AccessToken{Token: c.token}, nil
+}
+
+func TestChainCredential(t *testing.T) {
+	oidc := &fakeCredential{err: errors.New("no ID token")}
+	cli := &fakeCredential{token: "cli-token"}
+	msi := &fakeCredential{token: "msi-token"}
+	cred := newChainCredential(
+		[]string{credWorkloadIdentity, credOIDC, credAzureCLI, credManagedIdentity},
+		[]azcore.TokenCredential{nil, oidc, cli, msi},
+		[]error{errors.New("no token file specified"), nil, nil, nil},
+	)
+
+	token, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "cli-token", token.Token)
+
+	// The chosen credential is used for the later tokens
+	token, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "cli-token", token.Token)
+	require.Equal(t, 1, oidc.calls)
+	require.Equal(t, 2, cli.calls)
+	require.Equal(t, 0, msi.calls)
+
+	cred = newChainCredential(
+		[]string{credWorkloadIdentity, credOIDC},
+		[]azcore.TokenCredential{nil, oidc},
+		[]error{errors.New("no token file specified"), nil},
+	)
+	_, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{})
+	require.EqualError(t, err, "none of the credentials in the chain gets a token:\nworkload-identity: no token file specified\noidc: no ID token")
+
+	// The error of a single credential is returned as is
+	cred = newChainCredential([]string{credOIDC}, []azcore.TokenCredential{oidc}, []error{nil})
+	_, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{})
+	require.EqualError(t, err, "no ID token")
+}
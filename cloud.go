@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The cloud environments. Except for the custom one, they are also the values of the "environment" setting of the providers.
+const (
+	cloudEnvPublic       = "public"
+	cloudEnvUSGovernment = "usgovernment"
+	cloudEnvChina        = "china"
+	cloudEnvCustom       = "custom"
+)
+
+// cloudEnvAliases maps the lower cased environment names, including the ones used by the Azure CLI (e.g. "AzureUSGovernment"), to the cloud environments.
+var cloudEnvAliases = map[string]string{
+	"public":                 cloudEnvPublic,
+	"azurecloud":             cloudEnvPublic,
+	"azurepubliccloud":       cloudEnvPublic,
+	"usgovernment":           cloudEnvUSGovernment,
+	"azureusgovernment":      cloudEnvUSGovernment,
+	"azureusgovernmentcloud": cloudEnvUSGovernment,
+	"china":                  cloudEnvChina,
+	"azurechinacloud":        cloudEnvChina,
+	"custom":                 cloudEnvCustom,
+}
+
+// normalizeCloudEnv returns the cloud environment of the environment name.
+func normalizeCloudEnv(env string) (string, error) {
+	if v, ok := cloudEnvAliases[strings.ToLower(env)]; ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("unknown environment specified: %q", env)
+}
+
+// cloudConfiguration returns the cloud configuration of the Azure SDK clients. The one of the custom cloud (e.g. Azure Stack Hub) is discovered from the metadata
+// endpoint of its resource manager.
+func cloudConfiguration(env, resourceManagerEndpoint string) (cloud.Configuration, error) {
+	env, err := normalizeCloudEnv(env)
+	if err != nil {
+		return cloud.Configuration{}, err
+	}
+	switch env {
+	case cloudEnvUSGovernment:
+		return cloud.AzureGovernment, nil
+	case cloudEnvChina:
+		return cloud.AzureChina, nil
+	case cloudEnvCustom:
+		return customCloudConfiguration(resourceManagerEndpoint)
+	default:
+		return cloud.AzurePublic, nil
+	}
+}
+
+func customCloudConfiguration(endpoint string) (cloud.Configuration, error) {
+	if endpoint == "" {
+		return cloud.Configuration{}, fmt.Errorf("the resource manager endpoint of the custom environment is not specified")
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(endpoint + "/metadata/endpoints?api-version=2015-01-01")
+	if err != nil {
+		return cloud.Configuration{}, fmt.Errorf("retrieving the metadata of the resource manager %s: %v", endpoint, err)
+	}
+	// #nosec G307
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return cloud.Configuration{}, fmt.Errorf("retrieving the metadata of the resource manager %s: unexpected status %s", endpoint, resp.Status)
+	}
+	var metadata struct {
+		Authentication struct {
+			LoginEndpoint string   `json:"loginEndpoint"`
+			Audiences     []string `json:"audiences"`
+		} `json:"authentication"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return cloud.Configuration{}, fmt.Errorf("decoding the metadata of the resource manager %s: %v", endpoint, err)
+	}
+	if metadata.Authentication.LoginEndpoint == "" || len(metadata.Authentication.Audiences) == 0 {
+		return cloud.Configuration{}, fmt.Errorf("the metadata of the resource manager %s has no login endpoint or audience", endpoint)
+	}
+	return cloud.Configuration{
+		ActiveDirectoryAuthorityHost: metadata.Authentication.LoginEndpoint,
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+			cloud.ResourceManager: {
+				Audience: metadata.Authentication.Audiences[0],
+				Endpoint: endpoint,
+			},
+		},
+	}, nil
+}
+
+// providerCloudConfig returns the provider settings that point the provider to the cloud environment, which is empty for the public cloud.
+// The custom cloud is only supported by the azurerm provider, via its "metadata_host".
+func providerCloudConfig(providerName, env, resourceManagerEndpoint string) (map[string]cty.Value, error) {
+	env, err := normalizeCloudEnv(env)
+	if err != nil {
+		return nil, err
+	}
+	switch env {
+	case cloudEnvPublic:
+		return nil, nil
+	case cloudEnvCustom:
+		if providerName != "" && providerName != meta.ProviderAzureRM {
+			return nil, fmt.Errorf("the custom environment is not supported by the provider %q", providerName)
+		}
+		u, err := url.Parse(resourceManagerEndpoint)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid resource manager endpoint %q", resourceManagerEndpoint)
+		}
+		return map[string]cty.Value{"metadata_host": cty.StringVal(u.Host)}, nil
+	default:
+		return map[string]cty.Value{"environment": cty.StringVal(env)}, nil
+	}
+}
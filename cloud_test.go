@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestCloudConfiguration(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metadata/endpoints" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// #nosec G104
+		w.Write([]byte(`{"authentication": {"loginEndpoint": "https://adfs.local.azurestack.external/adfs/", "audiences": ["https://management.adfs.azurestack.local/1234"]}}`))
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		name     string
+		env      string
+		endpoint string
+		expect   cloud.Configuration
+		errMatch string
+	}{
+		{
+			name:   "public",
+			env:    "public",
+			expect: cloud.AzurePublic,
+		},
+		{
+			name:   "AzureUSGovernment",
+			env:    "AzureUSGovernment",
+			expect: cloud.AzureGovernment,
+		},
+		{
+			name:   "AzureChinaCloud",
+			env:    "AzureChinaCloud",
+			expect: cloud.AzureChina,
+		},
+		{
+			name:     "custom",
+			env:      "custom",
+			endpoint: srv.URL + "/",
+			expect: cloud.Configuration{
+				ActiveDirectoryAuthorityHost: "https://adfs.local.azurestack.external/adfs/",
+				Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+					cloud.ResourceManager: {
+						Audience: "https://management.adfs.azurestack.local/1234",
+						Endpoint: srv.URL,
+					},
+				},
+			},
+		},
+		{
+			name:     "custom without metadata",
+			env:      "custom",
+			endpoint: srv.URL + "/foo",
+			errMatch: "unexpected status 404 Not Found",
+		},
+		{
+			name:     "unknown",
+			env:      "mars",
+			errMatch: `unknown environment specified: "mars"`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := cloudConfiguration(tt.env, tt.endpoint)
+			if tt.errMatch != "" {
+				require.ErrorContains(t, err, tt.errMatch)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, actual)
+		})
+	}
+}
+
+func TestProviderCloudConfig(t *testing.T) {
+	cases := []struct {
+		name         string
+		providerName string
+		env          string
+		endpoint     string
+		expect       map[string]cty.Value
+		errMatch     string
+	}{
+		{
+			name: "public",
+			env:  "public",
+		},
+		{
+			name:   "AzureUSGovernment",
+			env:    "AzureUSGovernment",
+			expect: map[string]cty.Value{"environment": cty.StringVal("usgovernment")},
+		},
+		{
+			name:         "china with azapi",
+			providerName: "azapi",
+			env:          "china",
+			expect:       map[string]cty.Value{"environment": cty.StringVal("china")},
+		},
+		{
+			name:     "custom",
+			env:      "custom",
+			endpoint: "https://management.local.azurestack.external/",
+			expect:   map[string]cty.Value{"metadata_host": cty.StringVal("management.local.azurestack.external")},
+		},
+		{
+			name:         "custom with azapi",
+			providerName: "azapi",
+			env:          "custom",
+			endpoint:     "https://management.local.azurestack.external",
+			errMatch:     `the custom environment is not supported by the provider "azapi"`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := providerCloudConfig(tt.providerName, tt.env, tt.endpoint)
+			if tt.errMatch != "" {
+				require.ErrorContains(t, err, tt.errMatch)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, actual)
+		})
+	}
+}
@@ -2,18 +2,53 @@ package main
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 
+	internalconfig "github.com/Azure/aztfexport/internal/config"
+	"github.com/Azure/aztfexport/internal/i18n"
 	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/Azure/aztfexport/internal/provenance"
+	"github.com/Azure/aztfexport/internal/pulumi"
+	"github.com/Azure/aztfexport/internal/resourceset"
 	"github.com/Azure/aztfexport/internal/utils"
+	"github.com/Azure/aztfexport/pkg/telemetry"
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/magodo/armid"
 	"github.com/urfave/cli/v2"
 )
 
+// unlockWorkspace releases the lock of the output directory acquired by the commandBeforeFunc, which is called on exit.
+var unlockWorkspace = func() error { return nil }
+
 func commandBeforeFunc(fset *FlagSet) func(ctx *cli.Context) error {
 	return func(_ *cli.Context) error {
 		// Common flags check
+		if fset.flagOnNonEmptyDir != "" {
+			if err := validateOneOf("--on-nonempty-dir", fset.flagOnNonEmptyDir, OnNonEmptyDirActions); err != nil {
+				return err
+			}
+			switch fset.flagOnNonEmptyDir {
+			case OnNonEmptyDirOverwrite:
+				if fset.flagAppend {
+					return fmt.Errorf("`--on-nonempty-dir=%s` conflicts with `--append`", fset.flagOnNonEmptyDir)
+				}
+				fset.flagOverwrite = true
+			case OnNonEmptyDirAppend:
+				if fset.flagOverwrite {
+					return fmt.Errorf("`--on-nonempty-dir=%s` conflicts with `--overwrite`", fset.flagOnNonEmptyDir)
+				}
+				fset.flagAppend = true
+			case OnNonEmptyDirFail:
+				if fset.flagOverwrite {
+					return fmt.Errorf("`--on-nonempty-dir=%s` conflicts with `--overwrite`", fset.flagOnNonEmptyDir)
+				}
+				if fset.flagAppend {
+					return fmt.Errorf("`--on-nonempty-dir=%s` conflicts with `--append`", fset.flagOnNonEmptyDir)
+				}
+			}
+		}
 		if fset.flagAppend {
 			if fset.flagOverwrite {
 				return fmt.Errorf("`--append` conflicts with `--overwrite`")
@@ -23,8 +58,136 @@ func commandBeforeFunc(fset *FlagSet) func(ctx *cli.Context) error {
 			if fset.flagContinue {
 				return fmt.Errorf("`--continue` must be used together with `--non-interactive`")
 			}
-			if fset.flagGenerateMappingFile {
-				return fmt.Errorf("`--generate-mapping-file` must be used together with `--non-interactive`")
+			if fset.flagShowCommands {
+				return fmt.Errorf("`--show-commands` must be used together with `--non-interactive`")
+			}
+			if fset.flagDryRun {
+				return fmt.Errorf("`--dry-run` must be used together with `--non-interactive`")
+			}
+			if fset.flagChunkSize != 0 {
+				return fmt.Errorf("`--chunk-size` must be used together with `--non-interactive`")
+			}
+			if fset.flagResume {
+				return fmt.Errorf("`--resume` must be used together with `--non-interactive`")
+			}
+			if fset.flagMaxResources != 0 {
+				return fmt.Errorf("`--max-resources` must be used together with `--non-interactive`")
+			}
+			if fset.flagMaxDuration != 0 {
+				return fmt.Errorf("`--max-duration` must be used together with `--non-interactive`")
+			}
+			if fset.flagVerify {
+				return fmt.Errorf("`--verify` must be used together with `--non-interactive`")
+			}
+			if fset.flagReportMarkdown {
+				return fmt.Errorf("`--report-markdown` must be used together with `--non-interactive`")
+			}
+			if fset.flagOutputFormat == internalconfig.OutputFormatJSON {
+				return fmt.Errorf("`--output-format=%s` must be used together with `--non-interactive`", internalconfig.OutputFormatJSON)
+			}
+		}
+		if fset.flagPrune && !fset.flagAppend {
+			return fmt.Errorf("`--prune` must be used together with `--append`")
+		}
+		if fset.flagMovedFromState != "" {
+			if _, err := os.Stat(fset.flagMovedFromState); err != nil {
+				return fmt.Errorf("invalid `--moved-from-state`: %v", err)
+			}
+			if fset.flagDryRun {
+				return fmt.Errorf("`--moved-from-state` conflicts with `--dry-run`")
+			}
+		}
+		if fset.flagResume && fset.flagOverwrite {
+			return fmt.Errorf("`--resume` conflicts with `--overwrite`")
+		}
+		if fset.flagDryRun {
+			switch {
+			case fset.flagGenerateMappingFile:
+				return fmt.Errorf("`--dry-run` conflicts with `--generate-mapping-file`")
+			case fset.flagResume:
+				return fmt.Errorf("`--dry-run` conflicts with `--resume`")
+			case fset.flagOverwrite:
+				return fmt.Errorf("`--dry-run` conflicts with `--overwrite`")
+			case fset.flagAppend:
+				return fmt.Errorf("`--dry-run` conflicts with `--append`")
+			case fset.flagVerify:
+				return fmt.Errorf("`--dry-run` conflicts with `--verify`")
+			case fset.flagCostEstimate:
+				return fmt.Errorf("`--dry-run` conflicts with `--cost-estimate`")
+			case fset.flagHoldBackendLock:
+				return fmt.Errorf("`--dry-run` conflicts with `--hold-backend-lock`")
+			}
+		}
+		if fset.flagDryRunOutput != "" && !fset.flagDryRun {
+			return fmt.Errorf("`--dry-run-output` must be used together with `--dry-run`")
+		}
+		if fset.flagChunkSize < 0 {
+			return fmt.Errorf("`--chunk-size` must be a positive number")
+		}
+		if fset.flagMaxResources < 0 {
+			return fmt.Errorf("`--max-resources` must be a positive number")
+		}
+		if fset.flagMaxDuration < 0 {
+			return fmt.Errorf("`--max-duration` must not be negative")
+		}
+		if fset.flagLimit < 0 {
+			return fmt.Errorf("`--limit` must be a positive number")
+		}
+		if fset.flagSample < 0 {
+			return fmt.Errorf("`--sample` must be a positive number")
+		}
+		if fset.flagLimit != 0 && fset.flagSample != 0 {
+			return fmt.Errorf("`--limit` conflicts with `--sample`")
+		}
+		if fset.flagRecord != "" && fset.flagReplay != "" {
+			return fmt.Errorf("`--record` conflicts with `--replay`")
+		}
+		if fset.flagRetryMax < 0 {
+			return fmt.Errorf("`--retry-max` must be a positive number")
+		}
+		if fset.flagRetryBaseDelay < 0 {
+			return fmt.Errorf("`--retry-base-delay` must be a positive duration")
+		}
+		if fset.flagImportTimeout < 0 {
+			return fmt.Errorf("`--import-timeout` must be a positive duration")
+		}
+		// The replay never retries, as the retried interactions are not recorded.
+		if fset.flagReplay != "" && (fset.flagRetryMax != 0 || fset.flagRetryBaseDelay != 0) {
+			return fmt.Errorf("`--retry-max` and `--retry-base-delay` conflict with `--replay`")
+		}
+		if fset.flagNoCache && fset.flagCacheDir != "" {
+			return fmt.Errorf("`--cache-dir` conflicts with `--no-cache`")
+		}
+		if (fset.flagRecord != "" || fset.flagReplay != "") && fset.flagCacheDir != "" {
+			return fmt.Errorf("`--cache-dir` conflicts with `--record` and `--replay`")
+		}
+		if fset.flagTelemetrySink != "" {
+			if err := validateOneOf("--telemetry-sink", fset.flagTelemetrySink, telemetry.Sinks); err != nil {
+				return err
+			}
+		}
+		if fset.flagTelemetry != "" {
+			if err := validateOneOf("--telemetry", fset.flagTelemetry, telemetry.Details); err != nil {
+				return err
+			}
+		}
+		if fset.flagProfileDir != "" {
+			if fset.hflagProfile != "" {
+				return fmt.Errorf("`--profile-dir` conflicts with `--profile`")
+			}
+			if fi, err := os.Stat(fset.flagProfileDir); err == nil && !fi.IsDir() {
+				return fmt.Errorf("`--profile-dir` must be a directory")
+			}
+		}
+		switch fset.flagTelemetrySink {
+		case telemetry.SinkFile:
+			if fset.flagTelemetrySinkTarget == "" {
+				return fmt.Errorf("`--telemetry-sink=%s` must be used together with `--telemetry-sink-target`", telemetry.SinkFile)
+			}
+		case telemetry.SinkOTLP:
+		default:
+			if fset.flagTelemetrySinkTarget != "" {
+				return fmt.Errorf("`--telemetry-sink-target` can only be used when `--telemetry-sink` is %q or %q", telemetry.SinkOTLP, telemetry.SinkFile)
 			}
 		}
 		if fset.flagHCLOnly {
@@ -34,20 +197,349 @@ func commandBeforeFunc(fset *FlagSet) func(ctx *cli.Context) error {
 			if fset.flagModulePath != "" {
 				return fmt.Errorf("`--module-path` conflicts with `--hcl-only`")
 			}
+			if fset.flagVerify {
+				return fmt.Errorf("`--verify` conflicts with `--hcl-only`")
+			}
+		}
+		if fset.flagUseImportBlocks {
+			if fset.flagHCLOnly {
+				return fmt.Errorf("`--use-import-blocks` conflicts with `--hcl-only`")
+			}
+			if fset.flagModulePath != "" {
+				return fmt.Errorf("`--use-import-blocks` conflicts with `--module-path`")
+			}
 		}
 		if fset.flagModulePath != "" {
 			if !fset.flagAppend {
 				return fmt.Errorf("`--module-path` must be used together with `--append`")
 			}
 		}
+		if fset.flagProviderName != "" {
+			if err := validateOneOf("--provider", fset.flagProviderName, meta.Providers); err != nil {
+				return err
+			}
+		}
+		if fset.flagEnv != "" {
+			if _, err := normalizeCloudEnv(fset.flagEnv); err != nil {
+				return fmt.Errorf("`--env`: %v", err)
+			}
+		}
+		if strings.EqualFold(fset.flagEnv, cloudEnvCustom) {
+			if fset.flagResourceManagerEndpoint == "" {
+				return fmt.Errorf("`--env=%s` must be used together with `--resource-manager-endpoint`", cloudEnvCustom)
+			}
+			if fset.flagProviderName != "" && fset.flagProviderName != meta.ProviderAzureRM {
+				return fmt.Errorf("`--env=%s` can only be used when `--provider` is %q", cloudEnvCustom, meta.ProviderAzureRM)
+			}
+		} else if fset.flagResourceManagerEndpoint != "" {
+			return fmt.Errorf("`--resource-manager-endpoint` must be used together with `--env=%s`", cloudEnvCustom)
+		}
+		if fset.flagResourceManagerEndpoint != "" {
+			if u, err := url.Parse(fset.flagResourceManagerEndpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+				return fmt.Errorf("`--resource-manager-endpoint` must be a HTTPS URL, e.g. https://management.local.azurestack.external")
+			}
+		}
+		if fset.flagAzAPIFallback {
+			if fset.flagProviderName != "" && fset.flagProviderName != meta.ProviderAzureRM {
+				return fmt.Errorf("`--azapi-fallback` can only be used when `--provider` is %q", meta.ProviderAzureRM)
+			}
+		}
+		if fset.flagSubresourceStrategy != "" {
+			if err := validateOneOf("--subresource-strategy", fset.flagSubresourceStrategy, meta.SubresourceStrategies); err != nil {
+				return err
+			}
+		}
+		if fset.flagProviderMajorVersion != "" {
+			if err := validateOneOf("--provider-major-version", fset.flagProviderMajorVersion, meta.ProviderMajorVersions); err != nil {
+				return err
+			}
+			if fset.flagProviderName != "" && fset.flagProviderName != meta.ProviderAzureRM {
+				return fmt.Errorf("`--provider-major-version` can only be used when `--provider` is %q", meta.ProviderAzureRM)
+			}
+		}
+		if fset.flagProviderRegistry != "" {
+			if strings.Contains(fset.flagProviderRegistry, "/") {
+				return fmt.Errorf("`--provider-registry` must be a hostname, e.g. registry.example.com")
+			}
+		}
+		if fset.flagProviderSource != "" {
+			if err := meta.ValidateProviderSource(fset.flagProviderSource); err != nil {
+				return fmt.Errorf("`--provider-source`: %v", err)
+			}
+		}
+		if fset.flagProviderMirror != "" {
+			if strings.HasPrefix(fset.flagProviderMirror, "http://") {
+				return fmt.Errorf("`--provider-mirror` must be either a HTTPS URL or a local directory")
+			}
+		}
+		if err := meta.ValidateResourceNamePattern(fset.flagPattern); err != nil {
+			return fmt.Errorf("`--name-pattern`: %v", err)
+		}
+		if err := meta.ValidateNameFrom(fset.flagNameFrom); err != nil {
+			return fmt.Errorf("`--name-from`: %v", err)
+		}
+		if err := meta.ValidateTypeFilter(fset.flagOnlyTypes.Value()); err != nil {
+			return fmt.Errorf("`--only-types`: %v", err)
+		}
+		if err := meta.ValidateTypeFilter(fset.flagSkipTypes.Value()); err != nil {
+			return fmt.Errorf("`--skip-types`: %v", err)
+		}
+		if len(fset.flagOnlyTypes.Value()) != 0 && len(fset.flagSkipTypes.Value()) != 0 {
+			return fmt.Errorf("`--only-types` conflicts with `--skip-types`")
+		}
+		if err := resourceset.ValidateResolvers(fset.flagResolvers.Value()); err != nil {
+			return fmt.Errorf("`--resolvers`: %v", err)
+		}
+		for _, hook := range fset.flagHooks.Value() {
+			phase, command, ok := strings.Cut(hook, "=")
+			if !ok || strings.TrimSpace(command) == "" {
+				return fmt.Errorf("`--hook` must be in form of \"phase=command\", got %q", hook)
+			}
+			if err := validateOneOf("--hook", phase, meta.HookPhases); err != nil {
+				return err
+			}
+		}
+		if fset.flagResolverPlugin != "" {
+			if _, err := os.Stat(fset.flagResolverPlugin); err != nil {
+				return fmt.Errorf("`--resolver-plugin`: %v", err)
+			}
+			if resolvers := fset.flagResolvers.Value(); len(resolvers) != 0 {
+				var found bool
+				for _, r := range resolvers {
+					if r == resourceset.ResolverPlugin {
+						found = true
+						break
+					}
+				}
+				if !found {
+					return fmt.Errorf("`--resolver-plugin` requires the %q resolver in `--resolvers`", resourceset.ResolverPlugin)
+				}
+			}
+		}
 		if fset.flagDevProvider {
 			if fset.flagProviderVersion != "" {
 				return fmt.Errorf("`--dev-provider` conflicts with `--provider-version`")
 			}
 		}
-		if fset.hflagTFClientPluginPath != "" {
-			if !fset.flagHCLOnly {
-				return fmt.Errorf("`--tfclient-plugin-path` must be used together with `--hcl-only`")
+		if fset.hflagTFClientProviderVersion != "" {
+			if fset.hflagTFClientPluginPath != "" {
+				return fmt.Errorf("`--tfclient-provider-version` conflicts with `--tfclient-plugin-path`")
+			}
+		}
+		if fset.flagPulumiConvert != "" {
+			if err := validateOneOf("--pulumi-convert", fset.flagPulumiConvert, pulumi.SupportedLanguages); err != nil {
+				return err
+			}
+		}
+		if fset.flagStackConfig != "" {
+			if err := validateOneOf("--stack-config", fset.flagStackConfig, meta.StackConfigTypes); err != nil {
+				return err
+			}
+		}
+		if fset.flagGraphOutput != "" {
+			if err := validateOneOf("--graph-output", fset.flagGraphOutput, meta.GraphOutputs); err != nil {
+				return err
+			}
+		}
+		for _, tag := range fset.flagInjectTags.Value() {
+			if k, _, ok := strings.Cut(tag, "="); !ok || k == "" {
+				return fmt.Errorf("`--inject-tag` must be in form of \"key=value\", got %q", tag)
+			}
+		}
+		if err := meta.ValidateARGFilter(fset.argFilter()); err != nil {
+			return fmt.Errorf("invalid query filter: %v", err)
+		}
+		for _, tag := range fset.flagIncludeTags.Value() {
+			if k, _, ok := strings.Cut(tag, "="); !ok || k == "" {
+				return fmt.Errorf("`--include-tag` must be in form of \"key=value\", got %q", tag)
+			}
+		}
+		for _, tag := range fset.flagExcludeTags.Value() {
+			if k, _, ok := strings.Cut(tag, "="); !ok || k == "" {
+				return fmt.Errorf("`--exclude-tag` must be in form of \"key=value\", got %q", tag)
+			}
+		}
+		if fset.flagApplyInjectedTags && len(fset.flagInjectTags.Value()) == 0 {
+			return fmt.Errorf("`--apply-injected-tags` must be used together with `--inject-tag`")
+		}
+		for _, id := range fset.flagKeyVaultRefs.Value() {
+			if azureId, err := armid.ParseResourceId(id); err != nil || !strings.EqualFold(azureId.TypeString(), "Microsoft.KeyVault/vaults") {
+				return fmt.Errorf("`--key-vault-ref` must be Key Vault resource ids, got %q", id)
+			}
+		}
+		if len(fset.flagKeyVaultRefs.Value()) != 0 {
+			if fset.flagProviderName != "" && fset.flagProviderName != meta.ProviderAzureRM {
+				return fmt.Errorf("`--key-vault-ref` can only be used when `--provider` is %q", meta.ProviderAzureRM)
+			}
+		}
+		if fset.flagIncludeKeyVaultItems {
+			if fset.flagProviderName != "" && fset.flagProviderName != meta.ProviderAzureRM {
+				return fmt.Errorf("`--include-keyvault-items` can only be used when `--provider` is %q", meta.ProviderAzureRM)
+			}
+		}
+		if len(fset.flagProviderFeatures.Value()) != 0 {
+			if fset.flagProviderName != "" && fset.flagProviderName != meta.ProviderAzureRM {
+				return fmt.Errorf("`--provider-feature` can only be used when `--provider` is %q", meta.ProviderAzureRM)
+			}
+			if _, err := parseProviderFeatures(fset.flagProviderFeatures.Value()); err != nil {
+				return fmt.Errorf("invalid `--provider-feature`: %v", err)
+			}
+		}
+		if fset.flagIncludeStorageItems {
+			if fset.flagProviderName != "" && fset.flagProviderName != meta.ProviderAzureRM {
+				return fmt.Errorf("`--include-storage-items` can only be used when `--provider` is %q", meta.ProviderAzureRM)
+			}
+		}
+		if fset.flagGenerateDataSources {
+			if fset.flagProviderName != "" && fset.flagProviderName != meta.ProviderAzureRM {
+				return fmt.Errorf("`--generate-data-sources` can only be used when `--provider` is %q", meta.ProviderAzureRM)
+			}
+		}
+		if fset.flagCredentialsFile != "" {
+			if fset.flagProviderName != "" && fset.flagProviderName != meta.ProviderAzureRM {
+				return fmt.Errorf("`--credentials-file` can only be used when `--provider` is %q", meta.ProviderAzureRM)
+			}
+			if fset.flagModulePath != "" {
+				return fmt.Errorf("`--credentials-file` conflicts with `--module-path`")
+			}
+		}
+		if fset.flagRedactSecrets && fset.flagOnSecret != "" && fset.flagOnSecret != meta.OnSecretRedact {
+			return fmt.Errorf("`--redact-secrets` conflicts with `--on-secret=%s`", fset.flagOnSecret)
+		}
+		if fset.flagSecretAllowlistFile != "" && fset.flagOnSecret == "" && !fset.flagRedactSecrets {
+			return fmt.Errorf("`--secret-allowlist-file` must be used together with `--on-secret` or `--redact-secrets`")
+		}
+		if fset.flagVariableAttrsFile != "" && !fset.flagExtractVariables {
+			return fmt.Errorf("`--variable-attributes-file` must be used together with `--extract-variables`")
+		}
+		if fset.flagExtractVariables && len(fset.flagEnvSplit.Value()) != 0 {
+			return fmt.Errorf("`--extract-variables` can't be used with `--env-split`")
+		}
+		if fset.flagOnSecret != "" {
+			if err := validateOneOf("--on-secret", fset.flagOnSecret, meta.OnSecretActions); err != nil {
+				return err
+			}
+		}
+		if fset.flagOutputFormat != "" {
+			if err := validateOneOf("--output-format", fset.flagOutputFormat, internalconfig.OutputFormats); err != nil {
+				return err
+			}
+		}
+		if fset.flagOnLocked != "" {
+			if err := validateOneOf("--on-locked", fset.flagOnLocked, meta.OnLockedPolicies); err != nil {
+				return err
+			}
+		}
+		if fset.flagOnSoftDeleted != "" {
+			if err := validateOneOf("--on-soft-deleted", fset.flagOnSoftDeleted, meta.OnSoftDeletedPolicies); err != nil {
+				return err
+			}
+		}
+		if fset.flagProvenanceSign != "" {
+			if !fset.flagProvenance {
+				return fmt.Errorf("`--provenance-sign` must be used together with `--provenance`")
+			}
+			if err := validateOneOf("--provenance-sign", fset.flagProvenanceSign, provenance.Signers); err != nil {
+				return err
+			}
+		}
+		if fset.flagProvenanceSignKey != "" && fset.flagProvenanceSign == "" {
+			return fmt.Errorf("`--provenance-sign-key` must be used together with `--provenance-sign`")
+		}
+		if err := meta.ValidateEnvSplit(fset.flagEnvSplit.Value()); err != nil {
+			return fmt.Errorf("`--env-split`: %v", err)
+		}
+		if fset.flagSortResources != "" {
+			if err := validateOneOf("--sort-resources", fset.flagSortResources, meta.SortResourcesOptions); err != nil {
+				return err
+			}
+		}
+		if fset.flagSplitBy != "" {
+			if err := validateOneOf("--split-by", fset.flagSplitBy, meta.SplitByOptions); err != nil {
+				return err
+			}
+			switch {
+			case fset.flagModulePath != "":
+				return fmt.Errorf("`--split-by` conflicts with `--module-path`")
+			case len(fset.flagEnvSplit.Value()) != 0:
+				return fmt.Errorf("`--split-by` conflicts with `--env-split`")
+			case fset.flagGenerateDataSources:
+				return fmt.Errorf("`--split-by` conflicts with `--generate-data-sources`")
+			case len(fset.flagKeyVaultRefs.Value()) != 0:
+				return fmt.Errorf("`--split-by` conflicts with `--key-vault-ref`")
+			case fset.flagOnSecret == meta.OnSecretVar:
+				return fmt.Errorf("`--split-by` conflicts with `--on-secret=%s`", meta.OnSecretVar)
+			}
+		}
+		if fset.flagSplitFiles != "" {
+			if err := validateOneOf("--split-files", fset.flagSplitFiles, meta.SplitFilesOptions); err != nil {
+				return err
+			}
+			switch {
+			case fset.flagSplitBy != "":
+				return fmt.Errorf("`--split-files` conflicts with `--split-by`")
+			case fset.flagModuleTemplate != "":
+				return fmt.Errorf("`--split-files` conflicts with `--module-template`")
+			case len(fset.flagEnvSplit.Value()) != 0:
+				return fmt.Errorf("`--split-files` conflicts with `--env-split`")
+			}
+		}
+		if fset.flagOutputSyntax != "" {
+			if err := validateOneOf("--output-syntax", fset.flagOutputSyntax, meta.OutputSyntaxes); err != nil {
+				return err
+			}
+			if fset.flagOutputSyntax != meta.OutputSyntaxHCL {
+				switch {
+				case fset.flagSplitBy != "":
+					return fmt.Errorf("`--output-syntax=%s` conflicts with `--split-by`", fset.flagOutputSyntax)
+				case fset.flagModuleTemplate != "":
+					return fmt.Errorf("`--output-syntax=%s` conflicts with `--module-template`", fset.flagOutputSyntax)
+				case len(fset.flagEnvSplit.Value()) != 0:
+					return fmt.Errorf("`--output-syntax=%s` conflicts with `--env-split`", fset.flagOutputSyntax)
+				}
+			}
+		}
+		if fset.flagModuleTemplate != "" {
+			switch {
+			case fset.flagModulePath != "":
+				return fmt.Errorf("`--module-template` conflicts with `--module-path`")
+			case fset.flagSplitBy != "":
+				return fmt.Errorf("`--module-template` conflicts with `--split-by`")
+			case len(fset.flagEnvSplit.Value()) != 0:
+				return fmt.Errorf("`--module-template` conflicts with `--env-split`")
+			case fset.flagGenerateDataSources:
+				return fmt.Errorf("`--module-template` conflicts with `--generate-data-sources`")
+			case len(fset.flagKeyVaultRefs.Value()) != 0:
+				return fmt.Errorf("`--module-template` conflicts with `--key-vault-ref`")
+			case fset.flagExtractVariables:
+				return fmt.Errorf("`--module-template` conflicts with `--extract-variables`")
+			case fset.flagOnSecret == meta.OnSecretVar:
+				return fmt.Errorf("`--module-template` conflicts with `--on-secret=%s`", meta.OnSecretVar)
+			case fset.flagCredentialsFile != "":
+				return fmt.Errorf("`--module-template` conflicts with `--credentials-file`")
+			}
+		} else if fset.flagCreateMissingModules {
+			return fmt.Errorf("`--create-missing-modules` must be used together with `--module-template`")
+		}
+		if fset.flagGenerateOutputs {
+			switch {
+			case fset.flagSplitBy != "":
+				return fmt.Errorf("`--generate-outputs` conflicts with `--split-by`")
+			case fset.flagModuleTemplate != "":
+				return fmt.Errorf("`--generate-outputs` conflicts with `--module-template`")
+			case len(fset.flagEnvSplit.Value()) != 0:
+				return fmt.Errorf("`--generate-outputs` conflicts with `--env-split`")
+			}
+		} else if fset.flagOutputRulesFile != "" {
+			return fmt.Errorf("`--output-rules` must be used together with `--generate-outputs`")
+		}
+		if fset.flagBackstageCatalog {
+			if fset.flagBackstageOwner == "" {
+				return fmt.Errorf("`--backstage-owner` must be specified when `--backstage-catalog` is set")
+			}
+		} else {
+			if fset.flagBackstageOwner != "" || fset.flagBackstageSystem != "" {
+				return fmt.Errorf("`--backstage-owner` and `--backstage-system` must be used together with `--backstage-catalog`")
 			}
 		}
 		if flagLogLevel != "" {
@@ -61,13 +553,29 @@ func commandBeforeFunc(fset *FlagSet) func(ctx *cli.Context) error {
 			fset.flagUseManagedIdentityCred,
 			fset.flagUseAzureCLICred,
 			fset.flagUseOIDCCred,
+			fset.flagUseWorkloadIdentityCred,
 		} {
 			if ok {
 				occur += 1
 			}
 		}
 		if occur > 1 {
-			return fmt.Errorf("only one of `--use-environment-cred`, `--use-managed-identity-cred`, `--use-azure-cli-cred` and `--use-oidc-cred` can be specified")
+			return fmt.Errorf("only one of `--use-environment-cred`, `--use-managed-identity-cred`, `--use-azure-cli-cred`, `--use-oidc-cred` and `--use-workload-identity-cred` can be specified")
+		}
+		if chain := fset.flagCredChain.Value(); len(chain) != 0 {
+			if occur != 0 {
+				return fmt.Errorf("`--cred-chain` conflicts with the `--use-*-cred` flags")
+			}
+			seen := map[string]bool{}
+			for _, name := range chain {
+				if err := validateOneOf("--cred-chain", name, credChainNames); err != nil {
+					return err
+				}
+				if seen[name] {
+					return fmt.Errorf("`--cred-chain` has duplicate credential %q", name)
+				}
+				seen[name] = true
+			}
 		}
 
 		// Initialize output directory
@@ -76,48 +584,58 @@ func commandBeforeFunc(fset *FlagSet) func(ctx *cli.Context) error {
 				return fmt.Errorf("creating output directory %q: %v", fset.flagOutputDir, err)
 			}
 		}
-		empty, err := utils.DirIsEmpty(fset.flagOutputDir)
+		// The dry run writes nothing to the output directory, which needs no lock.
+		if !fset.flagDryRun {
+			unlock, err := meta.LockWorkspace(fset.flagOutputDir, fset.flagForceUnlock)
+			if err != nil {
+				return err
+			}
+			unlockWorkspace = unlock
+		}
+		empty, err := utils.DirIsEmpty(fset.flagOutputDir, meta.WorkspaceLockFileName, meta.IgnoreFileName)
 		if err != nil {
 			return fmt.Errorf("failed to check emptiness of output directory %q: %v", fset.flagOutputDir, err)
 		}
 
 		var tfblock *utils.TerraformBlockDetail
-		if !empty {
+		// The dry run writes nothing to the output directory, which needn't be empty.
+		if !empty && !fset.flagDryRun {
 			switch {
 			case fset.flagOverwrite:
-				if err := utils.RemoveEverythingUnder(fset.flagOutputDir, meta.ResourceMappingFileName); err != nil {
+				if err := utils.RemoveEverythingUnder(fset.flagOutputDir, meta.ResourceMappingFileName, meta.WorkspaceLockFileName, meta.IgnoreFileName); err != nil {
 					return fmt.Errorf("failed to clean up output directory %q: %v", fset.flagOutputDir, err)
 				}
-			case fset.flagAppend:
+			// Resuming a run continues to populate the output directory of the run.
+			case fset.flagAppend, fset.flagResume:
 				tfblock, err = utils.InspecTerraformBlock(fset.flagOutputDir)
 				if err != nil {
 					return fmt.Errorf("determine the backend type from the existing files: %v", err)
 				}
 			default:
-				if fset.flagNonInteractive {
-					return fmt.Errorf("the output directory %q is not empty", fset.flagOutputDir)
+				if fset.flagNonInteractive || fset.flagOnNonEmptyDir == OnNonEmptyDirFail {
+					return i18n.Errorf("the output directory %q is not empty", fset.flagOutputDir)
 				}
 
 				// Interactive mode
-				fmt.Printf(`
+				fmt.Print(i18n.T(`
 The output directory is not empty. Please choose one of actions below:
 
 * Press "Y" to overwrite the existing directory with new files
 * Press "N" to append new files and add to the existing state instead
 * Press other keys to quit
 
-> `)
+> `))
 				var ans string
 				// #nosec G104
 				fmt.Scanf("%s", &ans)
 				switch strings.ToLower(ans) {
 				case "y":
-					if err := utils.RemoveEverythingUnder(fset.flagOutputDir, meta.ResourceMappingFileName); err != nil {
+					if err := utils.RemoveEverythingUnder(fset.flagOutputDir, meta.ResourceMappingFileName, meta.WorkspaceLockFileName, meta.IgnoreFileName); err != nil {
 						return err
 					}
 				case "n":
 					if fset.flagHCLOnly {
-						return fmt.Errorf("`--hcl-only` can only run within an empty directory. Use `-o` to specify an empty directory.")
+						return i18n.Errorf("`--hcl-only` can only run within an empty directory. Use `-o` to specify an empty directory.")
 					}
 					fset.flagAppend = true
 					tfblock, err = utils.InspecTerraformBlock(fset.flagOutputDir)
@@ -125,7 +643,7 @@ The output directory is not empty. Please choose one of actions below:
 						return fmt.Errorf("determine the backend type from the existing files: %v", err)
 					}
 				default:
-					return fmt.Errorf("the output directory %q is not empty", fset.flagOutputDir)
+					return i18n.Errorf("the output directory %q is not empty", fset.flagOutputDir)
 				}
 			}
 		}
@@ -160,11 +678,75 @@ The output directory is not empty. Please choose one of actions below:
 				return fmt.Errorf("`--backend-config` only works for non-local backend")
 			}
 		}
+		if fset.flagBackendType == meta.BackendTypeCloud {
+			if len(fset.flagBackendConfig.Value()) != 0 {
+				return fmt.Errorf("`--backend-config` doesn't work for the cloud backend, use `--cloud-organization` and `--cloud-workspace` instead")
+			}
+			if existingBackendType == "" && (fset.flagCloudOrganization == "" || fset.flagCloudWorkspace == "") {
+				return fmt.Errorf("`--backend-type=cloud` must be used together with `--cloud-organization` and `--cloud-workspace`")
+			}
+			if len(fset.flagEnvSplit.Value()) != 0 {
+				return fmt.Errorf("`--backend-type=cloud` conflicts with `--env-split`")
+			}
+		} else if fset.flagCloudOrganization != "" || fset.flagCloudWorkspace != "" {
+			return fmt.Errorf("`--cloud-organization` and `--cloud-workspace` only work for the cloud backend")
+		}
+		if existingBackendType != "" && (fset.flagCloudOrganization != "" || fset.flagCloudWorkspace != "") {
+			return fmt.Errorf("`--cloud-organization` and `--cloud-workspace` should not be specified when appending to a workspace that has terraform block already defined")
+		}
+		if fset.flagWorkspace != "" {
+			if url.PathEscape(fset.flagWorkspace) != fset.flagWorkspace {
+				return fmt.Errorf("`--workspace` must be a valid URL path component")
+			}
+			if fset.flagBackendType == meta.BackendTypeCloud {
+				return fmt.Errorf("`--workspace` doesn't work for the cloud backend, use `--cloud-workspace` instead")
+			}
+			if !meta.BackendSupportsWorkspaces(fset.flagBackendType) {
+				return fmt.Errorf("`--workspace` doesn't work for the %s backend, which doesn't support workspaces", fset.flagBackendType)
+			}
+			if fset.flagHCLOnly {
+				return fmt.Errorf("`--workspace` conflicts with `--hcl-only`")
+			}
+		}
 		if fset.flagBackendType != "local" {
 			if fset.flagHCLOnly {
 				return fmt.Errorf("`--hcl-only` only works for local backend")
 			}
 		}
+		if fset.flagBootstrapBackend {
+			if fset.flagBackendType != "azurerm" {
+				return fmt.Errorf("`--bootstrap-backend` only works for the azurerm backend")
+			}
+			if len(fset.flagBackendConfig.Value()) == 0 {
+				return fmt.Errorf("`--bootstrap-backend` must be used together with `--backend-config`")
+			}
+		} else {
+			if fset.flagBootstrapBackendLocation != "" {
+				return fmt.Errorf("`--bootstrap-backend-location` must be used together with `--bootstrap-backend`")
+			}
+			if fset.flagBootstrapBackendExport {
+				return fmt.Errorf("`--bootstrap-backend-export` must be used together with `--bootstrap-backend`")
+			}
+		}
+		if fset.flagLocalThenMigrate {
+			if existingBackendType != "" {
+				return fmt.Errorf("`--local-then-migrate` should not be specified when appending to a workspace that has terraform block already defined")
+			}
+			if fset.flagBackendType == "local" {
+				return fmt.Errorf("`--local-then-migrate` only works for non-local backend")
+			}
+		}
+		if fset.flagHoldBackendLock {
+			if fset.flagBackendType != "azurerm" {
+				return fmt.Errorf("`--hold-backend-lock` only works for the azurerm backend")
+			}
+			if fset.flagLocalThenMigrate {
+				return fmt.Errorf("`--hold-backend-lock` conflicts with `--local-then-migrate`")
+			}
+			if fset.flagHCLOnly {
+				return fmt.Errorf("`--hold-backend-lock` conflicts with `--hcl-only`")
+			}
+		}
 
 		// Determine any existing provider version constraint if not using a dev provider and the provider version not specified.
 		if !fset.flagDevProvider && fset.flagProviderVersion == "" {
@@ -182,6 +764,10 @@ The output directory is not empty. Please choose one of actions below:
 		// - Env variable: AZTFEXPORT_SUBSCRIPTION_ID
 		// - Env variable: ARM_SUBSCRIPTION_ID
 		// - Output of azure cli, the current active subscription
+		// The first one is used if multiple subscription ids are specified.
+		if ids := fset.flagSubscriptionIds.Value(); len(ids) != 0 {
+			fset.flagSubscriptionId = ids[0]
+		}
 		if fset.flagSubscriptionId == "" {
 			var err error
 			fset.flagSubscriptionId, err = subscriptionIdFromCLI()
@@ -192,3 +778,13 @@ The output directory is not empty. Please choose one of actions below:
 		return nil
 	}
 }
+
+// validateOneOf validates the flag value is one of the supported values.
+func validateOneOf(flag, value string, supported []string) error {
+	for _, v := range supported {
+		if value == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("`%s` only supports one of: %s", flag, strings.Join(supported, ", "))
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli/v2"
@@ -45,6 +46,21 @@ func TestCommondBeforeFunc(t *testing.T) {
 				flagSubscriptionId: "123",
 			},
 		},
+		{
+			name: "--prune should be used together with --append",
+			fset: FlagSet{
+				flagPrune: true,
+			},
+			err: "`--prune` must be used together with `--append`",
+		},
+		{
+			name: "--prune with --append works",
+			fset: FlagSet{
+				flagPrune:          true,
+				flagAppend:         true,
+				flagSubscriptionId: "123",
+			},
+		},
 		{
 			name: "only a --overwrite works",
 			fset: FlagSet{
@@ -66,11 +82,72 @@ func TestCommondBeforeFunc(t *testing.T) {
 			},
 		},
 		{
-			name: "--generate-mapping-file shouldn't be used in interactive mode since interactive mode has a special code to do it",
+			name: "--show-commands should be used together with --non-interactive",
+			fset: FlagSet{
+				flagShowCommands: true,
+			},
+			err: "`--show-commands` must be used together with `--non-interactive`",
+		},
+		{
+			name: "--resume should be used together with --non-interactive",
+			fset: FlagSet{
+				flagResume: true,
+			},
+			err: "`--resume` must be used together with `--non-interactive`",
+		},
+		{
+			name: "--resume conflicts with --overwrite",
+			fset: FlagSet{
+				flagResume:         true,
+				flagNonInteractive: true,
+				flagOverwrite:      true,
+			},
+			err: "`--resume` conflicts with `--overwrite`",
+		},
+		{
+			name: "--resume works in a non-empty directory",
+			fset: FlagSet{
+				flagResume:         true,
+				flagNonInteractive: true,
+			},
+			dirGen: dirGenWithTFBlock("foo {}"),
+		},
+		{
+			name: "--output-format=json should be used together with --non-interactive",
+			fset: FlagSet{
+				flagOutputFormat: "json",
+			},
+			err: "`--output-format=json` must be used together with `--non-interactive`",
+		},
+		{
+			name: "--output-format with unsupported value",
+			fset: FlagSet{
+				flagOutputFormat:   "yaml",
+				flagNonInteractive: true,
+			},
+			err: "`--output-format` only supports one of: text, json",
+		},
+		{
+			name: "--verify should be used together with --non-interactive",
+			fset: FlagSet{
+				flagVerify: true,
+			},
+			err: "`--verify` must be used together with `--non-interactive`",
+		},
+		{
+			name: "--verify conflicts with --hcl-only",
+			fset: FlagSet{
+				flagVerify:         true,
+				flagNonInteractive: true,
+				flagHCLOnly:        true,
+			},
+			err: "`--verify` conflicts with `--hcl-only`",
+		},
+		{
+			name: "--generate-mapping-file in interactive mode works",
 			fset: FlagSet{
 				flagGenerateMappingFile: true,
 			},
-			err: "`--generate-mapping-file` must be used together with `--non-interactive`",
 		},
 		{
 			name: "--generate-mapping-file with --non-interactive works",
@@ -79,6 +156,39 @@ func TestCommondBeforeFunc(t *testing.T) {
 				flagNonInteractive:      true,
 			},
 		},
+		{
+			name: "--dry-run shouldn't be used in interactive mode",
+			fset: FlagSet{
+				flagDryRun: true,
+			},
+			err: "`--dry-run` must be used together with `--non-interactive`",
+		},
+		{
+			name: "--dry-run with --non-interactive works in a non-empty directory",
+			fset: FlagSet{
+				flagDryRun:         true,
+				flagDryRunOutput:   "mapping.json",
+				flagNonInteractive: true,
+			},
+			dirGen: dirGenWithTFBlock(`terraform {}`),
+		},
+		{
+			name: "--dry-run with --resume",
+			fset: FlagSet{
+				flagDryRun:         true,
+				flagResume:         true,
+				flagNonInteractive: true,
+			},
+			err: "`--dry-run` conflicts with `--resume`",
+		},
+		{
+			name: "--dry-run-output without --dry-run",
+			fset: FlagSet{
+				flagDryRunOutput:   "mapping.json",
+				flagNonInteractive: true,
+			},
+			err: "`--dry-run-output` must be used together with `--dry-run`",
+		},
 		{
 			name: "--hcl-only shouldn't be used with --append since it doesn't make sense to generate config/state to an existing workspace for hcl only",
 			fset: FlagSet{
@@ -101,6 +211,29 @@ func TestCommondBeforeFunc(t *testing.T) {
 			},
 			err: "`--module-path` conflicts with `--hcl-only`",
 		},
+		{
+			name: "--use-import-blocks conflicts with --hcl-only",
+			fset: FlagSet{
+				flagUseImportBlocks: true,
+				flagHCLOnly:         true,
+			},
+			err: "`--use-import-blocks` conflicts with `--hcl-only`",
+		},
+		{
+			name: "--use-import-blocks conflicts with --module-path since import blocks are only allowed in the root module",
+			fset: FlagSet{
+				flagUseImportBlocks: true,
+				flagModulePath:      "foo",
+				flagAppend:          true,
+			},
+			err: "`--use-import-blocks` conflicts with `--module-path`",
+		},
+		{
+			name: "--use-import-blocks works alone",
+			fset: FlagSet{
+				flagUseImportBlocks: true,
+			},
+		},
 		{
 			name: "--module-path should be used together with --append",
 			fset: FlagSet{
@@ -130,6 +263,53 @@ func TestCommondBeforeFunc(t *testing.T) {
 			},
 			dirGen: dirGenWithTFBlock("foo {}"),
 		},
+		{
+			name: "--hook of unknown phase",
+			fset: FlagSet{
+				flagHooks: *cli.NewStringSlice("pre-list=./check.sh"),
+			},
+			err: "`--hook` only supports one of: pre-import, post-import, pre-generate, post-generate",
+		},
+		{
+			name: "--hook without command",
+			fset: FlagSet{
+				flagHooks: *cli.NewStringSlice("post-generate"),
+			},
+			err: "`--hook` must be in form of \"phase=command\", got \"post-generate\"",
+		},
+		{
+			name: "invalid --on-nonempty-dir",
+			fset: FlagSet{
+				flagOnNonEmptyDir: "prompt",
+			},
+			err: "`--on-nonempty-dir` only supports one of: overwrite, append, fail",
+		},
+		{
+			name: "--on-nonempty-dir=overwrite conflicts with --append",
+			fset: FlagSet{
+				flagOnNonEmptyDir: "overwrite",
+				flagAppend:        true,
+			},
+			err: "`--on-nonempty-dir=overwrite` conflicts with `--append`",
+		},
+		{
+			name: "non empty dir with --on-nonempty-dir=append",
+			fset: FlagSet{
+				flagOnNonEmptyDir: "append",
+			},
+			dirGen: dirGenWithTFBlock("foo {}"),
+			postCheck: func(t *testing.T, flagset FlagSet) {
+				require.True(t, flagset.flagAppend)
+			},
+		},
+		{
+			name: "non empty dir with --on-nonempty-dir=fail fails without prompting in interactive mode",
+			fset: FlagSet{
+				flagOnNonEmptyDir: "fail",
+			},
+			dirGen: dirGenWithTFBlock("foo {}"),
+			err:    "is not empty",
+		},
 		{
 			name: "default backend type is local",
 			fset: FlagSet{},
@@ -204,6 +384,133 @@ func TestCommondBeforeFunc(t *testing.T) {
 			dirGen: dirGenWithTFBlock(`terraform {}`),
 			err:    "`--backend-config` should not be specified when appending to a workspace that has terraform block already defined",
 		},
+		{
+			name: "--bootstrap-backend shouldn't be used with non-azurerm backend",
+			fset: FlagSet{
+				flagBackendType:      "gcs",
+				flagBackendConfig:    *cli.NewStringSlice("bucket=foo"),
+				flagBootstrapBackend: true,
+			},
+			err: "`--bootstrap-backend` only works for the azurerm backend",
+		},
+		{
+			name: "--bootstrap-backend without --backend-config",
+			fset: FlagSet{
+				flagBackendType:      "azurerm",
+				flagBootstrapBackend: true,
+			},
+			err: "`--bootstrap-backend` must be used together with `--backend-config`",
+		},
+		{
+			name: "--bootstrap-backend-location without --bootstrap-backend",
+			fset: FlagSet{
+				flagBootstrapBackendLocation: "westus",
+			},
+			err: "`--bootstrap-backend-location` must be used together with `--bootstrap-backend`",
+		},
+		{
+			name: "--local-then-migrate shouldn't be used with local backend",
+			fset: FlagSet{
+				flagLocalThenMigrate: true,
+			},
+			err: "`--local-then-migrate` only works for non-local backend",
+		},
+		{
+			name: "--local-then-migrate shouldn't be used when appending to a workspace with terraform block defined",
+			fset: FlagSet{
+				flagAppend:           true,
+				flagBackendType:      "azurerm",
+				flagLocalThenMigrate: true,
+			},
+			dirGen: dirGenWithTFBlock(`terraform {
+	backend azurerm {}
+}`),
+			err: "`--local-then-migrate` should not be specified when appending to a workspace that has terraform block already defined",
+		},
+		{
+			name: "--backend-type=cloud without --cloud-workspace",
+			fset: FlagSet{
+				flagBackendType:       "cloud",
+				flagCloudOrganization: "contoso",
+			},
+			err: "`--backend-type=cloud` must be used together with `--cloud-organization` and `--cloud-workspace`",
+		},
+		{
+			name: "--cloud-workspace shouldn't be used with non-cloud backend",
+			fset: FlagSet{
+				flagBackendType:    "azurerm",
+				flagCloudWorkspace: "aztfexport",
+			},
+			err: "`--cloud-organization` and `--cloud-workspace` only work for the cloud backend",
+		},
+		{
+			name: "--cloud-workspace shouldn't be used when appending to a workspace with terraform block defined",
+			fset: FlagSet{
+				flagAppend:            true,
+				flagCloudOrganization: "contoso",
+				flagCloudWorkspace:    "aztfexport",
+			},
+			dirGen: dirGenWithTFBlock(`terraform {
+	cloud {}
+}`),
+			err: "`--cloud-organization` and `--cloud-workspace` should not be specified when appending to a workspace that has terraform block already defined",
+		},
+		{
+			name: "--workspace with azurerm backend",
+			fset: FlagSet{
+				flagBackendType: "azurerm",
+				flagWorkspace:   "prod",
+			},
+		},
+		{
+			name: "--workspace with invalid name",
+			fset: FlagSet{
+				flagWorkspace: "prod/east",
+			},
+			err: "`--workspace` must be a valid URL path component",
+		},
+		{
+			name: "--workspace shouldn't be used with cloud backend",
+			fset: FlagSet{
+				flagBackendType:       "cloud",
+				flagCloudOrganization: "contoso",
+				flagCloudWorkspace:    "aztfexport",
+				flagWorkspace:         "prod",
+			},
+			err: "`--workspace` doesn't work for the cloud backend, use `--cloud-workspace` instead",
+		},
+		{
+			name: "--workspace shouldn't be used with the backend without workspaces",
+			fset: FlagSet{
+				flagBackendType: "http",
+				flagWorkspace:   "prod",
+			},
+			err: "`--workspace` doesn't work for the http backend, which doesn't support workspaces",
+		},
+		{
+			name: "--workspace conflicts with --hcl-only",
+			fset: FlagSet{
+				flagHCLOnly:   true,
+				flagWorkspace: "prod",
+			},
+			err: "`--workspace` conflicts with `--hcl-only`",
+		},
+		{
+			name: "--hold-backend-lock shouldn't be used with local backend",
+			fset: FlagSet{
+				flagHoldBackendLock: true,
+			},
+			err: "`--hold-backend-lock` only works for the azurerm backend",
+		},
+		{
+			name: "--hold-backend-lock conflicts with --local-then-migrate",
+			fset: FlagSet{
+				flagBackendType:      "azurerm",
+				flagLocalThenMigrate: true,
+				flagHoldBackendLock:  true,
+			},
+			err: "`--hold-backend-lock` conflicts with `--local-then-migrate`",
+		},
 		{
 			name: "--hcl-only can't work for remote backend",
 			fset: FlagSet{
@@ -213,18 +520,724 @@ func TestCommondBeforeFunc(t *testing.T) {
 			err: "`--hcl-only` only works for local backend",
 		},
 		{
-			name: "--use-environment-cred works",
+			name: "--pulumi-convert with unsupported language",
 			fset: FlagSet{
-				flagUseEnvironmentCred: true,
+				flagPulumiConvert: "cobol",
 			},
+			err: "`--pulumi-convert` only supports one of: yaml, typescript, python, go, csharp, java",
 		},
 		{
-			name: "--use-environment-cred with --use-azure-cli failed",
+			name: "--pulumi-convert works",
 			fset: FlagSet{
-				flagUseEnvironmentCred: true,
-				flagUseAzureCLICred:    true,
+				flagPulumiConvert: "yaml",
+			},
+		},
+		{
+			name: "--stack-config with unsupported platform",
+			fset: FlagSet{
+				flagStackConfig: "atlantis",
+			},
+			err: "`--stack-config` only supports one of: spacelift, env0",
+		},
+		{
+			name: "--graph-output with unsupported format",
+			fset: FlagSet{
+				flagGraphOutput: "svg",
+			},
+			err: "`--graph-output` only supports one of: dot, mermaid",
+		},
+		{
+			name: "--generate-outputs with --module-template",
+			fset: FlagSet{
+				flagGenerateOutputs: true,
+				flagModuleTemplate:  "module.rg_{resource_group}",
+			},
+			err: "`--generate-outputs` conflicts with `--module-template`",
+		},
+		{
+			name: "--output-rules without --generate-outputs",
+			fset: FlagSet{
+				flagOutputRulesFile: "rules.json",
+			},
+			err: "`--output-rules` must be used together with `--generate-outputs`",
+		},
+		{
+			name: "--backstage-catalog without --backstage-owner",
+			fset: FlagSet{
+				flagBackstageCatalog: true,
+			},
+			err: "`--backstage-owner` must be specified when `--backstage-catalog` is set",
+		},
+		{
+			name: "--backstage-system without --backstage-catalog",
+			fset: FlagSet{
+				flagBackstageSystem: "foo",
+			},
+			err: "`--backstage-owner` and `--backstage-system` must be used together with `--backstage-catalog`",
+		},
+		{
+			name: "--name-pattern with invalid template",
+			fset: FlagSet{
+				flagPattern: "{{ .Foo }}",
+			},
+			err: "`--name-pattern`: executing the resource name template: template: name:1:3: executing \"name\" at <.Foo>: can't evaluate field Foo in type meta.resourceNameData",
+		},
+		{
+			name: "--inject-tag without value",
+			fset: FlagSet{
+				flagInjectTags: *cli.NewStringSlice("managed_by"),
+			},
+			err: "`--inject-tag` must be in form of \"key=value\", got \"managed_by\"",
+		},
+		{
+			name: "--name-from with unsupported strategy",
+			fset: FlagSet{
+				flagNameFrom: "random",
+			},
+			err: "`--name-from`: unsupported naming strategy \"random\", must be one of: pattern, azure-name, azure-name-kebab, type-and-name",
+		},
+		{
+			name: "--include-tag without value",
+			fset: FlagSet{
+				flagIncludeTags: *cli.NewStringSlice("env"),
+			},
+			err: "`--include-tag` must be in form of \"key=value\", got \"env\"",
+		},
+		{
+			name: "--exclude-tag without key",
+			fset: FlagSet{
+				flagExcludeTags: *cli.NewStringSlice("=prod"),
+			},
+			err: "`--exclude-tag` must be in form of \"key=value\", got \"=prod\"",
+		},
+		{
+			name: "--apply-injected-tags without --inject-tag",
+			fset: FlagSet{
+				flagApplyInjectedTags: true,
+			},
+			err: "`--apply-injected-tags` must be used together with `--inject-tag`",
+		},
+		{
+			name: "--key-vault-ref with non Key Vault id",
+			fset: FlagSet{
+				flagKeyVaultRefs: *cli.NewStringSlice("/subscriptions/123/resourceGroups/rg"),
+			},
+			err: "`--key-vault-ref` must be Key Vault resource ids, got \"/subscriptions/123/resourceGroups/rg\"",
+		},
+		{
+			name: "--key-vault-ref with azapi provider",
+			fset: FlagSet{
+				flagProviderName: "azapi",
+				flagKeyVaultRefs: *cli.NewStringSlice("/subscriptions/123/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv"),
+			},
+			err: "`--key-vault-ref` can only be used when `--provider` is \"azurerm\"",
+		},
+		{
+			name: "--generate-data-sources with azapi provider",
+			fset: FlagSet{
+				flagProviderName:        "azapi",
+				flagGenerateDataSources: true,
+			},
+			err: "`--generate-data-sources` can only be used when `--provider` is \"azurerm\"",
+		},
+		{
+			name: "--credentials-file with azapi provider",
+			fset: FlagSet{
+				flagProviderName:    "azapi",
+				flagCredentialsFile: "credentials.json",
+			},
+			err: "`--credentials-file` can only be used when `--provider` is \"azurerm\"",
+		},
+		{
+			name: "--credentials-file with --module-path",
+			fset: FlagSet{
+				flagCredentialsFile: "credentials.json",
+				flagModulePath:      "mod",
+				flagAppend:          true,
+			},
+			err: "`--credentials-file` conflicts with `--module-path`",
+		},
+		{
+			name: "--redact-secrets with --on-secret=fail",
+			fset: FlagSet{
+				flagRedactSecrets: true,
+				flagOnSecret:      "fail",
+			},
+			err: "`--redact-secrets` conflicts with `--on-secret=fail`",
+		},
+		{
+			name: "--secret-allowlist-file without scanning secrets",
+			fset: FlagSet{
+				flagSecretAllowlistFile: "allowlist.json",
+			},
+			err: "`--secret-allowlist-file` must be used together with `--on-secret` or `--redact-secrets`",
+		},
+		{
+			name: "--sort-resources with unsupported value",
+			fset: FlagSet{
+				flagSortResources: "location",
+			},
+			err: "`--sort-resources` only supports one of: type, name, azure-id",
+		},
+		{
+			name: "--type with invalid resource type",
+			fset: FlagSet{
+				flagQueryTypes: *cli.NewStringSlice("virtualNetworks"),
+			},
+			err: "invalid query filter: invalid resource type \"virtualNetworks\", which is expected to be like \"Microsoft.Network/virtualNetworks\"",
+		},
+		{
+			name: "--created-after with invalid time",
+			fset: FlagSet{
+				flagCreatedAfter: "yesterday",
+			},
+			err: "invalid query filter: invalid creation time: \"yesterday\" is neither a RFC3339 timestamp nor a positive duration (e.g. \"72h\")",
+		},
+		{
+			name: "--split-by with unsupported value",
+			fset: FlagSet{
+				flagSplitBy: "location",
+			},
+			err: "`--split-by` only supports one of: resource-group, type",
+		},
+		{
+			name: "--split-by with --generate-data-sources",
+			fset: FlagSet{
+				flagSplitBy:             "resource-group",
+				flagGenerateDataSources: true,
+			},
+			err: "`--split-by` conflicts with `--generate-data-sources`",
+		},
+		{
+			name: "--split-by with --on-secret=var",
+			fset: FlagSet{
+				flagSplitBy:  "type",
+				flagOnSecret: "var",
+			},
+			err: "`--split-by` conflicts with `--on-secret=var`",
+		},
+		{
+			name: "--split-files with unsupported value",
+			fset: FlagSet{
+				flagSplitFiles: "per-location",
+			},
+			err: "`--split-files` only supports one of: per-resource, per-type, per-rg",
+		},
+		{
+			name: "--split-files with --split-by",
+			fset: FlagSet{
+				flagSplitFiles: "per-type",
+				flagSplitBy:    "type",
+			},
+			err: "`--split-files` conflicts with `--split-by`",
+		},
+		{
+			name: "--output-syntax with unsupported value",
+			fset: FlagSet{
+				flagOutputSyntax: "yaml",
+			},
+			err: "`--output-syntax` only supports one of: hcl, tf-json",
+		},
+		{
+			name: "--output-syntax=tf-json with --split-by",
+			fset: FlagSet{
+				flagOutputSyntax: "tf-json",
+				flagSplitBy:      "type",
+			},
+			err: "`--output-syntax=tf-json` conflicts with `--split-by`",
+		},
+		{
+			name: "--module-template with --split-by",
+			fset: FlagSet{
+				flagModuleTemplate: "module.rg_{resource_group}",
+				flagSplitBy:        "type",
+			},
+			err: "`--module-template` conflicts with `--split-by`",
+		},
+		{
+			name: "--module-template with --extract-variables",
+			fset: FlagSet{
+				flagModuleTemplate:   "module.rg_{resource_group}",
+				flagExtractVariables: true,
+			},
+			err: "`--module-template` conflicts with `--extract-variables`",
+		},
+		{
+			name: "--create-missing-modules without --module-template",
+			fset: FlagSet{
+				flagCreateMissingModules: true,
+			},
+			err: "`--create-missing-modules` must be used together with `--module-template`",
+		},
+		{
+			name: "--variable-attributes-file without --extract-variables",
+			fset: FlagSet{
+				flagVariableAttrsFile: "attrs.json",
+			},
+			err: "`--variable-attributes-file` must be used together with `--extract-variables`",
+		},
+		{
+			name: "--extract-variables with --env-split",
+			fset: FlagSet{
+				flagExtractVariables: true,
+				flagEnvSplit:         *cli.NewStringSlice("dev", "prod"),
+			},
+			err: "`--extract-variables` can't be used with `--env-split`",
+		},
+		{
+			name: "--on-secret with unsupported action",
+			fset: FlagSet{
+				flagOnSecret: "ignore",
+			},
+			err: "`--on-secret` only supports one of: redact, var, fail",
+		},
+		{
+			name: "--on-locked with unsupported policy",
+			fset: FlagSet{
+				flagOnLocked: "fail",
+			},
+			err: "`--on-locked` only supports one of: warn, skip",
+		},
+		{
+			name: "--on-soft-deleted with unsupported policy",
+			fset: FlagSet{
+				flagOnSoftDeleted: "purge",
+			},
+			err: "`--on-soft-deleted` only supports one of: warn, skip",
+		},
+		{
+			name: "--provenance-sign without --provenance",
+			fset: FlagSet{
+				flagProvenanceSign: "cosign",
+			},
+			err: "`--provenance-sign` must be used together with `--provenance`",
+		},
+		{
+			name: "--provenance-sign with unsupported signer",
+			fset: FlagSet{
+				flagProvenance:     true,
+				flagProvenanceSign: "gpg",
+			},
+			err: "`--provenance-sign` only supports one of: cosign, minisign",
+		},
+		{
+			name: "--env-split with invalid environment name",
+			fset: FlagSet{
+				flagEnvSplit: *cli.NewStringSlice("dev", "stage/eu"),
+			},
+			err: "`--env-split`: invalid environment name \"stage/eu\"",
+		},
+		{
+			name: "--env-split with duplicated environment names",
+			fset: FlagSet{
+				flagEnvSplit: *cli.NewStringSlice("dev", "prod", "dev"),
+			},
+			err: "`--env-split`: duplicated environment name \"dev\"",
+		},
+		{
+			name: "--provider with unsupported provider",
+			fset: FlagSet{
+				flagProviderName: "azuread",
+			},
+			err: "`--provider` only supports one of: azurerm, azapi",
+		},
+		{
+			name: "--azapi-fallback with --provider azapi",
+			fset: FlagSet{
+				flagProviderName:  "azapi",
+				flagAzAPIFallback: true,
+			},
+			err: "`--azapi-fallback` can only be used when `--provider` is \"azurerm\"",
+		},
+		{
+			name: "--resolvers with unknown resolver",
+			fset: FlagSet{
+				flagResolvers: *cli.NewStringSlice("api", "guess"),
+			},
+			err: "`--resolvers`: unknown resolver \"guess\"",
+		},
+		{
+			name: "--resolvers with duplicated resolver",
+			fset: FlagSet{
+				flagResolvers: *cli.NewStringSlice("api", "api"),
+			},
+			err: "`--resolvers`: duplicated resolver \"api\"",
+		},
+		{
+			name: "--resolver-plugin without the plugin resolver",
+			fset: FlagSet{
+				flagResolvers:      *cli.NewStringSlice("api", "ask"),
+				flagResolverPlugin: "main.go",
+			},
+			err: "`--resolver-plugin` requires the \"plugin\" resolver in `--resolvers`",
+		},
+		{
+			name: "--subresource-strategy with unsupported strategy",
+			fset: FlagSet{
+				flagSubresourceStrategy: "mixed",
+			},
+			err: "`--subresource-strategy` only supports one of: standalone, inline",
+		},
+		{
+			name: "--provider-major-version with unsupported version",
+			fset: FlagSet{
+				flagProviderMajorVersion: "2",
+			},
+			err: "`--provider-major-version` only supports one of: 3, 4",
+		},
+		{
+			name: "--provider-major-version with azapi provider",
+			fset: FlagSet{
+				flagProviderName:         "azapi",
+				flagProviderMajorVersion: "4",
+			},
+			err: "`--provider-major-version` can only be used when `--provider` is \"azurerm\"",
+		},
+		{
+			name: "unknown --env",
+			fset: FlagSet{
+				flagEnv: "mars",
+			},
+			err: "`--env`: unknown environment specified: \"mars\"",
+		},
+		{
+			name: "--env=AzureUSGovernment",
+			fset: FlagSet{
+				flagEnv: "AzureUSGovernment",
+			},
+		},
+		{
+			name: "--env=custom without --resource-manager-endpoint",
+			fset: FlagSet{
+				flagEnv: "custom",
+			},
+			err: "`--env=custom` must be used together with `--resource-manager-endpoint`",
+		},
+		{
+			name: "--env=custom with azapi provider",
+			fset: FlagSet{
+				flagEnv:                     "custom",
+				flagResourceManagerEndpoint: "https://management.local.azurestack.external",
+				flagProviderName:            "azapi",
+			},
+			err: "`--env=custom` can only be used when `--provider` is \"azurerm\"",
+		},
+		{
+			name: "--resource-manager-endpoint without --env=custom",
+			fset: FlagSet{
+				flagEnv:                     "public",
+				flagResourceManagerEndpoint: "https://management.local.azurestack.external",
+			},
+			err: "`--resource-manager-endpoint` must be used together with `--env=custom`",
+		},
+		{
+			name: "--resource-manager-endpoint with HTTP URL",
+			fset: FlagSet{
+				flagEnv:                     "custom",
+				flagResourceManagerEndpoint: "http://management.local.azurestack.external",
+			},
+			err: "`--resource-manager-endpoint` must be a HTTPS URL, e.g. https://management.local.azurestack.external",
+		},
+		{
+			name: "--env=custom with --resource-manager-endpoint",
+			fset: FlagSet{
+				flagEnv:                     "custom",
+				flagResourceManagerEndpoint: "https://management.local.azurestack.external",
+			},
+		},
+		{
+			name: "--provider-registry with URL",
+			fset: FlagSet{
+				flagProviderRegistry: "https://registry.example.com",
+			},
+			err: "`--provider-registry` must be a hostname, e.g. registry.example.com",
+		},
+		{
+			name: "--provider-source with only the type",
+			fset: FlagSet{
+				flagProviderSource: "azurerm",
+			},
+			err: "`--provider-source`: \"azurerm\" is not in the form of [<hostname>/]<namespace>/<type>",
+		},
+		{
+			name: "--provider-source of a private registry",
+			fset: FlagSet{
+				flagProviderSource: "registry.example.com/myorg/azurerm",
+			},
+		},
+		{
+			name: "--provider-mirror with HTTP URL",
+			fset: FlagSet{
+				flagProviderMirror: "http://mirror.example.com/providers/",
+			},
+			err: "`--provider-mirror` must be either a HTTPS URL or a local directory",
+		},
+		{
+			name: "--include-keyvault-items with azapi provider",
+			fset: FlagSet{
+				flagIncludeKeyVaultItems: true,
+				flagProviderName:         "azapi",
+			},
+			err: "`--include-keyvault-items` can only be used when `--provider` is \"azurerm\"",
+		},
+		{
+			name: "--provider-feature with azapi provider",
+			fset: FlagSet{
+				flagProviderFeatures: *cli.NewStringSlice("key_vault.purge_soft_delete_on_destroy=false"),
+				flagProviderName:     "azapi",
+			},
+			err: "`--provider-feature` can only be used when `--provider` is \"azurerm\"",
+		},
+		{
+			name: "invalid --provider-feature",
+			fset: FlagSet{
+				flagProviderFeatures: *cli.NewStringSlice("key_vault"),
+			},
+			err: "invalid `--provider-feature`: must be in form of \"key=value\"",
+		},
+		{
+			name: "--include-storage-items with azapi provider",
+			fset: FlagSet{
+				flagIncludeStorageItems: true,
+				flagProviderName:        "azapi",
+			},
+			err: "`--include-storage-items` can only be used when `--provider` is \"azurerm\"",
+		},
+		{
+			name: "--moved-from-state with non-existent file",
+			fset: FlagSet{
+				flagMovedFromState: "not-exist.tfstate",
+			},
+			err: "invalid `--moved-from-state`",
+		},
+		{
+			name: "--tfclient-provider-version without --hcl-only",
+			fset: FlagSet{
+				hflagTFClientProviderVersion: "3.65.0",
+			},
+		},
+		{
+			name: "--tfclient-provider-version with --tfclient-plugin-path",
+			fset: FlagSet{
+				flagHCLOnly:                  true,
+				hflagTFClientPluginPath:      "/path/to/provider",
+				hflagTFClientProviderVersion: "3.65.0",
+			},
+			err: "`--tfclient-provider-version` conflicts with `--tfclient-plugin-path`",
+		},
+		{
+			name: "--report-markdown without --non-interactive",
+			fset: FlagSet{
+				flagReportMarkdown: true,
+			},
+			err: "`--report-markdown` must be used together with `--non-interactive`",
+		},
+		{
+			name: "--chunk-size without --non-interactive",
+			fset: FlagSet{
+				flagChunkSize: 100,
+			},
+			err: "`--chunk-size` must be used together with `--non-interactive`",
+		},
+		{
+			name: "--chunk-size with negative number",
+			fset: FlagSet{
+				flagNonInteractive: true,
+				flagChunkSize:      -1,
+			},
+			err: "`--chunk-size` must be a positive number",
+		},
+		{
+			name: "--max-resources without --non-interactive",
+			fset: FlagSet{
+				flagMaxResources: 100,
+			},
+			err: "`--max-resources` must be used together with `--non-interactive`",
+		},
+		{
+			name: "--max-resources with negative number",
+			fset: FlagSet{
+				flagNonInteractive: true,
+				flagMaxResources:   -1,
+			},
+			err: "`--max-resources` must be a positive number",
+		},
+		{
+			name: "--max-duration with negative duration",
+			fset: FlagSet{
+				flagNonInteractive: true,
+				flagMaxDuration:    -time.Minute,
+			},
+			err: "`--max-duration` must not be negative",
+		},
+		{
+			name: "--limit with negative number",
+			fset: FlagSet{
+				flagLimit: -1,
+			},
+			err: "`--limit` must be a positive number",
+		},
+		{
+			name: "--limit with --sample",
+			fset: FlagSet{
+				flagLimit:  10,
+				flagSample: 10,
+			},
+			err: "`--limit` conflicts with `--sample`",
+		},
+		{
+			name: "--record with --replay",
+			fset: FlagSet{
+				flagRecord: "rec",
+				flagReplay: "rec",
+			},
+			err: "`--record` conflicts with `--replay`",
+		},
+		{
+			name: "--cache-dir with --no-cache",
+			fset: FlagSet{
+				flagCacheDir: "cache",
+				flagNoCache:  true,
+			},
+			err: "`--cache-dir` conflicts with `--no-cache`",
+		},
+		{
+			name: "--cache-dir with --replay",
+			fset: FlagSet{
+				flagCacheDir: "cache",
+				flagReplay:   "rec",
+			},
+			err: "`--cache-dir` conflicts with `--record` and `--replay`",
+		},
+		{
+			name: "--telemetry-sink with invalid value",
+			fset: FlagSet{
+				flagTelemetrySink: "foo",
+			},
+			err: "`--telemetry-sink` only supports one of: appinsights, otlp, file, none",
+		},
+		{
+			name: "--telemetry with invalid value",
+			fset: FlagSet{
+				flagTelemetry: "verbose",
+			},
+			err: "`--telemetry` only supports one of: off, minimal, full",
+		},
+		{
+			name: "--telemetry-sink=file without --telemetry-sink-target",
+			fset: FlagSet{
+				flagTelemetrySink: "file",
+			},
+			err: "`--telemetry-sink=file` must be used together with `--telemetry-sink-target`",
+		},
+		{
+			name: "--telemetry-sink-target with --telemetry-sink=none",
+			fset: FlagSet{
+				flagTelemetrySink:       "none",
+				flagTelemetrySinkTarget: "foo",
+			},
+			err: "`--telemetry-sink-target` can only be used when `--telemetry-sink` is \"otlp\" or \"file\"",
+		},
+		{
+			name: "--only-types with unknown type",
+			fset: FlagSet{
+				flagOnlyTypes: *cli.NewStringSlice("azurerm_storage_account", "azurerm_foo"),
+			},
+			err: "`--only-types`: unknown TF resource type \"azurerm_foo\"",
+		},
+		{
+			name: "--only-types with --skip-types",
+			fset: FlagSet{
+				flagOnlyTypes: *cli.NewStringSlice("azurerm_storage_account"),
+				flagSkipTypes: *cli.NewStringSlice("azurerm_virtual_network"),
+			},
+			err: "`--only-types` conflicts with `--skip-types`",
+		},
+		{
+			name: "--profile-dir with a file",
+			fset: FlagSet{
+				flagProfileDir: "main.go",
+			},
+			err: "`--profile-dir` must be a directory",
+		},
+		{
+			name: "--profile-dir with --profile",
+			fset: FlagSet{
+				flagProfileDir: "profile",
+				hflagProfile:   "cpu",
+			},
+			err: "`--profile-dir` conflicts with `--profile`",
+		},
+		{
+			name: "--retry-max with negative number",
+			fset: FlagSet{
+				flagRetryMax: -1,
+			},
+			err: "`--retry-max` must be a positive number",
+		},
+		{
+			name: "--retry-max with --replay",
+			fset: FlagSet{
+				flagRetryMax: 10,
+				flagReplay:   "rec",
+			},
+			err: "`--retry-max` and `--retry-base-delay` conflict with `--replay`",
+		},
+		{
+			name: "--import-timeout with negative duration",
+			fset: FlagSet{
+				flagImportTimeout: -time.Second,
+			},
+			err: "`--import-timeout` must be a positive duration",
+		},
+		{
+			name: "--retry-max with --retry-base-delay works",
+			fset: FlagSet{
+				flagRetryMax:       10,
+				flagRetryBaseDelay: 2 * time.Second,
+			},
+		},
+		{
+			name: "--use-environment-cred works",
+			fset: FlagSet{
+				flagUseEnvironmentCred: true,
+			},
+		},
+		{
+			name: "--use-environment-cred with --use-azure-cli failed",
+			fset: FlagSet{
+				flagUseEnvironmentCred: true,
+				flagUseAzureCLICred:    true,
+			},
+			err: "only one of `--use-environment-cred`, `--use-managed-identity-cred`, `--use-azure-cli-cred`, `--use-oidc-cred` and `--use-workload-identity-cred` can be specified",
+		},
+		{
+			name: "--cred-chain",
+			fset: FlagSet{
+				flagCredChain: *cli.NewStringSlice("oidc", "cli", "managed-identity"),
+			},
+		},
+		{
+			name: "--cred-chain with unsupported credential",
+			fset: FlagSet{
+				flagCredChain: *cli.NewStringSlice("oidc", "browser"),
+			},
+			err: "`--cred-chain` only supports one of: environment, managed-identity, cli, oidc, workload-identity",
+		},
+		{
+			name: "--cred-chain with duplicate credential",
+			fset: FlagSet{
+				flagCredChain: *cli.NewStringSlice("cli", "cli"),
+			},
+			err: "`--cred-chain` has duplicate credential \"cli\"",
+		},
+		{
+			name: "--cred-chain with --use-workload-identity-cred",
+			fset: FlagSet{
+				flagCredChain:               *cli.NewStringSlice("cli"),
+				flagUseWorkloadIdentityCred: true,
 			},
-			err: "only one of `--use-environment-cred`, `--use-managed-identity-cred`, `--use-azure-cli-cred` and `--use-oidc-cred` can be specified",
+			err: "`--cred-chain` conflicts with the `--use-*-cred` flags",
 		},
 	}
 
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/cfgfile"
+	"github.com/magodo/tfadd/providers/azurerm"
+	"github.com/urfave/cli/v2"
+)
+
+// The completion scripts call aztfexport with the words before the cursor and the "--generate-bash-completion" flag, whose output are the candidates.
+// The word under the cursor is only passed if it is a flag, in which case the flag names are completed.
+
+const bashCompletionScript = `# bash completion for aztfexport, e.g. source <(aztfexport completion bash)
+_aztfexport_complete() {
+  local cur opts
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  if [[ "$cur" == "-"* ]]; then
+    opts=$( "${COMP_WORDS[@]:0:$COMP_CWORD}" "${cur}" --generate-bash-completion 2>/dev/null )
+  else
+    opts=$( "${COMP_WORDS[@]:0:$COMP_CWORD}" --generate-bash-completion 2>/dev/null )
+  fi
+  COMPREPLY=( $(compgen -W "${opts}" -- "${cur}") )
+  return 0
+}
+
+complete -o bashdefault -o default -F _aztfexport_complete aztfexport
+`
+
+const zshCompletionScript = `#compdef aztfexport
+# zsh completion for aztfexport, e.g. source <(aztfexport completion zsh)
+_aztfexport_complete() {
+  local -a opts
+  local cur
+  cur=${words[-1]}
+  if [[ "$cur" == "-"* ]]; then
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} ${cur} --generate-bash-completion 2>/dev/null)}")
+  else
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} --generate-bash-completion 2>/dev/null)}")
+  fi
+
+  if [[ "${opts[1]}" != "" ]]; then
+    _describe 'values' opts
+  else
+    _files
+  fi
+}
+
+compdef _aztfexport_complete aztfexport
+`
+
+const fishCompletionScript = `# fish completion for aztfexport, e.g. aztfexport completion fish | source
+function __aztfexport_complete
+    set -l args (commandline -opc)
+    set -l cur (commandline -ct)
+    if string match -q -- '-*' $cur
+        $args $cur --generate-bash-completion 2>/dev/null
+    else
+        $args --generate-bash-completion 2>/dev/null
+    end
+end
+
+complete -c aztfexport -f -a '(__aztfexport_complete)'
+`
+
+const powershellCompletionScript = `# PowerShell completion for aztfexport, e.g. aztfexport completion powershell | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName aztfexport -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -le $cursorPosition } | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -ne '' -and -not $wordToComplete.StartsWith('-')) {
+        $words = @($words | Select-Object -SkipLast 1)
+    }
+    $prog, $rest = $words
+    & $prog @rest --generate-bash-completion 2>$null | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`
+
+// completionScripts are the completion scripts by the shells.
+var completionScripts = map[string]string{
+	"bash":       bashCompletionScript,
+	"zsh":        zshCompletionScript,
+	"fish":       fishCompletionScript,
+	"powershell": powershellCompletionScript,
+}
+
+// completionShells are the shells that the completion scripts are generated for.
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// flagValueCompleter returns the candidate values of a flag.
+type flagValueCompleter func() []string
+
+// completeTFResourceTypes returns the azurerm resource types of the embedded provider schema.
+func completeTFResourceTypes() []string {
+	var types []string
+	for rt := range azurerm.ProviderSchemaInfo.ResourceSchemas {
+		types = append(types, rt)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// completeSubscriptionIds returns the subscriptions that the Azure CLI is logged in, if any.
+func completeSubscriptionIds() []string {
+	ids, err := cfgfile.GetSubscriptionIdsFromCLI()
+	if err != nil {
+		return nil
+	}
+	return ids
+}
+
+// flagValueCompleters returns the value completers of the flags of the command, by the flag names (including the aliases).
+func flagValueCompleters(cmd *cli.Command) map[string]flagValueCompleter {
+	m := map[string]flagValueCompleter{}
+	for _, flag := range cmd.Flags {
+		var complete flagValueCompleter
+		switch flag.Names()[0] {
+		case "subscription-id":
+			complete = completeSubscriptionIds
+		case "type":
+			// Only the "--type" of the resource command is the TF resource type, while the one of the query command is the Azure resource type.
+			if cmd.Name == ModeResource {
+				complete = completeTFResourceTypes
+			}
+		}
+		if complete == nil {
+			continue
+		}
+		for _, name := range flag.Names() {
+			m[name] = complete
+		}
+	}
+	return m
+}
+
+// withCompletion makes the commands complete the values of the flags (see flagValueCompleters) that precede the cursor,
+// in addition to the flag names and the subcommands.
+func withCompletion(cmds []*cli.Command) {
+	for _, cmd := range cmds {
+		withCompletion(cmd.Subcommands)
+		completers := flagValueCompleters(cmd)
+		if len(completers) == 0 {
+			continue
+		}
+		fallback := cmd.BashComplete
+		if fallback == nil {
+			fallback = cli.DefaultCompleteWithFlags(cmd)
+		}
+		cmd.BashComplete = func(ctx *cli.Context) {
+			// The last argument is the "--generate-bash-completion" flag.
+			if len(os.Args) > 2 {
+				prev := os.Args[len(os.Args)-2]
+				if complete, ok := completers[strings.TrimLeft(prev, "-")]; ok && strings.HasPrefix(prev, "-") {
+					for _, v := range complete() {
+						fmt.Fprintln(ctx.App.Writer, v)
+					}
+					return
+				}
+			}
+			fallback(ctx)
+		}
+	}
+}
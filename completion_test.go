@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestFlagValueCompleters(t *testing.T) {
+	flags := []cli.Flag{
+		&cli.StringSliceFlag{Name: "subscription-id", Aliases: []string{"s"}},
+		&cli.StringFlag{Name: "type"},
+		&cli.StringFlag{Name: "output-dir"},
+	}
+
+	completers := flagValueCompleters(&cli.Command{Name: ModeResource, Flags: flags})
+	require.Len(t, completers, 3)
+	require.Contains(t, completers, "subscription-id")
+	require.Contains(t, completers, "s")
+	types := completers["type"]()
+	require.Contains(t, types, "azurerm_resource_group")
+	require.IsIncreasing(t, types)
+
+	// The "--type" of the query command is the Azure resource type
+	completers = flagValueCompleters(&cli.Command{Name: ModeQuery, Flags: flags})
+	require.Len(t, completers, 2)
+	require.NotContains(t, completers, "type")
+}
+
+func TestCompletionScripts(t *testing.T) {
+	require.Len(t, completionScripts, len(completionShells))
+	for _, shell := range completionShells {
+		require.Contains(t, completionScripts[shell], "--generate-bash-completion", shell)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/urfave/cli/v2"
+	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileFlagName is the flag that specifies the config file, which sets the flags of the command by their names, e.g.:
+//
+//	# HCL
+//	non-interactive = true
+//	include-tag     = ["env=prod", "team=infra"]
+//
+//	# YAML
+//	non-interactive: true
+//	include-tag: [env=prod, team=infra]
+//
+// The flags specified on the command line or via the environment variables take precedence over the config file.
+const configFileFlagName = "config"
+
+// loadConfigFile loads the config file, keyed by the flag names. The values of the list flags have multiple elements.
+func loadConfigFile(path string) (map[string][]string, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the config file %s: %v", path, err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".hcl":
+		return loadHCLConfigFile(path, b)
+	case ".yaml", ".yml":
+		return loadYAMLConfigFile(path, b)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q, must be one of %q", ext, []string{".hcl", ".yaml", ".yml"})
+	}
+}
+
+func loadHCLConfigFile(path string, b []byte) (map[string][]string, error) {
+	f, diags := hclsyntax.ParseConfig(b, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing the config file: %s", diags.Error())
+	}
+	body := f.Body.(*hclsyntax.Body)
+	if len(body.Blocks) != 0 {
+		return nil, fmt.Errorf("the config file %s must only contain attributes", path)
+	}
+	out := map[string][]string{}
+	for name, attr := range body.Attributes {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("evaluating %s: %s", name, diags.Error())
+		}
+		var vals []cty.Value
+		if ty := val.Type(); ty.IsListType() || ty.IsTupleType() || ty.IsSetType() {
+			vals = val.AsValueSlice()
+		} else {
+			vals = []cty.Value{val}
+		}
+		for _, v := range vals {
+			s, err := ctyToFlagValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			out[name] = append(out[name], s)
+		}
+	}
+	return out, nil
+}
+
+func ctyToFlagValue(v cty.Value) (string, error) {
+	if v.IsNull() || !v.IsWhollyKnown() {
+		return "", fmt.Errorf("null or unknown value")
+	}
+	switch v.Type() {
+	case cty.String:
+		return v.AsString(), nil
+	case cty.Bool:
+		if v.True() {
+			return "true", nil
+		}
+		return "false", nil
+	case cty.Number:
+		return v.AsBigFloat().Text('f', -1), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %s", v.Type().FriendlyName())
+	}
+}
+
+func loadYAMLConfigFile(path string, b []byte) (map[string][]string, error) {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("unmarshalling the config file %s: %v", path, err)
+	}
+	out := map[string][]string{}
+	for name, v := range m {
+		vals, ok := v.([]interface{})
+		if !ok {
+			vals = []interface{}{v}
+		}
+		for _, v := range vals {
+			switch v.(type) {
+			case string, bool, int, float64:
+				out[name] = append(out[name], fmt.Sprint(v))
+			default:
+				return nil, fmt.Errorf("%s: unsupported value %v", name, v)
+			}
+		}
+	}
+	return out, nil
+}
+
+// applyConfigFile sets the flags of the command from the config file, if specified, except the ones that are already set on the command line or via the environment variables.
+func applyConfigFile(ctx *cli.Context) error {
+	path := ctx.String(configFileFlagName)
+	if path == "" {
+		return nil
+	}
+	values, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	flags := map[string]bool{}
+	for _, flag := range ctx.Command.Flags {
+		for _, name := range flag.Names() {
+			flags[name] = true
+		}
+	}
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !flags[name] || name == configFileFlagName {
+			return fmt.Errorf("unknown flag %q of command %q in the config file %s", name, ctx.Command.Name, path)
+		}
+		if ctx.IsSet(name) {
+			continue
+		}
+		for _, v := range values[name] {
+			if err := ctx.Set(name, v); err != nil {
+				return fmt.Errorf("setting flag %q from the config file %s: %v", name, path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// withConfigFile makes the commands (that have the config file flag) apply the config file prior to their own Before functions.
+func withConfigFile(cmds []*cli.Command) {
+	for _, cmd := range cmds {
+		withConfigFile(cmd.Subcommands)
+		var hasFlag bool
+		for _, flag := range cmd.Flags {
+			if flag.Names()[0] == configFileFlagName {
+				hasFlag = true
+				break
+			}
+		}
+		if !hasFlag {
+			continue
+		}
+		before := cmd.Before
+		cmd.Before = func(ctx *cli.Context) error {
+			if err := applyConfigFile(ctx); err != nil {
+				return err
+			}
+			if before != nil {
+				return before(ctx)
+			}
+			return nil
+		}
+	}
+}
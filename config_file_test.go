@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	cases := []struct {
+		name     string
+		file     string
+		content  string
+		expect   map[string][]string
+		errMatch string
+	}{
+		{
+			name: "HCL",
+			file: "config.hcl",
+			content: `
+non-interactive = true
+parallelism     = 5
+output-dir      = "/tmp/out"
+include-tag     = ["env=prod", "team=infra"]
+`,
+			expect: map[string][]string{
+				"non-interactive": {"true"},
+				"parallelism":     {"5"},
+				"output-dir":      {"/tmp/out"},
+				"include-tag":     {"env=prod", "team=infra"},
+			},
+		},
+		{
+			name: "YAML",
+			file: "config.yaml",
+			content: `
+non-interactive: true
+parallelism: 5
+output-dir: /tmp/out
+include-tag: [env=prod, team=infra]
+`,
+			expect: map[string][]string{
+				"non-interactive": {"true"},
+				"parallelism":     {"5"},
+				"output-dir":      {"/tmp/out"},
+				"include-tag":     {"env=prod", "team=infra"},
+			},
+		},
+		{
+			name:     "HCL with block",
+			file:     "config.hcl",
+			content:  `foo {}`,
+			errMatch: "must only contain attributes",
+		},
+		{
+			name:     "YAML with map value",
+			file:     "config.yml",
+			content:  `foo: {bar: 1}`,
+			errMatch: "foo: unsupported value",
+		},
+		{
+			name:     "Unsupported extension",
+			file:     "config.json",
+			content:  `{}`,
+			errMatch: `unsupported config file extension ".json"`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			actual, err := loadConfigFile(path)
+			if tt.errMatch != "" {
+				require.ErrorContains(t, err, tt.errMatch)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, actual)
+		})
+	}
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.hcl")
+	require.NoError(t, os.WriteFile(path, []byte(`
+output-dir  = "file"
+parallelism = 5
+include-tag = ["env=prod", "team=infra"]
+overwrite   = true
+`), 0644))
+
+	run := func(t *testing.T, env map[string]string, args ...string) (outputDir string, parallelism int, tags []string, overwrite bool, err error) {
+		for k, v := range env {
+			t.Setenv(k, v)
+		}
+		app := &cli.App{
+			Commands: []*cli.Command{
+				{
+					Name: "test",
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: configFileFlagName},
+						&cli.StringFlag{Name: "output-dir", EnvVars: []string{"TEST_OUTPUT_DIR"}, Value: "default", Destination: &outputDir},
+						&cli.IntFlag{Name: "parallelism", Value: 10, Destination: &parallelism},
+						&cli.StringSliceFlag{Name: "include-tag"},
+						&cli.BoolFlag{Name: "overwrite", Destination: &overwrite},
+					},
+					Action: func(c *cli.Context) error {
+						tags = c.StringSlice("include-tag")
+						return nil
+					},
+				},
+			},
+		}
+		withConfigFile(app.Commands)
+		err = app.Run(append([]string{"aztfexport", "test"}, args...))
+		return
+	}
+
+	t.Run("no config file", func(t *testing.T) {
+		outputDir, parallelism, tags, overwrite, err := run(t, nil)
+		require.NoError(t, err)
+		require.Equal(t, "default", outputDir)
+		require.Equal(t, 10, parallelism)
+		require.Empty(t, tags)
+		require.False(t, overwrite)
+	})
+
+	t.Run("config file only", func(t *testing.T) {
+		outputDir, parallelism, tags, overwrite, err := run(t, nil, "--config", path)
+		require.NoError(t, err)
+		require.Equal(t, "file", outputDir)
+		require.Equal(t, 5, parallelism)
+		require.Equal(t, []string{"env=prod", "team=infra"}, tags)
+		require.True(t, overwrite)
+	})
+
+	t.Run("env takes precedence over config file", func(t *testing.T) {
+		outputDir, _, _, _, err := run(t, map[string]string{"TEST_OUTPUT_DIR": "env"}, "--config", path)
+		require.NoError(t, err)
+		require.Equal(t, "env", outputDir)
+	})
+
+	t.Run("CLI takes precedence over env and config file", func(t *testing.T) {
+		outputDir, parallelism, tags, _, err := run(t, map[string]string{"TEST_OUTPUT_DIR": "env"}, "--config", path, "--output-dir", "cli", "--parallelism", "1", "--include-tag", "a=b")
+		require.NoError(t, err)
+		require.Equal(t, "cli", outputDir)
+		require.Equal(t, 1, parallelism)
+		require.Equal(t, []string{"a=b"}, tags)
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		unknown := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(unknown, []byte(`foo: bar`), 0644))
+		_, _, _, _, err := run(t, nil, "--config", unknown)
+		require.ErrorContains(t, err, `unknown flag "foo"`)
+	})
+}
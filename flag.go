@@ -1,9 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/Azure/aztfexport/internal/provenance"
+	"github.com/Azure/aztfexport/internal/providerinstall"
+	"github.com/Azure/aztfexport/internal/utils"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/aztfexport/pkg/recorder"
+	"github.com/hashicorp/go-hclog"
+	"github.com/magodo/terraform-client-go/tfclient"
 	"github.com/urfave/cli/v2"
 )
 
@@ -11,38 +25,139 @@ var flagset FlagSet
 
 type FlagSet struct {
 	// common flags
-	flagEnv                 string
-	flagSubscriptionId      string
-	flagOutputDir           string
-	flagOverwrite           bool
-	flagAppend              bool
-	flagDevProvider         bool
-	flagProviderVersion     string
-	flagBackendType         string
-	flagBackendConfig       cli.StringSlice
-	flagFullConfig          bool
-	flagParallelism         int
-	flagContinue            bool
-	flagNonInteractive      bool
-	flagPlainUI             bool
-	flagGenerateMappingFile bool
-	flagHCLOnly             bool
-	flagModulePath          string
+	flagEnv                      string
+	flagResourceManagerEndpoint  string
+	flagSubscriptionId           string
+	flagSubscriptionIds          cli.StringSlice
+	flagOutputDir                string
+	flagOverwrite                bool
+	flagForceUnlock              bool
+	flagAppend                   bool
+	flagOnNonEmptyDir            string
+	flagPrune                    bool
+	flagMovedFromState           string
+	flagDevProvider              bool
+	flagProviderName             string
+	flagAzAPIFallback            bool
+	flagTypeOverrideFile         string
+	flagExcludeFile              string
+	flagIgnoreFile               string
+	flagCredentialsFile          string
+	flagResolvers                cli.StringSlice
+	flagResolverPlugin           string
+	flagHooks                    cli.StringSlice
+	flagSubresourceStrategy      string
+	flagProviderVersion          string
+	flagProviderMajorVersion     string
+	flagProviderRegistry         string
+	flagProviderSource           string
+	flagProviderFeatures         cli.StringSlice
+	flagProviderMirror           string
+	flagProviderPluginCache      string
+	flagBackendType              string
+	flagBackendConfig            cli.StringSlice
+	flagCloudOrganization        string
+	flagCloudWorkspace           string
+	flagWorkspace                string
+	flagLocalThenMigrate         bool
+	flagHoldBackendLock          bool
+	flagBootstrapBackend         bool
+	flagBootstrapBackendLocation string
+	flagBootstrapBackendExport   bool
+	flagScaffoldAuth             bool
+	flagFullConfig               bool
+	flagPropertyRulesFile        string
+	flagParallelism              int
+	flagImportTimeout            time.Duration
+	flagContinue                 bool
+	flagChunkSize                int
+	flagResume                   bool
+	flagMaxResources             int
+	flagMaxDuration              time.Duration
+	flagLimit                    int
+	flagSample                   int
+	flagNonInteractive           bool
+	flagPlainUI                  bool
+	flagOutputFormat             string
+	flagAccessible               bool
+	flagGenerateMappingFile      bool
+	flagDryRun                   bool
+	flagDryRunOutput             string
+	flagHCLOnly                  bool
+	flagShowCommands             bool
+	flagUseImportBlocks          bool
+	flagModulePath               string
+	flagCostEstimate             bool
+	flagVerify                   bool
+	flagReportMarkdown           bool
+	flagExportARMJSON            bool
+	flagEmitDependsOn            bool
+	flagPulumiConvert            string
+	flagStackConfig              string
+	flagAKSProviders             bool
+	flagBackstageCatalog         bool
+	flagBackstageOwner           string
+	flagBackstageSystem          string
+	flagInventory                bool
+	flagGenerateOutputs          bool
+	flagOutputRulesFile          string
+	flagGraphOutput              string
+	flagInjectTags               cli.StringSlice
+	flagApplyInjectedTags        bool
+	flagKeyVaultRefs             cli.StringSlice
+	flagGenerateDataSources      bool
+	flagNoReferenceRewrite       bool
+	flagExtractVariables         bool
+	flagVariableAttrsFile        string
+	flagOnSecret                 string
+	flagRedactSecrets            bool
+	flagSecretAllowlistFile      string
+	flagOnLocked                 string
+	flagOnSoftDeleted            string
+	flagIncludeRoleAssignments   bool
+	flagIncludeLocks             bool
+	flagIncludeMonitoring        bool
+	flagIncludeKeyVaultItems     bool
+	flagIncludeStorageItems      bool
+	flagIncludePolicyAssignments bool
+	flagProvenance               bool
+	flagProvenanceSign           string
+	flagProvenanceSignKey        string
+	flagEnvSplit                 cli.StringSlice
+	flagSplitBy                  string
+	flagSplitFiles               string
+	flagOutputSyntax             string
+	flagModuleTemplate           string
+	flagCreateMissingModules     bool
+	flagSortResources            string
+	flagRecord                   string
+	flagReplay                   string
+	flagRetryMax                 int
+	flagRetryBaseDelay           time.Duration
+	flagCacheDir                 string
+	flagNoCache                  bool
+	flagTelemetrySink            string
+	flagTelemetrySinkTarget      string
+	flagTelemetry                string
+	flagProfileDir               string
 
 	// common flags (auth)
-	flagUseEnvironmentCred     bool
-	flagUseManagedIdentityCred bool
-	flagUseAzureCLICred        bool
-	flagUseOIDCCred            bool
-	flagOIDCRequestToken       string
-	flagOIDCRequestURL         string
-	flagOIDCTokenFilePath      string
-	flagOIDCToken              string
+	flagUseEnvironmentCred      bool
+	flagUseManagedIdentityCred  bool
+	flagUseAzureCLICred         bool
+	flagUseOIDCCred             bool
+	flagUseWorkloadIdentityCred bool
+	flagCredChain               cli.StringSlice
+	flagOIDCRequestToken        string
+	flagOIDCRequestURL          string
+	flagOIDCTokenFilePath       string
+	flagOIDCToken               string
 
 	// common flags (hidden)
-	hflagMockClient         bool
-	hflagProfile            string
-	hflagTFClientPluginPath string
+	hflagMockClient              bool
+	hflagTFClientPluginPath      string
+	hflagTFClientProviderVersion string
+	hflagProfile                 string
 
 	// Subcommand specific flags
 	//
@@ -52,23 +167,117 @@ type FlagSet struct {
 	//
 	// rg:
 	// flagPattern
+	// flagNameFrom
+	// flagIncludeTags
+	// flagExcludeTags
+	// flagOnlyTypes
+	// flagSkipTypes
 	//
 	// query:
 	// flagPattern
+	// flagNameFrom
+	// flagIncludeTags
+	// flagExcludeTags
 	// flagRecursive
-	flagPattern   string
-	flagRecursive bool
-	flagResName   string
-	flagResType   string
+	// flagQueryTypes
+	// flagQueryLocations
+	// flagCreatedAfter
+	// flagCreatedBefore
+	// flagNameRegex
+	//
+	// watch:
+	// flagPattern
+	// flagNameFrom
+	// flagIncludeTags
+	// flagExcludeTags
+	// flagRecursive
+	// flagQueryTypes
+	// flagQueryLocations
+	// flagCreatedAfter
+	// flagCreatedBefore
+	// flagNameRegex
+	// flagWatchInterval
+	// flagWatchOnce
+	// flagWatchBranch
+	//
+	// sync:
+	// flagPattern
+	// flagNameFrom
+	// flagIncludeTags
+	// flagExcludeTags
+	// flagRecursive
+	// flagQueryTypes
+	// flagQueryLocations
+	// flagCreatedAfter
+	// flagCreatedBefore
+	// flagNameRegex
+	// flagSyncStateRm
+	//
+	// multi:
+	// flagPattern
+	// flagNameFrom
+	// flagIncludeTags
+	// flagExcludeTags
+	// flagConcurrency
+	//
+	// retry:
+	// flagRetryFrom
+	//
+	// bench:
+	// flagPattern
+	// flagNameFrom
+	// flagIncludeTags
+	// flagExcludeTags
+	// flagBenchMockImport
+	flagPattern         string
+	flagNameFrom        string
+	flagIncludeTags     cli.StringSlice
+	flagExcludeTags     cli.StringSlice
+	flagOnlyTypes       cli.StringSlice
+	flagSkipTypes       cli.StringSlice
+	flagRecursive       bool
+	flagQueryTypes      cli.StringSlice
+	flagQueryLocations  cli.StringSlice
+	flagCreatedAfter    string
+	flagCreatedBefore   string
+	flagNameRegex       string
+	flagResName         string
+	flagResType         string
+	flagWatchInterval   time.Duration
+	flagWatchOnce       bool
+	flagWatchBranch     string
+	flagSyncStateRm     bool
+	flagConcurrency     int
+	flagRetryFrom       string
+	flagBenchMockImport bool
 }
 
 const (
-	ModeResource      = "resource"
-	ModeResourceGroup = "resource-group"
-	ModeQuery         = "query"
-	ModeMappingFile   = "mapping-file"
+	ModeResource        = "resource"
+	ModeResourceGroup   = "resource-group"
+	ModeQuery           = "query"
+	ModeMappingFile     = "mapping-file"
+	ModeManagementGroup = "management-group"
+	ModeWatch           = "watch"
+	ModeSync            = "sync"
+	ModeMulti           = "multi"
+	ModeRetry           = "retry"
+	ModeBench           = "bench"
+	ModePlan            = "plan"
 )
 
+const (
+	// OnNonEmptyDirOverwrite cleans up the non-empty output directory, the same as `--overwrite`
+	OnNonEmptyDirOverwrite = "overwrite"
+	// OnNonEmptyDirAppend appends to the non-empty output directory, the same as `--append`
+	OnNonEmptyDirAppend = "append"
+	// OnNonEmptyDirFail fails on the non-empty output directory, without prompting even in the interactive mode
+	OnNonEmptyDirFail = "fail"
+)
+
+// OnNonEmptyDirActions are the supported actions for the non-empty output directory, which answer the prompt of the interactive mode in advance.
+var OnNonEmptyDirActions = []string{OnNonEmptyDirOverwrite, OnNonEmptyDirAppend, OnNonEmptyDirFail}
+
 // DescribeCLI construct a description of the CLI based on the flag set and the specified mode.
 // The main reason is to record the usage of some "interesting" options in the telemetry.
 // Note that only insensitive values are recorded (i.e. subscription id, resource id, etc are not recorded)
@@ -78,8 +287,10 @@ func (flag FlagSet) DescribeCLI(mode string) string {
 	// The following flags are skipped eiter not interesting, or might contain sensitive info:
 	// - flagSubscriptionId
 	// - flagOutputDir
+	// - flagResourceManagerEndpoint
 	// - flagDevProvider
 	// - flagBackendConfig
+	// - flagProfileDir
 	// - all hflags
 
 	if flag.flagEnv != "" {
@@ -88,39 +299,322 @@ func (flag FlagSet) DescribeCLI(mode string) string {
 	if flag.flagOverwrite {
 		args = append(args, "--overwrite=true")
 	}
+	if flag.flagForceUnlock {
+		args = append(args, "--force-unlock=true")
+	}
 	if flag.flagAppend {
 		args = append(args, "--append=true")
 	}
+	if flag.flagOnNonEmptyDir != "" {
+		args = append(args, "--on-nonempty-dir="+flag.flagOnNonEmptyDir)
+	}
+	if flag.flagPrune {
+		args = append(args, "--prune=true")
+	}
+	if flag.flagMovedFromState != "" {
+		args = append(args, "--moved-from-state="+flag.flagMovedFromState)
+	}
+	if flag.flagProviderName != "" {
+		args = append(args, "--provider="+flag.flagProviderName)
+	}
+	if flag.flagAzAPIFallback {
+		args = append(args, "--azapi-fallback=true")
+	}
+	if flag.flagTypeOverrideFile != "" {
+		args = append(args, "--type-override-file="+flag.flagTypeOverrideFile)
+	}
+	if flag.flagExcludeFile != "" {
+		args = append(args, "--exclude-file="+flag.flagExcludeFile)
+	}
+	if flag.flagIgnoreFile != "" {
+		args = append(args, "--ignore-file="+flag.flagIgnoreFile)
+	}
+	if flag.flagCredentialsFile != "" {
+		args = append(args, "--credentials-file="+flag.flagCredentialsFile)
+	}
+	if v := flag.flagResolvers.Value(); len(v) != 0 {
+		args = append(args, "--resolvers="+strings.Join(v, ","))
+	}
+	if flag.flagResolverPlugin != "" {
+		args = append(args, "--resolver-plugin="+flag.flagResolverPlugin)
+	}
+	// Only the phases of the hooks are recorded, as the commands might contain sensitive info
+	for _, hook := range parseCommandHooks(flag.flagHooks.Value()) {
+		args = append(args, "--hook="+hook.Phase)
+	}
+	if flag.flagSubresourceStrategy != "" {
+		args = append(args, "--subresource-strategy="+flag.flagSubresourceStrategy)
+	}
 	if flag.flagProviderVersion != "" {
 		args = append(args, `-provider-version="%s"`, flag.flagProviderVersion)
 	}
+	if flag.flagProviderMajorVersion != "" {
+		args = append(args, "--provider-major-version="+flag.flagProviderMajorVersion)
+	}
+	if flag.flagProviderRegistry != "" {
+		args = append(args, "--provider-registry="+flag.flagProviderRegistry)
+	}
+	if flag.flagProviderSource != "" {
+		args = append(args, "--provider-source="+flag.flagProviderSource)
+	}
+	if v := flag.flagProviderFeatures.Value(); len(v) != 0 {
+		args = append(args, "--provider-feature="+strings.Join(v, ","))
+	}
+	if flag.flagProviderMirror != "" {
+		args = append(args, "--provider-mirror="+flag.flagProviderMirror)
+	}
+	if flag.flagProviderPluginCache != "" {
+		args = append(args, "--provider-plugin-cache="+flag.flagProviderPluginCache)
+	}
 	if flag.flagBackendType != "" {
 		args = append(args, "--backend-type="+flag.flagBackendType)
 	}
+	if flag.flagCloudOrganization != "" {
+		args = append(args, "--cloud-organization="+flag.flagCloudOrganization)
+	}
+	if flag.flagCloudWorkspace != "" {
+		args = append(args, "--cloud-workspace="+flag.flagCloudWorkspace)
+	}
+	if flag.flagWorkspace != "" {
+		args = append(args, "--workspace="+flag.flagWorkspace)
+	}
+	if flag.flagLocalThenMigrate {
+		args = append(args, "--local-then-migrate=true")
+	}
+	if flag.flagHoldBackendLock {
+		args = append(args, "--hold-backend-lock=true")
+	}
+	if flag.flagBootstrapBackend {
+		args = append(args, "--bootstrap-backend=true")
+	}
+	if flag.flagBootstrapBackendLocation != "" {
+		args = append(args, "--bootstrap-backend-location="+flag.flagBootstrapBackendLocation)
+	}
+	if flag.flagBootstrapBackendExport {
+		args = append(args, "--bootstrap-backend-export=true")
+	}
+	if flag.flagScaffoldAuth {
+		args = append(args, "--scaffold-auth=true")
+	}
 	if flag.flagFullConfig {
 		args = append(args, "--full-properties=true")
 	}
+	if flag.flagPropertyRulesFile != "" {
+		args = append(args, "--property-rules="+flag.flagPropertyRulesFile)
+	}
 	if flag.flagParallelism != 0 {
 		args = append(args, fmt.Sprintf("--parallelism=%d", flag.flagParallelism))
 	}
+	if flag.flagImportTimeout != 0 {
+		args = append(args, "--import-timeout="+flag.flagImportTimeout.String())
+	}
 	if flag.flagNonInteractive {
 		args = append(args, "--non-interactive=true")
 	}
 	if flag.flagPlainUI {
 		args = append(args, "--plain-ui=true")
 	}
+	if flag.flagAccessible {
+		args = append(args, "--accessible=true")
+	}
 	if flag.flagContinue {
 		args = append(args, "--continue=true")
 	}
+	if flag.flagChunkSize != 0 {
+		args = append(args, fmt.Sprintf("--chunk-size=%d", flag.flagChunkSize))
+	}
+	if flag.flagResume {
+		args = append(args, "--resume=true")
+	}
+	if flag.flagMaxResources != 0 {
+		args = append(args, fmt.Sprintf("--max-resources=%d", flag.flagMaxResources))
+	}
+	if flag.flagMaxDuration != 0 {
+		args = append(args, "--max-duration="+flag.flagMaxDuration.String())
+	}
+	if flag.flagLimit != 0 {
+		args = append(args, fmt.Sprintf("--limit=%d", flag.flagLimit))
+	}
+	if flag.flagSample != 0 {
+		args = append(args, fmt.Sprintf("--sample=%d", flag.flagSample))
+	}
+	if flag.flagOutputFormat != "" {
+		args = append(args, "--output-format="+flag.flagOutputFormat)
+	}
 	if flag.flagGenerateMappingFile {
 		args = append(args, "--generate-mapping-file=true")
 	}
+	if flag.flagDryRun {
+		args = append(args, "--dry-run=true")
+	}
+	if flag.flagDryRunOutput != "" {
+		args = append(args, "--dry-run-output="+flag.flagDryRunOutput)
+	}
 	if flag.flagHCLOnly {
 		args = append(args, "--hcl-only=true")
 	}
+	if flag.flagShowCommands {
+		args = append(args, "--show-commands=true")
+	}
+	if flag.flagUseImportBlocks {
+		args = append(args, "--use-import-blocks=true")
+	}
 	if flag.flagModulePath != "" {
 		args = append(args, "--module-path="+flag.flagModulePath)
 	}
+	if flag.flagCostEstimate {
+		args = append(args, "--cost-estimate=true")
+	}
+	if flag.flagVerify {
+		args = append(args, "--verify=true")
+	}
+	if flag.flagReportMarkdown {
+		args = append(args, "--report-markdown=true")
+	}
+	if flag.flagExportARMJSON {
+		args = append(args, "--export-arm-json=true")
+	}
+	if flag.flagEmitDependsOn {
+		args = append(args, "--emit-depends-on=true")
+	}
+	if flag.flagPulumiConvert != "" {
+		args = append(args, "--pulumi-convert="+flag.flagPulumiConvert)
+	}
+	if flag.flagStackConfig != "" {
+		args = append(args, "--stack-config="+flag.flagStackConfig)
+	}
+	if flag.flagAKSProviders {
+		args = append(args, "--aks-providers=true")
+	}
+	if flag.flagBackstageCatalog {
+		args = append(args, "--backstage-catalog=true")
+	}
+	if flag.flagBackstageOwner != "" {
+		args = append(args, "--backstage-owner="+flag.flagBackstageOwner)
+	}
+	if flag.flagBackstageSystem != "" {
+		args = append(args, "--backstage-system="+flag.flagBackstageSystem)
+	}
+	if flag.flagInventory {
+		args = append(args, "--inventory=true")
+	}
+	if flag.flagGenerateOutputs {
+		args = append(args, "--generate-outputs=true")
+	}
+	if flag.flagOutputRulesFile != "" {
+		args = append(args, "--output-rules="+flag.flagOutputRulesFile)
+	}
+	if flag.flagGraphOutput != "" {
+		args = append(args, "--graph-output="+flag.flagGraphOutput)
+	}
+	if v := flag.flagInjectTags.Value(); len(v) != 0 {
+		args = append(args, "--inject-tag="+strings.Join(v, ","))
+	}
+	if flag.flagApplyInjectedTags {
+		args = append(args, "--apply-injected-tags=true")
+	}
+	if v := flag.flagKeyVaultRefs.Value(); len(v) != 0 {
+		args = append(args, "--key-vault-ref="+strings.Join(v, ","))
+	}
+	if flag.flagGenerateDataSources {
+		args = append(args, "--generate-data-sources=true")
+	}
+	if flag.flagNoReferenceRewrite {
+		args = append(args, "--no-reference-rewrite=true")
+	}
+	if flag.flagExtractVariables {
+		args = append(args, "--extract-variables=true")
+	}
+	if flag.flagVariableAttrsFile != "" {
+		args = append(args, "--variable-attributes-file="+flag.flagVariableAttrsFile)
+	}
+	if flag.flagOnSecret != "" {
+		args = append(args, "--on-secret="+flag.flagOnSecret)
+	}
+	if flag.flagRedactSecrets {
+		args = append(args, "--redact-secrets=true")
+	}
+	if flag.flagSecretAllowlistFile != "" {
+		args = append(args, "--secret-allowlist-file="+flag.flagSecretAllowlistFile)
+	}
+	if flag.flagOnLocked != "" {
+		args = append(args, "--on-locked="+flag.flagOnLocked)
+	}
+	if flag.flagOnSoftDeleted != "" {
+		args = append(args, "--on-soft-deleted="+flag.flagOnSoftDeleted)
+	}
+	if flag.flagIncludeRoleAssignments {
+		args = append(args, "--include-role-assignments=true")
+	}
+	if flag.flagIncludeLocks {
+		args = append(args, "--include-locks=true")
+	}
+	if flag.flagIncludePolicyAssignments {
+		args = append(args, "--include-policy-assignments=true")
+	}
+	if flag.flagIncludeMonitoring {
+		args = append(args, "--include-monitoring=true")
+	}
+	if flag.flagIncludeKeyVaultItems {
+		args = append(args, "--include-keyvault-items=true")
+	}
+	if flag.flagIncludeStorageItems {
+		args = append(args, "--include-storage-items=true")
+	}
+	if flag.flagProvenance {
+		args = append(args, "--provenance=true")
+	}
+	if flag.flagProvenanceSign != "" {
+		args = append(args, "--provenance-sign="+flag.flagProvenanceSign)
+	}
+	if v := flag.flagEnvSplit.Value(); len(v) != 0 {
+		args = append(args, "--env-split="+strings.Join(v, ","))
+	}
+	if flag.flagSplitBy != "" {
+		args = append(args, "--split-by="+flag.flagSplitBy)
+	}
+	if flag.flagSplitFiles != "" {
+		args = append(args, "--split-files="+flag.flagSplitFiles)
+	}
+	if flag.flagOutputSyntax != "" {
+		args = append(args, "--output-syntax="+flag.flagOutputSyntax)
+	}
+	if flag.flagModuleTemplate != "" {
+		args = append(args, "--module-template="+flag.flagModuleTemplate)
+	}
+	if flag.flagCreateMissingModules {
+		args = append(args, "--create-missing-modules=true")
+	}
+	if flag.flagSortResources != "" {
+		args = append(args, "--sort-resources="+flag.flagSortResources)
+	}
+	if flag.flagRecord != "" {
+		args = append(args, "--record="+flag.flagRecord)
+	}
+	if flag.flagReplay != "" {
+		args = append(args, "--replay="+flag.flagReplay)
+	}
+	if flag.flagRetryMax != 0 {
+		args = append(args, fmt.Sprintf("--retry-max=%d", flag.flagRetryMax))
+	}
+	if flag.flagRetryBaseDelay != 0 {
+		args = append(args, "--retry-base-delay="+flag.flagRetryBaseDelay.String())
+	}
+	if flag.flagCacheDir != "" {
+		args = append(args, "--cache-dir="+flag.flagCacheDir)
+	}
+	if flag.flagNoCache {
+		args = append(args, "--no-cache=true")
+	}
+	if flag.flagTelemetrySink != "" {
+		args = append(args, "--telemetry-sink="+flag.flagTelemetrySink)
+	}
+	if flag.flagTelemetrySinkTarget != "" {
+		args = append(args, "--telemetry-sink-target="+flag.flagTelemetrySinkTarget)
+	}
+	if flag.flagTelemetry != "" {
+		args = append(args, "--telemetry="+flag.flagTelemetry)
+	}
 
 	if flag.flagUseEnvironmentCred {
 		args = append(args, "--use-environment-cred=true")
@@ -134,6 +628,12 @@ func (flag FlagSet) DescribeCLI(mode string) string {
 	if flag.flagUseOIDCCred {
 		args = append(args, "--use-oidc-cred=true")
 	}
+	if flag.flagUseWorkloadIdentityCred {
+		args = append(args, "--use-workload-identity-cred=true")
+	}
+	if v := flag.flagCredChain.Value(); len(v) != 0 {
+		args = append(args, "--cred-chain="+strings.Join(v, ","))
+	}
 	if flag.flagOIDCRequestToken != "" {
 		args = append(args, "--oidc-request-token=*")
 	}
@@ -150,6 +650,9 @@ func (flag FlagSet) DescribeCLI(mode string) string {
 	if flag.hflagTFClientPluginPath != "" {
 		args = append(args, "--tfclient-plugin-path="+flag.hflagTFClientPluginPath)
 	}
+	if flag.hflagTFClientProviderVersion != "" {
+		args = append(args, "--tfclient-provider-version="+flag.hflagTFClientProviderVersion)
+	}
 	switch mode {
 	case ModeResource:
 		if flag.flagResName != "" {
@@ -162,6 +665,16 @@ func (flag FlagSet) DescribeCLI(mode string) string {
 		if flag.flagPattern != "" {
 			args = append(args, "--name-pattern="+flag.flagPattern)
 		}
+		if v := flag.flagOnlyTypes.Value(); len(v) != 0 {
+			args = append(args, "--only-types="+strings.Join(v, ","))
+		}
+		if v := flag.flagSkipTypes.Value(); len(v) != 0 {
+			args = append(args, "--skip-types="+strings.Join(v, ","))
+		}
+	case ModeManagementGroup:
+		if flag.flagPattern != "" {
+			args = append(args, "--name-pattern="+flag.flagPattern)
+		}
 	case ModeQuery:
 		if flag.flagPattern != "" {
 			args = append(args, "--name-pattern="+flag.flagPattern)
@@ -169,6 +682,466 @@ func (flag FlagSet) DescribeCLI(mode string) string {
 		if flag.flagRecursive {
 			args = append(args, "--recursive=true")
 		}
+		args = append(args, flag.queryFilterArgs()...)
+	case ModeWatch:
+		if flag.flagPattern != "" {
+			args = append(args, "--name-pattern="+flag.flagPattern)
+		}
+		if flag.flagRecursive {
+			args = append(args, "--recursive=true")
+		}
+		args = append(args, flag.queryFilterArgs()...)
+		if flag.flagWatchInterval != 0 {
+			args = append(args, "--interval="+flag.flagWatchInterval.String())
+		}
+		if flag.flagWatchOnce {
+			args = append(args, "--once=true")
+		}
+		if flag.flagWatchBranch != "" {
+			args = append(args, "--export-branch="+flag.flagWatchBranch)
+		}
+	case ModeSync:
+		if flag.flagPattern != "" {
+			args = append(args, "--name-pattern="+flag.flagPattern)
+		}
+		if flag.flagRecursive {
+			args = append(args, "--recursive=true")
+		}
+		args = append(args, flag.queryFilterArgs()...)
+		if flag.flagSyncStateRm {
+			args = append(args, "--state-rm=true")
+		}
+	case ModeMulti:
+		if flag.flagPattern != "" {
+			args = append(args, "--name-pattern="+flag.flagPattern)
+		}
+		if flag.flagConcurrency != 0 {
+			args = append(args, fmt.Sprintf("--concurrency=%d", flag.flagConcurrency))
+		}
+	case ModeBench:
+		if flag.flagPattern != "" {
+			args = append(args, "--name-pattern="+flag.flagPattern)
+		}
+		if flag.flagBenchMockImport {
+			args = append(args, "--mock-import=true")
+		}
+	}
+	// The naming strategy and the tag filters are shared by all the modes that have the name pattern
+	if flag.flagNameFrom != "" {
+		args = append(args, "--name-from="+flag.flagNameFrom)
+	}
+	if v := flag.flagIncludeTags.Value(); len(v) != 0 {
+		args = append(args, "--include-tag="+strings.Join(v, ","))
+	}
+	if v := flag.flagExcludeTags.Value(); len(v) != 0 {
+		args = append(args, "--exclude-tag="+strings.Join(v, ","))
 	}
 	return "aztfexport " + strings.Join(args, " ")
 }
+
+// safeOutputFileNames is used when appending to an existing workspace, to avoid overwriting the existing files.
+var safeOutputFileNames = config.OutputFileNames{
+	TerraformFileName:   "terraform.aztfexport.tf",
+	ProviderFileName:    "provider.aztfexport.tf",
+	MainFileName:        "main.aztfexport.tf",
+	ImportBlockFileName: "import.aztfexport.tf",
+}
+
+// BuildCommonConfig builds the CommonConfig from the flag set, which is shared by all the modes.
+func (flag FlagSet) BuildCommonConfig() (config.CommonConfig, error) {
+	cred, clientOpt, err := buildAzureSDKCredAndClientOpt(flag)
+	if err != nil {
+		return config.CommonConfig{}, err
+	}
+
+	// The transport is set after the credential is built, which keeps the token requests out of the recording.
+	switch {
+	case flag.flagRecord != "":
+		t, err := recorder.NewRecorder(flag.flagRecord, nil)
+		if err != nil {
+			return config.CommonConfig{}, err
+		}
+		clientOpt.Transport = t
+	case flag.flagReplay != "":
+		t, err := recorder.NewReplayer(flag.flagReplay)
+		if err != nil {
+			return config.CommonConfig{}, err
+		}
+		clientOpt.Transport = t
+		// Retrying an interaction that is not recorded is meaningless
+		clientOpt.Retry.MaxRetries = -1
+		cred = recorder.Credential{}
+	}
+
+	tc, err := initTelemetryClient(flag.flagSubscriptionId, flag.flagTelemetrySink, flag.flagTelemetrySinkTarget, flag.flagTelemetry)
+	if err != nil {
+		return config.CommonConfig{}, err
+	}
+
+	cfg := config.CommonConfig{
+		SubscriptionId:            flag.flagSubscriptionId,
+		AdditionalSubscriptionIds: flag.additionalSubscriptionIds(),
+		CredentialsFile:           flag.flagCredentialsFile,
+		AzureSDKCredential:        cred,
+		AzureSDKClientOption:      *clientOpt,
+		RetryPolicy:               config.RetryPolicy{MaxRetries: flag.flagRetryMax, BaseDelay: flag.flagRetryBaseDelay},
+		OutputDir:                 flag.flagOutputDir,
+		ProviderName:              flag.flagProviderName,
+		AzAPIFallback:             flag.flagAzAPIFallback,
+		TypeOverrideFile:          flag.flagTypeOverrideFile,
+		ExcludeFile:               flag.flagExcludeFile,
+		IgnoreFile:                flag.flagIgnoreFile,
+		Resolvers:                 flag.flagResolvers.Value(),
+		ResolverPluginPath:        flag.flagResolverPlugin,
+		CommandHooks:              parseCommandHooks(flag.flagHooks.Value()),
+		SubresourceStrategy:       flag.flagSubresourceStrategy,
+		ProviderVersion:           flag.flagProviderVersion,
+		ProviderMajorVersion:      flag.flagProviderMajorVersion,
+		ProviderRegistry:          flag.flagProviderRegistry,
+		ProviderSource:            flag.flagProviderSource,
+		ProviderMirror:            flag.flagProviderMirror,
+		ProviderPluginCacheDir:    flag.flagProviderPluginCache,
+		DevProvider:               flag.flagDevProvider,
+		ContinueOnError:           flag.flagContinue,
+		BackendType:               flag.flagBackendType,
+		BackendConfig:             flag.flagBackendConfig.Value(),
+		CloudOrganization:         flag.flagCloudOrganization,
+		CloudWorkspace:            flag.flagCloudWorkspace,
+		Workspace:                 flag.flagWorkspace,
+		LocalThenMigrate:          flag.flagLocalThenMigrate,
+		HoldBackendLock:           flag.flagHoldBackendLock,
+		FullConfig:                flag.flagFullConfig,
+		PropertyRulesFile:         flag.flagPropertyRulesFile,
+		Limit:                     flag.flagLimit,
+		Sample:                    flag.flagSample,
+		Parallelism:               flag.flagParallelism,
+		ImportTimeout:             flag.flagImportTimeout,
+		HCLOnly:                   flag.flagHCLOnly,
+		ShowCommands:              flag.flagShowCommands,
+		UseImportBlocks:           flag.flagUseImportBlocks,
+		DryRun:                    flag.flagDryRun,
+		Prune:                     flag.flagPrune,
+		MovedFromState:            flag.flagMovedFromState,
+		ModulePath:                flag.flagModulePath,
+		ExportARMJSON:             flag.flagExportARMJSON,
+		EmitDependsOn:             flag.flagEmitDependsOn,
+		StackConfigType:           flag.flagStackConfig,
+		AKSProviders:              flag.flagAKSProviders,
+		BackstageCatalog:          flag.flagBackstageCatalog,
+		BackstageOwner:            flag.flagBackstageOwner,
+		BackstageSystem:           flag.flagBackstageSystem,
+		Inventory:                 flag.flagInventory,
+		GenerateOutputs:           flag.flagGenerateOutputs,
+		OutputRulesFile:           flag.flagOutputRulesFile,
+		GraphOutput:               flag.flagGraphOutput,
+		InjectTags:                parseTags(flag.flagInjectTags.Value()),
+		ApplyInjectedTags:         flag.flagApplyInjectedTags,
+		KeyVaultIds:               flag.flagKeyVaultRefs.Value(),
+		GenerateDataSources:       flag.flagGenerateDataSources,
+		DisableReferenceRewrite:   flag.flagNoReferenceRewrite,
+		ExtractVariables:          flag.flagExtractVariables,
+		VariableAttributesFile:    flag.flagVariableAttrsFile,
+		OnSecret:                  flag.onSecret(),
+		SecretAllowlistFile:       flag.flagSecretAllowlistFile,
+		OnLocked:                  flag.flagOnLocked,
+		OnSoftDeleted:             flag.flagOnSoftDeleted,
+		IncludeRoleAssignments:    flag.flagIncludeRoleAssignments,
+		IncludeLocks:              flag.flagIncludeLocks,
+		IncludePolicyAssignments:  flag.flagIncludePolicyAssignments,
+		IncludeMonitoring:         flag.flagIncludeMonitoring,
+		IncludeKeyVaultItems:      flag.flagIncludeKeyVaultItems,
+		IncludeStorageItems:       flag.flagIncludeStorageItems,
+		EnvSplit:                  flag.flagEnvSplit.Value(),
+		SplitBy:                   flag.flagSplitBy,
+		SplitFiles:                flag.flagSplitFiles,
+		OutputSyntax:              flag.flagOutputSyntax,
+		ModuleTemplate:            flag.flagModuleTemplate,
+		CreateMissingModules:      flag.flagCreateMissingModules,
+		SortResources:             flag.flagSortResources,
+		CacheDir:                  flag.cacheDir(),
+		TelemetryClient:           tc,
+	}
+
+	if flag.flagAppend {
+		cfg.OutputFileNames = safeOutputFileNames
+	}
+
+	cfg.ProviderConfig, err = providerCloudConfig(flag.flagProviderName, flag.flagEnv, flag.flagResourceManagerEndpoint)
+	if err != nil {
+		return config.CommonConfig{}, err
+	}
+
+	cfg.ProviderFeatures, err = parseProviderFeatures(flag.flagProviderFeatures.Value())
+	if err != nil {
+		return config.CommonConfig{}, fmt.Errorf("invalid `--provider-feature`: %v", err)
+	}
+
+	if flag.flagBootstrapBackend {
+		cfg.BackendBootstrap = &config.BackendBootstrap{
+			Location: flag.flagBootstrapBackendLocation,
+			Export:   flag.flagBootstrapBackendExport,
+		}
+	}
+
+	if flag.flagScaffoldAuth {
+		cfg.AuthScaffold = &config.AuthScaffold{
+			Method:      flag.authMethod(),
+			Environment: flag.scaffoldEnvironment(),
+			TenantId:    os.Getenv("ARM_TENANT_ID"),
+			ClientId:    os.Getenv("ARM_CLIENT_ID"),
+		}
+	}
+
+	pluginPath, err := flag.tfclientPluginPath()
+	if err != nil {
+		return config.CommonConfig{}, err
+	}
+	if pluginPath != "" {
+		cfg.TFClient, err = newTFClient(pluginPath)
+		if err != nil {
+			return config.CommonConfig{}, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// tfclientPluginPath returns the path of the provider that the tfclient runs, which is installed first if `--tfclient-provider-version` is specified.
+// It is empty if the tfclient is not used.
+func (flag FlagSet) tfclientPluginPath() (string, error) {
+	pluginPath := flag.hflagTFClientPluginPath
+	if pluginPath != "" || flag.hflagTFClientProviderVersion != "" {
+		if reason := flag.tfclientFallbackReason(); reason != "" {
+			log.Printf("[INFO] Importing via the terraform binary instead of the tfclient, as %s", reason)
+			return "", nil
+		}
+	}
+	if flag.hflagTFClientProviderVersion != "" {
+		return providerinstall.Ensure(context.Background(), flag.hflagTFClientProviderVersion, flag.flagProviderPluginCache)
+	}
+	return pluginPath, nil
+}
+
+// newTFClient starts the provider of the plugin path for the tfclient, which is closed on the deinitialization of the meta.
+func newTFClient(pluginPath string) (tfclient.Client, error) {
+	// #nosec G204
+	cmd := exec.Command(pluginPath)
+	// The provider doesn't receive the Ctrl-C from the terminal, which otherwise aborts the in-flight imports on the first interrupt.
+	utils.SetProcessGroup(cmd)
+	return tfclient.New(tfclient.Option{
+		Cmd:    cmd,
+		Logger: hclog.NewNullLogger(),
+	})
+}
+
+// tfclientFallbackReason returns why the tfclient can't replace the terraform binary for importing, which is empty if it can.
+// Without `--hcl-only`, the tfclient writes the state file directly, which only works for the local backend in the root module.
+func (flag FlagSet) tfclientFallbackReason() string {
+	// These options rely on the terraform binary, even with `--hcl-only`.
+	switch {
+	case flag.flagWorkspace != "":
+		return "`--workspace` is specified"
+	case flag.flagLocalThenMigrate:
+		return "`--local-then-migrate` is specified"
+	case flag.flagAzAPIFallback:
+		return "`--azapi-fallback` is specified"
+	case flag.flagHoldBackendLock:
+		return "`--hold-backend-lock` is specified"
+	}
+	if flag.flagHCLOnly {
+		return ""
+	}
+	switch {
+	case flag.flagBackendType != "local":
+		return fmt.Sprintf("the %s backend is used", flag.flagBackendType)
+	case flag.flagModulePath != "":
+		return "`--module-path` is specified"
+	case flag.flagUseImportBlocks:
+		return "`--use-import-blocks` is specified"
+	case flag.flagSplitBy != "" || flag.flagModuleTemplate != "":
+		return "the config is split into child modules"
+	case flag.flagPrune:
+		return "`--prune` is specified"
+	}
+	return ""
+}
+
+// runOptions returns the options of the run in the mode.
+func (flag FlagSet) runOptions(mode string) runOptions {
+	return runOptions{
+		batch:              flag.flagNonInteractive,
+		mockMeta:           flag.hflagMockClient,
+		plainUI:            flag.flagPlainUI,
+		accessible:         flag.flagAccessible,
+		genMappingFileOnly: flag.flagGenerateMappingFile,
+		costEstimate:       flag.flagCostEstimate,
+		verify:             flag.flagVerify,
+		reportMarkdown:     flag.flagReportMarkdown,
+		pulumiLanguage:     flag.flagPulumiConvert,
+		outputFormat:       flag.flagOutputFormat,
+		chunkSize:          flag.flagChunkSize,
+		resume:             flag.flagResume,
+		maxResources:       flag.flagMaxResources,
+		maxDuration:        flag.flagMaxDuration,
+		dryRunOutput:       flag.flagDryRunOutput,
+		profileDir:         flag.profileDir(),
+		effectiveCLI:       flag.DescribeCLI(mode),
+		provenance:         flag.ProvenanceOption(),
+	}
+}
+
+// profileDir returns the directory to write the profiles to, which is empty if not profiling.
+// The deprecated `--profile` (i.e. "cpu" or "mem") writes the profiles to the current directory, as it used to.
+func (flag FlagSet) profileDir() string {
+	if flag.flagProfileDir != "" {
+		return flag.flagProfileDir
+	}
+	switch strings.ToLower(flag.hflagProfile) {
+	case "cpu", "mem", "memory":
+		return "."
+	}
+	return ""
+}
+
+// ProvenanceOption returns the provenance option of the run.
+func (flag FlagSet) ProvenanceOption() provenance.Option {
+	return provenance.Option{
+		Enabled: flag.flagProvenance,
+		Signer:  flag.flagProvenanceSign,
+		SignKey: flag.flagProvenanceSignKey,
+	}
+}
+
+// parseTags converts the "key=value" tags to a map, which is nil if there is no tag.
+func parseTags(tags []string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	m := map[string]string{}
+	for _, tag := range tags {
+		k, v, _ := strings.Cut(tag, "=")
+		m[k] = v
+	}
+	return m
+}
+
+// parseCommandHooks converts the "phase=command" hooks to the command hooks.
+func parseCommandHooks(hooks []string) []config.CommandHook {
+	var out []config.CommandHook
+	for _, hook := range hooks {
+		phase, command, _ := strings.Cut(hook, "=")
+		out = append(out, config.CommandHook{Phase: phase, Command: command})
+	}
+	return out
+}
+
+// additionalSubscriptionIds returns the subscription ids specified after the first one, which is nil if there is only one.
+func (flag FlagSet) additionalSubscriptionIds() []string {
+	ids := flag.flagSubscriptionIds.Value()
+	if len(ids) <= 1 {
+		return nil
+	}
+	return ids[1:]
+}
+
+// scaffoldEnvironment returns the cloud environment recorded in the auth scaffold, which is empty for the custom cloud, as it is set via the "metadata_host" of the provider.
+func (flag FlagSet) scaffoldEnvironment() string {
+	env, err := normalizeCloudEnv(flag.flagEnv)
+	if err != nil || env == cloudEnvCustom {
+		return ""
+	}
+	return env
+}
+
+// authMethod returns the authentication method used to build the Azure SDK credential.
+func (flag FlagSet) authMethod() string {
+	switch {
+	case flag.flagUseEnvironmentCred:
+		return meta.AuthMethodEnvironment
+	case flag.flagUseManagedIdentityCred:
+		return meta.AuthMethodManagedIdentity
+	case flag.flagUseAzureCLICred:
+		return meta.AuthMethodAzureCLI
+	case flag.flagUseOIDCCred:
+		return meta.AuthMethodOIDC
+	case flag.flagUseWorkloadIdentityCred:
+		return meta.AuthMethodWorkloadIdentity
+	default:
+		// The credential of the chain is only chosen at runtime
+		return meta.AuthMethodDefault
+	}
+}
+
+// credentialName returns the name of the credential specified by the "--use-*-cred" flags.
+func (flag FlagSet) credentialName() string {
+	switch {
+	case flag.flagUseEnvironmentCred:
+		return credEnvironment
+	case flag.flagUseManagedIdentityCred:
+		return credManagedIdentity
+	case flag.flagUseAzureCLICred:
+		return credAzureCLI
+	case flag.flagUseOIDCCred:
+		return credOIDC
+	case flag.flagUseWorkloadIdentityCred:
+		return credWorkloadIdentity
+	default:
+		return credDefault
+	}
+}
+
+// onSecret returns the action to take on the secrets found in the generated config, where "--redact-secrets" is a shorthand of "--on-secret=redact".
+func (flag FlagSet) onSecret() string {
+	if flag.flagRedactSecrets && flag.flagOnSecret == "" {
+		return meta.OnSecretRedact
+	}
+	return flag.flagOnSecret
+}
+
+// cacheDir returns the directory of the config generation cache, which defaults to the "aztfexport" directory under the user cache directory.
+// The cache is disabled by `--no-cache`, and when the traffic is recorded or replayed, as the cached responses would be missing from the recording.
+func (flag FlagSet) cacheDir() string {
+	if flag.flagNoCache || flag.flagRecord != "" || flag.flagReplay != "" {
+		return ""
+	}
+	if flag.flagCacheDir != "" {
+		return flag.flagCacheDir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "aztfexport")
+}
+
+// queryFilterArgs describes the flags that build the ARG where predicate, which are shared by the modes that query by the predicate.
+func (flag FlagSet) queryFilterArgs() []string {
+	var args []string
+	if v := flag.flagQueryTypes.Value(); len(v) != 0 {
+		args = append(args, "--type="+strings.Join(v, ","))
+	}
+	if v := flag.flagQueryLocations.Value(); len(v) != 0 {
+		args = append(args, "--location="+strings.Join(v, ","))
+	}
+	if flag.flagCreatedAfter != "" {
+		args = append(args, "--created-after="+flag.flagCreatedAfter)
+	}
+	if flag.flagCreatedBefore != "" {
+		args = append(args, "--created-before="+flag.flagCreatedBefore)
+	}
+	if flag.flagNameRegex != "" {
+		args = append(args, "--name-regex="+flag.flagNameRegex)
+	}
+	return args
+}
+
+// argFilter returns the filter that is built into the ARG where predicate.
+func (flag FlagSet) argFilter() meta.ARGFilter {
+	return meta.ARGFilter{
+		Types:         flag.flagQueryTypes.Value(),
+		Locations:     flag.flagQueryLocations.Value(),
+		CreatedAfter:  flag.flagCreatedAfter,
+		CreatedBefore: flag.flagCreatedBefore,
+		NameRegex:     flag.flagNameRegex,
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTFClientFallbackReason(t *testing.T) {
+	cases := []struct {
+		name   string
+		fset   FlagSet
+		reason string
+	}{
+		{
+			name: "local backend",
+			fset: FlagSet{flagBackendType: "local"},
+		},
+		{
+			name:   "non-local backend",
+			fset:   FlagSet{flagBackendType: "azurerm"},
+			reason: "the azurerm backend is used",
+		},
+		{
+			name: "non-local backend with --hcl-only",
+			fset: FlagSet{flagBackendType: "azurerm", flagHCLOnly: true},
+		},
+		{
+			name:   "--module-path",
+			fset:   FlagSet{flagBackendType: "local", flagModulePath: "mod"},
+			reason: "`--module-path` is specified",
+		},
+		{
+			name:   "--use-import-blocks",
+			fset:   FlagSet{flagBackendType: "local", flagUseImportBlocks: true},
+			reason: "`--use-import-blocks` is specified",
+		},
+		{
+			name:   "--split-by",
+			fset:   FlagSet{flagBackendType: "local", flagSplitBy: "rg"},
+			reason: "the config is split into child modules",
+		},
+		{
+			name:   "--prune",
+			fset:   FlagSet{flagBackendType: "local", flagPrune: true},
+			reason: "`--prune` is specified",
+		},
+		{
+			name:   "--workspace",
+			fset:   FlagSet{flagBackendType: "local", flagWorkspace: "dev"},
+			reason: "`--workspace` is specified",
+		},
+		{
+			name:   "--workspace with --hcl-only",
+			fset:   FlagSet{flagBackendType: "local", flagWorkspace: "dev", flagHCLOnly: true},
+			reason: "`--workspace` is specified",
+		},
+		{
+			name:   "--local-then-migrate",
+			fset:   FlagSet{flagBackendType: "azurerm", flagLocalThenMigrate: true},
+			reason: "`--local-then-migrate` is specified",
+		},
+		{
+			name:   "--azapi-fallback",
+			fset:   FlagSet{flagBackendType: "local", flagAzAPIFallback: true},
+			reason: "`--azapi-fallback` is specified",
+		},
+		{
+			name:   "--azapi-fallback with --hcl-only",
+			fset:   FlagSet{flagBackendType: "local", flagAzAPIFallback: true, flagHCLOnly: true},
+			reason: "`--azapi-fallback` is specified",
+		},
+		{
+			name:   "--hold-backend-lock",
+			fset:   FlagSet{flagBackendType: "azurerm", flagHoldBackendLock: true},
+			reason: "`--hold-backend-lock` is specified",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.reason, tt.fset.tfclientFallbackReason())
+		})
+	}
+}
+
+func TestProfileDir(t *testing.T) {
+	require.Equal(t, "", FlagSet{}.profileDir())
+	require.Equal(t, "profile", FlagSet{flagProfileDir: "profile"}.profileDir())
+	// The deprecated --profile writes the profiles to the current directory.
+	require.Equal(t, ".", FlagSet{hflagProfile: "cpu"}.profileDir())
+	require.Equal(t, ".", FlagSet{hflagProfile: "MEM"}.profileDir())
+	require.Equal(t, "", FlagSet{hflagProfile: "foo"}.profileDir())
+}
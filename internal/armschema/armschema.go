@@ -2,6 +2,8 @@ package armschema
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
@@ -26,3 +28,32 @@ func GetARMSchemas() (map[string][]string, error) {
 	return armSchemas, nil
 
 }
+
+// LatestAPIVersion returns the latest API version of the specified Azure resource type (e.g. "Microsoft.Compute/virtualMachines").
+// The stable API versions are preferred over the preview ones.
+func LatestAPIVersion(rt string) (string, error) {
+	schemas, err := GetARMSchemas()
+	if err != nil {
+		return "", err
+	}
+	version, ok := latestAPIVersion(schemas[strings.ToUpper(rt)])
+	if !ok {
+		return "", fmt.Errorf("no API version found for %s", rt)
+	}
+	return version, nil
+}
+
+// latestAPIVersion returns the latest stable version of the versions, or the latest preview one if there is no stable version.
+func latestAPIVersion(versions []string) (string, bool) {
+	if len(versions) == 0 {
+		return "", false
+	}
+	versions = append([]string{}, versions...)
+	sort.Strings(versions)
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !strings.Contains(versions[i], "preview") {
+			return versions[i], true
+		}
+	}
+	return versions[len(versions)-1], true
+}
@@ -0,0 +1,46 @@
+package armschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestAPIVersion(t *testing.T) {
+	cases := []struct {
+		name     string
+		versions []string
+		version  string
+		ok       bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:     "stable only",
+			versions: []string{"2021-03-01", "2022-09-01", "2020-06-01"},
+			version:  "2022-09-01",
+			ok:       true,
+		},
+		{
+			name:     "preview only",
+			versions: []string{"2022-01-01-preview", "2023-05-01-preview", "2021-06-01-preview"},
+			version:  "2023-05-01-preview",
+			ok:       true,
+		},
+		{
+			name:     "mixed with a newer preview",
+			versions: []string{"2023-05-01-preview", "2021-03-01", "2022-09-01", "2022-01-01-preview"},
+			version:  "2022-09-01",
+			ok:       true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			version, ok := latestAPIVersion(tt.versions)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.version, version)
+		})
+	}
+}
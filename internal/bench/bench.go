@@ -0,0 +1,162 @@
+// Package bench measures the duration and throughput of each phase of an export, which helps users to tune the parallelism
+// and maintainers to track the performance regressions.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	internalmeta "github.com/Azure/aztfexport/internal/meta"
+	"github.com/Azure/aztfexport/pkg/meta"
+)
+
+const (
+	PhaseInit      = "init"
+	PhaseList      = "list"
+	PhaseImport    = "import"
+	PhasePushState = "push state"
+	PhaseGenerate  = "generate"
+)
+
+type Config struct {
+	Parallelism int
+	// MockImport skips importing the resources, which also skips the phases that depend on the imported state (i.e. push state and generate).
+	MockImport bool
+}
+
+type Phase struct {
+	Name     string
+	Duration time.Duration
+	// Resources is the number of the resources processed in this phase, zero means not applicable.
+	Resources int
+	Skipped   bool
+}
+
+type Result struct {
+	Config Config
+	Phases []Phase
+	// ImportBatches are the durations of the import batches, each of which imports at most Parallelism resources in parallel.
+	ImportBatches []time.Duration
+	// ImportErrors is the number of the resources that failed to import.
+	ImportErrors int
+}
+
+// Run runs the phases of an export against the meta, and measures them.
+func Run(ctx context.Context, c meta.Meta, cfg Config) (*Result, error) {
+	result := &Result{Config: cfg}
+
+	start := time.Now()
+	if err := c.Init(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		// #nosec G104
+		c.DeInit(ctx)
+	}()
+	result.Phases = append(result.Phases, Phase{Name: PhaseInit, Duration: time.Since(start)})
+
+	start = time.Now()
+	list, err := c.ListResource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Phases = append(result.Phases, Phase{Name: PhaseList, Duration: time.Since(start), Resources: len(list)})
+
+	var items []*internalmeta.ImportItem
+	for i := range list {
+		if list[i].Skip() {
+			continue
+		}
+		items = append(items, &list[i])
+	}
+
+	if cfg.MockImport {
+		result.Phases = append(result.Phases,
+			Phase{Name: PhaseImport, Resources: len(items), Skipped: true},
+			Phase{Name: PhasePushState, Skipped: true},
+			Phase{Name: PhaseGenerate, Resources: len(items), Skipped: true},
+		)
+		return result, nil
+	}
+
+	start = time.Now()
+	for i := 0; i < len(items); i += cfg.Parallelism {
+		n := cfg.Parallelism
+		if i+n > len(items) {
+			n = len(items) - i
+		}
+		batchStart := time.Now()
+		if err := c.ParallelImport(ctx, items[i:i+n]); err != nil {
+			return nil, fmt.Errorf("parallel importing: %v", err)
+		}
+		result.ImportBatches = append(result.ImportBatches, time.Since(batchStart))
+	}
+	result.Phases = append(result.Phases, Phase{Name: PhaseImport, Duration: time.Since(start), Resources: len(items)})
+	for _, item := range items {
+		if item.ImportError != nil {
+			result.ImportErrors++
+		}
+	}
+
+	start = time.Now()
+	if err := c.PushState(ctx); err != nil {
+		return nil, fmt.Errorf("failed to push state: %v", err)
+	}
+	result.Phases = append(result.Phases, Phase{Name: PhasePushState, Duration: time.Since(start)})
+
+	start = time.Now()
+	if err := c.GenerateCfg(ctx, list); err != nil {
+		return nil, fmt.Errorf("generating Terraform configuration: %v", err)
+	}
+	result.Phases = append(result.Phases, Phase{Name: PhaseGenerate, Duration: time.Since(start), Resources: len(list.Imported())})
+
+	return result, nil
+}
+
+// Write prints the breakdown of the result.
+func (r Result) Write(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	// #nosec G104
+	fmt.Fprintln(tw, "PHASE\tDURATION\tRESOURCES\tRESOURCES/S")
+	var total time.Duration
+	for _, p := range r.Phases {
+		total += p.Duration
+		duration, resources, throughput := "(skipped)", "-", "-"
+		if !p.Skipped {
+			duration = p.Duration.Round(time.Millisecond).String()
+		}
+		if p.Resources != 0 {
+			resources = fmt.Sprint(p.Resources)
+			if !p.Skipped && p.Duration > 0 {
+				throughput = fmt.Sprintf("%.2f", float64(p.Resources)/p.Duration.Seconds())
+			}
+		}
+		// #nosec G104
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.Name, duration, resources, throughput)
+	}
+	// #nosec G104
+	fmt.Fprintf(tw, "total\t%s\n", total.Round(time.Millisecond))
+	// #nosec G104
+	tw.Flush()
+
+	if len(r.ImportBatches) == 0 {
+		return
+	}
+	var sum, slowest time.Duration
+	for _, d := range r.ImportBatches {
+		sum += d
+		if d > slowest {
+			slowest = d
+		}
+	}
+	avg := sum / time.Duration(len(r.ImportBatches))
+	// #nosec G104
+	fmt.Fprintf(w, "\nImport batches: %d (parallelism %d), average %s, slowest %s\n", len(r.ImportBatches), r.Config.Parallelism, avg.Round(time.Millisecond), slowest.Round(time.Millisecond))
+	if r.ImportErrors != 0 {
+		// #nosec G104
+		fmt.Fprintf(w, "Import errors: %d\n", r.ImportErrors)
+	}
+}
@@ -0,0 +1,60 @@
+package bench
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultWrite(t *testing.T) {
+	r := Result{
+		Config: Config{Parallelism: 2},
+		Phases: []Phase{
+			{Name: PhaseInit, Duration: 2 * time.Second},
+			{Name: PhaseList, Duration: time.Second, Resources: 4},
+			{Name: PhaseImport, Duration: 4 * time.Second, Resources: 3},
+			{Name: PhasePushState, Duration: 500 * time.Millisecond},
+			{Name: PhaseGenerate, Duration: 1500 * time.Millisecond, Resources: 3},
+		},
+		ImportBatches: []time.Duration{3 * time.Second, time.Second},
+		ImportErrors:  1,
+	}
+	var buf bytes.Buffer
+	r.Write(&buf)
+	require.Equal(t, `PHASE       DURATION  RESOURCES  RESOURCES/S
+init        2s        -          -
+list        1s        4          4.00
+import      4s        3          0.75
+push state  500ms     -          -
+generate    1.5s      3          2.00
+total       9s
+
+Import batches: 2 (parallelism 2), average 2s, slowest 3s
+Import errors: 1
+`, buf.String())
+}
+
+func TestResultWriteMockImport(t *testing.T) {
+	r := Result{
+		Config: Config{Parallelism: 2, MockImport: true},
+		Phases: []Phase{
+			{Name: PhaseInit, Duration: 2 * time.Second},
+			{Name: PhaseList, Duration: time.Second, Resources: 4},
+			{Name: PhaseImport, Resources: 3, Skipped: true},
+			{Name: PhasePushState, Skipped: true},
+			{Name: PhaseGenerate, Resources: 3, Skipped: true},
+		},
+	}
+	var buf bytes.Buffer
+	r.Write(&buf)
+	require.Equal(t, `PHASE       DURATION   RESOURCES  RESOURCES/S
+init        2s         -          -
+list        1s         4          4.00
+import      (skipped)  3          -
+push state  (skipped)  -          -
+generate    (skipped)  3          -
+total       3s
+`, buf.String())
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/Azure/aztfexport/internal/i18n"
+	"github.com/Azure/aztfexport/pkg/meta"
+)
+
+// runBudget is the safeguard that stops importing gracefully, once the run has imported the maximum number of resources, or has lasted for the maximum duration.
+// The resources that are not attempted are left to the next run, which resumes from the checkpoint.
+type runBudget struct {
+	// maxResources is the maximum number of the resources to import, zero means no limit
+	maxResources int
+	// maxDuration is the maximum duration of the run, zero means no limit
+	maxDuration time.Duration
+	start       time.Time
+	used        int
+}
+
+func newRunBudget(maxResources int, maxDuration time.Duration, start time.Time) *runBudget {
+	return &runBudget{maxResources: maxResources, maxDuration: maxDuration, start: start}
+}
+
+// take takes a resource to import from the budget. It returns the reason if the budget is exhausted, in which case the resource is not taken.
+func (b *runBudget) take(now time.Time) string {
+	switch {
+	case b.maxResources > 0 && b.used >= b.maxResources:
+		return i18n.Sprintf("the maximum number of resources (%d) is reached", b.maxResources)
+	case b.maxDuration > 0 && now.Sub(b.start) >= b.maxDuration:
+		return i18n.Sprintf("the maximum duration (%s) is reached", b.maxDuration)
+	}
+	b.used++
+	return ""
+}
+
+// remainingItems returns the resources that are neither skipped, nor attempted.
+func remainingItems(l meta.ImportList) meta.ImportList {
+	var out meta.ImportList
+	for _, item := range l {
+		if item.Skip() || item.Imported || item.ImportError != nil {
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
+}
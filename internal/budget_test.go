@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBudget(t *testing.T) {
+	start := time.Now()
+
+	b := newRunBudget(0, 0, start)
+	for i := 0; i < 10; i++ {
+		require.Empty(t, b.take(start.Add(time.Hour)))
+	}
+
+	b = newRunBudget(2, 0, start)
+	require.Empty(t, b.take(start))
+	require.Empty(t, b.take(start))
+	require.Equal(t, "the maximum number of resources (2) is reached", b.take(start))
+
+	b = newRunBudget(0, time.Minute, start)
+	require.Empty(t, b.take(start.Add(time.Second)))
+	require.Equal(t, "the maximum duration (1m0s) is reached", b.take(start.Add(time.Minute)))
+}
+
+func TestRemainingItems(t *testing.T) {
+	item := func(id, tfType, name string) meta.ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return meta.ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}}
+	}
+	imported := item("/subscriptions/123/resourceGroups/rg1", "azurerm_resource_group", "res-0")
+	imported.Imported = true
+	failed := item("/subscriptions/123/resourceGroups/rg2", "azurerm_resource_group", "res-1")
+	failed.ImportError = fmt.Errorf("boom")
+	skipped := item("/subscriptions/123/resourceGroups/rg3", "", "")
+	remaining := item("/subscriptions/123/resourceGroups/rg4", "azurerm_resource_group", "res-3")
+
+	require.Equal(t, meta.ImportList{remaining}, remainingItems(meta.ImportList{imported, failed, skipped, remaining}))
+}
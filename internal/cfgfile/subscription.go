@@ -0,0 +1,54 @@
+package cfgfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// GetSubscriptionIdsFromCLI returns the ids of the subscriptions that the Azure CLI is logged in, with the default one first.
+// The Azure CLI config directory is honored if set via the AZURE_CONFIG_DIR.
+func GetSubscriptionIdsFromCLI() ([]string, error) {
+	dir := os.Getenv("AZURE_CONFIG_DIR")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("retrieving user's HOME dir")
+		}
+		dir = filepath.Join(home, ".azure")
+	}
+	path := filepath.Join(dir, "azureProfile.json")
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %v", path, err)
+	}
+	// Removing the preceding BOM (Byte Order Mark)
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	var f struct {
+		Subscriptions []struct {
+			Id        string `json:"id"`
+			IsDefault bool   `json:"isDefault"`
+		} `json:"subscriptions"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("unmarshalling the file: %v", err)
+	}
+	var ids []string
+	seen := map[string]bool{}
+	for _, sub := range f.Subscriptions {
+		// The same subscription is listed once per tenant and user that it is logged in with.
+		if sub.Id == "" || seen[sub.Id] {
+			continue
+		}
+		seen[sub.Id] = true
+		if sub.IsDefault {
+			ids = append([]string{sub.Id}, ids...)
+			continue
+		}
+		ids = append(ids, sub.Id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,30 @@
+package cfgfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSubscriptionIdsFromCLI(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AZURE_CONFIG_DIR", dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "azureProfile.json"), []byte("\xef\xbb\xbf"+`{
+  "installationId": "00000000-0000-0000-0000-000000000000",
+  "subscriptions": [
+    {"id": "11111111-1111-1111-1111-111111111111", "isDefault": false},
+    {"id": "22222222-2222-2222-2222-222222222222", "isDefault": true},
+    {"id": "11111111-1111-1111-1111-111111111111", "isDefault": false}
+  ]
+}`), 0644))
+
+	ids, err := GetSubscriptionIdsFromCLI()
+	require.NoError(t, err)
+	require.Equal(t, []string{"22222222-2222-2222-2222-222222222222", "11111111-1111-1111-1111-111111111111"}, ids)
+
+	t.Setenv("AZURE_CONFIG_DIR", t.TempDir())
+	_, err = GetSubscriptionIdsFromCLI()
+	require.ErrorContains(t, err, "azureProfile.json")
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+)
+
+// CheckpointFileName is the file under the output directory that records the progress of each resource of the non-interactive run,
+// which is used to resume the run.
+const CheckpointFileName = "aztfexportCheckpoint.json"
+
+// The stages of a resource in the checkpoint, in order.
+const (
+	// StageQueried means the resource is listed, but not resolved to a TF resource type (i.e. skipped).
+	StageQueried = "queried"
+	// StageResolved means the resource is resolved to a TF resource type.
+	StageResolved = "resolved"
+	// StageImported means the resource is imported to the state of the output directory.
+	StageImported = "imported"
+	// StageGenerated means the config of the resource is generated.
+	StageGenerated = "generated"
+)
+
+type CheckpointResource struct {
+	resmap.ResourceMapEntity
+	Stage string `json:"stage"`
+}
+
+type Checkpoint struct {
+	// Resources are the resources of the run, the key is the Azure resource Id in uppercase.
+	Resources map[string]CheckpointResource `json:"resources"`
+}
+
+func newCheckpoint() *Checkpoint {
+	return &Checkpoint{Resources: map[string]CheckpointResource{}}
+}
+
+// ReadCheckpoint reads the checkpoint file under the directory. An empty checkpoint is returned if the file doesn't exist.
+func ReadCheckpoint(dir string) (*Checkpoint, error) {
+	path := filepath.Join(dir, CheckpointFileName)
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return newCheckpoint(), nil
+		}
+		return nil, fmt.Errorf("reading the checkpoint %s: %v", path, err)
+	}
+	cp := newCheckpoint()
+	if err := json.Unmarshal(b, cp); err != nil {
+		return nil, fmt.Errorf("unmarshalling the checkpoint %s: %v", path, err)
+	}
+	return cp, nil
+}
+
+func (cp Checkpoint) write(dir string) error {
+	b, err := json.MarshalIndent(cp, "", "\t")
+	if err != nil {
+		return fmt.Errorf("marshalling the checkpoint: %v", err)
+	}
+	path := filepath.Join(dir, CheckpointFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing the checkpoint to %s: %v", path, err)
+	}
+	return nil
+}
+
+func (cp *Checkpoint) set(item meta.ImportItem, stage string) {
+	cp.Resources[strings.ToUpper(item.AzureResourceID.String())] = CheckpointResource{
+		ResourceMapEntity: resmap.ResourceMapEntity{
+			ResourceId:   item.TFResourceId,
+			ResourceType: item.TFAddr.Type,
+			ResourceName: item.TFAddr.Name,
+			ImportId:     item.ImportId,
+		},
+		Stage: stage,
+	}
+}
+
+func (cp Checkpoint) stage(item meta.ImportItem) string {
+	return cp.Resources[strings.ToUpper(item.AzureResourceID.String())].Stage
+}
+
+// resume builds the checkpoint of the listed resources, which inherits the progress of the previous checkpoint (if any).
+// The resources that are imported (or even generated) in the previous run are marked as imported in the list, with the TF resource address
+// of the previous run, as is in the state. If the state is not populated by the run (i.e. via import blocks), the imported resources are imported again.
+func (prev Checkpoint) resume(l meta.ImportList, statePopulated bool) (*Checkpoint, error) {
+	cp := newCheckpoint()
+	var resumed bool
+	for i := range l {
+		item := &l[i]
+		res, ok := prev.Resources[strings.ToUpper(item.AzureResourceID.String())]
+		switch {
+		case ok && (res.Stage == StageGenerated || res.Stage == StageImported && statePopulated):
+			addr := tfaddr.TFAddr{Type: res.ResourceType, Name: res.ResourceName}
+			item.TFResourceId = res.ResourceId
+			item.ImportId = res.ImportId
+			item.TFAddr = addr
+			item.TFAddrCache = addr
+			item.Imported = true
+			cp.Resources[strings.ToUpper(item.AzureResourceID.String())] = res
+			resumed = true
+		case item.Skip():
+			cp.set(*item, StageQueried)
+		default:
+			cp.set(*item, StageResolved)
+		}
+	}
+	if !resumed {
+		return cp, nil
+	}
+
+	// The newly listed resources might be named the same as the resumed ones, e.g. when new resources are created in between.
+	addrs := map[string]string{}
+	for _, item := range l {
+		if item.Skip() {
+			continue
+		}
+		if id, ok := addrs[item.TFAddr.String()]; ok {
+			return nil, fmt.Errorf("the resources %s and %s are both resolved to %s, please re-run without `--resume`", id, item.AzureResourceID, item.TFAddr)
+		}
+		addrs[item.TFAddr.String()] = item.AzureResourceID.String()
+	}
+	return cp, nil
+}
+
+// pendingGeneration returns the imported resources whose config is not generated yet.
+func (cp Checkpoint) pendingGeneration(l meta.ImportList) meta.ImportList {
+	var out meta.ImportList
+	for _, item := range l {
+		if item.Imported && cp.stage(item) != StageGenerated {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	item := func(id, tfType, name string) meta.ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return meta.ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}}
+	}
+	rg := item("/subscriptions/123/resourceGroups/rg1", "azurerm_resource_group", "res-0")
+	vnet := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1", "azurerm_virtual_network", "res-1")
+	nsg := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkSecurityGroups/nsg1", "azurerm_network_security_group", "res-2")
+	skipped := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/bars/bar1", "", "res-3")
+
+	// The first run
+	l := meta.ImportList{rg, vnet, nsg, skipped}
+	cp, err := newCheckpoint().resume(l, true)
+	require.NoError(t, err)
+	require.Equal(t, StageResolved, cp.stage(l[0]))
+	require.Equal(t, StageQueried, cp.stage(l[3]))
+	l[0].Imported = true
+	l[1].Imported = true
+	for _, item := range cp.pendingGeneration(l) {
+		cp.set(item, StageImported)
+	}
+	cp.set(l[0], StageGenerated)
+
+	dir := t.TempDir()
+	require.NoError(t, cp.write(dir))
+	prev, err := ReadCheckpoint(dir)
+	require.NoError(t, err)
+	require.Equal(t, cp, prev)
+
+	// The resumed run, where the resource group is named differently by the listing
+	rg.TFAddr.Name = "res-9"
+	l = meta.ImportList{rg, vnet, nsg, skipped}
+	cp, err = prev.resume(l, true)
+	require.NoError(t, err)
+	require.True(t, l[0].Imported)
+	require.Equal(t, "res-0", l[0].TFAddr.Name)
+	require.True(t, l[1].Imported)
+	require.False(t, l[2].Imported)
+	require.Equal(t, meta.ImportList{l[1]}, cp.pendingGeneration(l))
+
+	// The imported resources are imported again if the state is not populated by the run
+	l = meta.ImportList{rg, vnet, nsg, skipped}
+	_, err = prev.resume(l, false)
+	require.NoError(t, err)
+	require.True(t, l[0].Imported)
+	require.False(t, l[1].Imported)
+
+	// The newly listed resource conflicts with the resumed one
+	rg2 := item("/subscriptions/123/resourceGroups/rg2", "azurerm_resource_group", "res-0")
+	l = meta.ImportList{rg, rg2, vnet, nsg, skipped}
+	_, err = prev.resume(l, true)
+	require.ErrorContains(t, err, "both resolved to azurerm_resource_group.res-0")
+}
+
+func TestReadCheckpointNotExist(t *testing.T) {
+	cp, err := ReadCheckpoint(t.TempDir())
+	require.NoError(t, err)
+	require.Empty(t, cp.Resources)
+}
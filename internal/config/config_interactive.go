@@ -5,5 +5,10 @@ import "github.com/Azure/aztfexport/pkg/config"
 type InteractiveModeConfig struct {
 	config.Config
 
-	MockMeta bool
+	MockMeta     bool
+	CostEstimate bool
+	// GenMappingFileOnly saves the resource mapping file of the curated import list, in place of importing the resources.
+	GenMappingFileOnly bool
+	// PulumiLanguage is the language of the Pulumi program that the generated TF config will be converted to (experimental). Empty means no conversion.
+	PulumiLanguage string
 }
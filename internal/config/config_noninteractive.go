@@ -1,6 +1,19 @@
 package config
 
-import "github.com/Azure/aztfexport/pkg/config"
+import (
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/config"
+)
+
+const (
+	OutputFormatText = "text"
+	// OutputFormatJSON streams the progress events to the stdout as NDJSON, in place of the human readable messages.
+	OutputFormatJSON = "json"
+)
+
+// OutputFormats are the supported output formats of the non-interactive mode.
+var OutputFormats = []string{OutputFormatText, OutputFormatJSON}
 
 type NonInteractiveModeConfig struct {
 	config.Config
@@ -8,4 +21,25 @@ type NonInteractiveModeConfig struct {
 	MockMeta           bool
 	PlainUI            bool
 	GenMappingFileOnly bool
+	CostEstimate       bool
+	// Verify runs "terraform plan" after the export, and writes the resources whose plan is not empty to the verification report.
+	Verify bool
+	// ChunkSize splits the resources into sequential chunks of this size, each is imported and generated independently, with the state pushed in between. Zero means no chunking.
+	ChunkSize int
+	// Resume resumes the previous run in the output directory from its checkpoint file, skipping the resources that are already exported.
+	Resume bool
+	// MaxResources stops importing gracefully once this number of resources are imported by the run, the rest are left to be resumed. Zero means no limit.
+	MaxResources int
+	// MaxDuration stops importing gracefully once the run lasts for this duration, the rest are left to be resumed. Zero means no limit.
+	MaxDuration time.Duration
+	// DryRunOutput is the file that the mapping of the dry run (see config.CommonConfig.DryRun) is written to. Empty means to print it.
+	DryRunOutput string
+	// OutputFormat is the format of the progress output, either OutputFormatText (the default) or OutputFormatJSON.
+	OutputFormat string
+	// PulumiLanguage is the language of the Pulumi program that the generated TF config will be converted to (experimental). Empty means no conversion.
+	PulumiLanguage string
+	// ToolVersion is the version of aztfexport, which is recorded in the report.
+	ToolVersion string
+	// ReportMarkdown writes the Markdown rendering of the report, in addition to the JSON one.
+	ReportMarkdown bool
 }
@@ -0,0 +1,118 @@
+package costestimate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Estimate is the monthly cost estimate of the Terraform configuration in a directory.
+type Estimate struct {
+	Currency         string
+	TotalMonthlyCost string
+	Resources        []ResourceCost
+}
+
+// ResourceCost is the monthly cost estimate of a single Terraform resource.
+type ResourceCost struct {
+	// The TF resource address
+	Address string
+	// The monthly cost, this is empty if the cost can't be estimated (e.g. usage based, or not supported by infracost).
+	MonthlyCost string
+}
+
+// infracostOutput is the subset of the output of `infracost breakdown --format json` that we care about.
+type infracostOutput struct {
+	Currency         string  `json:"currency"`
+	TotalMonthlyCost *string `json:"totalMonthlyCost"`
+	Projects         []struct {
+		Breakdown struct {
+			Resources []struct {
+				Name        string  `json:"name"`
+				MonthlyCost *string `json:"monthlyCost"`
+			} `json:"resources"`
+		} `json:"breakdown"`
+	} `json:"projects"`
+}
+
+// Run runs the infracost against the Terraform configuration in the specified directory.
+// This requires the "infracost" binary available in the PATH, and has been authenticated (i.e. has an API key configured).
+func Run(ctx context.Context, dir string) (*Estimate, error) {
+	path, err := exec.LookPath("infracost")
+	if err != nil {
+		return nil, fmt.Errorf("finding the infracost executable: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, path, "breakdown", "--path", dir, "--format", "json", "--no-color")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		err = fmt.Errorf("running infracost: %v", err)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	return parse(stdout.Bytes())
+}
+
+func parse(b []byte) (*Estimate, error) {
+	var out infracostOutput
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("unmarshalling the infracost output: %v", err)
+	}
+
+	est := &Estimate{
+		Currency: out.Currency,
+	}
+	if out.TotalMonthlyCost != nil {
+		est.TotalMonthlyCost = *out.TotalMonthlyCost
+	}
+	for _, proj := range out.Projects {
+		for _, res := range proj.Breakdown.Resources {
+			rc := ResourceCost{
+				Address: res.Name,
+			}
+			if res.MonthlyCost != nil {
+				rc.MonthlyCost = *res.MonthlyCost
+			}
+			est.Resources = append(est.Resources, rc)
+		}
+	}
+	sort.Slice(est.Resources, func(i, j int) bool {
+		return est.Resources[i].Address < est.Resources[j].Address
+	})
+	return est, nil
+}
+
+// String returns a human readable table of the estimate.
+func (est Estimate) String() string {
+	width := 0
+	for _, res := range est.Resources {
+		if len(res.Address) > width {
+			width = len(res.Address)
+		}
+	}
+
+	var lines []string
+	for _, res := range est.Resources {
+		cost := res.MonthlyCost
+		if cost == "" {
+			cost = "-"
+		}
+		lines = append(lines, fmt.Sprintf("%-*s  %s", width, res.Address, cost))
+	}
+	total := est.TotalMonthlyCost
+	if total == "" {
+		total = "-"
+	}
+	lines = append(lines, fmt.Sprintf("Total monthly cost (%s): %s", est.Currency, total))
+	return strings.Join(lines, "\n")
+}
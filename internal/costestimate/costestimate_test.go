@@ -0,0 +1,36 @@
+package costestimate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	input := `{
+  "currency": "USD",
+  "totalMonthlyCost": "73.5",
+  "projects": [
+    {
+      "breakdown": {
+        "resources": [
+          {"name": "azurerm_linux_virtual_machine.res-1", "monthlyCost": "70.08"},
+          {"name": "azurerm_resource_group.res-0", "monthlyCost": null},
+          {"name": "azurerm_managed_disk.res-2", "monthlyCost": "3.42"}
+        ]
+      }
+    }
+  ]
+}`
+	est, err := parse([]byte(input))
+	require.NoError(t, err)
+	require.Equal(t, &Estimate{
+		Currency:         "USD",
+		TotalMonthlyCost: "73.5",
+		Resources: []ResourceCost{
+			{Address: "azurerm_linux_virtual_machine.res-1", MonthlyCost: "70.08"},
+			{Address: "azurerm_managed_disk.res-2", MonthlyCost: "3.42"},
+			{Address: "azurerm_resource_group.res-0"},
+		},
+	}, est)
+}
@@ -0,0 +1,262 @@
+// Package diff compares two export outputs of aztfexport.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Resource is a resource that only exists in one of the export outputs.
+type Resource struct {
+	// Id is the Azure resource id, or the TF resource address if the output has no resource mapping file
+	Id      string
+	Address string
+}
+
+// AttributeChange is the change of one (flattened) attribute of a resource.
+// Old is empty if the attribute is added, New is empty if the attribute is removed.
+type AttributeChange struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// ResourceChange is a resource that exists in both of the export outputs, but with different attributes.
+type ResourceChange struct {
+	Id       string
+	AddressA string
+	AddressB string
+
+	Attributes []AttributeChange
+}
+
+type Result struct {
+	Added   []Resource
+	Removed []Resource
+	Changed []ResourceChange
+}
+
+func (r Result) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// Write writes the result in a human readable format.
+func (r Result) Write(w io.Writer) {
+	if r.Empty() {
+		fmt.Fprintln(w, "No difference found")
+		return
+	}
+	for _, res := range r.Added {
+		fmt.Fprintf(w, "+ %s (%s)\n", res.Id, res.Address)
+	}
+	for _, res := range r.Removed {
+		fmt.Fprintf(w, "- %s (%s)\n", res.Id, res.Address)
+	}
+	for _, res := range r.Changed {
+		addr := res.AddressA
+		if res.AddressA != res.AddressB {
+			addr = res.AddressA + " -> " + res.AddressB
+		}
+		fmt.Fprintf(w, "~ %s (%s)\n", res.Id, addr)
+		for _, attr := range res.Attributes {
+			switch {
+			case attr.Old == "":
+				fmt.Fprintf(w, "    + %s = %s\n", attr.Name, attr.New)
+			case attr.New == "":
+				fmt.Fprintf(w, "    - %s = %s\n", attr.Name, attr.Old)
+			default:
+				fmt.Fprintf(w, "    ~ %s = %s -> %s\n", attr.Name, attr.Old, attr.New)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d added, %d removed, %d changed\n", len(r.Added), len(r.Removed), len(r.Changed))
+}
+
+// Diff compares the export outputs in dirA and dirB, where dirA is regarded as the older one.
+// The resources are matched by their Azure resource ids recorded in the resource mapping files, so that the changes of the TF resource names between the exports are not regarded as differences.
+func Diff(dirA, dirB string) (*Result, error) {
+	outA, err := load(dirA)
+	if err != nil {
+		return nil, err
+	}
+	outB, err := load(dirB)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Result
+	for _, key := range sortedKeys(outB.resources) {
+		resB := outB.resources[key]
+		resA, ok := outA.resources[key]
+		if !ok {
+			result.Added = append(result.Added, Resource{Id: resB.id, Address: resB.address})
+			continue
+		}
+		if changes := diffAttributes(resA.attributes, resB.attributes); len(changes) != 0 {
+			result.Changed = append(result.Changed, ResourceChange{
+				Id:         resB.id,
+				AddressA:   resA.address,
+				AddressB:   resB.address,
+				Attributes: changes,
+			})
+		}
+	}
+	for _, key := range sortedKeys(outA.resources) {
+		if _, ok := outB.resources[key]; !ok {
+			resA := outA.resources[key]
+			result.Removed = append(result.Removed, Resource{Id: resA.id, Address: resA.address})
+		}
+	}
+	return &result, nil
+}
+
+type resource struct {
+	id         string
+	address    string
+	attributes map[string]string
+}
+
+type output struct {
+	// resources is keyed by the uppercased Azure resource id (or the TF resource address)
+	resources map[string]resource
+}
+
+func load(dir string) (*output, error) {
+	// The Azure resource id of each TF resource address, if the resource mapping file exists.
+	ids := map[string]string{}
+	b, err := os.ReadFile(filepath.Join(dir, meta.ResourceMappingFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading the resource mapping file in %s: %v", dir, err)
+	}
+	if err == nil {
+		var m resmap.ResourceMapping
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("unmarshalling the resource mapping file in %s: %v", dir, err)
+		}
+		for id, entity := range m {
+			ids[entity.ResourceType+"."+entity.ResourceName] = id
+		}
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("listing the .tf files in %s: %v", dir, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .tf file found in %s", dir)
+	}
+
+	out := &output{resources: map[string]resource{}}
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %v", path, err)
+		}
+		f, diags := hclwrite.ParseConfig(b, path, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing %s: %s", path, diags.Error())
+		}
+		for _, blk := range f.Body().Blocks() {
+			if blk.Type() != "resource" || len(blk.Labels()) != 2 {
+				continue
+			}
+			addr := blk.Labels()[0] + "." + blk.Labels()[1]
+			id, ok := ids[addr]
+			if !ok {
+				id = addr
+			}
+			attrs := map[string]string{}
+			flattenBody(attrs, "", blk.Body(), ids)
+			out.resources[strings.ToUpper(id)] = resource{
+				id:         id,
+				address:    addr,
+				attributes: attrs,
+			}
+		}
+	}
+	return out, nil
+}
+
+// flattenBody flattens the attributes of the body (including the nested blocks) to the attrs, keyed by the attribute path (e.g. "identity.0.type").
+func flattenBody(attrs map[string]string, prefix string, body *hclwrite.Body, ids map[string]string) {
+	for name, attr := range body.Attributes() {
+		attrs[prefix+name] = normalizeExpr(attr.Expr().BuildTokens(nil), ids)
+	}
+	counts := map[string]int{}
+	for _, blk := range body.Blocks() {
+		// The lifecycle and timeouts blocks are not the properties of the Azure resource
+		if prefix == "" && (blk.Type() == "lifecycle" || blk.Type() == "timeouts") {
+			continue
+		}
+		idx := counts[blk.Type()]
+		counts[blk.Type()]++
+		flattenBody(attrs, prefix+blk.Type()+"."+strconv.Itoa(idx)+".", blk.Body(), ids)
+	}
+}
+
+// normalizeExpr renders the expression tokens, with the references to the other exported resources replaced by their Azure resource ids.
+// This makes the references comparable between exports, where the same resource can have different TF resource names.
+func normalizeExpr(tokens hclwrite.Tokens, ids map[string]string) string {
+	var sb strings.Builder
+	// Multi-line expressions are rendered in one line
+	newline := false
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.Type == hclsyntax.TokenNewline {
+			newline = true
+			continue
+		}
+		if tok.SpacesBefore != 0 || newline {
+			sb.WriteString(" ")
+		}
+		newline = false
+		if tok.Type == hclsyntax.TokenIdent && i+2 < len(tokens) &&
+			tokens[i+1].Type == hclsyntax.TokenDot && tokens[i+2].Type == hclsyntax.TokenIdent {
+			if id, ok := ids[string(tok.Bytes)+"."+string(tokens[i+2].Bytes)]; ok {
+				sb.WriteString("<" + strings.ToUpper(id) + ">")
+				i += 2
+				continue
+			}
+		}
+		sb.Write(tok.Bytes)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func diffAttributes(a, b map[string]string) []AttributeChange {
+	names := map[string]bool{}
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+	var changes []AttributeChange
+	for _, name := range sortedKeys(names) {
+		if a[name] != b[name] {
+			changes = append(changes, AttributeChange{Name: name, Old: a[name], New: b[name]})
+		}
+	}
+	return changes
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
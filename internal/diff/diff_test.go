@@ -0,0 +1,94 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func writeOutput(t *testing.T, mapping, config string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, meta.ResourceMappingFileName), []byte(mapping), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0644))
+	return dir
+}
+
+func TestDiff(t *testing.T) {
+	dirA := writeOutput(t, `{
+  "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG": {"resource_id": "/subscriptions/123/resourceGroups/rg", "resource_type": "azurerm_resource_group", "resource_name": "res-0"},
+  "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.NETWORK/VIRTUALNETWORKS/VNET": {"resource_id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", "resource_type": "azurerm_virtual_network", "resource_name": "res-1"},
+  "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.COMPUTE/DISKS/DISK": {"resource_id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/disks/disk", "resource_type": "azurerm_managed_disk", "resource_name": "res-2"}
+}`, `
+resource "azurerm_resource_group" "res-0" {
+  location = "westus"
+  name     = "rg"
+}
+resource "azurerm_virtual_network" "res-1" {
+  address_space       = ["10.0.0.0/16"]
+  location            = "westus"
+  name                = "vnet"
+  resource_group_name = azurerm_resource_group.res-0.name
+}
+resource "azurerm_managed_disk" "res-2" {
+  name                = "disk"
+  resource_group_name = azurerm_resource_group.res-0.name
+}
+`)
+	dirB := writeOutput(t, `{
+  "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG": {"resource_id": "/subscriptions/123/resourceGroups/rg", "resource_type": "azurerm_resource_group", "resource_name": "res-0"},
+  "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.STORAGE/STORAGEACCOUNTS/SA": {"resource_id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa", "resource_type": "azurerm_storage_account", "resource_name": "res-1"},
+  "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.NETWORK/VIRTUALNETWORKS/VNET": {"resource_id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", "resource_type": "azurerm_virtual_network", "resource_name": "res-2"}
+}`, `
+resource "azurerm_resource_group" "res-0" {
+  location = "westus"
+  name     = "rg"
+}
+resource "azurerm_storage_account" "res-1" {
+  name                = "sa"
+  resource_group_name = azurerm_resource_group.res-0.name
+}
+resource "azurerm_virtual_network" "res-2" {
+  address_space       = [
+    "10.0.0.0/16",
+    "10.1.0.0/16",
+  ]
+  location            = "westus"
+  name                = "vnet"
+  resource_group_name = azurerm_resource_group.res-0.name
+  tags = {
+    env = "prod"
+  }
+}
+`)
+
+	result, err := Diff(dirA, dirB)
+	require.NoError(t, err)
+	require.Equal(t, &Result{
+		Added: []Resource{
+			{
+				Id:      "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.STORAGE/STORAGEACCOUNTS/SA",
+				Address: "azurerm_storage_account.res-1",
+			},
+		},
+		Removed: []Resource{
+			{
+				Id:      "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.COMPUTE/DISKS/DISK",
+				Address: "azurerm_managed_disk.res-2",
+			},
+		},
+		Changed: []ResourceChange{
+			{
+				Id:       "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.NETWORK/VIRTUALNETWORKS/VNET",
+				AddressA: "azurerm_virtual_network.res-1",
+				AddressB: "azurerm_virtual_network.res-2",
+				Attributes: []AttributeChange{
+					{Name: "address_space", Old: `["10.0.0.0/16"]`, New: `[ "10.0.0.0/16", "10.1.0.0/16", ]`},
+					{Name: "tags", New: `{ env = "prod" }`},
+				},
+			},
+		},
+	}, result)
+}
@@ -0,0 +1,123 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/magodo/armid"
+)
+
+// LiveResult is the comparison of the resources in Azure (within the scope) with the ones in the state of an export output.
+type LiveResult struct {
+	// AzureOnly are the ids of the resources in Azure that are not in the state
+	AzureOnly []string
+	// StateOnly are the resources in the state that are not in Azure, which are either deleted or moved out of the scope
+	StateOnly []Resource
+	// Both are the resources that are both in Azure and in the state
+	Both []Resource
+}
+
+// Write writes the result in a human readable format.
+func (r LiveResult) Write(w io.Writer) {
+	fmt.Fprintf(w, "In Azure, not in the state (%d):\n", len(r.AzureOnly))
+	for _, id := range r.AzureOnly {
+		fmt.Fprintf(w, "+ %s\n", id)
+	}
+	fmt.Fprintf(w, "\nIn the state, not in Azure (%d):\n", len(r.StateOnly))
+	for _, res := range r.StateOnly {
+		fmt.Fprintf(w, "- %s (%s)\n", res.Id, res.Address)
+	}
+	fmt.Fprintf(w, "\nIn both (%d):\n", len(r.Both))
+	for _, res := range r.Both {
+		fmt.Fprintf(w, "= %s (%s)\n", res.Id, res.Address)
+	}
+}
+
+// DiffLive compares the ids of the resources in Azure with the resources in the state of the export output in dir, which is expected to be initialized.
+// The state is read via "terraform show", so that the remote backends are supported.
+func DiffLive(ctx context.Context, dir string, ids []string) (*LiveResult, error) {
+	execPath, err := meta.FindTerraform(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("finding the terraform executable: %v", err)
+	}
+	tf, err := tfexec.NewTerraform(dir, execPath)
+	if err != nil {
+		return nil, fmt.Errorf("new terraform: %v", err)
+	}
+	st, err := tf.Show(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("showing the state: %v", err)
+	}
+	result := compareLive(ids, stateResources(st))
+	return &result, nil
+}
+
+// stateResources returns the managed resources in the state, whose ids are Azure resource ids.
+// The resources whose TF resource id isn't an Azure resource id (e.g. the association resources) are skipped.
+func stateResources(st *tfjson.State) []Resource {
+	if st == nil || st.Values == nil || st.Values.RootModule == nil {
+		return nil
+	}
+	var out []Resource
+	var walk func(module *tfjson.StateModule)
+	walk = func(module *tfjson.StateModule) {
+		for _, res := range module.Resources {
+			if res.Mode != tfjson.ManagedResourceMode {
+				continue
+			}
+			id, ok := res.AttributeValues["id"].(string)
+			if !ok {
+				continue
+			}
+			if _, err := armid.ParseResourceId(id); err != nil {
+				continue
+			}
+			out = append(out, Resource{Id: id, Address: res.Address})
+		}
+		for _, child := range module.ChildModules {
+			walk(child)
+		}
+	}
+	walk(st.Values.RootModule)
+	return out
+}
+
+// compareLive compares the ids of the resources in Azure with the resources in the state, case insensitively.
+func compareLive(ids []string, resources []Resource) LiveResult {
+	live := map[string]bool{}
+	for _, id := range ids {
+		live[strings.ToUpper(id)] = true
+	}
+	managed := map[string]bool{}
+	var result LiveResult
+	for _, res := range resources {
+		managed[strings.ToUpper(res.Id)] = true
+		if live[strings.ToUpper(res.Id)] {
+			result.Both = append(result.Both, res)
+		} else {
+			result.StateOnly = append(result.StateOnly, res)
+		}
+	}
+	for _, id := range ids {
+		if managed[strings.ToUpper(id)] {
+			continue
+		}
+		// Avoid reporting the same resource twice, as the ids can differ in casing
+		managed[strings.ToUpper(id)] = true
+		result.AzureOnly = append(result.AzureOnly, id)
+	}
+	sort.Strings(result.AzureOnly)
+	sort.Slice(result.StateOnly, func(i, j int) bool {
+		return result.StateOnly[i].Address < result.StateOnly[j].Address
+	})
+	sort.Slice(result.Both, func(i, j int) bool {
+		return result.Both[i].Address < result.Both[j].Address
+	})
+	return result
+}
@@ -0,0 +1,75 @@
+package diff
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateResources(t *testing.T) {
+	st := &tfjson.State{
+		Values: &tfjson.StateValues{
+			RootModule: &tfjson.StateModule{
+				Resources: []*tfjson.StateResource{
+					{
+						Address:         "azurerm_resource_group.res-0",
+						Mode:            tfjson.ManagedResourceMode,
+						AttributeValues: map[string]interface{}{"id": "/subscriptions/123/resourceGroups/rg"},
+					},
+					{
+						Address:         "data.azurerm_client_config.current",
+						Mode:            tfjson.DataResourceMode,
+						AttributeValues: map[string]interface{}{"id": "/subscriptions/123/resourceGroups/rg"},
+					},
+					{
+						Address:         "azurerm_subnet_network_security_group_association.res-1",
+						Mode:            tfjson.ManagedResourceMode,
+						AttributeValues: map[string]interface{}{"id": "foo|bar"},
+					},
+				},
+				ChildModules: []*tfjson.StateModule{
+					{
+						Resources: []*tfjson.StateResource{
+							{
+								Address:         "module.net.azurerm_virtual_network.res-0",
+								Mode:            tfjson.ManagedResourceMode,
+								AttributeValues: map[string]interface{}{"id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	require.Equal(t, []Resource{
+		{Id: "/subscriptions/123/resourceGroups/rg", Address: "azurerm_resource_group.res-0"},
+		{Id: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", Address: "module.net.azurerm_virtual_network.res-0"},
+	}, stateResources(st))
+	require.Nil(t, stateResources(&tfjson.State{}))
+}
+
+func TestCompareLive(t *testing.T) {
+	ids := []string{
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa",
+		"/subscriptions/123/resourceGroups/RG",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/disks/disk",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/disks/DISK",
+	}
+	resources := []Resource{
+		{Id: "/subscriptions/123/resourceGroups/rg", Address: "azurerm_resource_group.res-0"},
+		{Id: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", Address: "azurerm_virtual_network.res-1"},
+	}
+	require.Equal(t, LiveResult{
+		AzureOnly: []string{
+			"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/disks/disk",
+			"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa",
+		},
+		StateOnly: []Resource{
+			{Id: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", Address: "azurerm_virtual_network.res-1"},
+		},
+		Both: []Resource{
+			{Id: "/subscriptions/123/resourceGroups/rg", Address: "azurerm_resource_group.res-0"},
+		},
+	}, compareLive(ids, resources))
+}
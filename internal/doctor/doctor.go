@@ -0,0 +1,334 @@
+// Package doctor diagnoses the environment of aztfexport (e.g. the terraform executable, the Azure credential and permissions, the output directory),
+// so that the problems are found before a run, rather than in the middle of it.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/utils"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	"github.com/magodo/azlist/azlist"
+)
+
+type Status string
+
+const (
+	StatusOK   Status = "OK"
+	StatusWarn Status = "WARN"
+	StatusFail Status = "FAIL"
+	// StatusSkip means the check is not run, as the check it requires failed
+	StatusSkip Status = "SKIP"
+)
+
+const (
+	CheckTerraform    = "terraform"
+	CheckProvider     = "provider"
+	CheckCredential   = "credential"
+	CheckSubscription = "subscription"
+	CheckARG          = "resource graph"
+	CheckOutputDir    = "output directory"
+)
+
+// Result is the result of a check, with the remediation steps if it doesn't pass.
+type Result struct {
+	Name        string
+	Status      Status
+	Detail      string
+	Remediation string
+}
+
+// Check diagnoses one aspect of the environment.
+type Check struct {
+	Name string
+	// Requires is the name of the check that has to pass (or warn) before running this one, if not empty
+	Requires string
+	run      func(ctx context.Context) Result
+}
+
+// Run runs the checks in order.
+func Run(ctx context.Context, checks []Check) []Result {
+	statuses := map[string]Status{}
+	var results []Result
+	for _, check := range checks {
+		var res Result
+		if st, ok := statuses[check.Requires]; ok && (st == StatusFail || st == StatusSkip) {
+			res = Result{Status: StatusSkip, Detail: fmt.Sprintf("skipped as the %s check didn't pass", check.Requires)}
+		} else {
+			res = check.run(ctx)
+		}
+		res.Name = check.Name
+		statuses[check.Name] = res.Status
+		results = append(results, res)
+	}
+	return results
+}
+
+// Write prints the results, and returns the number of the failed checks.
+func Write(w io.Writer, results []Result) int {
+	var failed int
+	for _, res := range results {
+		fmt.Fprintf(w, "[%s] %s: %s\n", res.Status, res.Name, res.Detail)
+		if res.Remediation != "" {
+			fmt.Fprintf(w, "    %s\n", res.Remediation)
+		}
+		if res.Status == StatusFail {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Failed returns the check that always fails with the error, e.g. its prerequisite can't be built.
+func Failed(name string, err error, remediation string) Check {
+	return Check{
+		Name: name,
+		run: func(context.Context) Result {
+			return Result{Status: StatusFail, Detail: err.Error(), Remediation: remediation}
+		},
+	}
+}
+
+// TerraformCheck checks the terraform executable found by the find function, and its version.
+func TerraformCheck(find func(ctx context.Context) (string, error)) Check {
+	return Check{
+		Name: CheckTerraform,
+		run: func(ctx context.Context) Result {
+			path, err := find(ctx)
+			if err != nil {
+				remediation := "Install terraform (>= v0.12) and add it to the PATH."
+				if tofu, err := exec.LookPath("tofu"); err == nil {
+					remediation += fmt.Sprintf(" The OpenTofu executable (%s) is not supported.", tofu)
+				}
+				return Result{Status: StatusFail, Detail: err.Error(), Remediation: remediation}
+			}
+			tf, err := tfexec.NewTerraform(os.TempDir(), path)
+			if err != nil {
+				return Result{Status: StatusFail, Detail: err.Error(), Remediation: fmt.Sprintf("Check the terraform executable %s.", path)}
+			}
+			ver, _, err := tf.Version(ctx, true)
+			if err != nil {
+				return Result{Status: StatusFail, Detail: fmt.Sprintf("getting the version of %s: %v", path, err), Remediation: fmt.Sprintf("Check the terraform executable %s.", path)}
+			}
+			return Result{Status: StatusOK, Detail: fmt.Sprintf("v%s (%s)", ver, path)}
+		},
+	}
+}
+
+// ProviderCheck checks that the provider of the source address (e.g. "hashicorp/azurerm") can be downloaded, either from the provider mirror (if not empty),
+// or from its registry.
+func ProviderCheck(client *http.Client, source, mirror string) Check {
+	return Check{
+		Name: CheckProvider,
+		run: func(ctx context.Context) Result {
+			host, namespace, typ, err := parseProviderSource(source)
+			if err != nil {
+				return Result{Status: StatusFail, Detail: err.Error()}
+			}
+			switch {
+			case mirror == "":
+				err = checkRegistry(ctx, client, host, namespace, typ)
+				if err != nil {
+					return Result{Status: StatusFail, Detail: err.Error(), Remediation: fmt.Sprintf("Allow the access to %s, or specify a provider mirror via `--provider-mirror`.", host)}
+				}
+				return Result{Status: StatusOK, Detail: fmt.Sprintf("%s is available from %s", source, host)}
+			case strings.HasPrefix(mirror, "https://"), strings.HasPrefix(mirror, "http://"):
+				err = checkNetworkMirror(ctx, client, mirror, host, namespace, typ)
+			default:
+				dir := filepath.Join(mirror, host, namespace, typ)
+				if _, serr := os.Stat(dir); serr != nil {
+					err = fmt.Errorf("%s is not found in the filesystem mirror: %v", source, serr)
+				}
+			}
+			if err != nil {
+				return Result{Status: StatusFail, Detail: err.Error(), Remediation: fmt.Sprintf("Add %s to the provider mirror %s.", source, mirror)}
+			}
+			return Result{Status: StatusOK, Detail: fmt.Sprintf("%s is available from the provider mirror %s", source, mirror)}
+		},
+	}
+}
+
+// parseProviderSource parses the provider source address, whose hostname defaults to registry.terraform.io.
+func parseProviderSource(source string) (host, namespace, typ string, err error) {
+	segs := strings.Split(source, "/")
+	switch len(segs) {
+	case 2:
+		return "registry.terraform.io", segs[0], segs[1], nil
+	case 3:
+		return segs[0], segs[1], segs[2], nil
+	default:
+		return "", "", "", fmt.Errorf("invalid provider source %q", source)
+	}
+}
+
+func httpGet(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: unexpected status %s", url, resp.Status)
+	}
+	return b, nil
+}
+
+// checkRegistry lists the versions of the provider via the provider registry protocol, whose base URL is discovered from the host.
+func checkRegistry(ctx context.Context, client *http.Client, host, namespace, typ string) error {
+	b, err := httpGet(ctx, client, "https://"+host+"/.well-known/terraform.json")
+	if err != nil {
+		return fmt.Errorf("discovering the services of %s: %v", host, err)
+	}
+	var services struct {
+		ProvidersV1 string `json:"providers.v1"`
+	}
+	if err := json.Unmarshal(b, &services); err != nil {
+		return fmt.Errorf("unmarshalling the services of %s: %v", host, err)
+	}
+	if services.ProvidersV1 == "" {
+		return fmt.Errorf("%s is not a provider registry", host)
+	}
+	base := services.ProvidersV1
+	if !strings.HasPrefix(base, "https://") && !strings.HasPrefix(base, "http://") {
+		base = "https://" + host + "/" + strings.TrimPrefix(base, "/")
+	}
+	b, err = httpGet(ctx, client, strings.TrimSuffix(base, "/")+"/"+namespace+"/"+typ+"/versions")
+	if err != nil {
+		return fmt.Errorf("listing the versions of %s/%s: %v", namespace, typ, err)
+	}
+	var versions struct {
+		Versions []interface{} `json:"versions"`
+	}
+	if err := json.Unmarshal(b, &versions); err != nil {
+		return fmt.Errorf("unmarshalling the versions of %s/%s: %v", namespace, typ, err)
+	}
+	if len(versions.Versions) == 0 {
+		return fmt.Errorf("no version of %s/%s is available", namespace, typ)
+	}
+	return nil
+}
+
+// checkNetworkMirror lists the versions of the provider via the provider network mirror protocol.
+func checkNetworkMirror(ctx context.Context, client *http.Client, mirror, host, namespace, typ string) error {
+	_, err := httpGet(ctx, client, strings.TrimSuffix(mirror, "/")+"/"+host+"/"+namespace+"/"+typ+"/index.json")
+	return err
+}
+
+// CredentialCheck checks that the credential can get a token for the Azure Resource Manager.
+func CredentialCheck(cred azcore.TokenCredential, clientOpt arm.ClientOptions) Check {
+	return Check{
+		Name: CheckCredential,
+		run: func(ctx context.Context) Result {
+			audience := cloud.AzurePublic.Services[cloud.ResourceManager].Audience
+			if svc, ok := clientOpt.Cloud.Services[cloud.ResourceManager]; ok && svc.Audience != "" {
+				audience = svc.Audience
+			}
+			if _, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{strings.TrimSuffix(audience, "/") + "/.default"}}); err != nil {
+				return Result{Status: StatusFail, Detail: err.Error(), Remediation: "Run `az login`, or set the ARM_* environment variables of the credential that is selected by the `--use-*` flags."}
+			}
+			return Result{Status: StatusOK, Detail: "a token is acquired for the Azure Resource Manager"}
+		},
+	}
+}
+
+// SubscriptionCheck checks that the subscription can be read by the credential.
+func SubscriptionCheck(subscriptionId string, cred azcore.TokenCredential, clientOpt arm.ClientOptions) Check {
+	return Check{
+		Name:     CheckSubscription,
+		Requires: CheckCredential,
+		run: func(ctx context.Context) Result {
+			remediation := fmt.Sprintf("Grant the identity a role (e.g. \"Reader\") on the subscription %s, or specify another one via `--subscription-id`.", subscriptionId)
+			client, err := armresources.NewClient(subscriptionId, cred, &clientOpt)
+			if err != nil {
+				return Result{Status: StatusFail, Detail: err.Error()}
+			}
+			if _, err := client.GetByID(ctx, "/subscriptions/"+subscriptionId, "2020-01-01", nil); err != nil {
+				var rerr *azcore.ResponseError
+				if errors.As(err, &rerr) {
+					return Result{Status: StatusFail, Detail: fmt.Sprintf("reading the subscription %s: %s", subscriptionId, rerr.ErrorCode), Remediation: remediation}
+				}
+				return Result{Status: StatusFail, Detail: fmt.Sprintf("reading the subscription %s: %v", subscriptionId, err), Remediation: remediation}
+			}
+			return Result{Status: StatusOK, Detail: fmt.Sprintf("the subscription %s is accessible", subscriptionId)}
+		},
+	}
+}
+
+// ARGCheck checks that the Azure Resource Graph can be queried within the subscription.
+func ARGCheck(subscriptionId string, cred azcore.TokenCredential, clientOpt arm.ClientOptions) Check {
+	return Check{
+		Name:     CheckARG,
+		Requires: CheckSubscription,
+		run: func(ctx context.Context) Result {
+			// The query matches nothing, which only checks the permission
+			if _, err := azlist.List(ctx, "1 == 0", azlist.Option{SubscriptionId: subscriptionId, Cred: cred, ClientOpt: clientOpt, Parallelism: 1}); err != nil {
+				return Result{Status: StatusFail, Detail: err.Error(), Remediation: "Grant the identity the read permission (e.g. \"Reader\") on the resources to export, which are queried via the Azure Resource Graph."}
+			}
+			return Result{Status: StatusOK, Detail: "the Azure Resource Graph can be queried"}
+		},
+	}
+}
+
+// OutputDirCheck checks that the output directory is writable, or can be created if not exists.
+func OutputDirCheck(dir string) Check {
+	return Check{
+		Name: CheckOutputDir,
+		run: func(ctx context.Context) Result {
+			// The nearest existing directory, where the output directory is created if not exists
+			existing := dir
+			for {
+				fi, err := os.Stat(existing)
+				if err == nil {
+					if !fi.IsDir() {
+						return Result{Status: StatusFail, Detail: fmt.Sprintf("%s is not a directory", existing), Remediation: "Specify another output directory via `--output-dir`."}
+					}
+					break
+				}
+				if !os.IsNotExist(err) {
+					return Result{Status: StatusFail, Detail: err.Error(), Remediation: "Specify another output directory via `--output-dir`."}
+				}
+				parent := filepath.Dir(existing)
+				if parent == existing {
+					return Result{Status: StatusFail, Detail: err.Error(), Remediation: "Specify another output directory via `--output-dir`."}
+				}
+				existing = parent
+			}
+			f, err := os.CreateTemp(existing, ".aztfexport-doctor-")
+			if err != nil {
+				return Result{Status: StatusFail, Detail: fmt.Sprintf("%s is not writable: %v", existing, err), Remediation: fmt.Sprintf("Grant the write permission of %s, or specify another output directory via `--output-dir`.", existing)}
+			}
+			// #nosec G104
+			f.Close()
+			// #nosec G104
+			os.Remove(f.Name())
+			if existing != dir {
+				return Result{Status: StatusOK, Detail: fmt.Sprintf("%s doesn't exist, which can be created", dir)}
+			}
+			if empty, err := utils.DirIsEmpty(dir); err == nil && !empty {
+				return Result{Status: StatusWarn, Detail: fmt.Sprintf("%s is writable, but not empty", dir), Remediation: "Specify `--on-nonempty-dir` (or `--overwrite`, `--append`) for the non-interactive runs, which otherwise fail."}
+			}
+			return Result{Status: StatusOK, Detail: fmt.Sprintf("%s is writable", dir)}
+		},
+	}
+}
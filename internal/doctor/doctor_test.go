@@ -0,0 +1,93 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	checks := []Check{
+		Failed(CheckCredential, errors.New("no credential"), "Run `az login`."),
+		{Name: CheckSubscription, Requires: CheckCredential, run: func(context.Context) Result { return Result{Status: StatusOK} }},
+		{Name: CheckARG, Requires: CheckSubscription, run: func(context.Context) Result { return Result{Status: StatusOK} }},
+		{Name: CheckOutputDir, run: func(context.Context) Result { return Result{Status: StatusWarn, Detail: "not empty"} }},
+	}
+	results := Run(context.Background(), checks)
+	require.Equal(t, []Result{
+		{Name: CheckCredential, Status: StatusFail, Detail: "no credential", Remediation: "Run `az login`."},
+		{Name: CheckSubscription, Status: StatusSkip, Detail: "skipped as the credential check didn't pass"},
+		{Name: CheckARG, Status: StatusSkip, Detail: "skipped as the subscription check didn't pass"},
+		{Name: CheckOutputDir, Status: StatusWarn, Detail: "not empty"},
+	}, results)
+
+	var buf bytes.Buffer
+	require.Equal(t, 1, Write(&buf, results))
+	require.Equal(t, `[FAIL] credential: no credential
+    Run `+"`az login`"+`.
+[SKIP] subscription: skipped as the credential check didn't pass
+[SKIP] resource graph: skipped as the subscription check didn't pass
+[WARN] output directory: not empty
+`, buf.String())
+}
+
+func TestProviderCheck(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/terraform.json":
+			w.Write([]byte(`{"providers.v1": "/v1/providers/"}`))
+		case "/v1/providers/hashicorp/azurerm/versions":
+			w.Write([]byte(`{"versions": [{"version": "3.0.0"}]}`))
+		case "/mirror/registry.terraform.io/hashicorp/azurerm/index.json":
+			w.Write([]byte(`{"versions": {"3.0.0": {}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	// Registry
+	res := ProviderCheck(srv.Client(), host+"/hashicorp/azurerm", "").run(context.Background())
+	require.Equal(t, StatusOK, res.Status, res.Detail)
+	res = ProviderCheck(srv.Client(), host+"/azure/azapi", "").run(context.Background())
+	require.Equal(t, StatusFail, res.Status)
+	require.Contains(t, res.Detail, "listing the versions of azure/azapi")
+
+	// Network mirror
+	res = ProviderCheck(srv.Client(), "hashicorp/azurerm", srv.URL+"/mirror/").run(context.Background())
+	require.Equal(t, StatusOK, res.Status, res.Detail)
+	res = ProviderCheck(srv.Client(), "azure/azapi", srv.URL+"/mirror/").run(context.Background())
+	require.Equal(t, StatusFail, res.Status)
+
+	// Filesystem mirror
+	mirror := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(mirror, "registry.terraform.io", "hashicorp", "azurerm"), 0755))
+	res = ProviderCheck(srv.Client(), "hashicorp/azurerm", mirror).run(context.Background())
+	require.Equal(t, StatusOK, res.Status, res.Detail)
+	res = ProviderCheck(srv.Client(), "azure/azapi", mirror).run(context.Background())
+	require.Equal(t, StatusFail, res.Status)
+}
+
+func TestOutputDirCheck(t *testing.T) {
+	dir := t.TempDir()
+	require.Equal(t, StatusOK, OutputDirCheck(dir).run(context.Background()).Status)
+
+	// The directory to be created
+	res := OutputDirCheck(filepath.Join(dir, "foo", "bar")).run(context.Background())
+	require.Equal(t, StatusOK, res.Status)
+	require.Contains(t, res.Detail, "can be created")
+
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	require.Equal(t, StatusWarn, OutputDirCheck(dir).run(context.Background()).Status)
+	require.Equal(t, StatusFail, OutputDirCheck(filepath.Join(file, "foo")).run(context.Background()).Status)
+}
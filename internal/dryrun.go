@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/pkg/meta"
+)
+
+type SkippedResource struct {
+	ResourceId string `json:"resource_id"`
+	Reason     string `json:"reason"`
+}
+
+// DryRunResult is the mapping that would be used by a run, as resolved by the dry run.
+type DryRunResult struct {
+	// Resources are the resources that would be exported, which can be used as the mapping file of the mapping file mode.
+	Resources resmap.ResourceMapping `json:"resources"`
+	// Skipped are the resources that would be skipped, the key is the Azure resource Id.
+	Skipped map[string]SkippedResource `json:"skipped"`
+}
+
+func newDryRunResult(l meta.ImportList) DryRunResult {
+	result := DryRunResult{
+		Resources: resmap.ResourceMapping{},
+		Skipped:   map[string]SkippedResource{},
+	}
+	for _, item := range l {
+		if item.Skip() {
+			result.Skipped[item.AzureResourceID.String()] = SkippedResource{
+				ResourceId: item.TFResourceId,
+				Reason:     item.SkipReason(),
+			}
+			continue
+		}
+		result.Resources[item.AzureResourceID.String()] = resmap.ResourceMapEntity{
+			ResourceId:   item.TFResourceId,
+			ResourceType: item.TFAddr.Type,
+			ResourceName: item.TFAddr.Name,
+		}
+	}
+	return result
+}
+
+// writeDryRunResult writes the dry run result to the file at path, or to w if path is empty.
+func writeDryRunResult(w io.Writer, path string, result DryRunResult) error {
+	b, err := json.MarshalIndent(result, "", "\t")
+	if err != nil {
+		return fmt.Errorf("marshalling the dry run result: %v", err)
+	}
+	if path == "" {
+		_, err := fmt.Fprintln(w, string(b))
+		return err
+	}
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing the dry run result to %s: %v", path, err)
+	}
+	return nil
+}
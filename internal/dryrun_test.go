@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunResult(t *testing.T) {
+	item := func(id, tfType, name string) meta.ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return meta.ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}, TFAddrCache: tfaddr.TFAddr{Type: tfType, Name: name}}
+	}
+	rg := item("/subscriptions/123/resourceGroups/rg1", "azurerm_resource_group", "res-0")
+	unsupported := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/bars/bar1", "", "res-1")
+	ambiguous := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/bazs/baz1", "", "res-2")
+	ambiguous.Recommendations = []string{"azurerm_foo", "azurerm_bar"}
+	locked := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1", "azurerm_virtual_network", "res-3")
+	locked.TFAddr.Type = ""
+	locked.Lock = "ReadOnly"
+
+	result := newDryRunResult(meta.ImportList{rg, unsupported, ambiguous, locked})
+	expect := DryRunResult{
+		Resources: resmap.ResourceMapping{
+			"/subscriptions/123/resourceGroups/rg1": {
+				ResourceId:   rg.TFResourceId,
+				ResourceType: "azurerm_resource_group",
+				ResourceName: "res-0",
+			},
+		},
+		Skipped: map[string]SkippedResource{
+			unsupported.AzureResourceID.String(): {ResourceId: unsupported.TFResourceId, Reason: "no TF resource type"},
+			ambiguous.AzureResourceID.String():   {ResourceId: ambiguous.TFResourceId, Reason: "no unique TF resource type, candidates: azurerm_foo, azurerm_bar"},
+			locked.AzureResourceID.String():      {ResourceId: locked.TFResourceId, Reason: "under a ReadOnly lock"},
+		},
+	}
+	require.Equal(t, expect, result)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeDryRunResult(&buf, "", result))
+	var printed DryRunResult
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &printed))
+	require.Equal(t, expect, printed)
+
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	require.NoError(t, writeDryRunResult(nil, path, result))
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var written DryRunResult
+	require.NoError(t, json.Unmarshal(b, &written))
+	require.Equal(t, expect, written)
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/meta"
+)
+
+type EventType string
+
+const (
+	// EventStatus reports the progress of the run, e.g. "Listing resources...".
+	EventStatus             EventType = "status"
+	EventResourceDiscovered EventType = "resource_discovered"
+	EventTypeResolved       EventType = "type_resolved"
+	EventImportStarted      EventType = "import_started"
+	EventImportSucceeded    EventType = "import_succeeded"
+	EventImportFailed       EventType = "import_failed"
+	EventConfigGenerated    EventType = "config_generated"
+)
+
+// Event is a machine-readable progress event of the non-interactive run, which is written as a line of JSON (i.e. NDJSON).
+type Event struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"event"`
+	// Message is the status message, only for EventStatus.
+	Message string `json:"message,omitempty"`
+	// ResourceId is the resource id, as in the resource mapping file.
+	ResourceId string `json:"resource_id,omitempty"`
+	// ResourceType is the Terraform resource type, which is empty before the type is resolved.
+	ResourceType string `json:"resource_type,omitempty"`
+	// ResourceName is the Terraform resource name.
+	ResourceName string `json:"resource_name,omitempty"`
+	// Error is the import error, only for EventImportFailed.
+	Error string `json:"error,omitempty"`
+}
+
+// eventWriter writes the events as NDJSON. A nil eventWriter drops the events, which is used for the text output.
+type eventWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newEventWriter(w io.Writer) *eventWriter {
+	return &eventWriter{enc: json.NewEncoder(w)}
+}
+
+func (w *eventWriter) emit(ev Event) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ev.Time = time.Now().UTC()
+	// #nosec G104
+	w.enc.Encode(ev)
+}
+
+func (w *eventWriter) emitItem(typ EventType, item meta.ImportItem) {
+	ev := Event{
+		Type:         typ,
+		ResourceId:   item.TFResourceId,
+		ResourceType: item.TFAddr.Type,
+		ResourceName: item.TFAddr.Name,
+	}
+	if typ == EventImportFailed && item.ImportError != nil {
+		ev.Error = item.ImportError.Error()
+	}
+	w.emit(ev)
+}
+
+// eventMessager reports the status messages as the EventStatus events.
+type eventMessager struct {
+	w *eventWriter
+}
+
+func (m eventMessager) SetStatus(msg string) {
+	m.w.emit(Event{Type: EventStatus, Message: msg})
+}
+
+func (m eventMessager) SetDetail(msg string) {
+	m.w.emit(Event{Type: EventStatus, Message: msg})
+}
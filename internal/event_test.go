@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventWriter(t *testing.T) {
+	// The nil writer drops the events
+	var w *eventWriter
+	w.emit(Event{Type: EventStatus, Message: "Listing resources..."})
+
+	var buf bytes.Buffer
+	w = newEventWriter(&buf)
+	item := meta.ImportItem{
+		TFResourceId: "/subscriptions/123/resourceGroups/rg1",
+		TFAddr:       tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+		ImportError:  errors.New("boom"),
+	}
+	eventMessager{w: w}.SetStatus("Listing resources...")
+	w.emitItem(EventImportStarted, item)
+	w.emitItem(EventImportFailed, item)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	var events []Event
+	for _, line := range lines {
+		var ev Event
+		require.NoError(t, json.Unmarshal([]byte(line), &ev))
+		require.False(t, ev.Time.IsZero())
+		ev.Time = ev.Time.UTC()
+		events = append(events, ev)
+	}
+	require.Equal(t, Event{Time: events[0].Time, Type: EventStatus, Message: "Listing resources..."}, events[0])
+	require.Equal(t, Event{Time: events[1].Time, Type: EventImportStarted, ResourceId: item.TFResourceId, ResourceType: "azurerm_resource_group", ResourceName: "res-0"}, events[1])
+	require.Equal(t, Event{Time: events[2].Time, Type: EventImportFailed, ResourceId: item.TFResourceId, ResourceType: "azurerm_resource_group", ResourceName: "res-0", Error: "boom"}, events[2])
+}
@@ -0,0 +1,121 @@
+// Package gencache is the on-disk cache of the ARM payloads and the generated configs of the resources, so that re-running the config generation doesn't
+// re-download or re-import the resources that are not changed. The entries are addressed by the resource ids and the etags of the resources.
+package gencache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	armDirName    = "arm"
+	configDirName = "config"
+)
+
+type Cache struct {
+	dir string
+}
+
+// New returns the cache of the directory, which is created if not exists.
+func New(dir string) (*Cache, error) {
+	for _, d := range []string{armDirName, configDirName} {
+		// #nosec G301
+		if err := os.MkdirAll(filepath.Join(dir, d), 0750); err != nil {
+			return nil, fmt.Errorf("creating the cache directory %s: %v", filepath.Join(dir, d), err)
+		}
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// key returns the content address of the parts, where the resource id (the first part) is compared case insensitively.
+func key(id string, parts ...string) string {
+	h := sha256.New()
+	h.Write([]byte(strings.ToUpper(id)))
+	for _, p := range parts {
+		h.Write([]byte{0})
+		h.Write([]byte(p))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func read(path string) ([]byte, bool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("reading the cache entry %s: %v", path, err)
+	}
+	return b, true, nil
+}
+
+// write writes the entry via a temporary file, so that a partially written entry is never read.
+func write(path string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("creating the cache entry %s: %v", path, err)
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("writing the cache entry %s: %v", path, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("closing the cache entry %s: %v", path, err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("renaming the cache entry %s: %v", path, err)
+	}
+	return nil
+}
+
+// ETag returns the etag of the ARM payload, which is empty if the resource has no etag.
+func ETag(payload []byte) string {
+	return gjson.GetBytes(payload, "etag").String()
+}
+
+func (c *Cache) armPath(id, apiVersion string) string {
+	return filepath.Join(c.dir, armDirName, key(id, apiVersion)+".json")
+}
+
+// GetARM returns the cached ARM payload of the resource in the API version, which is only valid if the etag of the resource is unchanged.
+func (c *Cache) GetARM(id, apiVersion string) (payload []byte, ok bool, err error) {
+	return read(c.armPath(id, apiVersion))
+}
+
+// PutARM caches the ARM payload of the resource in the API version. The payload without an etag is not cached, as it can't be validated.
+func (c *Cache) PutARM(id, apiVersion string, payload []byte) error {
+	if ETag(payload) == "" {
+		return nil
+	}
+	return write(c.armPath(id, apiVersion), payload)
+}
+
+func (c *Cache) configPath(id, etag, fingerprint string) string {
+	return filepath.Join(c.dir, configDirName, key(id, etag, fingerprint)+".tf")
+}
+
+// GetConfig returns the cached config of the resource of the etag, which is generated with the settings of the fingerprint (e.g. the provider version).
+func (c *Cache) GetConfig(id, etag, fingerprint string) (cfg []byte, ok bool, err error) {
+	if etag == "" {
+		return nil, false, nil
+	}
+	return read(c.configPath(id, etag, fingerprint))
+}
+
+// PutConfig caches the config of the resource of the etag, which is generated with the settings of the fingerprint. It is a noop if the etag is empty.
+func (c *Cache) PutConfig(id, etag, fingerprint string, cfg []byte) error {
+	if etag == "" {
+		return nil
+	}
+	return write(c.configPath(id, etag, fingerprint), cfg)
+}
@@ -0,0 +1,58 @@
+package gencache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	c, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	const (
+		id         = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"
+		apiVersion = "2022-07-01"
+	)
+
+	// ARM payloads
+	_, ok, err := c.GetARM(id, apiVersion)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, c.PutARM(id, apiVersion, []byte(`{"name": "vnet1"}`)))
+	_, ok, err = c.GetARM(id, apiVersion)
+	require.NoError(t, err)
+	require.False(t, ok, "the payload without etag is not cached")
+
+	payload := []byte(`{"name": "vnet1", "etag": "W/\"abc\""}`)
+	require.Equal(t, `W/"abc"`, ETag(payload))
+	require.NoError(t, c.PutARM(id, apiVersion, payload))
+	b, ok, err := c.GetARM(id, apiVersion)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, payload, b)
+	_, ok, err = c.GetARM(id, "2023-01-01")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Configs
+	cfg := []byte(`resource "azurerm_virtual_network" "res-0" {}`)
+	require.NoError(t, c.PutConfig(id, "", "fp", cfg))
+	_, ok, err = c.GetConfig(id, "", "fp")
+	require.NoError(t, err)
+	require.False(t, ok, "the config without etag is not cached")
+
+	require.NoError(t, c.PutConfig(id, "abc", "fp", cfg))
+	// The resource id is case insensitive
+	b, ok, err = c.GetConfig("/SUBSCRIPTIONS/123/resourceGroups/RG1/providers/Microsoft.Network/virtualNetworks/vnet1", "abc", "fp")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, cfg, b)
+	_, ok, err = c.GetConfig(id, "def", "fp")
+	require.NoError(t, err)
+	require.False(t, ok)
+	_, ok, err = c.GetConfig(id, "abc", "fp2")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
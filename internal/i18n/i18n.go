@@ -0,0 +1,90 @@
+// Package i18n translates the user facing messages (i.e. CLI errors, prompts and TUI labels) to the language of the user's locale.
+//
+// The messages are keyed by their English text, which is also used as is when the locale has no catalog.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LangEnvVar is the environment variable that selects the language, which takes precedence over the locale environment variables (e.g. LANG).
+// Setting it to "en" disables the translation.
+const LangEnvVar = "AZTFEXPORT_LANG"
+
+// catalogs maps the supported locales to their message catalogs.
+var catalogs = map[string]map[string]string{
+	"zh-CN": zhCN,
+}
+
+// current is the catalog in use, nil means English.
+var current map[string]string
+
+// Init selects the catalog from the environment, in the order of AZTFEXPORT_LANG, LC_ALL, LC_MESSAGES and LANG.
+func Init() {
+	SetLocale(DetectLocale())
+}
+
+// DetectLocale returns the normalized locale (e.g. "zh-CN") from the environment, or empty if not set.
+func DetectLocale() string {
+	for _, env := range []string{LangEnvVar, "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := normalizeLocale(os.Getenv(env)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// normalizeLocale converts a POSIX locale (e.g. "zh_CN.UTF-8") to the BCP 47 like form used by the catalogs (e.g. "zh-CN").
+// The "C" and "POSIX" locales are treated as not set.
+func normalizeLocale(s string) string {
+	s, _, _ = strings.Cut(s, ".")
+	s, _, _ = strings.Cut(s, "@")
+	if s == "" || s == "C" || s == "POSIX" {
+		return ""
+	}
+	lang, region, ok := strings.Cut(strings.ReplaceAll(s, "_", "-"), "-")
+	if !ok {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(lang) + "-" + strings.ToUpper(region)
+}
+
+// SetLocale selects the catalog of the locale. A locale with only the language part (e.g. "zh") selects a catalog of that language.
+// Unsupported locales fall back to English.
+func SetLocale(locale string) {
+	current = nil
+	if locale == "" {
+		return
+	}
+	if c, ok := catalogs[locale]; ok {
+		current = c
+		return
+	}
+	lang, _, _ := strings.Cut(locale, "-")
+	for k, c := range catalogs {
+		if strings.HasPrefix(k, lang+"-") {
+			current = c
+			return
+		}
+	}
+}
+
+// T returns the translation of the message, or the message itself if there is no translation.
+func T(msg string) string {
+	if v, ok := current[msg]; ok {
+		return v
+	}
+	return msg
+}
+
+// Sprintf is like fmt.Sprintf, but translates the format first.
+func Sprintf(format string, a ...interface{}) string {
+	return fmt.Sprintf(T(format), a...)
+}
+
+// Errorf is like fmt.Errorf, but translates the format first.
+func Errorf(format string, a ...interface{}) error {
+	return fmt.Errorf(T(format), a...)
+}
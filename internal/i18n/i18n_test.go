@@ -0,0 +1,56 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	require.Equal(t, "zh-CN", normalizeLocale("zh_CN.UTF-8"))
+	require.Equal(t, "zh-CN", normalizeLocale("zh-cn"))
+	require.Equal(t, "en-US", normalizeLocale("en_US.UTF-8@euro"))
+	require.Equal(t, "zh", normalizeLocale("zh"))
+	require.Equal(t, "", normalizeLocale("C.UTF-8"))
+	require.Equal(t, "", normalizeLocale("POSIX"))
+	require.Equal(t, "", normalizeLocale(""))
+}
+
+func TestSetLocale(t *testing.T) {
+	defer SetLocale("")
+
+	SetLocale("zh-CN")
+	require.Equal(t, "按任意键退出", T("Press any key to quit"))
+	require.Equal(t, "not translated", T("not translated"))
+
+	SetLocale("zh")
+	require.Equal(t, "按任意键退出", T("Press any key to quit"))
+
+	SetLocale("fr-FR")
+	require.Equal(t, "Press any key to quit", T("Press any key to quit"))
+
+	SetLocale("")
+	require.Equal(t, "Press any key to quit", T("Press any key to quit"))
+}
+
+func TestDetectLocale(t *testing.T) {
+	t.Setenv("LANG", "zh_CN.UTF-8")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv(LangEnvVar, "")
+	require.Equal(t, "zh-CN", DetectLocale())
+
+	t.Setenv(LangEnvVar, "en")
+	require.Equal(t, "en", DetectLocale())
+}
+
+// The translations must keep the format verbs of the messages, in the same order.
+func TestCatalogVerbs(t *testing.T) {
+	p := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	for locale, catalog := range catalogs {
+		for k, v := range catalog {
+			require.Equal(t, p.FindAllString(k, -1), p.FindAllString(v, -1), "%s: %q", locale, k)
+		}
+	}
+}
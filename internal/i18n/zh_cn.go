@@ -0,0 +1,186 @@
+package i18n
+
+var zhCN = map[string]string{
+	// CLI errors
+	"Error: %v": "错误：%v",
+	"Please specify a configuration key and value": "请指定配置项的键和值",
+	"Please specify a configuration key":           "请指定配置项的键",
+	"No resource id specified":                     "未指定资源 ID",
+	"More than one resource ids specified":         "指定了多于一个资源 ID",
+	"No resource group specified":                  "未指定资源组",
+	"More than one resource groups specified":      "指定了多于一个资源组",
+	"No management group specified":                "未指定管理组",
+	"More than one management groups specified":    "指定了多于一个管理组",
+	"No query specified":                           "未指定查询",
+	"More than one queries specified. Use `and` with double quotes to run multiple query parameters.": "指定了多于一个查询。请使用双引号并以 `and` 连接多个查询条件。",
+	"No scope file specified":                                 "未指定范围文件",
+	"More than one scope files specified":                     "指定了多于一个范围文件",
+	"No argument is expected":                                 "不需要任何参数",
+	"No resource mapping file specified":                      "未指定资源映射文件",
+	"More than one resource mapping files specified":          "指定了多于一个资源映射文件",
+	"No plan file specified":                                  "未指定计划文件",
+	"More than one plan files specified":                      "指定了多于一个计划文件",
+	"Exactly two output directories are expected":             "需要恰好两个输出目录",
+	"Exactly one mapping file is expected":                    "需要恰好一个映射文件",
+	"Exactly one mapping file and one query are expected":     "需要恰好一个映射文件和一个查询",
+	"Exactly two mapping files are expected":                  "需要恰好两个映射文件",
+	"Exactly one state file or working directory is expected": "需要恰好一个状态文件或工作目录",
+	"the output directory %q is not empty":                    "输出目录 %q 不为空",
+	"`--hcl-only` can only run within an empty directory. Use `-o` to specify an empty directory.": "`--hcl-only` 只能在空目录中运行。请使用 `-o` 指定一个空目录。",
+
+	// Prompts
+	`
+The output directory is not empty. Please choose one of actions below:
+
+* Press "Y" to overwrite the existing directory with new files
+* Press "N" to append new files and add to the existing state instead
+* Press other keys to quit
+
+> `: `
+输出目录不为空。请选择以下操作之一：
+
+* 按 "Y" 用新文件覆盖现有目录
+* 按 "N" 追加新文件，并将资源添加到现有状态中
+* 按其他键退出
+
+> `,
+
+	// Batch mode messages
+	"Initializing...":                                    "正在初始化...",
+	"DeInitializing...":                                  "正在清理初始化...",
+	"Listing resources...":                               "正在列出资源...",
+	"Listing resources... (%d/%d)":                       "正在列出资源... (%d/%d)",
+	"Exporting Skipped Resource file...":                 "正在导出跳过的资源文件...",
+	"Exporting Resource Mapping file...":                 "正在导出资源映射文件...",
+	"(chunk %d/%d)":                                      "（分块 %d/%d）",
+	"Importing resources...":                             "正在导入资源...",
+	"(%d/%d) Skipping %s":                                "(%d/%d) 跳过 %s",
+	"(%d/%d) Importing %s as %s":                         "(%d/%d) 正在将 %s 导入为 %s",
+	"(%d/%d) Resuming %s as %s":                          "(%d/%d) 恢复已导出的 %s（%s）",
+	"Failed to import %s as %s: %v":                      "无法将 %s 导入为 %s：%v",
+	"Generating Terraform configurations...":             "正在生成 Terraform 配置...",
+	"Retrying %d resource(s) that failed transiently...": "正在重试 %d 个暂时失败的资源...",
+	"Cleaning up...":                                     "正在清理...",
+	"Converting to Pulumi program...":                    "正在转换为 Pulumi 程序...",
+	"Estimating cost...":                                 "正在估算成本...",
+	"Verifying the exported configuration...":            "正在验证导出的配置...",
+	"Errors:":                           "错误：",
+	"Resources under management locks:": "处于管理锁下的资源：",
+	"Cost estimate:":                    "成本估算：",
+	"Warnings:":                         "警告：",
+	"Verification: %d resource(s) with non-empty plan, see %s":                           "验证：%d 个资源的计划不为空，详见 %s",
+	"Stopped importing as %s, %d resource(s) remaining, run with `--resume` to continue": "由于%s，已停止导入，剩余 %d 个资源，使用 `--resume` 继续",
+	"the maximum number of resources (%d) is reached":                                    "已达到最大资源数（%d）",
+	"the maximum duration (%s) is reached":                                               "已达到最长运行时间（%s）",
+	"the run is interrupted":                                                             "运行被中断",
+	"Interrupted, stopping after the in-flight imports... (press Ctrl-C again to force)": "已中断，正在等待进行中的导入完成后停止...（再次按 Ctrl-C 强制停止）",
+	"Force stopping, killing the in-flight imports...":                                   "正在强制停止，终止进行中的导入...",
+	"Resources colliding with soft-deleted resources:":                                   "与软删除资源冲突的资源：",
+	"Skipped": "已跳过",
+	"%d resource(s) failed to import, see %s, which can be used as the mapping file of a follow-up run": "%d 个资源导入失败，详见 %s，可用作后续运行的映射文件",
+	"No failed resource to retry":         "没有需要重试的失败资源",
+	"No planned resource exists in Azure": "计划中的资源在 Azure 中均不存在",
+
+	// TUI labels
+	"Microsoft Azure Export for Terraform":                "Microsoft Azure Terraform 导出工具",
+	"Listing Azure Resources...":                          "正在列出 Azure 资源...",
+	"Pushing Terraform Status...":                         "正在推送 Terraform 状态...",
+	"Exporting Resource Mapping...":                       "正在导出资源映射...",
+	"Exporting Skipped Resources...":                      "正在导出跳过的资源...",
+	"Generating Terraform Configurations...":              "正在生成 Terraform 配置...",
+	"Cleaning up the output directory...":                 "正在清理输出目录...",
+	"Converting to Pulumi Program...":                     "正在转换为 Pulumi 程序...",
+	"Estimating Cost...":                                  "正在估算成本...",
+	"Terraform state and the config are generated at: %s": "Terraform 状态和配置已生成于：%s",
+	"Pulumi program is generated at: %s":                  "Pulumi 程序已生成于：%s",
+	"Press any key to quit":                               "按任意键退出",
+	"Imported %d/%d (%d succeeded, %d failed)":            "已导入 %d/%d（%d 个成功，%d 个失败）",
+	"ETA %s":                 "预计剩余 %s",
+	"idle":                   "空闲",
+	"%s skipped":             "%s 已跳过",
+	"%s import successfully": "%s 导入成功",
+	"%s import failed":       "%s 导入失败",
+	"All resources are skipped, nothing to import":    "所有资源均已跳过，没有需要导入的资源",
+	"One or more user input is invalid":               "一个或多个输入无效",
+	"No resource type recommendation is available...": "没有可用的资源类型推荐...",
+	"Possible resource type(s): %s":                   "可能的资源类型：%s",
+	"Saving the resouce mapping...":                   "正在保存资源映射...",
+	"Resource mapping saved to %s":                    "资源映射已保存至 %s",
+	"Resource mapping file is generated at: %s":       "资源映射文件已生成于：%s",
+	"quit":                            "退出",
+	"skip":                            "跳过",
+	"show error":                      "显示错误",
+	"show recommendation":             "显示推荐",
+	"import":                          "导入",
+	"save mapping":                    "保存映射",
+	"save mapping and quit":           "保存映射并退出",
+	"mark":                            "标记",
+	"invert marks":                    "反选标记",
+	"skip marked":                     "跳过已标记",
+	"apply type to marked":            "应用类型到已标记",
+	"%d marked resource(s) skipped":   "已跳过 %d 个已标记的资源",
+	"%d marked resource(s) set to %s": "已将 %d 个已标记的资源设置为 %s",
+	"The selected resource is skipped, set its resource type first":             "所选资源已跳过，请先设置其资源类型",
+	"No marked resource is of the same Azure resource type as the selected one": "没有与所选资源的 Azure 资源类型相同的已标记资源",
+	"filter by status":                 "按状态筛选",
+	"filter by Azure type":             "按 Azure 类型筛选",
+	"status: %s":                       "状态：%s",
+	"type: %s":                         "类型：%s",
+	"No resource matches the filter":   "没有匹配筛选条件的资源",
+	"unresolved":                       "未解析",
+	"skipped":                          "已跳过",
+	"error":                            "错误",
+	"preview config":                   "预览配置",
+	"Generating the config preview...": "正在生成配置预览...",
+	"Generating the config preview, please wait...":     "正在生成配置预览，请稍候...",
+	"The resource is skipped, nothing to preview":       "该资源已跳过，没有可预览的配置",
+	"Press esc to close the preview, up/down to scroll": "按 esc 关闭预览，上/下键滚动",
+	"edit import ID": "编辑导入 ID",
+	"import ID":      "导入 ID",
+	"The resource is imported by its Azure resource ID, no need to edit the import ID": "该资源通过其 Azure 资源 ID 导入，无需编辑导入 ID",
+	"%d resource(s) failed to import":                                                  "%d 个资源导入失败",
+	"%d resource(s) still failed to import, retry or skip them":                        "仍有 %d 个资源导入失败，请重试或跳过",
+	"retry":                                 "重试",
+	"change type and retry":                 "更改类型并重试",
+	"continue":                              "继续",
+	"edit import list":                      "编辑导入列表",
+	"Retrying %s...":                        "正在重试 %s...",
+	"%s (retrying...)":                      "%s（重试中...）",
+	"Retrying the import, please wait...":   "正在重试导入，请稍候...",
+	"The resource is imported successfully": "该资源已导入成功",
+	"Press esc to close the error, up/down to scroll": "按 esc 关闭错误，上/下键滚动",
+
+	// Accessible mode
+	"One or more resources failed to import, type \"x <number>\" to show the error": "一个或多个资源导入失败，输入 \"x <编号>\" 以显示错误",
+	"Type \"h\" for help, \"w\" to import, \"q\" to quit.":                          "输入 \"h\" 显示帮助，\"w\" 开始导入，\"q\" 退出。",
+	"Type \"h\" for help, \"w\" to save the resource mapping file, \"q\" to quit.":  "输入 \"h\" 显示帮助，\"w\" 保存资源映射文件，\"q\" 退出。",
+	"Unknown command %q, type \"h\" for help":                                       "未知命令 %q，输入 \"h\" 显示帮助",
+	"Please specify the resource number and the Terraform resource address":         "请指定资源编号和 Terraform 资源地址",
+	"Please specify the resource number":                                            "请指定资源编号",
+	"Invalid resource number %q":                                                    "无效的资源编号 %q",
+	"The resource has no import error":                                              "该资源没有导入错误",
+	"%d resources in %s:":                                                           "共 %d 个资源，位于 %s：",
+	"%s lock":                                                                       "%s 锁",
+	"import failed":                                                                 "导入失败",
+	"imported":                                                                      "已导入",
+	"recommended":                                                                   "推荐",
+	`Commands:
+  l                 List the resources
+  e <number> <addr> Import the resource as the Terraform resource address (e.g. azurerm_resource_group.res-0)
+  s <number>        Skip the resource, or stop skipping it
+  r <number>        Show the recommended resource types of the resource
+  x <number>        Show the import error of the resource
+  save              Save the resource mapping file
+  w                 Import the resources that are not skipped
+  q                 Quit
+  h                 Show this help`: `命令：
+  l                 列出资源
+  e <编号> <地址>   将资源导入为指定的 Terraform 资源地址（例如 azurerm_resource_group.res-0）
+  s <编号>          跳过该资源，或取消跳过
+  r <编号>          显示该资源推荐的资源类型
+  x <编号>          显示该资源的导入错误
+  save              保存资源映射文件
+  w                 导入未跳过的资源
+  q                 退出
+  h                 显示此帮助`,
+}
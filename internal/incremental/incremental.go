@@ -0,0 +1,303 @@
+// Package incremental syncs an exported workspace with its scope, by exporting the resources that appeared since the last run, and reporting
+// (optionally removing from the state) the ones that disappeared.
+package incremental
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/aztfexport/internal"
+	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// StateFileName is the file under the output directory that records the resource ids exported by the last sync.
+const StateFileName = ".aztfexport-sync.json"
+
+type Config struct {
+	// OutputDir is the exported workspace to sync
+	OutputDir string
+	// StateRm specifies to run "terraform state rm" for the resources that disappeared
+	StateRm bool
+
+	// Discover lists the ids of the resources in the scope
+	Discover func(ctx context.Context) ([]string, error)
+	// Export exports the resources of the specified ids to the output directory, in append mode, and returns the ids of the resources that are exported
+	Export func(ctx context.Context, ids []string) ([]string, error)
+
+	// Out is where the reports are written to
+	Out io.Writer
+}
+
+type state struct {
+	// Timestamp is when the resources are discovered by the last sync, in RFC3339
+	Timestamp string `json:"timestamp"`
+	// ETag is the digest of the resource ids, which tells whether the scope is changed since the last sync
+	ETag      string   `json:"etag"`
+	Resources []string `json:"resources"`
+}
+
+// Result is the resources changed since the last sync.
+type Result struct {
+	Added   []string
+	Removed []string
+	// RemovedFromState are the state addresses of the removed resources that are removed from the state
+	RemovedFromState []string
+}
+
+// Run runs one round of sync. If the added resources are partially exported, the state is still synced, and the *internal.PartialSuccessError is returned
+// together with the result.
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	last, err := loadState(cfg.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := cfg.Discover(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("discovering resources: %v", err)
+	}
+	current := newState(ids)
+	current.Timestamp = time.Now().Format(time.RFC3339)
+
+	since := "the last export"
+	if last.Timestamp != "" {
+		since = last.Timestamp
+	}
+	var result Result
+	if current.ETag == last.ETag {
+		fmt.Fprintf(cfg.Out, "No resource changed since %s\n", since)
+		return &result, saveState(cfg.OutputDir, current)
+	}
+	result.Added, result.Removed = diffIds(last.Resources, current.Resources)
+	fmt.Fprintf(cfg.Out, "Since %s, %d resource(s) added, %d resource(s) removed\n", since, len(result.Added), len(result.Removed))
+	for _, id := range result.Added {
+		fmt.Fprintf(cfg.Out, "+ %s\n", id)
+	}
+	for _, id := range result.Removed {
+		fmt.Fprintf(cfg.Out, "- %s\n", id)
+	}
+
+	// The partially succeeded export still syncs the state, the partial success is returned at the end.
+	var partialErr error
+	if len(result.Added) != 0 {
+		exported, err := cfg.Export(ctx, result.Added)
+		if err != nil {
+			var perr *internal.PartialSuccessError
+			if !errors.As(err, &perr) {
+				return nil, fmt.Errorf("exporting the added resources: %v", err)
+			}
+			partialErr = err
+		}
+		fmt.Fprintf(cfg.Out, "Exported %d added resource(s)\n", len(exported))
+		// The added resources that are not exported (e.g. failed to import) are not recorded, which are exported again by the next sync.
+		if missed := notExported(result.Added, exported); len(missed) != 0 {
+			fmt.Fprintf(cfg.Out, "%d added resource(s) not exported, which are retried by the next sync\n", len(missed))
+			synced := newState(withoutIds(current.Resources, missed))
+			synced.Timestamp = current.Timestamp
+			current = synced
+		}
+	}
+	if cfg.StateRm && len(result.Removed) != 0 {
+		addrs, err := removeFromState(ctx, cfg.OutputDir, result.Removed)
+		if err != nil {
+			return nil, err
+		}
+		result.RemovedFromState = addrs
+		for _, addr := range addrs {
+			fmt.Fprintf(cfg.Out, "Removed %s from the state\n", addr)
+		}
+	}
+
+	if err := saveState(cfg.OutputDir, current); err != nil {
+		return nil, err
+	}
+	return &result, partialErr
+}
+
+func newState(ids []string) state {
+	set := map[string]bool{}
+	var resources []string
+	for _, id := range ids {
+		if set[strings.ToUpper(id)] {
+			continue
+		}
+		set[strings.ToUpper(id)] = true
+		resources = append(resources, id)
+	}
+	sort.Strings(resources)
+	return state{ETag: etag(resources), Resources: resources}
+}
+
+// etag returns the digest of the resource ids, case insensitively and regardless of the order.
+func etag(ids []string) string {
+	var upper []string
+	for _, id := range ids {
+		upper = append(upper, strings.ToUpper(id))
+	}
+	sort.Strings(upper)
+	sum := sha256.Sum256([]byte(strings.Join(upper, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// diffIds returns the ids that are only in the current ids (added), and the ones that are only in the last ids (removed), case insensitively.
+func diffIds(last, current []string) (added, removed []string) {
+	lastSet, currentSet := map[string]bool{}, map[string]bool{}
+	for _, id := range last {
+		lastSet[strings.ToUpper(id)] = true
+	}
+	for _, id := range current {
+		currentSet[strings.ToUpper(id)] = true
+		if !lastSet[strings.ToUpper(id)] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range last {
+		if !currentSet[strings.ToUpper(id)] {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// notExported returns the added ids that are not among the exported ids, case insensitively.
+func notExported(added, exported []string) []string {
+	set := map[string]bool{}
+	for _, id := range exported {
+		set[strings.ToUpper(id)] = true
+	}
+	var out []string
+	for _, id := range added {
+		if !set[strings.ToUpper(id)] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// withoutIds returns the ids that are not among the excluded ids, case insensitively.
+func withoutIds(ids, excluded []string) []string {
+	set := map[string]bool{}
+	for _, id := range excluded {
+		set[strings.ToUpper(id)] = true
+	}
+	var out []string
+	for _, id := range ids {
+		if !set[strings.ToUpper(id)] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// loadState loads the sync state file. If it doesn't exist yet, the resources in the resource mapping file of the last export are used as the baseline.
+func loadState(dir string) (state, error) {
+	b, err := os.ReadFile(filepath.Join(dir, StateFileName))
+	if err == nil {
+		var st state
+		if err := json.Unmarshal(b, &st); err != nil {
+			return state{}, fmt.Errorf("unmarshalling the sync state file: %v", err)
+		}
+		return st, nil
+	}
+	if !os.IsNotExist(err) {
+		return state{}, fmt.Errorf("reading the sync state file: %v", err)
+	}
+
+	b, err = os.ReadFile(filepath.Join(dir, meta.ResourceMappingFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state{}, nil
+		}
+		return state{}, fmt.Errorf("reading the resource mapping file: %v", err)
+	}
+	var m resmap.ResourceMapping
+	if err := json.Unmarshal(b, &m); err != nil {
+		return state{}, fmt.Errorf("unmarshalling the resource mapping file: %v", err)
+	}
+	var ids []string
+	for id := range m {
+		ids = append(ids, id)
+	}
+	return newState(ids), nil
+}
+
+func saveState(dir string, st state) error {
+	b, err := json.MarshalIndent(st, "", "\t")
+	if err != nil {
+		return fmt.Errorf("marshalling the sync state: %v", err)
+	}
+	// #nosec G306
+	if err := os.WriteFile(filepath.Join(dir, StateFileName), b, 0644); err != nil {
+		return fmt.Errorf("writing the sync state file: %v", err)
+	}
+	return nil
+}
+
+// removeFromState removes the resources of the Azure resource ids from the state of the output directory, which is expected to be initialized.
+func removeFromState(ctx context.Context, dir string, ids []string) ([]string, error) {
+	execPath, err := meta.FindTerraform(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("finding the terraform executable: %v", err)
+	}
+	tf, err := tfexec.NewTerraform(dir, execPath)
+	if err != nil {
+		return nil, fmt.Errorf("new terraform: %v", err)
+	}
+	st, err := tf.Show(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("showing the state: %v", err)
+	}
+	addrs := stateAddresses(st, ids)
+	for _, addr := range addrs {
+		log.Printf("[INFO] Removing %s from the state", addr)
+		if err := tf.StateRm(ctx, addr); err != nil {
+			return nil, fmt.Errorf("removing %s from the state: %v", addr, err)
+		}
+	}
+	return addrs, nil
+}
+
+// stateAddresses returns the addresses of the managed resources in the state, whose ids are any of the Azure resource ids, case insensitively.
+// The resources whose TF resource id differs from the Azure resource id (e.g. the association resources) are not matched.
+func stateAddresses(st *tfjson.State, ids []string) []string {
+	if st == nil || st.Values == nil || st.Values.RootModule == nil {
+		return nil
+	}
+	set := map[string]bool{}
+	for _, id := range ids {
+		set[strings.ToUpper(id)] = true
+	}
+	var addrs []string
+	var walk func(module *tfjson.StateModule)
+	walk = func(module *tfjson.StateModule) {
+		for _, res := range module.Resources {
+			if res.Mode != tfjson.ManagedResourceMode {
+				continue
+			}
+			if id, ok := res.AttributeValues["id"].(string); ok && set[strings.ToUpper(id)] {
+				addrs = append(addrs, res.Address)
+			}
+		}
+		for _, child := range module.ChildModules {
+			walk(child)
+		}
+	}
+	walk(st.Values.RootModule)
+	sort.Strings(addrs)
+	return addrs
+}
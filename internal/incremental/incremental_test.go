@@ -0,0 +1,157 @@
+package incremental
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal"
+	"github.com/Azure/aztfexport/internal/meta"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	rg   = "/subscriptions/123/resourceGroups/rg"
+	vnet = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"
+	sa   = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	mapping := `{
+  "/subscriptions/123/resourceGroups/rg": {"resource_id": "/subscriptions/123/resourceGroups/rg", "resource_type": "azurerm_resource_group", "resource_name": "res-0"},
+  "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet": {"resource_id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", "resource_type": "azurerm_virtual_network", "resource_name": "res-1"}
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, meta.ResourceMappingFileName), []byte(mapping), 0644))
+
+	var exported [][]string
+	run := func(ids ...string) *Result {
+		result, err := Run(context.Background(), Config{
+			OutputDir: dir,
+			Discover: func(ctx context.Context) ([]string, error) {
+				return ids, nil
+			},
+			Export: func(ctx context.Context, ids []string) ([]string, error) {
+				exported = append(exported, ids)
+				return ids, nil
+			},
+			Out: &bytes.Buffer{},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	// The resource mapping file is the baseline of the first sync
+	result := run("/subscriptions/123/resourceGroups/RG", sa)
+	require.Equal(t, []string{sa}, result.Added)
+	require.Equal(t, []string{vnet}, result.Removed)
+	require.Equal(t, [][]string{{sa}}, exported)
+
+	// Nothing changed since the last sync
+	result = run(sa, rg)
+	require.Empty(t, result.Added)
+	require.Empty(t, result.Removed)
+	require.Len(t, exported, 1)
+
+	result = run(rg)
+	require.Empty(t, result.Added)
+	require.Equal(t, []string{sa}, result.Removed)
+}
+
+func TestRunPartialSuccess(t *testing.T) {
+	dir := t.TempDir()
+	partial := &internal.PartialSuccessError{Problems: []internal.Problem{{Kind: internal.ProblemKindUnsupported, AzureResourceId: vnet}}}
+
+	var exported [][]string
+	cfg := Config{
+		OutputDir: dir,
+		Discover: func(ctx context.Context) ([]string, error) {
+			return []string{rg, vnet}, nil
+		},
+		Export: func(ctx context.Context, ids []string) ([]string, error) {
+			exported = append(exported, ids)
+			return ids, partial
+		},
+		Out: &bytes.Buffer{},
+	}
+	result, err := Run(context.Background(), cfg)
+	require.ErrorIs(t, err, partial)
+	require.Equal(t, []string{rg, vnet}, result.Added)
+
+	// The state is still synced, which doesn't export the same resources again.
+	result, err = Run(context.Background(), cfg)
+	require.NoError(t, err)
+	require.Empty(t, result.Added)
+	require.Len(t, exported, 1)
+}
+
+func TestRunNotExported(t *testing.T) {
+	dir := t.TempDir()
+
+	var exported [][]string
+	failing := map[string]bool{vnet: true}
+	cfg := Config{
+		OutputDir: dir,
+		Discover: func(ctx context.Context) ([]string, error) {
+			return []string{rg, vnet}, nil
+		},
+		Export: func(ctx context.Context, ids []string) ([]string, error) {
+			exported = append(exported, ids)
+			var out []string
+			for _, id := range ids {
+				if !failing[id] {
+					out = append(out, id)
+				}
+			}
+			return out, &internal.PartialSuccessError{}
+		},
+		Out: &bytes.Buffer{},
+	}
+	_, err := Run(context.Background(), cfg)
+	var perr *internal.PartialSuccessError
+	require.ErrorAs(t, err, &perr)
+
+	// The resource not exported is added again by the next sync.
+	failing = map[string]bool{}
+	result, err := Run(context.Background(), cfg)
+	require.ErrorAs(t, err, &perr)
+	require.Equal(t, []string{vnet}, result.Added)
+	require.Empty(t, result.Removed)
+	require.Equal(t, [][]string{{rg, vnet}, {vnet}}, exported)
+
+	result, err = Run(context.Background(), cfg)
+	require.NoError(t, err)
+	require.Empty(t, result.Added)
+}
+
+func TestDiffIds(t *testing.T) {
+	added, removed := diffIds([]string{rg, vnet}, []string{"/subscriptions/123/resourceGroups/RG", sa})
+	require.Equal(t, []string{sa}, added)
+	require.Equal(t, []string{vnet}, removed)
+}
+
+func TestStateAddresses(t *testing.T) {
+	st := &tfjson.State{
+		Values: &tfjson.StateValues{
+			RootModule: &tfjson.StateModule{
+				Resources: []*tfjson.StateResource{
+					{Address: "azurerm_resource_group.res-0", Mode: tfjson.ManagedResourceMode, AttributeValues: map[string]interface{}{"id": rg}},
+					{Address: "data.azurerm_virtual_network.vnet", Mode: tfjson.DataResourceMode, AttributeValues: map[string]interface{}{"id": vnet}},
+				},
+				ChildModules: []*tfjson.StateModule{
+					{
+						Address: "module.network",
+						Resources: []*tfjson.StateResource{
+							{Address: "module.network.azurerm_virtual_network.res-1", Mode: tfjson.ManagedResourceMode, AttributeValues: map[string]interface{}{"id": vnet}},
+						},
+					},
+				},
+			},
+		},
+	}
+	require.Equal(t, []string{"module.network.azurerm_virtual_network.res-1"}, stateAddresses(st, []string{"/SUBSCRIPTIONS/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", sa}))
+	require.Nil(t, stateAddresses(&tfjson.State{}, []string{rg}))
+}
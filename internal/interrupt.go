@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/Azure/aztfexport/internal/i18n"
+)
+
+// interrupter stops the run of the non-interactive mode gracefully on the first interrupt, which lets the in-flight imports finish and checkpoints the imported resources.
+// The second interrupt force stops the run by cancelling its context, which kills the in-flight terraform processes.
+type interrupter struct {
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	count int
+	// out is where the notices are printed to, nil means the notices are rendered by the UI instead
+	out io.Writer
+}
+
+func newInterrupter(cancel context.CancelFunc, out io.Writer) *interrupter {
+	return &interrupter{cancel: cancel, out: out}
+}
+
+// interrupt records an interrupt, the second one force stops the run.
+func (i *interrupter) interrupt() {
+	i.mu.Lock()
+	i.count++
+	count, out := i.count, i.out
+	i.mu.Unlock()
+
+	if count > 1 {
+		i.cancel()
+	}
+	if out != nil {
+		fmt.Fprintln(out, i.notice())
+	}
+}
+
+// setOutput sets where the notices are printed to, e.g. once the UI rendering them exits.
+func (i *interrupter) setOutput(out io.Writer) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.out = out
+}
+
+// notice returns the message describing how the run is being stopped, or empty if not interrupted.
+func (i *interrupter) notice() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	switch {
+	case i.count == 0:
+		return ""
+	case i.count == 1:
+		return i18n.T("Interrupted, stopping after the in-flight imports... (press Ctrl-C again to force)")
+	default:
+		return i18n.T("Force stopping, killing the in-flight imports...")
+	}
+}
+
+// reason returns the reason to stop importing, or empty if not interrupted.
+func (i *interrupter) reason() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.count == 0 {
+		return ""
+	}
+	return i18n.T("the run is interrupted")
+}
+
+// notify relays the SIGINT and SIGTERM to the interrupter, until the returned function is called.
+func (i *interrupter) notify() (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				i.interrupt()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
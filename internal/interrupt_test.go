@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterrupter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var out bytes.Buffer
+	i := newInterrupter(cancel, &out)
+	require.Empty(t, i.reason())
+	require.Empty(t, i.notice())
+
+	// The first interrupt stops gracefully, without cancelling the context.
+	i.interrupt()
+	require.Equal(t, "the run is interrupted", i.reason())
+	require.NoError(t, ctx.Err())
+	require.Equal(t, "Interrupted, stopping after the in-flight imports... (press Ctrl-C again to force)\n", out.String())
+
+	// The second interrupt force stops by cancelling the context.
+	out.Reset()
+	i.interrupt()
+	require.Equal(t, "the run is interrupted", i.reason())
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+	require.Equal(t, "Force stopping, killing the in-flight imports...\n", out.String())
+
+	// The notices are not printed without the output, e.g. rendered by the spinner.
+	out.Reset()
+	i.setOutput(nil)
+	i.interrupt()
+	require.Empty(t, out.String())
+	require.Equal(t, "Force stopping, killing the in-flight imports...", i.notice())
+}
+
+func TestSpinnerMessager(t *testing.T) {
+	var out bytes.Buffer
+	stdout := NewStdoutMessager().(*stdoutMessager)
+	stdout.SetOutput(&out)
+	stdout.SetFlags(0)
+	m := &spinnerMessager{stdout: stdout}
+
+	m.SetStatus("status")
+	m.SetDetail("detail")
+	status, detail := m.view()
+	require.Equal(t, "status", status)
+	require.Equal(t, "detail", detail)
+	require.Empty(t, out.String())
+
+	// The messages are printed to the stdout once the spinner exits.
+	m.exit()
+	m.SetStatus("after")
+	require.Equal(t, "[aztfexport] after\n", out.String())
+}
@@ -0,0 +1,31 @@
+package mapping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/aztfexport/internal/armschema"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/magodo/armid"
+)
+
+// ARMExists returns a function that checks the existence of the Azure resource by getting it in the latest API version, which is used as ValidateOption.Exists.
+func ARMExists(client *armresources.Client) func(ctx context.Context, id armid.ResourceId) (bool, error) {
+	return func(ctx context.Context, id armid.ResourceId) (bool, error) {
+		apiVersion, err := armschema.LatestAPIVersion(id.TypeString())
+		if err != nil {
+			return false, err
+		}
+		if _, err := client.GetByID(ctx, id.String(), apiVersion, nil); err != nil {
+			var respErr *azcore.ResponseError
+			if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+				return false, nil
+			}
+			return false, fmt.Errorf("getting %s: %v", id, err)
+		}
+		return true, nil
+	}
+}
@@ -0,0 +1,292 @@
+// Package mapping validates, diffs, merges and generates (from the Terraform state) the resource mapping files, which are maintained by teams (e.g. in git) to export a customized scope of resources.
+package mapping
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/magodo/armid"
+)
+
+// Read reads the resource mapping file.
+func Read(path string) (resmap.ResourceMapping, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mapping file %s: %v", path, err)
+	}
+	var m resmap.ResourceMapping
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("unmarshalling the mapping file %s: %v", path, err)
+	}
+	return m, nil
+}
+
+// Write writes the resource mapping in the same format as the resource mapping file exported by aztfexport.
+func Write(w io.Writer, m resmap.ResourceMapping) error {
+	b, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return fmt.Errorf("JSON marshalling the resource mapping: %v", err)
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// Issue is a problem of a resource mapping entry.
+type Issue struct {
+	// Id is the Azure resource id of the entry
+	Id      string
+	Message string
+}
+
+type ValidateOption struct {
+	// ValidType reports whether the TF resource type is valid.
+	ValidType func(tfType string) bool
+	// Exists reports whether the Azure resource exists. Nil means not to check the existence (i.e. offline).
+	Exists func(ctx context.Context, id armid.ResourceId) (bool, error)
+	// Parallelism is the number of the existence checks that run at the same time.
+	Parallelism int
+}
+
+// Validate validates the resource mapping, and returns the issues found, ordered by the Azure resource id.
+func Validate(ctx context.Context, m resmap.ResourceMapping, opt ValidateOption) []Issue {
+	var issues []Issue
+	report := func(id, format string, a ...interface{}) {
+		issues = append(issues, Issue{Id: id, Message: fmt.Sprintf(format, a...)})
+	}
+
+	addrs := map[string][]string{}
+	ids := map[string][]string{}
+	var existenceIds []armid.ResourceId
+	for _, id := range sortedKeys(m) {
+		res := m[id]
+		ids[strings.ToUpper(id)] = append(ids[strings.ToUpper(id)], id)
+		azureId, err := armid.ParseResourceId(id)
+		if err != nil {
+			report(id, "invalid Azure resource id: %v", err)
+		} else {
+			existenceIds = append(existenceIds, azureId)
+		}
+		if res.ResourceId == "" {
+			report(id, "resource_id is empty")
+		}
+		switch {
+		case res.ResourceType == "":
+			report(id, "resource_type is empty")
+		case opt.ValidType != nil && !opt.ValidType(res.ResourceType):
+			report(id, "unknown resource_type %q", res.ResourceType)
+		}
+		if !hclsyntax.ValidIdentifier(res.ResourceName) {
+			report(id, "invalid resource_name %q", res.ResourceName)
+		}
+		addr := res.ResourceType + "." + res.ResourceName
+		addrs[addr] = append(addrs[addr], id)
+	}
+	for _, dups := range ids {
+		if len(dups) > 1 {
+			for _, id := range dups[1:] {
+				report(id, "duplicates %s, as the Azure resource ids are case insensitive", dups[0])
+			}
+		}
+	}
+	for addr, dups := range addrs {
+		if len(dups) > 1 {
+			for _, id := range dups[1:] {
+				report(id, "resource address %s is also used by %s", addr, dups[0])
+			}
+		}
+	}
+
+	if opt.Exists != nil {
+		parallelism := opt.Parallelism
+		if parallelism <= 0 {
+			parallelism = 1
+		}
+		var (
+			mu sync.Mutex
+			wg sync.WaitGroup
+		)
+		ch := make(chan armid.ResourceId)
+		for i := 0; i < parallelism; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for id := range ch {
+					ok, err := opt.Exists(ctx, id)
+					mu.Lock()
+					switch {
+					case err != nil:
+						report(id.String(), "checking the existence: %v", err)
+					case !ok:
+						report(id.String(), "resource not found")
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		for _, id := range existenceIds {
+			ch <- id
+		}
+		close(ch)
+		wg.Wait()
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Id < issues[j].Id
+	})
+	return issues
+}
+
+// Resource is a resource that only exists in either the resource mapping or the live resources.
+type Resource struct {
+	Id string
+	// Address is the TF resource address in the resource mapping, which is empty for the resources that are only live.
+	Address string
+}
+
+type DiffResult struct {
+	// Added are the live resources that are not in the resource mapping.
+	Added []Resource
+	// Removed are the resources in the resource mapping that are no longer live.
+	Removed []Resource
+}
+
+func (r DiffResult) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0
+}
+
+// Write writes the result in a human readable format.
+func (r DiffResult) Write(w io.Writer) {
+	if r.Empty() {
+		fmt.Fprintln(w, "No difference found")
+		return
+	}
+	for _, res := range r.Added {
+		fmt.Fprintf(w, "+ %s\n", res.Id)
+	}
+	for _, res := range r.Removed {
+		fmt.Fprintf(w, "- %s (%s)\n", res.Id, res.Address)
+	}
+	fmt.Fprintf(w, "\n%d added, %d removed\n", len(r.Added), len(r.Removed))
+}
+
+// Diff compares the resource mapping against the ids of the live resources (e.g. listed by Azure Resource Graph). The ids are compared case insensitively.
+func Diff(m resmap.ResourceMapping, liveIds []string) DiffResult {
+	mapped := map[string]bool{}
+	for id := range m {
+		mapped[strings.ToUpper(id)] = true
+	}
+	live := map[string]bool{}
+	var result DiffResult
+	for _, id := range liveIds {
+		live[strings.ToUpper(id)] = true
+		if !mapped[strings.ToUpper(id)] {
+			result.Added = append(result.Added, Resource{Id: id})
+		}
+	}
+	for _, id := range sortedKeys(m) {
+		if !live[strings.ToUpper(id)] {
+			result.Removed = append(result.Removed, Resource{Id: id, Address: m[id].ResourceType + "." + m[id].ResourceName})
+		}
+	}
+	sort.Slice(result.Added, func(i, j int) bool {
+		return result.Added[i].Id < result.Added[j].Id
+	})
+	return result
+}
+
+const (
+	// ConflictError fails the merge on any conflict.
+	ConflictError = "error"
+	// ConflictFirst resolves the conflicts in favor of the first resource mapping.
+	ConflictFirst = "first"
+	// ConflictSecond resolves the conflicts in favor of the second resource mapping.
+	ConflictSecond = "second"
+)
+
+// ConflictStrategies are the supported strategies to resolve the merge conflicts.
+var ConflictStrategies = []string{ConflictError, ConflictFirst, ConflictSecond}
+
+// Conflict is a merge conflict, and how it is resolved.
+type Conflict struct {
+	Id      string
+	Message string
+}
+
+// Merge merges the resource mappings. There are two kinds of conflicts:
+//
+//   - The same Azure resource (compared case insensitively) is mapped differently, the entry of the favored resource mapping is kept.
+//   - Different Azure resources are mapped to the same TF resource address, the entry of the other resource mapping is renamed by a "-<n>" suffix.
+//
+// All the conflicts are returned as an error if the strategy is ConflictError.
+func Merge(first, second resmap.ResourceMapping, strategy string) (resmap.ResourceMapping, []Conflict, error) {
+	favored, other := first, second
+	switch strategy {
+	case ConflictError, ConflictFirst:
+	case ConflictSecond:
+		favored, other = second, first
+	default:
+		return nil, nil, fmt.Errorf("unknown conflict strategy %q", strategy)
+	}
+
+	out := resmap.ResourceMapping{}
+	ids := map[string]string{}
+	addrs := map[string]string{}
+	for _, id := range sortedKeys(favored) {
+		res := favored[id]
+		out[id] = res
+		ids[strings.ToUpper(id)] = id
+		addrs[res.ResourceType+"."+res.ResourceName] = id
+	}
+
+	var conflicts []Conflict
+	for _, id := range sortedKeys(other) {
+		res := other[id]
+		if fid, ok := ids[strings.ToUpper(id)]; ok {
+			// The candidates are only informative, which are not compared.
+			if f := out[fid]; f.ResourceId != res.ResourceId || f.ResourceType != res.ResourceType || f.ResourceName != res.ResourceName {
+				conflicts = append(conflicts, Conflict{Id: id, Message: fmt.Sprintf("mapped to %s.%s and %s.%s, the former is kept", out[fid].ResourceType, out[fid].ResourceName, res.ResourceType, res.ResourceName)})
+			}
+			continue
+		}
+		addr := res.ResourceType + "." + res.ResourceName
+		if aid, ok := addrs[addr]; ok {
+			name := res.ResourceName
+			for i := 2; addrs[res.ResourceType+"."+name] != ""; i++ {
+				name = fmt.Sprintf("%s-%d", res.ResourceName, i)
+			}
+			conflicts = append(conflicts, Conflict{Id: id, Message: fmt.Sprintf("resource address %s is also used by %s, renamed to %s.%s", addr, aid, res.ResourceType, name)})
+			res.ResourceName = name
+			addr = res.ResourceType + "." + name
+		}
+		out[id] = res
+		ids[strings.ToUpper(id)] = id
+		addrs[addr] = id
+	}
+
+	if strategy == ConflictError && len(conflicts) != 0 {
+		var msgs []string
+		for _, c := range conflicts {
+			msgs = append(msgs, c.Id+": "+c.Message)
+		}
+		return nil, conflicts, fmt.Errorf("%d conflict(s) found:\n%s", len(conflicts), strings.Join(msgs, "\n"))
+	}
+	return out, conflicts, nil
+}
+
+func sortedKeys(m resmap.ResourceMapping) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
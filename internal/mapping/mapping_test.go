@@ -0,0 +1,141 @@
+package mapping
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	rg1   = "/subscriptions/123/resourceGroups/rg1"
+	rg2   = "/subscriptions/123/resourceGroups/rg2"
+	vnet1 = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"
+)
+
+func entity(id, rt, name string) resmap.ResourceMapEntity {
+	return resmap.ResourceMapEntity{ResourceId: id, ResourceType: rt, ResourceName: name}
+}
+
+func TestValidate(t *testing.T) {
+	m := resmap.ResourceMapping{
+		rg1:                      entity(rg1, "azurerm_resource_group", "res-0"),
+		strings.ToUpper(rg1):     entity(rg1, "azurerm_resource_group", "res-1"),
+		rg2:                      entity(rg2, "azurerm_resource_group", "res-0"),
+		vnet1:                    entity(vnet1, "azurerm_vnet", "1vnet"),
+		"/invalid":               entity("/invalid", "", "res-2"),
+		"/subscriptions/123/foo": entity("", "azurerm_subscription", "res-3"),
+	}
+	issues := Validate(context.Background(), m, ValidateOption{
+		ValidType: func(tfType string) bool {
+			return tfType != "azurerm_vnet"
+		},
+		Exists: func(ctx context.Context, id armid.ResourceId) (bool, error) {
+			return id.String() != rg2, nil
+		},
+		Parallelism: 2,
+	})
+
+	var actual []string
+	for _, issue := range issues {
+		actual = append(actual, issue.Id+": "+issue.Message)
+	}
+	require.ElementsMatch(t, []string{
+		rg1 + `: duplicates /SUBSCRIPTIONS/123/RESOURCEGROUPS/RG1, as the Azure resource ids are case insensitive`,
+		`/invalid: invalid Azure resource id: scopes should be split by "/providers/"`,
+		`/invalid: resource_type is empty`,
+		`/subscriptions/123/foo: invalid Azure resource id: scopes should be split by "/providers/"`,
+		`/subscriptions/123/foo: resource_id is empty`,
+		`/subscriptions/123/resourceGroups/rg2: resource address azurerm_resource_group.res-0 is also used by /subscriptions/123/resourceGroups/rg1`,
+		`/subscriptions/123/resourceGroups/rg2: resource not found`,
+		vnet1 + `: unknown resource_type "azurerm_vnet"`,
+		vnet1 + `: invalid resource_name "1vnet"`,
+	}, actual)
+}
+
+func TestDiff(t *testing.T) {
+	m := resmap.ResourceMapping{
+		rg1:   entity(rg1, "azurerm_resource_group", "res-0"),
+		vnet1: entity(vnet1, "azurerm_virtual_network", "res-1"),
+	}
+	result := Diff(m, []string{strings.ToUpper(rg1), rg2})
+	require.Equal(t, DiffResult{
+		Added:   []Resource{{Id: rg2}},
+		Removed: []Resource{{Id: vnet1, Address: "azurerm_virtual_network.res-1"}},
+	}, result)
+
+	var buf bytes.Buffer
+	result.Write(&buf)
+	require.Equal(t, "+ "+rg2+"\n- "+vnet1+" (azurerm_virtual_network.res-1)\n\n1 added, 1 removed\n", buf.String())
+
+	require.True(t, Diff(m, []string{rg1, vnet1}).Empty())
+}
+
+func TestMerge(t *testing.T) {
+	first := resmap.ResourceMapping{
+		rg1:   entity(rg1, "azurerm_resource_group", "res-0"),
+		vnet1: entity(vnet1, "azurerm_virtual_network", "res-0"),
+	}
+	second := resmap.ResourceMapping{
+		strings.ToUpper(rg1): entity(rg1, "azurerm_resource_group", "rg1"),
+		rg2:                  entity(rg2, "azurerm_resource_group", "res-0"),
+	}
+
+	_, conflicts, err := Merge(first, second, ConflictError)
+	require.Error(t, err)
+	require.Len(t, conflicts, 2)
+
+	merged, _, err := Merge(first, second, ConflictFirst)
+	require.NoError(t, err)
+	require.Equal(t, resmap.ResourceMapping{
+		rg1:   entity(rg1, "azurerm_resource_group", "res-0"),
+		rg2:   entity(rg2, "azurerm_resource_group", "res-0-2"),
+		vnet1: entity(vnet1, "azurerm_virtual_network", "res-0"),
+	}, merged)
+
+	merged, _, err = Merge(first, second, ConflictSecond)
+	require.NoError(t, err)
+	require.Equal(t, resmap.ResourceMapping{
+		strings.ToUpper(rg1): entity(rg1, "azurerm_resource_group", "rg1"),
+		rg2:                  entity(rg2, "azurerm_resource_group", "res-0"),
+		vnet1:                entity(vnet1, "azurerm_virtual_network", "res-0"),
+	}, merged)
+
+	_, _, err = Merge(first, second, "unknown")
+	require.Error(t, err)
+}
+
+func TestFromState(t *testing.T) {
+	state := `{
+  "version": 4,
+  "resources": [
+    {"mode": "managed", "type": "azurerm_resource_group", "name": "main", "instances": [{"attributes": {"id": "` + rg1 + `"}}]},
+    {"mode": "managed", "type": "azurerm_resource_group", "name": "env", "instances": [
+      {"index_key": "prod", "attributes": {"id": "` + rg2 + `"}}
+    ]},
+    {"mode": "managed", "type": "azurerm_virtual_network", "name": "main", "module": "module.network[\"hub\"]", "instances": [{"attributes": {"id": "` + vnet1 + `"}}]},
+    {"mode": "managed", "type": "azurerm_virtual_network", "name": "dup", "instances": [{"attributes": {"id": "` + strings.ToUpper(vnet1) + `"}}]},
+    {"mode": "managed", "type": "azurerm_key_vault_secret", "name": "secret", "instances": [{"attributes": {"id": "https://kv.vault.azure.net/secrets/foo/1"}}]},
+    {"mode": "managed", "type": "random_string", "name": "suffix", "instances": [{"attributes": {"id": "abc"}}]},
+    {"mode": "data", "type": "azurerm_client_config", "name": "current", "instances": [{"attributes": {"id": "xyz"}}]}
+  ]
+}`
+	m, skipped, err := FromState([]byte(state))
+	require.NoError(t, err)
+	require.Equal(t, resmap.ResourceMapping{
+		rg1:   entity(rg1, "azurerm_resource_group", "main"),
+		rg2:   entity(rg2, "azurerm_resource_group", "env_prod"),
+		vnet1: entity(vnet1, "azurerm_virtual_network", "network_hub_main"),
+	}, m)
+	require.Equal(t, []SkippedStateResource{
+		{Address: "azurerm_key_vault_secret.secret", Reason: `the id "https://kv.vault.azure.net/secrets/foo/1" is not an Azure resource id`},
+		{Address: "azurerm_virtual_network.dup", Reason: "the Azure resource " + strings.ToUpper(vnet1) + ` is already mapped by module.network["hub"].azurerm_virtual_network.main`},
+	}, skipped)
+
+	_, _, err = FromState([]byte(`{"version": 3}`))
+	require.Error(t, err)
+}
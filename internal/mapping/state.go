@@ -0,0 +1,110 @@
+package mapping
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/magodo/armid"
+	"github.com/tidwall/gjson"
+)
+
+// SkippedStateResource is a resource instance in the state that is not put into the resource mapping.
+type SkippedStateResource struct {
+	Address string
+	Reason  string
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// FromState builds the resource mapping of the Azure resources (i.e. the azurerm and azapi managed resources) in the state, which is in the raw state format (e.g. the output of "terraform state pull").
+//
+// As the resource mapping only records the TF resource type and name, the resource instances in the modules or with an index key are flattened, whose names are prefixed by the module names and suffixed
+// by the index key. The names that collide are renamed by a "-<n>" suffix.
+//
+// The resource instances whose id is not an Azure resource id (e.g. the data plane resources), or is already mapped by another resource instance (e.g. some association resources), are skipped.
+func FromState(state []byte) (resmap.ResourceMapping, []SkippedStateResource, error) {
+	if !gjson.ValidBytes(state) {
+		return nil, nil, fmt.Errorf("the state is not a valid JSON")
+	}
+	if v := gjson.GetBytes(state, "version"); v.Exists() && v.Int() != 4 {
+		return nil, nil, fmt.Errorf("unsupported state version %d", v.Int())
+	}
+
+	m := resmap.ResourceMapping{}
+	var skipped []SkippedStateResource
+	ids := map[string]string{}
+	addrs := map[string]bool{}
+	for _, res := range gjson.GetBytes(state, "resources").Array() {
+		if res.Get("mode").String() != "managed" {
+			continue
+		}
+		rt := res.Get("type").String()
+		if !strings.HasPrefix(rt, "azurerm_") && !strings.HasPrefix(rt, "azapi_") {
+			continue
+		}
+		addr := rt + "." + res.Get("name").String()
+		var nameSegs []string
+		if module := res.Get("module").String(); module != "" {
+			addr = module + "." + addr
+			// E.g. module.network["hub"].module.subnet
+			for _, seg := range strings.Split(module, ".") {
+				if seg != "module" {
+					nameSegs = append(nameSegs, seg)
+				}
+			}
+		}
+		nameSegs = append(nameSegs, res.Get("name").String())
+		for _, instance := range res.Get("instances").Array() {
+			instanceAddr := addr
+			segs := nameSegs
+			if key := instance.Get("index_key"); key.Exists() {
+				instanceAddr = fmt.Sprintf("%s[%s]", addr, key.Raw)
+				segs = append(append([]string{}, nameSegs...), key.String())
+			}
+			id := instance.Get("attributes.id").String()
+			if _, err := armid.ParseResourceId(id); err != nil {
+				skipped = append(skipped, SkippedStateResource{Address: instanceAddr, Reason: fmt.Sprintf("the id %q is not an Azure resource id", id)})
+				continue
+			}
+			if other, ok := ids[strings.ToUpper(id)]; ok {
+				skipped = append(skipped, SkippedStateResource{Address: instanceAddr, Reason: fmt.Sprintf("the Azure resource %s is already mapped by %s", id, other)})
+				continue
+			}
+			ids[strings.ToUpper(id)] = instanceAddr
+
+			name := stateResourceName(segs)
+			for i := 2; addrs[rt+"."+name]; i++ {
+				name = fmt.Sprintf("%s-%d", stateResourceName(segs), i)
+			}
+			addrs[rt+"."+name] = true
+			m[id] = resmap.ResourceMapEntity{
+				ResourceId:   id,
+				ResourceType: rt,
+				ResourceName: name,
+			}
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].Address < skipped[j].Address
+	})
+	return m, skipped, nil
+}
+
+// stateResourceName joins the name segments (i.e. the module names, resource name and the index key) into a valid TF resource name.
+func stateResourceName(segs []string) string {
+	var parts []string
+	for _, seg := range segs {
+		seg = strings.Trim(invalidNameChars.ReplaceAllString(seg, "_"), "_")
+		if seg != "" {
+			parts = append(parts, seg)
+		}
+	}
+	name := strings.Join(parts, "_")
+	if name == "" || !(name[0] == '_' || (name[0] >= 'a' && name[0] <= 'z') || (name[0] >= 'A' && name[0] <= 'Z')) {
+		name = "res_" + name
+	}
+	return name
+}
@@ -0,0 +1,75 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// writeAKSProviders writes the kubernetes and helm provider blocks for each imported AKS cluster, which are configured from the cluster's kube config.
+// The Kubernetes objects are not imported, the providers are meant for managing the add-ons afterwards.
+func (meta baseMeta) writeAKSProviders(l ImportList) error {
+	var clusters ImportList
+	for _, item := range l.Imported() {
+		if item.TFAddr.Type == "azurerm_kubernetes_cluster" {
+			clusters = append(clusters, item)
+		}
+	}
+	if len(clusters) == 0 {
+		return nil
+	}
+	path := filepath.Join(meta.moduleDir, AKSProvidersFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, aksProvidersConfig(clusters), 0644); err != nil {
+		return fmt.Errorf("writing the AKS providers to %s: %v", path, err)
+	}
+	return nil
+}
+
+// aksProvidersConfig builds the provider blocks for the AKS clusters. The providers are aliased by the cluster's TF resource name, unless there is only one cluster.
+func aksProvidersConfig(clusters ImportList) []byte {
+	f := hclwrite.NewEmptyFile()
+	for i, item := range clusters {
+		if i != 0 {
+			f.Body().AppendNewline()
+		}
+		alias := ""
+		if len(clusters) > 1 {
+			alias = item.TFAddr.Name
+		}
+
+		kb := f.Body().AppendNewBlock("provider", []string{"kubernetes"}).Body()
+		if alias != "" {
+			kb.SetAttributeValue("alias", cty.StringVal(alias))
+		}
+		setKubeConfigAttributes(kb, item.TFAddr.Type, item.TFAddr.Name)
+
+		f.Body().AppendNewline()
+		hb := f.Body().AppendNewBlock("provider", []string{"helm"}).Body()
+		if alias != "" {
+			hb.SetAttributeValue("alias", cty.StringVal(alias))
+		}
+		setKubeConfigAttributes(hb.AppendNewBlock("kubernetes", nil).Body(), item.TFAddr.Type, item.TFAddr.Name)
+	}
+	return hclwrite.Format(f.Bytes())
+}
+
+func setKubeConfigAttributes(body *hclwrite.Body, resourceType, resourceName string) {
+	kubeConfigAttr := func(name string) hcl.Traversal {
+		return hcl.Traversal{
+			hcl.TraverseRoot{Name: resourceType},
+			hcl.TraverseAttr{Name: resourceName},
+			hcl.TraverseAttr{Name: "kube_config"},
+			hcl.TraverseIndex{Key: cty.NumberIntVal(0)},
+			hcl.TraverseAttr{Name: name},
+		}
+	}
+	body.SetAttributeTraversal("host", kubeConfigAttr("host"))
+	for _, name := range []string{"client_certificate", "client_key", "cluster_ca_certificate"} {
+		body.SetAttributeRaw(name, hclwrite.TokensForFunctionCall("base64decode", hclwrite.TokensForTraversal(kubeConfigAttr(name))))
+	}
+}
@@ -0,0 +1,36 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAKSProvidersConfig(t *testing.T) {
+	cluster := func(name string) ImportItem {
+		return ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_kubernetes_cluster", Name: name}}
+	}
+
+	require.Equal(t, `provider "kubernetes" {
+  host                   = azurerm_kubernetes_cluster.res-0.kube_config[0].host
+  client_certificate     = base64decode(azurerm_kubernetes_cluster.res-0.kube_config[0].client_certificate)
+  client_key             = base64decode(azurerm_kubernetes_cluster.res-0.kube_config[0].client_key)
+  cluster_ca_certificate = base64decode(azurerm_kubernetes_cluster.res-0.kube_config[0].cluster_ca_certificate)
+}
+
+provider "helm" {
+  kubernetes {
+    host                   = azurerm_kubernetes_cluster.res-0.kube_config[0].host
+    client_certificate     = base64decode(azurerm_kubernetes_cluster.res-0.kube_config[0].client_certificate)
+    client_key             = base64decode(azurerm_kubernetes_cluster.res-0.kube_config[0].client_key)
+    cluster_ca_certificate = base64decode(azurerm_kubernetes_cluster.res-0.kube_config[0].cluster_ca_certificate)
+  }
+}
+`, string(aksProvidersConfig(ImportList{cluster("res-0")})))
+
+	out := string(aksProvidersConfig(ImportList{cluster("res-0"), cluster("res-1")}))
+	require.Contains(t, out, `alias                  = "res-0"`)
+	require.Contains(t, out, `alias                  = "res-1"`)
+	require.Contains(t, out, `host                   = azurerm_kubernetes_cluster.res-1.kube_config[0].host`)
+}
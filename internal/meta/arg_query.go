@@ -0,0 +1,208 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/magodo/armid"
+	"github.com/magodo/azlist/azlist"
+)
+
+const (
+	// argPageSize is the max number of the resources of each page of the ARG query.
+	argPageSize int32 = 1000
+	// argMaxRestarts is the max number of the times that the paging is restarted, once the skip token is rejected (e.g. expired, or invalidated by the changes of the result set).
+	argMaxRestarts = 3
+)
+
+// argClient is the client of the Azure Resource Graph.
+type argClient interface {
+	Resources(ctx context.Context, query armresourcegraph.QueryRequest, options *armresourcegraph.ClientResourcesOptions) (armresourcegraph.ClientResourcesResponse, error)
+}
+
+// listARG lists the resources that match the ARG predicate in the subscription, together with their child resources if recursive is set, as azlist.List does.
+// The tracked resources are listed page by page via listTrackedResources instead, which are deterministic and report the progress.
+func (meta baseMeta) listARG(ctx context.Context, subscriptionId, predicate string, recursive bool) ([]azlist.AzureResource, error) {
+	argClient, err := armresourcegraph.NewClient(meta.azureSDKCred, &meta.azureSDKClientOpt)
+	if err != nil {
+		return nil, fmt.Errorf("new ARG client: %v", err)
+	}
+	rl, err := listTrackedResources(ctx, argClient, subscriptionId, predicate, meta.hooks.OnListProgress)
+	if err != nil {
+		return nil, err
+	}
+	if !recursive {
+		return rl, nil
+	}
+
+	client, err := azlist.NewClient(subscriptionId, meta.azureSDKCred, meta.azureSDKClientOpt)
+	if err != nil {
+		return nil, fmt.Errorf("new client: %v", err)
+	}
+	schemaTree, err := azlist.BuildARMSchemaTree(azlist.ARMSchemaFile)
+	if err != nil {
+		return nil, err
+	}
+	result, err := azlist.ListChildResource(ctx, client, schemaTree, rl, meta.parallelism)
+	if err != nil {
+		return nil, err
+	}
+	// The resources managed by others (e.g. the managed disks of the AKS node pools) are removed.
+	rl = []azlist.AzureResource{}
+	for _, res := range result.Resources {
+		if v, ok := res.Properties["managedBy"]; ok && v != "" {
+			log.Printf("[INFO] Remove resource %s as it is managed by %s", res.Id.String(), v)
+			continue
+		}
+		rl = append(rl, res)
+	}
+	return rl, nil
+}
+
+// listTrackedResources lists the tracked resources that match the ARG predicate in the subscription, page by page, in the order of their ids.
+//
+// The total count is queried beforehand for the progress, which is reported once each page is listed. The result set might change during the paging, where:
+//   - The resources listed in more than one pages are deduplicated.
+//   - Once the skip token is rejected, the rest are listed from the last listed id.
+//   - The total reported is raised to the listed count, if exceeded.
+func listTrackedResources(ctx context.Context, client argClient, subscriptionId, predicate string, progress func(listed, total int)) ([]azlist.AzureResource, error) {
+	total, err := countResources(ctx, client, subscriptionId, predicate)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] %d tracked resources are counted in subscription %s", total, subscriptionId)
+	if progress != nil {
+		progress(0, total)
+	}
+
+	var rl []azlist.AzureResource
+	seen := map[string]bool{}
+	var skipToken *string
+	var lastId string
+	restarts := 0
+	for {
+		query := fmt.Sprintf("Resources | where %s | order by id asc", predicate)
+		if skipToken == nil && lastId != "" {
+			query = fmt.Sprintf("Resources | where %s | where strcmp(id, %q) > 0 | order by id asc", predicate, lastId)
+		}
+		req := argQueryRequest(subscriptionId, query)
+		req.Options.Top = ptr(argPageSize)
+		req.Options.SkipToken = skipToken
+		resp, err := client.Resources(ctx, req, nil)
+		if err != nil {
+			if skipToken != nil && isSkipTokenRejected(err) && restarts < argMaxRestarts {
+				log.Printf("[WARN] The skip token of the ARG query is rejected, listing the rest from %s: %v", lastId, err)
+				skipToken = nil
+				restarts++
+				continue
+			}
+			return nil, fmt.Errorf("executing ARG query %q: %v", query, err)
+		}
+		resources, err := argResources(resp.Data)
+		if err != nil {
+			return nil, fmt.Errorf("ARG query %q: %v", query, err)
+		}
+		for _, res := range resources {
+			key := strings.ToUpper(res.Id.String())
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			rl = append(rl, res)
+			if id := res.Id.String(); id > lastId {
+				lastId = id
+			}
+		}
+		if len(rl) > total {
+			total = len(rl)
+		}
+		if progress != nil {
+			progress(len(rl), total)
+		}
+		if resp.SkipToken == nil || *resp.SkipToken == "" {
+			break
+		}
+		skipToken = resp.SkipToken
+	}
+
+	sort.Slice(rl, func(i, j int) bool {
+		return rl[i].Id.String() < rl[j].Id.String()
+	})
+	return rl, nil
+}
+
+// countResources counts the tracked resources that match the ARG predicate in the subscription.
+func countResources(ctx context.Context, client argClient, subscriptionId, predicate string) (int, error) {
+	query := fmt.Sprintf("Resources | where %s | count", predicate)
+	resp, err := client.Resources(ctx, argQueryRequest(subscriptionId, query), nil)
+	if err != nil {
+		return 0, fmt.Errorf("executing ARG query %q: %v", query, err)
+	}
+	rows, ok := resp.Data.([]interface{})
+	if !ok || len(rows) != 1 {
+		return 0, fmt.Errorf("unexpected result of ARG query %q: %v", query, resp.Data)
+	}
+	row, ok := rows[0].(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("unexpected result of ARG query %q: %v", query, resp.Data)
+	}
+	switch v := row["Count"].(type) {
+	case float64:
+		return int(v), nil
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err
+	default:
+		return 0, fmt.Errorf("unexpected result of ARG query %q: %v", query, resp.Data)
+	}
+}
+
+func argQueryRequest(subscriptionId, query string) armresourcegraph.QueryRequest {
+	return armresourcegraph.QueryRequest{
+		Query: &query,
+		Options: &armresourcegraph.QueryRequestOptions{
+			AuthorizationScopeFilter: ptr(armresourcegraph.AuthorizationScopeFilterAtScopeAndBelow),
+			ResultFormat:             ptr(armresourcegraph.ResultFormatObjectArray),
+		},
+		Subscriptions: []*string{&subscriptionId},
+	}
+}
+
+// argResources parses the data of the ARG response in the object array format.
+func argResources(data interface{}) ([]azlist.AzureResource, error) {
+	rows, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected data of type %T", data)
+	}
+	var rl []azlist.AzureResource
+	for _, row := range rows {
+		resource, ok := row.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected resource of type %T", row)
+		}
+		id, _ := resource["id"].(string)
+		azureId, err := armid.ParseResourceId(id)
+		if err != nil {
+			return nil, fmt.Errorf("parsing resource id %s: %v", id, err)
+		}
+		rl = append(rl, azlist.AzureResource{
+			Id:         azureId,
+			Properties: resource,
+		})
+	}
+	return rl, nil
+}
+
+// isSkipTokenRejected tells whether the error is caused by a rejected skip token, which is a bad request.
+func isSkipTokenRejected(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusBadRequest
+}
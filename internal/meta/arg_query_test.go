@@ -0,0 +1,97 @@
+package meta
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeARGClient serves the pages in order, where the skip token of the page is its index. The skip token of rejectedToken is rejected once, and the queries from an id are served from all the pages.
+type fakeARGClient struct {
+	count         int
+	pages         [][]string
+	rejectedToken string
+	queries       []string
+}
+
+func (c *fakeARGClient) Resources(_ context.Context, query armresourcegraph.QueryRequest, _ *armresourcegraph.ClientResourcesOptions) (armresourcegraph.ClientResourcesResponse, error) {
+	c.queries = append(c.queries, *query.Query)
+	if strings.HasSuffix(*query.Query, "| count") {
+		return armresourcegraph.ClientResourcesResponse{QueryResponse: armresourcegraph.QueryResponse{
+			Data: []interface{}{map[string]interface{}{"Count": float64(c.count)}},
+		}}, nil
+	}
+	// The rest are served from the ids greater than the specified one.
+	if _, after, ok := strings.Cut(*query.Query, "strcmp(id, \""); ok {
+		from, _, _ := strings.Cut(after, "\"")
+		var data []interface{}
+		for _, page := range c.pages {
+			for _, id := range page {
+				if id > from {
+					data = append(data, map[string]interface{}{"id": id})
+				}
+			}
+		}
+		return armresourcegraph.ClientResourcesResponse{QueryResponse: armresourcegraph.QueryResponse{Data: data}}, nil
+	}
+	idx := 0
+	if token := query.Options.SkipToken; token != nil {
+		if *token == c.rejectedToken {
+			c.rejectedToken = ""
+			return armresourcegraph.ClientResourcesResponse{}, &azcore.ResponseError{StatusCode: http.StatusBadRequest}
+		}
+		idx = int((*token)[0] - '0')
+	}
+	var data []interface{}
+	for _, id := range c.pages[idx] {
+		data = append(data, map[string]interface{}{"id": id})
+	}
+	resp := armresourcegraph.ClientResourcesResponse{QueryResponse: armresourcegraph.QueryResponse{Data: data}}
+	if idx+1 < len(c.pages) {
+		resp.SkipToken = ptr(string(rune('0' + idx + 1)))
+	}
+	return resp, nil
+}
+
+func TestListTrackedResources(t *testing.T) {
+	rg := "/subscriptions/123/resourceGroups/rg"
+	client := &fakeARGClient{
+		count: 3,
+		pages: [][]string{
+			{rg + "/providers/Microsoft.Network/virtualNetworks/vnet1", rg + "/providers/Microsoft.Network/virtualNetworks/vnet2"},
+			// The resource listed in the former page is listed again, as a resource is added during the paging.
+			{rg + "/providers/Microsoft.Network/virtualNetworks/VNET2", rg + "/providers/Microsoft.Network/virtualNetworks/vnet3"},
+			{rg + "/providers/Microsoft.Network/virtualNetworks/vnet4"},
+		},
+		rejectedToken: "2",
+	}
+	var progress [][2]int
+	rl, err := listTrackedResources(context.Background(), client, "123", "1 == 1", func(listed, total int) {
+		progress = append(progress, [2]int{listed, total})
+	})
+	require.NoError(t, err)
+	var ids []string
+	for _, res := range rl {
+		ids = append(ids, res.Id.String())
+	}
+	require.Equal(t, []string{
+		rg + "/providers/Microsoft.Network/virtualNetworks/vnet1",
+		rg + "/providers/Microsoft.Network/virtualNetworks/vnet2",
+		rg + "/providers/Microsoft.Network/virtualNetworks/vnet3",
+		rg + "/providers/Microsoft.Network/virtualNetworks/vnet4",
+	}, ids)
+	require.Equal(t, [][2]int{{0, 3}, {2, 3}, {3, 3}, {4, 4}}, progress)
+	require.Equal(t, []string{
+		"Resources | where 1 == 1 | count",
+		"Resources | where 1 == 1 | order by id asc",
+		"Resources | where 1 == 1 | order by id asc",
+		"Resources | where 1 == 1 | order by id asc",
+		// The rest are listed from the last listed id, once the skip token is rejected.
+		`Resources | where 1 == 1 | where strcmp(id, "` + rg + `/providers/Microsoft.Network/virtualNetworks/vnet3") > 0 | order by id asc`,
+	}, client.queries)
+}
@@ -0,0 +1,177 @@
+package meta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/magodo/armid"
+	"github.com/magodo/workerpool"
+)
+
+// armBodyFetcher fetches the ARM body of the Azure resource.
+type armBodyFetcher func(id armid.ResourceId) (map[string]interface{}, error)
+
+// addARMDependency adds the dependencies on the exported resources that are referred to by the ARM body of each resource, but are neither referenced by its config,
+// nor already its dependencies (e.g. the property is not modelled by the TF resource, or the ordering constraint between a policy and its assignment).
+// The dependency that would introduce a cycle is skipped, which is typically a back reference (e.g. the "virtualMachine" of a network interface).
+// This is expected to be called after the dependencies are added by ConfigInfos.AddDependency.
+func (cfgs ConfigInfos) addARMDependency(parallelism int, fetch armBodyFetcher) error {
+	// Uppercased Azure resource id to the Azure resource id
+	ids := map[string]string{}
+	// TF resource address to the Azure resource id
+	addrs := map[string]string{}
+	for _, cfg := range cfgs {
+		ids[strings.ToUpper(cfg.AzureResourceID.String())] = cfg.AzureResourceID.String()
+		addrs[cfg.TFAddr.String()] = cfg.AzureResourceID.String()
+	}
+
+	// The existing dependencies, including the references
+	graph := map[string]map[string]bool{}
+	for _, cfg := range cfgs {
+		id := cfg.AzureResourceID.String()
+		graph[id] = map[string]bool{}
+		for _, dep := range cfg.DependsOn {
+			for _, c := range dep.Candidates {
+				graph[id][c] = true
+			}
+		}
+		file, diags := hclsyntax.ParseConfig(cfg.hcl.Bytes(), "main.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			return fmt.Errorf("parsing hcl for %s: %v", cfg.AzureResourceID, diags.Error())
+		}
+		// #nosec G104
+		hclsyntax.VisitAll(file.Body.(*hclsyntax.Body), func(node hclsyntax.Node) hcl.Diagnostics {
+			expr, ok := node.(*hclsyntax.ScopeTraversalExpr)
+			if !ok || len(expr.Traversal) < 2 {
+				return nil
+			}
+			attr, ok := expr.Traversal[1].(hcl.TraverseAttr)
+			if !ok {
+				return nil
+			}
+			if dep, ok := addrs[expr.Traversal.RootName()+"."+attr.Name]; ok && dep != id {
+				graph[id][dep] = true
+			}
+			return nil
+		})
+	}
+
+	// Fetch the ARM bodies, the resources failed to fetch are ignored.
+	var mu sync.Mutex
+	armRefs := map[string][]string{}
+	wp := workerpool.NewWorkPool(parallelism)
+	wp.Run(nil)
+	for _, cfg := range cfgs {
+		cfg := cfg
+		wp.AddTask(func() (interface{}, error) {
+			body, err := fetch(cfg.AzureResourceID)
+			if err != nil {
+				log.Printf("[WARN] Failed to get the ARM body of %s for the dependency analysis: %v", cfg.AzureResourceID, err)
+				return nil, nil
+			}
+			refs := armBodyResourceIds(body, ids)
+			mu.Lock()
+			armRefs[cfg.AzureResourceID.String()] = refs
+			mu.Unlock()
+			return nil, nil
+		})
+	}
+	// #nosec G104
+	wp.Done()
+
+	// reachable tells whether the "to" is reachable from the "from" in the dependency graph
+	reachable := func(from, to string) bool {
+		visited := map[string]bool{}
+		stack := []string{from}
+		for len(stack) != 0 {
+			cur := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if cur == to {
+				return true
+			}
+			if visited[cur] {
+				continue
+			}
+			visited[cur] = true
+			for next := range graph[cur] {
+				stack = append(stack, next)
+			}
+		}
+		return false
+	}
+
+	// Add the dependencies in a deterministic order, as the cycle detection depends on the order.
+	idxs := make([]int, len(cfgs))
+	for i := range idxs {
+		idxs[i] = i
+	}
+	sort.Slice(idxs, func(i, j int) bool {
+		return cfgs[idxs[i]].AzureResourceID.String() < cfgs[idxs[j]].AzureResourceID.String()
+	})
+	for _, i := range idxs {
+		cfg := cfgs[i]
+		id := cfg.AzureResourceID.String()
+		for _, dep := range armRefs[id] {
+			uid, udep := strings.ToUpper(id), strings.ToUpper(dep)
+			// The ancestors are covered by the parent dependency, while the descendants are back references.
+			if dep == id || strings.HasPrefix(uid, udep+"/") || strings.HasPrefix(udep, uid+"/") {
+				continue
+			}
+			if graph[id][dep] || reachable(dep, id) {
+				continue
+			}
+			log.Printf("[DEBUG] Adding the dependency of %s on %s, as referred to by its ARM body", id, dep)
+			graph[id][dep] = true
+			cfg.DependsOn = append(cfg.DependsOn, Dependency{Candidates: []string{dep}})
+		}
+		sort.SliceStable(cfg.DependsOn, func(i, j int) bool {
+			d1, d2 := cfg.DependsOn[i], cfg.DependsOn[j]
+			if len(d1.Candidates) != len(d2.Candidates) {
+				return len(d1.Candidates) < len(d2.Candidates)
+			}
+			return strings.Join(d1.Candidates, "") < strings.Join(d2.Candidates, "")
+		})
+		cfgs[i] = cfg
+	}
+	return nil
+}
+
+// armBodyResourceIds returns the sorted resource ids in the ARM body, that are in the uppercased id set.
+func armBodyResourceIds(body map[string]interface{}, ids map[string]string) []string {
+	set := map[string]bool{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for _, v := range v {
+				walk(v)
+			}
+		case []interface{}:
+			for _, v := range v {
+				walk(v)
+			}
+		case string:
+			if id, ok := ids[strings.ToUpper(v)]; ok {
+				set[id] = true
+			}
+		}
+	}
+	// The id of the resource itself is skipped
+	for k, v := range body {
+		if k == "id" {
+			continue
+		}
+		walk(v)
+	}
+	var out []string
+	for id := range set {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,97 @@
+package meta
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddARMDependency(t *testing.T) {
+	const (
+		defId    = "/subscriptions/123/providers/Microsoft.Authorization/policyDefinitions/def"
+		assignId = "/subscriptions/123/providers/Microsoft.Authorization/policyAssignments/assign"
+		rgId     = "/subscriptions/123/resourceGroups/rg"
+		vnetId   = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"
+		subnetId = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet"
+		nicId    = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/nic"
+		vmId     = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"
+	)
+	cfg := func(id, tfType, name, input string) ConfigInfo {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		f, diags := hclwrite.ParseConfig([]byte(input), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return ConfigInfo{
+			ImportItem: ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}},
+			hcl:        f,
+		}
+	}
+	configs := ConfigInfos{
+		cfg(defId, "azurerm_policy_definition", "res-0", `resource "azurerm_policy_definition" "res-0" {
+}
+`),
+		cfg(assignId, "azurerm_subscription_policy_assignment", "res-1", `resource "azurerm_subscription_policy_assignment" "res-1" {
+}
+`),
+		cfg(rgId, "azurerm_resource_group", "res-2", `resource "azurerm_resource_group" "res-2" {
+}
+`),
+		cfg(vnetId, "azurerm_virtual_network", "res-3", `resource "azurerm_virtual_network" "res-3" {
+}
+`),
+		cfg(subnetId, "azurerm_subnet", "res-4", `resource "azurerm_subnet" "res-4" {
+  virtual_network_name = azurerm_virtual_network.res-3.name
+}
+`),
+		cfg(nicId, "azurerm_network_interface", "res-5", `resource "azurerm_network_interface" "res-5" {
+}
+`),
+		cfg(vmId, "azurerm_linux_virtual_machine", "res-6", `resource "azurerm_linux_virtual_machine" "res-6" {
+  network_interface_ids = [azurerm_network_interface.res-5.id]
+}
+`),
+	}
+	bodies := map[string]map[string]interface{}{
+		defId: {"id": defId},
+		// The policy definition is not modelled by the assignment config
+		assignId: {"id": assignId, "properties": map[string]interface{}{"policyDefinitionId": strings.ToLower(defId)}},
+		// The back reference to the child resource
+		vnetId: {"id": vnetId, "properties": map[string]interface{}{"subnets": []interface{}{map[string]interface{}{"id": subnetId}}}},
+		// The reference to the parent resource
+		subnetId: {"id": subnetId, "properties": map[string]interface{}{"vnet": vnetId}},
+		// The back reference that would introduce a cycle
+		nicId: {"id": nicId, "properties": map[string]interface{}{"virtualMachine": map[string]interface{}{"id": vmId}}},
+		// The reference that is already in place
+		vmId: {"id": vmId, "properties": map[string]interface{}{"networkProfile": map[string]interface{}{"networkInterfaces": []interface{}{map[string]interface{}{"id": nicId}}}}},
+	}
+	fetch := func(id armid.ResourceId) (map[string]interface{}, error) {
+		body, ok := bodies[id.String()]
+		if !ok {
+			return nil, fmt.Errorf("not found")
+		}
+		return body, nil
+	}
+
+	require.NoError(t, configs.AddDependency())
+	require.NoError(t, configs.addARMDependency(2, fetch))
+
+	deps := map[string][]Dependency{}
+	for _, cfg := range configs {
+		deps[cfg.AzureResourceID.String()] = cfg.DependsOn
+	}
+	require.Equal(t, map[string][]Dependency{
+		defId:    nil,
+		assignId: {{Candidates: []string{defId}}},
+		rgId:     nil,
+		vnetId:   {{Candidates: []string{rgId}}},
+		subnetId: {{Candidates: []string{vnetId}}},
+		nicId:    {{Candidates: []string{rgId}}},
+		vmId:     {{Candidates: []string{rgId}}},
+	}, deps)
+}
@@ -0,0 +1,135 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/aztfexport/internal/armschema"
+	"github.com/Azure/aztfexport/internal/gencache"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/magodo/armid"
+	"github.com/magodo/workerpool"
+)
+
+// writeARMJSON writes the raw ARM JSON (as returned by the API) of each imported resource to the ARMJSONDirName directory under the output directory.
+// Each file is named after the TF resource address of the resource.
+func (meta baseMeta) writeARMJSON(ctx context.Context, l ImportList) error {
+	dir := filepath.Join(meta.outdir, ARMJSONDirName)
+	// #nosec G301
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("creating directory %s: %v", dir, err)
+	}
+
+	wp := workerpool.NewWorkPool(meta.parallelism)
+	wp.Run(nil)
+	for _, item := range l.Imported() {
+		item := item
+		wp.AddTask(func() (interface{}, error) {
+			b, err := meta.getARMJSON(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			path := filepath.Join(dir, item.TFAddr.String()+".json")
+			// #nosec G306
+			if err := os.WriteFile(path, b, 0644); err != nil {
+				return nil, fmt.Errorf("writing the ARM JSON of %s to %s: %v", item.AzureResourceID, path, err)
+			}
+			return nil, nil
+		})
+	}
+	return wp.Done()
+}
+
+func (meta baseMeta) getARMJSON(ctx context.Context, item ImportItem) ([]byte, error) {
+	id := item.AzureResourceID
+	apiVersion, err := armschema.LatestAPIVersion(id.TypeString())
+	if err != nil {
+		return nil, fmt.Errorf("getting the API version of %s: %v", id, err)
+	}
+	payload, err := meta.getARMResource(ctx, id, apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, payload, "", "\t"); err != nil {
+		return nil, fmt.Errorf("indenting the ARM JSON of %s: %v", id, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// getARMResource gets the raw response body of the Azure resource of the specified API version.
+// If the cache is enabled, the cached body is returned if the resource is not modified since then (i.e. the etag matches).
+func (meta baseMeta) getARMResource(ctx context.Context, id armid.ResourceId, apiVersion string) ([]byte, error) {
+	var cached []byte
+	if meta.cache != nil {
+		b, ok, err := meta.cache.GetARM(id.String(), apiVersion)
+		if err != nil {
+			log.Printf("[WARN] %v", err)
+		}
+		if ok {
+			cached = b
+			ctx = runtime.WithHTTPHeader(ctx, http.Header{"If-None-Match": []string{gencache.ETag(b)}})
+		}
+	}
+
+	log.Printf("[DEBUG] Getting the ARM JSON of %s (api-version=%s)", id, apiVersion)
+	var resp *http.Response
+	if _, err := meta.resourceClient.GetByID(runtime.WithCaptureResponse(ctx, &resp), id.String(), apiVersion, nil); err != nil {
+		var respErr *azcore.ResponseError
+		if cached != nil && errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotModified {
+			log.Printf("[DEBUG] Using the cached ARM JSON of %s (api-version=%s)", id, apiVersion)
+			return cached, nil
+		}
+		return nil, fmt.Errorf("getting %s: %v", id, err)
+	}
+	payload, err := runtime.Payload(resp)
+	if err != nil {
+		return nil, fmt.Errorf("reading the response body of %s: %v", id, err)
+	}
+	if meta.cache != nil {
+		if err := meta.cache.PutARM(id.String(), apiVersion, payload); err != nil {
+			log.Printf("[WARN] %v", err)
+		}
+	}
+	return payload, nil
+}
+
+// resourceETag returns the etag of the Azure resource in the latest API version, which is empty if the resource has no etag, or it fails to get the resource.
+func (meta baseMeta) resourceETag(ctx context.Context, id armid.ResourceId) string {
+	apiVersion, err := armschema.LatestAPIVersion(id.TypeString())
+	if err != nil {
+		log.Printf("[WARN] Failed to get the API version of %s: %v", id, err)
+		return ""
+	}
+	payload, err := meta.getARMResource(ctx, id, apiVersion)
+	if err != nil {
+		log.Printf("[WARN] Failed to get the etag of %s: %v", id, err)
+		return ""
+	}
+	return gencache.ETag(payload)
+}
+
+// getARMResourceBody gets the body of the Azure resource in the latest API version.
+func (meta baseMeta) getARMResourceBody(id armid.ResourceId) (map[string]interface{}, error) {
+	apiVersion, err := armschema.LatestAPIVersion(id.TypeString())
+	if err != nil {
+		return nil, fmt.Errorf("getting the API version of %s: %v", id, err)
+	}
+	payload, err := meta.getARMResource(context.Background(), id, apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, fmt.Errorf("unmarshalling the body of %s: %v", id, err)
+	}
+	return body, nil
+}
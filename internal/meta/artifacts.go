@@ -0,0 +1,94 @@
+package meta
+
+// The artifacts generated to the output directory, besides the Terraform configuration and state.
+// Remember to add the new ones to generatedArtifactNames, so that they are kept when the workspace is cleaned up.
+const (
+	// WorkspaceLockFileName is the lock file of the output directory, which prevents the concurrent runs against the same output directory.
+	WorkspaceLockFileName = ".aztfexport.lock"
+
+	// ARMJSONDirName is the directory under the output directory that holds the raw ARM JSON of each exported resource.
+	ARMJSONDirName = "aztfexportARM"
+
+	// SpaceliftConfigDirName is the directory under the output directory that holds the Spacelift runtime config.
+	SpaceliftConfigDirName = ".spacelift"
+
+	// Env0ConfigFileName is the env0 custom flow config under the output directory.
+	Env0ConfigFileName = "env0.yml"
+
+	// BackstageCatalogFileName is the Backstage catalog under the output directory, which describes the exported module and resources.
+	BackstageCatalogFileName = "catalog-info.yaml"
+
+	// InventoryFileName is the inventory of the exported resources under the output directory.
+	InventoryFileName = "aztfexportInventory.json"
+
+	// GraphDotFileName and GraphMermaidFileName are the dependency graph of the exported resources under the output directory.
+	GraphDotFileName     = "aztfexportGraph.dot"
+	GraphMermaidFileName = "aztfexportGraph.mmd"
+
+	// MovedBlocksFileName is the file under the output directory that contains the moved and removed blocks, which refactor the state of the former workspace (see MovedFromState)
+	// to the addresses of the exported resources.
+	MovedBlocksFileName = "moved.tf"
+
+	// AKSProvidersFileName is the file under the output directory that holds the kubernetes and helm provider blocks of the exported AKS clusters.
+	AKSProvidersFileName = "aks-providers.tf"
+
+	// OutputsFileName is the file under the output directory that holds the outputs of the exported resources.
+	OutputsFileName = "outputs.tf"
+
+	// CLIConfigFileName is the Terraform CLI config file generated to the output directory when a provider mirror is specified.
+	// It is also used by aztfexport itself (via TF_CLI_CONFIG_FILE) when running terraform.
+	CLIConfigFileName = "aztfexport.tfrc"
+
+	// KeyVaultSecretsFileName is the file under the module directory that holds the data sources of the Key Vault secrets referenced by the generated config.
+	KeyVaultSecretsFileName = "keyvault-secrets.tf"
+
+	// DataSourcesFileName is the file that contains the data sources of the resources that are referenced by the exported resources, but are not exported.
+	DataSourcesFileName = "data-sources.tf"
+
+	// VariablesFileName is the file under the module directory that declares the variables extracted from the generated config.
+	VariablesFileName = "variables.tf"
+
+	// SecretsReportFileName is the file under the output directory that records the secrets found in the generated config, and the action taken.
+	SecretsReportFileName = "aztfexportSecretsReport.json"
+
+	// AuthScaffoldFileName is the file under the output directory that holds the commented provider config reflecting the authentication used during the export.
+	AuthScaffoldFileName = "provider_auth.tf"
+
+	// EnvSplitDirName is the directory under the output directory that holds the reusable module and the per-environment root configs.
+	EnvSplitDirName = "environments"
+
+	// SplitModulesDirName is the directory under the output directory that holds the child modules when the generated config is split.
+	SplitModulesDirName = "modules"
+
+	// IgnoreFileName is the file under the output directory that specifies the resources to ignore, which is picked up automatically,
+	// so that the recurring exports to the directory carry their exclusions with them.
+	IgnoreFileName = ".aztfexportignore"
+
+	// AuditLogFileName is the audit log of the terraform commands executed, in the output directory.
+	AuditLogFileName = "aztfexportAuditLog.jsonl"
+)
+
+// generatedArtifactNames are the artifacts under the output directory that are kept by CleanUpWorkspace in the HCL only mode.
+var generatedArtifactNames = []string{
+	WorkspaceLockFileName,
+	ARMJSONDirName,
+	SpaceliftConfigDirName,
+	Env0ConfigFileName,
+	BackstageCatalogFileName,
+	InventoryFileName,
+	GraphDotFileName,
+	GraphMermaidFileName,
+	MovedBlocksFileName,
+	AKSProvidersFileName,
+	OutputsFileName,
+	CLIConfigFileName,
+	KeyVaultSecretsFileName,
+	DataSourcesFileName,
+	VariablesFileName,
+	SecretsReportFileName,
+	AuthScaffoldFileName,
+	EnvSplitDirName,
+	SplitModulesDirName,
+	IgnoreFileName,
+	AuditLogFileName,
+}
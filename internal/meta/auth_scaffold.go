@@ -0,0 +1,118 @@
+package meta
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	AuthMethodDefault          = "default"
+	AuthMethodEnvironment      = "environment"
+	AuthMethodManagedIdentity  = "managed-identity"
+	AuthMethodAzureCLI         = "azure-cli"
+	AuthMethodOIDC             = "oidc"
+	AuthMethodWorkloadIdentity = "workload-identity"
+)
+
+// AuthMethods are the authentication methods that the auth scaffold can reflect.
+var AuthMethods = []string{AuthMethodDefault, AuthMethodEnvironment, AuthMethodManagedIdentity, AuthMethodAzureCLI, AuthMethodOIDC, AuthMethodWorkloadIdentity}
+
+// BackendVarsFileName is the file under the output directory that holds the backend config used during the export, which is passed to "terraform init -backend-config".
+const BackendVarsFileName = "backend.tfvars"
+
+// backendSecretKeys are the backend config keys that hold secrets, besides the ones matched by the secretAttributeRegex.
+var backendSecretKeys = map[string]bool{"sas_token": true}
+
+// authScaffoldFiles builds the auth scaffold files, keyed by the file name under the output directory.
+// The secrets (e.g. the client secret, the backend access key) are never written, but left to the corresponding ARM_* environment variables.
+func (meta baseMeta) authScaffoldFiles() map[string][]byte {
+	scaffold := meta.authScaffold
+
+	f := hclwrite.NewEmptyFile()
+	body := f.Body().AppendNewBlock("provider", []string{meta.providerName}).Body()
+	if meta.providerName == ProviderAzureRM {
+		meta.appendProviderFeatures(body)
+	}
+	body.SetAttributeValue("subscription_id", cty.StringVal(meta.subscriptionId))
+	if scaffold.Environment != "" {
+		body.SetAttributeValue("environment", cty.StringVal(strings.ToLower(scaffold.Environment)))
+	}
+	if scaffold.TenantId != "" {
+		body.SetAttributeValue("tenant_id", cty.StringVal(scaffold.TenantId))
+	}
+	if scaffold.ClientId != "" {
+		body.SetAttributeValue("client_id", cty.StringVal(scaffold.ClientId))
+	}
+	var note string
+	switch scaffold.Method {
+	case AuthMethodDefault:
+		note = "No authentication method is pinned, the provider authenticates via the ARM_* environment variables or the Azure CLI by default."
+	case AuthMethodEnvironment:
+		note = "The client secret (or certificate) is not written, set it via ARM_CLIENT_SECRET (or ARM_CLIENT_CERTIFICATE_PATH)."
+	case AuthMethodManagedIdentity:
+		body.SetAttributeValue("use_msi", cty.True)
+	case AuthMethodAzureCLI:
+		body.SetAttributeValue("use_cli", cty.True)
+	case AuthMethodOIDC:
+		body.SetAttributeValue("use_oidc", cty.True)
+		note = "The ID token is not written, set it via ARM_OIDC_TOKEN, ARM_OIDC_TOKEN_FILE_PATH, or ARM_OIDC_REQUEST_TOKEN and ARM_OIDC_REQUEST_URL."
+	case AuthMethodWorkloadIdentity:
+		body.SetAttributeValue("use_aks_workload_identity", cty.True)
+		note = "The federated token is read from the AZURE_FEDERATED_TOKEN_FILE, which is set by the workload identity webhook."
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# The provider config that reflects the authentication used during the export (%s).\n", scaffold.Method)
+	fmt.Fprintf(&buf, "# Merge it into the provider block of %s, or set the corresponding ARM_* environment variables instead.\n", meta.outputFileNames.ProviderFileName)
+	if note != "" {
+		fmt.Fprintf(&buf, "# %s\n", note)
+	}
+	buf.WriteString("#\n")
+	for _, line := range strings.Split(strings.TrimSuffix(string(hclwrite.Format(f.Bytes())), "\n"), "\n") {
+		buf.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+	}
+	files := map[string][]byte{AuthScaffoldFileName: buf.Bytes()}
+
+	if meta.backendType == "" || meta.backendType == "local" || len(meta.backendConfig) == 0 {
+		return files
+	}
+	vars := hclwrite.NewEmptyFile()
+	var secrets []string
+	for _, opt := range meta.backendConfig {
+		k, v, _ := strings.Cut(opt, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if secretAttributeRegex.MatchString(k) || backendSecretKeys[k] {
+			secrets = append(secrets, k)
+			continue
+		}
+		vars.Body().SetAttributeValue(k, cty.StringVal(v))
+	}
+	var backend bytes.Buffer
+	fmt.Fprintf(&backend, "# The %s backend config used during the export, which is passed to \"terraform init -backend-config=%s\".\n", meta.backendType, BackendVarsFileName)
+	sort.Strings(secrets)
+	for _, k := range secrets {
+		fmt.Fprintf(&backend, "# The %s is not written, set it via ARM_%s.\n", k, strings.ToUpper(k))
+	}
+	backend.Write(hclwrite.Format(vars.Bytes()))
+	files[BackendVarsFileName] = backend.Bytes()
+	return files
+}
+
+// writeAuthScaffold writes the auth scaffold files to the output directory.
+func (meta baseMeta) writeAuthScaffold() error {
+	for name, b := range meta.authScaffoldFiles() {
+		path := filepath.Join(meta.outdir, name)
+		// #nosec G306
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			return fmt.Errorf("writing the auth scaffold to %s: %v", path, err)
+		}
+	}
+	return nil
+}
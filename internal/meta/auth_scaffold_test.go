@@ -0,0 +1,97 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthScaffoldFiles(t *testing.T) {
+	cases := []struct {
+		name          string
+		meta          baseMeta
+		expectAuth    string
+		expectBackend string
+	}{
+		{
+			name: "azure cli with local backend",
+			meta: baseMeta{
+				providerName:    ProviderAzureRM,
+				subscriptionId:  "123",
+				outputFileNames: config.OutputFileNames{ProviderFileName: "provider.tf"},
+				backendType:     "local",
+				authScaffold:    &config.AuthScaffold{Method: AuthMethodAzureCLI, Environment: "Public"},
+			},
+			expectAuth: `# The provider config that reflects the authentication used during the export (azure-cli).
+# Merge it into the provider block of provider.tf, or set the corresponding ARM_* environment variables instead.
+#
+# provider "azurerm" {
+#   features {
+#   }
+#   subscription_id = "123"
+#   environment     = "public"
+#   use_cli         = true
+# }
+`,
+		},
+		{
+			name: "workload identity",
+			meta: baseMeta{
+				providerName:    ProviderAzureRM,
+				subscriptionId:  "123",
+				outputFileNames: config.OutputFileNames{ProviderFileName: "provider.tf"},
+				authScaffold:    &config.AuthScaffold{Method: AuthMethodWorkloadIdentity, TenantId: "tenant", ClientId: "client"},
+			},
+			expectAuth: `# The provider config that reflects the authentication used during the export (workload-identity).
+# Merge it into the provider block of provider.tf, or set the corresponding ARM_* environment variables instead.
+# The federated token is read from the AZURE_FEDERATED_TOKEN_FILE, which is set by the workload identity webhook.
+#
+# provider "azurerm" {
+#   features {
+#   }
+#   subscription_id           = "123"
+#   tenant_id                 = "tenant"
+#   client_id                 = "client"
+#   use_aks_workload_identity = true
+# }
+`,
+		},
+		{
+			name: "service principal with azurerm backend",
+			meta: baseMeta{
+				providerName:    ProviderAzAPI,
+				subscriptionId:  "123",
+				outputFileNames: config.OutputFileNames{ProviderFileName: "provider.tf"},
+				backendType:     "azurerm",
+				backendConfig:   []string{"resource_group_name=rg", "storage_account_name=sa", "container_name=tfstate", "key=export.tfstate", "access_key=xxx", "sas_token=yyy"},
+				authScaffold:    &config.AuthScaffold{Method: AuthMethodEnvironment, TenantId: "tenant", ClientId: "client"},
+			},
+			expectAuth: `# The provider config that reflects the authentication used during the export (environment).
+# Merge it into the provider block of provider.tf, or set the corresponding ARM_* environment variables instead.
+# The client secret (or certificate) is not written, set it via ARM_CLIENT_SECRET (or ARM_CLIENT_CERTIFICATE_PATH).
+#
+# provider "azapi" {
+#   subscription_id = "123"
+#   tenant_id       = "tenant"
+#   client_id       = "client"
+# }
+`,
+			expectBackend: `# The azurerm backend config used during the export, which is passed to "terraform init -backend-config=backend.tfvars".
+# The access_key is not written, set it via ARM_ACCESS_KEY.
+# The sas_token is not written, set it via ARM_SAS_TOKEN.
+resource_group_name  = "rg"
+storage_account_name = "sa"
+container_name       = "tfstate"
+key                  = "export.tfstate"
+`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			files := tt.meta.authScaffoldFiles()
+			require.Equal(t, tt.expectAuth, string(files[AuthScaffoldFileName]))
+			require.Equal(t, tt.expectBackend, string(files[BackendVarsFileName]))
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/armschema"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const (
+	ProviderAzureRM = "azurerm"
+	ProviderAzAPI   = "azapi"
+)
+
+// Providers are the supported Terraform providers that the resources can be exported to.
+var Providers = []string{ProviderAzureRM, ProviderAzAPI}
+
+const (
+	// AzAPIProviderVersion is the default azapi provider version used when exporting to azapi.
+	AzAPIProviderVersion = "1.9.0"
+	AzAPIResourceType    = "azapi_resource"
+)
+
+// AzAPIResourceId returns the azapi_resource import id of the Azure resource, which is the Azure resource id suffixed by the API version.
+func AzAPIResourceId(id armid.ResourceId, apiVersion string) string {
+	return id.String() + "?api-version=" + apiVersion
+}
+
+// azapiAPIVersion returns the API version recorded in the azapi_resource import id. If it is absent, use the latest API version of the resource type.
+func azapiAPIVersion(item ImportItem) (string, error) {
+	if _, query, ok := strings.Cut(item.TFResourceId, "?"); ok {
+		for _, kv := range strings.Split(query, "&") {
+			if k, v, ok := strings.Cut(kv, "="); ok && k == "api-version" {
+				return v, nil
+			}
+		}
+	}
+	return armschema.LatestAPIVersion(item.AzureResourceID.TypeString())
+}
+
+// azapiConfig generates the azapi_resource config of the import item from its ARM JSON.
+func (meta baseMeta) azapiConfig(ctx context.Context, item ImportItem) (*hclwrite.File, error) {
+	apiVersion, err := azapiAPIVersion(item)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := meta.getARMResource(ctx, item.AzureResourceID, apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	return azapiResourceHCL(item.TFAddr, item.AzureResourceID, apiVersion, payload)
+}
+
+// azapiResourceHCL builds the azapi_resource block from the ARM JSON of the resource.
+// The top level ARM properties that have a dedicated azapi attribute (e.g. location, tags) are set to that attribute, while the others go to the body.
+func azapiResourceHCL(addr tfaddr.TFAddr, id armid.ResourceId, apiVersion string, payload []byte) (*hclwrite.File, error) {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(payload, &obj); err != nil {
+		return nil, fmt.Errorf("unmarshalling the ARM JSON of %s: %v", id, err)
+	}
+
+	parentId := id.Parent()
+	if parentId == nil {
+		parentId = id.ParentScope()
+	}
+	if parentId == nil {
+		return nil, fmt.Errorf("%s is a root scope, which can't be managed by %s", id, AzAPIResourceType)
+	}
+
+	f := hclwrite.NewEmptyFile()
+	body := f.Body().AppendNewBlock("resource", []string{AzAPIResourceType, addr.Name}).Body()
+	body.SetAttributeValue("type", cty.StringVal(id.TypeString()+"@"+apiVersion))
+	body.SetAttributeValue("parent_id", cty.StringVal(parentId.String()))
+	names := id.Names()
+	body.SetAttributeValue("name", cty.StringVal(names[len(names)-1]))
+	if v, ok := obj["location"].(string); ok && v != "" {
+		body.SetAttributeValue("location", cty.StringVal(v))
+	}
+	if v, ok := obj["tags"].(map[string]interface{}); ok && len(v) != 0 {
+		tags := map[string]cty.Value{}
+		for k, tv := range v {
+			tags[k] = cty.StringVal(fmt.Sprint(tv))
+		}
+		body.SetAttributeValue("tags", cty.MapVal(tags))
+	}
+	if v, ok := obj["identity"].(map[string]interface{}); ok {
+		if typ, ok := v["type"].(string); ok && !strings.EqualFold(typ, "None") {
+			ib := body.AppendNewBlock("identity", nil).Body()
+			ib.SetAttributeValue("type", cty.StringVal(typ))
+			if uids, ok := v["userAssignedIdentities"].(map[string]interface{}); ok && len(uids) != 0 {
+				var ids []string
+				for k := range uids {
+					ids = append(ids, k)
+				}
+				sort.Strings(ids)
+				var vals []cty.Value
+				for _, id := range ids {
+					vals = append(vals, cty.StringVal(id))
+				}
+				ib.SetAttributeValue("identity_ids", cty.ListVal(vals))
+			}
+		}
+	}
+
+	for _, k := range []string{"id", "name", "type", "location", "tags", "identity", "etag", "systemData"} {
+		delete(obj, k)
+	}
+	if props, ok := obj["properties"].(map[string]interface{}); ok {
+		delete(props, "provisioningState")
+	}
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling the body of %s: %v", id, err)
+	}
+	ty, err := ctyjson.ImpliedType(b)
+	if err != nil {
+		return nil, fmt.Errorf("implying the type of the body of %s: %v", id, err)
+	}
+	val, err := ctyjson.Unmarshal(b, ty)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling the body of %s: %v", id, err)
+	}
+	body.SetAttributeRaw("body", hclwrite.TokensForFunctionCall("jsonencode", hclwrite.TokensForValue(val)))
+
+	// The body contains read-only properties returned by the API, which fail the schema validation.
+	body.SetAttributeValue("schema_validation_enabled", cty.False)
+	return f, nil
+}
@@ -0,0 +1,95 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzAPIResourceHCL(t *testing.T) {
+	id, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet")
+	require.NoError(t, err)
+	payload := `{
+  "id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+  "name": "vnet",
+  "type": "Microsoft.Network/virtualNetworks",
+  "location": "westus",
+  "etag": "W/\"xxx\"",
+  "tags": {"env": "dev"},
+  "properties": {
+    "provisioningState": "Succeeded",
+    "addressSpace": {"addressPrefixes": ["10.0.0.0/16"]}
+  }
+}`
+	f, err := azapiResourceHCL(tfaddr.TFAddr{Type: AzAPIResourceType, Name: "res-0"}, id, "2023-04-01", []byte(payload))
+	require.NoError(t, err)
+	require.Equal(t, `resource "azapi_resource" "res-0" {
+  type      = "Microsoft.Network/virtualNetworks@2023-04-01"
+  parent_id = "/subscriptions/123/resourceGroups/rg"
+  name      = "vnet"
+  location  = "westus"
+  tags = {
+    env = "dev"
+  }
+  body = jsonencode({
+    properties = {
+      addressSpace = {
+        addressPrefixes = ["10.0.0.0/16"]
+      }
+    }
+  })
+  schema_validation_enabled = false
+}
+`, string(hclwrite.Format(f.Bytes())))
+}
+
+func TestAzAPIAPIVersion(t *testing.T) {
+	id, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg")
+	require.NoError(t, err)
+	v, err := azapiAPIVersion(ImportItem{AzureResourceID: id, TFResourceId: AzAPIResourceId(id, "2021-04-01")})
+	require.NoError(t, err)
+	require.Equal(t, "2021-04-01", v)
+}
+
+func TestBuildTerraformConfigAzAPIFallback(t *testing.T) {
+	meta := baseMeta{
+		providerName:    ProviderAzureRM,
+		providerVersion: "3.0.0",
+		azapiFallback:   true,
+	}
+	require.Equal(t, `terraform {
+  backend "local" {}
+  required_providers {
+    azurerm = {
+      source = "hashicorp/azurerm"
+      version = "3.0.0"
+    }
+    azapi = {
+      source = "azure/azapi"
+      version = "`+AzAPIProviderVersion+`"
+    }
+  }
+}
+`, meta.buildTerraformConfig("local"))
+	require.Equal(t, `provider "azurerm" {
+  features {
+  }
+}
+provider "azapi" {
+}
+`, meta.buildProviderConfig(meta.ProviderNames()...))
+}
+
+func TestMappingHasAzAPIResource(t *testing.T) {
+	require.False(t, mappingHasAzAPIResource([]resourceMappingEntry{
+		{line: 2, id: "/subscriptions/123/resourceGroups/rg", res: resmap.ResourceMapEntity{ResourceType: "azurerm_resource_group", ResourceName: "res-0"}},
+	}))
+	require.True(t, mappingHasAzAPIResource([]resourceMappingEntry{
+		{line: 2, id: "/subscriptions/123/resourceGroups/rg", res: resmap.ResourceMapEntity{ResourceType: "azurerm_resource_group", ResourceName: "res-0"}},
+		{line: 7, id: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/bars/bar", res: resmap.ResourceMapEntity{ResourceType: AzAPIResourceType, ResourceName: "res-1"}},
+	}))
+}
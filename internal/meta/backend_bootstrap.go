@@ -0,0 +1,203 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/magodo/armid"
+)
+
+const (
+	backendStorageAPIVersion = "2022-09-01"
+	// backendStorageKind is the kind and sku of the bootstrapped storage account, which supports the blob versioning.
+	backendStorageKind = "StorageV2"
+	backendStorageSku  = "Standard_LRS"
+)
+
+// azurermBackend is the storage of the state of the azurerm backend, as specified by the backend config.
+type azurermBackend struct {
+	subscriptionId     string
+	resourceGroupName  string
+	storageAccountName string
+	containerName      string
+}
+
+// parseAzurermBackend parses the azurerm backend from the backend config (in form of "key=value"), where the subscription id defaults to the exported subscription.
+func parseAzurermBackend(backendConfig []string, subscriptionId string) (*azurermBackend, error) {
+	backend := azurermBackend{subscriptionId: subscriptionId}
+	for _, opt := range backendConfig {
+		k, v, ok := strings.Cut(opt, "=")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		switch strings.TrimSpace(k) {
+		case "subscription_id":
+			backend.subscriptionId = v
+		case "resource_group_name":
+			backend.resourceGroupName = v
+		case "storage_account_name":
+			backend.storageAccountName = v
+		case "container_name":
+			backend.containerName = v
+		}
+	}
+	if backend.resourceGroupName == "" || backend.storageAccountName == "" || backend.containerName == "" {
+		return nil, fmt.Errorf(`bootstrapping the azurerm backend requires "resource_group_name", "storage_account_name" and "container_name" in the backend config`)
+	}
+	return &backend, nil
+}
+
+func (b azurermBackend) resourceGroupId() string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", b.subscriptionId, b.resourceGroupName)
+}
+
+func (b azurermBackend) storageAccountId() string {
+	return b.resourceGroupId() + "/providers/Microsoft.Storage/storageAccounts/" + b.storageAccountName
+}
+
+func (b azurermBackend) blobServiceId() string {
+	return b.storageAccountId() + "/blobServices/default"
+}
+
+func (b azurermBackend) containerId() string {
+	return b.blobServiceId() + "/containers/" + b.containerName
+}
+
+func isNotFound(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
+// bootstrapBackend creates the resource group, the storage account (with the blob versioning enabled) and the container of the azurerm backend, if missing.
+func (meta baseMeta) bootstrapBackend(ctx context.Context) error {
+	backend, err := parseAzurermBackend(meta.backendConfig, meta.subscriptionId)
+	if err != nil {
+		return err
+	}
+
+	rgClient, err := armresources.NewResourceGroupsClient(backend.subscriptionId, meta.azureSDKCred, &meta.azureSDKClientOpt)
+	if err != nil {
+		return fmt.Errorf("building the resource group client: %v", err)
+	}
+	client, err := armresources.NewClient(backend.subscriptionId, meta.azureSDKCred, &meta.azureSDKClientOpt)
+	if err != nil {
+		return fmt.Errorf("building the resource client: %v", err)
+	}
+
+	location := meta.backendBootstrap.Location
+	resp, err := rgClient.Get(ctx, backend.resourceGroupName, nil)
+	switch {
+	case err == nil:
+		if location == "" && resp.Location != nil {
+			location = *resp.Location
+		}
+	case isNotFound(err):
+		if location == "" {
+			return fmt.Errorf("the resource group %s of the backend doesn't exist, the location is required to create it", backend.resourceGroupName)
+		}
+		log.Printf("[INFO] Creating the resource group %s of the backend", backend.resourceGroupId())
+		if _, err := rgClient.CreateOrUpdate(ctx, backend.resourceGroupName, armresources.ResourceGroup{Location: &location}, nil); err != nil {
+			return fmt.Errorf("creating the resource group %s: %v", backend.resourceGroupId(), err)
+		}
+	default:
+		return fmt.Errorf("getting the resource group %s: %v", backend.resourceGroupId(), err)
+	}
+
+	// The versioning of the blob service is only enabled for the created storage account, leaving the existing one as is.
+	ok, err := armResourceExists(ctx, client, backend.storageAccountId())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := createARMResource(ctx, client, backend.storageAccountId(), armresources.GenericResource{
+			Location: &location,
+			Kind:     ptr(backendStorageKind),
+			SKU:      &armresources.SKU{Name: ptr(backendStorageSku)},
+			Properties: map[string]interface{}{
+				"allowBlobPublicAccess":    false,
+				"minimumTlsVersion":        "TLS1_2",
+				"supportsHttpsTrafficOnly": true,
+			},
+		}); err != nil {
+			return err
+		}
+		if err := createARMResource(ctx, client, backend.blobServiceId(), armresources.GenericResource{
+			Properties: map[string]interface{}{
+				"isVersioningEnabled": true,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	ok, err = armResourceExists(ctx, client, backend.containerId())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := createARMResource(ctx, client, backend.containerId(), armresources.GenericResource{
+			Properties: map[string]interface{}{
+				"publicAccess": "None",
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func armResourceExists(ctx context.Context, client *armresources.Client, id string) (bool, error) {
+	if _, err := client.GetByID(ctx, id, backendStorageAPIVersion, nil); err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting %s: %v", id, err)
+	}
+	return true, nil
+}
+
+func createARMResource(ctx context.Context, client *armresources.Client, id string, params armresources.GenericResource) error {
+	log.Printf("[INFO] Creating %s of the backend", id)
+	poller, err := client.BeginCreateOrUpdateByID(ctx, id, backendStorageAPIVersion, params, nil)
+	if err != nil {
+		return fmt.Errorf("creating %s: %v", id, err)
+	}
+	if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+		return fmt.Errorf("polling the creation of %s: %v", id, err)
+	}
+	return nil
+}
+
+// populateBackendResources adds the resources of the bootstrapped azurerm backend to the resource set, if they are asked to be exported.
+func (meta baseMeta) populateBackendResources(rset *resourceset.AzureResourceSet) error {
+	if meta.backendBootstrap == nil || !meta.backendBootstrap.Export {
+		return nil
+	}
+	backend, err := parseAzurermBackend(meta.backendConfig, meta.subscriptionId)
+	if err != nil {
+		return err
+	}
+	set := map[string]bool{}
+	for _, res := range rset.Resources {
+		set[strings.ToUpper(res.Id.String())] = true
+	}
+	for _, id := range []string{backend.resourceGroupId(), backend.storageAccountId(), backend.containerId()} {
+		if set[strings.ToUpper(id)] {
+			continue
+		}
+		azureId, err := armid.ParseResourceId(id)
+		if err != nil {
+			return fmt.Errorf("parsing the backend resource id %q: %v", id, err)
+		}
+		rset.Resources = append(rset.Resources, resourceset.AzureResource{Id: azureId})
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAzurermBackend(t *testing.T) {
+	backend, err := parseAzurermBackend([]string{
+		"resource_group_name = rg",
+		"storage_account_name=sa",
+		"container_name=tfstate",
+		"key=prod.tfstate",
+	}, "123")
+	require.NoError(t, err)
+	require.Equal(t, "/subscriptions/123/resourceGroups/rg", backend.resourceGroupId())
+	require.Equal(t, "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa", backend.storageAccountId())
+	require.Equal(t, "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa/blobServices/default/containers/tfstate", backend.containerId())
+
+	backend, err = parseAzurermBackend([]string{
+		"subscription_id=456",
+		"resource_group_name=rg",
+		"storage_account_name=sa",
+		"container_name=tfstate",
+	}, "123")
+	require.NoError(t, err)
+	require.Equal(t, "456", backend.subscriptionId)
+
+	_, err = parseAzurermBackend([]string{"resource_group_name=rg", "storage_account_name=sa"}, "123")
+	require.Error(t, err)
+}
+
+func TestPopulateBackendResources(t *testing.T) {
+	rgId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/RG")
+	require.NoError(t, err)
+	meta := baseMeta{
+		subscriptionId:   "123",
+		backendConfig:    []string{"resource_group_name=rg", "storage_account_name=sa", "container_name=tfstate"},
+		backendBootstrap: &config.BackendBootstrap{Export: true},
+	}
+	rset := resourceset.AzureResourceSet{Resources: []resourceset.AzureResource{{Id: rgId}}}
+	require.NoError(t, meta.populateBackendResources(&rset))
+
+	var ids []string
+	for _, res := range rset.Resources {
+		ids = append(ids, res.Id.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/RG",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa/blobServices/default/containers/tfstate",
+	}, ids)
+
+	// Not exported
+	meta.backendBootstrap.Export = false
+	rset = resourceset.AzureResourceSet{}
+	require.NoError(t, meta.populateBackendResources(&rset))
+	require.Empty(t, rset.Resources)
+}
@@ -0,0 +1,322 @@
+package meta
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/gofrs/uuid"
+)
+
+// backendLockInfoMetaKey is the metadata of the state blob that records the lock info, which is the same as the azurerm backend of terraform,
+// so that the lock held by aztfexport is reported by terraform, and vice versa.
+const backendLockInfoMetaKey = "terraformlockid"
+
+const blobAPIVersion = "2020-10-02"
+
+// backendLockInfo is the lock info, in the same format as the one of terraform.
+type backendLockInfo struct {
+	ID        string    `json:"ID"`
+	Operation string    `json:"Operation"`
+	Info      string    `json:"Info"`
+	Who       string    `json:"Who"`
+	Version   string    `json:"Version"`
+	Created   time.Time `json:"Created"`
+	Path      string    `json:"Path"`
+}
+
+func (info backendLockInfo) String() string {
+	return fmt.Sprintf("ID: %s, operation: %s, who: %s, created: %s", info.ID, info.Operation, info.Who, info.Created.Format(time.RFC3339))
+}
+
+// backendStateBlob is the state blob of the azurerm backend.
+type backendStateBlob struct {
+	url string
+	// path is "<container>/<key>"
+	path string
+}
+
+// readBackendStateBlob reads the state blob of the azurerm backend that the directory is initialized with, i.e. from the ".terraform/terraform.tfstate".
+func readBackendStateBlob(dir string) (*backendStateBlob, error) {
+	path := filepath.Join(dir, ".terraform", "terraform.tfstate")
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the backend config %s: %v", path, err)
+	}
+	var state struct {
+		Backend *struct {
+			Type   string                 `json:"type"`
+			Config map[string]interface{} `json:"config"`
+		} `json:"backend"`
+	}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, fmt.Errorf("unmarshalling the backend config %s: %v", path, err)
+	}
+	if state.Backend == nil || state.Backend.Type != "azurerm" {
+		return nil, fmt.Errorf("%s is not initialized with the azurerm backend", dir)
+	}
+	config := func(k string) string {
+		v, _ := state.Backend.Config[k].(string)
+		return v
+	}
+	account, container, key := config("storage_account_name"), config("container_name"), config("key")
+	if account == "" || container == "" || key == "" {
+		return nil, fmt.Errorf(`the azurerm backend of %s misses any of "storage_account_name", "container_name" and "key"`, dir)
+	}
+
+	// The state of the non-default workspace is stored in the blob suffixed by "env:<workspace>"
+	workspace := os.Getenv("TF_WORKSPACE")
+	if workspace == "" {
+		// #nosec G304
+		if b, err := os.ReadFile(filepath.Join(dir, ".terraform", "environment")); err == nil {
+			workspace = strings.TrimSpace(string(b))
+		}
+	}
+	if workspace != "" && workspace != "default" {
+		key += "env:" + workspace
+	}
+
+	suffix := "core.windows.net"
+	switch config("environment") {
+	case "", "public":
+	case "usgovernment":
+		suffix = "core.usgovcloudapi.net"
+	case "china":
+		suffix = "core.chinacloudapi.cn"
+	default:
+		return nil, fmt.Errorf("unsupported environment %q of the azurerm backend", config("environment"))
+	}
+	u := url.URL{Scheme: "https", Host: account + ".blob." + suffix, Path: "/" + container + "/" + key}
+	return &backendStateBlob{url: u.String(), path: container + "/" + key}, nil
+}
+
+// backendLock is the state lock of the azurerm backend, which is the infinite lease of the state blob.
+type backendLock struct {
+	blob backendStateBlob
+	pl   runtime.Pipeline
+	info backendLockInfo
+	// locked tells whether the lease is currently held
+	locked bool
+}
+
+func newBackendLock(blob backendStateBlob, pl runtime.Pipeline) *backendLock {
+	who := "aztfexport"
+	if u, err := user.Current(); err == nil {
+		who = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		who += "@" + host
+	}
+	return &backendLock{
+		blob: blob,
+		pl:   pl,
+		info: backendLockInfo{
+			// The lock id is used as the proposed lease id, which is reused on re-acquiring the lease.
+			ID:        uuid.Must(uuid.NewV4()).String(),
+			Operation: "aztfexport",
+			Who:       who,
+			Created:   time.Now().UTC(),
+			Path:      blob.path,
+		},
+	}
+}
+
+// storageDataPlanePipeline builds the pipeline to access the storage data plane (e.g. the blobs) via the Azure AD credential.
+func (meta baseMeta) storageDataPlanePipeline() runtime.Pipeline {
+	return runtime.NewPipeline("aztfexport", "", runtime.PipelineOptions{
+		PerRetry: []policy.Policy{runtime.NewBearerTokenPolicy(meta.azureSDKCred, []string{"https://storage.azure.com/.default"}, nil)},
+	}, &meta.azureSDKClientOpt.ClientOptions)
+}
+
+func (l *backendLock) do(ctx context.Context, method, query string, header map[string]string) (*http.Response, error) {
+	u := l.blob.url
+	if query != "" {
+		u += "?" + query
+	}
+	req, err := runtime.NewRequest(ctx, method, u)
+	if err != nil {
+		return nil, err
+	}
+	req.Raw().Header.Set("x-ms-version", blobAPIVersion)
+	for k, v := range header {
+		req.Raw().Header.Set(k, v)
+	}
+	return l.pl.Do(req)
+}
+
+// Lock acquires the lease of the state blob, which is created if not exists, then records the lock info in the blob metadata.
+// If the lease is held by others, the error reports the lock info of the holder.
+func (l *backendLock) Lock(ctx context.Context) error {
+	if l.locked {
+		return nil
+	}
+	acquire := func() (*http.Response, error) {
+		return l.do(ctx, http.MethodPut, "comp=lease", map[string]string{
+			"x-ms-lease-action":      "acquire",
+			"x-ms-lease-duration":    "-1",
+			"x-ms-proposed-lease-id": l.info.ID,
+		})
+	}
+	resp, err := acquire()
+	if err != nil {
+		return fmt.Errorf("acquiring the lease of %s: %v", l.blob.url, err)
+	}
+	if runtime.HasStatusCode(resp, http.StatusNotFound) {
+		// The empty state blob is created, as the same as terraform, unless it is created by others in the meanwhile.
+		cresp, err := l.do(ctx, http.MethodPut, "", map[string]string{
+			"x-ms-blob-type": "BlockBlob",
+			"If-None-Match":  "*",
+		})
+		if err != nil {
+			return fmt.Errorf("creating the state blob %s: %v", l.blob.url, err)
+		}
+		if !runtime.HasStatusCode(cresp, http.StatusCreated, http.StatusConflict, http.StatusPreconditionFailed) {
+			return fmt.Errorf("creating the state blob %s: %v", l.blob.url, runtime.NewResponseError(cresp))
+		}
+		if resp, err = acquire(); err != nil {
+			return fmt.Errorf("acquiring the lease of %s: %v", l.blob.url, err)
+		}
+	}
+	if runtime.HasStatusCode(resp, http.StatusConflict) {
+		msg := fmt.Sprintf("the state blob %s is locked by another run", l.blob.url)
+		if info, err := l.holder(ctx); err == nil && info != nil {
+			msg += fmt.Sprintf(" (%s)", info)
+		}
+		return errors.New(msg)
+	}
+	if !runtime.HasStatusCode(resp, http.StatusCreated, http.StatusOK) {
+		return fmt.Errorf("acquiring the lease of %s: %v", l.blob.url, runtime.NewResponseError(resp))
+	}
+	l.locked = true
+
+	b, err := json.Marshal(l.info)
+	if err != nil {
+		return fmt.Errorf("marshalling the lock info: %v", err)
+	}
+	if err := l.setLockInfo(ctx, base64.StdEncoding.EncodeToString(b)); err != nil {
+		return fmt.Errorf("writing the lock info to %s: %v", l.blob.url, err)
+	}
+	return nil
+}
+
+// Unlock removes the lock info from the blob metadata, then releases the lease.
+func (l *backendLock) Unlock(ctx context.Context) error {
+	if !l.locked {
+		return nil
+	}
+	if err := l.setLockInfo(ctx, ""); err != nil {
+		return fmt.Errorf("removing the lock info from %s: %v", l.blob.url, err)
+	}
+	resp, err := l.do(ctx, http.MethodPut, "comp=lease", map[string]string{
+		"x-ms-lease-action": "release",
+		"x-ms-lease-id":     l.info.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("releasing the lease of %s: %v", l.blob.url, err)
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return fmt.Errorf("releasing the lease of %s: %v", l.blob.url, runtime.NewResponseError(resp))
+	}
+	l.locked = false
+	return nil
+}
+
+// holder returns the lock info of the current lease holder, which is nil if not recorded.
+func (l *backendLock) holder(ctx context.Context) (*backendLockInfo, error) {
+	resp, err := l.do(ctx, http.MethodHead, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return nil, runtime.NewResponseError(resp)
+	}
+	v := resp.Header.Get("x-ms-meta-" + backendLockInfoMetaKey)
+	if v == "" {
+		return nil, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil, err
+	}
+	var info backendLockInfo
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// setLockInfo sets (or removes, if empty) the lock info in the blob metadata, while the other metadata is kept.
+func (l *backendLock) setLockInfo(ctx context.Context, info string) error {
+	resp, err := l.do(ctx, http.MethodHead, "", nil)
+	if err != nil {
+		return err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return runtime.NewResponseError(resp)
+	}
+	header := map[string]string{"x-ms-lease-id": l.info.ID}
+	for k, v := range resp.Header {
+		if strings.HasPrefix(strings.ToLower(k), "x-ms-meta-") && !strings.EqualFold(k, "x-ms-meta-"+backendLockInfoMetaKey) && len(v) != 0 {
+			header[k] = v[0]
+		}
+	}
+	if info != "" {
+		header["x-ms-meta-"+backendLockInfoMetaKey] = info
+	}
+	resp, err = l.do(ctx, http.MethodPut, "comp=metadata", header)
+	if err != nil {
+		return err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return runtime.NewResponseError(resp)
+	}
+	return nil
+}
+
+// lockBackend acquires the state lock of the backend of the output directory, which is held until unlockBackend.
+func (meta *baseMeta) lockBackend(ctx context.Context) error {
+	blob, err := readBackendStateBlob(meta.outdir)
+	if err != nil {
+		return err
+	}
+	meta.backendLock = newBackendLock(*blob, meta.storageDataPlanePipeline())
+	log.Printf("[INFO] Locking the backend state %s", blob.url)
+	return meta.backendLock.Lock(ctx)
+}
+
+// unlockBackend releases the state lock of the backend, if held.
+func (meta baseMeta) unlockBackend(ctx context.Context) error {
+	if meta.backendLock == nil {
+		return nil
+	}
+	return meta.backendLock.Unlock(ctx)
+}
+
+// withBackendUnlocked runs the function that writes the state of the output directory via terraform, which locks the state on its own.
+// The held state lock of the backend is released before, and re-acquired after.
+func (meta baseMeta) withBackendUnlocked(ctx context.Context, f func() error) error {
+	if meta.backendLock == nil || !meta.backendLock.locked {
+		return f()
+	}
+	if err := meta.backendLock.Unlock(ctx); err != nil {
+		return fmt.Errorf("unlocking the backend state: %v", err)
+	}
+	ferr := f()
+	if err := meta.backendLock.Lock(ctx); err != nil {
+		return fmt.Errorf("re-locking the backend state: %v", err)
+	}
+	return ferr
+}
@@ -0,0 +1,147 @@
+package meta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBackendStateBlob(t *testing.T) {
+	t.Setenv("TF_WORKSPACE", "")
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".terraform"), 0750))
+	write := func(state string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".terraform", "terraform.tfstate"), []byte(state), 0600))
+	}
+
+	write(`{"backend": {"type": "local", "config": {}}}`)
+	_, err := readBackendStateBlob(dir)
+	require.EqualError(t, err, dir+" is not initialized with the azurerm backend")
+
+	write(`{"backend": {"type": "azurerm", "config": {"storage_account_name": "sa", "container_name": "tfstate", "key": "prod.tfstate"}}}`)
+	blob, err := readBackendStateBlob(dir)
+	require.NoError(t, err)
+	require.Equal(t, backendStateBlob{url: "https://sa.blob.core.windows.net/tfstate/prod.tfstate", path: "tfstate/prod.tfstate"}, *blob)
+
+	write(`{"backend": {"type": "azurerm", "config": {"storage_account_name": "sa", "container_name": "tfstate", "key": "prod.tfstate", "environment": "china"}}}`)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".terraform", "environment"), []byte("dev"), 0600))
+	blob, err = readBackendStateBlob(dir)
+	require.NoError(t, err)
+	require.Equal(t, backendStateBlob{url: "https://sa.blob.core.chinacloudapi.cn/tfstate/prod.tfstateenv:dev", path: "tfstate/prod.tfstateenv:dev"}, *blob)
+}
+
+// fakeBlob is a state blob that only supports the lease and the metadata operations.
+type fakeBlob struct {
+	mu      sync.Mutex
+	exists  bool
+	leaseId string
+	meta    map[string]string
+}
+
+func (b *fakeBlob) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	comp := r.URL.Query().Get("comp")
+	switch {
+	case r.Method == http.MethodPut && comp == "":
+		if b.exists {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		b.exists = true
+		w.WriteHeader(http.StatusCreated)
+	case !b.exists:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodHead:
+		for k, v := range b.meta {
+			w.Header().Set("x-ms-meta-"+k, v)
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut && comp == "lease":
+		id := r.Header.Get("x-ms-lease-id")
+		switch r.Header.Get("x-ms-lease-action") {
+		case "acquire":
+			if b.leaseId != "" {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			b.leaseId = r.Header.Get("x-ms-proposed-lease-id")
+			w.WriteHeader(http.StatusCreated)
+		case "release":
+			if b.leaseId != id {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			b.leaseId = ""
+			w.WriteHeader(http.StatusOK)
+		}
+	case r.Method == http.MethodPut && comp == "metadata":
+		if b.leaseId != "" && b.leaseId != r.Header.Get("x-ms-lease-id") {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		b.meta = map[string]string{}
+		for k, v := range r.Header {
+			if strings.HasPrefix(strings.ToLower(k), "x-ms-meta-") {
+				b.meta[strings.ToLower(strings.TrimPrefix(strings.ToLower(k), "x-ms-meta-"))] = v[0]
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestBackendLock(t *testing.T) {
+	blob := &fakeBlob{meta: map[string]string{"other": "value"}}
+	srv := httptest.NewServer(blob)
+	defer srv.Close()
+
+	newLock := func() *backendLock {
+		pl := runtime.NewPipeline("aztfexport", "", runtime.PipelineOptions{}, &policy.ClientOptions{Retry: policy.RetryOptions{MaxRetries: -1}})
+		return newBackendLock(backendStateBlob{url: srv.URL + "/tfstate/prod.tfstate", path: "tfstate/prod.tfstate"}, pl)
+	}
+	ctx := context.Background()
+
+	// The missing state blob is created on locking
+	l1 := newLock()
+	require.NoError(t, l1.Lock(ctx))
+	require.True(t, blob.exists)
+	require.Equal(t, l1.info.ID, blob.leaseId)
+	require.Contains(t, blob.meta, backendLockInfoMetaKey)
+	require.Equal(t, "value", blob.meta["other"])
+
+	// The lock held by others is reported
+	l2 := newLock()
+	err := l2.Lock(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is locked by another run (ID: "+l1.info.ID+", operation: aztfexport")
+
+	// The lock can be released and re-acquired
+	require.NoError(t, l1.Unlock(ctx))
+	require.Empty(t, blob.leaseId)
+	require.NotContains(t, blob.meta, backendLockInfoMetaKey)
+	require.Equal(t, "value", blob.meta["other"])
+	require.NoError(t, l1.Unlock(ctx))
+	require.NoError(t, l1.Lock(ctx))
+	require.Equal(t, l1.info.ID, blob.leaseId)
+
+	// The lock is held until the unlock, across the terraform invocations
+	meta := baseMeta{backendLock: l1}
+	require.NoError(t, meta.withBackendUnlocked(ctx, func() error {
+		require.Empty(t, blob.leaseId)
+		return nil
+	}))
+	require.Equal(t, l1.info.ID, blob.leaseId)
+	require.NoError(t, meta.unlockBackend(ctx))
+	require.NoError(t, l2.Lock(ctx))
+}
@@ -0,0 +1,95 @@
+package meta
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var backstageInvalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// backstageName converts the input to a valid Backstage entity name, which is a sequence of [a-z0-9A-Z] possibly separated by one of [-_.], at most 63 chars.
+// The truncated name is suffixed with a short hash of the input, so that the long inputs sharing a prefix still have distinct names.
+func backstageName(s string) string {
+	name := backstageInvalidNameChars.ReplaceAllString(s, "-")
+	if len(name) > 63 {
+		sum := sha256.Sum256([]byte(s))
+		name = strings.TrimRight(name[:54], "-_.") + "-" + hex.EncodeToString(sum[:4])
+	}
+	return strings.Trim(name, "-_.")
+}
+
+// writeBackstageCatalog writes the Backstage catalog file to the output directory, which describes the exported module as a Component,
+// together with each imported resource as a Resource that the Component depends on.
+func (meta baseMeta) writeBackstageCatalog(l ImportList) error {
+	abs, err := filepath.Abs(meta.outdir)
+	if err != nil {
+		return fmt.Errorf("getting the absolute path of %s: %v", meta.outdir, err)
+	}
+	path := filepath.Join(meta.outdir, BackstageCatalogFileName)
+	content := backstageCatalog(backstageName(filepath.Base(abs)), meta.backstageOwner, meta.backstageSystem, meta.subscriptionId, l.Imported())
+	// #nosec G306
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("writing the Backstage catalog to %s: %v", path, err)
+	}
+	return nil
+}
+
+func backstageCatalog(name, owner, system, subscriptionId string, l ImportList) string {
+	var resNames []string
+	for _, item := range l {
+		resNames = append(resNames, backstageName(item.TFAddr.String()))
+	}
+
+	lines := []string{
+		"# Generated by aztfexport.",
+		"apiVersion: backstage.io/v1alpha1",
+		"kind: Component",
+		"metadata:",
+		"  name: " + strconv.Quote(name),
+		`  description: "Terraform module exported by aztfexport"`,
+		"  annotations:",
+		"    azure.com/subscription-id: " + strconv.Quote(subscriptionId),
+		"  tags:",
+		"    - terraform",
+		"    - azure",
+		"spec:",
+		"  type: terraform-module",
+		"  lifecycle: production",
+		"  owner: " + strconv.Quote(owner),
+	}
+	if system != "" {
+		lines = append(lines, "  system: "+strconv.Quote(system))
+	}
+	if len(resNames) != 0 {
+		lines = append(lines, "  dependsOn:")
+		for _, resName := range resNames {
+			lines = append(lines, "    - "+strconv.Quote("resource:"+resName))
+		}
+	}
+
+	for i, item := range l {
+		lines = append(lines,
+			"---",
+			"apiVersion: backstage.io/v1alpha1",
+			"kind: Resource",
+			"metadata:",
+			"  name: "+strconv.Quote(resNames[i]),
+			"  annotations:",
+			"    azure.com/resource-id: "+strconv.Quote(item.AzureResourceID.String()),
+			"    terraform.io/address: "+strconv.Quote(item.TFAddr.String()),
+			"spec:",
+			"  type: "+strconv.Quote(item.AzureResourceID.TypeString()),
+			"  owner: "+strconv.Quote(owner),
+		)
+		if system != "" {
+			lines = append(lines, "  system: "+strconv.Quote(system))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
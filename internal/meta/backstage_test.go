@@ -0,0 +1,25 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackstageName(t *testing.T) {
+	require.Equal(t, "azurerm_resource_group.res-0", backstageName("azurerm_resource_group.res-0"))
+	require.Equal(t, "my-output-dir", backstageName("my output dir"))
+	require.Equal(t, "foo", backstageName("_foo!"))
+	// The truncated names are suffixed with the hash of the input.
+	require.Regexp(t, `^[0-9a-f]{8}$`, backstageName(string(make([]byte, 100))))
+	require.Regexp(t, `^a-[0-9a-f]{8}$`, backstageName("a"+string(make([]byte, 100))))
+
+	// The long names sharing a prefix don't collide.
+	prefix := "azurerm_storage_account.storage_account_of_the_application_in_the_production_"
+	name1 := backstageName(prefix + "east")
+	name2 := backstageName(prefix + "west")
+	require.Len(t, name1, 63)
+	require.Len(t, name2, 63)
+	require.NotEqual(t, name1, name2)
+	require.Equal(t, name1, backstageName(prefix+"east"))
+}
@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	tfjson "github.com/hashicorp/terraform-json"
@@ -18,7 +21,12 @@ import (
 	"github.com/zclconf/go-cty/cty"
 
 	"github.com/Azure/aztfexport/internal/client"
+	"github.com/Azure/aztfexport/internal/gencache"
 	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/internal/resolverplugin"
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/internal/tfaudit"
+	"github.com/Azure/aztfexport/internal/typeoverride"
 	"github.com/Azure/aztfexport/internal/utils"
 	"github.com/Azure/aztfexport/pkg/telemetry"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
@@ -36,7 +44,6 @@ import (
 	"github.com/magodo/terraform-client-go/tfclient/typ"
 	"github.com/magodo/tfadd/providers/azurerm"
 	"github.com/magodo/tfadd/tfadd"
-	"github.com/magodo/tfmerge/tfmerge"
 	"github.com/magodo/tfstate"
 	"github.com/magodo/workerpool"
 	ctyjson "github.com/zclconf/go-cty/cty/json"
@@ -54,6 +61,8 @@ type BaseMeta interface {
 	DeInit(ctx context.Context) error
 	// Workspace returns the path of the output directory.
 	Workspace() string
+	// Result returns the structured result of the resources that are exported (with the generated config) or failed to import so far.
+	Result() ExportResult
 	// ParallelImport imports the specified import list in parallel (parallelism is set during the meta builder function).
 	// Import error won't be returned in the error, but is recorded in each ImportItem.
 	ParallelImport(ctx context.Context, items []*ImportItem) error
@@ -63,10 +72,16 @@ type BaseMeta interface {
 	CleanTFState(ctx context.Context, addr string)
 	// GenerateCfg generates the TF configuration of the import list. Only resources successfully imported will be processed.
 	GenerateCfg(ctx context.Context, l ImportList) error
+	// PreviewCfg generates the TF configuration of the import item, without importing it to the workspace.
+	PreviewCfg(ctx context.Context, item ImportItem) ([]byte, error)
 	// ExportSkippedResources writes a file listing record resources that are skipped to be imported to the output directory.
 	ExportSkippedResources(ctx context.Context, l ImportList) error
 	// ExportResourceMapping writes a resource mapping file to the output directory.
 	ExportResourceMapping(ctx context.Context, l ImportList) error
+	// ProviderNames returns the names of the Terraform providers that the resources are exported to.
+	ProviderNames() []string
+	// ProviderVersion returns the version of the (primary) Terraform provider, which is empty for the dev provider.
+	ProviderVersion() string
 	// CleanUpWorkspace is a weired method that is only meant to be used internally by aztfexport, which under the hood will remove everything in the output directory, except the generated TF config.
 	// This method does nothing if HCLOnly in the Config is not set.
 	CleanUpWorkspace(ctx context.Context) error
@@ -75,20 +90,95 @@ type BaseMeta interface {
 var _ BaseMeta = &baseMeta{}
 
 type baseMeta struct {
-	subscriptionId    string
-	azureSDKCred      azcore.TokenCredential
-	azureSDKClientOpt arm.ClientOptions
-	outdir            string
-	outputFileNames   config.OutputFileNames
-	tf                *tfexec.Terraform
-	resourceClient    *armresources.Client
-	providerVersion   string
-	devProvider       bool
-	backendType       string
-	backendConfig     []string
-	providerConfig    map[string]cty.Value
-	fullConfig        bool
-	parallelism       int
+	subscriptionId         string
+	azureSDKCred           azcore.TokenCredential
+	azureSDKClientOpt      arm.ClientOptions
+	outdir                 string
+	outputFileNames        config.OutputFileNames
+	tf                     *tfexec.Terraform
+	resourceClient         *armresources.Client
+	providerName           string
+	azapiFallback          bool
+	typeOverrides          typeoverride.Overrides
+	resolvers              []string
+	resolverPlugin         *resolverplugin.Plugin
+	subresourceStrategy    string
+	providerVersion        string
+	providerMajorVersion   string
+	providerRegistry       string
+	customProviderSource   string
+	providerMirror         string
+	providerPluginCacheDir string
+	devProvider            bool
+	backendType            string
+	backendConfig          []string
+	cloudOrganization      string
+	cloudWorkspace         string
+	workspace              string
+	localThenMigrate       bool
+	holdBackendLock        bool
+	backendLock            *backendLock
+	backendBootstrap       *config.BackendBootstrap
+	providerConfig         map[string]cty.Value
+	providerFeatures       map[string]cty.Value
+	fullConfig             bool
+	propertyRules          propertyRules
+	exportARMJSON          bool
+	emitDependsOn          bool
+	stackConfigType        string
+	aksProviders           bool
+	backstageCatalog       bool
+	backstageOwner         string
+	backstageSystem        string
+	inventory              bool
+	generateOutputs        bool
+	outputRules            outputRules
+	graphOutput            string
+	injectTags             map[string]string
+	applyInjectedTags      bool
+	keyVaultIds            []string
+	onSecret               string
+	secretAllowlist        secretAllowlist
+	onLocked               string
+	onSoftDeleted          string
+	extensionResourceTypes []string
+	includeMonitoring      bool
+	includeKeyVaultItems   bool
+	includeStorageItems    bool
+	limit                  int
+	sample                 int
+	envSplit               []string
+	parallelism            int
+	importTimeout          time.Duration
+	useImportBlocks        bool
+	cache                  *gencache.Cache
+	dryRun                 bool
+	hooks                  config.Hooks
+	commandHooks           []config.CommandHook
+	prune                  bool
+	movedFromState         string
+	excludePatterns        []excludePattern
+	ignoreRules            []ignoreRule
+	authScaffold           *config.AuthScaffold
+	namingStrategy         config.NamingStrategy
+	generateDataSources    bool
+	rewriteReferences      bool
+	extractVariables       bool
+	variableAttributes     []string
+	splitBy                string
+	fileLayout             config.FileLayout
+	// layoutFiles are the files that the config is generated into by the fileLayout, which are shared by the copies of the meta.
+	layoutFiles          map[string]bool
+	renderer             config.ConfigRenderer
+	moduleTemplate       string
+	createMissingModules bool
+	sortResources        string
+
+	// aliasSubscriptionIds are the subscriptions, other than the subscriptionId, whose resources are exported.
+	// Each of them is managed by an aliased azurerm provider.
+	aliasSubscriptionIds []string
+	// credentialAliases are the aliased azurerm providers with their own credential settings, each manages the resources within its scopes.
+	credentialAliases []credentialAlias
 
 	hclOnly  bool
 	tfclient tfclient.Client
@@ -105,12 +195,45 @@ type baseMeta struct {
 	importModuleDirs []string
 	importTFs        []*tfexec.Terraform
 
+	// The audit log of the terraform commands, which are also echoed to the stderr if showCommands is set.
+	auditLog     *tfaudit.Log
+	showCommands bool
+
 	// The original base state, which is retrieved prior to the import, and is compared with the actual base state prior to the mutated state is pushed,
 	// to ensure the base state has no out of band changes during the importing.
 	originBaseState []byte
 	// The current base state, which is mutated during the importing
 	baseState []byte
 
+	// The accumulated list of the items that the config is generated for, which can be generated in chunks
+	generatedList ImportList
+	// The generated config of the resources, keyed by the uppercased Azure resource id.
+	generatedHCL map[string][]byte
+	// The items that failed to import, keyed by the uppercased Azure resource id.
+	importFailures map[string]ImportItem
+
+	// The secrets of the Key Vaults (keyed by the secret value), which are loaded on the first config generation.
+	keyVaultSecrets map[string]keyVaultSecret
+	// The Key Vault secrets (keyed by the TF data source name) that are referenced by the generated config.
+	keyVaultSecretRefs map[string]keyVaultSecret
+
+	// The uppercased Azure resource ids of the resources to export, which is maintained on listing and generating the config.
+	scopeIds map[string]bool
+	// The data sources (keyed by the uppercased Azure resource id) of the resources that are referenced but not exported.
+	dataSources map[string]dataSource
+	// The "<type>.<name>" of the data sources, used to keep the data source names unique.
+	dataSourceNames map[string]bool
+	// The names of the variables that are extracted from the generated config, keyed by the extracted values.
+	variables map[variableValue]string
+	// The child modules that the config is generated into, when the generated config is split.
+	splitModules map[string]bool
+	// The references across the child modules, which are wired by the root module.
+	crossModuleRefs map[crossModuleRef]bool
+	// The directories of the child modules of the module template, keyed by the module name.
+	templateModuleDirs map[string]string
+	// The secrets found in the generated config, which are reported.
+	secretFindings map[secretFinding]bool
+
 	tc telemetry.Client
 }
 
@@ -118,11 +241,34 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 	if cfg.Parallelism == 0 {
 		return nil, fmt.Errorf("Parallelism not set in the config")
 	}
+	if cfg.ImportTimeout < 0 {
+		return nil, fmt.Errorf("ImportTimeout must not be negative in the config")
+	}
 	if cfg.ProviderVersion != "" && cfg.DevProvider {
 		return nil, fmt.Errorf("ProviderVersion conflicts with DevProvider in the config")
 	}
+	if cfg.BackstageCatalog && cfg.BackstageOwner == "" {
+		return nil, fmt.Errorf("BackstageOwner must be set when BackstageCatalog is set in the config")
+	}
+	if err := ValidateEnvSplit(cfg.EnvSplit); err != nil {
+		return nil, fmt.Errorf("invalid EnvSplit in the config: %v", err)
+	}
 	if cfg.TFClient != nil && !cfg.HCLOnly {
-		return nil, fmt.Errorf("TFClient must be used together with HCLOnly")
+		if cfg.BackendType != "" && cfg.BackendType != "local" {
+			return nil, fmt.Errorf("TFClient can only be used with the local BackendType in the config, unless HCLOnly is set")
+		}
+		if cfg.ModulePath != "" || cfg.UseImportBlocks || cfg.SplitBy != "" || cfg.ModuleTemplate != "" || cfg.Prune {
+			return nil, fmt.Errorf("TFClient can't be used with ModulePath, UseImportBlocks, SplitBy, ModuleTemplate or Prune in the config, unless HCLOnly is set")
+		}
+	}
+	if cfg.UseImportBlocks && cfg.ModulePath != "" {
+		return nil, fmt.Errorf("UseImportBlocks can't be used with ModulePath in the config")
+	}
+	if cfg.Prune && (cfg.HCLOnly || cfg.DryRun) {
+		return nil, fmt.Errorf("Prune can't be used with HCLOnly or DryRun in the config")
+	}
+	if cfg.MovedFromState != "" && cfg.DryRun {
+		return nil, fmt.Errorf("MovedFromState can't be used with DryRun in the config")
 	}
 
 	// Determine the module directory and module address
@@ -147,6 +293,18 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 		}
 	}
 
+	if cfg.AuthScaffold != nil {
+		switch cfg.AuthScaffold.Method {
+		case AuthMethodDefault, AuthMethodEnvironment, AuthMethodManagedIdentity, AuthMethodAzureCLI, AuthMethodOIDC:
+		default:
+			return nil, fmt.Errorf("unknown auth method %q of AuthScaffold in the config", cfg.AuthScaffold.Method)
+		}
+	}
+	if cfg.RetryPolicy.BaseDelay < 0 || cfg.RetryPolicy.MaxDelay < 0 {
+		return nil, fmt.Errorf("the delays of RetryPolicy must not be negative in the config")
+	}
+	cfg.AzureSDKClientOption = ClientOption(cfg)
+
 	// Construct Azure resources client
 	b := client.ClientBuilder{
 		Credential: cfg.AzureSDKCredential,
@@ -161,7 +319,7 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 
 	// AzureRM provider will honor env.var "AZURE_HTTP_USER_AGENT" when constructing for HTTP "User-Agent" header.
 	// #nosec G104
-	os.Setenv("AZURE_HTTP_USER_AGENT", cfg.AzureSDKClientOption.Telemetry.ApplicationID)
+	os.Setenv("AZURE_HTTP_USER_AGENT", strings.TrimSpace(cfg.AzureSDKClientOption.Telemetry.ApplicationID+" "+cfg.ClientPolicy.UserAgentSuffix))
 
 	// Avoid the AzureRM provider to call the expensive RP listing API, repeatedly.
 	// #nosec G104
@@ -188,26 +346,470 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 		tc = telemetry.NewNullClient()
 	}
 
+	switch cfg.ProviderName {
+	case "":
+		cfg.ProviderName = ProviderAzureRM
+	case ProviderAzureRM, ProviderAzAPI:
+	default:
+		return nil, fmt.Errorf("unknown provider %q in the config", cfg.ProviderName)
+	}
+
+	if cfg.AzAPIFallback {
+		if cfg.ProviderName != ProviderAzureRM {
+			return nil, fmt.Errorf("AzAPIFallback can only be used when ProviderName is %q in the config", ProviderAzureRM)
+		}
+		if cfg.TFClient != nil {
+			return nil, fmt.Errorf("AzAPIFallback conflicts with TFClient in the config")
+		}
+	}
+
+	if cfg.BackendType == BackendTypeCloud {
+		if len(cfg.BackendConfig) != 0 {
+			return nil, fmt.Errorf("BackendConfig can't be used with the %q BackendType in the config", BackendTypeCloud)
+		}
+		if len(cfg.EnvSplit) != 0 {
+			return nil, fmt.Errorf("EnvSplit can't be used with the %q BackendType in the config", BackendTypeCloud)
+		}
+	} else if cfg.CloudOrganization != "" || cfg.CloudWorkspace != "" {
+		return nil, fmt.Errorf("CloudOrganization and CloudWorkspace require the BackendType to be %q in the config", BackendTypeCloud)
+	}
+
+	if cfg.Workspace != "" {
+		if err := validateWorkspace(cfg.Workspace, cfg.BackendType); err != nil {
+			return nil, fmt.Errorf("invalid Workspace in the config: %v", err)
+		}
+		if cfg.TFClient != nil {
+			return nil, fmt.Errorf("Workspace conflicts with TFClient in the config")
+		}
+	}
+
+	if cfg.LocalThenMigrate {
+		if cfg.BackendType == "" || cfg.BackendType == "local" {
+			return nil, fmt.Errorf("LocalThenMigrate requires a non-local BackendType in the config")
+		}
+		if cfg.TFClient != nil {
+			return nil, fmt.Errorf("LocalThenMigrate conflicts with TFClient in the config")
+		}
+		if cfg.HCLOnly {
+			return nil, fmt.Errorf("LocalThenMigrate conflicts with HCLOnly in the config")
+		}
+	}
+
+	if cfg.HoldBackendLock {
+		if cfg.TFClient != nil {
+			return nil, fmt.Errorf("HoldBackendLock conflicts with TFClient in the config")
+		}
+		if cfg.HCLOnly || cfg.DryRun || cfg.LocalThenMigrate {
+			return nil, fmt.Errorf("HoldBackendLock can't be used with HCLOnly, DryRun or LocalThenMigrate in the config")
+		}
+	}
+
+	if cfg.BackendBootstrap != nil {
+		if cfg.BackendType != "azurerm" {
+			return nil, fmt.Errorf("BackendBootstrap requires the BackendType to be \"azurerm\" in the config")
+		}
+		if _, err := parseAzurermBackend(cfg.BackendConfig, cfg.SubscriptionId); err != nil {
+			return nil, err
+		}
+	}
+
+	switch cfg.SubresourceStrategy {
+	case "", SubresourceStrategyStandalone, SubresourceStrategyInline:
+	default:
+		return nil, fmt.Errorf("unknown sub-resource strategy %q in the config", cfg.SubresourceStrategy)
+	}
+
+	if err := resourceset.ValidateResolvers(cfg.Resolvers); err != nil {
+		return nil, err
+	}
+
+	var resolverPlugin *resolverplugin.Plugin
+	if cfg.ResolverPluginPath != "" {
+		resolverPlugin = resolverplugin.New(cfg.ResolverPluginPath)
+	}
+
+	var typeOverrides typeoverride.Overrides
+	if cfg.TypeOverrideFile != "" {
+		typeOverrides, err = typeoverride.Load(cfg.TypeOverrideFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch cfg.OnSecret {
+	case "", OnSecretRedact, OnSecretVar, OnSecretFail:
+	default:
+		return nil, fmt.Errorf("unknown on-secret action %q in the config", cfg.OnSecret)
+	}
+	var rules propertyRules
+	if cfg.PropertyRulesFile != "" {
+		rules, err = loadPropertyRules(cfg.PropertyRulesFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var outRules outputRules
+	if cfg.OutputRulesFile != "" {
+		outRules, err = loadOutputRules(cfg.OutputRulesFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var credentialAliases []credentialAlias
+	if cfg.CredentialsFile != "" {
+		if err := validateProviderAliases(cfg, "CredentialsFile"); err != nil {
+			return nil, err
+		}
+		if cfg.ProviderName != ProviderAzureRM {
+			return nil, fmt.Errorf("CredentialsFile can only be used when ProviderName is %q in the config", ProviderAzureRM)
+		}
+		credentialAliases, err = loadCredentialAliases(cfg.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var allowlist secretAllowlist
+	if cfg.SecretAllowlistFile != "" {
+		if cfg.OnSecret == "" {
+			return nil, fmt.Errorf("SecretAllowlistFile requires OnSecret in the config")
+		}
+		allowlist, err = loadSecretAllowlist(cfg.SecretAllowlistFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Limit < 0 || cfg.Sample < 0 {
+		return nil, fmt.Errorf("Limit and Sample can't be negative in the config")
+	}
+	if cfg.Limit != 0 && cfg.Sample != 0 {
+		return nil, fmt.Errorf("Limit conflicts with Sample in the config")
+	}
+
+	switch cfg.OnLocked {
+	case "", OnLockedWarn, OnLockedSkip:
+	default:
+		return nil, fmt.Errorf("unknown on-locked policy %q in the config", cfg.OnLocked)
+	}
+	switch cfg.OnSoftDeleted {
+	case "", OnSoftDeletedWarn, OnSoftDeletedSkip:
+	default:
+		return nil, fmt.Errorf("unknown on-soft-deleted policy %q in the config", cfg.OnSoftDeleted)
+	}
+
+	if cfg.ApplyInjectedTags && len(cfg.InjectTags) == 0 {
+		return nil, fmt.Errorf("ApplyInjectedTags requires InjectTags in the config")
+	}
+
+	if cfg.IncludeKeyVaultItems && cfg.ProviderName != ProviderAzureRM {
+		return nil, fmt.Errorf("IncludeKeyVaultItems can only be used when ProviderName is %q in the config", ProviderAzureRM)
+	}
+	for _, hook := range cfg.CommandHooks {
+		if !validHookPhase(hook.Phase) {
+			return nil, fmt.Errorf("unknown phase %q of the command hook, must be one of: %s", hook.Phase, strings.Join(HookPhases, ", "))
+		}
+		if strings.TrimSpace(hook.Command) == "" {
+			return nil, fmt.Errorf("empty command of the %s hook", hook.Phase)
+		}
+	}
+	if len(cfg.ProviderFeatures) != 0 && cfg.ProviderName != ProviderAzureRM {
+		return nil, fmt.Errorf("ProviderFeatures can only be used when ProviderName is %q in the config", ProviderAzureRM)
+	}
+	if cfg.IncludeStorageItems && cfg.ProviderName != ProviderAzureRM {
+		return nil, fmt.Errorf("IncludeStorageItems can only be used when ProviderName is %q in the config", ProviderAzureRM)
+	}
+	if len(cfg.KeyVaultIds) != 0 && cfg.ProviderName != ProviderAzureRM {
+		return nil, fmt.Errorf("KeyVaultIds can only be used when ProviderName is %q in the config", ProviderAzureRM)
+	}
+	if cfg.GenerateDataSources && cfg.ProviderName != ProviderAzureRM {
+		return nil, fmt.Errorf("GenerateDataSources can only be used when ProviderName is %q in the config", ProviderAzureRM)
+	}
+
+	if cfg.VariableAttributesFile != "" && !cfg.ExtractVariables {
+		return nil, fmt.Errorf("VariableAttributesFile requires ExtractVariables in the config")
+	}
+	// The env split extracts the location to the variable of its own, and only carries over the variables declared in the generated config files.
+	if cfg.ExtractVariables && len(cfg.EnvSplit) != 0 {
+		return nil, fmt.Errorf("ExtractVariables can't be used with EnvSplit in the config")
+	}
+	switch cfg.SplitBy {
+	case "", SplitByResourceGroup, SplitByType:
+	default:
+		return nil, fmt.Errorf("unknown split by %q in the config", cfg.SplitBy)
+	}
+	switch cfg.SortResources {
+	case "", SortResourcesByType, SortResourcesByName, SortResourcesByAzureId:
+	default:
+		return nil, fmt.Errorf("unknown sort resources %q in the config", cfg.SortResources)
+	}
+	// The child modules can only refer to the resources in themselves, or in the other child modules via the root module.
+	if cfg.SplitBy != "" {
+		switch {
+		case cfg.ModulePath != "":
+			return nil, fmt.Errorf("SplitBy can't be used with ModulePath in the config")
+		case len(cfg.EnvSplit) != 0:
+			return nil, fmt.Errorf("SplitBy can't be used with EnvSplit in the config")
+		case cfg.GenerateDataSources:
+			return nil, fmt.Errorf("SplitBy can't be used with GenerateDataSources in the config")
+		case len(cfg.KeyVaultIds) != 0:
+			return nil, fmt.Errorf("SplitBy can't be used with KeyVaultIds in the config")
+		case cfg.OnSecret == OnSecretVar:
+			return nil, fmt.Errorf("SplitBy can't be used with OnSecret %q in the config", OnSecretVar)
+		}
+	}
+	switch cfg.SplitFiles {
+	case "", SplitFilesPerResource, SplitFilesPerType, SplitFilesPerResourceGroup:
+	default:
+		return nil, fmt.Errorf("unknown split files %q in the config", cfg.SplitFiles)
+	}
+	fileLayout := outputFileNames.Layout
+	if fileLayout == nil {
+		fileLayout = builtinFileLayout(cfg.SplitFiles)
+	}
+	// The other ways of generating the config (e.g. the env split) expect the config of the resources in the MainFileName.
+	if fileLayout != nil {
+		switch {
+		case cfg.SplitBy != "":
+			return nil, fmt.Errorf("SplitFiles (or OutputFileNames.Layout) can't be used with SplitBy in the config")
+		case cfg.ModuleTemplate != "":
+			return nil, fmt.Errorf("SplitFiles (or OutputFileNames.Layout) can't be used with ModuleTemplate in the config")
+		case len(cfg.EnvSplit) != 0:
+			return nil, fmt.Errorf("SplitFiles (or OutputFileNames.Layout) can't be used with EnvSplit in the config")
+		}
+	}
+	switch cfg.OutputSyntax {
+	case "", OutputSyntaxHCL, OutputSyntaxTFJSON:
+	default:
+		return nil, fmt.Errorf("unknown output syntax %q in the config", cfg.OutputSyntax)
+	}
+	renderer := cfg.Renderer
+	if renderer == nil {
+		renderer = builtinRenderer(cfg.OutputSyntax)
+	}
+	// The other ways of generating the config are in the HCL native syntax only.
+	if _, ok := renderer.(hclRenderer); !ok {
+		switch {
+		case cfg.SplitBy != "":
+			return nil, fmt.Errorf("OutputSyntax (or Renderer) can't be used with SplitBy in the config")
+		case cfg.ModuleTemplate != "":
+			return nil, fmt.Errorf("OutputSyntax (or Renderer) can't be used with ModuleTemplate in the config")
+		case len(cfg.EnvSplit) != 0:
+			return nil, fmt.Errorf("OutputSyntax (or Renderer) can't be used with EnvSplit in the config")
+		}
+	}
+	var moduleTemplate string
+	if cfg.ModuleTemplate != "" {
+		if moduleTemplate, err = parseModuleTemplate(cfg.ModuleTemplate); err != nil {
+			return nil, fmt.Errorf("invalid ModuleTemplate in the config: %v", err)
+		}
+		// The existing child modules don't declare the variables, or refer to the files generated to the root module.
+		switch {
+		case cfg.ModulePath != "":
+			return nil, fmt.Errorf("ModuleTemplate can't be used with ModulePath in the config")
+		case cfg.SplitBy != "":
+			return nil, fmt.Errorf("ModuleTemplate can't be used with SplitBy in the config")
+		case len(cfg.EnvSplit) != 0:
+			return nil, fmt.Errorf("ModuleTemplate can't be used with EnvSplit in the config")
+		case cfg.GenerateDataSources:
+			return nil, fmt.Errorf("ModuleTemplate can't be used with GenerateDataSources in the config")
+		case len(cfg.KeyVaultIds) != 0:
+			return nil, fmt.Errorf("ModuleTemplate can't be used with KeyVaultIds in the config")
+		case cfg.ExtractVariables:
+			return nil, fmt.Errorf("ModuleTemplate can't be used with ExtractVariables in the config")
+		case cfg.OnSecret == OnSecretVar:
+			return nil, fmt.Errorf("ModuleTemplate can't be used with OnSecret %q in the config", OnSecretVar)
+		}
+	} else if cfg.CreateMissingModules {
+		return nil, fmt.Errorf("CreateMissingModules requires ModuleTemplate in the config")
+	}
+
+	var excludePatterns []excludePattern
+	if cfg.ExcludeFile != "" {
+		excludePatterns, err = loadExcludeFile(cfg.ExcludeFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ignoreFile := cfg.IgnoreFile
+	if ignoreFile == "" {
+		ignoreFile = filepath.Join(cfg.OutputDir, IgnoreFileName)
+		if _, err := os.Stat(ignoreFile); err != nil {
+			ignoreFile = ""
+		}
+	}
+	var ignoreRules []ignoreRule
+	if ignoreFile != "" {
+		ignoreRules, err = loadIgnoreFile(ignoreFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	variableAttributes := DefaultVariableAttributes
+	if cfg.VariableAttributesFile != "" {
+		variableAttributes, err = LoadVariableAttributes(cfg.VariableAttributesFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch cfg.ProviderMajorVersion {
+	case "":
+	case ProviderMajorVersion3, ProviderMajorVersion4:
+		if cfg.ProviderName != ProviderAzureRM {
+			return nil, fmt.Errorf("ProviderMajorVersion can only be used when ProviderName is %q in the config", ProviderAzureRM)
+		}
+		if v := providerMajorVersionOf(cfg.ProviderVersion); v != "" && v != cfg.ProviderMajorVersion {
+			return nil, fmt.Errorf("ProviderMajorVersion %q conflicts with ProviderVersion %q in the config", cfg.ProviderMajorVersion, cfg.ProviderVersion)
+		}
+	default:
+		return nil, fmt.Errorf("unknown provider major version %q in the config", cfg.ProviderMajorVersion)
+	}
+
+	if cfg.ProviderSource != "" {
+		if err := ValidateProviderSource(cfg.ProviderSource); err != nil {
+			return nil, fmt.Errorf("invalid ProviderSource in the config: %v", err)
+		}
+	}
+
+	if cfg.ProviderMirror != "" {
+		if strings.HasPrefix(cfg.ProviderMirror, "http://") {
+			return nil, fmt.Errorf("ProviderMirror must be a HTTPS URL or a local directory in the config")
+		}
+		if !isNetworkMirror(cfg.ProviderMirror) {
+			if cfg.ProviderMirror, err = filepath.Abs(cfg.ProviderMirror); err != nil {
+				return nil, fmt.Errorf("getting the absolute path of the ProviderMirror: %v", err)
+			}
+		}
+	}
+
+	if cfg.ProviderPluginCacheDir != "" {
+		// Terraform requires the plugin cache directory to be an absolute path, as it runs in different working directories.
+		if cfg.ProviderPluginCacheDir, err = filepath.Abs(cfg.ProviderPluginCacheDir); err != nil {
+			return nil, fmt.Errorf("getting the absolute path of the ProviderPluginCacheDir: %v", err)
+		}
+	}
+
+	var cache *gencache.Cache
+	if cfg.CacheDir != "" {
+		if cache, err = gencache.New(cfg.CacheDir); err != nil {
+			return nil, err
+		}
+	}
+
 	if !cfg.DevProvider && cfg.ProviderVersion == "" {
 		cfg.ProviderVersion = azurerm.ProviderSchemaInfo.Version
+		switch {
+		case cfg.ProviderName == ProviderAzAPI:
+			cfg.ProviderVersion = AzAPIProviderVersion
+		case cfg.ProviderMajorVersion == ProviderMajorVersion4:
+			cfg.ProviderVersion = AzureRMV4ProviderVersion
+		}
+	}
+	if cfg.ProviderName == ProviderAzureRM && cfg.ProviderMajorVersion == "" {
+		cfg.ProviderMajorVersion = providerMajorVersionOf(cfg.ProviderVersion)
 	}
 
 	meta := &baseMeta{
-		subscriptionId:    cfg.SubscriptionId,
-		azureSDKCred:      cfg.AzureSDKCredential,
-		azureSDKClientOpt: cfg.AzureSDKClientOption,
-		outdir:            cfg.OutputDir,
-		outputFileNames:   outputFileNames,
-		resourceClient:    resClient,
-		providerVersion:   cfg.ProviderVersion,
-		devProvider:       cfg.DevProvider,
-		backendType:       cfg.BackendType,
-		backendConfig:     cfg.BackendConfig,
-		providerConfig:    cfg.ProviderConfig,
-		fullConfig:        cfg.FullConfig,
-		parallelism:       cfg.Parallelism,
-		hclOnly:           cfg.HCLOnly,
-		tfclient:          cfg.TFClient,
+		subscriptionId:         cfg.SubscriptionId,
+		azureSDKCred:           cfg.AzureSDKCredential,
+		azureSDKClientOpt:      cfg.AzureSDKClientOption,
+		outdir:                 cfg.OutputDir,
+		outputFileNames:        outputFileNames,
+		resourceClient:         resClient,
+		providerName:           cfg.ProviderName,
+		azapiFallback:          cfg.AzAPIFallback,
+		typeOverrides:          typeOverrides,
+		resolvers:              cfg.Resolvers,
+		resolverPlugin:         resolverPlugin,
+		subresourceStrategy:    cfg.SubresourceStrategy,
+		providerVersion:        cfg.ProviderVersion,
+		providerMajorVersion:   cfg.ProviderMajorVersion,
+		providerRegistry:       cfg.ProviderRegistry,
+		customProviderSource:   cfg.ProviderSource,
+		providerMirror:         cfg.ProviderMirror,
+		providerPluginCacheDir: cfg.ProviderPluginCacheDir,
+		devProvider:            cfg.DevProvider,
+		backendType:            cfg.BackendType,
+		backendConfig:          cfg.BackendConfig,
+		cloudOrganization:      cfg.CloudOrganization,
+		cloudWorkspace:         cfg.CloudWorkspace,
+		workspace:              cfg.Workspace,
+		localThenMigrate:       cfg.LocalThenMigrate,
+		holdBackendLock:        cfg.HoldBackendLock,
+		backendBootstrap:       cfg.BackendBootstrap,
+		providerConfig:         cfg.ProviderConfig,
+		providerFeatures:       cfg.ProviderFeatures,
+		fullConfig:             cfg.FullConfig,
+		propertyRules:          rules,
+		exportARMJSON:          cfg.ExportARMJSON,
+		emitDependsOn:          cfg.EmitDependsOn,
+		stackConfigType:        cfg.StackConfigType,
+		aksProviders:           cfg.AKSProviders,
+		backstageCatalog:       cfg.BackstageCatalog,
+		backstageOwner:         cfg.BackstageOwner,
+		backstageSystem:        cfg.BackstageSystem,
+		inventory:              cfg.Inventory,
+		generateOutputs:        cfg.GenerateOutputs,
+		outputRules:            outRules,
+		graphOutput:            cfg.GraphOutput,
+		injectTags:             cfg.InjectTags,
+		applyInjectedTags:      cfg.ApplyInjectedTags,
+		keyVaultIds:            cfg.KeyVaultIds,
+		onSecret:               cfg.OnSecret,
+		secretAllowlist:        allowlist,
+		onLocked:               cfg.OnLocked,
+		onSoftDeleted:          cfg.OnSoftDeleted,
+		extensionResourceTypes: extensionResourceTypes(cfg),
+		includeMonitoring:      cfg.IncludeMonitoring,
+		includeKeyVaultItems:   cfg.IncludeKeyVaultItems,
+		includeStorageItems:    cfg.IncludeStorageItems,
+		limit:                  cfg.Limit,
+		sample:                 cfg.Sample,
+		envSplit:               cfg.EnvSplit,
+		parallelism:            cfg.Parallelism,
+		importTimeout:          cfg.ImportTimeout,
+		useImportBlocks:        cfg.UseImportBlocks,
+		cache:                  cache,
+		dryRun:                 cfg.DryRun,
+		hooks:                  cfg.Hooks,
+		commandHooks:           cfg.CommandHooks,
+		prune:                  cfg.Prune,
+		movedFromState:         cfg.MovedFromState,
+		excludePatterns:        excludePatterns,
+		ignoreRules:            ignoreRules,
+		authScaffold:           cfg.AuthScaffold,
+		namingStrategy:         cfg.NamingStrategy,
+		generateDataSources:    cfg.GenerateDataSources,
+		rewriteReferences:      !cfg.DisableReferenceRewrite,
+		extractVariables:       cfg.ExtractVariables,
+		variableAttributes:     variableAttributes,
+		splitBy:                cfg.SplitBy,
+		fileLayout:             fileLayout,
+		layoutFiles:            map[string]bool{},
+		renderer:               renderer,
+		moduleTemplate:         moduleTemplate,
+		createMissingModules:   cfg.CreateMissingModules,
+		sortResources:          cfg.SortResources,
+		scopeIds:               map[string]bool{},
+		generatedHCL:           map[string][]byte{},
+		importFailures:         map[string]ImportItem{},
+		dataSources:            map[string]dataSource{},
+		dataSourceNames:        map[string]bool{},
+		variables:              map[variableValue]string{},
+		splitModules:           map[string]bool{},
+		crossModuleRefs:        map[crossModuleRef]bool{},
+		templateModuleDirs:     map[string]string{},
+		secretFindings:         map[secretFinding]bool{},
+		aliasSubscriptionIds:   aliasSubscriptionIds(cfg.SubscriptionId, cfg.AdditionalSubscriptionIds),
+		credentialAliases:      credentialAliases,
+		hclOnly:                cfg.HCLOnly,
+		tfclient:               cfg.TFClient,
+		showCommands:           cfg.ShowCommands,
 
 		moduleAddr: moduleAddr,
 		moduleDir:  moduleDir,
@@ -222,9 +824,27 @@ func (meta baseMeta) Workspace() string {
 	return meta.outdir
 }
 
-func (meta *baseMeta) Init(ctx context.Context) error {
-	meta.tc.Trace(telemetry.Info, "Init Enter")
-	defer meta.tc.Trace(telemetry.Info, "Init Leave")
+func (meta baseMeta) ProviderNames() []string {
+	if meta.azapiFallback {
+		return []string{meta.providerName, ProviderAzAPI}
+	}
+	return []string{meta.providerName}
+}
+
+func (meta baseMeta) ProviderVersion() string {
+	return meta.providerVersion
+}
+
+func (meta *baseMeta) Init(ctx context.Context) (err error) {
+	defer meta.hookError(&err)
+	end := meta.tc.StartSpan("Init")
+	defer func() { end(err) }()
+	defer log.Phase("init")()
+
+	// The dry run only lists the resources, which needs no terraform.
+	if meta.dryRun {
+		return nil
+	}
 
 	if meta.tfclient != nil {
 		return meta.init_notf(ctx)
@@ -233,9 +853,21 @@ func (meta *baseMeta) Init(ctx context.Context) error {
 	return meta.init_tf(ctx)
 }
 
-func (meta baseMeta) DeInit(ctx context.Context) error {
-	meta.tc.Trace(telemetry.Info, "DeInit Enter")
-	defer meta.tc.Trace(telemetry.Info, "DeInit Leave")
+func (meta baseMeta) DeInit(ctx context.Context) (err error) {
+	defer meta.hookError(&err)
+	end := meta.tc.StartSpan("DeInit")
+	defer func() { end(err) }()
+	defer log.Phase("deinit")()
+
+	if meta.resolverPlugin != nil {
+		if err := meta.resolverPlugin.Close(); err != nil {
+			log.Printf("[WARN] Failed to close the resolver plugin: %v", err)
+		}
+	}
+
+	if meta.dryRun {
+		return nil
+	}
 
 	if meta.tfclient != nil {
 		return meta.deinit_notf(ctx)
@@ -245,18 +877,41 @@ func (meta baseMeta) DeInit(ctx context.Context) error {
 }
 
 func (meta *baseMeta) CleanTFState(ctx context.Context, addr string) {
-	// Noop if tfclient is set
 	if meta.tfclient != nil {
+		if !meta.usesTFClientState() {
+			return
+		}
+		state, err := removeStateResource(meta.baseState, addr)
+		if err != nil {
+			log.Printf("[WARN] Removing %s from the state: %v", addr, err)
+			return
+		}
+		meta.baseState = state
+		if err := meta.writeLocalState(); err != nil {
+			log.Printf("[WARN] %v", err)
+		}
 		return
 	}
 
 	// #nosec G104
-	meta.tf.StateRm(ctx, addr)
+	meta.withBackendUnlocked(ctx, func() error {
+		return meta.auditLog.Run(meta.tf, func() error { return meta.tf.StateRm(ctx, addr) })
+	})
 }
 
-func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) error {
-	meta.tc.Trace(telemetry.Info, "ParallelImport Enter")
-	defer meta.tc.Trace(telemetry.Info, "ParallelImport Leave")
+func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) (err error) {
+	defer meta.hookError(&err)
+	end := meta.tc.StartSpan("ParallelImport")
+	defer func() { end(err) }()
+	defer log.Phase("import")()
+	if err := meta.runCommandHooks(ctx, HookPhasePreImport, importItemList(items)); err != nil {
+		return err
+	}
+	if meta.moduleTemplate != "" {
+		if err := meta.resolveTemplateModules(items); err != nil {
+			return err
+		}
+	}
 	itemsCh := make(chan *ImportItem, len(items))
 	for _, item := range items {
 		itemsCh <- item
@@ -265,6 +920,7 @@ func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) e
 
 	wp := workerpool.NewWorkPool(meta.parallelism)
 
+	var states []importState
 	wp.Run(func(i interface{}) error {
 		idx := i.(int)
 
@@ -279,16 +935,7 @@ func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) e
 		if _, err := os.Stat(stateFile); os.IsNotExist(err) {
 			return nil
 		}
-		// Ensure the state file is removed after this round import, preparing for the next round.
-		defer os.Remove(stateFile)
-
-		log.Printf("[DEBUG] Merging terraform state file %s (tfmerge)", stateFile)
-		newState, err := tfmerge.Merge(ctx, meta.tf, meta.baseState, stateFile)
-		if err != nil {
-			return fmt.Errorf("failed to merge state file: %v", err)
-		}
-		meta.baseState = newState
-
+		states = append(states, importState{idx: idx, path: stateFile})
 		return nil
 	})
 
@@ -302,20 +949,62 @@ func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) e
 		})
 	}
 
+	// Ensure the state files are removed after this round import, preparing for the next round.
+	defer func() {
+		for _, state := range states {
+			// #nosec G104
+			os.Remove(state.path)
+		}
+	}()
+
 	// #nosec G104
 	if err := wp.Done(); err != nil {
 		return err
 	}
+	meta.recordImportResults(items)
 
-	return nil
+	// The states read via the tfclient are set to the base state directly, which is written to the local state file by PushState.
+	if meta.usesTFClientState() {
+		if err := meta.setTFClientStates(items); err != nil {
+			return fmt.Errorf("setting the imported states: %v", err)
+		}
+	}
+
+	// The config has been generated from the state file during the import, the state is populated by the user via the import blocks.
+	if !meta.useImportBlocks {
+		if err := meta.mergeImportStates(ctx, states); err != nil {
+			return err
+		}
+	}
+
+	// The resources are imported to the root module of the import directories, which are then moved to their child modules.
+	if meta.splitsModules() && meta.tfclient == nil && !meta.useImportBlocks && len(meta.baseState) != 0 {
+		state, err := meta.moveStateToModules(meta.baseState, items)
+		if err != nil {
+			return fmt.Errorf("moving the imported resources to the child modules: %v", err)
+		}
+		meta.baseState = state
+	}
+
+	return meta.runCommandHooks(ctx, HookPhasePostImport, importItemList(items))
 }
 
-func (meta baseMeta) PushState(ctx context.Context) error {
-	meta.tc.Trace(telemetry.Info, "PushState Enter")
-	defer meta.tc.Trace(telemetry.Info, "PushState Leave")
+func (meta *baseMeta) PushState(ctx context.Context) (err error) {
+	defer meta.hookError(&err)
+	end := meta.tc.StartSpan("PushState")
+	defer func() { end(err) }()
+	defer log.Phase("push_state")()
 
-	// Noop if tfclient is set
+	// Write the local state file directly if tfclient is set, or noop for HCLOnly
 	if meta.tfclient != nil {
+		if meta.hclOnly {
+			return nil
+		}
+		return meta.writeLocalState()
+	}
+
+	// Noop if the state is populated via the import blocks
+	if meta.useImportBlocks {
 		return nil
 	}
 
@@ -324,17 +1013,6 @@ func (meta baseMeta) PushState(ctx context.Context) error {
 		return nil
 	}
 
-	// Ensure there is no out of band change on the base state
-	baseState, err := meta.tf.StatePull(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to pull state: %v", err)
-	}
-	if baseState != string(meta.originBaseState) {
-		edits := myers.ComputeEdits(span.URIFromPath("origin.tfstate"), string(meta.originBaseState), baseState)
-		changes := fmt.Sprint(gotextdiff.ToUnified("origin.tfstate", "current.tfstate", string(meta.originBaseState), edits))
-		return fmt.Errorf("there is out-of-band changes on the state file:\n%s", changes)
-	}
-
 	// Create a temporary state file to hold the merged states, then push the state to the output directory.
 	f, err := os.CreateTemp("", "")
 	if err != nil {
@@ -350,20 +1028,146 @@ func (meta baseMeta) PushState(ctx context.Context) error {
 
 	defer os.Remove(f.Name())
 
-	if err := meta.tf.StatePush(ctx, f.Name(), tfexec.Lock(true)); err != nil {
-		return fmt.Errorf("failed to push state: %v", err)
-	}
+	// The held backend lock is released for the push, which is locked by terraform on its own.
+	return meta.withBackendUnlocked(ctx, func() error {
+		// Ensure there is no out of band change on the base state
+		baseState, err := meta.statePull(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to pull state: %v", err)
+		}
+		if baseState != string(meta.originBaseState) {
+			edits := myers.ComputeEdits(span.URIFromPath("origin.tfstate"), string(meta.originBaseState), baseState)
+			changes := fmt.Sprint(gotextdiff.ToUnified("origin.tfstate", "current.tfstate", string(meta.originBaseState), edits))
+			return fmt.Errorf("there is out-of-band changes on the state file:\n%s", changes)
+		}
 
-	return nil
+		if err := meta.auditLog.Run(meta.tf, func() error { return meta.tf.StatePush(ctx, f.Name(), tfexec.Lock(true)) }); err != nil {
+			return fmt.Errorf("failed to push state: %v", err)
+		}
+
+		// Refresh the base state, in case there are further imports and pushes afterwards (e.g. exporting in chunks).
+		baseState, err = meta.statePull(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to pull state: %v", err)
+		}
+		meta.baseState = []byte(baseState)
+		meta.originBaseState = []byte(baseState)
+		return nil
+	})
 }
 
-func (meta baseMeta) GenerateCfg(ctx context.Context, l ImportList) error {
-	meta.tc.Trace(telemetry.Info, "GenerateCfg Enter")
-	defer meta.tc.Trace(telemetry.Info, "GenerateCfg Leave")
-	return meta.generateCfg(ctx, l, meta.lifecycleAddon, meta.addDependency)
+func (meta *baseMeta) GenerateCfg(ctx context.Context, l ImportList) (err error) {
+	defer meta.hookError(&err)
+	end := meta.tc.StartSpan("GenerateCfg")
+	defer func() { end(err) }()
+	defer log.Phase("generate_config")()
+	if err := meta.runCommandHooks(ctx, HookPhasePreGenerate, l); err != nil {
+		return err
+	}
+	if len(meta.keyVaultIds) != 0 && meta.keyVaultSecrets == nil {
+		secrets, err := meta.loadKeyVaultSecrets(ctx)
+		if err != nil {
+			return fmt.Errorf("loading the Key Vault secrets: %v", err)
+		}
+		meta.keyVaultSecrets = secrets
+		meta.keyVaultSecretRefs = map[string]keyVaultSecret{}
+	}
+	// The resources might be skipped (or un-skipped) interactively after listing
+	for _, item := range l {
+		meta.scopeIds[strings.ToUpper(item.AzureResourceID.String())] = !item.Skip()
+	}
+	// The Key Vault secrets are substituted prior to the secret detection, as the references are not secrets anymore.
+	// The variables are extracted after the secret detection, so that no secret ends up as a variable default.
+	// The references are rewritten prior to adding the dependencies, which are then only added for the remaining hardcoded ids.
+	if err := meta.generateCfg(ctx, l, meta.lifecycleAddon, meta.referenceAddon, meta.addDependency, meta.dataSourceAddon, meta.writeOnlyAddon, meta.injectTagAddon, meta.keyVaultRefAddon, meta.secretAddon, meta.variableAddon, meta.providerAliasAddon, meta.canonicalizeAddon); err != nil {
+		return err
+	}
+	if meta.applyInjectedTags {
+		if err := meta.tagLiveResources(ctx, l); err != nil {
+			return fmt.Errorf("applying the injected tags: %v", err)
+		}
+	}
+	if err := meta.writeKeyVaultSecrets(); err != nil {
+		return err
+	}
+	if err := meta.writeDataSources(); err != nil {
+		return err
+	}
+	if err := meta.writeVariables(); err != nil {
+		return err
+	}
+	if meta.onSecret != "" {
+		if err := meta.writeSecretsReport(); err != nil {
+			return err
+		}
+	}
+	if meta.authScaffold != nil {
+		if err := meta.writeAuthScaffold(); err != nil {
+			return err
+		}
+	}
+	// The child modules are installed for the later terraform commands in the output directory (e.g. converting the state of the next chunk, verification).
+	if meta.splitsModules() && meta.tf != nil {
+		if err := meta.auditLog.Run(meta.tf, func() error { return meta.tf.Get(ctx) }); err != nil {
+			return fmt.Errorf("installing the child modules: %v", err)
+		}
+	}
+	// The config might be generated in chunks, the outputs below that cover all the resources are based on the accumulated list.
+	meta.generatedList = append(meta.generatedList, l...)
+	if meta.exportARMJSON {
+		if err := meta.writeARMJSON(ctx, l); err != nil {
+			return fmt.Errorf("exporting the ARM JSON: %v", err)
+		}
+	}
+	if meta.stackConfigType != "" {
+		if err := meta.writeStackConfig(); err != nil {
+			return fmt.Errorf("generating the %s stack config: %v", meta.stackConfigType, err)
+		}
+	}
+	if meta.aksProviders {
+		if err := meta.writeAKSProviders(meta.generatedList); err != nil {
+			return fmt.Errorf("generating the AKS providers: %v", err)
+		}
+	}
+	if meta.backstageCatalog {
+		if err := meta.writeBackstageCatalog(meta.generatedList); err != nil {
+			return fmt.Errorf("generating the Backstage catalog: %v", err)
+		}
+	}
+	if meta.inventory {
+		if err := meta.writeInventory(meta.generatedList); err != nil {
+			return fmt.Errorf("generating the inventory: %v", err)
+		}
+	}
+	if meta.generateOutputs {
+		if err := meta.writeOutputs(meta.generatedList); err != nil {
+			return fmt.Errorf("generating the outputs: %v", err)
+		}
+	}
+	if meta.graphOutput != "" {
+		if err := meta.writeGraph(meta.generatedList); err != nil {
+			return fmt.Errorf("generating the graph: %v", err)
+		}
+	}
+	if meta.movedFromState != "" {
+		if err := meta.writeMovedBlocks(meta.generatedList); err != nil {
+			return fmt.Errorf("generating the moved blocks: %v", err)
+		}
+	}
+	if len(meta.envSplit) != 0 {
+		if err := meta.writeEnvSplit(meta.generatedList); err != nil {
+			return fmt.Errorf("generating the environments: %v", err)
+		}
+	}
+	if err := meta.runCommandHooks(ctx, HookPhasePostGenerate, l); err != nil {
+		return err
+	}
+	meta.hookConfigGenerated(l)
+	return nil
 }
 
-func (meta baseMeta) ExportResourceMapping(ctx context.Context, l ImportList) error {
+func (meta baseMeta) ExportResourceMapping(ctx context.Context, l ImportList) (err error) {
+	defer meta.hookError(&err)
 	m := resmap.ResourceMapping{}
 	for _, item := range l {
 		if item.Skip() {
@@ -375,6 +1179,8 @@ func (meta baseMeta) ExportResourceMapping(ctx context.Context, l ImportList) er
 			ResourceId:   item.TFResourceId,
 			ResourceType: item.TFAddr.Type,
 			ResourceName: item.TFAddr.Name,
+			ImportId:     item.ImportId,
+			Candidates:   item.RankedRecommendations(),
 		}
 	}
 	b, err := json.MarshalIndent(m, "", "\t")
@@ -392,16 +1198,21 @@ func (meta baseMeta) ExportResourceMapping(ctx context.Context, l ImportList) er
 	if meta.tf == nil {
 		supportPlannableImport = true
 	} else {
-		ver, _, err := meta.tf.Version(ctx, true)
+		ver, err := meta.tfVersion(ctx)
 		if err != nil {
 			return fmt.Errorf("getting terraform version")
 		}
 		supportPlannableImport = ver.GreaterThanOrEqual(version.Must(version.NewVersion("v1.5.0")))
 	}
 	if supportPlannableImport {
+		items := make(ImportList, len(l))
+		copy(items, l)
+		sort.SliceStable(items, func(i, j int) bool {
+			return resourceLess(meta.sortResources, items[i], items[j])
+		})
 		f := hclwrite.NewFile()
 		body := f.Body()
-		for _, item := range l {
+		for _, item := range items {
 			if item.Skip() {
 				continue
 			}
@@ -409,7 +1220,14 @@ func (meta baseMeta) ExportResourceMapping(ctx context.Context, l ImportList) er
 			// The import block
 			blk := hclwrite.NewBlock("import", nil)
 			blk.Body().SetAttributeValue("id", cty.StringVal(item.TFResourceId))
-			blk.Body().SetAttributeTraversal("to", hcl.Traversal{hcl.TraverseRoot{Name: item.TFAddr.Type}, hcl.TraverseAttr{Name: item.TFAddr.Name}})
+			to := hcl.Traversal{hcl.TraverseRoot{Name: item.TFAddr.Type}, hcl.TraverseAttr{Name: item.TFAddr.Name}}
+			if module := meta.splitModule(item); module != "" {
+				to = hcl.Traversal{hcl.TraverseRoot{Name: "module"}, hcl.TraverseAttr{Name: module}, hcl.TraverseAttr{Name: item.TFAddr.Type}, hcl.TraverseAttr{Name: item.TFAddr.Name}}
+			}
+			blk.Body().SetAttributeTraversal("to", to)
+			if alias := meta.providerAlias(item); alias != "" {
+				blk.Body().SetAttributeTraversal("provider", providerAliasTraversal(alias))
+			}
 			body.AppendBlock(blk)
 		}
 		oImportFile := filepath.Join(meta.moduleDir, meta.outputFileNames.ImportBlockFileName)
@@ -421,7 +1239,8 @@ func (meta baseMeta) ExportResourceMapping(ctx context.Context, l ImportList) er
 	return nil
 }
 
-func (meta baseMeta) ExportSkippedResources(_ context.Context, l ImportList) error {
+func (meta baseMeta) ExportSkippedResources(_ context.Context, l ImportList) (err error) {
+	defer meta.hookError(&err)
 	var sl []string
 	for _, item := range l {
 		if item.Skip() {
@@ -443,7 +1262,12 @@ func (meta baseMeta) ExportSkippedResources(_ context.Context, l ImportList) err
 	return nil
 }
 
-func (meta baseMeta) CleanUpWorkspace(_ context.Context) error {
+func (meta baseMeta) CleanUpWorkspace(ctx context.Context) (err error) {
+	defer meta.hookError(&err)
+	// The export is done, release the backend lock for the followups (e.g. the verification).
+	if err := meta.unlockBackend(ctx); err != nil {
+		return fmt.Errorf("unlocking the backend state: %v", err)
+	}
 	// For hcl only mode with using terraform binary, we will have to clean up everything under the output directory,
 	// except for the TF code, resource mapping file and ignore list file.
 	if meta.hclOnly && meta.tfclient == nil {
@@ -456,13 +1280,15 @@ func (meta baseMeta) CleanUpWorkspace(_ context.Context) error {
 			os.RemoveAll(tmpDir)
 		}()
 
-		tmpMainCfg := filepath.Join(tmpDir, meta.outputFileNames.MainFileName)
 		tmpProviderCfg := filepath.Join(tmpDir, meta.outputFileNames.ProviderFileName)
 		tmpResourceMappingFileName := filepath.Join(tmpDir, ResourceMappingFileName)
 		tmpSkippedResourcesFileName := filepath.Join(tmpDir, SkippedResourcesFileName)
 
-		if err := utils.CopyFile(filepath.Join(meta.outdir, meta.outputFileNames.MainFileName), tmpMainCfg); err != nil {
-			return err
+		cfgFileNames := meta.configFileNames()
+		for _, name := range cfgFileNames {
+			if err := utils.CopyFile(filepath.Join(meta.outdir, name), filepath.Join(tmpDir, name)); err != nil {
+				return err
+			}
 		}
 		if err := utils.CopyFile(filepath.Join(meta.outdir, meta.outputFileNames.ProviderFileName), tmpProviderCfg); err != nil {
 			return err
@@ -476,12 +1302,14 @@ func (meta baseMeta) CleanUpWorkspace(_ context.Context) error {
 			}
 		}
 
-		if err := utils.RemoveEverythingUnder(meta.outdir); err != nil {
+		if err := utils.RemoveEverythingUnder(meta.outdir, generatedArtifactNames...); err != nil {
 			return err
 		}
 
-		if err := utils.CopyFile(tmpMainCfg, filepath.Join(meta.outdir, meta.outputFileNames.MainFileName)); err != nil {
-			return err
+		for _, name := range cfgFileNames {
+			if err := utils.CopyFile(filepath.Join(tmpDir, name), filepath.Join(meta.outdir, name)); err != nil {
+				return err
+			}
 		}
 		if err := utils.CopyFile(tmpProviderCfg, filepath.Join(meta.outdir, meta.outputFileNames.ProviderFileName)); err != nil {
 			return err
@@ -496,6 +1324,43 @@ func (meta baseMeta) CleanUpWorkspace(_ context.Context) error {
 		}
 	}
 
+	if meta.localThenMigrate {
+		if err := meta.migrateState(ctx); err != nil {
+			return fmt.Errorf("migrating the local state to the %s backend: %v", meta.backendType, err)
+		}
+	}
+
+	return nil
+}
+
+// migrateState switches the output directory from the local backend to the backend, and migrates the local state to it (see LocalThenMigrate).
+func (meta baseMeta) migrateState(ctx context.Context) error {
+	cfgFile := filepath.Join(meta.outdir, meta.outputFileNames.TerraformFileName)
+	// #nosec G306
+	if err := os.WriteFile(cfgFile, []byte(meta.buildTerraformConfig(meta.backendType)), 0644); err != nil {
+		return fmt.Errorf("updating the terraform config: %v", err)
+	}
+
+	// The "terraform init" is always run with "-force-copy", which implies "-migrate-state" and answers "yes" to the migration prompts.
+	var opts []tfexec.InitOption
+	for _, opt := range meta.backendConfig {
+		opts = append(opts, tfexec.BackendConfig(opt))
+	}
+	log.Printf(`[INFO] Run "terraform init -migrate-state" for the output directory %s`, meta.outdir)
+	if err := meta.auditLog.Run(meta.tf, func() error { return meta.tf.Init(ctx, opts...) }); err != nil {
+		return fmt.Errorf("running terraform init: %v", err)
+	}
+
+	// The local state files are left by terraform after the migration, which are stale then.
+	for _, name := range []string{"terraform.tfstate", "terraform.tfstate.backup"} {
+		if err := os.Remove(filepath.Join(meta.outdir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing the local state file %s: %v", name, err)
+		}
+	}
+	// The states of the non-default workspaces (see Workspace) are migrated as well.
+	if err := os.RemoveAll(filepath.Join(meta.outdir, "terraform.tfstate.d")); err != nil {
+		return fmt.Errorf("removing the local workspace states: %v", err)
+	}
 	return nil
 }
 
@@ -508,52 +1373,93 @@ func (meta baseMeta) generateCfg(ctx context.Context, l ImportList, cfgTrans ...
 	if err != nil {
 		return fmt.Errorf("Terraform HCL meta hook: %w", err)
 	}
+	meta.recordGeneratedHCL(cfginfos)
 
 	return meta.generateConfig(cfginfos)
 }
 
 func (meta *baseMeta) buildTerraformConfigForImportDir() string {
-	if meta.devProvider {
-		return "terraform {}"
+	return "terraform {\n" + meta.buildRequiredProviders() + "}\n"
+}
+
+func (meta *baseMeta) buildTerraformConfig(backendType string) string {
+	if backendType == BackendTypeCloud {
+		return "terraform {\n" + meta.buildCloudBlock() + meta.buildRequiredProviders() + "}\n"
 	}
+	return fmt.Sprintf("terraform {\n  backend %q {}\n", backendType) + meta.buildRequiredProviders() + "}\n"
+}
 
-	return fmt.Sprintf(`terraform {
-  required_providers {
-    azurerm = {
-      source = "hashicorp/azurerm"
+func (meta *baseMeta) buildRequiredProviders() string {
+	rps := meta.requiredProviders()
+	if len(rps) == 0 {
+		return ""
+	}
+	var providers []string
+	for _, rp := range rps {
+		providers = append(providers, fmt.Sprintf(`    %s = {
+      source = %q
       version = "%s"
     }
-  }
-}
-`, meta.providerVersion)
+`, rp.name, rp.source, rp.version))
+	}
+	return "  required_providers {\n" + strings.Join(providers, "") + "  }\n"
 }
 
-func (meta *baseMeta) buildTerraformConfig(backendType string) string {
-	if meta.devProvider {
-		return fmt.Sprintf(`terraform {
-  backend %q {}
+type requiredProvider struct {
+	name    string
+	source  string
+	version string
 }
-`, backendType)
+
+// requiredProviders returns the providers that are required by the exported resources. The dev provider is not required, as it is resolved via the dev_overrides.
+func (meta *baseMeta) requiredProviders() []requiredProvider {
+	var providers []requiredProvider
+	if !meta.devProvider {
+		providers = append(providers, requiredProvider{name: meta.providerName, source: meta.providerSource(meta.providerName), version: meta.providerVersion})
+	}
+	if meta.azapiFallback {
+		providers = append(providers, requiredProvider{name: ProviderAzAPI, source: meta.providerSource(ProviderAzAPI), version: AzAPIProviderVersion})
 	}
+	return providers
+}
 
-	return fmt.Sprintf(`terraform {
-  backend %q {}
-  required_providers {
-    azurerm = {
-      source = "hashicorp/azurerm"
-      version = "%s"
-    }
-  }
+// providerSource returns the source address of the provider, which is prefixed by the private registry hostname, if specified.
+// The custom source address, if specified, is used for the primary provider instead.
+func (meta *baseMeta) providerSource(providerName string) string {
+	if providerName == meta.providerName && meta.customProviderSource != "" {
+		return meta.customProviderSource
+	}
+	return ProviderSource(providerName, meta.providerRegistry)
 }
-`, backendType, meta.providerVersion)
+
+// ProviderSource returns the source address of the provider, which is prefixed by the private registry hostname, if not empty.
+func ProviderSource(providerName, registry string) string {
+	source := "hashicorp/azurerm"
+	if providerName == ProviderAzAPI {
+		source = "azure/azapi"
+	}
+	if registry != "" {
+		source = registry + "/" + source
+	}
+	return source
 }
 
-func (meta *baseMeta) buildProviderConfig() string {
+// buildProviderConfig builds the provider blocks of the specified providers. The provider config is only applied to the main provider (and its aliases).
+func (meta *baseMeta) buildProviderConfig(providerNames ...string) string {
 	f := hclwrite.NewEmptyFile()
-	body := f.Body().AppendNewBlock("provider", []string{"azurerm"}).Body()
-	body.AppendNewBlock("features", nil)
-	for k, v := range meta.providerConfig {
-		body.SetAttributeValue(k, v)
+	for _, providerName := range providerNames {
+		body := f.Body().AppendNewBlock("provider", []string{providerName}).Body()
+		if providerName == ProviderAzureRM {
+			meta.appendProviderFeatures(body)
+		}
+		if providerName == meta.providerName {
+			for k, v := range meta.providerConfig {
+				body.SetAttributeValue(k, v)
+			}
+		}
+		if providerName == ProviderAzureRM {
+			meta.appendAliasedProviders(f.Body())
+		}
 	}
 	return string(f.Bytes())
 }
@@ -565,7 +1471,15 @@ func (meta *baseMeta) init_notf(ctx context.Context) error {
 	}
 
 	// Ensure "features" is always defined in the provider initConfig
-	initConfig, err := ctyjson.Unmarshal([]byte(`{"features": []}`), configschema.SchemaBlockImpliedType(schResp.Provider.Block))
+	initConfigJSON := `{}`
+	if meta.providerName == ProviderAzureRM {
+		features, err := meta.providerFeaturesJSON()
+		if err != nil {
+			return err
+		}
+		initConfigJSON = `{"features": ` + features + `}`
+	}
+	initConfig, err := ctyjson.Unmarshal([]byte(initConfigJSON), configschema.SchemaBlockImpliedType(schResp.Provider.Block))
 	if err != nil {
 		return fmt.Errorf("ctyjson unmarshal initial provider config")
 	}
@@ -581,10 +1495,42 @@ func (meta *baseMeta) init_notf(ctx context.Context) error {
 		return fmt.Errorf("configure provider: %v", diags)
 	}
 
+	// The imported resources are written to the local state file directly, whose config is left for the user to "terraform init".
+	if meta.usesTFClientState() {
+		if err := meta.initProviderConfig(); err != nil {
+			return err
+		}
+		if err := meta.readLocalState(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (meta *baseMeta) init_tf(ctx context.Context) error {
+	if meta.providerMirror != "" {
+		if err := meta.writeCLIConfig(); err != nil {
+			return err
+		}
+	}
+
+	if meta.providerPluginCacheDir != "" {
+		// #nosec G301
+		if err := os.MkdirAll(meta.providerPluginCacheDir, 0750); err != nil {
+			return fmt.Errorf("creating the provider plugin cache directory %s: %v", meta.providerPluginCacheDir, err)
+		}
+		// #nosec G104
+		os.Setenv("TF_PLUGIN_CACHE_DIR", meta.providerPluginCacheDir)
+	}
+
+	// The backend is bootstrapped before being initialized, which is however not used until the end of the export if the local state is to be migrated.
+	if meta.backendBootstrap != nil {
+		if err := meta.bootstrapBackend(ctx); err != nil {
+			return fmt.Errorf("bootstrapping the backend: %v", err)
+		}
+	}
+
 	// Create the import directories per parallelism
 	if err := meta.initImportDirs(); err != nil {
 		return err
@@ -595,13 +1541,50 @@ func (meta *baseMeta) init_tf(ctx context.Context) error {
 		return err
 	}
 
+	if meta.useImportBlocks {
+		ver, err := meta.tfVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("getting terraform version: %v", err)
+		}
+		if !ver.GreaterThanOrEqual(version.Must(version.NewVersion("v1.5.0"))) {
+			return fmt.Errorf("import blocks require terraform >= v1.5.0, got %s", ver)
+		}
+	}
+
+	if meta.backendType == BackendTypeCloud {
+		ver, err := meta.tfVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("getting terraform version: %v", err)
+		}
+		if !ver.GreaterThanOrEqual(version.Must(version.NewVersion("v1.1.0"))) {
+			return fmt.Errorf("the cloud block requires terraform >= v1.1.0, got %s", ver)
+		}
+	}
+
+	if meta.movedFromState != "" {
+		ver, err := meta.tfVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("getting terraform version: %v", err)
+		}
+		if !ver.GreaterThanOrEqual(version.Must(version.NewVersion("v1.7.0"))) {
+			return fmt.Errorf("the removed blocks generated from the previous state require terraform >= v1.7.0, got %s", ver)
+		}
+	}
+
 	// Init provider
 	if err := meta.initProvider(ctx); err != nil {
 		return err
 	}
 
+	// Hold the backend lock for the whole session, prior to pulling the state, so that no other run can write the state in the middle of the export.
+	if meta.holdBackendLock {
+		if err := meta.lockBackend(ctx); err != nil {
+			return fmt.Errorf("locking the backend state: %v", err)
+		}
+	}
+
 	// Pull TF state
-	baseState, err := meta.tf.StatePull(ctx)
+	baseState, err := meta.statePull(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to pull state: %v", err)
 	}
@@ -662,11 +1645,22 @@ func (meta *baseMeta) initTF(ctx context.Context) error {
 	}
 	log.Printf("[INFO] Find terraform binary at %s", execPath)
 
+	var show io.Writer
+	if meta.showCommands {
+		show = os.Stderr
+	}
+	auditLog, err := tfaudit.Open(filepath.Join(meta.outdir, AuditLogFileName), show)
+	if err != nil {
+		return err
+	}
+	meta.auditLog = auditLog
+
 	newTF := func(dir string) (*tfexec.Terraform, error) {
 		tf, err := tfexec.NewTerraform(dir, execPath)
 		if err != nil {
 			return nil, fmt.Errorf("error running NewTerraform: %w", err)
 		}
+		meta.auditLog.Attach(tf)
 		if v, ok := os.LookupEnv("TF_LOG_PATH"); ok {
 			// #nosec G104
 			tf.SetLogPath(v)
@@ -695,47 +1689,48 @@ func (meta *baseMeta) initTF(ctx context.Context) error {
 	return nil
 }
 
-func (meta *baseMeta) initProvider(ctx context.Context) error {
-	log.Printf("[INFO] Init provider")
-
-	module, diags := tfconfig.LoadModule(meta.outdir)
-	if diags.HasErrors() {
-		return diags.Err()
-	}
+// statePull pulls the state of the output directory.
+func (meta baseMeta) statePull(ctx context.Context) (state string, err error) {
+	err = meta.auditLog.Run(meta.tf, func() (err error) {
+		state, err = meta.tf.StatePull(ctx)
+		return err
+	})
+	return state, err
+}
 
-	tfblock, err := utils.InspecTerraformBlock(meta.outdir)
-	if err != nil {
+// tfVersion returns the version of the terraform executable.
+func (meta baseMeta) tfVersion(ctx context.Context) (ver *version.Version, err error) {
+	err = meta.auditLog.Run(meta.tf, func() (err error) {
+		ver, _, err = meta.tf.Version(ctx, true)
 		return err
-	}
+	})
+	return ver, err
+}
 
-	if module.ProviderConfigs["azurerm"] == nil {
-		log.Printf("[INFO] Output directory doesn't contain provider setting, create one then")
-		cfgFile := filepath.Join(meta.outdir, meta.outputFileNames.ProviderFileName)
-		// #nosec G306
-		if err := os.WriteFile(cfgFile, []byte(meta.buildProviderConfig()), 0644); err != nil {
-			return fmt.Errorf("error creating provider config: %w", err)
-		}
-	}
+func (meta *baseMeta) initProvider(ctx context.Context) error {
+	log.Printf("[INFO] Init provider")
 
-	if tfblock == nil {
-		log.Printf("[INFO] Output directory doesn't contain terraform block, create one then")
-		cfgFile := filepath.Join(meta.outdir, meta.outputFileNames.TerraformFileName)
-		// #nosec G306
-		if err := os.WriteFile(cfgFile, []byte(meta.buildTerraformConfig(meta.backendType)), 0644); err != nil {
-			return fmt.Errorf("error creating terraform config: %w", err)
-		}
+	if err := meta.initProviderConfig(); err != nil {
+		return err
 	}
 
 	// Initialize provider for the output directory.
 	var opts []tfexec.InitOption
-	for _, opt := range meta.backendConfig {
-		opts = append(opts, tfexec.BackendConfig(opt))
+	if !meta.localThenMigrate {
+		for _, opt := range meta.backendConfig {
+			opts = append(opts, tfexec.BackendConfig(opt))
+		}
 	}
 
 	log.Printf(`[DEBUG] Run "terraform init" for the output directory %s`, meta.outdir)
-	if err := meta.tf.Init(ctx, opts...); err != nil {
+	if err := meta.auditLog.Run(meta.tf, func() error { return meta.tf.Init(ctx, opts...) }); err != nil {
 		return fmt.Errorf("error running terraform init for the output directory: %s", err)
 	}
+	if meta.workspace != "" {
+		if err := meta.selectWorkspace(ctx); err != nil {
+			return err
+		}
+	}
 
 	// Initialize provider for the import directories.
 	wp := workerpool.NewWorkPool(meta.parallelism)
@@ -745,7 +1740,7 @@ func (meta *baseMeta) initProvider(ctx context.Context) error {
 		wp.AddTask(func() (interface{}, error) {
 			providerFile := filepath.Join(meta.importBaseDirs[i], "provider.tf")
 			// #nosec G306
-			if err := os.WriteFile(providerFile, []byte(meta.buildProviderConfig()), 0644); err != nil {
+			if err := os.WriteFile(providerFile, []byte(meta.buildProviderConfig(meta.ProviderNames()...)), 0644); err != nil {
 				return nil, fmt.Errorf("error creating provider config: %w", err)
 			}
 			terraformFile := filepath.Join(meta.importBaseDirs[i], "terraform.tf")
@@ -753,11 +1748,11 @@ func (meta *baseMeta) initProvider(ctx context.Context) error {
 			if err := os.WriteFile(terraformFile, []byte(meta.buildTerraformConfigForImportDir()), 0644); err != nil {
 				return nil, fmt.Errorf("error creating terraform config: %w", err)
 			}
-			if meta.devProvider {
+			if meta.devProvider && !meta.azapiFallback {
 				log.Printf(`[DEBUG] Skip running "terraform init" for the import directory (dev provider): %s`, meta.importBaseDirs[i])
 			} else {
 				log.Printf(`[DEBUG] Run "terraform init" for the import directory %s`, meta.importBaseDirs[i])
-				if err := meta.importTFs[i].Init(ctx); err != nil {
+				if err := meta.auditLog.Run(meta.importTFs[i], func() error { return meta.importTFs[i].Init(ctx) }); err != nil {
 					return nil, fmt.Errorf("error running terraform init: %s", err)
 				}
 			}
@@ -771,12 +1766,88 @@ func (meta *baseMeta) initProvider(ctx context.Context) error {
 	return nil
 }
 
+// initProviderConfig creates the provider config and the terraform block in the output directory, or merges the required settings to the existing ones.
+func (meta *baseMeta) initProviderConfig() error {
+	module, diags := tfconfig.LoadModule(meta.outdir)
+	if diags.HasErrors() {
+		return diags.Err()
+	}
+
+	tfblock, err := utils.InspecTerraformBlock(meta.outdir)
+	if err != nil {
+		return err
+	}
+
+	var missingProviders []string
+	for _, providerName := range meta.ProviderNames() {
+		if module.ProviderConfigs[providerName] == nil {
+			missingProviders = append(missingProviders, providerName)
+		}
+	}
+	if len(missingProviders) != 0 {
+		log.Printf("[INFO] Output directory doesn't contain provider setting of %s, create one then", strings.Join(missingProviders, ", "))
+		cfgFile := filepath.Join(meta.outdir, meta.outputFileNames.ProviderFileName)
+		if err := appendToFile(cfgFile, meta.buildProviderConfig(missingProviders...)); err != nil {
+			return fmt.Errorf("error creating provider config: %w", err)
+		}
+	}
+	if err := meta.mergeProviderFeatures(); err != nil {
+		return fmt.Errorf("merging the existing provider config: %w", err)
+	}
+
+	if tfblock == nil {
+		log.Printf("[INFO] Output directory doesn't contain terraform block, create one then")
+		cfgFile := filepath.Join(meta.outdir, meta.outputFileNames.TerraformFileName)
+		// The local backend is used during the export, which is migrated to the backend at the end.
+		backendType := meta.backendType
+		if meta.localThenMigrate {
+			backendType = "local"
+		}
+		// #nosec G306
+		if err := os.WriteFile(cfgFile, []byte(meta.buildTerraformConfig(backendType)), 0644); err != nil {
+			return fmt.Errorf("error creating terraform config: %w", err)
+		}
+	} else {
+		if err := meta.mergeRequiredProviders(); err != nil {
+			return fmt.Errorf("merging the existing terraform config: %w", err)
+		}
+	}
+	return nil
+}
+
 func (meta *baseMeta) importItem(ctx context.Context, item *ImportItem, importIdx int) {
 	if item.Skip() {
 		log.Printf("[INFO] Skipping %s", item.TFResourceId)
 		return
 	}
 
+	item.TFResourceId = meta.importId(*item)
+
+	meta.hookImportStart(*item)
+	defer func() {
+		meta.hookImportDone(*item)
+		meta.traceImportEvent(*item)
+	}()
+
+	if meta.importTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, meta.importTimeout)
+		defer cancel()
+		defer func() {
+			if ctx.Err() != context.DeadlineExceeded || item.ImportError == nil {
+				return
+			}
+			item.ImportError = fmt.Errorf("importing timed out after %s: %w", meta.importTimeout, item.ImportError)
+			resourceLogger(*item).Printf("[ERROR] Importing %s timed out after %s", item.TFAddr, meta.importTimeout)
+			if meta.tfclient == nil {
+				// The killed terraform process leaves the state lock behind, which blocks the following imports of the import directory.
+				if err := os.Remove(filepath.Join(meta.importBaseDirs[importIdx], ".terraform.tfstate.lock.info")); err != nil && !os.IsNotExist(err) {
+					log.Printf("[WARN] Removing the state lock of the import directory %s: %v", meta.importBaseDirs[importIdx], err)
+				}
+			}
+		}()
+	}
+
 	if meta.tfclient != nil {
 		meta.importItem_notf(ctx, item, importIdx)
 		return
@@ -785,17 +1856,51 @@ func (meta *baseMeta) importItem(ctx context.Context, item *ImportItem, importId
 	meta.importItem_tf(ctx, item, importIdx)
 }
 
+// resourceLogger returns the logger whose entries carry the Azure resource id of the item, which correlates the entries of the resource among the parallel imports.
+func resourceLogger(item ImportItem) log.Logger {
+	return log.With(log.Fields{"resource_id": item.AzureResourceID.String()})
+}
+
+// traceImportEvent records the import result of the item, where only the resource types are recorded, not the resource ids.
+func (meta *baseMeta) traceImportEvent(item ImportItem) {
+	result := "success"
+	if item.ImportError != nil {
+		result = "failure"
+	}
+	meta.tc.Event("import", map[string]string{
+		"resource_type": item.AzureResourceID.TypeString(),
+		"tf_type":       item.TFAddr.Type,
+		"result":        result,
+	})
+}
+
 func (meta *baseMeta) importItem_tf(ctx context.Context, item *ImportItem, importIdx int) {
+	logger := resourceLogger(*item)
 	moduleDir := meta.importModuleDirs[importIdx]
 	tf := meta.importTFs[importIdx]
 
+	// The configs generated from the import directory are cached by the etag of the resource, in which case the unchanged resource needs no import.
+	var etag string
+	if meta.cache != nil && meta.useImportBlocks && item.TFAddr.Type != AzAPIResourceType {
+		etag = meta.resourceETag(ctx, item.AzureResourceID)
+		if meta.cachedConfig(item, etag) {
+			logger.Printf("[INFO] Using the cached config of %s as %s", item.TFResourceId, item.TFAddr)
+			item.ImportError = nil
+			item.Imported = true
+			return
+		}
+	}
+
 	// Construct the empty cfg file for importing
 	cfgFile := filepath.Join(moduleDir, "tmp.aztfexport.tf")
 	tpl := fmt.Sprintf(`resource "%s" "%s" {}`, item.TFAddr.Type, item.TFAddr.Name)
+	if alias := meta.providerAlias(*item); alias != "" {
+		tpl = fmt.Sprintf("resource %q %q {\n  provider = %s.%s\n}\n", item.TFAddr.Type, item.TFAddr.Name, ProviderAzureRM, alias)
+	}
 	// #nosec G306
 	if err := os.WriteFile(cfgFile, []byte(tpl), 0644); err != nil {
 		err := fmt.Errorf("generating resource template file for %s: %w", item.TFAddr, err)
-		log.Printf("[ERROR] %v", err)
+		logger.Printf("[ERROR] %v", err)
 		item.ImportError = err
 		return
 	}
@@ -807,13 +1912,16 @@ func (meta *baseMeta) importItem_tf(ctx context.Context, item *ImportItem, impor
 		addr = meta.moduleAddr + "." + addr
 	}
 
-	log.Printf("[INFO] Importing %s as %s", item.TFResourceId, addr)
+	logger.Printf("[INFO] Importing %s as %s", item.TFResourceId, addr)
 	// The actual resource type names in telemetry is redacted
 	meta.tc.Trace(telemetry.Info, fmt.Sprintf("Importing %s as %s", item.AzureResourceID.TypeString(), addr))
 
-	err := tf.Import(ctx, addr, item.TFResourceId)
+	err := meta.auditLog.Run(tf, func() error { return tf.Import(ctx, addr, item.TFResourceId) })
+	if err == nil && meta.useImportBlocks {
+		err = meta.importDirConfig(ctx, tf, item, addr, etag)
+	}
 	if err != nil {
-		log.Printf("[ERROR] Importing %s: %v", item.TFAddr, err)
+		logger.Printf("[ERROR] Importing %s: %v", item.TFAddr, err)
 		meta.tc.Trace(telemetry.Error, fmt.Sprintf("Importing %s failed", item.AzureResourceID.TypeString()))
 		meta.tc.Trace(telemetry.Error, fmt.Sprintf("Error detail: %v", err))
 	} else {
@@ -823,10 +1931,81 @@ func (meta *baseMeta) importItem_tf(ctx context.Context, item *ImportItem, impor
 	item.Imported = err == nil
 }
 
+// importDirConfig generates the config of the item from the state of the import directory, which replaces merging the state to the output directory
+// when UseImportBlocks is set. If the etag is not empty, the configs are cached.
+func (meta *baseMeta) importDirConfig(ctx context.Context, tf *tfexec.Terraform, item *ImportItem, addr, etag string) error {
+	// The azapi resources are generated from their ARM JSON.
+	if item.TFAddr.Type == AzAPIResourceType {
+		return nil
+	}
+	cacheable := meta.cache != nil && etag != ""
+	configs := map[bool][]byte{}
+	for _, full := range []bool{meta.fullConfig, !meta.fullConfig} {
+		// The other config is needed if the full config is needed but not asked (see needsFullConfig), or to be cached so that toggling the FullConfig
+		// in the later runs still hits the cache.
+		if full != meta.fullConfig && !(full && meta.needsFullConfig(item.TFAddr.Type)) && !cacheable {
+			continue
+		}
+		var bs [][]byte
+		err := meta.auditLog.Run(tf, func() (err error) {
+			bs, err = tfadd.StateForTargets(ctx, tf, []string{addr}, tfadd.Full(full))
+			return err
+		})
+		if err != nil {
+			if full {
+				return fmt.Errorf("converting terraform state to full config: %w", err)
+			}
+			return fmt.Errorf("converting terraform state to config: %w", err)
+		}
+		configs[full] = bs[0]
+		if cacheable {
+			if err := meta.cache.PutConfig(item.AzureResourceID.String(), etag, meta.configFingerprint(*item, full), bs[0]); err != nil {
+				log.Printf("[WARN] %v", err)
+			}
+		}
+	}
+	item.config = configs[meta.fullConfig]
+	if !meta.fullConfig && meta.needsFullConfig(item.TFAddr.Type) {
+		item.fullConfig = configs[true]
+	}
+	return nil
+}
+
+// configFingerprint returns the settings that the config of the item is generated with, which is part of the cache key of the config.
+func (meta baseMeta) configFingerprint(item ImportItem, full bool) string {
+	return fmt.Sprintf("%s@%s|%s|%s|%s|full=%t", meta.providerName, meta.providerVersion, item.TFAddr, item.TFResourceId, meta.providerAlias(item), full)
+}
+
+// cachedConfig sets the configs of the item from the cache, which reports whether they are all cached.
+func (meta baseMeta) cachedConfig(item *ImportItem, etag string) bool {
+	id := item.AzureResourceID.String()
+	cfg, ok, err := meta.cache.GetConfig(id, etag, meta.configFingerprint(*item, meta.fullConfig))
+	if err != nil {
+		log.Printf("[WARN] %v", err)
+	}
+	if !ok {
+		return false
+	}
+	var fullCfg []byte
+	if !meta.fullConfig && meta.needsFullConfig(item.TFAddr.Type) {
+		fullCfg, ok, err = meta.cache.GetConfig(id, etag, meta.configFingerprint(*item, true))
+		if err != nil {
+			log.Printf("[WARN] %v", err)
+		}
+		if !ok {
+			return false
+		}
+	}
+	item.config = cfg
+	item.fullConfig = fullCfg
+	return true
+}
+
 func (meta *baseMeta) importItem_notf(ctx context.Context, item *ImportItem, importIdx int) {
+	logger := resourceLogger(*item)
 	// Import resources
 	addr := item.TFAddr.String()
-	log.Printf("[INFO] Importing %s as %s", item.TFResourceId, addr)
+	logger.Printf("[INFO] Importing %s as %s", item.TFResourceId, addr)
 	// The actual resource type names in telemetry is redacted
 	meta.tc.Trace(telemetry.Info, fmt.Sprintf("Importing %s as %s", item.AzureResourceID.TypeString(), addr))
 
@@ -835,7 +2014,7 @@ func (meta *baseMeta) importItem_notf(ctx context.Context, item *ImportItem, imp
 		ID:       item.TFResourceId,
 	})
 	if diags.HasErrors() {
-		log.Printf("[ERROR] Importing %s: %v", item.TFAddr, diags)
+		logger.Printf("[ERROR] Importing %s: %v", item.TFAddr, diags)
 		meta.tc.Trace(telemetry.Error, fmt.Sprintf("Importing %s failed", item.AzureResourceID.TypeString()))
 		meta.tc.Trace(telemetry.Error, fmt.Sprintf("Error detail: %v", diags.Err()))
 		item.ImportError = diags.Err()
@@ -844,7 +2023,7 @@ func (meta *baseMeta) importItem_notf(ctx context.Context, item *ImportItem, imp
 	}
 	if len(importResp.ImportedResources) != 1 {
 		err := fmt.Errorf("expect 1 resource being imported, got=%d", len(importResp.ImportedResources))
-		log.Printf("[ERROR] %s", err)
+		logger.Printf("[ERROR] %s", err)
 		meta.tc.Trace(telemetry.Error, err.Error())
 		item.ImportError = err
 		item.Imported = false
@@ -857,7 +2036,7 @@ func (meta *baseMeta) importItem_notf(ctx context.Context, item *ImportItem, imp
 		Private:    res.Private,
 	})
 	if diags.HasErrors() {
-		log.Printf("[ERROR] Reading %s: %v", item.TFAddr, diags)
+		logger.Printf("[ERROR] Reading %s: %v", item.TFAddr, diags)
 		meta.tc.Trace(telemetry.Error, fmt.Sprintf("Reading %s failed", item.AzureResourceID.TypeString()))
 		meta.tc.Trace(telemetry.Error, fmt.Sprintf("Error detail: %v", diags.Err()))
 		item.ImportError = diags.Err()
@@ -866,27 +2045,163 @@ func (meta *baseMeta) importItem_notf(ctx context.Context, item *ImportItem, imp
 	}
 
 	item.State = readResp.NewState
+	item.private = readResp.Private
 	item.ImportError = nil
 	item.Imported = true
 	return
 }
 
+// stateToConfig generates the config of each imported item, in the order of the list. The per-item generation (e.g. reading the ARM JSON of the azapi resources,
+// parsing and tuning the HCL) runs in parallel, while the error of the foremost item is returned, so that the output is deterministic regardless of the scheduling.
 func (meta baseMeta) stateToConfig(ctx context.Context, list ImportList) (ConfigInfos, error) {
-	var out []ConfigInfo
-	var bs [][]byte
-
 	importedList := list.Imported()
 
-	if meta.tfclient != nil {
-		for _, item := range importedList {
-			schResp, diags := meta.tfclient.GetProviderSchema()
-			if diags.HasErrors() {
-				return nil, fmt.Errorf("get provider schema: %v", diags)
+	// The azapi resources are generated from their ARM JSON, while the others are generated from their state via tfadd.
+	var tfaddList ImportList
+	for _, item := range importedList {
+		if item.TFAddr.Type != AzAPIResourceType {
+			tfaddList = append(tfaddList, item)
+		}
+	}
+
+	bs, err := meta.tfaddConfigs(ctx, tfaddList, meta.fullConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Some attributes are only generated in the full config:
+	// - The inline sub-resources, which are optional and computed
+	// - The attributes introduced in azurerm v4, which are absent from the schema used for tuning the config
+	fullBs := map[string][]byte{}
+	if !meta.fullConfig {
+		var fullList ImportList
+		for _, item := range tfaddList {
+			if meta.needsFullConfig(item.TFAddr.Type) {
+				fullList = append(fullList, item)
 			}
-			rsch, ok := schResp.ResourceTypes[item.TFAddr.Type]
-			if !ok {
+		}
+		bs, err := meta.tfaddConfigs(ctx, fullList, true)
+		if err != nil {
+			return nil, err
+		}
+		for i, item := range fullList {
+			fullBs[item.TFAddr.String()] = bs[i]
+		}
+	}
+
+	// The tfadd generated HCL of each item, which is absent for the azapi resources.
+	itemBs := make([][]byte, len(importedList))
+	var i int
+	for idx, item := range importedList {
+		if item.TFAddr.Type != AzAPIResourceType {
+			itemBs[idx] = bs[i]
+			i++
+		}
+	}
+
+	out := make([]ConfigInfo, len(importedList))
+	if err := meta.forEachInParallel(len(importedList), func(idx int) (err error) {
+		item := importedList[idx]
+		out[idx], err = meta.itemConfig(ctx, item, itemBs[idx], fullBs[item.TFAddr.String()])
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// forEachInParallel calls f with each index in [0, n) by the parallelism of workers. Unlike the workerpool, which returns the errors in the order of their occurrences,
+// it returns the error of the smallest index (if any), which keeps the result deterministic.
+func (meta baseMeta) forEachInParallel(n int, f func(i int) error) error {
+	errs := make([]error, n)
+	wp := workerpool.NewWorkPool(meta.parallelism)
+	wp.Run(nil)
+	for i := 0; i < n; i++ {
+		i := i
+		wp.AddTask(func() (interface{}, error) {
+			errs[i] = f(i)
+			return nil, nil
+		})
+	}
+	// The tasks always succeed, whose errors are recorded in errs instead.
+	// #nosec G104
+	wp.Done()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// itemConfig generates the config of the imported item, from either its ARM JSON (for azapi), or its HCL generated by tfadd (b), complemented by its full HCL (fullB) if any.
+// It is safe to be called concurrently.
+func (meta baseMeta) itemConfig(ctx context.Context, item ImportItem, b, fullB []byte) (ConfigInfo, error) {
+	if item.TFAddr.Type == AzAPIResourceType {
+		f, err := meta.azapiConfig(ctx, item)
+		if err != nil {
+			return ConfigInfo{}, fmt.Errorf("generating config for resource %s: %v", item.TFAddr, err)
+		}
+		meta.propertyRules.apply(f.Body().Blocks()[0].Body(), nil, item.TFAddr.Type)
+		return ConfigInfo{ImportItem: item, hcl: f}, nil
+	}
+
+	f, diag := hclwrite.ParseConfig([]byte(meta.cleanupTerraformAdd(string(b))), "", hcl.InitialPos)
+	if diag.HasErrors() {
+		return ConfigInfo{}, fmt.Errorf("parsing the HCL generated by \"terraform add\" of %s: %s", item.TFAddr, diag.Error())
+	}
+	var fullBody *hclwrite.Body
+	if fullB != nil {
+		ff, diag := hclwrite.ParseConfig([]byte(meta.cleanupTerraformAdd(string(fullB))), "", hcl.InitialPos)
+		if diag.HasErrors() {
+			return ConfigInfo{}, fmt.Errorf("parsing the full HCL generated by \"terraform add\" of %s: %s", item.TFAddr, diag.Error())
+		}
+		fullBody = ff.Body().Blocks()[0].Body()
+		if sub, ok := inlineSubresourceByParentType(item.TFAddr.Type); ok && meta.subresourceStrategy == SubresourceStrategyInline {
+			hclBlockCopyAttribute(f.Body().Blocks()[0].Body(), fullBody, sub.Attribute)
+		}
+		if meta.providerMajorVersion == ProviderMajorVersion4 {
+			ProviderV4Addon(f, ff, item.TFAddr.Type)
+		}
+	}
+	meta.propertyRules.apply(f.Body().Blocks()[0].Body(), fullBody, item.TFAddr.Type)
+	return ConfigInfo{ImportItem: item, hcl: f}, nil
+}
+
+// needsFullConfig tells whether the full config of the resource type is needed to complement its (tuned) config.
+func (meta baseMeta) needsFullConfig(resourceType string) bool {
+	if _, ok := inlineSubresourceByParentType(resourceType); ok && meta.subresourceStrategy == SubresourceStrategyInline {
+		return true
+	}
+	if _, ok := providerV4Attributes[resourceType]; ok && meta.providerMajorVersion == ProviderMajorVersion4 {
+		return true
+	}
+	if len(meta.propertyRules.includes(resourceType)) != 0 {
+		return true
+	}
+	return false
+}
+
+// tfaddConfigs generates the TF config of each item from its state via tfadd.
+func (meta baseMeta) tfaddConfigs(ctx context.Context, l ImportList, full bool) ([][]byte, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+
+	if meta.tfclient != nil {
+		schResp, diags := meta.tfclient.GetProviderSchema()
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("get provider schema: %v", diags)
+		}
+		for _, item := range l {
+			if _, ok := schResp.ResourceTypes[item.TFAddr.Type]; !ok {
 				return nil, fmt.Errorf("no resource schema for %s found in the provider schema", item.TFAddr.Type)
 			}
+		}
+		bs := make([][]byte, len(l))
+		if err := meta.forEachInParallel(len(l), func(i int) error {
+			item := l[i]
+			rsch := schResp.ResourceTypes[item.TFAddr.Type]
 			b, err := tfadd.GenerateForOneResource(
 				&rsch,
 				tfstate.StateResource{
@@ -896,42 +2211,44 @@ func (meta baseMeta) stateToConfig(ctx context.Context, list ImportList) (Config
 					ProviderName: "registry.terraform.io/hashicorp/azurerm",
 					Value:        item.State,
 				},
-				meta.fullConfig)
+				full)
 			if err != nil {
-				return nil, fmt.Errorf("generating state for resource %s: %v", item.TFAddr, err)
+				return fmt.Errorf("generating state for resource %s: %v", item.TFAddr, err)
 			}
-			bs = append(bs, b)
-		}
-	} else {
-		var addrs []string
-		for _, item := range importedList {
-			addr := item.TFAddr.String()
-			if meta.moduleAddr != "" {
-				addr = meta.moduleAddr + "." + addr
-			}
-			addrs = append(addrs, addr)
+			bs[i] = b
+			return nil
+		}); err != nil {
+			return nil, err
 		}
+		return bs, nil
+	}
 
-		var err error
-		bs, err = tfadd.StateForTargets(ctx, meta.tf, addrs, tfadd.Full(meta.fullConfig))
-		if err != nil {
-			return nil, fmt.Errorf("converting terraform state to config: %w", err)
+	if meta.useImportBlocks {
+		var bs [][]byte
+		for _, item := range l {
+			b := item.config
+			if full && !meta.fullConfig {
+				b = item.fullConfig
+			}
+			bs = append(bs, b)
 		}
+		return bs, nil
 	}
 
-	for i, b := range bs {
-		tpl := meta.cleanupTerraformAdd(string(b))
-		f, diag := hclwrite.ParseConfig([]byte(tpl), "", hcl.InitialPos)
-		if diag.HasErrors() {
-			return nil, fmt.Errorf("parsing the HCL generated by \"terraform add\" of %s: %s", importedList[i].TFAddr, diag.Error())
-		}
-		out = append(out, ConfigInfo{
-			ImportItem: importedList[i],
-			hcl:        f,
-		})
+	var addrs []string
+	for _, item := range l {
+		addrs = append(addrs, meta.resourceAddr(item))
 	}
 
-	return out, nil
+	var bs [][]byte
+	err := meta.auditLog.Run(meta.tf, func() (err error) {
+		bs, err = tfadd.StateForTargets(ctx, meta.tf, addrs, tfadd.Full(full))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("converting terraform state to config: %w", err)
+	}
+	return bs, nil
 }
 
 func (meta baseMeta) terraformMetaHook(configs ConfigInfos, cfgTrans ...TFConfigTransformer) (ConfigInfos, error) {
@@ -946,15 +2263,25 @@ func (meta baseMeta) terraformMetaHook(configs ConfigInfos, cfgTrans ...TFConfig
 }
 
 func (meta baseMeta) generateConfig(cfgs ConfigInfos) error {
-	cfgFile := filepath.Join(meta.moduleDir, meta.outputFileNames.MainFileName)
-	buf := bytes.NewBuffer([]byte{})
+	if meta.splitBy != "" {
+		return meta.generateSplitConfig(cfgs)
+	}
+	if meta.moduleTemplate != "" {
+		return meta.generateTemplateModuleConfig(cfgs)
+	}
+	if meta.fileLayout != nil {
+		return meta.generateLayoutConfig(cfgs)
+	}
+	cfgFile := filepath.Join(meta.moduleDir, meta.renderer.FileName(meta.outputFileNames.MainFileName))
+	var blocks [][]byte
 	for _, cfg := range cfgs {
+		buf := bytes.NewBuffer([]byte{})
 		if _, err := cfg.DumpHCL(buf); err != nil {
 			return err
 		}
-		buf.Write([]byte("\n"))
+		blocks = append(blocks, buf.Bytes())
 	}
-	if err := appendToFile(cfgFile, buf.String()); err != nil {
+	if err := renderToFile(meta.renderer, cfgFile, blocks); err != nil {
 		return fmt.Errorf("generating main configuration file: %w", err)
 	}
 
@@ -962,6 +2289,11 @@ func (meta baseMeta) generateConfig(cfgs ConfigInfos) error {
 }
 
 func (meta baseMeta) cleanupTerraformAdd(tpl string) string {
+	return CleanupTerraformAdd(tpl)
+}
+
+// CleanupTerraformAdd removes the non HCL output of "terraform add", e.g. the state lock related logs when a TF backend is used.
+func CleanupTerraformAdd(tpl string) string {
 	segs := strings.Split(tpl, "\n")
 	// Removing:
 	// - preceding/trailing state lock related log when TF backend is used.
@@ -977,16 +2309,27 @@ func (meta baseMeta) cleanupTerraformAdd(tpl string) string {
 	return strings.Join(segs, "\n")
 }
 
-// lifecycleAddon adds lifecycle meta arguments for some identified resources, which are mandatory to make them usable.
+// lifecycleAddon adds lifecycle meta arguments for some identified resources, which are mandatory to make them usable, together with the ones of the property rules.
 func (meta baseMeta) lifecycleAddon(configs ConfigInfos) (ConfigInfos, error) {
 	out := make(ConfigInfos, len(configs))
 	for i, cfg := range configs {
+		var ignoreChanges []string
 		switch cfg.TFAddr.Type {
 		case "azurerm_application_insights_web_test":
-			if err := hclBlockAppendLifecycle(cfg.hcl.Body().Blocks()[0].Body(), []string{"tags"}); err != nil {
-				return nil, fmt.Errorf("azurerm_application_insights_web_test: %v", err)
+			ignoreChanges = append(ignoreChanges, "tags")
+		}
+		seen := map[string]bool{}
+		for _, attr := range ignoreChanges {
+			seen[attr] = true
+		}
+		for _, attr := range meta.propertyRules.ignoreChanges(cfg.TFAddr.Type) {
+			if !seen[attr] {
+				ignoreChanges = append(ignoreChanges, attr)
 			}
 		}
+		if err := hclBlockAppendLifecycle(cfg.hcl.Body().Blocks()[0].Body(), ignoreChanges); err != nil {
+			return nil, fmt.Errorf("%s: %v", cfg.TFAddr, err)
+		}
 		out[i] = cfg
 	}
 	return out, nil
@@ -996,6 +2339,11 @@ func (meta baseMeta) addDependency(configs ConfigInfos) (ConfigInfos, error) {
 	if err := configs.AddDependency(); err != nil {
 		return nil, err
 	}
+	if meta.emitDependsOn && meta.resourceClient != nil {
+		if err := configs.addARMDependency(meta.parallelism, meta.getARMResourceBody); err != nil {
+			return nil, fmt.Errorf("adding the dependencies by the ARM bodies: %v", err)
+		}
+	}
 
 	var out ConfigInfos
 
@@ -1005,8 +2353,8 @@ func (meta baseMeta) addDependency(configs ConfigInfos) (ConfigInfos, error) {
 	}
 
 	for _, cfg := range configs {
-		if len(cfg.DependsOn) != 0 {
-			if err := hclBlockAppendDependency(cfg.hcl.Body().Blocks()[0].Body(), cfg.DependsOn, configSet); err != nil {
+		if deps := meta.splitDependencies(cfg, configSet); len(deps) != 0 {
+			if err := hclBlockAppendDependency(cfg.hcl.Body().Blocks()[0].Body(), deps, configSet); err != nil {
 				return nil, err
 			}
 		}
@@ -1022,36 +2370,176 @@ func (meta *baseMeta) deinit_notf(ctx context.Context) error {
 }
 
 func (meta *baseMeta) deinit_tf(ctx context.Context) error {
+	// Release the backend lock, if not yet released by the CleanUpWorkspace (e.g. the export fails).
+	if err := meta.unlockBackend(ctx); err != nil {
+		log.Printf("[WARN] Failed to unlock the backend state: %v", err)
+	}
+
 	// Clean up the temporary workspaces for parallel import
 	for _, dir := range meta.importBaseDirs {
 		// #nosec G104
 		os.RemoveAll(dir)
 	}
+
+	if err := meta.auditLog.Close(); err != nil {
+		log.Printf("[WARN] Failed to close the audit log: %v", err)
+	}
 	return nil
 }
 
-func getModuleDir(modulePaths []string, moduleDir string) (string, error) {
-	// Ensure the module path is something called by the main module
-	// We are following the module source and recursively call the LoadModule below. This is valid since we only support local path modules.
-	// (remote sources are not supported since we will end up generating config to that module, it only makes sense for local path modules)
-	module, err := tfconfig.LoadModule(moduleDir)
+// postListResource applies the tweaks that are common to the listed resources of all kinds of meta.
+// The state is pruned against the full list, as the resources dropped afterwards (e.g. excluded) still exist.
+func (meta *baseMeta) postListResource(ctx context.Context, l ImportList) (ImportList, error) {
+	if err := meta.pruneState(ctx, l); err != nil {
+		return nil, fmt.Errorf("pruning the state: %v", err)
+	}
+	l = meta.excludeResources(l)
+	l = meta.ignoreResources(l)
+	l = meta.limitResources(l)
+	l, err := meta.applyLocks(ctx, l)
+	if err != nil {
+		return nil, err
+	}
+	l, err = meta.applySoftDeleted(ctx, l)
+	if err != nil {
+		return nil, fmt.Errorf("detecting the soft-deleted resources: %v", err)
+	}
+	for _, item := range l {
+		meta.scopeIds[strings.ToUpper(item.AzureResourceID.String())] = !item.Skip()
+	}
+	meta.hookResourcesDiscovered(l)
+	return l, nil
+}
+
+// toTFResources maps the Azure resource set to the TF resource set of the provider.
+func (meta baseMeta) toTFResources(ctx context.Context, rset *resourceset.AzureResourceSet) ([]resourceset.TFResource, error) {
+	if err := meta.populateBackendResources(rset); err != nil {
+		return nil, fmt.Errorf("populating the backend resources: %v", err)
+	}
+	if err := meta.populateExtensionResources(ctx, rset); err != nil {
+		return nil, fmt.Errorf("populating the extension resources: %v", err)
+	}
+	if err := meta.populateMonitoringResources(ctx, rset); err != nil {
+		return nil, fmt.Errorf("populating the monitoring resources: %v", err)
+	}
+	if err := meta.populateKeyVaultItems(ctx, rset); err != nil {
+		return nil, fmt.Errorf("populating the Key Vault items: %v", err)
+	}
+	if err := meta.populateStorageItems(ctx, rset); err != nil {
+		return nil, fmt.Errorf("populating the storage items: %v", err)
+	}
+	meta.hookResolveStart()
+
+	if meta.providerName == ProviderAzAPI {
+		// The populate/reduce tweaks are meant for the azurerm provider, which are not needed for azapi as it maps to the Azure resources one to one.
+		log.Printf("[DEBUG] Azure Resource set map to azapi resource set")
+		return rset.ToAzAPIResources(AzAPIResourceType, AzAPIResourceId), nil
+	}
+
+	if meta.subresourceStrategy == SubresourceStrategyInline {
+		log.Printf("[DEBUG] Remove inline sub-resources from resource set")
+		removeInlineSubresources(rset)
+	}
+
+	log.Printf("[DEBUG] Populate resource set")
+	if err := rset.PopulateResource(); err != nil {
+		return nil, fmt.Errorf("tweaking single resources in the azure resource set: %v", err)
+	}
+	log.Printf("[DEBUG] Reduce resource set")
+	if err := rset.ReduceResource(); err != nil {
+		return nil, fmt.Errorf("tweaking across resources in the azure resource set: %v", err)
+	}
+
+	log.Printf("[DEBUG] Azure Resource set map to TF resource set")
+	rl := rset.ToTFResources(meta.parallelism, meta.resolverChain())
+	if meta.providerMajorVersion == ProviderMajorVersion4 {
+		for i, res := range rl {
+			if replacements, ok := providerV4RemovedResourceTypes[res.TFType]; ok {
+				log.Printf("[INFO] The resolved resource type %s of %s is removed in azurerm v4", res.TFType, res.AzureId)
+				rl[i] = resourceset.TFResource{
+					AzureId:    res.AzureId,
+					TFId:       res.AzureId.String(),
+					Candidates: replacements,
+				}
+			}
+		}
+	}
+	if !meta.azapiFallback {
+		return rl, nil
+	}
+
+	// Map the Azure resources that have no azurerm resource type to azapi resources.
+	var out []resourceset.TFResource
+	var unresolved resourceset.AzureResourceSet
+	for _, res := range rl {
+		if res.TFType == "" {
+			unresolved.Resources = append(unresolved.Resources, resourceset.AzureResource{Id: res.AzureId})
+			continue
+		}
+		out = append(out, res)
+	}
+	log.Printf("[DEBUG] Unresolved Azure Resource set map to azapi resource set")
+	out = append(out, unresolved.ToAzAPIResources(AzAPIResourceType, AzAPIResourceId)...)
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].AzureId.String() < out[j].AzureId.String()
+	})
+	return out, nil
+}
+
+func (meta baseMeta) resolverChain() resourceset.ResolverChain {
+	chain := resourceset.ResolverChain{
+		Resolvers: meta.resolvers,
+		Plugin:    meta.resolverPlugin,
+		Overrides: meta.typeOverrides,
+		Cred:      meta.azureSDKCred,
+		ClientOpt: meta.azureSDKClientOpt,
+	}
+	if meta.resourceClient != nil {
+		chain.Fetch = meta.getARMResourceBody
+	}
+	return chain
+}
+
+func getModuleDir(modulePaths []string, rootDir string) (string, error) {
+	// Ensure the module path is something called by the main module.
+	// We are following the module source and recursively call the LoadModule below. The local path modules are followed directly, while the modules from remote sources
+	// (and their descendants) are followed via the module manifest of the downloaded modules.
+	module, err := tfconfig.LoadModule(rootDir)
 	if err != nil {
 		return "", fmt.Errorf("loading main module: %v", err)
 	}
 
+	moduleDir := rootDir
+	remote := false
 	for i, moduleName := range modulePaths {
+		key := strings.Join(modulePaths[:i+1], ".")
 		mc := module.ModuleCalls[moduleName]
 		if mc == nil {
-			return "", fmt.Errorf("no module %q invoked by the root module", strings.Join(modulePaths[:i+1], "."))
+			return "", fmt.Errorf("no module %q invoked by the root module", key)
 		}
 		// See https://developer.hashicorp.com/terraform/language/modules/sources#local-paths
-		if !strings.HasPrefix(mc.Source, "./") && !strings.HasPrefix(mc.Source, "../") {
-			return "", fmt.Errorf("the source of module %q is not a local path", strings.Join(modulePaths[:i+1], "."))
+		if !remote && (strings.HasPrefix(mc.Source, "./") || strings.HasPrefix(mc.Source, "../")) {
+			moduleDir = filepath.Join(moduleDir, mc.Source)
+		} else {
+			remote = true
+			dir, err := remoteModuleDir(rootDir, key)
+			if err != nil {
+				return "", fmt.Errorf("resolving module %q: %v", key, err)
+			}
+			moduleDir = dir
 		}
-		moduleDir = filepath.Join(moduleDir, mc.Source)
 		module, err = tfconfig.LoadModule(moduleDir)
 		if err != nil {
-			return "", fmt.Errorf("loading module %q: %v", strings.Join(modulePaths[:i+1], "."), err)
+			return "", fmt.Errorf("loading module %q: %v", key, err)
+		}
+	}
+
+	if remote {
+		// The config can't be generated to the downloaded module, which is managed by terraform.
+		moduleDir = filepath.Join(rootDir, RemoteModuleConfigDirPrefix+strings.Join(modulePaths, "."))
+		// #nosec G301
+		if err := os.MkdirAll(moduleDir, 0750); err != nil {
+			return "", fmt.Errorf("creating directory %s: %v", moduleDir, err)
 		}
 	}
 	return moduleDir, nil
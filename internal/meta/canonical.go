@@ -0,0 +1,127 @@
+package meta
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+const (
+	// SortResourcesByType sorts the generated resources by the TF resource type, then by the TF resource name.
+	SortResourcesByType = "type"
+	// SortResourcesByName sorts the generated resources by the TF resource name, then by the TF resource type.
+	SortResourcesByName = "name"
+	// SortResourcesByAzureId sorts the generated resources by the Azure resource id (case insensitively).
+	SortResourcesByAzureId = "azure-id"
+)
+
+// SortResourcesOptions are the supported orders of the generated resources.
+var SortResourcesOptions = []string{SortResourcesByType, SortResourcesByName, SortResourcesByAzureId}
+
+// resourceLess tells whether the resource of item i is ordered before the one of item j, by the sort option (defaults to SortResourcesByType).
+func resourceLess(sortBy string, i, j ImportItem) bool {
+	switch sortBy {
+	case SortResourcesByName:
+		if i.TFAddr.Name != j.TFAddr.Name {
+			return i.TFAddr.Name < j.TFAddr.Name
+		}
+		return i.TFAddr.Type < j.TFAddr.Type
+	case SortResourcesByAzureId:
+		return strings.ToUpper(i.AzureResourceID.String()) < strings.ToUpper(j.AzureResourceID.String())
+	default:
+		if i.TFAddr.Type != j.TFAddr.Type {
+			return i.TFAddr.Type < j.TFAddr.Type
+		}
+		return i.TFAddr.Name < j.TFAddr.Name
+	}
+}
+
+// canonicalizeAddon normalizes the generated config, so that the successive exports of the same resources only differ in their changes:
+// - The resources are sorted (see resourceLess).
+// - The attributes are sorted by name, following the meta-arguments, while the nested blocks follow the attributes (see hclBodyCanonicalize).
+// - The null and empty attributes are removed, unless the full config is asked.
+func (meta baseMeta) canonicalizeAddon(configs ConfigInfos) (ConfigInfos, error) {
+	out := make(ConfigInfos, len(configs))
+	copy(out, configs)
+	for _, cfg := range out {
+		for _, blk := range cfg.hcl.Body().Blocks() {
+			if blk.Type() == "resource" {
+				hclBodyCanonicalize(blk.Body(), !meta.fullConfig)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return resourceLess(meta.sortResources, out[i].ImportItem, out[j].ImportItem)
+	})
+	return out, nil
+}
+
+// hclAttributeRank is the rank of the attributes of a body, where the meta-arguments that affect the whole block come first, and the depends_on comes last
+// (after the nested blocks).
+func hclAttributeRank(name string) int {
+	switch name {
+	case "count", "for_each", "provider":
+		return 0
+	case "depends_on":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// hclExprIsEmpty tells whether the expression is null, an empty list or an empty map.
+func hclExprIsEmpty(expr *hclwrite.Expression) bool {
+	src := strings.Join(strings.Fields(string(expr.BuildTokens(nil).Bytes())), "")
+	return src == "null" || src == "[]" || src == "{}"
+}
+
+// hclBodyCanonicalize reorders the body (recursively) as: the meta-arguments, the other attributes sorted by name, the nested blocks (stably sorted by type,
+// as the order of the blocks of the same type might matter), the lifecycle block and the depends_on. The null and empty attributes are removed if removeEmpty is set.
+func hclBodyCanonicalize(body *hclwrite.Body, removeEmpty bool) {
+	attrs := body.Attributes()
+	var names []string
+	tokens := map[string]hclwrite.Tokens{}
+	for name, attr := range attrs {
+		body.RemoveAttribute(name)
+		if removeEmpty && hclExprIsEmpty(attr.Expr()) {
+			continue
+		}
+		names = append(names, name)
+		tokens[name] = attr.Expr().BuildTokens(nil)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := hclAttributeRank(names[i]), hclAttributeRank(names[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+
+	blocks := body.Blocks()
+	for _, blk := range blocks {
+		body.RemoveBlock(blk)
+	}
+	sort.SliceStable(blocks, func(i, j int) bool {
+		ti, tj := blocks[i].Type(), blocks[j].Type()
+		if (ti == "lifecycle") != (tj == "lifecycle") {
+			return tj == "lifecycle"
+		}
+		return ti < tj
+	})
+
+	for _, name := range names {
+		if hclAttributeRank(name) < 2 {
+			body.SetAttributeRaw(name, tokens[name])
+		}
+	}
+	for _, blk := range blocks {
+		hclBodyCanonicalize(blk.Body(), removeEmpty)
+		body.AppendBlock(blk)
+	}
+	for _, name := range names {
+		if hclAttributeRank(name) == 2 {
+			body.SetAttributeRaw(name, tokens[name])
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHclBodyCanonicalize(t *testing.T) {
+	input := `resource "azurerm_storage_account" "test" {
+  name     = "foo"
+  tags     = {}
+  depends_on = [
+    azurerm_resource_group.test,
+  ]
+  lifecycle {
+    ignore_changes = [tags]
+  }
+  network_rules {
+    ip_rules       = []
+    default_action = "Deny"
+  }
+  blob_properties {
+    versioning_enabled = true
+  }
+  access_tier = null
+  provider    = azurerm.sub1
+  network_rules {
+    default_action = "Allow"
+  }
+}
+`
+	cases := []struct {
+		name        string
+		removeEmpty bool
+		expect      string
+	}{
+		{
+			name:        "remove empty",
+			removeEmpty: true,
+			expect: `resource "azurerm_storage_account" "test" {
+  provider = azurerm.sub1
+  name     = "foo"
+  blob_properties {
+    versioning_enabled = true
+  }
+  network_rules {
+    default_action = "Deny"
+  }
+  network_rules {
+    default_action = "Allow"
+  }
+  lifecycle {
+    ignore_changes = [tags]
+  }
+  depends_on = [
+    azurerm_resource_group.test,
+  ]
+}
+`,
+		},
+		{
+			name: "keep empty",
+			expect: `resource "azurerm_storage_account" "test" {
+  provider    = azurerm.sub1
+  access_tier = null
+  name        = "foo"
+  tags        = {}
+  blob_properties {
+    versioning_enabled = true
+  }
+  network_rules {
+    default_action = "Deny"
+    ip_rules       = []
+  }
+  network_rules {
+    default_action = "Allow"
+  }
+  lifecycle {
+    ignore_changes = [tags]
+  }
+  depends_on = [
+    azurerm_resource_group.test,
+  ]
+}
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, diags := hclwrite.ParseConfig([]byte(input), "main.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+			hclBodyCanonicalize(f.Body().Blocks()[0].Body(), c.removeEmpty)
+			require.Equal(t, c.expect, string(hclwrite.Format(f.Bytes())))
+		})
+	}
+}
+
+func TestResourceLess(t *testing.T) {
+	newItem := func(id, typ, name string) ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return ImportItem{AzureResourceID: azureId, TFAddr: tfaddr.TFAddr{Type: typ, Name: name}}
+	}
+	vnet := newItem("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", "azurerm_virtual_network", "res-1")
+	rg := newItem("/subscriptions/123/resourceGroups/rg", "azurerm_resource_group", "res-2")
+
+	require.True(t, resourceLess("", rg, vnet))
+	require.True(t, resourceLess(SortResourcesByType, rg, vnet))
+	require.True(t, resourceLess(SortResourcesByName, vnet, rg))
+	require.True(t, resourceLess(SortResourcesByAzureId, rg, vnet))
+	require.False(t, resourceLess(SortResourcesByAzureId, vnet, rg))
+}
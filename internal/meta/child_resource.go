@@ -0,0 +1,131 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/magodo/armid"
+	"github.com/magodo/workerpool"
+)
+
+// childResourceEnumerator lists the ids of the child resources of a parent resource, which are neither listed by the Azure Resource Graph nor by the recursive listing of
+// the proxy resources (e.g. the subnets, the Key Vault keys and secrets, the DNS record sets).
+type childResourceEnumerator interface {
+	Enumerate(ctx context.Context, client *arm.Client, parentId armid.ResourceId) ([]string, error)
+}
+
+// armChildCollection enumerates the child resources by listing the ARM collection under the parent resource.
+type armChildCollection struct {
+	// path is the path of the collection relative to the parent resource id, e.g. "blobServices/default/containers".
+	path       string
+	apiVersion string
+	// skip tells whether the child resource of the uppercased id is implicitly managed by the parent, e.g. the SOA record set of a DNS zone. It can be nil.
+	skip func(uid string) bool
+}
+
+func (c armChildCollection) Enumerate(ctx context.Context, client *arm.Client, parentId armid.ResourceId) ([]string, error) {
+	ids, err := listResourceIds(ctx, client, parentId.String()+"/"+c.path, c.apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	if c.skip == nil {
+		return ids, nil
+	}
+	var out []string
+	for _, id := range ids {
+		if !c.skip(strings.ToUpper(id)) {
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+// skipZoneApexRecordSets skips the SOA and NS record sets at the apex of a DNS zone, which are created along with the zone.
+func skipZoneApexRecordSets(uid string) bool {
+	return strings.HasSuffix(uid, "/SOA/@") || strings.HasSuffix(uid, "/NS/@")
+}
+
+// childResourceEnumerators are the enumerators of the child resources, keyed by the route scope of the parent.
+// The enumerators are only used when the resources are listed recursively.
+var childResourceEnumerators = map[string][]childResourceEnumerator{
+	"/MICROSOFT.NETWORK/VIRTUALNETWORKS": {
+		armChildCollection{path: "subnets", apiVersion: "2022-07-01"},
+	},
+	// The certificates are composed from the keys and the secrets, see reduceForKeyVaultCertificate.
+	"/MICROSOFT.KEYVAULT/VAULTS": {
+		armChildCollection{path: "keys", apiVersion: "2022-07-01"},
+		armChildCollection{path: "secrets", apiVersion: "2022-07-01"},
+	},
+	"/MICROSOFT.STORAGE/STORAGEACCOUNTS": {
+		armChildCollection{path: "blobServices/default/containers", apiVersion: "2022-09-01"},
+		armChildCollection{path: "fileServices/default/shares", apiVersion: "2022-09-01"},
+	},
+	"/MICROSOFT.NETWORK/DNSZONES": {
+		armChildCollection{path: "recordsets", apiVersion: "2018-05-01", skip: skipZoneApexRecordSets},
+	},
+	"/MICROSOFT.NETWORK/PRIVATEDNSZONES": {
+		armChildCollection{path: "ALL", apiVersion: "2020-06-01", skip: skipZoneApexRecordSets},
+	},
+}
+
+// enumerateChildResources adds the child resources of the resources in the resource set, as listed by the childResourceEnumerators.
+// The failure of enumerating a parent resource (e.g. due to the lack of permission) is logged, instead of failing the listing.
+func (meta baseMeta) enumerateChildResources(ctx context.Context, rset *resourceset.AzureResourceSet) error {
+	client, err := arm.NewClient("meta.Client", "v0.0.0", meta.azureSDKCred, &meta.azureSDKClientOpt)
+	if err != nil {
+		return fmt.Errorf("building the client: %v", err)
+	}
+
+	var ids []string
+	wp := workerpool.NewWorkPool(meta.parallelism)
+	wp.Run(func(i interface{}) error {
+		ids = append(ids, i.([]string)...)
+		return nil
+	})
+	for _, res := range rset.Resources {
+		parentId := res.Id
+		for _, e := range childResourceEnumerators[strings.ToUpper(parentId.RouteScopeString())] {
+			e := e
+			wp.AddTask(func() (interface{}, error) {
+				l, err := e.Enumerate(ctx, client, parentId)
+				if err != nil {
+					log.Printf("[WARN] Failed to enumerate the child resources of %s: %v", parentId, err)
+				}
+				return l, nil
+			})
+		}
+	}
+	if err := wp.Done(); err != nil {
+		return err
+	}
+
+	rset.Resources = append(rset.Resources, newChildResources(rset.Resources, ids)...)
+	return nil
+}
+
+// newChildResources returns the child resources of the ids that are not among the resources.
+func newChildResources(resources []resourceset.AzureResource, ids []string) []resourceset.AzureResource {
+	set := map[string]bool{}
+	for _, res := range resources {
+		set[strings.ToUpper(res.Id.String())] = true
+	}
+	var out []resourceset.AzureResource
+	for _, id := range ids {
+		uid := strings.ToUpper(id)
+		if set[uid] {
+			continue
+		}
+		azureId, err := armid.ParseResourceId(id)
+		if err != nil {
+			log.Printf("[WARN] Failed to parse the child resource id %q: %v", id, err)
+			continue
+		}
+		set[uid] = true
+		out = append(out, resourceset.AzureResource{Id: azureId})
+	}
+	return out
+}
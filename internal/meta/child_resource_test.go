@@ -0,0 +1,39 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChildResources(t *testing.T) {
+	vnetId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1")
+	require.NoError(t, err)
+	subnetId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet1")
+	require.NoError(t, err)
+	resources := []resourceset.AzureResource{{Id: vnetId}, {Id: subnetId}}
+
+	var actual []string
+	for _, res := range newChildResources(resources, []string{
+		// Already listed
+		"/subscriptions/123/resourceGroups/RG1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet2",
+		// Duplicated
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet2",
+		"invalid",
+	}) {
+		actual = append(actual, res.Id.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet2",
+	}, actual)
+}
+
+func TestSkipZoneApexRecordSets(t *testing.T) {
+	require.True(t, skipZoneApexRecordSets("/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG1/PROVIDERS/MICROSOFT.NETWORK/DNSZONES/EXAMPLE.COM/SOA/@"))
+	require.True(t, skipZoneApexRecordSets("/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG1/PROVIDERS/MICROSOFT.NETWORK/DNSZONES/EXAMPLE.COM/NS/@"))
+	require.False(t, skipZoneApexRecordSets("/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG1/PROVIDERS/MICROSOFT.NETWORK/DNSZONES/EXAMPLE.COM/NS/SUB"))
+	require.False(t, skipZoneApexRecordSets("/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG1/PROVIDERS/MICROSOFT.NETWORK/DNSZONES/EXAMPLE.COM/A/@"))
+}
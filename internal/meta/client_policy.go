@@ -0,0 +1,56 @@
+package meta
+
+import (
+	"net/http"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// CorrelationIdHeader is the header that correlates the requests in the ARM activity logs.
+const CorrelationIdHeader = "x-ms-correlation-request-id"
+
+// ClientOption returns the client option of the ARM and ARG clients, which is the AzureSDKClientOption with the RetryPolicy and the ClientPolicy of the config applied.
+func ClientOption(cfg config.CommonConfig) arm.ClientOptions {
+	return WithClientPolicy(WithRetryPolicy(cfg.AzureSDKClientOption, cfg.RetryPolicy), cfg.ClientPolicy)
+}
+
+// WithClientPolicy returns the client option with the client policy applied. The pipeline policies are appended to the ones of the client option.
+func WithClientPolicy(opt arm.ClientOptions, p config.ClientPolicy) arm.ClientOptions {
+	// The policy slices are copied, to not mutate the ones of the original client option.
+	var perCall []policy.Policy
+	if p.UserAgentSuffix != "" || p.CorrelationId != "" {
+		// This runs after the telemetry policy, which sets the "User-Agent".
+		perCall = append(perCall, headerPolicy{userAgentSuffix: p.UserAgentSuffix, correlationId: p.CorrelationId})
+	}
+	perCall = append(perCall, p.PerCallPolicies...)
+	if len(perCall) != 0 {
+		opt.PerCallPolicies = append(append([]policy.Policy{}, opt.PerCallPolicies...), perCall...)
+	}
+	if len(p.PerRetryPolicies) != 0 {
+		opt.PerRetryPolicies = append(append([]policy.Policy{}, opt.PerRetryPolicies...), p.PerRetryPolicies...)
+	}
+	return opt
+}
+
+// headerPolicy sets the headers of the client policy.
+type headerPolicy struct {
+	userAgentSuffix string
+	correlationId   string
+}
+
+func (p headerPolicy) Do(req *policy.Request) (*http.Response, error) {
+	header := req.Raw().Header
+	if p.userAgentSuffix != "" {
+		ua := p.userAgentSuffix
+		if v := header.Get("User-Agent"); v != "" {
+			ua = v + " " + ua
+		}
+		header.Set("User-Agent", ua)
+	}
+	if p.correlationId != "" {
+		header.Set(CorrelationIdHeader, p.correlationId)
+	}
+	return req.Next()
+}
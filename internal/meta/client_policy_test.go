@@ -0,0 +1,58 @@
+package meta
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/stretchr/testify/require"
+)
+
+type captureTransport struct {
+	req *http.Request
+}
+
+func (t *captureTransport) Do(req *http.Request) (*http.Response, error) {
+	t.req = req
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+type headerSetter string
+
+func (h headerSetter) Do(req *policy.Request) (*http.Response, error) {
+	req.Raw().Header.Set("x-custom", string(h))
+	return req.Next()
+}
+
+func TestWithClientPolicy(t *testing.T) {
+	transport := &captureTransport{}
+	opt := arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Transport: transport,
+			Telemetry: policy.TelemetryOptions{ApplicationID: "aztfexport"},
+		},
+	}
+	opt = WithClientPolicy(opt, config.ClientPolicy{
+		PerCallPolicies: []policy.Policy{headerSetter("foo")},
+		UserAgentSuffix: "myapp/1.0",
+		CorrelationId:   "123",
+	})
+	pl := runtime.NewPipeline("test", "v0.0.0", runtime.PipelineOptions{}, &opt.ClientOptions)
+	req, err := runtime.NewRequest(context.Background(), http.MethodGet, "https://management.azure.com/subscriptions")
+	require.NoError(t, err)
+	_, err = pl.Do(req)
+	require.NoError(t, err)
+
+	ua := transport.req.Header.Get("User-Agent")
+	require.Regexp(t, `^aztfexport azsdk-go-test/v0.0.0 .* myapp/1.0$`, ua)
+	require.Equal(t, "123", transport.req.Header.Get(CorrelationIdHeader))
+	require.Equal(t, "foo", transport.req.Header.Get("x-custom"))
+
+	// The zero policy keeps the client option
+	opt = arm.ClientOptions{}
+	require.Equal(t, opt, WithClientPolicy(opt, config.ClientPolicy{}))
+}
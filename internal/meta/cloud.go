@@ -0,0 +1,27 @@
+package meta
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BackendTypeCloud is the pseudo backend type for the HCP Terraform (Terraform Cloud) workspace, which is configured by the "cloud" block, rather than a "backend" block.
+// The state is read and written through the API of HCP Terraform by "terraform state pull/push", while the imports still run locally in the import directories.
+const BackendTypeCloud = "cloud"
+
+// buildCloudBlock builds the "cloud" block of the terraform block. The unset organization or workspace is left out, which is then read by terraform from
+// the TF_CLOUD_ORGANIZATION or TF_WORKSPACE environment variable.
+func (meta *baseMeta) buildCloudBlock() string {
+	var lines []string
+	lines = append(lines, "  cloud {")
+	if meta.cloudOrganization != "" {
+		lines = append(lines, fmt.Sprintf("    organization = %q", meta.cloudOrganization))
+	}
+	if meta.cloudWorkspace != "" {
+		lines = append(lines, "    workspaces {")
+		lines = append(lines, fmt.Sprintf("      name = %q", meta.cloudWorkspace))
+		lines = append(lines, "    }")
+	}
+	lines = append(lines, "  }")
+	return strings.Join(lines, "\n") + "\n"
+}
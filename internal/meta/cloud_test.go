@@ -0,0 +1,46 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTerraformConfigCloud(t *testing.T) {
+	meta := baseMeta{
+		providerName:      ProviderAzureRM,
+		providerVersion:   "3.0.0",
+		cloudOrganization: "contoso",
+		cloudWorkspace:    "aztfexport",
+	}
+	require.Equal(t, `terraform {
+  cloud {
+    organization = "contoso"
+    workspaces {
+      name = "aztfexport"
+    }
+  }
+  required_providers {
+    azurerm = {
+      source = "hashicorp/azurerm"
+      version = "3.0.0"
+    }
+  }
+}
+`, meta.buildTerraformConfig(BackendTypeCloud))
+
+	// The unset organization and workspace are read by terraform from the environment variables
+	meta.cloudOrganization = ""
+	meta.cloudWorkspace = ""
+	require.Equal(t, `terraform {
+  cloud {
+  }
+  required_providers {
+    azurerm = {
+      source = "hashicorp/azurerm"
+      version = "3.0.0"
+    }
+  }
+}
+`, meta.buildTerraformConfig(BackendTypeCloud))
+}
@@ -0,0 +1,122 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/aztfexport/pkg/log"
+)
+
+const (
+	// HookPhasePreImport runs before importing the resources, for each round of import (e.g. per chunk)
+	HookPhasePreImport = "pre-import"
+	// HookPhasePostImport runs after importing the resources, for each round of import (e.g. per chunk)
+	HookPhasePostImport = "post-import"
+	// HookPhasePreGenerate runs before generating the config, for each round of generation (e.g. per chunk)
+	HookPhasePreGenerate = "pre-generate"
+	// HookPhasePostGenerate runs after generating the config, for each round of generation (e.g. per chunk)
+	HookPhasePostGenerate = "post-generate"
+)
+
+// HookPhases are the supported phases of the command hooks.
+var HookPhases = []string{HookPhasePreImport, HookPhasePostImport, HookPhasePreGenerate, HookPhasePostGenerate}
+
+func validHookPhase(phase string) bool {
+	for _, p := range HookPhases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// commandHookPayload is written to the stdin of the command hooks, as a JSON object.
+type commandHookPayload struct {
+	Phase          string                `json:"phase"`
+	OutputDir      string                `json:"output_dir"`
+	SubscriptionId string                `json:"subscription_id"`
+	ProviderName   string                `json:"provider_name"`
+	Resources      []commandHookResource `json:"resources"`
+}
+
+type commandHookResource struct {
+	AzureResourceId string `json:"azure_resource_id"`
+	TFResourceId    string `json:"tf_resource_id"`
+	TFAddr          string `json:"tf_addr"`
+	// The import error, which is only set for the post-import phase
+	ImportError string `json:"import_error,omitempty"`
+}
+
+func (meta baseMeta) commandHookPayload(phase string, l ImportList) commandHookPayload {
+	payload := commandHookPayload{
+		Phase:          phase,
+		OutputDir:      meta.outdir,
+		SubscriptionId: meta.subscriptionId,
+		ProviderName:   meta.providerName,
+		Resources:      []commandHookResource{},
+	}
+	for _, item := range l {
+		if item.Skip() {
+			continue
+		}
+		res := commandHookResource{
+			AzureResourceId: item.AzureResourceID.String(),
+			TFResourceId:    item.TFResourceId,
+			TFAddr:          item.TFAddr.String(),
+		}
+		if item.ImportError != nil {
+			res.ImportError = item.ImportError.Error()
+		}
+		payload.Resources = append(payload.Resources, res)
+	}
+	return payload
+}
+
+// runCommandHooks runs the command hooks of the phase in order, within the output directory. The payload describing the run and the (not skipped) resources
+// is written to their stdin. A hook that exits with a non-zero code fails the phase, e.g. a policy check that rejects the resources or the generated config.
+func (meta baseMeta) runCommandHooks(ctx context.Context, phase string, l ImportList) error {
+	var hooks []config.CommandHook
+	for _, hook := range meta.commandHooks {
+		if hook.Phase == phase {
+			hooks = append(hooks, hook)
+		}
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(meta.commandHookPayload(phase, l))
+	if err != nil {
+		return fmt.Errorf("marshalling the payload of the %s hooks: %v", phase, err)
+	}
+	for _, hook := range hooks {
+		args := strings.Fields(hook.Command)
+		// #nosec G204
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Dir = meta.outdir
+		cmd.Stdin = bytes.NewReader(b)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if len(out) != 0 {
+			log.Printf("[INFO] The %s hook %q outputs: %s", phase, hook.Command, out)
+		}
+		if err != nil {
+			return fmt.Errorf("running the %s hook %q: %v: %s", phase, hook.Command, err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return nil
+}
+
+// importItemList converts the items to be imported to an import list.
+func importItemList(items []*ImportItem) ImportList {
+	var l ImportList
+	for _, item := range items {
+		l = append(l, *item)
+	}
+	return l
+}
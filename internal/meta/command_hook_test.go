@@ -0,0 +1,102 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeHookEnv = "AZTFEXPORT_TEST_FAKE_HOOK"
+
+// TestMain runs the test binary as the fake command hook, if told by the environment variable.
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeHookEnv) == "1" {
+		fakeHook()
+	}
+	os.Exit(m.Run())
+}
+
+// fakeHook copies the payload to the working directory, and fails if it is of the pre-generate phase.
+func fakeHook() {
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		os.Exit(2)
+	}
+	var payload commandHookPayload
+	if err := json.Unmarshal(b, &payload); err != nil {
+		os.Exit(2)
+	}
+	// #nosec G306
+	if err := os.WriteFile(payload.Phase+".json", b, 0644); err != nil {
+		os.Exit(2)
+	}
+	if payload.Phase == HookPhasePreGenerate {
+		fmt.Fprintln(os.Stderr, "policy violated")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestRunCommandHooks(t *testing.T) {
+	t.Setenv(fakeHookEnv, "1")
+	outdir := t.TempDir()
+
+	var l ImportList
+	for _, id := range []string{
+		"/subscriptions/123/resourceGroups/rg1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+	} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		l = append(l, ImportItem{AzureResourceID: azureId, TFResourceId: id})
+	}
+	l[0].TFAddr = tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}
+	l[2].TFAddr = tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-2"}
+	l[2].ImportError = errors.New("import failed")
+
+	meta := baseMeta{
+		outdir:         outdir,
+		subscriptionId: "123",
+		providerName:   ProviderAzureRM,
+		commandHooks: []config.CommandHook{
+			{Phase: HookPhasePostImport, Command: os.Args[0] + " -test.run=^$"},
+			{Phase: HookPhasePreGenerate, Command: os.Args[0]},
+		},
+	}
+
+	// No hook of the phase
+	require.NoError(t, meta.runCommandHooks(context.Background(), HookPhasePreImport, l))
+	_, err := os.Stat(filepath.Join(outdir, HookPhasePreImport+".json"))
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, meta.runCommandHooks(context.Background(), HookPhasePostImport, l))
+	b, err := os.ReadFile(filepath.Join(outdir, HookPhasePostImport+".json"))
+	require.NoError(t, err)
+	var payload commandHookPayload
+	require.NoError(t, json.Unmarshal(b, &payload))
+	require.Equal(t, commandHookPayload{
+		Phase:          HookPhasePostImport,
+		OutputDir:      outdir,
+		SubscriptionId: "123",
+		ProviderName:   ProviderAzureRM,
+		// The skipped resource is excluded
+		Resources: []commandHookResource{
+			{AzureResourceId: l[0].AzureResourceID.String(), TFResourceId: l[0].TFResourceId, TFAddr: "azurerm_resource_group.res-0"},
+			{AzureResourceId: l[2].AzureResourceID.String(), TFResourceId: l[2].TFResourceId, TFAddr: "azurerm_virtual_network.res-2", ImportError: "import failed"},
+		},
+	}, payload)
+
+	err = meta.runCommandHooks(context.Background(), HookPhasePreGenerate, l)
+	require.ErrorContains(t, err, "policy violated")
+}
@@ -0,0 +1,200 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/magodo/aztft/aztft"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// dataSourceArguments maps the TF resource types, which have a data source of the same type, to the arguments that identify the data source.
+// The argument values are the names in the Azure resource id, from the innermost to the resource group. E.g. the azurerm_subnet data source is
+// identified by the subnet name, the virtual network name and the resource group name.
+var dataSourceArguments = map[string][]string{
+	"azurerm_resource_group":             {"name"},
+	"azurerm_application_insights":       {"name", "resource_group_name"},
+	"azurerm_application_security_group": {"name", "resource_group_name"},
+	"azurerm_container_registry":         {"name", "resource_group_name"},
+	"azurerm_dns_zone":                   {"name", "resource_group_name"},
+	"azurerm_key_vault":                  {"name", "resource_group_name"},
+	"azurerm_kubernetes_cluster":         {"name", "resource_group_name"},
+	"azurerm_lb":                         {"name", "resource_group_name"},
+	"azurerm_log_analytics_workspace":    {"name", "resource_group_name"},
+	"azurerm_mssql_server":               {"name", "resource_group_name"},
+	"azurerm_network_interface":          {"name", "resource_group_name"},
+	"azurerm_network_security_group":     {"name", "resource_group_name"},
+	"azurerm_private_dns_zone":           {"name", "resource_group_name"},
+	"azurerm_public_ip":                  {"name", "resource_group_name"},
+	"azurerm_route_table":                {"name", "resource_group_name"},
+	"azurerm_service_plan":               {"name", "resource_group_name"},
+	"azurerm_storage_account":            {"name", "resource_group_name"},
+	"azurerm_subnet":                     {"name", "virtual_network_name", "resource_group_name"},
+	"azurerm_user_assigned_identity":     {"name", "resource_group_name"},
+	"azurerm_virtual_hub":                {"name", "resource_group_name"},
+	"azurerm_virtual_network":            {"name", "resource_group_name"},
+}
+
+// dataSource is the data source of a resource that is referenced by the exported resources, but is not exported.
+type dataSource struct {
+	tfType string
+	tfName string
+	// args are the argument values, in the order of the dataSourceArguments of the tfType
+	args []string
+	// providerAlias is the alias of the azurerm provider that manages the subscription of the resource, empty for the default provider
+	providerAlias string
+}
+
+func (ds dataSource) traversal() hcl.Traversal {
+	return hcl.Traversal{
+		hcl.TraverseRoot{Name: "data"},
+		hcl.TraverseAttr{Name: ds.tfType},
+		hcl.TraverseAttr{Name: ds.tfName},
+		hcl.TraverseAttr{Name: "id"},
+	}
+}
+
+// inScope tells whether the resource, or any of its parent resources, is exported.
+func (meta baseMeta) inScope(id armid.ResourceId) bool {
+	for ; id != nil; id = id.Parent() {
+		if meta.scopeIds[strings.ToUpper(id.String())] {
+			return true
+		}
+	}
+	return false
+}
+
+// newDataSource builds the data source of the Azure resource, which returns false if the resource type has no known data source,
+// or the resource is out of the subscriptions that are managed by the providers.
+func (meta baseMeta) newDataSource(id armid.ResourceId) (dataSource, bool) {
+	types, _, err := aztft.QueryType(id.String(), nil)
+	if err != nil || len(types) != 1 {
+		return dataSource{}, false
+	}
+	tfType := types[0].TFType
+	argNames, ok := dataSourceArguments[tfType]
+	if !ok {
+		return dataSource{}, false
+	}
+
+	var args []string
+	switch id := id.(type) {
+	case *armid.ResourceGroup:
+		args = []string{id.Name}
+	case *armid.ScopedResourceId:
+		rg, ok := id.ParentScope().(*armid.ResourceGroup)
+		if !ok {
+			return dataSource{}, false
+		}
+		names := id.Names()
+		for i := len(names) - 1; i >= 0; i-- {
+			args = append(args, names[i])
+		}
+		args = append(args, rg.Name)
+	}
+	if len(args) != len(argNames) {
+		return dataSource{}, false
+	}
+
+	ds := dataSource{tfType: tfType, args: args}
+	if sub := subscriptionOf(id); !strings.EqualFold(sub, meta.subscriptionId) {
+		ds.providerAlias = meta.providerAlias(ImportItem{AzureResourceID: id, TFAddr: tfaddr.TFAddr{Type: tfType}})
+		if ds.providerAlias == "" {
+			return dataSource{}, false
+		}
+	}
+
+	name := invalidTFNameChars.ReplaceAllString(args[0], "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || name[0] == '-' {
+		name = "res-" + name
+	}
+	ds.tfName = name
+	for i := 2; meta.dataSourceNames[tfType+"."+ds.tfName]; i++ {
+		ds.tfName = name + "-" + strconv.Itoa(i)
+	}
+	return ds, true
+}
+
+// dataSourceAddon replaces the Azure resource ids, which are referenced by the exported resources but are not exported, with references to their data sources.
+// The data sources are recorded, and are written to the DataSourcesFileName afterwards.
+func (meta baseMeta) dataSourceAddon(configs ConfigInfos) (ConfigInfos, error) {
+	if !meta.generateDataSources {
+		return configs, nil
+	}
+	out := make(ConfigInfos, len(configs))
+	for i, cfg := range configs {
+		err := hclBlockRewriteStringValues(cfg.hcl.Body().Blocks()[0].Body(), func(val string) hcl.Traversal {
+			if !strings.HasPrefix(strings.ToLower(val), "/subscriptions/") {
+				return nil
+			}
+			id, err := armid.ParseResourceId(val)
+			if err != nil || meta.inScope(id) {
+				return nil
+			}
+			k := strings.ToUpper(id.String())
+			if ds, ok := meta.dataSources[k]; ok {
+				return ds.traversal()
+			}
+			ds, ok := meta.newDataSource(id)
+			if !ok {
+				log.Printf("[DEBUG] No data source is generated for the referenced resource %s", id)
+				return nil
+			}
+			meta.dataSources[k] = ds
+			meta.dataSourceNames[ds.tfType+"."+ds.tfName] = true
+			return ds.traversal()
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", cfg.TFAddr, err)
+		}
+		out[i] = cfg
+	}
+	return out, nil
+}
+
+// writeDataSources writes the recorded data sources.
+func (meta baseMeta) writeDataSources() error {
+	if len(meta.dataSources) == 0 {
+		return nil
+	}
+	var dss []dataSource
+	for _, ds := range meta.dataSources {
+		dss = append(dss, ds)
+	}
+	sort.Slice(dss, func(i, j int) bool {
+		if dss[i].tfType != dss[j].tfType {
+			return dss[i].tfType < dss[j].tfType
+		}
+		return dss[i].tfName < dss[j].tfName
+	})
+
+	f := hclwrite.NewEmptyFile()
+	for i, ds := range dss {
+		if i != 0 {
+			f.Body().AppendNewline()
+		}
+		b := f.Body().AppendNewBlock("data", []string{ds.tfType, ds.tfName}).Body()
+		if ds.providerAlias != "" {
+			b.SetAttributeTraversal("provider", providerAliasTraversal(ds.providerAlias))
+		}
+		for j, arg := range dataSourceArguments[ds.tfType] {
+			b.SetAttributeValue(arg, cty.StringVal(ds.args[j]))
+		}
+	}
+
+	path := filepath.Join(meta.moduleDir, DataSourcesFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, hclwrite.Format(f.Bytes()), 0644); err != nil {
+		return fmt.Errorf("writing the data sources to %s: %v", path, err)
+	}
+	return nil
+}
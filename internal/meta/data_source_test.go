@@ -0,0 +1,95 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSourceAddon(t *testing.T) {
+	meta := baseMeta{
+		moduleDir:            t.TempDir(),
+		providerName:         ProviderAzureRM,
+		subscriptionId:       testMainSub,
+		aliasSubscriptionIds: []string{testOtherSub},
+		generateDataSources:  true,
+		scopeIds: map[string]bool{
+			"/SUBSCRIPTIONS/" + testMainSub + "/RESOURCEGROUPS/RG1":                                                   true,
+			"/SUBSCRIPTIONS/" + testMainSub + "/RESOURCEGROUPS/RG1/PROVIDERS/MICROSOFT.NETWORK/VIRTUALNETWORKS/VNET1": true,
+		},
+		dataSources:     map[string]dataSource{},
+		dataSourceNames: map[string]bool{},
+	}
+
+	parse := func(input string) *hclwrite.File {
+		f, diags := hclwrite.ParseConfig([]byte(input), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return f
+	}
+	configs := ConfigInfos{
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_network_interface", Name: "res-0"}},
+			hcl: parse(`resource "azurerm_network_interface" "res-0" {
+  resource_group_name = "rg1"
+  ip_configuration {
+    subnet_id = "/subscriptions/` + testMainSub + `/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/default"
+  }
+  ip_configuration {
+    subnet_id = "/subscriptions/` + testMainSub + `/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet2/subnets/default"
+  }
+}
+`),
+		},
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_private_dns_zone_virtual_network_link", Name: "res-1"}},
+			hcl: parse(`resource "azurerm_private_dns_zone_virtual_network_link" "res-1" {
+  virtual_network_id = "/subscriptions/` + testOtherSub + `/resourceGroups/rg3/providers/Microsoft.Network/virtualNetworks/vnet2"
+  subnet_ids         = ["/subscriptions/` + testMainSub + `/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet2/subnets/default", "foo"]
+  unmanaged_id       = "/subscriptions/22222222-2222-2222-2222-222222222222/resourceGroups/rg4/providers/Microsoft.Network/virtualNetworks/vnet4"
+  unsupported_id     = "/subscriptions/` + testMainSub + `/resourceGroups/rg2/providers/Microsoft.Foo/foos/foo1"
+}
+`),
+		},
+	}
+
+	configs, err := meta.dataSourceAddon(configs)
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_network_interface" "res-0" {
+  resource_group_name = "rg1"
+  ip_configuration {
+    subnet_id = "/subscriptions/`+testMainSub+`/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/default"
+  }
+  ip_configuration {
+    subnet_id = data.azurerm_subnet.default.id
+  }
+}
+`, string(hclwrite.Format(configs[0].hcl.Bytes())))
+	require.Equal(t, `resource "azurerm_private_dns_zone_virtual_network_link" "res-1" {
+  virtual_network_id = data.azurerm_virtual_network.vnet2.id
+  subnet_ids         = [data.azurerm_subnet.default.id, "foo"]
+  unmanaged_id       = "/subscriptions/22222222-2222-2222-2222-222222222222/resourceGroups/rg4/providers/Microsoft.Network/virtualNetworks/vnet4"
+  unsupported_id     = "/subscriptions/`+testMainSub+`/resourceGroups/rg2/providers/Microsoft.Foo/foos/foo1"
+}
+`, string(hclwrite.Format(configs[1].hcl.Bytes())))
+
+	require.NoError(t, meta.writeDataSources())
+	b, err := os.ReadFile(filepath.Join(meta.moduleDir, DataSourcesFileName))
+	require.NoError(t, err)
+	require.Equal(t, `data "azurerm_subnet" "default" {
+  name                 = "default"
+  virtual_network_name = "vnet2"
+  resource_group_name  = "rg2"
+}
+
+data "azurerm_virtual_network" "vnet2" {
+  provider            = azurerm.sub_11111111_1111_1111_1111_111111111111
+  name                = "vnet2"
+  resource_group_name = "rg3"
+}
+`, string(b))
+}
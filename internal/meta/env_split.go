@@ -0,0 +1,221 @@
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var envSplitNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateEnvSplit validates the environment names of the env split.
+func ValidateEnvSplit(envs []string) error {
+	seen := map[string]bool{}
+	for _, env := range envs {
+		if !envSplitNameRegex.MatchString(env) || env == "modules" {
+			return fmt.Errorf("invalid environment name %q", env)
+		}
+		if seen[env] {
+			return fmt.Errorf("duplicated environment name %q", env)
+		}
+		seen[env] = true
+	}
+	return nil
+}
+
+const (
+	envSplitModuleName  = "main"
+	envSplitLocationVar = "location"
+)
+
+// writeEnvSplit generates a reusable module from the generated config, together with a root config for each environment that calls the module.
+// The layout is:
+//
+//	environments/
+//	├── modules/main/   (the generated resources, with the common location extracted to a variable)
+//	├── <env1>/         (imports the exported resources into the module)
+//	└── <env2>/ ...
+//
+// Only the first environment is seeded with the import blocks, as the exported resources belong to it. The other environments start with the same
+// variable values, which are meant to be edited before applying.
+func (meta *baseMeta) writeEnvSplit(l ImportList) error {
+	files, err := meta.envSplitFiles(l)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(meta.outdir, EnvSplitDirName, filepath.FromSlash(name))
+		// #nosec G301
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			return fmt.Errorf("creating directory %s: %v", filepath.Dir(path), err)
+		}
+		// #nosec G306
+		if err := os.WriteFile(path, files[name], 0644); err != nil {
+			return fmt.Errorf("writing %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// envSplitFiles builds the files of the env split layout, keyed by the slash separated path relative to the EnvSplitDirName.
+func (meta *baseMeta) envSplitFiles(l ImportList) (map[string][]byte, error) {
+	var srcs []tfFile
+	for _, name := range []string{meta.outputFileNames.MainFileName, KeyVaultSecretsFileName, DataSourcesFileName} {
+		path := filepath.Join(meta.moduleDir, name)
+		// #nosec G304
+		b, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("reading file %s: %v", path, err)
+		}
+		f, diags := hclwrite.ParseConfig(b, path, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing file %s: %v", path, diags.Error())
+		}
+		srcs = append(srcs, tfFile{path: name, file: f})
+	}
+
+	location, err := envSplitExtractLocation(srcs)
+	if err != nil {
+		return nil, err
+	}
+
+	locationVar := hclwrite.NewEmptyFile()
+	if location != "" {
+		locationVar.Body().AppendNewBlock("variable", []string{envSplitLocationVar}).Body().SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+	}
+
+	// The variables of the module (e.g. the extracted secrets) are also declared in the root configs, and passed through
+	vars := hclwrite.NewEmptyFile()
+	vars.Body().AppendUnstructuredTokens(locationVar.BuildTokens(nil))
+	var varNames []string
+	for _, src := range srcs {
+		for _, blk := range src.file.Body().Blocks() {
+			if blk.Type() != "variable" || len(blk.Labels()) != 1 {
+				continue
+			}
+			varNames = append(varNames, blk.Labels()[0])
+			vars.Body().AppendNewline()
+			vars.Body().AppendUnstructuredTokens(blk.BuildTokens(nil))
+		}
+	}
+
+	moduleDir := "modules/" + envSplitModuleName
+	files := map[string][]byte{
+		moduleDir + "/terraform.tf": hclwrite.Format([]byte(meta.buildTerraformConfigForImportDir())),
+	}
+	if location != "" {
+		files[moduleDir+"/variables.tf"] = hclwrite.Format(locationVar.Bytes())
+	}
+	for _, src := range srcs {
+		files[moduleDir+"/"+src.path] = hclwrite.Format(src.file.Bytes())
+	}
+
+	call := hclwrite.NewEmptyFile()
+	cb := call.Body().AppendNewBlock("module", []string{envSplitModuleName}).Body()
+	cb.SetAttributeValue("source", cty.StringVal("../"+moduleDir))
+	if location != "" {
+		cb.SetAttributeTraversal(envSplitLocationVar, hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: envSplitLocationVar}})
+	}
+	for _, name := range varNames {
+		cb.SetAttributeTraversal(name, hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: name}})
+	}
+
+	tfvars := hclwrite.NewEmptyFile()
+	if location != "" {
+		tfvars.Body().SetAttributeValue(envSplitLocationVar, cty.StringVal(location))
+	}
+
+	imports := hclwrite.NewEmptyFile()
+	for _, item := range l.Imported() {
+		blk := imports.Body().AppendNewBlock("import", nil).Body()
+		blk.SetAttributeValue("id", cty.StringVal(item.TFResourceId))
+		blk.SetAttributeTraversal("to", hcl.Traversal{
+			hcl.TraverseRoot{Name: "module"},
+			hcl.TraverseAttr{Name: envSplitModuleName},
+			hcl.TraverseAttr{Name: item.TFAddr.Type},
+			hcl.TraverseAttr{Name: item.TFAddr.Name},
+		})
+	}
+
+	for i, env := range meta.envSplit {
+		files[env+"/main.tf"] = hclwrite.Format(call.Bytes())
+		files[env+"/terraform.tf"] = hclwrite.Format([]byte(meta.buildTerraformConfig(meta.backendType)))
+		files[env+"/provider.tf"] = hclwrite.Format([]byte(meta.buildProviderConfig(meta.ProviderNames()...)))
+		if location != "" || len(varNames) != 0 {
+			files[env+"/variables.tf"] = hclwrite.Format(vars.Bytes())
+		}
+		if location != "" {
+			files[env+"/terraform.tfvars"] = hclwrite.Format(tfvars.Bytes())
+		}
+		if i == 0 && len(l.Imported()) != 0 {
+			files[env+"/"+meta.outputFileNames.ImportBlockFileName] = hclwrite.Format(imports.Bytes())
+		}
+	}
+	return files, nil
+}
+
+// envSplitExtractLocation replaces the "location" attributes of the resources that have the most common location with a reference to the location variable,
+// which is returned. It returns empty if there is no constant location.
+func envSplitExtractLocation(srcs []tfFile) (string, error) {
+	type locationAttr struct {
+		body  *hclwrite.Body
+		value string
+	}
+	counts := map[string]int{}
+	var attrs []locationAttr
+	for _, src := range srcs {
+		for _, blk := range src.file.Body().Blocks() {
+			if blk.Type() != "resource" {
+				continue
+			}
+			attr := blk.Body().GetAttribute("location")
+			if attr == nil {
+				continue
+			}
+			expr, diags := hclsyntax.ParseExpression(attr.Expr().BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+			if diags.HasErrors() {
+				return "", fmt.Errorf("parsing the location of %v: %s", blk.Labels(), diags.Error())
+			}
+			val, diags := expr.Value(nil)
+			if diags.HasErrors() || !val.IsKnown() || val.IsNull() || val.Type() != cty.String {
+				continue
+			}
+			counts[val.AsString()]++
+			attrs = append(attrs, locationAttr{body: blk.Body(), value: val.AsString()})
+		}
+	}
+
+	var location string
+	for loc, cnt := range counts {
+		if cnt > counts[location] || (cnt == counts[location] && loc < location) {
+			location = loc
+		}
+	}
+	if location == "" {
+		return "", nil
+	}
+
+	for _, attr := range attrs {
+		if attr.value != location {
+			continue
+		}
+		attr.body.SetAttributeTraversal("location", hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: envSplitLocationVar}})
+	}
+	return location, nil
+}
@@ -0,0 +1,111 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvSplitFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "azurerm_resource_group" "res-0" {
+  location = "westeurope"
+  name     = "rg1"
+}
+resource "azurerm_storage_account" "res-1" {
+  location            = "westeurope"
+  name                = "sa1"
+  resource_group_name = azurerm_resource_group.res-0.name
+}
+resource "azurerm_resource_group" "res-2" {
+  location = "eastus"
+  name     = "rg2"
+}
+variable "res-3_password" {
+  type      = string
+  sensitive = true
+}
+`), 0644))
+
+	meta := baseMeta{
+		outdir:          dir,
+		moduleDir:       dir,
+		outputFileNames: config.OutputFileNames{MainFileName: "main.tf", ImportBlockFileName: "import.tf"},
+		providerName:    ProviderAzureRM,
+		providerVersion: "3.80.0",
+		backendType:     "local",
+		envSplit:        []string{"dev", "prod"},
+	}
+	l := ImportList{
+		{TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}, TFResourceId: "/subscriptions/123/resourceGroups/rg1", Imported: true},
+		{TFAddr: tfaddr.TFAddr{Type: "azurerm_storage_account", Name: "res-1"}, TFResourceId: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1", Imported: true},
+		{TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-2"}},
+	}
+	files, err := meta.envSplitFiles(l)
+	require.NoError(t, err)
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	require.ElementsMatch(t, []string{
+		"modules/main/main.tf",
+		"modules/main/terraform.tf",
+		"modules/main/variables.tf",
+		"dev/main.tf",
+		"dev/terraform.tf",
+		"dev/provider.tf",
+		"dev/variables.tf",
+		"dev/terraform.tfvars",
+		"dev/import.tf",
+		"prod/main.tf",
+		"prod/terraform.tf",
+		"prod/provider.tf",
+		"prod/variables.tf",
+		"prod/terraform.tfvars",
+	}, names)
+
+	require.Contains(t, string(files["modules/main/main.tf"]), `resource "azurerm_storage_account" "res-1" {
+  location            = var.location`)
+	require.Contains(t, string(files["modules/main/main.tf"]), `location = "eastus"`)
+	require.Equal(t, `variable "location" {
+  type = string
+}
+`, string(files["modules/main/variables.tf"]))
+	require.Equal(t, `module "main" {
+  source         = "../modules/main"
+  location       = var.location
+  res-3_password = var.res-3_password
+}
+`, string(files["prod/main.tf"]))
+	require.Equal(t, `variable "location" {
+  type = string
+}
+
+variable "res-3_password" {
+  type      = string
+  sensitive = true
+}
+`, string(files["prod/variables.tf"]))
+	require.Equal(t, "location = \"westeurope\"\n", string(files["dev/terraform.tfvars"]))
+	require.Equal(t, `import {
+  id = "/subscriptions/123/resourceGroups/rg1"
+  to = module.main.azurerm_resource_group.res-0
+}
+import {
+  id = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1"
+  to = module.main.azurerm_storage_account.res-1
+}
+`, string(files["dev/import.tf"]))
+}
+
+func TestValidateEnvSplit(t *testing.T) {
+	require.NoError(t, ValidateEnvSplit([]string{"dev", "stage", "prod"}))
+	require.EqualError(t, ValidateEnvSplit([]string{"dev", "modules"}), `invalid environment name "modules"`)
+	require.EqualError(t, ValidateEnvSplit([]string{"dev", ""}), `invalid environment name ""`)
+	require.EqualError(t, ValidateEnvSplit([]string{"dev", "dev"}), `duplicated environment name "dev"`)
+}
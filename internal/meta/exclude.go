@@ -0,0 +1,94 @@
+package meta
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/log"
+)
+
+// excludePattern is an Azure resource id, or a glob pattern of the Azure resource ids, of the resources to exclude.
+type excludePattern struct {
+	pattern string
+	regexp  *regexp.Regexp
+}
+
+// newExcludePattern compiles the pattern, where "*" matches any sequence of characters (including "/"), and "?" matches any single character.
+// The pattern is matched against the whole Azure resource id, case insensitively.
+func newExcludePattern(pattern string) (excludePattern, error) {
+	var sb strings.Builder
+	sb.WriteString("(?i)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return excludePattern{}, err
+	}
+	return excludePattern{pattern: pattern, regexp: re}, nil
+}
+
+// loadExcludeFile loads the exclude file, which has an Azure resource id or a glob pattern (e.g. "*/providers/Microsoft.Insights/*") per line.
+// The empty lines and the lines starting with "#" are ignored.
+func loadExcludeFile(path string) ([]excludePattern, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the exclude file %s: %v", path, err)
+	}
+	var patterns []excludePattern
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, err := newExcludePattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q at line %d of the exclude file %s: %v", line, i, path, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanning the exclude file %s: %v", path, err)
+	}
+	return patterns, nil
+}
+
+// excludeResources drops the listed resources that match any of the exclude patterns.
+func (meta baseMeta) excludeResources(l ImportList) ImportList {
+	if len(meta.excludePatterns) == 0 {
+		return l
+	}
+	var out ImportList
+	for _, item := range l {
+		if pattern, ok := matchExcludePatterns(meta.excludePatterns, item.AzureResourceID.String()); ok {
+			log.Printf("[INFO] Excluding %s as it matches %q", item.AzureResourceID, pattern)
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// matchExcludePatterns returns the first pattern that matches the id, if any.
+func matchExcludePatterns(patterns []excludePattern, id string) (string, bool) {
+	for _, p := range patterns {
+		if p.regexp.MatchString(id) {
+			return p.pattern, true
+		}
+	}
+	return "", false
+}
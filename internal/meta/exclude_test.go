@@ -0,0 +1,50 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcludeResources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exclude.txt")
+	require.NoError(t, os.WriteFile(path, []byte(`# Diagnostics are managed elsewhere
+*/providers/Microsoft.Insights/*
+
+/subscriptions/123/resourceGroups/RG1/providers/Microsoft.Network/virtualNetworks/vnet1
+/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa?
+`), 0644))
+	patterns, err := loadExcludeFile(path)
+	require.NoError(t, err)
+	require.Len(t, patterns, 3)
+
+	var l ImportList
+	for _, id := range []string{
+		"/subscriptions/123/resourceGroups/rg1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Insights/components/app1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa10",
+	} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		l = append(l, ImportItem{AzureResourceID: azureId})
+	}
+
+	var actual []string
+	for _, item := range (baseMeta{excludePatterns: patterns}).excludeResources(l) {
+		actual = append(actual, item.AzureResourceID.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa10",
+	}, actual)
+
+	_, err = loadExcludeFile(filepath.Join(t.TempDir(), "not-exist.txt"))
+	require.ErrorContains(t, err, "reading the exclude file")
+}
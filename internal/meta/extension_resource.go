@@ -0,0 +1,148 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/magodo/armid"
+)
+
+// The extension resource types that can be exported along with the resources they are scoped to.
+const (
+	ExtensionResourceTypeRoleAssignment   = "Microsoft.Authorization/roleAssignments"
+	ExtensionResourceTypeLock             = "Microsoft.Authorization/locks"
+	ExtensionResourceTypePolicyAssignment = "Microsoft.Authorization/policyAssignments"
+)
+
+// extensionResourceAPIVersions are the API versions to list the extension resources.
+var extensionResourceAPIVersions = map[string]string{
+	ExtensionResourceTypeRoleAssignment:   "2022-04-01",
+	ExtensionResourceTypeLock:             lockAPIVersion,
+	ExtensionResourceTypePolicyAssignment: "2022-06-01",
+}
+
+// extensionResourceTypes returns the extension resource types to export along with the resources, as specified by the config.
+func extensionResourceTypes(cfg config.CommonConfig) []string {
+	var types []string
+	if cfg.IncludeRoleAssignments {
+		types = append(types, ExtensionResourceTypeRoleAssignment)
+	}
+	if cfg.IncludeLocks {
+		types = append(types, ExtensionResourceTypeLock)
+	}
+	if cfg.IncludePolicyAssignments {
+		types = append(types, ExtensionResourceTypePolicyAssignment)
+	}
+	return types
+}
+
+// populateExtensionResources adds the extension resources (e.g. role assignments) that are scoped to the resources of the resource set.
+// These are not listed by the Azure Resource Graph, so they are listed per subscription and matched by their scopes.
+func (meta baseMeta) populateExtensionResources(ctx context.Context, rset *resourceset.AzureResourceSet) error {
+	if len(meta.extensionResourceTypes) == 0 {
+		return nil
+	}
+	client, err := arm.NewClient("meta.Client", "v0.0.0", meta.azureSDKCred, &meta.azureSDKClientOpt)
+	if err != nil {
+		return fmt.Errorf("building the client: %v", err)
+	}
+
+	var ids []string
+	for _, rt := range meta.extensionResourceTypes {
+		for _, subscriptionId := range append([]string{meta.subscriptionId}, meta.aliasSubscriptionIds...) {
+			l, err := listSubscriptionExtensionResources(ctx, client, subscriptionId, rt)
+			if err != nil {
+				return fmt.Errorf("listing %s in subscription %s: %v", rt, subscriptionId, err)
+			}
+			ids = append(ids, l...)
+		}
+	}
+	rset.Resources = append(rset.Resources, scopedExtensionResources(rset.Resources, ids)...)
+	return nil
+}
+
+// scopedExtensionResources returns the extension resources of the ids that are scoped to any of the resources, and are not among them.
+func scopedExtensionResources(resources []resourceset.AzureResource, ids []string) []resourceset.AzureResource {
+	set := map[string]bool{}
+	for _, res := range resources {
+		set[strings.ToUpper(res.Id.String())] = true
+	}
+	var out []resourceset.AzureResource
+	for _, id := range ids {
+		uid := strings.ToUpper(id)
+		scope, _, ok := strings.Cut(uid, "/PROVIDERS/MICROSOFT.AUTHORIZATION/")
+		if !ok || !set[scope] || set[uid] {
+			continue
+		}
+		azureId, err := armid.ParseResourceId(id)
+		if err != nil {
+			log.Printf("[WARN] Failed to parse the extension resource id %q: %v", id, err)
+			continue
+		}
+		set[uid] = true
+		out = append(out, resourceset.AzureResource{Id: azureId})
+	}
+	return out
+}
+
+// appendNewResources appends the resources to the resource set, except the ones that are already in it.
+func appendNewResources(rset *resourceset.AzureResourceSet, resources []resourceset.AzureResource) {
+	set := map[string]bool{}
+	for _, res := range rset.Resources {
+		set[strings.ToUpper(res.Id.String())] = true
+	}
+	for _, res := range resources {
+		uid := strings.ToUpper(res.Id.String())
+		if set[uid] {
+			continue
+		}
+		set[uid] = true
+		rset.Resources = append(rset.Resources, res)
+	}
+}
+
+// listSubscriptionExtensionResources lists the ids of the extension resources of the type in the subscription, including the ones at the resource group and the resource levels.
+// The ones inherited from the upper scopes (e.g. management groups) might also be listed.
+func listSubscriptionExtensionResources(ctx context.Context, client *arm.Client, subscriptionId, rt string) ([]string, error) {
+	return listResourceIds(ctx, client, fmt.Sprintf("/subscriptions/%s/providers/%s", subscriptionId, rt), extensionResourceAPIVersions[rt])
+}
+
+// listResourceIds lists the ids of the resources in the collection of the path (e.g. "/subscriptions/xxx/providers/Microsoft.Authorization/locks"), following the next links.
+func listResourceIds(ctx context.Context, client *arm.Client, path, apiVersion string) ([]string, error) {
+	var ids []string
+	next := fmt.Sprintf("%s%s?api-version=%s", strings.TrimSuffix(client.Endpoint(), "/"), path, apiVersion)
+	for next != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, next)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Pipeline().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+		var page struct {
+			Value []struct {
+				Id string `json:"id"`
+			} `json:"value"`
+			NextLink string `json:"nextLink"`
+		}
+		if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+			return nil, err
+		}
+		for _, v := range page.Value {
+			ids = append(ids, v.Id)
+		}
+		next = page.NextLink
+	}
+	return ids, nil
+}
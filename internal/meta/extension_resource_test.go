@@ -0,0 +1,41 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedExtensionResources(t *testing.T) {
+	var resources []resourceset.AzureResource
+	for _, id := range []string{
+		"/subscriptions/123/resourceGroups/rg1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Authorization/locks/listed",
+	} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		resources = append(resources, resourceset.AzureResource{Id: azureId})
+	}
+	ids := []string{
+		// Scoped to the listed resources
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Authorization/roleAssignments/ra1",
+		"/subscriptions/123/resourceGroups/RG1/providers/Microsoft.Storage/storageAccounts/sa1/providers/Microsoft.Authorization/locks/lock1",
+		// Already listed
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Authorization/locks/listed",
+		// Scoped to the resources that are not listed
+		"/subscriptions/123/providers/Microsoft.Authorization/policyAssignments/pa1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/providers/Microsoft.Authorization/roleAssignments/ra2",
+		"/providers/Microsoft.Management/managementGroups/mg1/providers/Microsoft.Authorization/policyAssignments/pa2",
+	}
+	var actual []string
+	for _, res := range scopedExtensionResources(resources, ids) {
+		actual = append(actual, res.Id.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Authorization/roleAssignments/ra1",
+		"/subscriptions/123/resourceGroups/RG1/providers/Microsoft.Storage/storageAccounts/sa1/providers/Microsoft.Authorization/locks/lock1",
+	}, actual)
+}
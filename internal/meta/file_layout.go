@@ -0,0 +1,129 @@
+package meta
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/magodo/armid"
+)
+
+const (
+	// SplitFilesPerResource generates the config of each resource into a file of its own.
+	SplitFilesPerResource = "per-resource"
+	// SplitFilesPerType generates the config of the resources of each TF resource type into a file.
+	SplitFilesPerType = "per-type"
+	// SplitFilesPerResourceGroup generates the config of the resources of each resource group into a file.
+	SplitFilesPerResourceGroup = "per-rg"
+)
+
+// SplitFilesOptions are the supported ways to split the generated config into files.
+var SplitFilesOptions = []string{SplitFilesPerResource, SplitFilesPerType, SplitFilesPerResourceGroup}
+
+// builtinFileLayout returns the builtin file layout of the split files option, or nil if not to split.
+func builtinFileLayout(splitFiles string) config.FileLayout {
+	switch splitFiles {
+	case SplitFilesPerResource:
+		return config.FileLayoutFunc(func(info config.ResourceFileInfo) (string, error) {
+			return trimProviderPrefix(info.Type) + "." + info.Name + ".tf", nil
+		})
+	case SplitFilesPerType:
+		return config.FileLayoutFunc(func(info config.ResourceFileInfo) (string, error) {
+			return trimProviderPrefix(info.Type) + ".tf", nil
+		})
+	case SplitFilesPerResourceGroup:
+		return config.FileLayoutFunc(func(info config.ResourceFileInfo) (string, error) {
+			if info.ResourceGroup == "" {
+				return splitModuleOutOfResourceGroup + ".tf", nil
+			}
+			return strings.ToLower(info.ResourceGroup) + ".tf", nil
+		})
+	}
+	return nil
+}
+
+// trimProviderPrefix trims the provider prefix of the TF resource type, e.g. "azurerm_storage_account" -> "storage_account".
+func trimProviderPrefix(tfType string) string {
+	if _, after, ok := strings.Cut(tfType, "_"); ok {
+		return after
+	}
+	return tfType
+}
+
+// resourceFileInfo describes the resource of the item for the file layout.
+func resourceFileInfo(item ImportItem) config.ResourceFileInfo {
+	info := config.ResourceFileInfo{
+		Type: item.TFAddr.Type,
+		Name: item.TFAddr.Name,
+	}
+	if item.AzureResourceID != nil {
+		info.Id = item.AzureResourceID.String()
+		if rg, ok := item.AzureResourceID.RootScope().(*armid.ResourceGroup); ok {
+			info.ResourceGroup = rg.Name
+		}
+	}
+	return info
+}
+
+// layoutFileName returns the file that the config of the item is generated into by the file layout, which is validated to be a ".tf" file under the module directory
+// other than the other output files.
+func (meta baseMeta) layoutFileName(item ImportItem) (string, error) {
+	name, err := meta.fileLayout.FileName(resourceFileInfo(item))
+	if err != nil {
+		return "", fmt.Errorf("placing the config of %s: %v", item.TFAddr, err)
+	}
+	if name != filepath.Base(name) || !strings.HasSuffix(name, ".tf") || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid file %q of %s, which must be a .tf file name without any directory", name, item.TFAddr)
+	}
+	switch name {
+	case meta.outputFileNames.TerraformFileName, meta.outputFileNames.ProviderFileName, meta.outputFileNames.ImportBlockFileName,
+		KeyVaultSecretsFileName, DataSourcesFileName, VariablesFileName, AuthScaffoldFileName, MovedBlocksFileName:
+		return "", fmt.Errorf("the file %q of %s collides with the other output file", name, item.TFAddr)
+	}
+	return name, nil
+}
+
+// generateLayoutConfig generates the config of each resource into the file of the file layout, under the module directory.
+func (meta baseMeta) generateLayoutConfig(cfgs ConfigInfos) error {
+	blocks := map[string][][]byte{}
+	for _, cfg := range cfgs {
+		name, err := meta.layoutFileName(cfg.ImportItem)
+		if err != nil {
+			return err
+		}
+		buf := bytes.NewBuffer([]byte{})
+		if _, err := cfg.DumpHCL(buf); err != nil {
+			return err
+		}
+		name = meta.renderer.FileName(name)
+		blocks[name] = append(blocks[name], buf.Bytes())
+	}
+	var names []string
+	for name := range blocks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := renderToFile(meta.renderer, filepath.Join(meta.moduleDir, name), blocks[name]); err != nil {
+			return fmt.Errorf("generating configuration file %s: %w", name, err)
+		}
+		meta.layoutFiles[name] = true
+	}
+	return nil
+}
+
+// configFileNames returns the files under the output directory that the config of the resources is generated into.
+func (meta baseMeta) configFileNames() []string {
+	if meta.fileLayout == nil {
+		return []string{meta.renderer.FileName(meta.outputFileNames.MainFileName)}
+	}
+	var names []string
+	for name := range meta.layoutFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,111 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinFileLayout(t *testing.T) {
+	rg := config.ResourceFileInfo{Id: "/subscriptions/123/resourceGroups/My.RG", Type: "azurerm_resource_group", Name: "res-0", ResourceGroup: "My.RG"}
+	sa := config.ResourceFileInfo{Id: "/subscriptions/123/resourceGroups/My.RG/providers/Microsoft.Storage/storageAccounts/sa", Type: "azurerm_storage_account", Name: "res-1", ResourceGroup: "My.RG"}
+	ra := config.ResourceFileInfo{Id: "/subscriptions/123/providers/Microsoft.Authorization/roleAssignments/ra", Type: "azurerm_role_assignment", Name: "res-2"}
+
+	cases := []struct {
+		splitFiles string
+		expect     []string
+	}{
+		{
+			splitFiles: SplitFilesPerResource,
+			expect:     []string{"resource_group.res-0.tf", "storage_account.res-1.tf", "role_assignment.res-2.tf"},
+		},
+		{
+			splitFiles: SplitFilesPerType,
+			expect:     []string{"resource_group.tf", "storage_account.tf", "role_assignment.tf"},
+		},
+		{
+			splitFiles: SplitFilesPerResourceGroup,
+			expect:     []string{"my.rg.tf", "my.rg.tf", "subscription.tf"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.splitFiles, func(t *testing.T) {
+			layout := builtinFileLayout(tt.splitFiles)
+			var actual []string
+			for _, info := range []config.ResourceFileInfo{rg, sa, ra} {
+				name, err := layout.FileName(info)
+				require.NoError(t, err)
+				actual = append(actual, name)
+			}
+			require.Equal(t, tt.expect, actual)
+		})
+	}
+	require.Nil(t, builtinFileLayout(""))
+}
+
+func TestGenerateLayoutConfig(t *testing.T) {
+	parse := func(input string) *hclwrite.File {
+		f, diags := hclwrite.ParseConfig([]byte(input), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return f
+	}
+	cfg := func(id, tfType, name string) ConfigInfo {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return ConfigInfo{
+			ImportItem: ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}},
+			hcl:        parse(fmt.Sprintf("resource %q %q {\n}\n", tfType, name)),
+		}
+	}
+	configs := ConfigInfos{
+		cfg("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1", "azurerm_virtual_network", "res-0"),
+		cfg("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa", "azurerm_storage_account", "res-1"),
+		cfg("/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet2", "azurerm_virtual_network", "res-2"),
+	}
+
+	dir := t.TempDir()
+	meta := baseMeta{
+		outdir:          dir,
+		moduleDir:       dir,
+		outputFileNames: config.OutputFileNames{MainFileName: "main.tf", ProviderFileName: "provider.tf"},
+		fileLayout:      builtinFileLayout(SplitFilesPerType),
+		layoutFiles:     map[string]bool{},
+		renderer:        hclRenderer{},
+	}
+	require.NoError(t, meta.generateConfig(configs))
+	require.Equal(t, []string{"storage_account.tf", "virtual_network.tf"}, meta.configFileNames())
+
+	b, err := os.ReadFile(filepath.Join(dir, "virtual_network.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_virtual_network" "res-0" {
+}
+
+resource "azurerm_virtual_network" "res-2" {
+}
+
+`, string(b))
+	_, err = os.Stat(filepath.Join(dir, "main.tf"))
+	require.True(t, os.IsNotExist(err))
+
+	// The file layout of the library users is validated
+	for name, errMsg := range map[string]string{
+		"sub/main.tf":  "must be a .tf file name without any directory",
+		"main.json":    "must be a .tf file name without any directory",
+		"provider.tf":  "collides with the other output file",
+		".resource.tf": "must be a .tf file name without any directory",
+	} {
+		name := name
+		meta.fileLayout = config.FileLayoutFunc(func(info config.ResourceFileInfo) (string, error) {
+			return name, nil
+		})
+		require.ErrorContains(t, meta.generateConfig(configs), errMsg)
+	}
+}
@@ -0,0 +1,209 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/magodo/armid"
+)
+
+const (
+	GraphOutputDot     = "dot"
+	GraphOutputMermaid = "mermaid"
+)
+
+// GraphOutputs are the supported formats of the resource graph.
+var GraphOutputs = []string{GraphOutputDot, GraphOutputMermaid}
+
+// resourceGraph is the dependency graph of the exported resources, whose nodes are the TF resource addresses.
+type resourceGraph struct {
+	// nodes are sorted by the address
+	nodes []graphNode
+	// edges are sorted by the from and to addresses
+	edges []graphEdge
+}
+
+type graphNode struct {
+	addr string
+	// group is the resource group that the resource belongs to, or empty if it is not a resource group scoped resource
+	group string
+}
+
+// graphEdge is the edge from the resource to the one it depends on.
+type graphEdge struct {
+	from string
+	to   string
+	// dependsOn tells whether the dependency is a "depends_on", other than a reference
+	dependsOn bool
+}
+
+// writeGraph writes the dependency graph of the imported resources to the output directory, in the format of the GraphOutput.
+func (meta baseMeta) writeGraph(l ImportList) error {
+	g, err := buildResourceGraph(l.Imported(), meta.generatedHCL, meta.resourceAddr)
+	if err != nil {
+		return err
+	}
+	var content, fileName string
+	switch meta.graphOutput {
+	case GraphOutputDot:
+		content, fileName = g.dot(), GraphDotFileName
+	case GraphOutputMermaid:
+		content, fileName = g.mermaid(), GraphMermaidFileName
+	default:
+		return fmt.Errorf("unsupported graph output %q", meta.graphOutput)
+	}
+	path := filepath.Join(meta.outdir, fileName)
+	// #nosec G306
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("writing the graph to %s: %v", path, err)
+	}
+	return nil
+}
+
+// buildResourceGraph builds the dependency graph of the resources from their generated HCL (keyed by the uppercased Azure resource id), whose edges are the references
+// and the "depends_on" among the resources.
+func buildResourceGraph(l ImportList, hcls map[string][]byte, resourceAddr func(ImportItem) string) (*resourceGraph, error) {
+	// The references are always the local TF resource addresses (i.e. no module prefix), which are unique among the exported resources.
+	addrs := map[string]string{}
+	for _, item := range l {
+		addrs[item.TFAddr.String()] = resourceAddr(item)
+	}
+
+	var g resourceGraph
+	edges := map[graphEdge]bool{}
+	for _, item := range l {
+		addr := resourceAddr(item)
+		node := graphNode{addr: addr}
+		if rg, ok := item.AzureResourceID.RootScope().(*armid.ResourceGroup); ok {
+			node.group = rg.Name
+		}
+		g.nodes = append(g.nodes, node)
+
+		b, ok := hcls[strings.ToUpper(item.AzureResourceID.String())]
+		if !ok {
+			continue
+		}
+		file, diags := hclsyntax.ParseConfig(b, "main.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing hcl for %s: %v", item.AzureResourceID, diags.Error())
+		}
+		// #nosec G104
+		hclsyntax.VisitAll(file.Body.(*hclsyntax.Body), func(node hclsyntax.Node) hcl.Diagnostics {
+			attr, ok := node.(*hclsyntax.Attribute)
+			if !ok {
+				return nil
+			}
+			for _, traversal := range attr.Expr.Variables() {
+				if len(traversal) < 2 {
+					continue
+				}
+				tattr, ok := traversal[1].(hcl.TraverseAttr)
+				if !ok {
+					continue
+				}
+				if dep, ok := addrs[traversal.RootName()+"."+tattr.Name]; ok && dep != addr {
+					edges[graphEdge{from: addr, to: dep, dependsOn: attr.Name == "depends_on"}] = true
+				}
+			}
+			return nil
+		})
+	}
+
+	for edge := range edges {
+		// The reference takes precedence over the "depends_on" of the same resources.
+		if edge.dependsOn && edges[graphEdge{from: edge.from, to: edge.to}] {
+			continue
+		}
+		g.edges = append(g.edges, edge)
+	}
+	sort.Slice(g.nodes, func(i, j int) bool {
+		return g.nodes[i].addr < g.nodes[j].addr
+	})
+	sort.Slice(g.edges, func(i, j int) bool {
+		e1, e2 := g.edges[i], g.edges[j]
+		if e1.from != e2.from {
+			return e1.from < e2.from
+		}
+		return e1.to < e2.to
+	})
+	return &g, nil
+}
+
+// groups returns the sorted resource groups, and the nodes of each, the nodes that belong to no resource group are keyed by the empty string.
+func (g resourceGraph) groups() ([]string, map[string][]graphNode) {
+	var names []string
+	nodes := map[string][]graphNode{}
+	for _, node := range g.nodes {
+		if _, ok := nodes[node.group]; !ok && node.group != "" {
+			names = append(names, node.group)
+		}
+		nodes[node.group] = append(nodes[node.group], node)
+	}
+	sort.Strings(names)
+	return names, nodes
+}
+
+// dot renders the graph in the Graphviz DOT language, where the resources of each resource group are clustered, and the "depends_on" edges are dashed.
+func (g resourceGraph) dot() string {
+	var sb strings.Builder
+	sb.WriteString("digraph aztfexport {\n")
+	sb.WriteString("  rankdir = \"LR\";\n")
+	sb.WriteString("  node [shape = \"box\"];\n")
+	names, nodes := g.groups()
+	for _, node := range nodes[""] {
+		sb.WriteString(fmt.Sprintf("  %s;\n", strconv.Quote(node.addr)))
+	}
+	for i, name := range names {
+		sb.WriteString(fmt.Sprintf("  subgraph cluster_%d {\n", i))
+		sb.WriteString(fmt.Sprintf("    label = %s;\n", strconv.Quote(name)))
+		for _, node := range nodes[name] {
+			sb.WriteString(fmt.Sprintf("    %s;\n", strconv.Quote(node.addr)))
+		}
+		sb.WriteString("  }\n")
+	}
+	for _, edge := range g.edges {
+		attr := ""
+		if edge.dependsOn {
+			attr = " [style = \"dashed\"]"
+		}
+		sb.WriteString(fmt.Sprintf("  %s -> %s%s;\n", strconv.Quote(edge.from), strconv.Quote(edge.to), attr))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// mermaid renders the graph as a Mermaid flowchart, where the resources of each resource group are in a subgraph, and the "depends_on" edges are dotted.
+func (g resourceGraph) mermaid() string {
+	// The node ids are generated, as the addresses contain characters (e.g. ".") that are not allowed.
+	ids := map[string]string{}
+	for i, node := range g.nodes {
+		ids[node.addr] = fmt.Sprintf("n%d", i)
+	}
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	names, nodes := g.groups()
+	for _, node := range nodes[""] {
+		sb.WriteString(fmt.Sprintf("  %s[%q]\n", ids[node.addr], node.addr))
+	}
+	for i, name := range names {
+		sb.WriteString(fmt.Sprintf("  subgraph g%d[%q]\n", i, name))
+		for _, node := range nodes[name] {
+			sb.WriteString(fmt.Sprintf("    %s[%q]\n", ids[node.addr], node.addr))
+		}
+		sb.WriteString("  end\n")
+	}
+	for _, edge := range g.edges {
+		arrow := "-->"
+		if edge.dependsOn {
+			arrow = "-.->"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s %s\n", ids[edge.from], arrow, ids[edge.to]))
+	}
+	return sb.String()
+}
@@ -0,0 +1,106 @@
+package meta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceGraph(t *testing.T) {
+	const (
+		rgId     = "/subscriptions/123/resourceGroups/rg1"
+		vnetId   = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet"
+		subnetId = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet"
+		defId    = "/subscriptions/123/providers/Microsoft.Authorization/policyDefinitions/def"
+	)
+	item := func(id, tfType, name string) ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}, Imported: true}
+	}
+	l := ImportList{
+		item(rgId, "azurerm_resource_group", "res-0"),
+		item(vnetId, "azurerm_virtual_network", "res-1"),
+		item(subnetId, "azurerm_subnet", "res-2"),
+		item(defId, "azurerm_policy_definition", "res-3"),
+	}
+	hcls := map[string][]byte{
+		strings.ToUpper(rgId): []byte(`resource "azurerm_resource_group" "res-0" {
+  name = "rg1"
+}
+`),
+		strings.ToUpper(vnetId): []byte(`resource "azurerm_virtual_network" "res-1" {
+  resource_group_name = azurerm_resource_group.res-0.name
+  depends_on = [
+    azurerm_policy_definition.res-3,
+    azurerm_resource_group.res-0,
+  ]
+}
+`),
+		strings.ToUpper(subnetId): []byte(`resource "azurerm_subnet" "res-2" {
+  virtual_network_name = azurerm_virtual_network.res-1.name
+  delegation {
+    name = data.azurerm_client_config.current.tenant_id
+    service_delegation {
+      name = azurerm_resource_group.res-0.name
+    }
+  }
+}
+`),
+		strings.ToUpper(defId): []byte(`resource "azurerm_policy_definition" "res-3" {
+  name = var.name
+}
+`),
+	}
+	resourceAddr := func(item ImportItem) string {
+		return "module.foo." + item.TFAddr.String()
+	}
+
+	g, err := buildResourceGraph(l, hcls, resourceAddr)
+	require.NoError(t, err)
+	require.Equal(t, []graphNode{
+		{addr: "module.foo.azurerm_policy_definition.res-3"},
+		{addr: "module.foo.azurerm_resource_group.res-0", group: "rg1"},
+		{addr: "module.foo.azurerm_subnet.res-2", group: "rg1"},
+		{addr: "module.foo.azurerm_virtual_network.res-1", group: "rg1"},
+	}, g.nodes)
+	require.Equal(t, []graphEdge{
+		{from: "module.foo.azurerm_subnet.res-2", to: "module.foo.azurerm_resource_group.res-0"},
+		{from: "module.foo.azurerm_subnet.res-2", to: "module.foo.azurerm_virtual_network.res-1"},
+		{from: "module.foo.azurerm_virtual_network.res-1", to: "module.foo.azurerm_policy_definition.res-3", dependsOn: true},
+		{from: "module.foo.azurerm_virtual_network.res-1", to: "module.foo.azurerm_resource_group.res-0"},
+	}, g.edges)
+
+	require.Equal(t, `digraph aztfexport {
+  rankdir = "LR";
+  node [shape = "box"];
+  "module.foo.azurerm_policy_definition.res-3";
+  subgraph cluster_0 {
+    label = "rg1";
+    "module.foo.azurerm_resource_group.res-0";
+    "module.foo.azurerm_subnet.res-2";
+    "module.foo.azurerm_virtual_network.res-1";
+  }
+  "module.foo.azurerm_subnet.res-2" -> "module.foo.azurerm_resource_group.res-0";
+  "module.foo.azurerm_subnet.res-2" -> "module.foo.azurerm_virtual_network.res-1";
+  "module.foo.azurerm_virtual_network.res-1" -> "module.foo.azurerm_policy_definition.res-3" [style = "dashed"];
+  "module.foo.azurerm_virtual_network.res-1" -> "module.foo.azurerm_resource_group.res-0";
+}
+`, g.dot())
+
+	require.Equal(t, `flowchart LR
+  n0["module.foo.azurerm_policy_definition.res-3"]
+  subgraph g0["rg1"]
+    n1["module.foo.azurerm_resource_group.res-0"]
+    n2["module.foo.azurerm_subnet.res-2"]
+    n3["module.foo.azurerm_virtual_network.res-1"]
+  end
+  n2 --> n1
+  n2 --> n3
+  n3 -.-> n0
+  n3 --> n1
+`, g.mermaid())
+}
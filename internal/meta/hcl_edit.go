@@ -2,10 +2,14 @@ package meta
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // hclBlockAppendDependency adds the depends_on instructions in the given hcl body.
@@ -62,3 +66,81 @@ func hclBlockAppendLifecycle(body *hclwrite.Body, ignoreChanges []string) error
 	body.AppendBlock(b)
 	return nil
 }
+
+// hclBlockCopyAttribute copies the attribute, or the nested blocks, of the specified name from the source body to the destination body.
+func hclBlockCopyAttribute(dst, src *hclwrite.Body, name string) {
+	if attr := src.GetAttribute(name); attr != nil {
+		dst.SetAttributeRaw(name, attr.Expr().BuildTokens(nil))
+		return
+	}
+	for _, blk := range src.Blocks() {
+		if blk.Type() == name {
+			dst.AppendBlock(blk)
+		}
+	}
+}
+
+// hclBlockWalkConstAttributes calls fn on each attribute of the body (including the nested blocks) that has a constant value, in the order of the attribute names.
+// The path is the block path to the attribute, e.g. ["site_config", "0"]. The attributes that reference to others (e.g. depends_on) are ignored.
+func hclBlockWalkConstAttributes(body *hclwrite.Body, path []string, fn func(body *hclwrite.Body, path []string, name string, val cty.Value)) error {
+	attrs := body.Attributes()
+	var names []string
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		expr, diags := hclsyntax.ParseExpression(attrs[name].Expr().BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			return fmt.Errorf("parsing the expression of %s: %s", name, diags.Error())
+		}
+		val, diags := expr.Value(nil)
+		if diags.HasErrors() {
+			continue
+		}
+		fn(body, path, name, val)
+	}
+	counts := map[string]int{}
+	for _, blk := range body.Blocks() {
+		idx := counts[blk.Type()]
+		counts[blk.Type()]++
+		if err := hclBlockWalkConstAttributes(blk.Body(), append(path[:len(path):len(path)], blk.Type(), strconv.Itoa(idx)), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hclBlockRewriteStringValues replaces the constant string attribute values of the body (including the nested blocks), and the string elements of the constant list values,
+// with the traversals returned by fn. The values that fn returns nil for are kept as is.
+func hclBlockRewriteStringValues(body *hclwrite.Body, fn func(val string) hcl.Traversal) error {
+	return hclBlockWalkConstAttributes(body, nil, func(body *hclwrite.Body, _ []string, name string, val cty.Value) {
+		if !val.IsWhollyKnown() || val.IsNull() {
+			return
+		}
+		ty := val.Type()
+		switch {
+		case ty == cty.String:
+			if traversal := fn(val.AsString()); traversal != nil {
+				body.SetAttributeTraversal(name, traversal)
+			}
+		case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+			var elems []hclwrite.Tokens
+			var rewritten bool
+			for it := val.ElementIterator(); it.Next(); {
+				_, ev := it.Element()
+				if !ev.IsNull() && ev.Type() == cty.String {
+					if traversal := fn(ev.AsString()); traversal != nil {
+						elems = append(elems, hclwrite.TokensForTraversal(traversal))
+						rewritten = true
+						continue
+					}
+				}
+				elems = append(elems, hclwrite.TokensForValue(ev))
+			}
+			if rewritten {
+				body.SetAttributeRaw(name, hclwrite.TokensForTuple(elems))
+			}
+		}
+	})
+}
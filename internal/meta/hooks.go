@@ -0,0 +1,63 @@
+package meta
+
+import (
+	"github.com/Azure/aztfexport/pkg/config"
+)
+
+func hookResource(item ImportItem) config.HookResource {
+	res := config.HookResource{
+		TFResourceId: item.TFResourceId,
+		TFAddr:       item.TFAddr.String(),
+	}
+	if item.AzureResourceID != nil {
+		res.AzureResourceId = item.AzureResourceID.String()
+	}
+	return res
+}
+
+func (meta baseMeta) hookResourcesDiscovered(l ImportList) {
+	if meta.hooks.OnResourceDiscovered == nil {
+		return
+	}
+	for _, item := range l {
+		meta.hooks.OnResourceDiscovered(hookResource(item))
+	}
+}
+
+func (meta baseMeta) hookResolveStart() {
+	if meta.hooks.OnResolveStart != nil {
+		meta.hooks.OnResolveStart()
+	}
+}
+
+func (meta baseMeta) hookImportStart(item ImportItem) {
+	if meta.hooks.OnImportStart != nil {
+		meta.hooks.OnImportStart(hookResource(item))
+	}
+}
+
+func (meta baseMeta) hookImportDone(item ImportItem) {
+	if meta.hooks.OnImportDone != nil {
+		meta.hooks.OnImportDone(hookResource(item), item.ImportError)
+	}
+}
+
+func (meta baseMeta) hookConfigGenerated(l ImportList) {
+	if meta.hooks.OnConfigGenerated == nil {
+		return
+	}
+	for _, item := range l {
+		if item.Skip() || item.ImportError != nil {
+			continue
+		}
+		meta.hooks.OnConfigGenerated(hookResource(item))
+	}
+}
+
+// hookError invokes the OnError hook if the error is not nil, which is meant to be deferred by the methods of the BaseMeta (and the ListResource of the metas)
+// with the named error result.
+func (meta baseMeta) hookError(err *error) {
+	if *err != nil && meta.hooks.OnError != nil {
+		meta.hooks.OnError(*err)
+	}
+}
@@ -0,0 +1,68 @@
+package meta
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks(t *testing.T) {
+	var (
+		discovered []config.HookResource
+		generated  []config.HookResource
+		errs       []error
+	)
+	meta := baseMeta{
+		hooks: config.Hooks{
+			OnResourceDiscovered: func(res config.HookResource) {
+				discovered = append(discovered, res)
+			},
+			OnConfigGenerated: func(res config.HookResource) {
+				generated = append(generated, res)
+			},
+			OnError: func(err error) {
+				errs = append(errs, err)
+			},
+		},
+	}
+
+	var l ImportList
+	for _, id := range []string{
+		"/subscriptions/123/resourceGroups/rg1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+	} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		l = append(l, ImportItem{AzureResourceID: azureId, TFResourceId: id})
+	}
+	l[0].TFAddr = tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}
+	l[2].TFAddr = tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-2"}
+	l[2].ImportError = errors.New("import failed")
+
+	meta.hookResourcesDiscovered(l)
+	require.Equal(t, []config.HookResource{
+		{AzureResourceId: l[0].AzureResourceID.String(), TFResourceId: l[0].TFResourceId, TFAddr: "azurerm_resource_group.res-0"},
+		{AzureResourceId: l[1].AzureResourceID.String(), TFResourceId: l[1].TFResourceId},
+		{AzureResourceId: l[2].AzureResourceID.String(), TFResourceId: l[2].TFResourceId, TFAddr: "azurerm_virtual_network.res-2"},
+	}, discovered)
+
+	// Only the imported resources have config generated
+	meta.hookConfigGenerated(l)
+	require.Equal(t, discovered[:1], generated)
+
+	var err error
+	meta.hookError(&err)
+	require.Empty(t, errs)
+	err = errors.New("listing failed")
+	meta.hookError(&err)
+	require.Equal(t, []error{err}, errs)
+
+	// The nil hooks are ignored
+	baseMeta{}.hookResourcesDiscovered(l)
+	baseMeta{}.hookError(&err)
+}
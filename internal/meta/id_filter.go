@@ -0,0 +1,28 @@
+package meta
+
+import (
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/log"
+)
+
+// filterResourceIds drops the resources whose Azure resource ids are not among the ids (case insensitively), unless the ids are empty.
+func filterResourceIds(rl []resourceset.TFResource, ids []string) []resourceset.TFResource {
+	if len(ids) == 0 {
+		return rl
+	}
+	set := map[string]bool{}
+	for _, id := range ids {
+		set[strings.ToUpper(id)] = true
+	}
+	var out []resourceset.TFResource
+	for _, res := range rl {
+		if !set[strings.ToUpper(res.AzureId.String())] {
+			log.Printf("[DEBUG] Dropping %s as it is not among the only resource ids", res.AzureId)
+			continue
+		}
+		out = append(out, res)
+	}
+	return out
+}
@@ -0,0 +1,26 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterResourceIds(t *testing.T) {
+	var rl []resourceset.TFResource
+	for _, id := range []string{
+		"/subscriptions/123/resourceGroups/rg",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/default",
+	} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		rl = append(rl, resourceset.TFResource{AzureId: azureId})
+	}
+
+	require.Equal(t, rl, filterResourceIds(rl, nil))
+	require.Equal(t, []resourceset.TFResource{rl[2]}, filterResourceIds(rl, []string{"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/DEFAULT"}))
+	require.Empty(t, filterResourceIds(rl, []string{"/subscriptions/123/resourceGroups/rg2"}))
+}
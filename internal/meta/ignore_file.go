@@ -0,0 +1,147 @@
+package meta
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/log"
+)
+
+// ignoreRule is a gitignore-style pattern of the ignore file.
+type ignoreRule struct {
+	pattern string
+	// negate specifies the resources matched are not ignored (i.e. the pattern starts with "!")
+	negate bool
+	// byType specifies the pattern matches the TF resource type, otherwise it matches the Azure resource id
+	byType bool
+	regexp *regexp.Regexp
+}
+
+// newIgnoreRule compiles a line of the ignore file, in the manner of gitignore:
+//   - The pattern that contains a "/" matches the Azure resource id, together with its child resources.
+//     It matches from the start of the id if it starts with "/", otherwise it matches from any segment of the id.
+//   - The pattern that contains no "/" matches the TF resource type (e.g. "azurerm_monitor_diagnostic_setting").
+//   - "*" matches any sequence of characters except "/", "**" matches any sequence of characters, and "?" matches any single character except "/".
+//   - The pattern starting with "!" re-includes the resources ignored by the previous patterns.
+//   - A leading "\" escapes the leading "!" or "#".
+//
+// The patterns are matched case insensitively.
+func newIgnoreRule(line string) (ignoreRule, error) {
+	rule := ignoreRule{pattern: line}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if line == "" {
+		return ignoreRule{}, fmt.Errorf("empty pattern")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("(?i)^")
+	rule.byType = !strings.Contains(line, "/")
+	if !rule.byType {
+		if strings.HasPrefix(line, "/") {
+			line = line[1:]
+			sb.WriteString("/")
+		} else {
+			sb.WriteString("(?:.*/)?")
+		}
+		line = strings.TrimSuffix(line, "/")
+	}
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case strings.HasPrefix(line[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if !rule.byType {
+		// Ignoring a resource ignores its child resources, as ignoring a directory in gitignore
+		sb.WriteString("(?:/.*)?")
+	}
+	sb.WriteString("$")
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return ignoreRule{}, err
+	}
+	rule.regexp = re
+	return rule, nil
+}
+
+// loadIgnoreFile loads the ignore file, which has a gitignore-style pattern per line (see newIgnoreRule).
+// The empty lines and the lines starting with "#" are ignored.
+func loadIgnoreFile(path string) ([]ignoreRule, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the ignore file %s: %v", path, err)
+	}
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := newIgnoreRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q at line %d of the ignore file %s: %v", line, i, path, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanning the ignore file %s: %v", path, err)
+	}
+	return rules, nil
+}
+
+// matchIgnoreRules tells whether the resource of the Azure resource id and the TF resource type is ignored, together with the last pattern that matches it.
+// As gitignore, the last matching pattern wins.
+func matchIgnoreRules(rules []ignoreRule, id, tfType string) (string, bool) {
+	var (
+		pattern string
+		ignored bool
+	)
+	for _, rule := range rules {
+		target := id
+		if rule.byType {
+			target = tfType
+		}
+		if target == "" || !rule.regexp.MatchString(target) {
+			continue
+		}
+		pattern, ignored = rule.pattern, !rule.negate
+	}
+	return pattern, ignored
+}
+
+// ignoreResources drops the listed resources that are ignored by the ignore file.
+func (meta baseMeta) ignoreResources(l ImportList) ImportList {
+	if len(meta.ignoreRules) == 0 {
+		return l
+	}
+	var out ImportList
+	for _, item := range l {
+		if pattern, ok := matchIgnoreRules(meta.ignoreRules, item.AzureResourceID.String(), item.TFAddr.Type); ok {
+			log.Printf("[INFO] Ignoring %s as it matches %q", item.AzureResourceID, pattern)
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchIgnoreRules(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		id      string
+		tfType  string
+		match   bool
+	}{
+		{
+			name:    "anchored id",
+			pattern: "/subscriptions/123/resourceGroups/RG1",
+			id:      "/subscriptions/123/resourceGroups/rg1",
+			match:   true,
+		},
+		{
+			name:    "anchored id matches the child resources",
+			pattern: "/subscriptions/123/resourceGroups/rg1",
+			id:      "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			match:   true,
+		},
+		{
+			name:    "anchored id doesn't match the prefix of a segment",
+			pattern: "/subscriptions/123/resourceGroups/rg1",
+			id:      "/subscriptions/123/resourceGroups/rg10",
+			match:   false,
+		},
+		{
+			name:    "unanchored id matches from any segment",
+			pattern: "virtualNetworks/vnet1/",
+			id:      "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet1",
+			match:   true,
+		},
+		{
+			name:    "single star doesn't match across segments",
+			pattern: "/subscriptions/*/providers/Microsoft.Insights/*",
+			id:      "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Insights/components/app1",
+			match:   false,
+		},
+		{
+			name:    "double star matches across segments",
+			pattern: "/subscriptions/**/providers/Microsoft.Insights/*",
+			id:      "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Insights/components/app1",
+			match:   true,
+		},
+		{
+			name:    "question mark",
+			pattern: "storageAccounts/sa?",
+			id:      "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1",
+			match:   true,
+		},
+		{
+			name:    "TF type",
+			pattern: "azurerm_role_*",
+			id:      "/subscriptions/123/providers/Microsoft.Authorization/roleAssignments/ra1",
+			tfType:  "azurerm_role_assignment",
+			match:   true,
+		},
+		{
+			name:    "TF type doesn't match the id",
+			pattern: "rg1",
+			id:      "/subscriptions/123/resourceGroups/rg1",
+			tfType:  "azurerm_resource_group",
+			match:   false,
+		},
+		{
+			name:    "escaped",
+			pattern: `\#foo`,
+			tfType:  "#foo",
+			match:   true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := newIgnoreRule(tt.pattern)
+			require.NoError(t, err)
+			_, ok := matchIgnoreRules([]ignoreRule{rule}, tt.id, tt.tfType)
+			require.Equal(t, tt.match, ok)
+		})
+	}
+}
+
+func TestIgnoreResources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), IgnoreFileName)
+	require.NoError(t, os.WriteFile(path, []byte(`# Diagnostics are managed elsewhere
+azurerm_monitor_diagnostic_setting
+
+/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1
+!/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet1
+`), 0644))
+	rules, err := loadIgnoreFile(path)
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+
+	var l ImportList
+	for _, res := range []struct {
+		id     string
+		tfType string
+	}{
+		{"/subscriptions/123/resourceGroups/rg1", "azurerm_resource_group"},
+		{"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1", "azurerm_virtual_network"},
+		{"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet1", "azurerm_subnet"},
+		{"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet2", "azurerm_subnet"},
+		{"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/providers/Microsoft.Insights/diagnosticSettings/diag1", "azurerm_monitor_diagnostic_setting"},
+	} {
+		azureId, err := armid.ParseResourceId(res.id)
+		require.NoError(t, err)
+		l = append(l, ImportItem{AzureResourceID: azureId, TFAddr: tfaddr.TFAddr{Type: res.tfType, Name: "res"}})
+	}
+
+	var actual []string
+	for _, item := range (baseMeta{ignoreRules: rules}).ignoreResources(l) {
+		actual = append(actual, item.AzureResourceID.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet1",
+	}, actual)
+
+	_, err = loadIgnoreFile(filepath.Join(t.TempDir(), "not-exist"))
+	require.ErrorContains(t, err, "reading the ignore file")
+	require.NoError(t, os.WriteFile(path, []byte("!\n"), 0644))
+	_, err = loadIgnoreFile(path)
+	require.ErrorContains(t, err, "invalid pattern")
+}
@@ -0,0 +1,35 @@
+package meta
+
+import (
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/magodo/aztft/aztft"
+)
+
+// importId returns the TF import id of the import item.
+// The TF resource id falls back to the Azure resource id when the resource type is not resolved beforehand (e.g. specified by the user later on).
+// Whilst for quite some TF resource types, the import id is not the Azure resource id (e.g. composite ids, ids with extra segments).
+// In this case, the import id is computed for the TF resource type instead.
+// The import id specified by the user always takes precedence.
+func (meta baseMeta) importId(item ImportItem) string {
+	if item.ImportId != "" {
+		return item.ImportId
+	}
+	if item.TFAddr.Type == AzAPIResourceType {
+		return item.TFResourceId
+	}
+	if !strings.EqualFold(item.TFResourceId, item.AzureResourceID.String()) {
+		return item.TFResourceId
+	}
+	tfid, err := aztft.QueryId(item.AzureResourceID.String(), item.TFAddr.Type,
+		&aztft.APIOption{
+			Cred:         meta.azureSDKCred,
+			ClientOption: meta.azureSDKClientOpt,
+		})
+	if err != nil {
+		log.Printf("[WARN] Failed to query the TF id of %s as %s, fallback to use the Azure resource id: %v", item.AzureResourceID, item.TFAddr.Type, err)
+		return item.TFResourceId
+	}
+	return tfid
+}
@@ -0,0 +1,76 @@
+package meta
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportId(t *testing.T) {
+	subnetId := "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet"
+	nsgId := "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg"
+	associationId := subnetId + "/networkSecurityGroups/" + base64.StdEncoding.EncodeToString([]byte(nsgId))
+
+	cases := []struct {
+		name   string
+		item   func(id armid.ResourceId) ImportItem
+		expect string
+	}{
+		{
+			name: "resolved",
+			item: func(id armid.ResourceId) ImportItem {
+				return ImportItem{
+					AzureResourceID: id,
+					TFResourceId:    subnetId,
+					TFAddr:          tfaddr.TFAddr{Type: "azurerm_subnet_network_security_group_association", Name: "res-0"},
+				}
+			},
+			expect: subnetId,
+		},
+		{
+			name: "type specified later",
+			item: func(id armid.ResourceId) ImportItem {
+				return ImportItem{
+					AzureResourceID: id,
+					TFResourceId:    id.String(),
+					TFAddr:          tfaddr.TFAddr{Type: "azurerm_subnet_network_security_group_association", Name: "res-0"},
+				}
+			},
+			expect: subnetId,
+		},
+		{
+			name: "unknown type",
+			item: func(id armid.ResourceId) ImportItem {
+				return ImportItem{
+					AzureResourceID: id,
+					TFResourceId:    id.String(),
+					TFAddr:          tfaddr.TFAddr{Type: "azurerm_foo", Name: "res-0"},
+				}
+			},
+			expect: associationId,
+		},
+		{
+			name: "overridden",
+			item: func(id armid.ResourceId) ImportItem {
+				return ImportItem{
+					AzureResourceID: id,
+					TFResourceId:    subnetId,
+					ImportId:        nsgId,
+					TFAddr:          tfaddr.TFAddr{Type: "azurerm_subnet_network_security_group_association", Name: "res-0"},
+				}
+			},
+			expect: nsgId,
+		},
+	}
+
+	id, err := armid.ParseResourceId(associationId)
+	require.NoError(t, err)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expect, baseMeta{}.importId(c.item(id)))
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package meta
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resmap"
 	"github.com/Azure/aztfexport/internal/tfaddr"
 	"github.com/magodo/armid"
 	"github.com/zclconf/go-cty/cty"
@@ -13,6 +17,9 @@ type ImportItem struct {
 	// The TF resource id
 	TFResourceId string
 
+	// The TF import id specified by the user (e.g. via the mapping file), which overrides the one computed from the TF resource id. It is empty if not specified.
+	ImportId string
+
 	// Whether this azure resource failed to import into terraform (this might due to the TFResourceType doesn't match the resource)
 	ImportError error
 
@@ -33,14 +40,79 @@ type ImportItem struct {
 
 	Recommendations []string
 
+	// The confidences of the Recommendations, keyed by the TF resource type. It is nil if the recommendations are not ranked (e.g. there is only one).
+	Confidences map[string]float64
+
+	// The tags of this azure resource, as listed by Azure Resource Graph. It is nil if the resource is not listed by it (e.g. child resources, or in the resource mode).
+	Tags map[string]string
+
+	// The most restrictive level of the management locks (i.e. CanNotDelete, ReadOnly) that apply to this azure resource. It is empty if not locked, or not detected.
+	Lock string
+
+	// The soft-deleted instance (e.g. a Key Vault) that this azure resource, or its ancestor, collides with. It is empty if none, or not detected.
+	SoftDeleted string
+
 	// State is what is being imported&read by terraform-plugin-go client. It is nil when importing via terraform binary.
 	State cty.Value
+
+	// The private data of the State, which is written to the state file along with it.
+	private []byte
+
+	// The configs generated from the state in the import directory, which are only set when UseImportBlocks is set.
+	// The fullConfig is only set when the full config is needed but not asked (see needsFullConfig).
+	config     []byte
+	fullConfig []byte
 }
 
 func (item ImportItem) Skip() bool {
 	return item.TFAddr.Type == ""
 }
 
+// SkipReason returns why the item is skipped, which is empty if it is not skipped.
+func (item ImportItem) SkipReason() string {
+	switch {
+	case !item.Skip():
+		return ""
+	case item.Lock != "":
+		return fmt.Sprintf("under a %s lock", item.Lock)
+	case item.SoftDeleted != "":
+		return fmt.Sprintf("colliding with a %s", item.SoftDeleted)
+	case item.TFAddrCache.Type != "":
+		return fmt.Sprintf("pseudo resource of %s, which is only exported on opt-in", item.TFAddrCache.Type)
+	case len(item.Recommendations) != 0:
+		return fmt.Sprintf("no unique TF resource type, candidates: %s", item.FormatRecommendations(", "))
+	default:
+		return "no TF resource type"
+	}
+}
+
+// FormatRecommendations joins the recommendations by the separator, each followed by its confidence if ranked (e.g. "azurerm_linux_virtual_machine (91%)").
+func (item ImportItem) FormatRecommendations(sep string) string {
+	var out []string
+	for _, rt := range item.Recommendations {
+		if c, ok := item.Confidences[rt]; ok {
+			rt = fmt.Sprintf("%s (%.0f%%)", rt, c*100)
+		}
+		out = append(out, rt)
+	}
+	return strings.Join(out, sep)
+}
+
+// RankedRecommendations returns the recommendations along with their confidences, which is nil if the recommendations are not ranked.
+func (item ImportItem) RankedRecommendations() []resmap.ResourceMapCandidate {
+	if item.Confidences == nil {
+		return nil
+	}
+	var out []resmap.ResourceMapCandidate
+	for _, rt := range item.Recommendations {
+		out = append(out, resmap.ResourceMapCandidate{
+			ResourceType: rt,
+			Confidence:   item.Confidences[rt],
+		})
+	}
+	return out
+}
+
 type ImportList []ImportItem
 
 func (l ImportList) Skipped() ImportList {
@@ -53,6 +125,26 @@ func (l ImportList) Skipped() ImportList {
 	return out
 }
 
+func (l ImportList) Locked() ImportList {
+	var out ImportList
+	for _, item := range l {
+		if item.Lock != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func (l ImportList) SoftDeleted() ImportList {
+	var out ImportList
+	for _, item := range l {
+		if item.SoftDeleted != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
 func (l ImportList) NonSkipped() ImportList {
 	var out ImportList
 	for _, item := range l {
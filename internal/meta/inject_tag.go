@@ -0,0 +1,103 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/tfadd/providers/azurerm"
+	"github.com/magodo/workerpool"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// supportsTags tells whether the azurerm resource type has the "tags" attribute.
+// The azapi_resource is not regarded as supported, as it is not known whether the underlying Azure resource type supports tags.
+func supportsTags(resourceType string) bool {
+	sch, ok := azurerm.ProviderSchemaInfo.ResourceSchemas[resourceType]
+	if !ok || sch.Block == nil {
+		return false
+	}
+	_, ok = sch.Block.Attributes["tags"]
+	return ok
+}
+
+// injectTagAddon adds the injected tags to the tags of each resource that supports tags. The existing tags of the same keys are overwritten.
+func (meta baseMeta) injectTagAddon(configs ConfigInfos) (ConfigInfos, error) {
+	if len(meta.injectTags) == 0 {
+		return configs, nil
+	}
+	out := make(ConfigInfos, len(configs))
+	for i, cfg := range configs {
+		if supportsTags(cfg.TFAddr.Type) {
+			if err := hclBlockMergeTags(cfg.hcl.Body().Blocks()[0].Body(), meta.injectTags); err != nil {
+				return nil, fmt.Errorf("%s: %v", cfg.TFAddr, err)
+			}
+		}
+		out[i] = cfg
+	}
+	return out, nil
+}
+
+func hclBlockMergeTags(body *hclwrite.Body, tags map[string]string) error {
+	m := map[string]cty.Value{}
+	if attr := body.GetAttribute("tags"); attr != nil {
+		expr, diags := hclsyntax.ParseExpression(attr.Expr().BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			return fmt.Errorf("parsing the tags: %s", diags.Error())
+		}
+		val, diags := expr.Value(nil)
+		if diags.HasErrors() {
+			return fmt.Errorf("evaluating the tags: %s", diags.Error())
+		}
+		if !val.IsNull() && val.CanIterateElements() {
+			for k, v := range val.AsValueMap() {
+				m[k] = v
+			}
+		}
+	}
+	for k, v := range tags {
+		m[k] = cty.StringVal(v)
+	}
+	body.SetAttributeValue("tags", cty.ObjectVal(m))
+	return nil
+}
+
+// tagLiveResources merges the injected tags to the live Azure resources that are imported, so that the config matches them.
+func (meta baseMeta) tagLiveResources(ctx context.Context, l ImportList) error {
+	client, err := armresources.NewTagsClient(meta.subscriptionId, meta.azureSDKCred, &meta.azureSDKClientOpt)
+	if err != nil {
+		return fmt.Errorf("building the tags client: %v", err)
+	}
+	tags := map[string]*string{}
+	var keys []string
+	for k, v := range meta.injectTags {
+		tags[k] = ptr(v)
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	wp := workerpool.NewWorkPool(meta.parallelism)
+	wp.Run(nil)
+	for _, item := range l.Imported() {
+		item := item
+		if !supportsTags(item.TFAddr.Type) {
+			continue
+		}
+		wp.AddTask(func() (interface{}, error) {
+			log.Printf("[DEBUG] Applying the tags %v to %s", keys, item.AzureResourceID)
+			if _, err := client.UpdateAtScope(ctx, item.AzureResourceID.String(), armresources.TagsPatchResource{
+				Operation:  ptr(armresources.TagsPatchOperationMerge),
+				Properties: &armresources.Tags{Tags: tags},
+			}, nil); err != nil {
+				return nil, fmt.Errorf("applying the tags to %s: %v", item.AzureResourceID, err)
+			}
+			return nil, nil
+		})
+	}
+	return wp.Done()
+}
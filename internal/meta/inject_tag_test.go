@@ -0,0 +1,77 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectTagAddon(t *testing.T) {
+	parse := func(input string) *hclwrite.File {
+		f, diags := hclwrite.ParseConfig([]byte(input), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return f
+	}
+	configs := ConfigInfos{
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}},
+			hcl: parse(`resource "azurerm_resource_group" "res-0" {
+  location = "westus"
+  name     = "rg"
+}
+`),
+		},
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"}},
+			hcl: parse(`resource "azurerm_virtual_network" "res-1" {
+  name = "vnet"
+  tags = {
+    env        = "dev"
+    managed_by = "portal"
+  }
+}
+`),
+		},
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_subnet", Name: "res-2"}},
+			hcl: parse(`resource "azurerm_subnet" "res-2" {
+  name = "subnet"
+}
+`),
+		},
+	}
+
+	meta := baseMeta{injectTags: map[string]string{"managed_by": "terraform"}}
+	configs, err := meta.injectTagAddon(configs)
+	require.NoError(t, err)
+
+	var actual []string
+	for _, cfg := range configs {
+		actual = append(actual, string(hclwrite.Format(cfg.hcl.Bytes())))
+	}
+	require.Equal(t, []string{
+		`resource "azurerm_resource_group" "res-0" {
+  location = "westus"
+  name     = "rg"
+  tags = {
+    managed_by = "terraform"
+  }
+}
+`,
+		`resource "azurerm_virtual_network" "res-1" {
+  name = "vnet"
+  tags = {
+    env        = "dev"
+    managed_by = "terraform"
+  }
+}
+`,
+		`resource "azurerm_subnet" "res-2" {
+  name = "subnet"
+}
+`,
+	}, actual)
+}
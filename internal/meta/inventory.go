@@ -0,0 +1,63 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/magodo/armid"
+)
+
+// inventoryEntry describes an exported resource in the inventory. The inventory is meant to be diffed over time and ingested by CMDB tools,
+// therefore it only contains the stable facts of the resources (e.g. no timestamps), sorted by the resource id.
+type inventoryEntry struct {
+	Id             string            `json:"id"`
+	Type           string            `json:"type"`
+	SubscriptionId string            `json:"subscription_id,omitempty"`
+	ResourceGroup  string            `json:"resource_group,omitempty"`
+	TFType         string            `json:"tf_type"`
+	TFAddress      string            `json:"tf_address"`
+	Tags           map[string]string `json:"tags,omitempty"`
+}
+
+// writeInventory writes the inventory of the imported resources to the output directory.
+func (meta baseMeta) writeInventory(l ImportList) error {
+	b, err := json.MarshalIndent(inventory(l.Imported(), meta.resourceAddr), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the inventory: %v", err)
+	}
+	path := filepath.Join(meta.outdir, InventoryFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing the inventory to %s: %v", path, err)
+	}
+	return nil
+}
+
+func inventory(l ImportList, resourceAddr func(ImportItem) string) []inventoryEntry {
+	entries := []inventoryEntry{}
+	for _, item := range l {
+		entry := inventoryEntry{
+			Id:        item.AzureResourceID.String(),
+			Type:      item.AzureResourceID.TypeString(),
+			TFType:    item.TFAddr.Type,
+			TFAddress: resourceAddr(item),
+			Tags:      item.Tags,
+		}
+		switch scope := item.AzureResourceID.RootScope().(type) {
+		case *armid.SubscriptionId:
+			entry.SubscriptionId = scope.Id
+		case *armid.ResourceGroup:
+			entry.SubscriptionId = scope.SubscriptionId
+			entry.ResourceGroup = scope.Name
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToUpper(entries[i].Id) < strings.ToUpper(entries[j].Id)
+	})
+	return entries
+}
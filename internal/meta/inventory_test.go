@@ -0,0 +1,47 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventory(t *testing.T) {
+	mustParse := func(id string) armid.ResourceId {
+		rid, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return rid
+	}
+	l := ImportList{
+		{
+			AzureResourceID: mustParse("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+			Tags:            map[string]string{"env": "prod"},
+		},
+		{
+			AzureResourceID: mustParse("/subscriptions/123/resourceGroups/rg1"),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+		},
+	}
+	require.Equal(t, []inventoryEntry{
+		{
+			Id:             "/subscriptions/123/resourceGroups/rg1",
+			Type:           "Microsoft.Resources/resourceGroups",
+			SubscriptionId: "123",
+			ResourceGroup:  "rg1",
+			TFType:         "azurerm_resource_group",
+			TFAddress:      "module.foo.azurerm_resource_group.res-0",
+		},
+		{
+			Id:             "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet",
+			Type:           "Microsoft.Network/virtualNetworks",
+			SubscriptionId: "123",
+			ResourceGroup:  "rg1",
+			TFType:         "azurerm_virtual_network",
+			TFAddress:      "module.foo.azurerm_virtual_network.res-1",
+			Tags:           map[string]string{"env": "prod"},
+		},
+	}, inventory(l, baseMeta{moduleAddr: "module.foo"}.resourceAddr))
+}
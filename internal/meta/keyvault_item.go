@@ -0,0 +1,169 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/magodo/armid"
+	"github.com/magodo/workerpool"
+)
+
+// keyVaultItemCollections are the data plane collections of a Key Vault, whose items are exported as the azurerm resources of the pseudo resource ids
+// under the Key Vault (e.g. "<vault id>/keys/<name>").
+var keyVaultItemCollections = []string{"keys", "secrets", "certificates"}
+
+// keyVaultDataPlaneItem is an item listed from a data plane collection of a Key Vault.
+type keyVaultDataPlaneItem struct {
+	// The data plane id (e.g. "https://myvault.vault.azure.net/keys/mykey")
+	id string
+	// Whether the item is managed by a certificate, i.e. the backing key and secret of a certificate
+	managed bool
+}
+
+// keyVaultAccessPolicy is an access policy of a Key Vault.
+type keyVaultAccessPolicy struct {
+	objectId      string
+	applicationId string
+}
+
+// populateKeyVaultItems adds the keys, secrets (only the metadata are listed), certificates and access policies of the Key Vaults of the resource set.
+// The items are listed from the data plane of each Key Vault, with the same credential. The Key Vaults whose items can't be listed (e.g. lack of the data plane
+// permissions, or network restrictions) are skipped with a warning.
+func (meta baseMeta) populateKeyVaultItems(ctx context.Context, rset *resourceset.AzureResourceSet) error {
+	if !meta.includeKeyVaultItems {
+		return nil
+	}
+	var vaultIds []armid.ResourceId
+	for _, res := range rset.Resources {
+		if strings.EqualFold(res.Id.TypeString(), "Microsoft.KeyVault/vaults") {
+			vaultIds = append(vaultIds, res.Id)
+		}
+	}
+
+	var (
+		mu        sync.Mutex
+		resources []resourceset.AzureResource
+	)
+	wp := workerpool.NewWorkPool(meta.parallelism)
+	wp.Run(nil)
+	for _, vaultId := range vaultIds {
+		vaultId := vaultId
+		wp.AddTask(func() (interface{}, error) {
+			l, err := meta.listKeyVaultItems(ctx, vaultId)
+			if err != nil {
+				log.Printf("[WARN] Skipping the items of the Key Vault %s: %v", vaultId, err)
+				return nil, nil
+			}
+			mu.Lock()
+			resources = append(resources, l...)
+			mu.Unlock()
+			return nil, nil
+		})
+	}
+	if err := wp.Done(); err != nil {
+		return err
+	}
+
+	appendNewResources(rset, resources)
+	return nil
+}
+
+// listKeyVaultItems lists the items of the Key Vault, as the resources of their pseudo resource ids.
+func (meta baseMeta) listKeyVaultItems(ctx context.Context, vaultId armid.ResourceId) ([]resourceset.AzureResource, error) {
+	vaultURI, props, err := meta.keyVaultProperties(ctx, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	var policies []keyVaultAccessPolicy
+	if l, ok := props["accessPolicies"].([]interface{}); ok {
+		for _, v := range l {
+			policy, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			objectId, _ := policy["objectId"].(string)
+			applicationId, _ := policy["applicationId"].(string)
+			policies = append(policies, keyVaultAccessPolicy{objectId: objectId, applicationId: applicationId})
+		}
+	}
+
+	pl, err := meta.keyVaultPipeline(vaultURI)
+	if err != nil {
+		return nil, err
+	}
+	items := map[string][]keyVaultDataPlaneItem{}
+	for _, collection := range keyVaultItemCollections {
+		next := strings.TrimSuffix(vaultURI, "/") + "/" + collection + "?api-version=" + keyVaultDataPlaneAPIVersion
+		for next != "" {
+			var page struct {
+				Value []struct {
+					// The id of the secrets and certificates
+					Id string `json:"id"`
+					// The id of the keys
+					Kid     string `json:"kid"`
+					Managed bool   `json:"managed"`
+				} `json:"value"`
+				NextLink string `json:"nextLink"`
+			}
+			if err := keyVaultGet(ctx, pl, next, &page); err != nil {
+				return nil, fmt.Errorf("listing the %s: %v", collection, err)
+			}
+			for _, v := range page.Value {
+				id := v.Id
+				if id == "" {
+					id = v.Kid
+				}
+				items[collection] = append(items[collection], keyVaultDataPlaneItem{id: id, managed: v.Managed})
+			}
+			next = page.NextLink
+		}
+	}
+	return keyVaultItemResources(vaultId, items, policies), nil
+}
+
+// keyVaultItemResources returns the resources of the pseudo resource ids of the Key Vault items, which are resolved to the azurerm resources by aztft:
+//   - "<vault id>/keys/<name>": azurerm_key_vault_key
+//   - "<vault id>/secrets/<name>": azurerm_key_vault_secret
+//   - "<vault id>/certificates/<name>": azurerm_key_vault_certificate
+//   - "<vault id>/objectId/<object id>": azurerm_key_vault_access_policy
+//
+// The keys and secrets managed by the certificates are skipped, as well as the access policies of the compound identities (i.e. with the application id),
+// whose resource ids can't be represented.
+func keyVaultItemResources(vaultId armid.ResourceId, items map[string][]keyVaultDataPlaneItem, policies []keyVaultAccessPolicy) []resourceset.AzureResource {
+	var ids []string
+	for _, collection := range keyVaultItemCollections {
+		for _, item := range items[collection] {
+			if item.managed {
+				continue
+			}
+			name := strings.TrimSuffix(item.id, "/")
+			name = name[strings.LastIndex(name, "/")+1:]
+			ids = append(ids, vaultId.String()+"/"+collection+"/"+name)
+		}
+	}
+	for _, policy := range policies {
+		if policy.objectId == "" {
+			continue
+		}
+		if policy.applicationId != "" {
+			log.Printf("[DEBUG] Skipping the access policy of the compound identity (object id %s, application id %s) of %s", policy.objectId, policy.applicationId, vaultId)
+			continue
+		}
+		ids = append(ids, vaultId.String()+"/objectId/"+policy.objectId)
+	}
+
+	var out []resourceset.AzureResource
+	for _, id := range ids {
+		azureId, err := armid.ParseResourceId(id)
+		if err != nil {
+			log.Printf("[WARN] Failed to parse the Key Vault item id %q: %v", id, err)
+			continue
+		}
+		out = append(out, resourceset.AzureResource{Id: azureId})
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyVaultItemResources(t *testing.T) {
+	vaultId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1")
+	require.NoError(t, err)
+	items := map[string][]keyVaultDataPlaneItem{
+		"keys": {
+			{id: "https://kv1.vault.azure.net/keys/key1"},
+			// The backing key of the certificate
+			{id: "https://kv1.vault.azure.net/keys/cert1", managed: true},
+		},
+		"secrets": {
+			{id: "https://kv1.vault.azure.net/secrets/secret1"},
+			// The backing secret of the certificate
+			{id: "https://kv1.vault.azure.net/secrets/cert1", managed: true},
+		},
+		"certificates": {
+			{id: "https://kv1.vault.azure.net/certificates/cert1"},
+		},
+	}
+	policies := []keyVaultAccessPolicy{
+		{objectId: "00000000-0000-0000-0000-000000000001"},
+		// The compound identity
+		{objectId: "00000000-0000-0000-0000-000000000002", applicationId: "00000000-0000-0000-0000-000000000003"},
+	}
+	var actual []string
+	for _, res := range keyVaultItemResources(vaultId, items, policies) {
+		actual = append(actual, res.Id.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/keys/key1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/secrets/secret1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/certificates/cert1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/objectId/00000000-0000-0000-0000-000000000001",
+	}, actual)
+}
@@ -0,0 +1,229 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	keyVaultAPIVersion          = "2022-07-01"
+	keyVaultDataPlaneAPIVersion = "7.4"
+
+	// Secrets shorter than this are not substituted, to avoid replacing the unrelated common values (e.g. "true").
+	keyVaultSecretMinLength = 8
+)
+
+// keyVaultSecret is a secret stored in a Key Vault, which is referenced via an azurerm_key_vault_secret data source.
+type keyVaultSecret struct {
+	vaultId string
+	name    string
+	// The TF data source name
+	tfName string
+	value  string
+}
+
+var invalidTFNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// loadKeyVaultSecrets reads the (latest) value of the enabled secrets in the Key Vaults, keyed by the secret value.
+func (meta baseMeta) loadKeyVaultSecrets(ctx context.Context) (map[string]keyVaultSecret, error) {
+	secrets := map[string]keyVaultSecret{}
+	for _, vaultId := range meta.keyVaultIds {
+		id, err := armid.ParseResourceId(vaultId)
+		if err != nil {
+			return nil, fmt.Errorf("parsing the Key Vault id %s: %v", vaultId, err)
+		}
+		vaultName := id.Names()[len(id.Names())-1]
+
+		vaultURI, err := meta.keyVaultURI(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		pl, err := meta.keyVaultPipeline(vaultURI)
+		if err != nil {
+			return nil, err
+		}
+
+		var ids []string
+		next := strings.TrimSuffix(vaultURI, "/") + "/secrets?api-version=" + keyVaultDataPlaneAPIVersion
+		for next != "" {
+			var page struct {
+				Value []struct {
+					Id         string `json:"id"`
+					Managed    bool   `json:"managed"`
+					Attributes struct {
+						Enabled bool `json:"enabled"`
+					} `json:"attributes"`
+				} `json:"value"`
+				NextLink string `json:"nextLink"`
+			}
+			if err := keyVaultGet(ctx, pl, next, &page); err != nil {
+				return nil, fmt.Errorf("listing the secrets of %s: %v", vaultId, err)
+			}
+			for _, item := range page.Value {
+				// The managed secrets are the backing secrets of the certificates
+				if item.Managed || !item.Attributes.Enabled {
+					continue
+				}
+				ids = append(ids, item.Id)
+			}
+			next = page.NextLink
+		}
+
+		for _, secretId := range ids {
+			var secret struct {
+				Value string `json:"value"`
+			}
+			if err := keyVaultGet(ctx, pl, secretId+"?api-version="+keyVaultDataPlaneAPIVersion, &secret); err != nil {
+				return nil, fmt.Errorf("getting the secret %s: %v", secretId, err)
+			}
+			if len(secret.Value) < keyVaultSecretMinLength {
+				continue
+			}
+			name := secretId[strings.LastIndex(secretId, "/")+1:]
+			if _, ok := secrets[secret.Value]; ok {
+				log.Printf("[DEBUG] Secret %s has the same value as another secret, ignored", secretId)
+				continue
+			}
+			secrets[secret.Value] = keyVaultSecret{
+				vaultId: id.String(),
+				name:    name,
+				tfName:  invalidTFNameChars.ReplaceAllString(vaultName+"_"+name, "_"),
+				value:   secret.Value,
+			}
+		}
+	}
+	return secrets, nil
+}
+
+// keyVaultURI gets the data plane endpoint of the Key Vault, which differs between clouds.
+func (meta baseMeta) keyVaultURI(ctx context.Context, id armid.ResourceId) (string, error) {
+	uri, _, err := meta.keyVaultProperties(ctx, id)
+	return uri, err
+}
+
+// keyVaultProperties gets the data plane endpoint, along with the other properties of the Key Vault.
+func (meta baseMeta) keyVaultProperties(ctx context.Context, id armid.ResourceId) (string, map[string]interface{}, error) {
+	resp, err := meta.resourceClient.GetByID(ctx, id.String(), keyVaultAPIVersion, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("getting %s: %v", id, err)
+	}
+	props, ok := resp.Properties.(map[string]interface{})
+	if !ok {
+		return "", nil, fmt.Errorf("unexpected properties of %s", id)
+	}
+	uri, ok := props["vaultUri"].(string)
+	if !ok || uri == "" {
+		return "", nil, fmt.Errorf("no vault URI found for %s", id)
+	}
+	return uri, props, nil
+}
+
+func (meta baseMeta) keyVaultPipeline(vaultURI string) (runtime.Pipeline, error) {
+	u, err := url.Parse(vaultURI)
+	if err != nil {
+		return runtime.Pipeline{}, fmt.Errorf("parsing the vault URI %s: %v", vaultURI, err)
+	}
+	// E.g. myvault.vault.azure.net -> https://vault.azure.net/.default
+	_, suffix, _ := strings.Cut(u.Host, ".")
+	scope := "https://" + suffix + "/.default"
+	return runtime.NewPipeline("aztfexport", "", runtime.PipelineOptions{
+		PerRetry: []policy.Policy{runtime.NewBearerTokenPolicy(meta.azureSDKCred, []string{scope}, nil)},
+	}, &meta.azureSDKClientOpt.ClientOptions), nil
+}
+
+func keyVaultGet(ctx context.Context, pl runtime.Pipeline, endpoint string, v interface{}) error {
+	req, err := runtime.NewRequest(ctx, http.MethodGet, endpoint)
+	if err != nil {
+		return err
+	}
+	resp, err := pl.Do(req)
+	if err != nil {
+		return err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return runtime.NewResponseError(resp)
+	}
+	return runtime.UnmarshalAsJSON(resp, v)
+}
+
+// keyVaultRefAddon replaces the string attribute values that match a Key Vault secret with references to the azurerm_key_vault_secret data source of the secret.
+// The referenced secrets are recorded, whose data sources are written to the KeyVaultSecretsFileName afterwards.
+func (meta baseMeta) keyVaultRefAddon(configs ConfigInfos) (ConfigInfos, error) {
+	if len(meta.keyVaultSecrets) == 0 {
+		return configs, nil
+	}
+	out := make(ConfigInfos, len(configs))
+	for i, cfg := range configs {
+		// The exported secrets are the source of truth
+		if cfg.TFAddr.Type == "azurerm_key_vault_secret" {
+			out[i] = cfg
+			continue
+		}
+		err := hclBlockWalkConstAttributes(cfg.hcl.Body().Blocks()[0].Body(), nil, func(body *hclwrite.Body, _ []string, name string, val cty.Value) {
+			if !val.IsKnown() || val.IsNull() || val.Type() != cty.String {
+				return
+			}
+			secret, ok := meta.keyVaultSecrets[val.AsString()]
+			if !ok {
+				return
+			}
+			body.SetAttributeTraversal(name, hcl.Traversal{
+				hcl.TraverseRoot{Name: "data"},
+				hcl.TraverseAttr{Name: "azurerm_key_vault_secret"},
+				hcl.TraverseAttr{Name: secret.tfName},
+				hcl.TraverseAttr{Name: "value"},
+			})
+			meta.keyVaultSecretRefs[secret.tfName] = secret
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", cfg.TFAddr, err)
+		}
+		out[i] = cfg
+	}
+	return out, nil
+}
+
+// writeKeyVaultSecrets writes the data sources of the referenced Key Vault secrets.
+func (meta baseMeta) writeKeyVaultSecrets() error {
+	if len(meta.keyVaultSecretRefs) == 0 {
+		return nil
+	}
+	var names []string
+	for name := range meta.keyVaultSecretRefs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	f := hclwrite.NewEmptyFile()
+	for i, name := range names {
+		secret := meta.keyVaultSecretRefs[name]
+		if i != 0 {
+			f.Body().AppendNewline()
+		}
+		b := f.Body().AppendNewBlock("data", []string{"azurerm_key_vault_secret", name}).Body()
+		b.SetAttributeValue("name", cty.StringVal(secret.name))
+		b.SetAttributeValue("key_vault_id", cty.StringVal(secret.vaultId))
+	}
+
+	path := filepath.Join(meta.moduleDir, KeyVaultSecretsFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, hclwrite.Format(f.Bytes()), 0644); err != nil {
+		return fmt.Errorf("writing the Key Vault secret data sources to %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyVaultRefAddon(t *testing.T) {
+	vaultId := "/subscriptions/123/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv"
+	meta := baseMeta{
+		moduleDir: t.TempDir(),
+		keyVaultSecrets: map[string]keyVaultSecret{
+			"p@ssw0rd!": {vaultId: vaultId, name: "sql-password", tfName: "kv_sql-password", value: "p@ssw0rd!"},
+		},
+		keyVaultSecretRefs: map[string]keyVaultSecret{},
+	}
+
+	parse := func(input string) *hclwrite.File {
+		f, diags := hclwrite.ParseConfig([]byte(input), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return f
+	}
+	configs := ConfigInfos{
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_mssql_server", Name: "res-0"}},
+			hcl: parse(`resource "azurerm_mssql_server" "res-0" {
+  administrator_login          = "admin"
+  administrator_login_password = "p@ssw0rd!"
+}
+`),
+		},
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_key_vault_secret", Name: "res-1"}},
+			hcl: parse(`resource "azurerm_key_vault_secret" "res-1" {
+  name  = "sql-password"
+  value = "p@ssw0rd!"
+}
+`),
+		},
+	}
+
+	configs, err := meta.keyVaultRefAddon(configs)
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_mssql_server" "res-0" {
+  administrator_login          = "admin"
+  administrator_login_password = data.azurerm_key_vault_secret.kv_sql-password.value
+}
+`, string(hclwrite.Format(configs[0].hcl.Bytes())))
+	require.Equal(t, `resource "azurerm_key_vault_secret" "res-1" {
+  name  = "sql-password"
+  value = "p@ssw0rd!"
+}
+`, string(hclwrite.Format(configs[1].hcl.Bytes())))
+
+	require.NoError(t, meta.writeKeyVaultSecrets())
+	b, err := os.ReadFile(filepath.Join(meta.moduleDir, KeyVaultSecretsFileName))
+	require.NoError(t, err)
+	require.Equal(t, `data "azurerm_key_vault_secret" "kv_sql-password" {
+  name         = "sql-password"
+  key_vault_id = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv"
+}
+`, string(b))
+}
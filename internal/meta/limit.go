@@ -0,0 +1,36 @@
+package meta
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/log"
+)
+
+// limitResources caps the listed resources by the Limit, or randomly samples them by the Sample, so that a subset of a large scope can be piloted.
+func (meta baseMeta) limitResources(l ImportList) ImportList {
+	switch {
+	case meta.limit != 0 && len(l) > meta.limit:
+		log.Printf("[INFO] Limiting the %d listed resources to the first %d", len(l), meta.limit)
+		return l[:meta.limit]
+	case meta.sample != 0 && len(l) > meta.sample:
+		seed := time.Now().UnixNano()
+		log.Printf("[INFO] Sampling %d out of the %d listed resources (seed: %d)", meta.sample, len(l), seed)
+		// #nosec G404
+		return sampleResources(l, meta.sample, rand.New(rand.NewSource(seed)))
+	default:
+		return l
+	}
+}
+
+// sampleResources randomly picks n resources from the list, which keeps the original order.
+func sampleResources(l ImportList, n int, r *rand.Rand) ImportList {
+	indexes := r.Perm(len(l))[:n]
+	sort.Ints(indexes)
+	out := make(ImportList, 0, n)
+	for _, i := range indexes {
+		out = append(out, l[i])
+	}
+	return out
+}
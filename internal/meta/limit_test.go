@@ -0,0 +1,33 @@
+package meta
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitResources(t *testing.T) {
+	var l ImportList
+	for i := 0; i < 10; i++ {
+		l = append(l, ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: string(rune('a' + i))}})
+	}
+	names := func(l ImportList) []string {
+		var out []string
+		for _, item := range l {
+			out = append(out, item.TFAddr.Name)
+		}
+		return out
+	}
+
+	require.Equal(t, []string{"a", "b", "c"}, names(baseMeta{limit: 3}.limitResources(l)))
+	require.Len(t, baseMeta{limit: 20}.limitResources(l), 10)
+	require.Len(t, baseMeta{}.limitResources(l), 10)
+	require.Len(t, baseMeta{sample: 4}.limitResources(l), 4)
+
+	sampled := names(sampleResources(l, 5, rand.New(rand.NewSource(1))))
+	require.Len(t, sampled, 5)
+	require.IsIncreasing(t, sampled)
+	require.Equal(t, sampled, names(sampleResources(l, 5, rand.New(rand.NewSource(1)))))
+}
@@ -0,0 +1,136 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+const (
+	LockLevelCanNotDelete = "CanNotDelete"
+	LockLevelReadOnly     = "ReadOnly"
+)
+
+const (
+	// OnLockedWarn imports the locked resources as usual, with a warning.
+	OnLockedWarn = "warn"
+	// OnLockedSkip skips importing the locked resources.
+	OnLockedSkip = "skip"
+)
+
+// OnLockedPolicies are the supported policies for the resources that are under management locks.
+var OnLockedPolicies = []string{OnLockedWarn, OnLockedSkip}
+
+const lockAPIVersion = "2016-09-01"
+
+// resourceLock is a management lock, which applies to the resources at or under its scope.
+type resourceLock struct {
+	// The uppercased scope of the lock
+	scope string
+	level string
+}
+
+// listLocks lists all the management locks in the subscriptions, including the ones at the resource group and the resource levels.
+func (meta baseMeta) listLocks(ctx context.Context) ([]resourceLock, error) {
+	client, err := arm.NewClient("meta.Client", "v0.0.0", meta.azureSDKCred, &meta.azureSDKClientOpt)
+	if err != nil {
+		return nil, fmt.Errorf("building the client: %v", err)
+	}
+
+	var locks []resourceLock
+	for _, subscriptionId := range append([]string{meta.subscriptionId}, meta.aliasSubscriptionIds...) {
+		l, err := listSubscriptionLocks(ctx, client, subscriptionId)
+		if err != nil {
+			return nil, err
+		}
+		locks = append(locks, l...)
+	}
+	return locks, nil
+}
+
+func listSubscriptionLocks(ctx context.Context, client *arm.Client, subscriptionId string) ([]resourceLock, error) {
+	var locks []resourceLock
+	next := fmt.Sprintf("%s/subscriptions/%s/providers/Microsoft.Authorization/locks?api-version=%s", strings.TrimSuffix(client.Endpoint(), "/"), subscriptionId, lockAPIVersion)
+	for next != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, next)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Pipeline().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+		var page struct {
+			Value []struct {
+				Id         string `json:"id"`
+				Properties struct {
+					Level string `json:"level"`
+				} `json:"properties"`
+			} `json:"value"`
+			NextLink string `json:"nextLink"`
+		}
+		if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+			return nil, err
+		}
+		for _, v := range page.Value {
+			scope, _, ok := strings.Cut(strings.ToUpper(v.Id), "/PROVIDERS/MICROSOFT.AUTHORIZATION/LOCKS/")
+			if !ok {
+				continue
+			}
+			locks = append(locks, resourceLock{scope: scope, level: v.Properties.Level})
+		}
+		next = page.NextLink
+	}
+	return locks, nil
+}
+
+// lockLevel returns the most restrictive level of the locks that apply to the resource, or empty if not locked.
+func lockLevel(locks []resourceLock, id string) string {
+	id = strings.ToUpper(id)
+	var level string
+	for _, lock := range locks {
+		if id != lock.scope && !strings.HasPrefix(id, lock.scope+"/") {
+			continue
+		}
+		if lock.level == LockLevelReadOnly {
+			return LockLevelReadOnly
+		}
+		level = lock.level
+	}
+	return level
+}
+
+// applyLocks records the lock level of each resource in the list, and skips the locked resources if the OnLocked policy says so.
+// The skipped resources can still be opted in via the interactive list.
+func (meta baseMeta) applyLocks(ctx context.Context, l ImportList) (ImportList, error) {
+	if meta.onLocked == "" {
+		return l, nil
+	}
+	locks, err := meta.listLocks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing the management locks: %v", err)
+	}
+	for i, item := range l {
+		level := lockLevel(locks, item.AzureResourceID.String())
+		if level == "" {
+			continue
+		}
+		l[i].Lock = level
+		switch meta.onLocked {
+		case OnLockedWarn:
+			log.Printf("[WARN] %s is under a %s lock", item.AzureResourceID, level)
+		case OnLockedSkip:
+			log.Printf("[INFO] Skipping %s as it is under a %s lock", item.AzureResourceID, level)
+			l[i].TFAddr.Type = ""
+		}
+	}
+	return l, nil
+}
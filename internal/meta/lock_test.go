@@ -0,0 +1,28 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockLevel(t *testing.T) {
+	locks := []resourceLock{
+		{scope: "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG1", level: LockLevelCanNotDelete},
+		{scope: "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG1/PROVIDERS/MICROSOFT.STORAGE/STORAGEACCOUNTS/SA1", level: LockLevelReadOnly},
+	}
+	cases := []struct {
+		id     string
+		expect string
+	}{
+		{id: "/subscriptions/123/resourceGroups/rg1", expect: LockLevelCanNotDelete},
+		{id: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1", expect: LockLevelCanNotDelete},
+		{id: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1", expect: LockLevelReadOnly},
+		{id: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1/blobServices/default", expect: LockLevelReadOnly},
+		{id: "/subscriptions/123/resourceGroups/rg10", expect: ""},
+		{id: "/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Storage/storageAccounts/sa1", expect: ""},
+	}
+	for _, tt := range cases {
+		require.Equal(t, tt.expect, lockLevel(locks, tt.id), tt.id)
+	}
+}
@@ -0,0 +1,251 @@
+package meta
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/magodo/armid"
+	"github.com/magodo/aztft/aztft"
+)
+
+// The formats of the mapping file, which are detected by its content.
+const (
+	// mappingFormatJSON is the resource mapping file, i.e. a JSON object of resmap.ResourceMapping.
+	mappingFormatJSON = "json"
+	// mappingFormatARG is the JSON exported from the Azure Resource Graph, i.e. a JSON array of the resources (as downloaded from the Resource Graph explorer),
+	// or a JSON object with the resources in its "data" (as output by `az graph query`).
+	mappingFormatARG = "arg"
+	// mappingFormatCSV is the CSV of the "azure_id,tf_type,tf_name" columns, with an optional header.
+	mappingFormatCSV = "csv"
+)
+
+// detectMappingFormat detects the format of the mapping file content.
+func detectMappingFormat(b []byte) string {
+	b = bytes.TrimSpace(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf")))
+	switch {
+	case bytes.HasPrefix(b, []byte("[")):
+		return mappingFormatARG
+	case bytes.HasPrefix(b, []byte("{")):
+		var v struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if json.Unmarshal(b, &v) == nil && bytes.HasPrefix(bytes.TrimSpace(v.Data), []byte("[")) {
+			return mappingFormatARG
+		}
+		return mappingFormatJSON
+	default:
+		return mappingFormatCSV
+	}
+}
+
+// readResourceMappingFile reads the entries of the mapping file in any of the supported formats, along with the detected format.
+// The entries of the alternative formats (i.e. ARG and CSV) are to be completed, as their TF resource types, names and ids are optional (see completeResourceMappingEntries).
+func readResourceMappingFile(path string) (string, []resourceMappingEntry, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading mapping file %s: %v", path, err)
+	}
+	format := detectMappingFormat(b)
+	var entries []resourceMappingEntry
+	switch format {
+	case mappingFormatARG:
+		entries, err = decodeARGMappingEntries(b)
+	case mappingFormatCSV:
+		entries, err = decodeCSVMappingEntries(b)
+	default:
+		entries, err = decodeResourceMappingEntries(b)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("unmarshalling the mapping file (%s): %v", format, err)
+	}
+	return format, entries, nil
+}
+
+// completeResourceMappingEntries resolves the absent TF resource types of the entries by the type overrides, or aztft otherwise, and generates the absent TF resource names.
+// The TF resource ids are the Azure resource ids, from which the import ids are computed for the TF resource types on importing.
+// The entries whose TF resource types can't be resolved are left as is, which are reported by the validation.
+func (meta baseMeta) completeResourceMappingEntries(entries []resourceMappingEntry) {
+	names := map[string]bool{}
+	for _, entry := range entries {
+		names[entry.res.ResourceName] = true
+	}
+	idx := 0
+	for i := range entries {
+		res := &entries[i].res
+		if res.ResourceId == "" {
+			res.ResourceId = entries[i].id
+		}
+		if res.ResourceType == "" {
+			res.ResourceType = meta.resolveMappingResourceType(entries[i].id)
+		}
+		if res.ResourceName == "" {
+			for names[fmt.Sprintf("res-%d", idx)] {
+				idx++
+			}
+			res.ResourceName = fmt.Sprintf("res-%d", idx)
+			names[res.ResourceName] = true
+		}
+	}
+}
+
+// resolveMappingResourceType resolves the TF resource type of the Azure resource id, which is empty if it can't be resolved.
+func (meta baseMeta) resolveMappingResourceType(id string) string {
+	if meta.providerName == ProviderAzAPI {
+		return AzAPIResourceType
+	}
+	azureId, err := armid.ParseResourceId(id)
+	if err != nil {
+		return ""
+	}
+	if rt, ok := meta.typeOverrides.Match(azureId); ok {
+		return rt
+	}
+	types, _, err := aztft.QueryType(id, nil)
+	if err != nil || len(types) == 0 {
+		if meta.azapiFallback {
+			return AzAPIResourceType
+		}
+		log.Printf("[WARN] No TF resource type is resolved for %s in the mapping file: %v", id, err)
+		return ""
+	}
+	if len(types) > 1 {
+		log.Printf("[WARN] More than one TF resource types are resolved for %s in the mapping file, %s is used", id, types[0].TFType)
+	}
+	return types[0].TFType
+}
+
+// decodeARGMappingEntries decodes the resources exported from the Azure Resource Graph in order, along with their line numbers.
+// Each resource has the "id", and optionally the "tf_type" and "tf_name" (e.g. projected by the query), while the other properties are ignored.
+func decodeARGMappingEntries(b []byte) ([]resourceMappingEntry, error) {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	dec := json.NewDecoder(bytes.NewReader(b))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	// The resources are in the "data" of the `az graph query` output.
+	if delim, ok := tok.(json.Delim); ok && delim == '{' {
+		for {
+			if !dec.More() {
+				return nil, fmt.Errorf(`expect the resources in "data"`)
+			}
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if key == "data" {
+				break
+			}
+			var v json.RawMessage
+			if err := dec.Decode(&v); err != nil {
+				return nil, err
+			}
+		}
+		if tok, err = dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("expect a JSON array")
+	}
+	var entries []resourceMappingEntry
+	for dec.More() {
+		// The offset is at the end of the former element, the line is of the next one.
+		offset := int(dec.InputOffset())
+		for offset < len(b) && strings.ContainsRune(" \t\r\n,", rune(b[offset])) {
+			offset++
+		}
+		line := bytes.Count(b[:offset], []byte("\n")) + 1
+		var row struct {
+			Id     string `json:"id"`
+			TFType string `json:"tf_type"`
+			TFName string `json:"tf_name"`
+		}
+		if err := dec.Decode(&row); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if row.Id == "" {
+			return nil, fmt.Errorf("line %d: missing id", line)
+		}
+		entries = append(entries, resourceMappingEntry{
+			line: line,
+			id:   row.Id,
+			res:  resmap.ResourceMapEntity{ResourceType: row.TFType, ResourceName: row.TFName},
+		})
+	}
+	return entries, nil
+}
+
+// decodeCSVMappingEntries decodes the rows of the CSV in order, along with their line numbers.
+// The columns are "azure_id,tf_type,tf_name" in order, unless there is a header, which names the columns in any order (the "azure_id" can also be named as "id",
+// e.g. the CSV downloaded from the Resource Graph explorer). The other columns are ignored.
+func decodeCSVMappingEntries(b []byte) ([]resourceMappingEntry, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	columns := map[string]int{"azure_id": 0, "tf_type": 1, "tf_name": 2}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var entries []resourceMappingEntry
+	first := true
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		if first {
+			first = false
+			// The header is the first row whose first column is not a resource id.
+			if !strings.HasPrefix(strings.TrimSpace(record[0]), "/") {
+				columns = map[string]int{}
+				for i, name := range record {
+					name = strings.ToLower(strings.TrimSpace(name))
+					if name == "id" {
+						name = "azure_id"
+					}
+					if _, ok := columns[name]; !ok {
+						columns[name] = i
+					}
+				}
+				if _, ok := columns["azure_id"]; !ok {
+					return nil, fmt.Errorf("line %d: the header has no azure_id (or id) column", line)
+				}
+				continue
+			}
+		}
+		id := field(record, "azure_id")
+		if id == "" {
+			// Skip the empty lines of the spreadsheets, e.g. ",,"
+			if strings.TrimSpace(strings.Join(record, "")) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: missing azure_id", line)
+		}
+		entries = append(entries, resourceMappingEntry{
+			line: line,
+			id:   id,
+			res:  resmap.ResourceMapEntity{ResourceType: field(record, "tf_type"), ResourceName: field(record, "tf_name")},
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,95 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectMappingFormat(t *testing.T) {
+	require.Equal(t, mappingFormatJSON, detectMappingFormat([]byte(`{"/subscriptions/123/resourceGroups/rg": {}}`)))
+	require.Equal(t, mappingFormatJSON, detectMappingFormat([]byte(`{}`)))
+	require.Equal(t, mappingFormatARG, detectMappingFormat([]byte("\xef\xbb\xbf  [{\"id\": \"/subscriptions/123/resourceGroups/rg\"}]")))
+	require.Equal(t, mappingFormatARG, detectMappingFormat([]byte(`{"count": 1, "data": [{"id": "/subscriptions/123/resourceGroups/rg"}], "skip_token": null}`)))
+	require.Equal(t, mappingFormatCSV, detectMappingFormat([]byte("azure_id,tf_type,tf_name\n")))
+	require.Equal(t, mappingFormatCSV, detectMappingFormat([]byte("/subscriptions/123/resourceGroups/rg,azurerm_resource_group,rg\n")))
+}
+
+func TestDecodeARGMappingEntries(t *testing.T) {
+	entries, err := decodeARGMappingEntries([]byte(`{
+  "count": 2,
+  "data": [
+    {
+      "id": "/subscriptions/123/resourceGroups/rg",
+      "name": "rg"
+    },
+    {"id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", "tf_type": "azurerm_virtual_network", "tf_name": "vnet"}
+  ],
+  "skip_token": null
+}`))
+	require.NoError(t, err)
+	require.Equal(t, []resourceMappingEntry{
+		{line: 4, id: "/subscriptions/123/resourceGroups/rg"},
+		{line: 8, id: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", res: resmap.ResourceMapEntity{ResourceType: "azurerm_virtual_network", ResourceName: "vnet"}},
+	}, entries)
+
+	entries, err = decodeARGMappingEntries([]byte(`[{"id": "/subscriptions/123/resourceGroups/rg"}]`))
+	require.NoError(t, err)
+	require.Equal(t, []resourceMappingEntry{{line: 1, id: "/subscriptions/123/resourceGroups/rg"}}, entries)
+
+	_, err = decodeARGMappingEntries([]byte("[\n{\"name\": \"rg\"}\n]"))
+	require.EqualError(t, err, "line 2: missing id")
+}
+
+func TestDecodeCSVMappingEntries(t *testing.T) {
+	// Without header
+	entries, err := decodeCSVMappingEntries([]byte(`/subscriptions/123/resourceGroups/rg,azurerm_resource_group,rg
+/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet, azurerm_virtual_network
+,,
+`))
+	require.NoError(t, err)
+	require.Equal(t, []resourceMappingEntry{
+		{line: 1, id: "/subscriptions/123/resourceGroups/rg", res: resmap.ResourceMapEntity{ResourceType: "azurerm_resource_group", ResourceName: "rg"}},
+		{line: 2, id: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", res: resmap.ResourceMapEntity{ResourceType: "azurerm_virtual_network"}},
+	}, entries)
+
+	// With the header of the CSV downloaded from the Resource Graph explorer
+	entries, err = decodeCSVMappingEntries([]byte("\xef\xbb\xbf\"NAME\",\"TYPE\",\"ID\"\n\"rg\",\"microsoft.resources/resourcegroups\",\"/subscriptions/123/resourceGroups/rg\"\n"))
+	require.NoError(t, err)
+	require.Equal(t, []resourceMappingEntry{{line: 2, id: "/subscriptions/123/resourceGroups/rg"}}, entries)
+
+	_, err = decodeCSVMappingEntries([]byte("name,tf_type\nrg,azurerm_resource_group\n"))
+	require.EqualError(t, err, "line 1: the header has no azure_id (or id) column")
+
+	_, err = decodeCSVMappingEntries([]byte("azure_id,tf_type\n,azurerm_resource_group\n"))
+	require.EqualError(t, err, "line 2: missing azure_id")
+}
+
+func TestReadResourceMappingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.csv")
+	require.NoError(t, os.WriteFile(path, []byte(`azure_id,tf_type,tf_name
+/subscriptions/123/resourceGroups/rg,,
+/subscriptions/123/resourceGroups/rg2,azurerm_resource_group,res-0
+/subscriptions/123/foo,,
+`), 0600))
+
+	format, entries, err := readResourceMappingFile(path)
+	require.NoError(t, err)
+	require.Equal(t, mappingFormatCSV, format)
+	meta := baseMeta{providerName: ProviderAzureRM}
+	meta.completeResourceMappingEntries(entries)
+	require.Equal(t, []resourceMappingEntry{
+		{line: 2, id: "/subscriptions/123/resourceGroups/rg", res: resmap.ResourceMapEntity{ResourceId: "/subscriptions/123/resourceGroups/rg", ResourceType: "azurerm_resource_group", ResourceName: "res-1"}},
+		{line: 3, id: "/subscriptions/123/resourceGroups/rg2", res: resmap.ResourceMapEntity{ResourceId: "/subscriptions/123/resourceGroups/rg2", ResourceType: "azurerm_resource_group", ResourceName: "res-0"}},
+		{line: 4, id: "/subscriptions/123/foo", res: resmap.ResourceMapEntity{ResourceId: "/subscriptions/123/foo", ResourceName: "res-2"}},
+	}, entries)
+
+	// The entries whose TF resource types can't be resolved are reported by the validation, with the line numbers of the CSV.
+	err = meta.validateResourceMapping(path, entries)
+	var verr *MappingValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Contains(t, verr.Problems, MappingProblem{Line: 4, Id: "/subscriptions/123/foo", Message: "missing resource_type"})
+}
@@ -0,0 +1,157 @@
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/magodo/armid"
+	"github.com/magodo/aztft/aztft"
+	"github.com/magodo/tfadd/providers/azurerm"
+)
+
+// MappingProblem is a problem of an entry of the mapping file.
+type MappingProblem struct {
+	// Line is the line number of the entry (i.e. of its key) in the mapping file
+	Line int
+	// Id is the Azure resource id of the entry
+	Id      string
+	Message string
+}
+
+func (p MappingProblem) String() string {
+	return fmt.Sprintf("line %d: %s: %s", p.Line, p.Id, p.Message)
+}
+
+// MappingValidationError reports all the problems of the mapping file.
+type MappingValidationError struct {
+	Path     string
+	Problems []MappingProblem
+}
+
+func (e *MappingValidationError) Error() string {
+	var lines []string
+	for _, p := range e.Problems {
+		lines = append(lines, "  "+p.String())
+	}
+	return fmt.Sprintf("invalid mapping file %s (%d problem(s)):\n%s", e.Path, len(e.Problems), strings.Join(lines, "\n"))
+}
+
+// validateResourceMapping validates every entry of the mapping file eagerly, before any import starts, and reports all the problems at once (see MappingValidationError):
+// the Azure resource id parses, the TF resource type is supported by the provider, the Azure resource id matches the TF resource type (as identified by aztft, or
+// the type overrides), and the TF resource address is valid and unique.
+func (meta baseMeta) validateResourceMapping(path string, entries []resourceMappingEntry) error {
+	var problems []MappingProblem
+	addrs := map[string]int{}
+	for _, entry := range entries {
+		for _, msg := range meta.validateResourceMappingEntry(entry.id, entry.res) {
+			problems = append(problems, MappingProblem{Line: entry.line, Id: entry.id, Message: msg})
+		}
+		if entry.res.ResourceType == "" || entry.res.ResourceName == "" {
+			continue
+		}
+		addr := entry.res.ResourceType + "." + entry.res.ResourceName
+		if line, ok := addrs[addr]; ok {
+			problems = append(problems, MappingProblem{Line: entry.line, Id: entry.id, Message: fmt.Sprintf("duplicate TF resource address %s (also at line %d)", addr, line)})
+			continue
+		}
+		addrs[addr] = entry.line
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return &MappingValidationError{Path: path, Problems: problems}
+}
+
+func (meta baseMeta) validateResourceMappingEntry(id string, res resmap.ResourceMapEntity) []string {
+	var msgs []string
+	azureId, err := armid.ParseResourceId(id)
+	if err != nil {
+		msgs = append(msgs, fmt.Sprintf("invalid Azure resource id: %v", err))
+	}
+	if res.ResourceName == "" {
+		msgs = append(msgs, "missing resource_name")
+	} else if !hclsyntax.ValidIdentifier(res.ResourceName) {
+		msgs = append(msgs, fmt.Sprintf("invalid resource_name %q, which is not a valid identifier", res.ResourceName))
+	}
+
+	switch {
+	case res.ResourceType == "":
+		msgs = append(msgs, "missing resource_type")
+	case res.ResourceType == AzAPIResourceType:
+		// The azapi resource applies to any Azure resource.
+	case meta.providerName == ProviderAzAPI:
+		msgs = append(msgs, fmt.Sprintf("resource type %s is not supported by the %s provider", res.ResourceType, ProviderAzAPI))
+	default:
+		if _, ok := azurerm.ProviderSchemaInfo.ResourceSchemas[res.ResourceType]; !ok {
+			msgs = append(msgs, fmt.Sprintf("resource type %s doesn't exist in the provider schema", res.ResourceType))
+			break
+		}
+		if azureId == nil {
+			break
+		}
+		if rt, ok := meta.typeOverrides.Match(azureId); ok && rt == res.ResourceType {
+			break
+		}
+		// The id shape is only checked if the Azure resource id is identified by aztft.
+		types, _, err := aztft.QueryType(id, nil)
+		if err != nil || len(types) == 0 {
+			break
+		}
+		var expects []string
+		for _, t := range types {
+			if t.TFType == res.ResourceType {
+				expects = nil
+				break
+			}
+			expects = append(expects, t.TFType)
+		}
+		if len(expects) != 0 {
+			msgs = append(msgs, fmt.Sprintf("the Azure resource id doesn't match resource type %s, which is expected to be one of: %s", res.ResourceType, strings.Join(expects, ", ")))
+		}
+	}
+	return msgs
+}
+
+type resourceMappingEntry struct {
+	line int
+	id   string
+	res  resmap.ResourceMapEntity
+}
+
+// decodeResourceMappingEntries decodes the entries of the mapping file in order, along with their line numbers.
+func decodeResourceMappingEntries(b []byte) ([]resourceMappingEntry, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expect a JSON object")
+	}
+	var entries []resourceMappingEntry
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		id, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("expect a string key, got %v", tok)
+		}
+		line := bytes.Count(b[:dec.InputOffset()], []byte("\n")) + 1
+		var res resmap.ResourceMapEntity
+		if err := dec.Decode(&res); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		entries = append(entries, resourceMappingEntry{line: line, id: id, res: res})
+	}
+	return entries, nil
+}
@@ -0,0 +1,73 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateResourceMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.json")
+	write := func(content string) []resourceMappingEntry {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		_, entries, err := readResourceMappingFile(path)
+		require.NoError(t, err)
+		return entries
+	}
+	meta := baseMeta{providerName: ProviderAzureRM}
+
+	entries := write(`{
+  "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG": {
+    "resource_id": "/subscriptions/123/resourceGroups/rg",
+    "resource_type": "azurerm_resource_group",
+    "resource_name": "res-0"
+  },
+  "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.COMPUTE/VIRTUALMACHINES/VM": {
+    "resource_id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm",
+    "resource_type": "azurerm_linux_virtual_machine",
+    "resource_name": "res-1"
+  },
+  "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.WEB/SITES/APP": {
+    "resource_id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Web/sites/app",
+    "resource_type": "azapi_resource",
+    "resource_name": "res-2"
+  }
+}`)
+	require.NoError(t, meta.validateResourceMapping(path, entries))
+
+	entries = write(`{
+  "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG": {
+    "resource_id": "/subscriptions/123/resourceGroups/rg",
+    "resource_type": "azurerm_virtual_network",
+    "resource_name": "res-0"
+  },
+  "/SUBSCRIPTIONS/123/FOO": {
+    "resource_id": "/subscriptions/123/foo",
+    "resource_type": "azurerm_foo",
+    "resource_name": "res 1"
+  },
+  "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG2": {
+    "resource_id": "/subscriptions/123/resourceGroups/rg2",
+    "resource_type": "azurerm_virtual_network",
+    "resource_name": "res-0"
+  }
+}`)
+	err := meta.validateResourceMapping(path, entries)
+	require.Error(t, err)
+	var verr *MappingValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Len(t, verr.Problems, 6)
+	require.Equal(t, MappingProblem{
+		Line:    2,
+		Id:      "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG",
+		Message: "the Azure resource id doesn't match resource type azurerm_virtual_network, which is expected to be one of: azurerm_resource_group",
+	}, verr.Problems[0])
+	require.Equal(t, 7, verr.Problems[1].Line)
+	require.Contains(t, verr.Problems[1].Message, "invalid Azure resource id")
+	require.Equal(t, MappingProblem{Line: 7, Id: "/SUBSCRIPTIONS/123/FOO", Message: `invalid resource_name "res 1", which is not a valid identifier`}, verr.Problems[2])
+	require.Equal(t, MappingProblem{Line: 7, Id: "/SUBSCRIPTIONS/123/FOO", Message: "resource type azurerm_foo doesn't exist in the provider schema"}, verr.Problems[3])
+	require.Equal(t, 12, verr.Problems[4].Line)
+	require.Equal(t, MappingProblem{Line: 12, Id: "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG2", Message: "duplicate TF resource address azurerm_virtual_network.res-0 (also at line 2)"}, verr.Problems[5])
+}
@@ -0,0 +1,137 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type tfFile struct {
+	path string
+	file *hclwrite.File
+}
+
+// parseTFFiles parses the top level .tf files of the directory.
+func parseTFFiles(dir string) ([]tfFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("listing the .tf files in %s: %v", dir, err)
+	}
+	var files []tfFile
+	for _, path := range paths {
+		// #nosec G304
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading file %s: %v", path, err)
+		}
+		f, diags := hclwrite.ParseConfig(b, path, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing file %s: %v", path, diags.Error())
+		}
+		files = append(files, tfFile{path: path, file: f})
+	}
+	return files, nil
+}
+
+func (f tfFile) write() error {
+	// #nosec G306
+	if err := os.WriteFile(f.path, hclwrite.Format(f.file.Bytes()), 0644); err != nil {
+		return fmt.Errorf("writing file %s: %v", f.path, err)
+	}
+	return nil
+}
+
+// mergeRequiredProviders adds the required providers that are not declared yet to the existing terraform block of the output directory.
+// The existing declarations (e.g. version constraints, sources) are kept as is, as they are owned by the users.
+func (meta *baseMeta) mergeRequiredProviders() error {
+	files, err := parseTFFiles(meta.outdir)
+	if err != nil {
+		return err
+	}
+
+	declared := map[string]bool{}
+	var (
+		tfFileIdx = -1
+		tfBlock   *hclwrite.Block
+		rpBlock   *hclwrite.Block
+	)
+	for i, f := range files {
+		for _, blk := range f.file.Body().Blocks() {
+			if blk.Type() != "terraform" {
+				continue
+			}
+			if tfBlock == nil {
+				tfFileIdx, tfBlock = i, blk
+			}
+			for _, nblk := range blk.Body().Blocks() {
+				if nblk.Type() != "required_providers" {
+					continue
+				}
+				if rpBlock == nil && blk == tfBlock {
+					rpBlock = nblk
+				}
+				for name := range nblk.Body().Attributes() {
+					declared[name] = true
+				}
+			}
+		}
+	}
+	if tfBlock == nil {
+		return nil
+	}
+
+	var missing []requiredProvider
+	for _, rp := range meta.requiredProviders() {
+		if !declared[rp.name] {
+			missing = append(missing, rp)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if rpBlock == nil {
+		rpBlock = tfBlock.Body().AppendNewBlock("required_providers", nil)
+	}
+	for _, rp := range missing {
+		log.Printf("[INFO] Adding the required provider %s to the existing terraform block", rp.name)
+		rpBlock.Body().SetAttributeValue(rp.name, cty.ObjectVal(map[string]cty.Value{
+			"source":  cty.StringVal(rp.source),
+			"version": cty.StringVal(rp.version),
+		}))
+	}
+	return files[tfFileIdx].write()
+}
+
+// mergeProviderFeatures adds the "features" block, which is required by the azurerm provider, to the existing azurerm provider blocks that don't have one.
+func (meta *baseMeta) mergeProviderFeatures() error {
+	files, err := parseTFFiles(meta.outdir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		changed := false
+		for _, blk := range f.file.Body().Blocks() {
+			if blk.Type() != "provider" || len(blk.Labels()) != 1 || blk.Labels()[0] != ProviderAzureRM {
+				continue
+			}
+			if blk.Body().FirstMatchingBlock("features", nil) != nil {
+				continue
+			}
+			log.Printf("[INFO] Adding the features block to the existing %s provider block in %s", ProviderAzureRM, f.path)
+			meta.appendProviderFeatures(blk.Body())
+			changed = true
+		}
+		if changed {
+			if err := f.write(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
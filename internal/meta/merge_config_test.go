@@ -0,0 +1,80 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeExistingConfig(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "terraform.tf"), []byte(`terraform {
+  backend "local" {}
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+  }
+}
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "provider.tf"), []byte(`provider "azurerm" {
+  subscription_id = "123"
+}
+
+provider "azurerm" {
+  alias = "other"
+  features {
+    key_vault {
+      purge_soft_delete_on_destroy = false
+    }
+  }
+}
+`), 0644))
+
+	meta := baseMeta{
+		outdir:          dir,
+		providerName:    ProviderAzureRM,
+		providerVersion: "3.80.0",
+		azapiFallback:   true,
+	}
+	require.NoError(t, meta.mergeRequiredProviders())
+	require.NoError(t, meta.mergeProviderFeatures())
+
+	b, err := os.ReadFile(filepath.Join(dir, "terraform.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `terraform {
+  backend "local" {}
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+    azapi = {
+      source  = "azure/azapi"
+      version = "`+AzAPIProviderVersion+`"
+    }
+  }
+}
+`, string(b))
+
+	b, err = os.ReadFile(filepath.Join(dir, "provider.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `provider "azurerm" {
+  subscription_id = "123"
+  features {
+  }
+}
+
+provider "azurerm" {
+  alias = "other"
+  features {
+    key_vault {
+      purge_soft_delete_on_destroy = false
+    }
+  }
+}
+`, string(b))
+}
@@ -2,7 +2,10 @@ package meta
 
 import (
 	"context"
+	"fmt"
 	"time"
+
+	"github.com/magodo/tfadd/providers/azurerm"
 )
 
 type MetaGroupDummy struct {
@@ -31,6 +34,18 @@ func (m MetaGroupDummy) Workspace() string {
 	return "example-workspace"
 }
 
+func (m MetaGroupDummy) Result() ExportResult {
+	return ExportResult{Resources: []ExportedResource{}}
+}
+
+func (m MetaGroupDummy) ProviderNames() []string {
+	return []string{ProviderAzureRM}
+}
+
+func (m MetaGroupDummy) ProviderVersion() string {
+	return azurerm.ProviderSchemaInfo.Version
+}
+
 func (m MetaGroupDummy) ListResource(_ context.Context) (ImportList, error) {
 	time.Sleep(500 * time.Millisecond)
 	return ImportList{
@@ -71,6 +86,11 @@ func (m MetaGroupDummy) GenerateCfg(_ context.Context, l ImportList) error {
 	return nil
 }
 
+func (m MetaGroupDummy) PreviewCfg(_ context.Context, item ImportItem) ([]byte, error) {
+	time.Sleep(500 * time.Millisecond)
+	return []byte(fmt.Sprintf("resource %q %q {\n}\n", item.TFAddr.Type, item.TFAddr.Name)), nil
+}
+
 func (m MetaGroupDummy) ExportResourceMapping(_ context.Context, l ImportList) error {
 	time.Sleep(500 * time.Millisecond)
 	return nil
@@ -2,9 +2,7 @@ package meta
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 	"sort"
 
 	"github.com/Azure/aztfexport/pkg/config"
@@ -17,42 +15,71 @@ import (
 
 type MetaMap struct {
 	baseMeta
-	mappingFile string
+	mappingFile     string
+	resourceMapping resmap.ResourceMapping
 }
 
 func NewMetaMap(cfg config.Config) (*MetaMap, error) {
 	log.Printf("[INFO] New map meta")
+
+	// The mapping file is read before the base meta is created, as it might contain azapi resources (e.g. exported via the azapi fallback),
+	// which requires the azapi provider to import, i.e. the azapi fallback that is validated against the rest of the config.
+	format, entries, err := readResourceMappingFile(cfg.MappingFile)
+	if err != nil {
+		return nil, err
+	}
+	azapiFallback := !cfg.AzAPIFallback && cfg.ProviderName != ProviderAzAPI && mappingHasAzAPIResource(entries)
+	if azapiFallback {
+		cfg.AzAPIFallback = true
+	}
+
 	baseMeta, err := NewBaseMeta(cfg.CommonConfig)
 	if err != nil {
+		if azapiFallback {
+			return nil, fmt.Errorf("the mapping file contains %s resources, which requires the azapi fallback: %v", AzAPIResourceType, err)
+		}
 		return nil, err
 	}
 
-	meta := &MetaMap{
-		baseMeta:    *baseMeta,
-		mappingFile: cfg.MappingFile,
+	if format != mappingFormatJSON {
+		baseMeta.completeResourceMappingEntries(entries)
+	}
+	// Validate the mapping file eagerly, rather than failing midway through the imports.
+	if err := baseMeta.validateResourceMapping(cfg.MappingFile, entries); err != nil {
+		return nil, err
+	}
+
+	m := resmap.ResourceMapping{}
+	for _, entry := range entries {
+		m[entry.id] = entry.res
 	}
 
+	meta := &MetaMap{
+		baseMeta:        *baseMeta,
+		mappingFile:     cfg.MappingFile,
+		resourceMapping: m,
+	}
 	return meta, nil
 }
 
+func mappingHasAzAPIResource(entries []resourceMappingEntry) bool {
+	for _, entry := range entries {
+		if entry.res.ResourceType == AzAPIResourceType {
+			return true
+		}
+	}
+	return false
+}
+
 func (meta MetaMap) ScopeName() string {
 	return meta.mappingFile
 }
 
-func (meta *MetaMap) ListResource(_ context.Context) (ImportList, error) {
-	var m resmap.ResourceMapping
-
-	log.Printf("[DEBUG] Read resource set from mapping file")
-	b, err := os.ReadFile(meta.mappingFile)
-	if err != nil {
-		return nil, fmt.Errorf("reading mapping file %s: %v", meta.mappingFile, err)
-	}
-	if err := json.Unmarshal(b, &m); err != nil {
-		return nil, fmt.Errorf("unmarshalling the mapping file: %v", err)
-	}
-
+func (meta *MetaMap) ListResource(ctx context.Context) (_ ImportList, err error) {
+	defer meta.hookError(&err)
+	defer log.Phase("list")()
 	var l ImportList
-	for id, res := range m {
+	for id, res := range meta.resourceMapping {
 		azureId, err := armid.ParseResourceId(id)
 		if err != nil {
 			return nil, fmt.Errorf("parsing resource id %q: %v", id, err)
@@ -64,10 +91,19 @@ func (meta *MetaMap) ListResource(_ context.Context) (ImportList, error) {
 		item := ImportItem{
 			AzureResourceID: azureId,
 			TFResourceId:    res.ResourceId,
+			ImportId:        res.ImportId,
 			TFAddrCache:     tfAddr,
 			TFAddr:          tfAddr,
 			Recommendations: []string{res.ResourceType},
 		}
+		if len(res.Candidates) != 0 {
+			item.Recommendations = nil
+			item.Confidences = map[string]float64{}
+			for _, c := range res.Candidates {
+				item.Recommendations = append(item.Recommendations, c.ResourceType)
+				item.Confidences[c.ResourceType] = c.Confidence
+			}
+		}
 		l = append(l, item)
 	}
 
@@ -75,5 +111,5 @@ func (meta *MetaMap) ListResource(_ context.Context) (ImportList, error) {
 		return l[i].AzureResourceID.String() < l[j].AzureResourceID.String()
 	})
 
-	return l, nil
+	return meta.postListResource(ctx, l)
 }
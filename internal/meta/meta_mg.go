@@ -0,0 +1,229 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/magodo/armid"
+)
+
+const managementGroupAPIVersion = "2020-05-01"
+
+type MetaManagementGroup struct {
+	baseMeta
+	managementGroup string
+	namePattern     string
+	nameFrom        string
+	includeTags     map[string]string
+	excludeTags     map[string]string
+
+	// subscriptionIds are the subscriptions under the management group, which are enumerated during Init.
+	subscriptionIds []string
+}
+
+func NewMetaManagementGroup(cfg config.Config) (*MetaManagementGroup, error) {
+	log.Printf("[INFO] New management group meta")
+	if err := ValidateResourceNamePattern(cfg.ResourceNamePattern); err != nil {
+		return nil, err
+	}
+	if err := ValidateNameFrom(cfg.NameFrom); err != nil {
+		return nil, err
+	}
+	if err := validateProviderAliases(cfg.CommonConfig, "ManagementGroupName"); err != nil {
+		return nil, err
+	}
+	baseMeta, err := NewBaseMeta(cfg.CommonConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &MetaManagementGroup{
+		baseMeta:        *baseMeta,
+		managementGroup: cfg.ManagementGroupName,
+		namePattern:     cfg.ResourceNamePattern,
+		nameFrom:        cfg.NameFrom,
+		includeTags:     cfg.IncludeTags,
+		excludeTags:     cfg.ExcludeTags,
+	}
+
+	return meta, nil
+}
+
+func (meta MetaManagementGroup) ScopeName() string {
+	return meta.managementGroup
+}
+
+// Init enumerates the subscriptions under the management group before initializing the workspace, as the generated provider config
+// contains an aliased provider for each of them.
+func (meta *MetaManagementGroup) Init(ctx context.Context) error {
+	subscriptionIds, err := meta.listManagementGroupSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("listing the subscriptions of management group %s: %v", meta.managementGroup, err)
+	}
+	if len(subscriptionIds) == 0 {
+		return fmt.Errorf("no subscription found under management group %s", meta.managementGroup)
+	}
+	log.Printf("[INFO] Found %d subscriptions under management group %s", len(subscriptionIds), meta.managementGroup)
+	meta.subscriptionIds = subscriptionIds
+	meta.aliasSubscriptionIds = aliasSubscriptionIds(meta.subscriptionId, subscriptionIds)
+
+	return meta.baseMeta.Init(ctx)
+}
+
+func (meta *MetaManagementGroup) ListResource(ctx context.Context) (_ ImportList, err error) {
+	defer meta.hookError(&err)
+	defer log.Phase("list")()
+	log.Printf("[DEBUG] Query resource set")
+	rset, err := meta.queryResourceSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rl, err := meta.toTFResources(ctx, rset)
+	if err != nil {
+		return nil, err
+	}
+
+	namer, err := newResourceNamer(meta.namingStrategy, meta.nameFrom, meta.namePattern)
+	if err != nil {
+		return nil, err
+	}
+	tags := resourceTags(rset)
+
+	var l ImportList
+	for _, res := range rl {
+		resTags := tags[strings.ToUpper(res.AzureId.String())]
+		name, err := namer.Name(res, resTags)
+		if err != nil {
+			return nil, err
+		}
+		tfAddr := tfaddr.TFAddr{
+			Type: "",
+			Name: name,
+		}
+		item := ImportItem{
+			AzureResourceID: res.AzureId,
+			TFResourceId:    res.TFId,
+			TFAddr:          tfAddr,
+			TFAddrCache:     tfAddr,
+			Tags:            resTags,
+			Confidences:     res.Confidences,
+		}
+		if res.TFType != "" {
+			item.Recommendations = []string{res.TFType}
+			// The resource is resolved as the best of its ranked candidates.
+			if len(res.Candidates) != 0 {
+				item.Recommendations = res.Candidates
+			}
+			item.TFAddr.Type = res.TFType
+			item.TFAddrCache.Type = res.TFType
+			item.IsRecommended = true
+			// The pseudo resources are only offered as candidates, which are skipped until the user opts in (via the interactive list).
+			if res.Pseudo {
+				item.TFAddr.Type = ""
+			}
+		} else {
+			item.Recommendations = res.Candidates
+		}
+
+		l = append(l, item)
+	}
+	return meta.postListResource(ctx, l)
+}
+
+// queryResourceSet lists all the resources of the subscriptions under the management group, together with the resource groups that contain them.
+// Note that the empty resource groups are not listed.
+func (meta MetaManagementGroup) queryResourceSet(ctx context.Context) (*resourceset.AzureResourceSet, error) {
+	rset, err := meta.listResourceSet(ctx, WithTagFilter("true", meta.includeTags, meta.excludeTags), true, meta.subscriptionIds)
+	if err != nil {
+		return nil, err
+	}
+	rset.Resources = append(rset.Resources, resourceGroupsOf(rset.Resources)...)
+	return rset, nil
+}
+
+// resourceGroupsOf returns the distinct resource groups that contain the resources.
+func resourceGroupsOf(rl []resourceset.AzureResource) []resourceset.AzureResource {
+	seen := map[string]bool{}
+	var out []resourceset.AzureResource
+	for _, res := range rl {
+		rg, ok := res.Id.RootScope().(*armid.ResourceGroup)
+		if !ok {
+			continue
+		}
+		k := strings.ToUpper(rg.String())
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, resourceset.AzureResource{Id: &armid.ResourceGroup{
+			SubscriptionId: rg.SubscriptionId,
+			Name:           rg.Name,
+		}})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.ToUpper(out[i].Id.String()) < strings.ToUpper(out[j].Id.String())
+	})
+	return out
+}
+
+// listManagementGroupSubscriptions lists the subscriptions under the management group, including the ones of its descendant management groups.
+func (meta MetaManagementGroup) listManagementGroupSubscriptions(ctx context.Context) ([]string, error) {
+	client, err := arm.NewClient("meta.Client", "v0.0.0", meta.azureSDKCred, &meta.azureSDKClientOpt)
+	if err != nil {
+		return nil, fmt.Errorf("building the client: %v", err)
+	}
+
+	var ids []string
+	next := fmt.Sprintf("%s/providers/Microsoft.Management/managementGroups/%s/descendants?api-version=%s", strings.TrimSuffix(client.Endpoint(), "/"), url.PathEscape(meta.managementGroup), managementGroupAPIVersion)
+	for next != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, next)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Pipeline().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+		var page struct {
+			Value    []managementGroupDescendant `json:"value"`
+			NextLink string                      `json:"nextLink"`
+		}
+		if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+			return nil, err
+		}
+		ids = append(ids, descendantSubscriptions(page.Value)...)
+		next = page.NextLink
+	}
+	return ids, nil
+}
+
+type managementGroupDescendant struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// descendantSubscriptions returns the subscription ids among the descendants of a management group, which also include the child management groups.
+func descendantSubscriptions(descendants []managementGroupDescendant) []string {
+	var ids []string
+	for _, d := range descendants {
+		if !strings.EqualFold(d.Type, "/subscriptions") {
+			continue
+		}
+		ids = append(ids, d.Name)
+	}
+	return ids
+}
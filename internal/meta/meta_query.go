@@ -3,24 +3,40 @@ package meta
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/Azure/aztfexport/internal/resourceset"
 	"github.com/Azure/aztfexport/internal/tfaddr"
 	"github.com/Azure/aztfexport/pkg/config"
 	"github.com/Azure/aztfexport/pkg/log"
-	"github.com/magodo/azlist/azlist"
 )
 
 type MetaQuery struct {
 	baseMeta
-	argPredicate       string
-	recursiveQuery     bool
-	resourceNamePrefix string
-	resourceNameSuffix string
+	argPredicate   string
+	recursiveQuery bool
+	namePattern    string
+	nameFrom       string
+	includeTags    map[string]string
+	excludeTags    map[string]string
+
+	onlyResourceIds []string
+	avoidStateNames bool
 }
 
 func NewMetaQuery(cfg config.Config) (*MetaQuery, error) {
 	log.Printf("[INFO] New query meta")
+	if err := ValidateResourceNamePattern(cfg.ResourceNamePattern); err != nil {
+		return nil, err
+	}
+	if err := ValidateNameFrom(cfg.NameFrom); err != nil {
+		return nil, err
+	}
+	if len(cfg.AdditionalSubscriptionIds) != 0 {
+		if err := validateProviderAliases(cfg.CommonConfig, "AdditionalSubscriptionIds"); err != nil {
+			return nil, err
+		}
+	}
 	baseMeta, err := NewBaseMeta(cfg.CommonConfig)
 	if err != nil {
 		return nil, err
@@ -30,8 +46,14 @@ func NewMetaQuery(cfg config.Config) (*MetaQuery, error) {
 		baseMeta:       *baseMeta,
 		argPredicate:   cfg.ARGPredicate,
 		recursiveQuery: cfg.RecursiveQuery,
+		namePattern:    cfg.ResourceNamePattern,
+		nameFrom:       cfg.NameFrom,
+		includeTags:    cfg.IncludeTags,
+		excludeTags:    cfg.ExcludeTags,
+
+		onlyResourceIds: cfg.OnlyResourceIds,
+		avoidStateNames: cfg.AvoidStateNames,
 	}
-	meta.resourceNamePrefix, meta.resourceNameSuffix = resourceNamePattern(cfg.ResourceNamePattern)
 
 	return meta, nil
 }
@@ -44,71 +66,95 @@ func (meta MetaQuery) ScopeName() string {
 	return msg
 }
 
-func (meta *MetaQuery) ListResource(ctx context.Context) (ImportList, error) {
-	log.Printf("[DEBUG] Query resource set")
-	rset, err := meta.queryResourceSet(ctx, meta.argPredicate, meta.recursiveQuery)
+func (meta *MetaQuery) ListResource(ctx context.Context) (_ ImportList, err error) {
+	defer meta.hookError(&err)
+	defer log.Phase("list")()
+	predicate := WithTagFilter(meta.argPredicate, meta.includeTags, meta.excludeTags)
+	log.Printf("[INFO] Query resource set by the ARG predicate: %s", predicate)
+	rset, err := meta.listResourceSet(ctx, predicate, meta.recursiveQuery, append([]string{meta.subscriptionId}, meta.aliasSubscriptionIds...))
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("[DEBUG] Populate resource set")
-	if err := rset.PopulateResource(); err != nil {
-		return nil, fmt.Errorf("tweaking single resources in the azure resource set: %v", err)
-	}
-	log.Printf("[DEBUG] Reduce resource set")
-	if err := rset.ReduceResource(); err != nil {
-		return nil, fmt.Errorf("tweaking across resources in the azure resource set: %v", err)
+	rl, err := meta.toTFResources(ctx, rset)
+	if err != nil {
+		return nil, err
 	}
+	rl = filterResourceIds(rl, meta.onlyResourceIds)
 
-	log.Printf("[DEBUG] Azure Resource set map to TF resource set")
-	rl := rset.ToTFResources(meta.parallelism, meta.azureSDKCred, meta.azureSDKClientOpt)
+	namer, err := newResourceNamer(meta.namingStrategy, meta.nameFrom, meta.namePattern)
+	if err != nil {
+		return nil, err
+	}
+	if meta.avoidStateNames {
+		namer.reserve(stateResourceNames(meta.baseState)...)
+	}
+	tags := resourceTags(rset)
 
 	var l ImportList
-	for i, res := range rl {
+	for _, res := range rl {
+		resTags := tags[strings.ToUpper(res.AzureId.String())]
+		name, err := namer.Name(res, resTags)
+		if err != nil {
+			return nil, err
+		}
 		item := ImportItem{
 			AzureResourceID: res.AzureId,
 			TFResourceId:    res.TFId,
 			TFAddr: tfaddr.TFAddr{
 				Type: "",
-				Name: fmt.Sprintf("%s%d%s", meta.resourceNamePrefix, i, meta.resourceNameSuffix),
+				Name: name,
 			},
 			TFAddrCache: tfaddr.TFAddr{
 				Type: "",
-				Name: fmt.Sprintf("%s%d%s", meta.resourceNamePrefix, i, meta.resourceNameSuffix),
+				Name: name,
 			},
+			Tags:        resTags,
+			Confidences: res.Confidences,
 		}
 		if res.TFType != "" {
 			item.Recommendations = []string{res.TFType}
+			// The resource is resolved as the best of its ranked candidates.
+			if len(res.Candidates) != 0 {
+				item.Recommendations = res.Candidates
+			}
 			item.TFAddr.Type = res.TFType
 			item.TFAddrCache.Type = res.TFType
 			item.IsRecommended = true
+			// Skip the pseudo resources by default, the user can still opt in via the interactive list.
+			if res.Pseudo {
+				item.TFAddr.Type = ""
+			}
+		} else {
+			item.Recommendations = res.Candidates
 		}
 
 		l = append(l, item)
 	}
-	return l, nil
+	return meta.postListResource(ctx, l)
 }
 
-func (meta MetaQuery) queryResourceSet(ctx context.Context, predicate string, recursive bool) (*resourceset.AzureResourceSet, error) {
-	result, err := azlist.List(ctx, predicate,
-		azlist.Option{
-			SubscriptionId: meta.subscriptionId,
-			Cred:           meta.azureSDKCred,
-			ClientOpt:      meta.azureSDKClientOpt,
-			Parallelism:    meta.parallelism,
-			Recursive:      recursive,
-		})
-	if err != nil {
-		return nil, fmt.Errorf("listing resource set: %v", err)
-	}
-
+// listResourceSet lists the resources that match the ARG predicate, which fans out across the specified subscriptions.
+func (meta baseMeta) listResourceSet(ctx context.Context, predicate string, recursive bool, subscriptionIds []string) (*resourceset.AzureResourceSet, error) {
 	var rl []resourceset.AzureResource
-	for _, res := range result.Resources {
-		res := resourceset.AzureResource{
-			Id:         res.Id,
-			Properties: res.Properties,
+	for _, subscriptionId := range subscriptionIds {
+		resources, err := meta.listARG(ctx, subscriptionId, predicate, recursive)
+		if err != nil {
+			return nil, fmt.Errorf("listing resource set of subscription %s: %v", subscriptionId, err)
+		}
+		for _, res := range resources {
+			res := resourceset.AzureResource{
+				Id:         res.Id,
+				Properties: res.Properties,
+			}
+			rl = append(rl, res)
 		}
-		rl = append(rl, res)
 	}
 
-	return &resourceset.AzureResourceSet{Resources: rl}, nil
+	rset := &resourceset.AzureResourceSet{Resources: rl}
+	if recursive {
+		if err := meta.enumerateChildResources(ctx, rset); err != nil {
+			return nil, fmt.Errorf("enumerating the child resources: %v", err)
+		}
+	}
+	return rset, nil
 }
@@ -43,7 +43,9 @@ func (meta MetaResource) ScopeName() string {
 	return meta.AzureId.String()
 }
 
-func (meta *MetaResource) ListResource(_ context.Context) (ImportList, error) {
+func (meta *MetaResource) ListResource(ctx context.Context) (_ ImportList, err error) {
+	defer meta.hookError(&err)
+	defer log.Phase("list")()
 	resourceSet := resourceset.AzureResourceSet{
 		Resources: []resourceset.AzureResource{
 			{
@@ -51,8 +53,18 @@ func (meta *MetaResource) ListResource(_ context.Context) (ImportList, error) {
 			},
 		},
 	}
-	log.Printf("[DEBUG] Azure Resource set map to TF resource set")
-	rl := resourceSet.ToTFResources(meta.parallelism, meta.azureSDKCred, meta.azureSDKClientOpt)
+	if err := meta.populateExtensionResources(ctx, &resourceSet); err != nil {
+		return nil, fmt.Errorf("populating the extension resources: %v", err)
+	}
+	meta.hookResolveStart()
+	var rl []resourceset.TFResource
+	if meta.providerName == ProviderAzAPI {
+		log.Printf("[DEBUG] Azure Resource set map to azapi resource set")
+		rl = resourceSet.ToAzAPIResources(AzAPIResourceType, AzAPIResourceId)
+	} else {
+		log.Printf("[DEBUG] Azure Resource set map to TF resource set")
+		rl = resourceSet.ToTFResources(meta.parallelism, meta.resolverChain())
+	}
 
 	// This is to record known resource types. In case there is a known resource type and there comes another same typed resource,
 	// then we need to modify the resource name. Otherwise, there will be a resource address conflict.
@@ -75,11 +87,13 @@ func (meta *MetaResource) ListResource(_ context.Context) (ImportList, error) {
 			TFResourceId:    res.TFId, // this might be empty if have multiple matches in aztft
 			TFAddr:          tfAddr,
 			TFAddrCache:     tfAddr,
+			Recommendations: res.Candidates,
+			Confidences:     res.Confidences,
 		}
 
 		// Some special Azure resource is missing the essential property that is used by aztft to detect their TF resource type.
 		// In this case, users can use the `--type` option to manually specify the TF resource type.
-		if meta.ResourceType != "" {
+		if meta.ResourceType != "" && meta.providerName != ProviderAzAPI {
 			if meta.AzureId.Equal(res.AzureId) {
 				tfid, err := aztft.QueryId(meta.AzureId.String(), meta.ResourceType,
 					&aztft.APIOption{
@@ -98,5 +112,5 @@ func (meta *MetaResource) ListResource(_ context.Context) (ImportList, error) {
 		l = append(l, item)
 	}
 
-	return l, nil
+	return meta.postListResource(ctx, l)
 }
@@ -3,99 +3,231 @@ package meta
 import (
 	"context"
 	"fmt"
+	"path"
+	"strconv"
+	"strings"
 
 	"github.com/Azure/aztfexport/internal/resourceset"
 	"github.com/Azure/aztfexport/internal/tfaddr"
 	"github.com/Azure/aztfexport/pkg/config"
 	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 	"github.com/magodo/armid"
-	"github.com/magodo/azlist/azlist"
 )
 
 type MetaResourceGroup struct {
 	baseMeta
-	resourceGroup      string
-	resourceNamePrefix string
-	resourceNameSuffix string
+	// resourceGroups are the names (or the glob patterns) of the resource groups
+	resourceGroups []string
+	namePattern    string
+	nameFrom       string
+	includeTags    map[string]string
+	excludeTags    map[string]string
+	onlyTypes      []string
+	skipTypes      []string
 }
 
 func NewMetaResourceGroup(cfg config.Config) (*MetaResourceGroup, error) {
 	log.Printf("[INFO] New resource group meta")
+	if err := ValidateResourceNamePattern(cfg.ResourceNamePattern); err != nil {
+		return nil, err
+	}
+	if err := ValidateNameFrom(cfg.NameFrom); err != nil {
+		return nil, err
+	}
+	if err := ValidateTypeFilter(cfg.OnlyTypes); err != nil {
+		return nil, fmt.Errorf("invalid only types: %v", err)
+	}
+	if err := ValidateTypeFilter(cfg.SkipTypes); err != nil {
+		return nil, fmt.Errorf("invalid skip types: %v", err)
+	}
+	resourceGroups := append([]string{cfg.ResourceGroupName}, cfg.AdditionalResourceGroupNames...)
+	for _, rg := range resourceGroups {
+		if _, err := path.Match(rg, ""); err != nil {
+			return nil, fmt.Errorf("invalid resource group pattern %q: %v", rg, err)
+		}
+	}
 	baseMeta, err := NewBaseMeta(cfg.CommonConfig)
 	if err != nil {
 		return nil, err
 	}
 
 	meta := &MetaResourceGroup{
-		baseMeta:      *baseMeta,
-		resourceGroup: cfg.ResourceGroupName,
+		baseMeta:       *baseMeta,
+		resourceGroups: resourceGroups,
+		namePattern:    cfg.ResourceNamePattern,
+		nameFrom:       cfg.NameFrom,
+		includeTags:    cfg.IncludeTags,
+		excludeTags:    cfg.ExcludeTags,
+		onlyTypes:      cfg.OnlyTypes,
+		skipTypes:      cfg.SkipTypes,
 	}
-	meta.resourceNamePrefix, meta.resourceNameSuffix = resourceNamePattern(cfg.ResourceNamePattern)
 
 	return meta, nil
 }
 
 func (meta MetaResourceGroup) ScopeName() string {
-	return meta.resourceGroup
+	return strings.Join(meta.resourceGroups, ", ")
 }
 
-func (meta *MetaResourceGroup) ListResource(ctx context.Context) (ImportList, error) {
-	log.Printf("[DEBUG] Query resource set")
-	rset, err := meta.queryResourceSet(ctx, meta.resourceGroup)
+func (meta *MetaResourceGroup) ListResource(ctx context.Context) (_ ImportList, err error) {
+	defer meta.hookError(&err)
+	defer log.Phase("list")()
+	rgs, err := meta.resolveResourceGroups(ctx)
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("[DEBUG] Populate resource set")
-	if err := rset.PopulateResource(); err != nil {
-		return nil, fmt.Errorf("tweaking single resources in the azure resource set: %v", err)
+	log.Printf("[DEBUG] Query resource set")
+	rset, err := meta.queryResourceSet(ctx, rgs)
+	if err != nil {
+		return nil, err
 	}
-	log.Printf("[DEBUG] Reduce resource set")
-	if err := rset.ReduceResource(); err != nil {
-		return nil, fmt.Errorf("tweaking across resources in the azure resource set: %v", err)
+	rl, err := meta.toTFResources(ctx, rset)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Printf("[DEBUG] Azure Resource set map to TF resource set")
-	rl := rset.ToTFResources(meta.parallelism, meta.azureSDKCred, meta.azureSDKClientOpt)
+	namer, err := newResourceNamer(meta.namingStrategy, meta.nameFrom, meta.namePattern)
+	if err != nil {
+		return nil, err
+	}
+	tags := resourceTags(rset)
 
 	var l ImportList
-	for i, res := range rl {
+	for _, res := range rl {
+		resTags := tags[strings.ToUpper(res.AzureId.String())]
+		name, err := namer.Name(res, resTags)
+		if err != nil {
+			return nil, err
+		}
 		tfAddr := tfaddr.TFAddr{
 			Type: "",
-			Name: fmt.Sprintf("%s%d%s", meta.resourceNamePrefix, i, meta.resourceNameSuffix),
+			Name: name,
 		}
 		item := ImportItem{
 			AzureResourceID: res.AzureId,
 			TFResourceId:    res.TFId,
 			TFAddr:          tfAddr,
 			TFAddrCache:     tfAddr,
+			Tags:            resTags,
+			Confidences:     res.Confidences,
 		}
 		if res.TFType != "" {
 			item.Recommendations = []string{res.TFType}
+			// The resource is resolved as the best of its ranked candidates.
+			if len(res.Candidates) != 0 {
+				item.Recommendations = res.Candidates
+			}
 			item.TFAddr.Type = res.TFType
 			item.TFAddrCache.Type = res.TFType
 			item.IsRecommended = true
+			// The pseudo resources are only offered as candidates, which are skipped until the user opts in (via the interactive list).
+			if res.Pseudo {
+				item.TFAddr.Type = ""
+			}
+		} else {
+			item.Recommendations = res.Candidates
 		}
 
 		l = append(l, item)
 	}
-	return l, nil
+	// The types are filtered once resolved, ahead of the other filters (e.g. the exclude file) and the detections (e.g. the locks).
+	l = filterTypes(l, meta.onlyTypes, meta.skipTypes)
+	return meta.postListResource(ctx, l)
 }
 
-func (meta MetaResourceGroup) queryResourceSet(ctx context.Context, rg string) (*resourceset.AzureResourceSet, error) {
-	result, err := azlist.List(ctx, fmt.Sprintf("resourceGroup =~ %q", rg),
-		azlist.Option{
-			SubscriptionId: meta.subscriptionId,
-			Cred:           meta.azureSDKCred,
-			ClientOpt:      meta.azureSDKClientOpt,
-			Parallelism:    meta.parallelism,
-			Recursive:      true,
-		})
+// resolveResourceGroups resolves the glob patterns of the resource groups to the names of the resource groups of the subscription.
+func (meta MetaResourceGroup) resolveResourceGroups(ctx context.Context) ([]string, error) {
+	var hasPattern bool
+	for _, rg := range meta.resourceGroups {
+		if isResourceGroupPattern(rg) {
+			hasPattern = true
+			break
+		}
+	}
+	if !hasPattern {
+		return matchResourceGroups(meta.resourceGroups, nil)
+	}
+
+	client, err := armresources.NewResourceGroupsClient(meta.subscriptionId, meta.azureSDKCred, &meta.azureSDKClientOpt)
+	if err != nil {
+		return nil, fmt.Errorf("building the resource group client: %v", err)
+	}
+	var names []string
+	pager := client.NewListPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing the resource groups: %v", err)
+		}
+		for _, rg := range page.Value {
+			if rg.Name != nil {
+				names = append(names, *rg.Name)
+			}
+		}
+	}
+	rgs, err := matchResourceGroups(meta.resourceGroups, names)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Resolved the resource groups: %s", strings.Join(rgs, ", "))
+	return rgs, nil
+}
+
+// isResourceGroupPattern tells whether the resource group is a glob pattern, as "*", "?" and "[" are not allowed in the resource group names.
+func isResourceGroupPattern(rg string) bool {
+	return strings.ContainsAny(rg, "*?[")
+}
+
+// matchResourceGroups returns the resource groups that are either a name, or a name of the names that matches a pattern (case insensitively), deduplicated.
+func matchResourceGroups(rgs []string, names []string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	add := func(rg string) {
+		if !seen[strings.ToUpper(rg)] {
+			seen[strings.ToUpper(rg)] = true
+			out = append(out, rg)
+		}
+	}
+	for _, rg := range rgs {
+		if !isResourceGroupPattern(rg) {
+			add(rg)
+			continue
+		}
+		var matched bool
+		for _, name := range names {
+			if ok, _ := path.Match(strings.ToLower(rg), strings.ToLower(name)); ok {
+				matched = true
+				add(name)
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no resource group matches %q", rg)
+		}
+	}
+	return out, nil
+}
+
+// resourceGroupPredicate returns the ARG predicate of the resources in the resource groups.
+func resourceGroupPredicate(rgs []string) string {
+	if len(rgs) == 1 {
+		return fmt.Sprintf("resourceGroup =~ %q", rgs[0])
+	}
+	var quoted []string
+	for _, rg := range rgs {
+		quoted = append(quoted, strconv.Quote(rg))
+	}
+	return fmt.Sprintf("resourceGroup in~ (%s)", strings.Join(quoted, ", "))
+}
+
+func (meta MetaResourceGroup) queryResourceSet(ctx context.Context, rgs []string) (*resourceset.AzureResourceSet, error) {
+	resources, err := meta.listARG(ctx, meta.subscriptionId, WithTagFilter(resourceGroupPredicate(rgs), meta.includeTags, meta.excludeTags), true)
 	if err != nil {
 		return nil, fmt.Errorf("listing resource set: %v", err)
 	}
 
 	var rl []resourceset.AzureResource
-	for _, res := range result.Resources {
+	for _, res := range resources {
 		res := resourceset.AzureResource{
 			Id:         res.Id,
 			Properties: res.Properties,
@@ -103,11 +235,17 @@ func (meta MetaResourceGroup) queryResourceSet(ctx context.Context, rg string) (
 		rl = append(rl, res)
 	}
 
-	// Especially, adding the resoruce group itself to the resource set
-	rl = append(rl, resourceset.AzureResource{Id: &armid.ResourceGroup{
-		SubscriptionId: meta.subscriptionId,
-		Name:           meta.resourceGroup,
-	}})
+	// Especially, adding the resoruce groups themselves to the resource set
+	for _, rg := range rgs {
+		rl = append(rl, resourceset.AzureResource{Id: &armid.ResourceGroup{
+			SubscriptionId: meta.subscriptionId,
+			Name:           rg,
+		}})
+	}
 
-	return &resourceset.AzureResourceSet{Resources: rl}, nil
+	rset := &resourceset.AzureResourceSet{Resources: rl}
+	if err := meta.enumerateChildResources(ctx, rset); err != nil {
+		return nil, fmt.Errorf("enumerating the child resources: %v", err)
+	}
+	return rset, nil
 }
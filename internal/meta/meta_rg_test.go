@@ -0,0 +1,27 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchResourceGroups(t *testing.T) {
+	names := []string{"rg-prod-eu", "RG-PROD-US", "rg-dev"}
+
+	rgs, err := matchResourceGroups([]string{"rg-prod-*", "rg-dev", "RG-DEV", "rg-missing"}, names)
+	require.NoError(t, err)
+	require.Equal(t, []string{"rg-prod-eu", "RG-PROD-US", "rg-dev", "rg-missing"}, rgs)
+
+	rgs, err = matchResourceGroups([]string{"rg-?ev", "*"}, names)
+	require.NoError(t, err)
+	require.Equal(t, []string{"rg-dev", "rg-prod-eu", "RG-PROD-US"}, rgs)
+
+	_, err = matchResourceGroups([]string{"rg-test-*"}, names)
+	require.EqualError(t, err, `no resource group matches "rg-test-*"`)
+}
+
+func TestResourceGroupPredicate(t *testing.T) {
+	require.Equal(t, `resourceGroup =~ "rg1"`, resourceGroupPredicate([]string{"rg1"}))
+	require.Equal(t, `resourceGroup in~ ("rg1", "rg2")`, resourceGroupPredicate([]string{"rg1", "rg2"}))
+}
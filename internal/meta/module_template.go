@@ -0,0 +1,155 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// ModuleTemplateResourceGroup is the placeholder of the module template for the resource group name of the resource.
+	ModuleTemplateResourceGroup = "{resource_group}"
+	// ModuleTemplateType is the placeholder of the module template for the TF resource type of the resource.
+	ModuleTemplateType = "{type}"
+)
+
+var moduleTemplatePlaceholder = regexp.MustCompile(`\{[^}]*\}`)
+
+// parseModuleTemplate validates the module address template (e.g. "module.rg_{resource_group}"), and returns the template of the module name (e.g. "rg_{resource_group}").
+// Only the child modules of the root module are supported.
+func parseModuleTemplate(tpl string) (string, error) {
+	if !strings.HasPrefix(tpl, "module.") {
+		return "", fmt.Errorf(`%q doesn't start with "module."`, tpl)
+	}
+	name := strings.TrimPrefix(tpl, "module.")
+	if strings.Contains(name, ".") {
+		return "", fmt.Errorf("%q is not a child module of the root module", tpl)
+	}
+	for _, placeholder := range moduleTemplatePlaceholder.FindAllString(name, -1) {
+		if placeholder != ModuleTemplateResourceGroup && placeholder != ModuleTemplateType {
+			return "", fmt.Errorf("unknown placeholder %s in %q", placeholder, tpl)
+		}
+	}
+	if !hclsyntax.ValidIdentifier(moduleTemplatePlaceholder.ReplaceAllString(name, "x")) {
+		return "", fmt.Errorf("%q is not a valid module address", tpl)
+	}
+	return name, nil
+}
+
+// templateModule renders the module template for the resource.
+func (meta baseMeta) templateModule(item ImportItem) string {
+	name := strings.NewReplacer(
+		ModuleTemplateResourceGroup, moduleNameSegment(itemResourceGroup(item)),
+		ModuleTemplateType, moduleNameSegment(item.TFAddr.Type),
+	).Replace(meta.moduleTemplate)
+	if !hclsyntax.ValidIdentifier(name) {
+		name = "_" + name
+	}
+	return name
+}
+
+// resolveTemplateModules resolves the directories of the child modules that the resources are imported into, which must be called by the root module from local paths.
+// The missing child modules are generated as stubs, together with their module calls, if CreateMissingModules is set. Otherwise, they are reported as an error.
+func (meta baseMeta) resolveTemplateModules(items []*ImportItem) error {
+	var names []string
+	for _, item := range items {
+		if item.Skip() {
+			continue
+		}
+		name := meta.splitModule(*item)
+		if _, ok := meta.templateModuleDirs[name]; ok {
+			continue
+		}
+		meta.templateModuleDirs[name] = ""
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	module, diags := tfconfig.LoadModule(meta.outdir)
+	if diags.HasErrors() {
+		return fmt.Errorf("loading main module: %v", diags.Err())
+	}
+	var missing []string
+	for _, name := range names {
+		mc := module.ModuleCalls[name]
+		if mc == nil {
+			missing = append(missing, name)
+			continue
+		}
+		// See https://developer.hashicorp.com/terraform/language/modules/sources#local-paths
+		if !strings.HasPrefix(mc.Source, "./") && !strings.HasPrefix(mc.Source, "../") {
+			delete(meta.templateModuleDirs, name)
+			return fmt.Errorf("module %q is not called from a local path, whose config can't be generated", name)
+		}
+		meta.templateModuleDirs[name] = filepath.Join(meta.outdir, mc.Source)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if !meta.createMissingModules {
+		for _, name := range missing {
+			delete(meta.templateModuleDirs, name)
+		}
+		return fmt.Errorf("no module %s invoked by the root module", strings.Join(missing, ", "))
+	}
+
+	calls := hclwrite.NewEmptyFile()
+	for _, name := range missing {
+		dir := filepath.Join(meta.outdir, SplitModulesDirName, name)
+		// #nosec G301
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("creating directory %s: %v", dir, err)
+		}
+		path := filepath.Join(dir, "terraform.tf")
+		// #nosec G306
+		if err := os.WriteFile(path, hclwrite.Format([]byte(meta.buildTerraformConfigForImportDir())), 0644); err != nil {
+			return fmt.Errorf("writing %s: %v", path, err)
+		}
+		meta.templateModuleDirs[name] = dir
+
+		calls.Body().AppendNewline()
+		calls.Body().AppendNewBlock("module", []string{name}).Body().SetAttributeValue("source", cty.StringVal("./"+SplitModulesDirName+"/"+name))
+	}
+	if err := appendToFile(filepath.Join(meta.outdir, meta.outputFileNames.MainFileName), string(hclwrite.Format(calls.Bytes()))); err != nil {
+		return fmt.Errorf("generating the module calls: %v", err)
+	}
+	return nil
+}
+
+// generateTemplateModuleConfig appends the configs to the main config files of their child modules of the module template.
+func (meta baseMeta) generateTemplateModuleConfig(cfgs ConfigInfos) error {
+	var names []string
+	bufs := map[string]*strings.Builder{}
+	for _, cfg := range cfgs {
+		name := meta.splitModule(cfg.ImportItem)
+		if bufs[name] == nil {
+			bufs[name] = &strings.Builder{}
+			names = append(names, name)
+		}
+		if _, err := cfg.DumpHCL(bufs[name]); err != nil {
+			return err
+		}
+		bufs[name].WriteString("\n")
+	}
+	for _, name := range names {
+		dir, ok := meta.templateModuleDirs[name]
+		if !ok || dir == "" {
+			return fmt.Errorf("the directory of module %q is not resolved", name)
+		}
+		if err := appendToFile(filepath.Join(dir, meta.outputFileNames.MainFileName), bufs[name].String()); err != nil {
+			return fmt.Errorf("generating main configuration file of module %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModuleTemplate(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		expect string
+		err    string
+	}{
+		{
+			name:   "resource group",
+			input:  "module.rg_{resource_group}",
+			expect: "rg_{resource_group}",
+		},
+		{
+			name:   "multiple placeholders",
+			input:  "module.{resource_group}_{type}",
+			expect: "{resource_group}_{type}",
+		},
+		{
+			name:   "static",
+			input:  "module.network",
+			expect: "network",
+		},
+		{
+			name:  "no module prefix",
+			input: "rg_{resource_group}",
+			err:   `"rg_{resource_group}" doesn't start with "module."`,
+		},
+		{
+			name:  "nested module",
+			input: "module.rg.module.{type}",
+			err:   `"module.rg.module.{type}" is not a child module of the root module`,
+		},
+		{
+			name:  "unknown placeholder",
+			input: "module.{location}",
+			err:   `unknown placeholder {location} in "module.{location}"`,
+		},
+		{
+			name:  "invalid name",
+			input: "module.rg {resource_group}",
+			err:   `"module.rg {resource_group}" is not a valid module address`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := parseModuleTemplate(tt.input)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, actual)
+		})
+	}
+}
+
+func TestTemplateModule(t *testing.T) {
+	item := func(id, tfType string) ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return ImportItem{AzureResourceID: azureId, TFAddr: tfaddr.TFAddr{Type: tfType, Name: "res-0"}}
+	}
+	vnet := item("/subscriptions/123/resourceGroups/My.RG/providers/Microsoft.Network/virtualNetworks/vnet", "azurerm_virtual_network")
+	sub := item("/subscriptions/123/providers/Microsoft.Authorization/roleAssignments/ra", "azurerm_role_assignment")
+
+	meta := baseMeta{moduleTemplate: "rg_{resource_group}"}
+	require.True(t, meta.splitsModules())
+	require.Equal(t, "rg_my_rg", meta.splitModule(vnet))
+	require.Equal(t, "rg_subscription", meta.splitModule(sub))
+	require.Equal(t, "module.rg_my_rg.azurerm_virtual_network.res-0", meta.resourceAddr(vnet))
+
+	meta.moduleTemplate = "{resource_group}_{type}"
+	require.Equal(t, "my_rg_azurerm_virtual_network", meta.splitModule(vnet))
+
+	meta.moduleTemplate = "{resource_group}"
+	require.Equal(t, "_1rg", meta.splitModule(item("/subscriptions/123/resourceGroups/1rg", "azurerm_resource_group")))
+}
+
+func TestResolveTemplateModules(t *testing.T) {
+	item := func(id string) *ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return &ImportItem{AzureResourceID: azureId, TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}}
+	}
+	items := []*ImportItem{
+		item("/subscriptions/123/resourceGroups/rg1"),
+		item("/subscriptions/123/resourceGroups/rg2"),
+	}
+	newMeta := func(t *testing.T, createMissingModules bool) baseMeta {
+		outdir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(outdir, "main.tf"), []byte(`module "rg_rg1" {
+  source = "./rg1"
+}
+
+module "rg_remote" {
+  source = "Azure/avm-res-resources-resourcegroup/azurerm"
+}
+`), 0644))
+		return baseMeta{
+			outdir:               outdir,
+			outputFileNames:      config.OutputFileNames{MainFileName: "main.tf"},
+			providerName:         ProviderAzureRM,
+			providerVersion:      "3.0.0",
+			moduleTemplate:       "rg_{resource_group}",
+			createMissingModules: createMissingModules,
+			templateModuleDirs:   map[string]string{},
+		}
+	}
+
+	t.Run("missing module", func(t *testing.T) {
+		meta := newMeta(t, false)
+		require.EqualError(t, meta.resolveTemplateModules(items), `no module rg_rg2 invoked by the root module`)
+	})
+
+	t.Run("remote module", func(t *testing.T) {
+		meta := newMeta(t, false)
+		require.EqualError(t, meta.resolveTemplateModules([]*ImportItem{item("/subscriptions/123/resourceGroups/remote")}), `module "rg_remote" is not called from a local path, whose config can't be generated`)
+	})
+
+	t.Run("create missing modules", func(t *testing.T) {
+		meta := newMeta(t, true)
+		require.NoError(t, meta.resolveTemplateModules(items))
+		require.Equal(t, map[string]string{
+			"rg_rg1": filepath.Join(meta.outdir, "rg1"),
+			"rg_rg2": filepath.Join(meta.outdir, "modules", "rg_rg2"),
+		}, meta.templateModuleDirs)
+
+		b, err := os.ReadFile(filepath.Join(meta.outdir, "main.tf"))
+		require.NoError(t, err)
+		require.Contains(t, string(b), `
+module "rg_rg2" {
+  source = "./modules/rg_rg2"
+}
+`)
+		tb, err := os.ReadFile(filepath.Join(meta.outdir, "modules", "rg_rg2", "terraform.tf"))
+		require.NoError(t, err)
+		require.Contains(t, string(tb), `source  = "hashicorp/azurerm"`)
+
+		// The resolved modules are not created again
+		require.NoError(t, meta.resolveTemplateModules(items))
+		b2, err := os.ReadFile(filepath.Join(meta.outdir, "main.tf"))
+		require.NoError(t, err)
+		require.Equal(t, string(b), string(b2))
+	})
+}
+
+func TestGenerateTemplateModuleConfig(t *testing.T) {
+	const (
+		rgId  = "/subscriptions/123/resourceGroups/rg1"
+		nicId = "/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/networkInterfaces/nic"
+	)
+	outdir := t.TempDir()
+	meta := baseMeta{
+		outdir:            outdir,
+		outputFileNames:   config.OutputFileNames{MainFileName: "main.tf"},
+		moduleTemplate:    "rg_{resource_group}",
+		rewriteReferences: true,
+		templateModuleDirs: map[string]string{
+			"rg_rg1": filepath.Join(outdir, "rg1"),
+			"rg_rg2": filepath.Join(outdir, "rg2"),
+		},
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(outdir, "rg1"), 0750))
+	require.NoError(t, os.Mkdir(filepath.Join(outdir, "rg2"), 0750))
+
+	cfg := func(id, tfType, name, input string) ConfigInfo {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		f, diags := hclwrite.ParseConfig([]byte(input), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return ConfigInfo{
+			ImportItem: ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}},
+			hcl:        f,
+		}
+	}
+	configs := ConfigInfos{
+		cfg(rgId, "azurerm_resource_group", "res-0", `resource "azurerm_resource_group" "res-0" {
+  name = "rg1"
+}
+`),
+		cfg(nicId, "azurerm_network_interface", "res-1", `resource "azurerm_network_interface" "res-1" {
+  resource_group_id = "`+rgId+`"
+}
+`),
+	}
+	configs, err := meta.referenceAddon(configs)
+	require.NoError(t, err)
+	require.NoError(t, meta.generateTemplateModuleConfig(configs))
+
+	read := func(path string) string {
+		b, err := os.ReadFile(filepath.Join(outdir, path))
+		require.NoError(t, err)
+		return string(b)
+	}
+	require.Equal(t, `resource "azurerm_resource_group" "res-0" {
+  name = "rg1"
+}
+
+`, read(filepath.Join("rg1", "main.tf")))
+	// The reference across the modules is kept as the literal id
+	require.Equal(t, `resource "azurerm_network_interface" "res-1" {
+  resource_group_id = "`+rgId+`"
+}
+
+`, read(filepath.Join("rg2", "main.tf")))
+
+	meta.templateModuleDirs = map[string]string{}
+	require.EqualError(t, meta.generateTemplateModuleConfig(configs), `the directory of module "rg_rg1" is not resolved`)
+}
@@ -0,0 +1,154 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/magodo/armid"
+	"github.com/magodo/workerpool"
+)
+
+// The monitoring resource types that can be exported along with the resources they monitor.
+const (
+	MonitoringResourceTypeDiagnosticSetting = "Microsoft.Insights/diagnosticSettings"
+	MonitoringResourceTypeMetricAlert       = "Microsoft.Insights/metricAlerts"
+	MonitoringResourceTypeActivityLogAlert  = "Microsoft.Insights/activityLogAlerts"
+)
+
+// monitoringResourceAPIVersions are the API versions to list the monitoring resources.
+var monitoringResourceAPIVersions = map[string]string{
+	MonitoringResourceTypeDiagnosticSetting: "2021-05-01-preview",
+	MonitoringResourceTypeMetricAlert:       "2018-03-01",
+	MonitoringResourceTypeActivityLogAlert:  "2020-10-01",
+}
+
+// monitoringAlert is an alert rule (e.g. a metric alert), which is scoped to the resources by its "scopes" property.
+type monitoringAlert struct {
+	id     string
+	scopes []string
+}
+
+// populateMonitoringResources adds the monitoring resources (i.e. the diagnostic settings, the metric alerts and the activity log alerts) of the resources of the resource set.
+// The diagnostic settings are extension resources, which are listed per resource. While the alerts can reside in any resource group, which are listed per subscription
+// and matched by their scopes.
+func (meta baseMeta) populateMonitoringResources(ctx context.Context, rset *resourceset.AzureResourceSet) error {
+	if !meta.includeMonitoring {
+		return nil
+	}
+	client, err := arm.NewClient("meta.Client", "v0.0.0", meta.azureSDKCred, &meta.azureSDKClientOpt)
+	if err != nil {
+		return fmt.Errorf("building the client: %v", err)
+	}
+
+	// Not all the resource types support the diagnostic settings, the failures of listing are ignored.
+	var (
+		mu             sync.Mutex
+		diagSettingIds []string
+	)
+	wp := workerpool.NewWorkPool(meta.parallelism)
+	wp.Run(nil)
+	for _, res := range rset.Resources {
+		id := res.Id.String()
+		wp.AddTask(func() (interface{}, error) {
+			ids, err := listResourceIds(ctx, client, id+"/providers/"+MonitoringResourceTypeDiagnosticSetting, monitoringResourceAPIVersions[MonitoringResourceTypeDiagnosticSetting])
+			if err != nil {
+				log.Printf("[DEBUG] Skipping the diagnostic settings of %s: %v", id, err)
+				return nil, nil
+			}
+			mu.Lock()
+			diagSettingIds = append(diagSettingIds, ids...)
+			mu.Unlock()
+			return nil, nil
+		})
+	}
+	// #nosec G104
+	wp.Done()
+
+	var alerts []monitoringAlert
+	for _, rt := range []string{MonitoringResourceTypeMetricAlert, MonitoringResourceTypeActivityLogAlert} {
+		for _, subscriptionId := range append([]string{meta.subscriptionId}, meta.aliasSubscriptionIds...) {
+			l, err := listMonitoringAlerts(ctx, client, fmt.Sprintf("/subscriptions/%s/providers/%s", subscriptionId, rt), monitoringResourceAPIVersions[rt])
+			if err != nil {
+				return fmt.Errorf("listing %s in subscription %s: %v", rt, subscriptionId, err)
+			}
+			alerts = append(alerts, l...)
+		}
+	}
+	rset.Resources = append(rset.Resources, scopedMonitoringResources(rset.Resources, diagSettingIds, alerts)...)
+	return nil
+}
+
+// scopedMonitoringResources returns the diagnostic settings, and the alerts that are scoped to any of the resources, which are not among them.
+func scopedMonitoringResources(resources []resourceset.AzureResource, diagSettingIds []string, alerts []monitoringAlert) []resourceset.AzureResource {
+	set := map[string]bool{}
+	for _, res := range resources {
+		set[strings.ToUpper(res.Id.String())] = true
+	}
+	ids := diagSettingIds
+	for _, alert := range alerts {
+		for _, scope := range alert.scopes {
+			if set[strings.ToUpper(scope)] {
+				ids = append(ids, alert.id)
+				break
+			}
+		}
+	}
+	var out []resourceset.AzureResource
+	for _, id := range ids {
+		uid := strings.ToUpper(id)
+		if set[uid] {
+			continue
+		}
+		azureId, err := armid.ParseResourceId(id)
+		if err != nil {
+			log.Printf("[WARN] Failed to parse the monitoring resource id %q: %v", id, err)
+			continue
+		}
+		set[uid] = true
+		out = append(out, resourceset.AzureResource{Id: azureId})
+	}
+	return out
+}
+
+// listMonitoringAlerts lists the alerts in the collection of the path (e.g. "/subscriptions/xxx/providers/Microsoft.Insights/metricAlerts"), following the next links.
+func listMonitoringAlerts(ctx context.Context, client *arm.Client, path, apiVersion string) ([]monitoringAlert, error) {
+	var alerts []monitoringAlert
+	next := fmt.Sprintf("%s%s?api-version=%s", strings.TrimSuffix(client.Endpoint(), "/"), path, apiVersion)
+	for next != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, next)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Pipeline().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+		var page struct {
+			Value []struct {
+				Id         string `json:"id"`
+				Properties struct {
+					Scopes []string `json:"scopes"`
+				} `json:"properties"`
+			} `json:"value"`
+			NextLink string `json:"nextLink"`
+		}
+		if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+			return nil, err
+		}
+		for _, v := range page.Value {
+			alerts = append(alerts, monitoringAlert{id: v.Id, scopes: v.Properties.Scopes})
+		}
+		next = page.NextLink
+	}
+	return alerts, nil
+}
@@ -0,0 +1,60 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedMonitoringResources(t *testing.T) {
+	var resources []resourceset.AzureResource
+	for _, id := range []string{
+		"/subscriptions/123/resourceGroups/rg1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Insights/metricAlerts/listed",
+	} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		resources = append(resources, resourceset.AzureResource{Id: azureId})
+	}
+	diagSettingIds := []string{
+		"/subscriptions/123/resourceGroups/rg1/providers/microsoft.keyvault/vaults/kv1/providers/microsoft.insights/diagnosticSettings/ds1",
+	}
+	alerts := []monitoringAlert{
+		// Scoped to the listed resource, in another resource group
+		{
+			id:     "/subscriptions/123/resourceGroups/monitoring/providers/Microsoft.Insights/metricAlerts/ma1",
+			scopes: []string{"/subscriptions/123/resourceGroups/RG1/providers/Microsoft.KeyVault/vaults/kv1"},
+		},
+		// Scoped to the listed resource group, among others
+		{
+			id:     "/subscriptions/123/resourceGroups/monitoring/providers/Microsoft.Insights/activityLogAlerts/ala1",
+			scopes: []string{"/subscriptions/123/resourceGroups/rg2", "/subscriptions/123/resourceGroups/rg1"},
+		},
+		// Already listed
+		{
+			id:     "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Insights/metricAlerts/listed",
+			scopes: []string{"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1"},
+		},
+		// Scoped to the resources that are not listed
+		{
+			id:     "/subscriptions/123/resourceGroups/monitoring/providers/Microsoft.Insights/metricAlerts/ma2",
+			scopes: []string{"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv2"},
+		},
+		{
+			id:     "/subscriptions/123/resourceGroups/monitoring/providers/Microsoft.Insights/activityLogAlerts/ala2",
+			scopes: []string{"/subscriptions/123"},
+		},
+	}
+	var actual []string
+	for _, res := range scopedMonitoringResources(resources, diagSettingIds, alerts) {
+		actual = append(actual, res.Id.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg1/providers/microsoft.keyvault/vaults/kv1/providers/microsoft.insights/diagnosticSettings/ds1",
+		"/subscriptions/123/resourceGroups/monitoring/providers/Microsoft.Insights/metricAlerts/ma1",
+		"/subscriptions/123/resourceGroups/monitoring/providers/Microsoft.Insights/activityLogAlerts/ala1",
+	}, actual)
+}
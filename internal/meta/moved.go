@@ -0,0 +1,132 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// movedBlock moves the resource in the former state to the address of the exported resource.
+type movedBlock struct {
+	from string
+	to   string
+}
+
+// stateAddressType returns the resource type of the resource (instance) address (e.g. "module.mod.azurerm_resource_group.rg[0]").
+func stateAddressType(addr string) string {
+	if i := strings.Index(addr, "["); i != -1 {
+		addr = addr[:i]
+	}
+	segs := strings.Split(addr, ".")
+	if len(segs) < 2 {
+		return ""
+	}
+	return segs[len(segs)-2]
+}
+
+// stateResourceAddress returns the resource address of the resource instance address, i.e. without the instance key.
+func stateResourceAddress(addr string) string {
+	if i := strings.Index(addr, "["); i != -1 {
+		return addr[:i]
+	}
+	return addr
+}
+
+// refactorBlocks matches the resources in the former state with the exported resources by their TF resource ids. It returns:
+//   - The moved blocks of the resources that are exported at different addresses as the same resource types
+//   - The resource addresses to remove from the former state, whose resources are either not exported, or exported as different resource types
+func refactorBlocks(former []PrunedResource, l ImportList, addr func(ImportItem) string) ([]movedBlock, []string) {
+	exported := map[string]ImportItem{}
+	for _, item := range l {
+		if item.Skip() {
+			continue
+		}
+		exported[strings.ToUpper(item.TFResourceId)] = item
+	}
+
+	var moved []movedBlock
+	removedSet := map[string]bool{}
+	for _, res := range former {
+		item, ok := exported[strings.ToUpper(res.ResourceId)]
+		if !ok || stateAddressType(res.Address) != item.TFAddr.Type {
+			removedSet[stateResourceAddress(res.Address)] = true
+			continue
+		}
+		if to := addr(item); to != res.Address {
+			moved = append(moved, movedBlock{from: res.Address, to: to})
+		}
+	}
+	sort.Slice(moved, func(i, j int) bool {
+		return moved[i].from < moved[j].from
+	})
+	var removed []string
+	for addr := range removedSet {
+		removed = append(removed, addr)
+	}
+	sort.Strings(removed)
+	return moved, removed
+}
+
+// movedBlocksFile builds the moved blocks, and the removed blocks that only forget the resources, rather than destroying them.
+func movedBlocksFile(moved []movedBlock, removed []string) (*hclwrite.File, error) {
+	traversal := func(addr string) (hcl.Traversal, error) {
+		t, diags := hclsyntax.ParseTraversalAbs([]byte(addr), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing the address %q: %v", addr, diags.Error())
+		}
+		return t, nil
+	}
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	for _, mv := range moved {
+		from, err := traversal(mv.from)
+		if err != nil {
+			return nil, err
+		}
+		to, err := traversal(mv.to)
+		if err != nil {
+			return nil, err
+		}
+		blk := body.AppendNewBlock("moved", nil).Body()
+		blk.SetAttributeTraversal("from", from)
+		blk.SetAttributeTraversal("to", to)
+		body.AppendNewline()
+	}
+	for _, addr := range removed {
+		from, err := traversal(addr)
+		if err != nil {
+			return nil, err
+		}
+		blk := body.AppendNewBlock("removed", nil).Body()
+		blk.SetAttributeTraversal("from", from)
+		blk.AppendNewBlock("lifecycle", nil).Body().SetAttributeValue("destroy", cty.False)
+		body.AppendNewline()
+	}
+	return f, nil
+}
+
+// writeMovedBlocks writes the moved and removed blocks, that refactor the former state to the exported resources, to the MovedBlocksFileName.
+func (meta baseMeta) writeMovedBlocks(l ImportList) error {
+	// #nosec G304
+	state, err := os.ReadFile(meta.movedFromState)
+	if err != nil {
+		return fmt.Errorf("reading the former state: %v", err)
+	}
+	moved, removed := refactorBlocks(stateResources(state), l, meta.resourceAddr)
+	f, err := movedBlocksFile(moved, removed)
+	if err != nil {
+		return err
+	}
+	// #nosec G306
+	if err := os.WriteFile(filepath.Join(meta.outdir, MovedBlocksFileName), f.Bytes(), 0644); err != nil {
+		return fmt.Errorf("writing the moved blocks: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefactorBlocks(t *testing.T) {
+	item := func(id, tfType, name string) ImportItem {
+		return ImportItem{TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}}
+	}
+	l := ImportList{
+		item("/subscriptions/123/resourceGroups/rg1", "azurerm_resource_group", "rg1"),
+		item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1", "azurerm_virtual_network", "vnet1"),
+		item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1", "azurerm_windows_virtual_machine", "vm1"),
+		item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/publicIPAddresses/pip1", "azurerm_public_ip", "pip1"),
+		item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/bars/bar1", "", ""),
+	}
+	former := []PrunedResource{
+		// Moved to the new name
+		{Address: "azurerm_resource_group.res-0", ResourceId: "/subscriptions/123/resourceGroups/RG1"},
+		// Moved from the child module with the instance key
+		{Address: `module.network.azurerm_virtual_network.res[0]`, ResourceId: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"},
+		// Exported as a different resource type
+		{Address: "azurerm_linux_virtual_machine.res-2", ResourceId: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Compute/virtualMachines/vm1"},
+		// Unchanged
+		{Address: "azurerm_public_ip.pip1", ResourceId: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/publicIPAddresses/pip1"},
+		// Not exported (e.g. skipped or out of the scope)
+		{Address: `module.network.azurerm_subnet.res["a"]`, ResourceId: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/a"},
+		{Address: `module.network.azurerm_subnet.res["b"]`, ResourceId: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/b"},
+		{Address: "azurerm_resource.res-4", ResourceId: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/bars/bar1"},
+	}
+	moved, removed := refactorBlocks(former, l, func(item ImportItem) string { return item.TFAddr.String() })
+	require.Equal(t, []movedBlock{
+		{from: "azurerm_resource_group.res-0", to: "azurerm_resource_group.rg1"},
+		{from: "module.network.azurerm_virtual_network.res[0]", to: "azurerm_virtual_network.vnet1"},
+	}, moved)
+	require.Equal(t, []string{
+		"azurerm_linux_virtual_machine.res-2",
+		"azurerm_resource.res-4",
+		"module.network.azurerm_subnet.res",
+	}, removed)
+
+	f, err := movedBlocksFile(moved, removed[:1])
+	require.NoError(t, err)
+	require.Equal(t, `moved {
+  from = azurerm_resource_group.res-0
+  to   = azurerm_resource_group.rg1
+}
+
+moved {
+  from = module.network.azurerm_virtual_network.res[0]
+  to   = azurerm_virtual_network.vnet1
+}
+
+removed {
+  from = azurerm_linux_virtual_machine.res-2
+  lifecycle {
+    destroy = false
+  }
+}
+
+`, string(f.Bytes()))
+}
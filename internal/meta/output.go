@@ -0,0 +1,254 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/tfadd/providers/azurerm"
+	tfpluginschema "github.com/magodo/tfpluginschema/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// defaultOutputAttributes are the attributes that are commonly needed by the consumers of the exported resources, i.e. the resource ids,
+// the principal ids of the managed identities, and the FQDNs/endpoints. Besides the "id", they are only output for the resource types
+// whose schemas have them as computed and non-sensitive attributes.
+var defaultOutputAttributes = []string{
+	"id",
+	"principal_id",
+	"client_id",
+	"identity[0].principal_id",
+	"fqdn",
+	"private_fqdn",
+	"default_hostname",
+	"hostname",
+	"login_server",
+	"vault_uri",
+	"primary_blob_endpoint",
+	"primary_web_endpoint",
+	"endpoint",
+	"uri",
+	"ip_address",
+}
+
+// outputRule customizes the outputs of the resource type. The rule without a resource type applies to all the resource types.
+type outputRule struct {
+	ResourceType string `json:"resource_type,omitempty"`
+	// Include are the attribute paths (e.g. "primary_access_key", "identity[0].tenant_id") that are output besides the default ones.
+	Include []string `json:"include,omitempty"`
+	// Exclude are the attribute paths that are not output, including the default ones (e.g. "id").
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+type outputRules []outputRule
+
+// loadOutputRules loads the output rules file, which is a JSON array of outputRule.
+func loadOutputRules(path string) (outputRules, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the output rules file %s: %v", path, err)
+	}
+	var rules outputRules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("unmarshalling the output rules file %s: %v", path, err)
+	}
+	for i, rule := range rules {
+		if len(rule.Include) == 0 && len(rule.Exclude) == 0 {
+			return nil, fmt.Errorf("the %d-th output rule has nothing to include or exclude", i)
+		}
+		for _, path := range append(append([]string{}, rule.Include...), rule.Exclude...) {
+			if _, err := parseAttributePath(path); err != nil {
+				return nil, fmt.Errorf("the %d-th output rule has an invalid attribute path %q", i, path)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// attributes returns the attribute paths to output for the resource type, in the order of the default ones and then the included ones, deduplicated.
+// The default ones that are absent from the schema (if known) are dropped, while the included ones are kept as long as the schema is unknown (e.g. azapi_resource).
+func (rules outputRules) attributes(resourceType string) []string {
+	excluded := map[string]bool{}
+	var includes []string
+	for _, rule := range rules {
+		if rule.ResourceType != "" && rule.ResourceType != resourceType {
+			continue
+		}
+		for _, path := range rule.Exclude {
+			excluded[path] = true
+		}
+		includes = append(includes, rule.Include...)
+	}
+
+	var block *tfpluginschema.Block
+	if sch, ok := azurerm.ProviderSchemaInfo.ResourceSchemas[resourceType]; ok {
+		block = sch.Block
+	}
+
+	var out []string
+	seen := map[string]bool{}
+	add := func(path string) {
+		if !seen[path] && !excluded[path] {
+			seen[path] = true
+			out = append(out, path)
+		}
+	}
+	for _, path := range defaultOutputAttributes {
+		if path == "id" {
+			add(path)
+			continue
+		}
+		if attr := schemaAttribute(block, path); attr != nil && attr.Computed && !attr.Sensitive {
+			add(path)
+		}
+	}
+	for _, path := range includes {
+		if block != nil && path != "id" && schemaAttribute(block, path) == nil {
+			log.Printf("[WARN] The output attribute %q is not an attribute of %s, skipped", path, resourceType)
+			continue
+		}
+		add(path)
+	}
+	return out
+}
+
+// writeOutputs writes the outputs of the imported resources to the module directory.
+func (meta baseMeta) writeOutputs(l ImportList) error {
+	b := outputsConfig(l.Imported(), meta.outputRules)
+	if b == nil {
+		return nil
+	}
+	path := filepath.Join(meta.moduleDir, OutputsFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing the outputs to %s: %v", path, err)
+	}
+	return nil
+}
+
+// outputsConfig builds the output blocks of the resources, which are named by the TF resource name and the attribute path (e.g. "res-0_principal_id").
+// The resource names that are shared by more than one resource types are prefixed by the resource type. It returns nil if there is nothing to output.
+func outputsConfig(l ImportList, rules outputRules) []byte {
+	types := map[string]map[string]bool{}
+	for _, item := range l {
+		if types[item.TFAddr.Name] == nil {
+			types[item.TFAddr.Name] = map[string]bool{}
+		}
+		types[item.TFAddr.Name][item.TFAddr.Type] = true
+	}
+
+	f := hclwrite.NewEmptyFile()
+	var n int
+	for _, item := range l {
+		prefix := item.TFAddr.Name
+		if len(types[item.TFAddr.Name]) > 1 {
+			prefix = item.TFAddr.Type + "_" + item.TFAddr.Name
+		}
+		var block *tfpluginschema.Block
+		if sch, ok := azurerm.ProviderSchemaInfo.ResourceSchemas[item.TFAddr.Type]; ok {
+			block = sch.Block
+		}
+		for _, path := range rules.attributes(item.TFAddr.Type) {
+			// The path is validated by the rules.
+			traversal, _ := parseAttributePath(path)
+
+			if n != 0 {
+				f.Body().AppendNewline()
+			}
+			n++
+			body := f.Body().AppendNewBlock("output", []string{prefix + "_" + outputNameSuffix(traversal)}).Body()
+			body.SetAttributeValue("description", cty.StringVal(fmt.Sprintf("The %s of %s", path, item.TFAddr)))
+			value := hcl.Traversal{
+				hcl.TraverseRoot{Name: item.TFAddr.Type},
+				hcl.TraverseAttr{Name: item.TFAddr.Name},
+				hcl.TraverseAttr{Name: traversal.RootName()},
+			}
+			value = append(value, traversal[1:]...)
+			// The nested attributes are absent if their blocks (e.g. the "identity") are not configured.
+			if len(traversal) > 1 {
+				body.SetAttributeRaw("value", hclwrite.TokensForFunctionCall("try", hclwrite.TokensForTraversal(value), hclwrite.TokensForValue(cty.NullVal(cty.DynamicPseudoType))))
+			} else {
+				body.SetAttributeTraversal("value", value)
+			}
+			if attr := schemaAttribute(block, path); attr != nil && attr.Sensitive {
+				body.SetAttributeValue("sensitive", cty.True)
+			}
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	return hclwrite.Format(f.Bytes())
+}
+
+// parseAttributePath parses the attribute path (e.g. "identity[0].principal_id") as a relative traversal, which starts with a name.
+func parseAttributePath(path string) (hcl.Traversal, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty attribute path")
+	}
+	traversal, diags := hclsyntax.ParseTraversalAbs([]byte(path), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return traversal, nil
+}
+
+var outputNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// outputNameSuffix returns the output name suffix of the attribute path, which joins its names and string keys (i.e. without the numeric indexes) by "_".
+func outputNameSuffix(traversal hcl.Traversal) string {
+	var names []string
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			names = append(names, step.Name)
+		case hcl.TraverseAttr:
+			names = append(names, step.Name)
+		case hcl.TraverseIndex:
+			if step.Key.Type() == cty.String {
+				names = append(names, outputNameInvalidChars.ReplaceAllString(step.Key.AsString(), "_"))
+			}
+		}
+	}
+	return strings.Join(names, "_")
+}
+
+// schemaAttribute returns the schema of the attribute path in the block, which is nil if the path is not an attribute of the block (or the block is nil).
+func schemaAttribute(block *tfpluginschema.Block, path string) *tfpluginschema.Attribute {
+	traversal, err := parseAttributePath(path)
+	if err != nil {
+		return nil
+	}
+	for _, step := range traversal {
+		if block == nil {
+			return nil
+		}
+		var name string
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			name = step.Name
+		case hcl.TraverseAttr:
+			name = step.Name
+		default:
+			continue
+		}
+		if attr, ok := block.Attributes[name]; ok {
+			// The rest of the path indexes into the attribute (e.g. a map), which shares the attribute's schema.
+			return attr
+		}
+		nb, ok := block.NestedBlocks[name]
+		if !ok {
+			return nil
+		}
+		block = nb.Block
+	}
+	return nil
+}
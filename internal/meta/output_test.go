@@ -0,0 +1,90 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputsConfig(t *testing.T) {
+	item := func(rt, name string) ImportItem {
+		return ImportItem{TFAddr: tfaddr.TFAddr{Type: rt, Name: name}}
+	}
+
+	require.Equal(t, `output "res-0_id" {
+  description = "The id of azurerm_linux_web_app.res-0"
+  value       = azurerm_linux_web_app.res-0.id
+}
+
+output "res-0_identity_principal_id" {
+  description = "The identity[0].principal_id of azurerm_linux_web_app.res-0"
+  value       = try(azurerm_linux_web_app.res-0.identity[0].principal_id, null)
+}
+
+output "res-0_default_hostname" {
+  description = "The default_hostname of azurerm_linux_web_app.res-0"
+  value       = azurerm_linux_web_app.res-0.default_hostname
+}
+`, string(outputsConfig(ImportList{item("azurerm_linux_web_app", "res-0")}, nil)))
+
+	rules := outputRules{
+		{ResourceType: "azurerm_storage_account", Include: []string{"primary_access_key", "not_exist"}, Exclude: []string{"primary_web_endpoint"}},
+		{Exclude: []string{"identity[0].principal_id"}},
+	}
+	out := string(outputsConfig(ImportList{
+		item("azurerm_storage_account", "res-0"),
+		item("azurerm_user_assigned_identity", "res-1"),
+		item("azurerm_resource_group", "res-1"),
+	}, rules))
+	require.Contains(t, out, `output "res-0_primary_blob_endpoint" {`)
+	require.NotContains(t, out, "primary_web_endpoint")
+	require.NotContains(t, out, "not_exist")
+	require.NotContains(t, out, "identity[0]")
+	// The sensitive attributes are output as sensitive
+	require.Contains(t, out, `  value       = azurerm_storage_account.res-0.primary_access_key
+  sensitive   = true
+`)
+	// The resource names that are shared by more than one resource types are prefixed by the resource type
+	require.Contains(t, out, `output "azurerm_user_assigned_identity_res-1_principal_id" {`)
+	require.Contains(t, out, `output "azurerm_resource_group_res-1_id" {`)
+
+	require.Nil(t, outputsConfig(ImportList{item("azurerm_resource_group", "res-0")}, outputRules{{Exclude: []string{"id"}}}))
+}
+
+func TestLoadOutputRules(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "valid",
+			content: `[{"resource_type": "azurerm_storage_account", "include": ["primary_access_key", "identity[0].tenant_id"]}, {"exclude": ["id"]}]`,
+		},
+		{
+			name:    "nothing to include or exclude",
+			content: `[{"resource_type": "azurerm_storage_account"}]`,
+			err:     "the 0-th output rule has nothing to include or exclude",
+		},
+		{
+			name:    "invalid attribute path",
+			content: `[{"include": ["identity["]}]`,
+			err:     `the 0-th output rule has an invalid attribute path "identity["`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.json")
+			require.NoError(t, os.WriteFile(path, []byte(c.content), 0644))
+			_, err := loadOutputRules(path)
+			if c.err != "" {
+				require.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
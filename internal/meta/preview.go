@@ -0,0 +1,46 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// PreviewCfg generates the TF config of the item without changing the state of the output directory, so that the resource type can be verified before importing.
+// The item is imported to the first import directory, whose state is removed afterwards. The config is the one converted from the state, prior to the transformations
+// that involve the other resources (e.g. the references, the dependencies).
+func (meta *baseMeta) PreviewCfg(ctx context.Context, item ImportItem) (_ []byte, err error) {
+	defer meta.hookError(&err)
+	if item.Skip() {
+		return nil, fmt.Errorf("%s is skipped", item.TFResourceId)
+	}
+	item.TFResourceId = meta.importId(item)
+
+	// The preview is converted from the state of the import directory, in the same way as importing with the import blocks.
+	pm := *meta
+	pm.useImportBlocks = true
+	if pm.tfclient != nil {
+		pm.importItem_notf(ctx, &item, 0)
+	} else {
+		if len(pm.importBaseDirs) == 0 {
+			return nil, fmt.Errorf("the import directories are not initialized")
+		}
+		defer os.Remove(filepath.Join(pm.importBaseDirs[0], "terraform.tfstate"))
+		pm.importItem_tf(ctx, &item, 0)
+	}
+	if item.ImportError != nil {
+		return nil, fmt.Errorf("importing %s: %v", item.TFResourceId, item.ImportError)
+	}
+
+	cfgs, err := pm.stateToConfig(ctx, ImportList{item})
+	if err != nil {
+		return nil, fmt.Errorf("converting from state to configurations: %w", err)
+	}
+	if len(cfgs) != 1 {
+		return nil, fmt.Errorf("expect 1 config generated, got=%d", len(cfgs))
+	}
+	return hclwrite.Format(cfgs[0].hcl.Bytes()), nil
+}
@@ -0,0 +1,141 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// propertyRule includes or excludes the properties of the generated config of the resource type. The rule without a resource type applies to all the resource types.
+type propertyRule struct {
+	ResourceType string `json:"resource_type,omitempty"`
+	// Include are the top level properties (i.e. attributes or blocks, e.g. "identity") that are always included, even if they are omitted by the tuned config.
+	Include []string `json:"include,omitempty"`
+	// Exclude are the property paths (e.g. "tags", "site_config.always_on") that are always excluded, which take precedence over the included ones.
+	Exclude []string `json:"exclude,omitempty"`
+	// IgnoreChanges are the attributes (e.g. "tags", "default_node_pool[0].node_count") that are known to drift, which are added to the "ignore_changes" of the
+	// lifecycle block of the resource, so that the first plan after the export is clean.
+	IgnoreChanges []string `json:"ignore_changes,omitempty"`
+}
+
+type propertyRules []propertyRule
+
+// loadPropertyRules loads the property rules file, which is a JSON array of propertyRule.
+func loadPropertyRules(path string) (propertyRules, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the property rules file %s: %v", path, err)
+	}
+	var rules propertyRules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("unmarshalling the property rules file %s: %v", path, err)
+	}
+	for i, rule := range rules {
+		if len(rule.Include) == 0 && len(rule.Exclude) == 0 && len(rule.IgnoreChanges) == 0 {
+			return nil, fmt.Errorf("the %d-th property rule has nothing to include, exclude or ignore changes", i)
+		}
+		for _, name := range rule.Include {
+			if name == "" || strings.Contains(name, ".") {
+				return nil, fmt.Errorf("the %d-th property rule includes %q, which is not a top level property", i, name)
+			}
+		}
+		for _, path := range rule.Exclude {
+			if path == "" || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") {
+				return nil, fmt.Errorf("the %d-th property rule excludes an invalid property path %q", i, path)
+			}
+		}
+		for _, attr := range rule.IgnoreChanges {
+			if _, diags := hclsyntax.ParseTraversalAbs([]byte(attr), "", hcl.InitialPos); attr == "" || diags.HasErrors() {
+				return nil, fmt.Errorf("the %d-th property rule ignores the changes of an invalid attribute %q", i, attr)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// includes returns the properties that are always included for the resource type.
+func (rules propertyRules) includes(resourceType string) []string {
+	var out []string
+	for _, rule := range rules {
+		if rule.ResourceType == "" || rule.ResourceType == resourceType {
+			out = append(out, rule.Include...)
+		}
+	}
+	return out
+}
+
+// excludes returns the property paths that are always excluded for the resource type.
+func (rules propertyRules) excludes(resourceType string) []string {
+	var out []string
+	for _, rule := range rules {
+		if rule.ResourceType == "" || rule.ResourceType == resourceType {
+			out = append(out, rule.Exclude...)
+		}
+	}
+	return out
+}
+
+// ignoreChanges returns the attributes whose changes are ignored for the resource type, deduplicated.
+func (rules propertyRules) ignoreChanges(resourceType string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		if rule.ResourceType != "" && rule.ResourceType != resourceType {
+			continue
+		}
+		for _, attr := range rule.IgnoreChanges {
+			if !seen[attr] {
+				seen[attr] = true
+				out = append(out, attr)
+			}
+		}
+	}
+	return out
+}
+
+// apply applies the rules of the resource type to the resource block. The included properties that are absent are copied from the full config, if any.
+func (rules propertyRules) apply(body, fullBody *hclwrite.Body, resourceType string) {
+	if fullBody != nil {
+		for _, name := range rules.includes(resourceType) {
+			if body.GetAttribute(name) != nil || hasBlock(body, name) {
+				continue
+			}
+			hclBlockCopyAttribute(body, fullBody, name)
+		}
+	}
+	for _, path := range rules.excludes(resourceType) {
+		hclBlockRemovePath(body, strings.Split(path, "."))
+	}
+}
+
+func hasBlock(body *hclwrite.Body, typeName string) bool {
+	for _, blk := range body.Blocks() {
+		if blk.Type() == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+// hclBlockRemovePath removes the attribute, or the nested blocks, of the path (e.g. ["site_config", "always_on"]) from the body, which applies to all the nested blocks along the path.
+func hclBlockRemovePath(body *hclwrite.Body, path []string) {
+	if len(path) == 1 {
+		body.RemoveAttribute(path[0])
+	}
+	for _, blk := range body.Blocks() {
+		if blk.Type() != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			body.RemoveBlock(blk)
+			continue
+		}
+		hclBlockRemovePath(blk.Body(), path[1:])
+	}
+}
@@ -0,0 +1,123 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPropertyRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+  {"exclude": ["tags"]},
+  {"resource_type": "azurerm_linux_web_app", "include": ["identity"], "exclude": ["site_config.always_on"]}
+]`), 0644))
+	rules, err := loadPropertyRules(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"identity"}, rules.includes("azurerm_linux_web_app"))
+	require.Nil(t, rules.includes("azurerm_resource_group"))
+	require.Equal(t, []string{"tags", "site_config.always_on"}, rules.excludes("azurerm_linux_web_app"))
+	require.Equal(t, []string{"tags"}, rules.excludes("azurerm_resource_group"))
+
+	for input, errMsg := range map[string]string{
+		`[{"resource_type": "azurerm_resource_group"}]`: "the 0-th property rule has nothing to include, exclude or ignore changes",
+		`[{"include": ["site_config.always_on"]}]`:      `the 0-th property rule includes "site_config.always_on", which is not a top level property`,
+		`[{"exclude": ["site_config."]}]`:               `the 0-th property rule excludes an invalid property path "site_config."`,
+		`[{"ignore_changes": ["tags["]}]`:               `the 0-th property rule ignores the changes of an invalid attribute "tags["`,
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(input), 0644))
+		_, err := loadPropertyRules(path)
+		require.EqualError(t, err, errMsg)
+	}
+}
+
+func TestPropertyRulesApply(t *testing.T) {
+	parse := func(src string) *hclwrite.File {
+		f, diags := hclwrite.ParseConfig([]byte(src), "", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return f
+	}
+	f := parse(`resource "azurerm_linux_web_app" "test" {
+  name = "app"
+  tags = {
+    env = "prod"
+  }
+  site_config {
+    always_on     = true
+    http2_enabled = true
+  }
+}
+`)
+	full := parse(`resource "azurerm_linux_web_app" "test" {
+  enabled = true
+  name    = "app"
+  identity {
+    type = "SystemAssigned"
+  }
+}
+`)
+	rules := propertyRules{
+		{Exclude: []string{"tags"}},
+		{ResourceType: "azurerm_linux_web_app", Include: []string{"identity", "enabled", "name"}, Exclude: []string{"site_config.always_on"}},
+	}
+	rules.apply(f.Body().Blocks()[0].Body(), full.Body().Blocks()[0].Body(), "azurerm_linux_web_app")
+	require.Equal(t, `resource "azurerm_linux_web_app" "test" {
+  name = "app"
+  site_config {
+    http2_enabled = true
+  }
+  identity {
+    type = "SystemAssigned"
+  }
+  enabled = true
+}
+`, string(hclwrite.Format(f.Bytes())))
+}
+
+func TestLifecycleAddonIgnoreChanges(t *testing.T) {
+	parse := func(src string) *hclwrite.File {
+		f, diags := hclwrite.ParseConfig([]byte(src), "", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return f
+	}
+	meta := baseMeta{
+		propertyRules: propertyRules{
+			{IgnoreChanges: []string{"tags"}},
+			{ResourceType: "azurerm_kubernetes_cluster", IgnoreChanges: []string{"default_node_pool[0].node_count", "tags"}},
+		},
+	}
+	configs := ConfigInfos{
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_kubernetes_cluster", Name: "aks"}},
+			hcl:        parse("resource \"azurerm_kubernetes_cluster\" \"aks\" {\n}\n"),
+		},
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_application_insights_web_test", Name: "test"}},
+			hcl:        parse("resource \"azurerm_application_insights_web_test\" \"test\" {\n}\n"),
+		},
+	}
+	out, err := meta.lifecycleAddon(configs)
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_kubernetes_cluster" "aks" {
+  lifecycle {
+    ignore_changes = [
+      tags,
+      default_node_pool[0].node_count,
+    ]
+  }
+}
+`, string(hclwrite.Format(out[0].hcl.Bytes())))
+	require.Equal(t, `resource "azurerm_application_insights_web_test" "test" {
+  lifecycle {
+    ignore_changes = [
+      tags,
+    ]
+  }
+}
+`, string(hclwrite.Format(out[1].hcl.Bytes())))
+}
@@ -0,0 +1,235 @@
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// validateProviderAliases validates the config for exporting the resources across subscriptions. The resources of different subscriptions are
+// managed by different provider aliases, which are only defined in the root module, and are not supported by the tfclient.
+func validateProviderAliases(cfg config.CommonConfig, field string) error {
+	if cfg.ModulePath != "" {
+		return fmt.Errorf("%s can't be used with ModulePath in the config", field)
+	}
+	if len(cfg.EnvSplit) != 0 {
+		return fmt.Errorf("%s can't be used with EnvSplit in the config", field)
+	}
+	if cfg.TFClient != nil {
+		return fmt.Errorf("%s can't be used with TFClient in the config", field)
+	}
+	if cfg.SplitBy != "" {
+		return fmt.Errorf("%s can't be used with SplitBy in the config", field)
+	}
+	if cfg.ModuleTemplate != "" {
+		return fmt.Errorf("%s can't be used with ModuleTemplate in the config", field)
+	}
+	return nil
+}
+
+// aliasSubscriptionIds returns the distinct subscriptions other than the main subscription, which are managed by the aliased providers.
+func aliasSubscriptionIds(subscriptionId string, subscriptionIds []string) []string {
+	var out []string
+	seen := map[string]bool{strings.ToLower(subscriptionId): true}
+	for _, id := range subscriptionIds {
+		if seen[strings.ToLower(id)] {
+			continue
+		}
+		seen[strings.ToLower(id)] = true
+		out = append(out, id)
+	}
+	return out
+}
+
+// subscriptionAlias returns the alias of the azurerm provider that manages the resources of the subscription.
+func subscriptionAlias(subscriptionId string) string {
+	return "sub_" + strings.ReplaceAll(strings.ToLower(subscriptionId), "-", "_")
+}
+
+// subscriptionOf returns the subscription id of the resource, which is empty for the resources out of a subscription (e.g. the management groups).
+func subscriptionOf(id armid.ResourceId) string {
+	if id == nil {
+		return ""
+	}
+	switch scope := id.RootScope().(type) {
+	case *armid.SubscriptionId:
+		return scope.Id
+	case *armid.ResourceGroup:
+		return scope.SubscriptionId
+	}
+	return ""
+}
+
+// providerAlias returns the alias of the azurerm provider that manages the resource, which is empty if the resource is managed by the default provider.
+// Only the azurerm resources of the aliased subscriptions are managed by the aliased providers, as the azapi resources don't rely on the provider subscription.
+// The credential aliases take precedence over the subscription aliases, where the one with the most specific scope wins.
+func (meta baseMeta) providerAlias(item ImportItem) string {
+	if (len(meta.aliasSubscriptionIds) == 0 && len(meta.credentialAliases) == 0) || !strings.HasPrefix(item.TFAddr.Type, ProviderAzureRM+"_") {
+		return ""
+	}
+	if item.AzureResourceID != nil {
+		id := strings.ToUpper(item.AzureResourceID.String())
+		var alias, matched string
+		for _, ca := range meta.credentialAliases {
+			for _, scope := range ca.Scopes {
+				scope = strings.ToUpper(strings.TrimSuffix(scope, "/"))
+				if (id == scope || strings.HasPrefix(id, scope+"/")) && len(scope) > len(matched) {
+					alias, matched = ca.Alias, scope
+				}
+			}
+		}
+		if alias != "" {
+			return alias
+		}
+	}
+	sub := subscriptionOf(item.AzureResourceID)
+	for _, id := range meta.aliasSubscriptionIds {
+		if strings.EqualFold(id, sub) {
+			return subscriptionAlias(id)
+		}
+	}
+	return ""
+}
+
+// providerAliasTraversal returns the traversal that refers to the aliased azurerm provider, e.g. azurerm.sub_xxx.
+func providerAliasTraversal(alias string) hcl.Traversal {
+	return hcl.Traversal{hcl.TraverseRoot{Name: ProviderAzureRM}, hcl.TraverseAttr{Name: alias}}
+}
+
+// appendAliasedProviders appends an aliased azurerm provider block for each of the aliased subscriptions and the credential aliases, which shares the provider config of the default one.
+func (meta baseMeta) appendAliasedProviders(body *hclwrite.Body) {
+	ids := append([]string{}, meta.aliasSubscriptionIds...)
+	sort.Strings(ids)
+	for _, id := range ids {
+		pb := body.AppendNewBlock("provider", []string{ProviderAzureRM}).Body()
+		pb.SetAttributeValue("alias", cty.StringVal(subscriptionAlias(id)))
+		meta.appendProviderFeatures(pb)
+		for k, v := range meta.providerConfig {
+			pb.SetAttributeValue(k, v)
+		}
+		pb.SetAttributeValue("subscription_id", cty.StringVal(id))
+	}
+	for _, ca := range meta.credentialAliases {
+		pb := body.AppendNewBlock("provider", []string{ProviderAzureRM}).Body()
+		pb.SetAttributeValue("alias", cty.StringVal(ca.Alias))
+		meta.appendProviderFeatures(pb)
+		for k, v := range meta.providerConfig {
+			pb.SetAttributeValue(k, v)
+		}
+		settings := ca.settings()
+		var keys []string
+		for k := range settings {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			pb.SetAttributeValue(k, settings[k])
+		}
+	}
+}
+
+// providerAliasAddon sets the provider meta argument of the resources that are managed by the aliased providers.
+func (meta baseMeta) providerAliasAddon(configs ConfigInfos) (ConfigInfos, error) {
+	out := make(ConfigInfos, len(configs))
+	for i, cfg := range configs {
+		if alias := meta.providerAlias(cfg.ImportItem); alias != "" {
+			cfg.hcl.Body().Blocks()[0].Body().SetAttributeTraversal("provider", providerAliasTraversal(alias))
+		}
+		out[i] = cfg
+	}
+	return out, nil
+}
+
+// credentialAlias is an aliased azurerm provider with its own credential settings, which manages the resources within its scopes (e.g. the resources in another tenant).
+type credentialAlias struct {
+	Alias string `json:"alias"`
+	// Scopes are the Azure resource ids of the scopes (e.g. the subscriptions, the resource groups), whose resources are managed by the aliased provider
+	Scopes []string `json:"scopes"`
+
+	// The provider settings. The secrets are not supported, as they are written to the generated provider config.
+	// The subscription_id defaults to the subscription of the first scope.
+	SubscriptionId        string `json:"subscription_id,omitempty"`
+	TenantId              string `json:"tenant_id,omitempty"`
+	ClientId              string `json:"client_id,omitempty"`
+	ClientCertificatePath string `json:"client_certificate_path,omitempty"`
+	UseCLI                *bool  `json:"use_cli,omitempty"`
+	UseMSI                *bool  `json:"use_msi,omitempty"`
+	UseOIDC               *bool  `json:"use_oidc,omitempty"`
+}
+
+// settings returns the provider settings of the credential alias.
+func (ca credentialAlias) settings() map[string]cty.Value {
+	out := map[string]cty.Value{}
+	for k, v := range map[string]string{
+		"subscription_id":         ca.SubscriptionId,
+		"tenant_id":               ca.TenantId,
+		"client_id":               ca.ClientId,
+		"client_certificate_path": ca.ClientCertificatePath,
+	} {
+		if v != "" {
+			out[k] = cty.StringVal(v)
+		}
+	}
+	for k, v := range map[string]*bool{
+		"use_cli":  ca.UseCLI,
+		"use_msi":  ca.UseMSI,
+		"use_oidc": ca.UseOIDC,
+	} {
+		if v != nil {
+			out[k] = cty.BoolVal(*v)
+		}
+	}
+	return out
+}
+
+// loadCredentialAliases loads the credentials file, which is a JSON array of credentialAlias. The credential aliases are sorted by the alias.
+func loadCredentialAliases(path string) ([]credentialAlias, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the credentials file %s: %v", path, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	// Reject the unknown fields, e.g. the secrets
+	dec.DisallowUnknownFields()
+	var aliases []credentialAlias
+	if err := dec.Decode(&aliases); err != nil {
+		return nil, fmt.Errorf("unmarshalling the credentials file %s: %v", path, err)
+	}
+	seen := map[string]bool{}
+	for i, ca := range aliases {
+		if !hclsyntax.ValidIdentifier(ca.Alias) {
+			return nil, fmt.Errorf("invalid alias %q of the %d-th credential", ca.Alias, i)
+		}
+		if seen[ca.Alias] || strings.HasPrefix(ca.Alias, "sub_") {
+			return nil, fmt.Errorf("the alias %q of the %d-th credential is duplicated or reserved", ca.Alias, i)
+		}
+		seen[ca.Alias] = true
+		if len(ca.Scopes) == 0 {
+			return nil, fmt.Errorf("the %d-th credential has no scope", i)
+		}
+		for _, scope := range ca.Scopes {
+			id, err := armid.ParseResourceId(scope)
+			if err != nil {
+				return nil, fmt.Errorf("parsing the scope %q of the %d-th credential: %v", scope, i, err)
+			}
+			if aliases[i].SubscriptionId == "" {
+				aliases[i].SubscriptionId = subscriptionOf(id)
+			}
+		}
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Alias < aliases[j].Alias
+	})
+	return aliases, nil
+}
@@ -0,0 +1,164 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testMainSub  = "00000000-0000-0000-0000-000000000000"
+	testOtherSub = "11111111-1111-1111-1111-111111111111"
+)
+
+func TestBuildProviderConfigWithAliases(t *testing.T) {
+	meta := baseMeta{providerName: ProviderAzureRM, aliasSubscriptionIds: []string{testOtherSub}}
+	require.Equal(t, `provider "azurerm" {
+  features {
+  }
+}
+provider "azurerm" {
+  alias = "sub_11111111_1111_1111_1111_111111111111"
+  features {
+  }
+  subscription_id = "11111111-1111-1111-1111-111111111111"
+}
+`, meta.buildProviderConfig(ProviderAzureRM))
+
+	// The aliases are only for the azurerm provider
+	require.Equal(t, `provider "azapi" {
+}
+`, meta.buildProviderConfig(ProviderAzAPI))
+}
+
+func TestProviderAlias(t *testing.T) {
+	meta := baseMeta{providerName: ProviderAzureRM, subscriptionId: testMainSub, aliasSubscriptionIds: []string{testOtherSub}}
+	item := func(tfType, id string) ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return ImportItem{AzureResourceID: azureId, TFAddr: tfaddr.TFAddr{Type: tfType, Name: "res-0"}}
+	}
+
+	require.Equal(t, "", meta.providerAlias(item("azurerm_resource_group", "/subscriptions/"+testMainSub+"/resourceGroups/rg")))
+	require.Equal(t, "sub_11111111_1111_1111_1111_111111111111", meta.providerAlias(item("azurerm_resource_group", "/subscriptions/"+testOtherSub+"/resourceGroups/rg")))
+	require.Equal(t, "sub_11111111_1111_1111_1111_111111111111", meta.providerAlias(item("azurerm_virtual_network", "/subscriptions/"+testOtherSub+"/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet")))
+	require.Equal(t, "", meta.providerAlias(item(AzAPIResourceType, "/subscriptions/"+testOtherSub+"/resourceGroups/rg")))
+	require.Equal(t, "", meta.providerAlias(item("", "/subscriptions/"+testOtherSub+"/resourceGroups/rg")))
+}
+
+func TestProviderAliasAddon(t *testing.T) {
+	meta := baseMeta{providerName: ProviderAzureRM, subscriptionId: testMainSub, aliasSubscriptionIds: []string{testOtherSub}}
+	cfg := func(sub string) ConfigInfo {
+		id, err := armid.ParseResourceId("/subscriptions/" + sub + "/resourceGroups/rg")
+		require.NoError(t, err)
+		f := hclwrite.NewEmptyFile()
+		f.Body().AppendNewBlock("resource", []string{"azurerm_resource_group", "res-0"})
+		return ConfigInfo{
+			ImportItem: ImportItem{AzureResourceID: id, TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}},
+			hcl:        f,
+		}
+	}
+	out, err := meta.providerAliasAddon(ConfigInfos{cfg(testMainSub), cfg(testOtherSub)})
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_resource_group" "res-0" {
+}
+`, string(out[0].hcl.Bytes()))
+	require.Equal(t, `resource "azurerm_resource_group" "res-0" {
+  provider = azurerm.sub_11111111_1111_1111_1111_111111111111
+}
+`, string(out[1].hcl.Bytes()))
+}
+
+func TestDescendantSubscriptions(t *testing.T) {
+	require.Equal(t, []string{testMainSub, testOtherSub}, descendantSubscriptions([]managementGroupDescendant{
+		{Id: "/providers/Microsoft.Management/managementGroups/child", Type: "Microsoft.Management/managementGroups", Name: "child"},
+		{Id: "/subscriptions/" + testMainSub, Type: "/subscriptions", Name: testMainSub},
+		{Id: "/subscriptions/" + testOtherSub, Type: "/subscriptions", Name: testOtherSub},
+	}))
+}
+
+func TestResourceGroupsOf(t *testing.T) {
+	res := func(id string) resourceset.AzureResource {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return resourceset.AzureResource{Id: azureId}
+	}
+	rgs := resourceGroupsOf([]resourceset.AzureResource{
+		res("/subscriptions/" + testOtherSub + "/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet"),
+		res("/subscriptions/" + testMainSub + "/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet"),
+		res("/subscriptions/" + testMainSub + "/resourceGroups/RG1/providers/Microsoft.Network/virtualNetworks/vnet2"),
+	})
+	require.Len(t, rgs, 2)
+	require.Equal(t, "/subscriptions/"+testMainSub+"/resourceGroups/rg1", rgs[0].Id.String())
+	require.Equal(t, "/subscriptions/"+testOtherSub+"/resourceGroups/rg2", rgs[1].Id.String())
+}
+
+func TestAliasSubscriptionIds(t *testing.T) {
+	require.Nil(t, aliasSubscriptionIds(testMainSub, nil))
+	require.Nil(t, aliasSubscriptionIds(testMainSub, []string{testMainSub}))
+	require.Equal(t, []string{testOtherSub}, aliasSubscriptionIds(testMainSub, []string{testMainSub, testOtherSub, strings.ToUpper(testOtherSub)}))
+}
+
+func TestCredentialAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+  {"alias": "shared", "scopes": ["/subscriptions/`+testOtherSub+`/resourceGroups/shared"], "tenant_id": "tenant2", "use_cli": true},
+  {"alias": "other", "scopes": ["/subscriptions/`+testOtherSub+`"], "tenant_id": "tenant1", "client_id": "client1", "use_oidc": true}
+]`), 0644))
+	aliases, err := loadCredentialAliases(path)
+	require.NoError(t, err)
+	require.Len(t, aliases, 2)
+	require.Equal(t, "other", aliases[0].Alias)
+	require.Equal(t, testOtherSub, aliases[0].SubscriptionId)
+
+	meta := baseMeta{providerName: ProviderAzureRM, subscriptionId: testMainSub, credentialAliases: aliases}
+	require.Equal(t, `provider "azurerm" {
+  features {
+  }
+}
+provider "azurerm" {
+  alias = "other"
+  features {
+  }
+  client_id       = "client1"
+  subscription_id = "11111111-1111-1111-1111-111111111111"
+  tenant_id       = "tenant1"
+  use_oidc        = true
+}
+provider "azurerm" {
+  alias = "shared"
+  features {
+  }
+  subscription_id = "11111111-1111-1111-1111-111111111111"
+  tenant_id       = "tenant2"
+  use_cli         = true
+}
+`, meta.buildProviderConfig(ProviderAzureRM))
+
+	item := func(tfType, id string) ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return ImportItem{AzureResourceID: azureId, TFAddr: tfaddr.TFAddr{Type: tfType, Name: "res-0"}}
+	}
+	require.Equal(t, "", meta.providerAlias(item("azurerm_resource_group", "/subscriptions/"+testMainSub+"/resourceGroups/shared")))
+	require.Equal(t, "other", meta.providerAlias(item("azurerm_resource_group", "/subscriptions/"+testOtherSub+"/resourceGroups/rg")))
+	// The most specific scope wins
+	require.Equal(t, "shared", meta.providerAlias(item("azurerm_virtual_network", "/subscriptions/"+testOtherSub+"/resourceGroups/SHARED/providers/Microsoft.Network/virtualNetworks/vnet")))
+	require.Equal(t, "other", meta.providerAlias(item("azurerm_resource_group", "/subscriptions/"+testOtherSub+"/resourceGroups/shared2")))
+
+	// The secrets are not supported
+	require.NoError(t, os.WriteFile(path, []byte(`[{"alias": "other", "scopes": ["/subscriptions/`+testOtherSub+`"], "client_secret": "xxx"}]`), 0644))
+	_, err = loadCredentialAliases(path)
+	require.ErrorContains(t, err, `unknown field "client_secret"`)
+
+	require.NoError(t, os.WriteFile(path, []byte(`[{"alias": "sub_other", "scopes": ["/subscriptions/`+testOtherSub+`"]}]`), 0644))
+	_, err = loadCredentialAliases(path)
+	require.ErrorContains(t, err, "duplicated or reserved")
+}
@@ -0,0 +1,70 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// appendProviderFeatures appends the "features" block, which is required by the azurerm provider, with the provider features set.
+func (meta baseMeta) appendProviderFeatures(body *hclwrite.Body) {
+	setBlockValues(body.AppendNewBlock("features", nil).Body(), meta.providerFeatures)
+}
+
+// setBlockValues sets the values to the block body, where the object values are set as the nested blocks, while the others are set as the attributes.
+func setBlockValues(body *hclwrite.Body, values map[string]cty.Value) {
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := values[k]
+		if v.Type().IsObjectType() && !v.IsNull() {
+			setBlockValues(body.AppendNewBlock(k, nil).Body(), v.AsValueMap())
+			continue
+		}
+		body.SetAttributeValue(k, v)
+	}
+}
+
+// blockValuesJSON converts the block values to the JSON of the block, where the object values are the nested blocks (i.e. a list of a single object).
+func blockValuesJSON(values map[string]cty.Value) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for k, v := range values {
+		if v.Type().IsObjectType() && !v.IsNull() {
+			nested, err := blockValuesJSON(v.AsValueMap())
+			if err != nil {
+				return nil, err
+			}
+			out[k] = []interface{}{nested}
+			continue
+		}
+		b, err := ctyjson.Marshal(v, v.Type())
+		if err != nil {
+			return nil, fmt.Errorf("marshalling %s: %v", k, err)
+		}
+		out[k] = json.RawMessage(b)
+	}
+	return out, nil
+}
+
+// providerFeaturesJSON returns the JSON of the "features" block list of the azurerm provider config, which is empty if there is no provider feature.
+func (meta baseMeta) providerFeaturesJSON() (string, error) {
+	if len(meta.providerFeatures) == 0 {
+		return "[]", nil
+	}
+	features, err := blockValuesJSON(meta.providerFeatures)
+	if err != nil {
+		return "", fmt.Errorf("converting the provider features: %v", err)
+	}
+	b, err := json.Marshal([]interface{}{features})
+	if err != nil {
+		return "", fmt.Errorf("marshalling the provider features: %v", err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,56 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestBuildProviderConfigWithFeatures(t *testing.T) {
+	meta := baseMeta{
+		providerName:         ProviderAzureRM,
+		aliasSubscriptionIds: []string{testOtherSub},
+		providerFeatures: map[string]cty.Value{
+			"key_vault": cty.ObjectVal(map[string]cty.Value{
+				"purge_soft_delete_on_destroy": cty.False,
+			}),
+			"foo": cty.ListVal([]cty.Value{cty.StringVal("bar")}),
+		},
+	}
+	require.Equal(t, `provider "azurerm" {
+  features {
+    foo = ["bar"]
+    key_vault {
+      purge_soft_delete_on_destroy = false
+    }
+  }
+}
+provider "azurerm" {
+  alias = "sub_11111111_1111_1111_1111_111111111111"
+  features {
+    foo = ["bar"]
+    key_vault {
+      purge_soft_delete_on_destroy = false
+    }
+  }
+  subscription_id = "11111111-1111-1111-1111-111111111111"
+}
+`, meta.buildProviderConfig(ProviderAzureRM))
+}
+
+func TestProviderFeaturesJSON(t *testing.T) {
+	meta := baseMeta{providerName: ProviderAzureRM}
+	features, err := meta.providerFeaturesJSON()
+	require.NoError(t, err)
+	require.Equal(t, `[]`, features)
+
+	meta.providerFeatures = map[string]cty.Value{
+		"key_vault": cty.ObjectVal(map[string]cty.Value{
+			"purge_soft_delete_on_destroy": cty.False,
+		}),
+	}
+	features, err = meta.providerFeaturesJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"key_vault": [{"purge_soft_delete_on_destroy": false}]}]`, features)
+}
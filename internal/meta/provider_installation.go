@@ -0,0 +1,61 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ValidateProviderSource validates the source address of a provider, which is in the form of "[<hostname>/]<namespace>/<type>".
+func ValidateProviderSource(source string) error {
+	parts := strings.Split(source, "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return fmt.Errorf("%q is not in the form of [<hostname>/]<namespace>/<type>", source)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return fmt.Errorf("%q is not in the form of [<hostname>/]<namespace>/<type>", source)
+		}
+	}
+	return nil
+}
+
+// isNetworkMirror tells whether the provider mirror is a network mirror (i.e. an URL), otherwise it is a filesystem mirror (i.e. a local directory).
+func isNetworkMirror(mirror string) bool {
+	return strings.HasPrefix(mirror, "https://") || strings.HasPrefix(mirror, "http://")
+}
+
+// cliConfig builds the Terraform CLI config that installs all the providers from the provider mirror.
+func cliConfig(mirror string) []byte {
+	f := hclwrite.NewEmptyFile()
+	pb := f.Body().AppendNewBlock("provider_installation", nil).Body()
+	if isNetworkMirror(mirror) {
+		pb.AppendNewBlock("network_mirror", nil).Body().SetAttributeValue("url", cty.StringVal(mirror))
+	} else {
+		pb.AppendNewBlock("filesystem_mirror", nil).Body().SetAttributeValue("path", cty.StringVal(mirror))
+	}
+	return hclwrite.Format(f.Bytes())
+}
+
+// writeCLIConfig writes the Terraform CLI config to the output directory, and points the terraform commands run by aztfexport to it.
+func (meta baseMeta) writeCLIConfig() error {
+	path, err := filepath.Abs(filepath.Join(meta.outdir, CLIConfigFileName))
+	if err != nil {
+		return fmt.Errorf("getting the absolute path of the CLI config file: %v", err)
+	}
+	// #nosec G306
+	if err := os.WriteFile(path, cliConfig(meta.providerMirror), 0644); err != nil {
+		return fmt.Errorf("writing the CLI config to %s: %v", path, err)
+	}
+	if v, ok := os.LookupEnv("TF_CLI_CONFIG_FILE"); ok {
+		log.Printf("[WARN] Overriding the TF_CLI_CONFIG_FILE (%s) with %s", v, path)
+	}
+	// #nosec G104
+	os.Setenv("TF_CLI_CONFIG_FILE", path)
+	return nil
+}
@@ -0,0 +1,43 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCLIConfig(t *testing.T) {
+	require.Equal(t, `provider_installation {
+  network_mirror {
+    url = "https://mirror.example.com/providers/"
+  }
+}
+`, string(cliConfig("https://mirror.example.com/providers/")))
+	require.Equal(t, `provider_installation {
+  filesystem_mirror {
+    path = "/opt/terraform/providers"
+  }
+}
+`, string(cliConfig("/opt/terraform/providers")))
+}
+
+func TestProviderSource(t *testing.T) {
+	meta := &baseMeta{}
+	require.Equal(t, "hashicorp/azurerm", meta.providerSource(ProviderAzureRM))
+	require.Equal(t, "azure/azapi", meta.providerSource(ProviderAzAPI))
+
+	meta = &baseMeta{providerRegistry: "registry.example.com"}
+	require.Equal(t, "registry.example.com/hashicorp/azurerm", meta.providerSource(ProviderAzureRM))
+
+	meta = &baseMeta{providerName: ProviderAzureRM, providerRegistry: "registry.example.com", customProviderSource: "myorg/azurerm"}
+	require.Equal(t, "myorg/azurerm", meta.providerSource(ProviderAzureRM))
+	require.Equal(t, "registry.example.com/azure/azapi", meta.providerSource(ProviderAzAPI))
+}
+
+func TestValidateProviderSource(t *testing.T) {
+	require.NoError(t, ValidateProviderSource("myorg/azurerm"))
+	require.NoError(t, ValidateProviderSource("registry.example.com/myorg/azurerm"))
+	require.Error(t, ValidateProviderSource("azurerm"))
+	require.Error(t, ValidateProviderSource("registry.example.com//azurerm"))
+	require.Error(t, ValidateProviderSource("a/b/c/d"))
+}
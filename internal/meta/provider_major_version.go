@@ -0,0 +1,95 @@
+package meta
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+const (
+	ProviderMajorVersion3 = "3"
+	ProviderMajorVersion4 = "4"
+)
+
+var ProviderMajorVersions = []string{ProviderMajorVersion3, ProviderMajorVersion4}
+
+// AzureRMV4ProviderVersion is the default azurerm provider version used when the major version 4 is selected.
+const AzureRMV4ProviderVersion = "4.30.0"
+
+// providerV4Attributes records the attributes that are introduced (mostly renamed) in azurerm v4.0 for each resource type.
+// A nested attribute is represented by joining the nested block name and the attribute name with a ".".
+// These attributes are absent from the v3 schema that tfadd used for tuning the config, hence they have to be copied from the full config.
+var providerV4Attributes = map[string][]string{
+	"azurerm_cosmosdb_account": {
+		"automatic_failover_enabled",
+		"free_tier_enabled",
+		"multiple_write_locations_enabled",
+	},
+	"azurerm_kubernetes_cluster": {
+		"default_node_pool.auto_scaling_enabled",
+		"default_node_pool.host_encryption_enabled",
+		"default_node_pool.node_public_ip_enabled",
+	},
+	"azurerm_kubernetes_cluster_node_pool": {
+		"auto_scaling_enabled",
+		"host_encryption_enabled",
+		"node_public_ip_enabled",
+	},
+	"azurerm_network_interface": {
+		"accelerated_networking_enabled",
+		"ip_forwarding_enabled",
+	},
+	"azurerm_redis_cache": {
+		"non_ssl_port_enabled",
+	},
+	"azurerm_storage_account": {
+		"https_traffic_only_enabled",
+	},
+}
+
+// providerV4RemovedResourceTypes records the resource types removed in azurerm v4.0, together with their replacements.
+var providerV4RemovedResourceTypes = map[string][]string{
+	"azurerm_app_service":              {"azurerm_linux_web_app", "azurerm_windows_web_app"},
+	"azurerm_app_service_plan":         {"azurerm_service_plan"},
+	"azurerm_app_service_slot":         {"azurerm_linux_web_app_slot", "azurerm_windows_web_app_slot"},
+	"azurerm_function_app":             {"azurerm_linux_function_app", "azurerm_windows_function_app"},
+	"azurerm_function_app_slot":        {"azurerm_linux_function_app_slot", "azurerm_windows_function_app_slot"},
+	"azurerm_sql_database":             {"azurerm_mssql_database"},
+	"azurerm_sql_elasticpool":          {"azurerm_mssql_elasticpool"},
+	"azurerm_sql_firewall_rule":        {"azurerm_mssql_firewall_rule"},
+	"azurerm_sql_server":               {"azurerm_mssql_server"},
+	"azurerm_sql_virtual_network_rule": {"azurerm_mssql_virtual_network_rule"},
+}
+
+// ProviderV4Replacements returns the replacements of the resource type if it is removed in azurerm v4.0.
+func ProviderV4Replacements(resourceType string) ([]string, bool) {
+	replacements, ok := providerV4RemovedResourceTypes[resourceType]
+	return replacements, ok
+}
+
+// providerMajorVersionOf returns the major version of the provider version, or an empty string if it is not a version (e.g. a version constraint).
+func providerMajorVersionOf(v string) string {
+	ver, err := version.NewVersion(v)
+	if err != nil {
+		return ""
+	}
+	return strconv.Itoa(ver.Segments()[0])
+}
+
+// ProviderV4Addon copies the attributes introduced in azurerm v4.0 from the full config to the (tuned) config of each resource, as the tuning is based on the v3 schema.
+func ProviderV4Addon(f, full *hclwrite.File, resourceType string) {
+	dst, src := f.Body().Blocks()[0].Body(), full.Body().Blocks()[0].Body()
+	for _, attr := range providerV4Attributes[resourceType] {
+		dst, src, name := dst, src, attr
+		if blockName, attrName, ok := strings.Cut(attr, "."); ok {
+			dstBlk, srcBlk := dst.FirstMatchingBlock(blockName, nil), src.FirstMatchingBlock(blockName, nil)
+			if dstBlk == nil || srcBlk == nil {
+				continue
+			}
+			dst, src, name = dstBlk.Body(), srcBlk.Body(), attrName
+		}
+		hclBlockCopyAttribute(dst, src, name)
+	}
+}
@@ -0,0 +1,49 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderMajorVersionOf(t *testing.T) {
+	require.Equal(t, "3", providerMajorVersionOf("3.65.0"))
+	require.Equal(t, "4", providerMajorVersionOf("4.30.0"))
+	require.Equal(t, "", providerMajorVersionOf("~> 4.0"))
+	require.Equal(t, "", providerMajorVersionOf(""))
+}
+
+func TestProviderV4Addon(t *testing.T) {
+	parse := func(src string) *hclwrite.File {
+		f, diags := hclwrite.ParseConfig([]byte(src), "", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return f
+	}
+	f := parse(`resource "azurerm_kubernetes_cluster" "test" {
+  name = "aks"
+  default_node_pool {
+    name = "default"
+  }
+}
+`)
+	full := parse(`resource "azurerm_kubernetes_cluster" "test" {
+  name                = "aks"
+  sku_tier            = "Free"
+  default_node_pool {
+    name                 = "default"
+    auto_scaling_enabled = true
+  }
+}
+`)
+	ProviderV4Addon(f, full, "azurerm_kubernetes_cluster")
+	require.Equal(t, `resource "azurerm_kubernetes_cluster" "test" {
+  name = "aks"
+  default_node_pool {
+    name                 = "default"
+    auto_scaling_enabled = true
+  }
+}
+`, string(hclwrite.Format(f.Bytes())))
+}
@@ -0,0 +1,191 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/armschema"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/magodo/armid"
+	"github.com/magodo/workerpool"
+	"github.com/tidwall/gjson"
+)
+
+// PruneReportFileName is the file under the output directory that records the resources removed from the state, as they no longer exist in Azure.
+const PruneReportFileName = "aztfexportPruneReport.json"
+
+// PrunedResource is a resource in the state that no longer exists in Azure.
+type PrunedResource struct {
+	// Address is the address of the resource in the state
+	Address string `json:"address"`
+	// ResourceId is the TF resource id, which is the Azure resource id of the deleted resource
+	ResourceId string `json:"resource_id"`
+}
+
+// stateResources returns the managed resource instances in the state, whose ids are Azure resource ids.
+func stateResources(state []byte) []PrunedResource {
+	var out []PrunedResource
+	for _, res := range gjson.GetBytes(state, "resources").Array() {
+		if res.Get("mode").String() != "managed" {
+			continue
+		}
+		addr := res.Get("type").String() + "." + res.Get("name").String()
+		if module := res.Get("module").String(); module != "" {
+			addr = module + "." + addr
+		}
+		for _, instance := range res.Get("instances").Array() {
+			instanceAddr := addr
+			if key := instance.Get("index_key"); key.Exists() {
+				instanceAddr = fmt.Sprintf("%s[%s]", addr, key.Raw)
+			}
+			id := instance.Get("attributes.id").String()
+			if _, err := armid.ParseResourceId(id); err != nil {
+				continue
+			}
+			out = append(out, PrunedResource{Address: instanceAddr, ResourceId: id})
+		}
+	}
+	return out
+}
+
+// pruneScopes returns the uppercased scopes of the listed resources, which are the resource groups (or the root scopes for the ones out of any resource group).
+func pruneScopes(l ImportList) []string {
+	set := map[string]bool{}
+	for _, item := range l {
+		scope := item.AzureResourceID
+		if _, ok := scope.(*armid.ResourceGroup); !ok {
+			scope = scope.RootScope()
+		}
+		set[strings.ToUpper(scope.String())] = true
+	}
+	var scopes []string
+	for scope := range set {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// pruneCandidates returns the resources in the state that are not listed, but are within the scopes of the listed resources.
+func pruneCandidates(resources []PrunedResource, l ImportList) []PrunedResource {
+	listed := map[string]bool{}
+	for _, item := range l {
+		listed[strings.ToUpper(item.AzureResourceID.String())] = true
+	}
+	scopes := pruneScopes(l)
+	var out []PrunedResource
+	for _, res := range resources {
+		id := strings.ToUpper(res.ResourceId)
+		if listed[id] {
+			continue
+		}
+		for _, scope := range scopes {
+			if id == scope || strings.HasPrefix(id, scope+"/") {
+				out = append(out, res)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// resourceExists checks the existence of the Azure resource by getting it in the latest API version.
+func (meta baseMeta) resourceExists(ctx context.Context, id armid.ResourceId) (bool, error) {
+	apiVersion, err := armschema.LatestAPIVersion(id.TypeString())
+	if err != nil {
+		return false, err
+	}
+	if _, err := meta.resourceClient.GetByID(ctx, id.String(), apiVersion, nil); err != nil {
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting %s: %v", id, err)
+	}
+	return true, nil
+}
+
+// pruneState removes the resources that no longer exist in Azure from the state of the output directory, and records them in the PruneReportFileName.
+// Only the resources within the scopes of the listed resources are checked, as the others might be managed by the workspace in other ways.
+func (meta *baseMeta) pruneState(ctx context.Context, l ImportList) error {
+	if !meta.prune {
+		return nil
+	}
+	candidates := pruneCandidates(stateResources(meta.baseState), l)
+
+	wp := workerpool.NewWorkPool(meta.parallelism)
+	var pruned []PrunedResource
+	wp.Run(func(i interface{}) error {
+		if res, ok := i.(PrunedResource); ok {
+			pruned = append(pruned, res)
+		}
+		return nil
+	})
+	for _, res := range candidates {
+		res := res
+		wp.AddTask(func() (interface{}, error) {
+			id, err := armid.ParseResourceId(res.ResourceId)
+			if err != nil {
+				return nil, err
+			}
+			exists, err := meta.resourceExists(ctx, id)
+			if err != nil {
+				// The resources whose existence can't be told (e.g. the API version is unknown) are kept
+				log.Printf("[WARN] Checking the existence of %s: %v", res.ResourceId, err)
+				return nil, nil
+			}
+			if exists {
+				return nil, nil
+			}
+			return res, nil
+		})
+	}
+	if err := wp.Done(); err != nil {
+		return fmt.Errorf("checking the existence of the resources in the state: %v", err)
+	}
+	sort.Slice(pruned, func(i, j int) bool {
+		return pruned[i].Address < pruned[j].Address
+	})
+
+	if len(pruned) != 0 {
+		if err := meta.withBackendUnlocked(ctx, func() error {
+			for _, res := range pruned {
+				log.Printf("[INFO] Removing %s (%s) from the state, as it no longer exists", res.Address, res.ResourceId)
+				if err := meta.auditLog.Run(meta.tf, func() error { return meta.tf.StateRm(ctx, res.Address) }); err != nil {
+					return fmt.Errorf("removing %s from the state: %v", res.Address, err)
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		baseState, err := meta.statePull(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to pull state: %v", err)
+		}
+		meta.baseState = []byte(baseState)
+		meta.originBaseState = []byte(baseState)
+	}
+
+	if pruned == nil {
+		pruned = []PrunedResource{}
+	}
+	b, err := json.MarshalIndent(pruned, "", "\t")
+	if err != nil {
+		return fmt.Errorf("JSON marshalling the pruned resources: %v", err)
+	}
+	path := filepath.Join(meta.outdir, PruneReportFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing the prune report to %s: %v", path, err)
+	}
+	return nil
+}
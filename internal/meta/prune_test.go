@@ -0,0 +1,72 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	state := []byte(`{
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "azurerm_resource_group",
+      "name": "res-0",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/rg1"}}]
+    },
+    {
+      "mode": "managed",
+      "type": "azurerm_virtual_network",
+      "name": "res-1",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/RG1/providers/Microsoft.Network/virtualNetworks/vnet1"}}]
+    },
+    {
+      "module": "module.storage",
+      "mode": "managed",
+      "type": "azurerm_storage_account",
+      "name": "res-2",
+      "instances": [{"index_key": "a", "attributes": {"id": "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1"}}]
+    },
+    {
+      "mode": "managed",
+      "type": "azurerm_virtual_network",
+      "name": "res-3",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet2"}}]
+    },
+    {
+      "mode": "managed",
+      "type": "azurerm_key_vault_secret",
+      "name": "res-4",
+      "instances": [{"attributes": {"id": "https://kv1.vault.azure.net/secrets/secret1/abc"}}]
+    },
+    {
+      "mode": "data",
+      "type": "azurerm_virtual_network",
+      "name": "vnet3",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet3"}}]
+    }
+  ]
+}`)
+	resources := stateResources(state)
+	require.Equal(t, []PrunedResource{
+		{Address: "azurerm_resource_group.res-0", ResourceId: "/subscriptions/123/resourceGroups/rg1"},
+		{Address: "azurerm_virtual_network.res-1", ResourceId: "/subscriptions/123/resourceGroups/RG1/providers/Microsoft.Network/virtualNetworks/vnet1"},
+		{Address: `module.storage.azurerm_storage_account.res-2["a"]`, ResourceId: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1"},
+		{Address: "azurerm_virtual_network.res-3", ResourceId: "/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet2"},
+	}, resources)
+
+	var l ImportList
+	for _, id := range []string{
+		"/subscriptions/123/resourceGroups/rg1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1",
+	} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		l = append(l, ImportItem{AzureResourceID: azureId})
+	}
+	require.Equal(t, []PrunedResource{
+		{Address: "azurerm_virtual_network.res-1", ResourceId: "/subscriptions/123/resourceGroups/RG1/providers/Microsoft.Network/virtualNetworks/vnet1"},
+	}, pruneCandidates(resources, l))
+}
@@ -0,0 +1,105 @@
+package meta
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ARGFilter is the filter of the resources to query, which is built into the ARG where predicate, so that the users don't need to write the predicate by hand.
+type ARGFilter struct {
+	// Types are the Azure resource types (e.g. "Microsoft.Network/virtualNetworks"), any of which the resources must be of, case insensitively
+	Types []string
+	// Locations are the locations (e.g. "westeurope"), any of which the resources must be in, case insensitively
+	Locations []string
+	// CreatedAfter is either a RFC3339 timestamp, or a duration (e.g. "72h") before now, after which the resources must be created
+	CreatedAfter string
+	// CreatedBefore is either a RFC3339 timestamp, or a duration (e.g. "72h") before now, before which the resources must be created
+	CreatedBefore string
+	// NameRegex is the (RE2) regular expression that the resource names must match
+	NameRegex string
+}
+
+func (f ARGFilter) IsEmpty() bool {
+	return len(f.Types) == 0 && len(f.Locations) == 0 && f.CreatedAfter == "" && f.CreatedBefore == "" && f.NameRegex == ""
+}
+
+// ValidateARGFilter validates the filter.
+func ValidateARGFilter(f ARGFilter) error {
+	for _, t := range f.Types {
+		if segs := strings.Split(t, "/"); len(segs) < 2 || !strings.Contains(segs[0], ".") || strings.Contains(t, "//") || strings.HasSuffix(t, "/") {
+			return fmt.Errorf(`invalid resource type %q, which is expected to be like "Microsoft.Network/virtualNetworks"`, t)
+		}
+	}
+	for _, loc := range f.Locations {
+		if loc == "" || strings.ContainsAny(loc, " \"") {
+			return fmt.Errorf("invalid location %q", loc)
+		}
+	}
+	if _, err := createdTimeExpr(f.CreatedAfter); err != nil {
+		return fmt.Errorf("invalid creation time: %v", err)
+	}
+	if _, err := createdTimeExpr(f.CreatedBefore); err != nil {
+		return fmt.Errorf("invalid creation time: %v", err)
+	}
+	if f.NameRegex != "" {
+		if _, err := regexp.Compile(f.NameRegex); err != nil {
+			return fmt.Errorf("invalid name regex: %v", err)
+		}
+	}
+	return nil
+}
+
+// WithARGFilter appends the conditions of the filter to the ARG where predicate via "and". The predicate can be empty, in which case only the conditions are used.
+// The creation time is matched by the "systemData.createdAt" of the resources, which is not recorded for every resource type, these resources are never matched.
+// The filter is expected to be validated by ValidateARGFilter.
+func WithARGFilter(predicate string, f ARGFilter) string {
+	var conds []string
+	if len(f.Types) != 0 {
+		conds = append(conds, fmt.Sprintf("type in~ (%s)", quoteList(f.Types)))
+	}
+	if len(f.Locations) != 0 {
+		conds = append(conds, fmt.Sprintf("location in~ (%s)", quoteList(f.Locations)))
+	}
+	if expr, _ := createdTimeExpr(f.CreatedAfter); expr != "" {
+		conds = append(conds, fmt.Sprintf("todatetime(systemData.createdAt) > %s", expr))
+	}
+	if expr, _ := createdTimeExpr(f.CreatedBefore); expr != "" {
+		conds = append(conds, fmt.Sprintf("todatetime(systemData.createdAt) < %s", expr))
+	}
+	if f.NameRegex != "" {
+		// The verbatim string literal, where the double quote is escaped by doubling it.
+		conds = append(conds, fmt.Sprintf(`name matches regex @"%s"`, strings.ReplaceAll(f.NameRegex, `"`, `""`)))
+	}
+	if len(conds) == 0 {
+		return predicate
+	}
+	if predicate == "" {
+		return strings.Join(conds, " and ")
+	}
+	return fmt.Sprintf("(%s) and %s", predicate, strings.Join(conds, " and "))
+}
+
+func quoteList(l []string) string {
+	var out []string
+	for _, v := range l {
+		out = append(out, fmt.Sprintf("%q", v))
+	}
+	return strings.Join(out, ", ")
+}
+
+// createdTimeExpr converts the time, which is either a RFC3339 timestamp or a duration before now, to the KQL expression. Empty time returns an empty expression.
+func createdTimeExpr(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return fmt.Sprintf("datetime(%s)", t.UTC().Format(time.RFC3339)), nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return "", fmt.Errorf(`%q is neither a RFC3339 timestamp nor a positive duration (e.g. "72h")`, v)
+	}
+	return fmt.Sprintf("ago(%ds)", int64(d.Seconds())), nil
+}
@@ -0,0 +1,80 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithARGFilter(t *testing.T) {
+	require.Equal(t, `resourceGroup =~ "rg"`, WithARGFilter(`resourceGroup =~ "rg"`, ARGFilter{}))
+	require.Equal(t,
+		`type in~ ("Microsoft.Network/virtualNetworks", "Microsoft.Storage/storageAccounts") and location in~ ("westeurope")`,
+		WithARGFilter("", ARGFilter{
+			Types:     []string{"Microsoft.Network/virtualNetworks", "Microsoft.Storage/storageAccounts"},
+			Locations: []string{"westeurope"},
+		}),
+	)
+	require.Equal(t,
+		`(resourceGroup =~ "rg" or resourceGroup =~ "rg2") and todatetime(systemData.createdAt) > ago(259200s) and todatetime(systemData.createdAt) < datetime(2024-01-02T03:04:05Z) and name matches regex @"^app-""\d+$"`,
+		WithARGFilter(`resourceGroup =~ "rg" or resourceGroup =~ "rg2"`, ARGFilter{
+			CreatedAfter:  "72h",
+			CreatedBefore: "2024-01-02T11:04:05+08:00",
+			NameRegex:     `^app-"\d+$`,
+		}),
+	)
+}
+
+func TestValidateARGFilter(t *testing.T) {
+	cases := []struct {
+		name   string
+		filter ARGFilter
+		err    string
+	}{
+		{
+			name: "valid",
+			filter: ARGFilter{
+				Types:         []string{"Microsoft.Network/virtualNetworks/subnets"},
+				Locations:     []string{"westeurope"},
+				CreatedAfter:  "2024-01-01T00:00:00Z",
+				CreatedBefore: "24h",
+				NameRegex:     "^app-",
+			},
+		},
+		{
+			name:   "invalid type",
+			filter: ARGFilter{Types: []string{"virtualNetworks"}},
+			err:    `invalid resource type "virtualNetworks", which is expected to be like "Microsoft.Network/virtualNetworks"`,
+		},
+		{
+			name:   "invalid location",
+			filter: ARGFilter{Locations: []string{"west europe"}},
+			err:    `invalid location "west europe"`,
+		},
+		{
+			name:   "invalid time",
+			filter: ARGFilter{CreatedAfter: "yesterday"},
+			err:    `invalid creation time: "yesterday" is neither a RFC3339 timestamp nor a positive duration (e.g. "72h")`,
+		},
+		{
+			name:   "negative duration",
+			filter: ARGFilter{CreatedBefore: "-1h"},
+			err:    `invalid creation time: "-1h" is neither a RFC3339 timestamp nor a positive duration (e.g. "72h")`,
+		},
+		{
+			name:   "invalid regex",
+			filter: ARGFilter{NameRegex: "app-("},
+			err:    "invalid name regex: error parsing regexp: missing closing ): `app-(`",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateARGFilter(tt.filter)
+			if tt.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package meta
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// referenceAddon replaces the attribute values that equal to the TF resource id of another exported resource with references to its "id" attribute (e.g. azurerm_subnet.res-1.id),
+// which also implies the dependency between them. The resources exported in the former chunks are also referenced.
+// The TF resource ids that are shared by multiple resources (e.g. a parent and its child) are ambiguous, which are kept as is.
+func (meta baseMeta) referenceAddon(configs ConfigInfos) (ConfigInfos, error) {
+	if !meta.rewriteReferences {
+		return configs, nil
+	}
+
+	items := map[string][]ImportItem{}
+	for _, item := range meta.generatedList {
+		if item.Skip() || item.ImportError != nil {
+			continue
+		}
+		items[item.TFResourceId] = append(items[item.TFResourceId], item)
+	}
+	for _, cfg := range configs {
+		items[cfg.TFResourceId] = append(items[cfg.TFResourceId], cfg.ImportItem)
+	}
+
+	out := make(ConfigInfos, len(configs))
+	for i, cfg := range configs {
+		err := hclBlockRewriteStringValues(cfg.hcl.Body().Blocks()[0].Body(), func(val string) hcl.Traversal {
+			// This is safe to match case sensitively given the TF id are consistent across the provider, same as the reference dependency.
+			candidates := items[val]
+			if len(candidates) != 1 || val == cfg.TFResourceId {
+				return nil
+			}
+			target := candidates[0]
+			// The resource of another child module is referenced via the variable, which is passed from the output of that module.
+			if module, targetModule := meta.splitModule(cfg.ImportItem), meta.splitModule(target); module != targetModule {
+				// The existing child modules of the module template are not rewired, the id is kept as is.
+				if meta.moduleTemplate != "" {
+					return nil
+				}
+				ref := crossModuleRef{module: module, targetModule: targetModule, target: target.TFAddr}
+				meta.crossModuleRefs[ref] = true
+				return variableTraversal(ref.name())
+			}
+			return hcl.Traversal{
+				hcl.TraverseRoot{Name: target.TFAddr.Type},
+				hcl.TraverseAttr{Name: target.TFAddr.Name},
+				hcl.TraverseAttr{Name: "id"},
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", cfg.TFAddr, err)
+		}
+		out[i] = cfg
+	}
+	return out, nil
+}
@@ -0,0 +1,79 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferenceAddon(t *testing.T) {
+	const (
+		rgId     = "/subscriptions/123/resourceGroups/rg"
+		vnetId   = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"
+		subnetId = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/default"
+		nsgId    = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg"
+	)
+	meta := baseMeta{
+		rewriteReferences: true,
+		// The resource group is exported in a former chunk
+		generatedList: ImportList{
+			{TFResourceId: rgId, TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}},
+		},
+	}
+
+	parse := func(input string) *hclwrite.File {
+		f, diags := hclwrite.ParseConfig([]byte(input), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return f
+	}
+	configs := ConfigInfos{
+		{
+			ImportItem: ImportItem{TFResourceId: subnetId, TFAddr: tfaddr.TFAddr{Type: "azurerm_subnet", Name: "res-1"}},
+			hcl: parse(`resource "azurerm_subnet" "res-1" {
+  name = "default"
+}
+`),
+		},
+		{
+			ImportItem: ImportItem{TFResourceId: subnetId, TFAddr: tfaddr.TFAddr{Type: "azurerm_subnet_network_security_group_association", Name: "res-2"}},
+			hcl: parse(`resource "azurerm_subnet_network_security_group_association" "res-2" {
+  network_security_group_id = "` + nsgId + `"
+  subnet_id                 = "` + subnetId + `"
+}
+`),
+		},
+		{
+			ImportItem: ImportItem{TFResourceId: nsgId, TFAddr: tfaddr.TFAddr{Type: "azurerm_network_security_group", Name: "res-3"}},
+			hcl: parse(`resource "azurerm_network_security_group" "res-3" {
+  name = "nsg"
+}
+`),
+		},
+		{
+			ImportItem: ImportItem{TFResourceId: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/privateDnsZones/zone/virtualNetworkLinks/link", TFAddr: tfaddr.TFAddr{Type: "azurerm_private_dns_zone_virtual_network_link", Name: "res-4"}},
+			hcl: parse(`resource "azurerm_private_dns_zone_virtual_network_link" "res-4" {
+  scopes             = ["` + rgId + `", "` + vnetId + `"]
+  virtual_network_id = "` + vnetId + `"
+}
+`),
+		},
+	}
+
+	configs, err := meta.referenceAddon(configs)
+	require.NoError(t, err)
+	// The subnet id is shared by the subnet and the association, which is ambiguous
+	require.Equal(t, `resource "azurerm_subnet_network_security_group_association" "res-2" {
+  network_security_group_id = azurerm_network_security_group.res-3.id
+  subnet_id                 = "`+subnetId+`"
+}
+`, string(hclwrite.Format(configs[1].hcl.Bytes())))
+	// The virtual network is not exported
+	require.Equal(t, `resource "azurerm_private_dns_zone_virtual_network_link" "res-4" {
+  scopes             = [azurerm_resource_group.res-0.id, "`+vnetId+`"]
+  virtual_network_id = "`+vnetId+`"
+}
+`, string(hclwrite.Format(configs[3].hcl.Bytes())))
+}
@@ -0,0 +1,88 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/hashicorp/terraform-exec/tfexec"
+)
+
+// RemoteModuleConfigDirPrefix is the prefix of the directory (under the output directory) that the config is generated to, when the module path refers to a module from a remote source (e.g. registry, git).
+// The downloaded module can't be modified in place, hence the config is meant to be merged back to the module source by users.
+// The directory is suffixed by the module path, e.g. "aztfexport-module-mod1.mod2".
+const RemoteModuleConfigDirPrefix = "aztfexport-module-"
+
+// moduleManifestPath is the path of the module manifest (relative to the root module) maintained by "terraform init/get".
+var moduleManifestPath = filepath.Join(".terraform", "modules", "modules.json")
+
+type moduleManifest struct {
+	Modules []struct {
+		Key    string `json:"Key"`
+		Source string `json:"Source"`
+		Dir    string `json:"Dir"`
+	} `json:"Modules"`
+}
+
+// remoteModuleDir returns the directory of the downloaded module of the specified key (e.g. "mod1.mod2"), which is looked up from the module manifest.
+// The modules are downloaded via "terraform get" if the module is not found in the manifest.
+func remoteModuleDir(rootDir, key string) (string, error) {
+	dir, ok, err := lookupModuleManifest(rootDir, key)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return dir, nil
+	}
+
+	log.Printf(`[INFO] Module %q is not downloaded, running "terraform get" for %s`, key, rootDir)
+	ctx := context.Background()
+	execPath, err := FindTerraform(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error finding a terraform exectuable: %w", err)
+	}
+	tf, err := tfexec.NewTerraform(rootDir, execPath)
+	if err != nil {
+		return "", fmt.Errorf("new terraform: %w", err)
+	}
+	if err := tf.Get(ctx); err != nil {
+		return "", fmt.Errorf("running terraform get: %v", err)
+	}
+
+	dir, ok, err = lookupModuleManifest(rootDir, key)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("module %q is not found in the module manifest", key)
+	}
+	return dir, nil
+}
+
+func lookupModuleManifest(rootDir, key string) (string, bool, error) {
+	b, err := os.ReadFile(filepath.Join(rootDir, moduleManifestPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("reading the module manifest: %v", err)
+	}
+	var manifest moduleManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return "", false, fmt.Errorf("unmarshalling the module manifest: %v", err)
+	}
+	for _, m := range manifest.Modules {
+		if m.Key != key {
+			continue
+		}
+		dir := m.Dir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(rootDir, dir)
+		}
+		return dir, true, nil
+	}
+	return "", false, nil
+}
@@ -0,0 +1,52 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetModuleDir(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(content), 0644))
+	}
+	writeFile("main.tf", `
+module "local" {
+  source = "./local"
+}
+
+module "remote" {
+  source  = "Azure/foo/azurerm"
+  version = "1.0.0"
+}
+`)
+	writeFile("local/main.tf", "")
+	writeFile(".terraform/modules/remote/main.tf", `
+module "sub" {
+  source = "./sub"
+}
+`)
+	writeFile(".terraform/modules/remote/sub/main.tf", "")
+	writeFile(".terraform/modules/modules.json", `{"Modules":[
+  {"Key":"","Source":"","Dir":"."},
+  {"Key":"local","Source":"./local","Dir":"local"},
+  {"Key":"remote","Source":"registry.terraform.io/Azure/foo/azurerm","Version":"1.0.0","Dir":".terraform/modules/remote"},
+  {"Key":"remote.sub","Source":"./sub","Dir":".terraform/modules/remote/sub"}
+]}`)
+
+	dir, err := getModuleDir([]string{"local"}, root)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "local"), dir)
+
+	dir, err = getModuleDir([]string{"remote", "sub"}, root)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, RemoteModuleConfigDirPrefix+"remote.sub"), dir)
+	require.DirExists(t, dir)
+
+	_, err = getModuleDir([]string{"remote", "nonexist"}, root)
+	require.EqualError(t, err, `no module "remote.nonexist" invoked by the root module`)
+}
@@ -0,0 +1,219 @@
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const (
+	// OutputSyntaxHCL renders the config in the HCL native syntax, e.g. "main.tf".
+	OutputSyntaxHCL = "hcl"
+	// OutputSyntaxTFJSON renders the config in the JSON syntax, e.g. "main.tf.json".
+	OutputSyntaxTFJSON = "tf-json"
+)
+
+// OutputSyntaxes are the supported syntaxes of the generated config.
+var OutputSyntaxes = []string{OutputSyntaxHCL, OutputSyntaxTFJSON}
+
+// builtinRenderer returns the builtin renderer of the output syntax.
+func builtinRenderer(outputSyntax string) config.ConfigRenderer {
+	if outputSyntax == OutputSyntaxTFJSON {
+		return tfJSONRenderer{}
+	}
+	return hclRenderer{}
+}
+
+// hclRenderer renders the config in the HCL native syntax, as is.
+type hclRenderer struct{}
+
+func (hclRenderer) FileName(name string) string {
+	return name
+}
+
+func (hclRenderer) Render(existing []byte, blocks [][]byte) ([]byte, error) {
+	buf := bytes.NewBuffer(existing)
+	for _, b := range blocks {
+		buf.Write(b)
+		buf.Write([]byte("\n"))
+	}
+	return buf.Bytes(), nil
+}
+
+// tfJSONRenderer renders the config in the JSON syntax (https://developer.hashicorp.com/terraform/language/syntax/json).
+// The literal values are rendered as the JSON values, while the other expressions (e.g. the references) are rendered as the interpolations of their source.
+// The comments are dropped, as there is no comment in JSON.
+type tfJSONRenderer struct{}
+
+func (tfJSONRenderer) FileName(name string) string {
+	return name + ".json"
+}
+
+func (tfJSONRenderer) Render(existing []byte, blocks [][]byte) ([]byte, error) {
+	doc := map[string]interface{}{}
+	if len(existing) != 0 {
+		dec := json.NewDecoder(bytes.NewReader(existing))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decoding the existing config: %v", err)
+		}
+	}
+	for _, b := range blocks {
+		f, diags := hclsyntax.ParseConfig(b, "main.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing the config: %v", diags.Error())
+		}
+		for _, blk := range f.Body.(*hclsyntax.Body).Blocks {
+			body, err := tfJSONBody(blk.Body, b, blk.Type == "resource" || blk.Type == "data")
+			if err != nil {
+				return nil, fmt.Errorf("rendering %s: %v", strings.Join(append([]string{blk.Type}, blk.Labels...), "."), err)
+			}
+			// The block is nested in the objects of its type and labels, e.g. {"resource": {"<type>": {"<name>": <body>}}}.
+			parent, key := doc, blk.Type
+			for _, label := range blk.Labels {
+				m, ok := parent[key].(map[string]interface{})
+				if !ok {
+					m = map[string]interface{}{}
+					parent[key] = m
+				}
+				parent, key = m, label
+			}
+			parent[key] = body
+		}
+	}
+	// The HTML characters (e.g. "<") are kept as is, which are common in the config (e.g. the policy rules).
+	buf := bytes.NewBuffer([]byte{})
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("marshalling the config: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// tfJSONBody renders the body as a JSON object, where the nested blocks are rendered as the arrays of objects.
+// For the body of a resource, its meta arguments that are static references (e.g. "depends_on") are rendered as the strings of their source.
+func tfJSONBody(body *hclsyntax.Body, src []byte, isResource bool) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for name, attr := range body.Attributes {
+		var v interface{}
+		var err error
+		if isResource && (name == "depends_on" || name == "provider") {
+			v, err = tfJSONStaticExpr(attr.Expr, src)
+		} else {
+			v, err = tfJSONExpr(attr.Expr, src)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		out[name] = v
+	}
+	for _, blk := range body.Blocks {
+		var v map[string]interface{}
+		var err error
+		if isResource && blk.Type == "lifecycle" {
+			v, err = tfJSONLifecycle(blk.Body, src)
+		} else {
+			v, err = tfJSONBody(blk.Body, src, false)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", blk.Type, err)
+		}
+		blocks, _ := out[blk.Type].([]interface{})
+		out[blk.Type] = append(blocks, v)
+	}
+	return out, nil
+}
+
+// tfJSONLifecycle renders the lifecycle block, whose list arguments (e.g. "ignore_changes") are static references.
+func tfJSONLifecycle(body *hclsyntax.Body, src []byte) (map[string]interface{}, error) {
+	out, err := tfJSONBody(body, src, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range []string{"ignore_changes", "replace_triggered_by"} {
+		attr, ok := body.Attributes[name]
+		if !ok {
+			continue
+		}
+		if out[name], err = tfJSONStaticExpr(attr.Expr, src); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return out, nil
+}
+
+// tfJSONStaticExpr renders the static references (or the list of them) as the strings of their source, e.g. "azurerm_resource_group.rg".
+func tfJSONStaticExpr(expr hclsyntax.Expression, src []byte) (interface{}, error) {
+	if tuple, ok := expr.(*hclsyntax.TupleConsExpr); ok {
+		out := []interface{}{}
+		for _, expr := range tuple.Exprs {
+			out = append(out, string(expr.Range().SliceBytes(src)))
+		}
+		return out, nil
+	}
+	return string(expr.Range().SliceBytes(src)), nil
+}
+
+// tfJSONExpr renders the expression as a JSON value if it is a literal value, otherwise as the interpolation of its source.
+func tfJSONExpr(expr hclsyntax.Expression, src []byte) (interface{}, error) {
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return "${" + string(expr.Range().SliceBytes(src)) + "}", nil
+	}
+	if val.IsNull() {
+		return nil, nil
+	}
+	b, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return escapeTemplate(v), nil
+}
+
+// escapeTemplate escapes the template sequences in the strings of the JSON value, as the strings of the JSON syntax are templates.
+func escapeTemplate(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = escapeTemplate(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = escapeTemplate(v[k])
+		}
+	}
+	return v
+}
+
+// renderToFile renders the resource blocks into the file by the renderer, extending its existing content.
+func renderToFile(renderer config.ConfigRenderer, path string, blocks [][]byte) error {
+	// #nosec G304
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	b, err := renderer.Render(existing, blocks)
+	if err != nil {
+		return err
+	}
+	// #nosec G306
+	return os.WriteFile(path, b, 0600)
+}
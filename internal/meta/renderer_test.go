@@ -0,0 +1,102 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTFJSONRenderer(t *testing.T) {
+	blocks := [][]byte{
+		[]byte(`resource "azurerm_resource_group" "res-0" {
+  location = "westus"
+  name     = "rg"
+  tags = {
+    template = "$${foo}"
+  }
+}
+`),
+		[]byte(`resource "azurerm_virtual_network" "res-1" {
+  address_space       = ["10.0.0.0/16"]
+  location            = "westus"
+  name                = "vnet"
+  resource_group_name = azurerm_resource_group.res-0.name
+  provider            = azurerm.sub
+  subnet {
+    name = "subnet1"
+  }
+  subnet {
+    name = "subnet2"
+  }
+  lifecycle {
+    ignore_changes = [tags]
+  }
+  depends_on = [
+    azurerm_resource_group.res-0,
+  ]
+}
+`),
+	}
+	renderer := tfJSONRenderer{}
+	require.Equal(t, "main.tf.json", renderer.FileName("main.tf"))
+
+	b, err := renderer.Render(nil, blocks[:1])
+	require.NoError(t, err)
+	b, err = renderer.Render(b, blocks[1:])
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "resource": {
+    "azurerm_resource_group": {
+      "res-0": {
+        "location": "westus",
+        "name": "rg",
+        "tags": {
+          "template": "$${foo}"
+        }
+      }
+    },
+    "azurerm_virtual_network": {
+      "res-1": {
+        "address_space": [
+          "10.0.0.0/16"
+        ],
+        "depends_on": [
+          "azurerm_resource_group.res-0"
+        ],
+        "lifecycle": [
+          {
+            "ignore_changes": [
+              "tags"
+            ]
+          }
+        ],
+        "location": "westus",
+        "name": "vnet",
+        "provider": "azurerm.sub",
+        "resource_group_name": "${azurerm_resource_group.res-0.name}",
+        "subnet": [
+          {
+            "name": "subnet1"
+          },
+          {
+            "name": "subnet2"
+          }
+        ]
+      }
+    }
+  }
+}
+`, string(b))
+}
+
+func TestRenderToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.tf")
+	block := []byte("resource \"azurerm_resource_group\" \"res-0\" {\n}\n")
+	require.NoError(t, renderToFile(hclRenderer{}, path, [][]byte{block}))
+	require.NoError(t, renderToFile(hclRenderer{}, path, [][]byte{block}))
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(block)+"\n"+string(block)+"\n", string(b))
+}
@@ -0,0 +1,278 @@
+package meta
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/magodo/armid"
+	"github.com/tidwall/gjson"
+)
+
+// resourceNameData is the data that the resource name template is executed with.
+type resourceNameData struct {
+	// Index is the index of the resource in the listed resources
+	Index int
+	// TypeIndex is the index of the resource among the listed resources of the same TF resource type
+	TypeIndex int
+	// Type is the TF resource type, which is empty if the resource is unresolved
+	Type string
+	// Name is the Azure resource name
+	Name string
+	// ResourceGroup is the name of the resource group that the resource belongs to
+	ResourceGroup string
+	// Parent is the name of the parent resource, which is the resource group for the top level resources
+	Parent string
+	// Tags are the tags of the Azure resource
+	Tags map[string]string
+}
+
+var resourceNameFuncs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"replace": strings.ReplaceAll,
+}
+
+var invalidResourceNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// ValidateResourceNamePattern validates the resource name pattern, which is either a prefix (with an optional "*" to be replaced by the index), or a Go template (containing "{{").
+func ValidateResourceNamePattern(p string) error {
+	if !strings.Contains(p, "{{") {
+		return nil
+	}
+	tmpl, err := template.New("name").Funcs(resourceNameFuncs).Option("missingkey=zero").Parse(p)
+	if err != nil {
+		return fmt.Errorf("parsing the resource name template: %v", err)
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, resourceNameData{Tags: map[string]string{}}); err != nil {
+		return fmt.Errorf("executing the resource name template: %v", err)
+	}
+	return nil
+}
+
+const (
+	// NameFromPattern names the resources by the resource name pattern, which is the default.
+	NameFromPattern = "pattern"
+	// NameFromAzureName names the resources by their Azure resource names.
+	NameFromAzureName = "azure-name"
+	// NameFromAzureNameKebab names the resources by their Azure resource names in kebab case, e.g. "myVNet01" is named as "my-vnet01".
+	NameFromAzureNameKebab = "azure-name-kebab"
+	// NameFromTypeAndName names the resources by their TF resource types without the provider prefix, and their Azure resource names, e.g. "virtual_network-vnet1".
+	NameFromTypeAndName = "type-and-name"
+)
+
+// NameFromStrategies are the supported builtin naming strategies.
+var NameFromStrategies = []string{NameFromPattern, NameFromAzureName, NameFromAzureNameKebab, NameFromTypeAndName}
+
+// ValidateNameFrom validates the builtin naming strategy, where empty means NameFromPattern.
+func ValidateNameFrom(nameFrom string) error {
+	if nameFrom == "" {
+		return nil
+	}
+	for _, v := range NameFromStrategies {
+		if nameFrom == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported naming strategy %q, must be one of: %s", nameFrom, strings.Join(NameFromStrategies, ", "))
+}
+
+var (
+	kebabWordBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	kebabSeparators   = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// kebabCase converts the name to kebab case, e.g. "myVNet_01" to "my-vnet-01".
+func kebabCase(name string) string {
+	name = kebabWordBoundary.ReplaceAllString(name, "${1}-${2}")
+	return strings.Trim(kebabSeparators.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// builtinNamingStrategy returns the builtin naming strategy other than the NameFromPattern.
+func builtinNamingStrategy(nameFrom string) config.NamingStrategy {
+	switch nameFrom {
+	case NameFromAzureName:
+		return config.NamingStrategyFunc(func(info config.ResourceNameInfo) (string, error) {
+			return info.Name, nil
+		})
+	case NameFromAzureNameKebab:
+		return config.NamingStrategyFunc(func(info config.ResourceNameInfo) (string, error) {
+			return kebabCase(info.Name), nil
+		})
+	case NameFromTypeAndName:
+		return config.NamingStrategyFunc(func(info config.ResourceNameInfo) (string, error) {
+			if info.Type == "" {
+				return info.Name, nil
+			}
+			_, rt, _ := strings.Cut(info.Type, "_")
+			return rt + "-" + info.Name, nil
+		})
+	}
+	return nil
+}
+
+// templateNamingStrategy names the resources by the Go template of the resource name pattern.
+type templateNamingStrategy struct {
+	tmpl *template.Template
+}
+
+func (s templateNamingStrategy) Name(info config.ResourceNameInfo) (string, error) {
+	data := resourceNameData{
+		Index:         info.Index,
+		TypeIndex:     info.TypeIndex,
+		Type:          info.Type,
+		Name:          info.Name,
+		ResourceGroup: info.ResourceGroup,
+		Parent:        info.Parent,
+		Tags:          info.Tags,
+	}
+	var buf bytes.Buffer
+	if err := s.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("executing the resource name template for %s: %v", info.Id, err)
+	}
+	return buf.String(), nil
+}
+
+// resourceNamer generates the TF resource names for the listed resources by the naming strategy.
+type resourceNamer struct {
+	// prefix and suffix are only used for the non-template resource name pattern, in which case the strategy is nil.
+	prefix   string
+	suffix   string
+	strategy config.NamingStrategy
+
+	index     int
+	typeIndex map[string]int
+	used      map[string]bool
+}
+
+// newResourceNamer returns the namer, which uses the custom strategy if specified, otherwise the builtin strategy named by nameFrom (defaults to the resource name pattern).
+func newResourceNamer(strategy config.NamingStrategy, nameFrom, pattern string) (*resourceNamer, error) {
+	if err := ValidateNameFrom(nameFrom); err != nil {
+		return nil, err
+	}
+	if err := ValidateResourceNamePattern(pattern); err != nil {
+		return nil, err
+	}
+	namer := &resourceNamer{
+		strategy:  strategy,
+		typeIndex: map[string]int{},
+		used:      map[string]bool{},
+	}
+	switch {
+	case strategy != nil:
+	case nameFrom != "" && nameFrom != NameFromPattern:
+		namer.strategy = builtinNamingStrategy(nameFrom)
+	case strings.Contains(pattern, "{{"):
+		namer.strategy = templateNamingStrategy{tmpl: template.Must(template.New("name").Funcs(resourceNameFuncs).Option("missingkey=zero").Parse(pattern))}
+	default:
+		namer.prefix, namer.suffix = resourceNamePattern(pattern)
+	}
+	return namer, nil
+}
+
+// Name returns the TF resource name for the next resource.
+// For the naming strategies, the name is sanitized to be a valid TF identifier. The name is suffixed by "-<n>" on collision with the former (or the reserved) names.
+func (n *resourceNamer) Name(res resourceset.TFResource, tags map[string]string) (string, error) {
+	index := n.index
+	n.index++
+	typeIndex := n.typeIndex[res.TFType]
+	n.typeIndex[res.TFType]++
+
+	if n.strategy == nil {
+		return n.unique(fmt.Sprintf("%s%d%s", n.prefix, index, n.suffix)), nil
+	}
+
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	info := config.ResourceNameInfo{
+		Id:        res.AzureId.String(),
+		Index:     index,
+		TypeIndex: typeIndex,
+		Type:      res.TFType,
+		Name:      resourceIdName(res.AzureId),
+		Tags:      tags,
+	}
+	if rg, ok := res.AzureId.RootScope().(*armid.ResourceGroup); ok {
+		info.ResourceGroup = rg.Name
+	}
+	if parent := res.AzureId.Parent(); parent != nil {
+		info.Parent = resourceIdName(parent)
+	} else if parent := res.AzureId.ParentScope(); parent != nil {
+		info.Parent = resourceIdName(parent)
+	}
+
+	name, err := n.strategy.Name(info)
+	if err != nil {
+		return "", err
+	}
+	name = invalidResourceNameChars.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || name[0] == '-' {
+		name = "res-" + name
+	}
+
+	return n.unique(name), nil
+}
+
+// reserve marks the names as used, e.g. by the resources already in the state, so that the later names don't collide with them.
+func (n *resourceNamer) reserve(names ...string) {
+	for _, name := range names {
+		n.used[name] = true
+	}
+}
+
+// unique suffixes the name by "-<n>" on collision with the used names, and marks it as used.
+// The names are kept unique across types, as the unresolved resources (or the resources whose type is changed interactively) can end up with any type.
+func (n *resourceNamer) unique(name string) string {
+	uniqueName := name
+	for i := 2; n.used[uniqueName]; i++ {
+		uniqueName = name + "-" + strconv.Itoa(i)
+	}
+	n.used[uniqueName] = true
+	return uniqueName
+}
+
+// stateResourceNames returns the names of the managed resources in the state, regardless of their types and modules.
+func stateResourceNames(state []byte) []string {
+	var names []string
+	for _, res := range gjson.GetBytes(state, "resources").Array() {
+		if res.Get("mode").String() != "managed" {
+			continue
+		}
+		names = append(names, res.Get("name").String())
+	}
+	return names
+}
+
+// resourceIdName returns the name of the resource (or the resource group), which is empty for the other root scopes.
+func resourceIdName(id armid.ResourceId) string {
+	if rg, ok := id.(*armid.ResourceGroup); ok {
+		return rg.Name
+	}
+	if names := id.Names(); len(names) != 0 {
+		return names[len(names)-1]
+	}
+	return ""
+}
+
+// resourceTags returns the tags of the Azure resources in the resource set (as listed by Azure Resource Graph), keyed by the uppercased Azure resource id.
+func resourceTags(rset *resourceset.AzureResourceSet) map[string]map[string]string {
+	out := map[string]map[string]string{}
+	for _, res := range rset.Resources {
+		tags, ok := res.Properties["tags"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		m := map[string]string{}
+		for k, v := range tags {
+			m[k] = fmt.Sprint(v)
+		}
+		out[strings.ToUpper(res.Id.String())] = m
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package meta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceNamer(t *testing.T) {
+	mustParseId := func(id string) armid.ResourceId {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return azureId
+	}
+	resources := []resourceset.TFResource{
+		{
+			AzureId: mustParseId("/subscriptions/123/resourceGroups/rg"),
+			TFType:  "azurerm_resource_group",
+		},
+		{
+			AzureId: mustParseId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"),
+			TFType:  "azurerm_virtual_network",
+		},
+		{
+			AzureId: mustParseId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/default"),
+			TFType:  "azurerm_subnet",
+		},
+		{
+			AzureId: mustParseId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet2/subnets/default"),
+			TFType:  "azurerm_subnet",
+		},
+		{
+			AzureId: mustParseId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/1st.foo"),
+		},
+	}
+	tags := map[string]map[string]string{
+		"/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.NETWORK/VIRTUALNETWORKS/VNET": {"env": "prod"},
+	}
+
+	cases := []struct {
+		name     string
+		strategy config.NamingStrategy
+		nameFrom string
+		pattern  string
+		reserved []string
+		expect   []string
+	}{
+		{
+			name:    "prefix",
+			pattern: "res-",
+			expect:  []string{"res-0", "res-1", "res-2", "res-3", "res-4"},
+		},
+		{
+			name:    "prefix and suffix",
+			pattern: "res-*-x",
+			expect:  []string{"res-0-x", "res-1-x", "res-2-x", "res-3-x", "res-4-x"},
+		},
+		{
+			name:    "template with collision",
+			pattern: "{{ .Name }}",
+			expect:  []string{"rg", "vnet", "default", "default-2", "res-1st_foo"},
+		},
+		{
+			name:    "template with tags and parent",
+			pattern: `{{ with .Tags.env }}{{ . }}-{{ end }}{{ .Parent }}-{{ lower .Name }}`,
+			expect:  []string{"res--rg", "prod-rg-vnet", "vnet-default", "vnet2-default", "rg-1st_foo"},
+		},
+		{
+			name:    "template with type index",
+			pattern: `{{ replace .Type "azurerm_" "" }}{{ .TypeIndex }}`,
+			expect:  []string{"resource_group0", "virtual_network0", "subnet0", "subnet1", "res-0"},
+		},
+		{
+			name:     "prefix with reserved names",
+			pattern:  "res-",
+			reserved: []string{"res-0", "res-2"},
+			expect:   []string{"res-0-2", "res-1", "res-2-2", "res-3", "res-4"},
+		},
+		{
+			name:     "template with reserved names",
+			pattern:  "{{ .Name }}",
+			reserved: []string{"vnet", "default"},
+			expect:   []string{"rg", "vnet-2", "default-2", "default-3", "res-1st_foo"},
+		},
+		{
+			name:     "name from pattern",
+			nameFrom: NameFromPattern,
+			pattern:  "res-",
+			expect:   []string{"res-0", "res-1", "res-2", "res-3", "res-4"},
+		},
+		{
+			name:     "name from azure name",
+			nameFrom: NameFromAzureName,
+			pattern:  "res-",
+			expect:   []string{"rg", "vnet", "default", "default-2", "res-1st_foo"},
+		},
+		{
+			name:     "name from azure name in kebab case",
+			nameFrom: NameFromAzureNameKebab,
+			pattern:  "res-",
+			expect:   []string{"rg", "vnet", "default", "default-2", "res-1st-foo"},
+		},
+		{
+			name:     "name from type and name",
+			nameFrom: NameFromTypeAndName,
+			pattern:  "res-",
+			expect:   []string{"resource_group-rg", "virtual_network-vnet", "subnet-default", "subnet-default-2", "res-1st_foo"},
+		},
+		{
+			name: "custom strategy takes precedence",
+			strategy: config.NamingStrategyFunc(func(info config.ResourceNameInfo) (string, error) {
+				return info.ResourceGroup + "." + info.Name, nil
+			}),
+			nameFrom: NameFromAzureName,
+			pattern:  "res-",
+			expect:   []string{"rg_rg", "rg_vnet", "rg_default", "rg_default-2", "rg_1st_foo"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			namer, err := newResourceNamer(tt.strategy, tt.nameFrom, tt.pattern)
+			require.NoError(t, err)
+			namer.reserve(tt.reserved...)
+			var actual []string
+			for _, res := range resources {
+				name, err := namer.Name(res, tags[strings.ToUpper(res.AzureId.String())])
+				require.NoError(t, err)
+				actual = append(actual, name)
+			}
+			require.Equal(t, tt.expect, actual)
+		})
+	}
+}
+
+func TestStateResourceNames(t *testing.T) {
+	state := `{
+  "version": 4,
+  "resources": [
+    {"mode": "managed", "type": "azurerm_resource_group", "name": "res-0", "instances": []},
+    {"mode": "data", "type": "azurerm_client_config", "name": "current", "instances": []},
+    {"module": "module.rg", "mode": "managed", "type": "azurerm_virtual_network", "name": "res-1", "instances": []}
+  ]
+}`
+	require.Equal(t, []string{"res-0", "res-1"}, stateResourceNames([]byte(state)))
+	require.Empty(t, stateResourceNames(nil))
+}
+
+func TestKebabCase(t *testing.T) {
+	require.Equal(t, "my-vnet01", kebabCase("myVNet01"))
+	require.Equal(t, "my-vnet-01", kebabCase("myVNet_01"))
+	require.Equal(t, "storage-account", kebabCase("Storage.Account"))
+	require.Equal(t, "", kebabCase("--"))
+}
+
+func TestValidateNameFrom(t *testing.T) {
+	require.NoError(t, ValidateNameFrom(""))
+	require.NoError(t, ValidateNameFrom(NameFromAzureNameKebab))
+	require.EqualError(t, ValidateNameFrom("foo"), `unsupported naming strategy "foo", must be one of: pattern, azure-name, azure-name-kebab, type-and-name`)
+}
@@ -0,0 +1,105 @@
+package meta
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// ExportedResource is the outcome of exporting a resource.
+type ExportedResource struct {
+	// AzureResourceId is the Azure resource id
+	AzureResourceId string
+	// TFResourceId is the TF resource id
+	TFResourceId string
+	// TFAddr is the address of the resource in the output directory, which is prefixed by the module path and the child module (if any)
+	TFAddr string
+	// HCL is the generated config of the resource, which is empty if the resource failed to import
+	HCL []byte
+	// Error is the import error
+	Error error
+}
+
+// ExportResult is the structured result of the export, which is accumulated across the imports and config generations (e.g. in chunks).
+type ExportResult struct {
+	// Resources are the resources that are either exported or failed to import, sorted by the Azure resource id. The skipped resources are not included.
+	Resources []ExportedResource
+}
+
+// Exported returns the resources that are exported successfully.
+func (r ExportResult) Exported() []ExportedResource {
+	var out []ExportedResource
+	for _, res := range r.Resources {
+		if res.Error == nil {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// Failed returns the resources that failed to import.
+func (r ExportResult) Failed() []ExportedResource {
+	var out []ExportedResource
+	for _, res := range r.Resources {
+		if res.Error != nil {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// recordImportResults records the import failures of the items, a former failure is cleared once the item is imported (e.g. re-imported interactively).
+func (meta baseMeta) recordImportResults(items []*ImportItem) {
+	for _, item := range items {
+		if item.Skip() {
+			continue
+		}
+		key := strings.ToUpper(item.AzureResourceID.String())
+		if item.ImportError != nil {
+			meta.importFailures[key] = *item
+		} else {
+			delete(meta.importFailures, key)
+		}
+	}
+}
+
+// recordGeneratedHCL records the generated config of each resource.
+func (meta baseMeta) recordGeneratedHCL(cfgs ConfigInfos) {
+	for _, cfg := range cfgs {
+		meta.generatedHCL[strings.ToUpper(cfg.AzureResourceID.String())] = hclwrite.Format(cfg.hcl.Bytes())
+	}
+}
+
+func (meta baseMeta) Result() ExportResult {
+	resources := map[string]ExportedResource{}
+	for _, item := range meta.generatedList.Imported() {
+		key := strings.ToUpper(item.AzureResourceID.String())
+		resources[key] = ExportedResource{
+			AzureResourceId: item.AzureResourceID.String(),
+			TFResourceId:    item.TFResourceId,
+			TFAddr:          meta.resourceAddr(item),
+			HCL:             meta.generatedHCL[key],
+		}
+	}
+	for key, item := range meta.importFailures {
+		if _, ok := resources[key]; ok {
+			continue
+		}
+		resources[key] = ExportedResource{
+			AzureResourceId: item.AzureResourceID.String(),
+			TFResourceId:    item.TFResourceId,
+			TFAddr:          meta.resourceAddr(item),
+			Error:           item.ImportError,
+		}
+	}
+
+	result := ExportResult{Resources: []ExportedResource{}}
+	for _, res := range resources {
+		result.Resources = append(result.Resources, res)
+	}
+	sort.Slice(result.Resources, func(i, j int) bool {
+		return result.Resources[i].AzureResourceId < result.Resources[j].AzureResourceId
+	})
+	return result
+}
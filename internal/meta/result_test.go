@@ -0,0 +1,64 @@
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResult(t *testing.T) {
+	meta := baseMeta{
+		generatedHCL:   map[string][]byte{},
+		importFailures: map[string]ImportItem{},
+	}
+
+	var items []*ImportItem
+	for i, id := range []string{
+		"/subscriptions/123/resourceGroups/rg1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/foos/foo1",
+	} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		items = append(items, &ImportItem{AzureResourceID: azureId, TFResourceId: id})
+		if i != 2 {
+			items[i].TFAddr = tfaddr.TFAddr{Type: "azurerm_foo", Name: fmt.Sprintf("res-%d", i)}
+		}
+	}
+	items[0].Imported = true
+	items[1].ImportError = errors.New("import failed")
+	meta.recordImportResults(items)
+
+	f := hclwrite.NewEmptyFile()
+	f.Body().AppendNewBlock("resource", []string{"azurerm_foo", "res-0"})
+	meta.recordGeneratedHCL(ConfigInfos{{ImportItem: *items[0], hcl: f}})
+	meta.generatedList = ImportList{*items[0], *items[1], *items[2]}
+
+	result := meta.Result()
+	require.Equal(t, []ExportedResource{
+		{
+			AzureResourceId: items[0].AzureResourceID.String(),
+			TFResourceId:    items[0].TFResourceId,
+			TFAddr:          "azurerm_foo.res-0",
+			HCL:             []byte("resource \"azurerm_foo\" \"res-0\" {\n}\n"),
+		},
+		{
+			AzureResourceId: items[1].AzureResourceID.String(),
+			TFResourceId:    items[1].TFResourceId,
+			TFAddr:          "azurerm_foo.res-1",
+			Error:           items[1].ImportError,
+		},
+	}, result.Resources)
+	require.Len(t, result.Exported(), 1)
+	require.Len(t, result.Failed(), 1)
+
+	// The failure is cleared once the resource is re-imported
+	items[1].ImportError = nil
+	meta.recordImportResults(items[1:2])
+	require.Len(t, meta.Result().Resources, 1)
+}
@@ -0,0 +1,21 @@
+package meta
+
+import (
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+)
+
+// WithRetryPolicy returns the client option whose retry options are overridden by the non-zero fields of the retry policy.
+// The retry policy of the Azure SDK backs off exponentially, and honors the "Retry-After" header of the throttled responses.
+func WithRetryPolicy(opt arm.ClientOptions, p config.RetryPolicy) arm.ClientOptions {
+	if p.MaxRetries != 0 {
+		opt.Retry.MaxRetries = int32(p.MaxRetries)
+	}
+	if p.BaseDelay != 0 {
+		opt.Retry.RetryDelay = p.BaseDelay
+	}
+	if p.MaxDelay != 0 {
+		opt.Retry.MaxRetryDelay = p.MaxDelay
+	}
+	return opt
+}
@@ -0,0 +1,47 @@
+package meta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRetryPolicy(t *testing.T) {
+	opt := arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Retry: policy.RetryOptions{MaxRetries: -1, RetryDelay: time.Second},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		policy config.RetryPolicy
+		expect policy.RetryOptions
+	}{
+		{
+			name:   "zero policy keeps the client option",
+			expect: policy.RetryOptions{MaxRetries: -1, RetryDelay: time.Second},
+		},
+		{
+			name:   "max retries only",
+			policy: config.RetryPolicy{MaxRetries: 10},
+			expect: policy.RetryOptions{MaxRetries: 10, RetryDelay: time.Second},
+		},
+		{
+			name:   "all set",
+			policy: config.RetryPolicy{MaxRetries: 5, BaseDelay: 2 * time.Second, MaxDelay: 2 * time.Minute},
+			expect: policy.RetryOptions{MaxRetries: 5, RetryDelay: 2 * time.Second, MaxRetryDelay: 2 * time.Minute},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, WithRetryPolicy(opt, tt.policy).Retry)
+		})
+	}
+	// The original client option is not mutated
+	require.Equal(t, int32(-1), opt.Retry.MaxRetries)
+}
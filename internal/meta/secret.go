@@ -0,0 +1,303 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// OnSecretRedact replaces the secrets in the generated config with RedactedValue.
+	OnSecretRedact = "redact"
+	// OnSecretVar extracts the attributes that contain secrets to sensitive variables.
+	OnSecretVar = "var"
+	// OnSecretFail fails the config generation if any secret is found.
+	OnSecretFail = "fail"
+)
+
+// OnSecretActions are the supported actions to take when secrets are found in the generated config.
+var OnSecretActions = []string{OnSecretRedact, OnSecretVar, OnSecretFail}
+
+// RedactedValue is what the secrets are replaced with by OnSecretRedact.
+const RedactedValue = "REDACTED"
+
+// secretAttributeRegex matches the names of the attributes that are meant to hold secrets (e.g. admin_password, client_secret), whose whole values are secrets.
+var secretAttributeRegex = regexp.MustCompile(`(?i)(^|_)(password|passwd|secret|access_key|account_key|shared_key|api_key|primary_key|secondary_key)$`)
+
+// secretAttributeKind is the kind of the secrets that are detected by the attribute names.
+const secretAttributeKind = "secret attribute"
+
+type secretPattern struct {
+	name string
+	re   *regexp.Regexp
+	// The replacement template of the match when redacting, which keeps the non-secret part of the match
+	replace string
+}
+
+var secretPatterns = []secretPattern{
+	{
+		name:    "connection string",
+		re:      regexp.MustCompile(`(?i)\b(AccountKey|SharedAccessKey|Password|Pwd)=[^;"\s]+`),
+		replace: "${1}=" + RedactedValue,
+	},
+	{
+		name:    "SAS token",
+		re:      regexp.MustCompile(`(?i)\bsig=[A-Za-z0-9%+/=]{16,}`),
+		replace: "sig=" + RedactedValue,
+	},
+	{
+		// E.g. storage account keys, Cosmos DB keys, which are 64 bytes base64 encoded
+		name:    "access key",
+		re:      regexp.MustCompile(`[A-Za-z0-9+/]{86}==`),
+		replace: RedactedValue,
+	},
+	{
+		name:    "private key",
+		re:      regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+		replace: RedactedValue,
+	},
+}
+
+// detectSecret returns the name of the first kind of secret found in the string, or empty if not found.
+func detectSecret(s string) string {
+	for _, p := range secretPatterns {
+		if p.re.MatchString(s) {
+			return p.name
+		}
+	}
+	return ""
+}
+
+func redactSecret(s string) string {
+	for _, p := range secretPatterns {
+		s = p.re.ReplaceAllString(s, p.replace)
+	}
+	return s
+}
+
+// secretFinding is an attribute of a generated resource that contains a secret.
+type secretFinding struct {
+	addr string
+	// The attribute path, e.g. "site_config.0.app_setting"
+	path string
+	kind string
+}
+
+// secretAllowRule exempts the matched attributes from the secret detection. The fields that are set must all match, and at least one of them is set.
+type secretAllowRule struct {
+	// The TF resource type, e.g. "azurerm_linux_web_app"
+	ResourceType string `json:"resource_type,omitempty"`
+	// The attribute path without the block indexes, e.g. "connection_string.value"
+	Attribute string `json:"attribute,omitempty"`
+	// The regexp pattern that is matched against the string value
+	ValuePattern string `json:"value_pattern,omitempty"`
+
+	valueRegexp *regexp.Regexp
+}
+
+type secretAllowlist []secretAllowRule
+
+// loadSecretAllowlist loads the secret allowlist file, which is a JSON array of secretAllowRule.
+func loadSecretAllowlist(path string) (secretAllowlist, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the secret allowlist file %s: %v", path, err)
+	}
+	var allowlist secretAllowlist
+	if err := json.Unmarshal(b, &allowlist); err != nil {
+		return nil, fmt.Errorf("unmarshalling the secret allowlist file %s: %v", path, err)
+	}
+	for i, rule := range allowlist {
+		if rule.ResourceType == "" && rule.Attribute == "" && rule.ValuePattern == "" {
+			return nil, fmt.Errorf("the %d-th rule of the secret allowlist has nothing specified", i)
+		}
+		if rule.ValuePattern != "" {
+			p, err := regexp.Compile(rule.ValuePattern)
+			if err != nil {
+				return nil, fmt.Errorf("compiling the value_pattern of the %d-th rule of the secret allowlist: %v", i, err)
+			}
+			allowlist[i].valueRegexp = p
+		}
+	}
+	return allowlist, nil
+}
+
+// allows tells whether the attribute is exempted from the secret detection. The path contains no block indexes. The value pattern only matches the string values.
+func (allowlist secretAllowlist) allows(tfType, path string, val cty.Value) bool {
+	for _, rule := range allowlist {
+		if rule.ResourceType != "" && rule.ResourceType != tfType {
+			continue
+		}
+		if rule.Attribute != "" && rule.Attribute != path {
+			continue
+		}
+		if rule.valueRegexp != nil && (val.Type() != cty.String || !rule.valueRegexp.MatchString(val.AsString())) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func (f secretFinding) String() string {
+	return fmt.Sprintf("%s: %s (%s)", f.addr, f.path, f.kind)
+}
+
+// secretAddon scans the constant attribute values of the generated config for secrets (connection strings, keys, SAS tokens), and handles them by the OnSecret action.
+// It runs as a config transformer, so that the secrets never reach the generated files.
+func (meta baseMeta) secretAddon(configs ConfigInfos) (ConfigInfos, error) {
+	if meta.onSecret == "" {
+		return configs, nil
+	}
+	var findings []secretFinding
+	out := make(ConfigInfos, len(configs))
+	for i, cfg := range configs {
+		addr := cfg.TFAddr.String()
+		vars := map[string]bool{}
+		err := hclBlockWalkConstAttributes(cfg.hcl.Body().Blocks()[0].Body(), nil, func(body *hclwrite.Body, path []string, name string, val cty.Value) {
+			kind := detectValueSecret(val)
+			if kind == "" {
+				kind = detectAttributeSecret(name, val)
+			}
+			if kind == "" {
+				return
+			}
+			// Drop the block indexes from the path
+			var keys []string
+			for i := 0; i < len(path); i += 2 {
+				keys = append(keys, path[i])
+			}
+			if meta.secretAllowlist.allows(cfg.TFAddr.Type, strings.Join(append(keys, name), "."), val) {
+				return
+			}
+			finding := secretFinding{addr: addr, path: strings.Join(append(path, name), "."), kind: kind}
+			findings = append(findings, finding)
+			meta.secretFindings[finding] = true
+			switch meta.onSecret {
+			case OnSecretRedact:
+				if kind == secretAttributeKind {
+					body.SetAttributeValue(name, cty.StringVal(RedactedValue))
+					return
+				}
+				body.SetAttributeValue(name, redactValue(val))
+			case OnSecretVar:
+				varName := strings.ReplaceAll(cfg.TFAddr.Name, "-", "_") + "_" + strings.Join(append(path, name), "_")
+				body.SetAttributeTraversal(name, hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: varName}})
+				vars[varName] = val.Type() == cty.String
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", addr, err)
+		}
+		for _, name := range sortedVarNames(vars) {
+			vb := hclwrite.NewBlock("variable", []string{name})
+			if vars[name] {
+				vb.Body().SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+			}
+			vb.Body().SetAttributeValue("sensitive", cty.True)
+			cfg.hcl.Body().AppendNewline()
+			cfg.hcl.Body().AppendBlock(vb)
+		}
+		out[i] = cfg
+	}
+
+	if meta.onSecret == OnSecretFail && len(findings) != 0 {
+		var msgs []string
+		for _, f := range findings {
+			msgs = append(msgs, f.String())
+		}
+		return nil, fmt.Errorf("secrets found in the generated config:\n%s", strings.Join(msgs, "\n"))
+	}
+	return out, nil
+}
+
+// detectValueSecret returns the kind of the first secret found in the (nested) string values, or empty if not found.
+func detectValueSecret(val cty.Value) string {
+	var kind string
+	// #nosec G104
+	cty.Walk(val, func(_ cty.Path, v cty.Value) (bool, error) {
+		if kind != "" {
+			return false, nil
+		}
+		if v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+			kind = detectSecret(v.AsString())
+		}
+		return true, nil
+	})
+	return kind
+}
+
+// detectAttributeSecret returns secretAttributeKind if the attribute is meant to hold a secret by its name, and has a non-empty string value.
+func detectAttributeSecret(name string, val cty.Value) string {
+	if !secretAttributeRegex.MatchString(name) || !val.IsKnown() || val.IsNull() || val.Type() != cty.String || val.AsString() == "" {
+		return ""
+	}
+	return secretAttributeKind
+}
+
+func redactValue(val cty.Value) cty.Value {
+	// #nosec G104
+	val, _ = cty.Transform(val, func(_ cty.Path, v cty.Value) (cty.Value, error) {
+		if v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+			return cty.StringVal(redactSecret(v.AsString())), nil
+		}
+		return v, nil
+	})
+	return val
+}
+
+func sortedVarNames(vars map[string]bool) []string {
+	var names []string
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// secretsReportEntry is an attribute of a generated resource that contains a secret, and has been handled by the OnSecret action.
+type secretsReportEntry struct {
+	Address   string `json:"address"`
+	Attribute string `json:"attribute"`
+	Kind      string `json:"kind"`
+}
+
+type secretsReport struct {
+	Action string `json:"action"`
+	// The findings sorted by the address and the attribute
+	Findings []secretsReportEntry `json:"findings"`
+}
+
+// writeSecretsReport writes the secrets found in the generated config so far, which records what has been redacted (or extracted) without the secret values.
+func (meta baseMeta) writeSecretsReport() error {
+	report := secretsReport{Action: meta.onSecret, Findings: []secretsReportEntry{}}
+	for f := range meta.secretFindings {
+		report.Findings = append(report.Findings, secretsReportEntry{Address: f.addr, Attribute: f.path, Kind: f.kind})
+	}
+	sort.Slice(report.Findings, func(i, j int) bool {
+		fi, fj := report.Findings[i], report.Findings[j]
+		if fi.Address != fj.Address {
+			return fi.Address < fj.Address
+		}
+		return fi.Attribute < fj.Attribute
+	})
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the secrets report: %v", err)
+	}
+	path := filepath.Join(meta.outdir, SecretsReportFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing the secrets report to %s: %v", path, err)
+	}
+	return nil
+}
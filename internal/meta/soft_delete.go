@@ -0,0 +1,176 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+const (
+	// OnSoftDeletedWarn imports the resources that collide with the soft-deleted instances as usual, with a warning.
+	OnSoftDeletedWarn = "warn"
+	// OnSoftDeletedSkip skips importing the resources that collide with the soft-deleted instances, together with their children.
+	OnSoftDeletedSkip = "skip"
+)
+
+// OnSoftDeletedPolicies are the supported policies for the resources that collide with the soft-deleted instances.
+var OnSoftDeletedPolicies = []string{OnSoftDeletedWarn, OnSoftDeletedSkip}
+
+// softDeletedType describes how to list the soft-deleted instances of a resource type, per subscription.
+// The recovery services vaults are not covered, as their soft-deleted instances can only be listed per location.
+type softDeletedType struct {
+	kind       string
+	path       string
+	apiVersion string
+	// idProperty is the property that records the original resource id of the soft-deleted instance
+	idProperty string
+}
+
+var softDeletedTypes = []softDeletedType{
+	{kind: "Key Vault", path: "/providers/Microsoft.KeyVault/deletedVaults", apiVersion: "2022-07-01", idProperty: "vaultId"},
+	{kind: "API Management service", path: "/providers/Microsoft.ApiManagement/deletedservices", apiVersion: "2022-08-01", idProperty: "serviceId"},
+}
+
+// softDeletedResource is a soft-deleted instance, which still holds its name until purged.
+type softDeletedResource struct {
+	// The uppercased original resource id
+	id                 string
+	kind               string
+	deletionDate       string
+	scheduledPurgeDate string
+}
+
+func (res softDeletedResource) String() string {
+	s := "soft-deleted " + res.kind
+	var details []string
+	if res.deletionDate != "" {
+		details = append(details, "deleted at "+res.deletionDate)
+	}
+	if res.scheduledPurgeDate != "" {
+		details = append(details, "scheduled to be purged at "+res.scheduledPurgeDate)
+	}
+	if len(details) != 0 {
+		s += " (" + strings.Join(details, ", ") + ")"
+	}
+	return s
+}
+
+// listSoftDeleted lists the soft-deleted instances of the supported resource types in the subscriptions.
+func (meta baseMeta) listSoftDeleted(ctx context.Context) ([]softDeletedResource, error) {
+	client, err := arm.NewClient("meta.Client", "v0.0.0", meta.azureSDKCred, &meta.azureSDKClientOpt)
+	if err != nil {
+		return nil, fmt.Errorf("building the client: %v", err)
+	}
+
+	var out []softDeletedResource
+	for _, subscriptionId := range append([]string{meta.subscriptionId}, meta.aliasSubscriptionIds...) {
+		for _, typ := range softDeletedTypes {
+			l, err := listSubscriptionSoftDeleted(ctx, client, subscriptionId, typ)
+			if err != nil {
+				return nil, fmt.Errorf("listing the soft-deleted %ss: %v", typ.kind, err)
+			}
+			out = append(out, l...)
+		}
+	}
+	return out, nil
+}
+
+func listSubscriptionSoftDeleted(ctx context.Context, client *arm.Client, subscriptionId string, typ softDeletedType) ([]softDeletedResource, error) {
+	var out []softDeletedResource
+	next := fmt.Sprintf("%s/subscriptions/%s%s?api-version=%s", strings.TrimSuffix(client.Endpoint(), "/"), subscriptionId, typ.path, typ.apiVersion)
+	for next != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, next)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Pipeline().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+		var page struct {
+			Value []struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"value"`
+			NextLink string `json:"nextLink"`
+		}
+		if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+			return nil, err
+		}
+		for _, v := range page.Value {
+			id, _ := v.Properties[typ.idProperty].(string)
+			if id == "" {
+				continue
+			}
+			deletionDate, _ := v.Properties["deletionDate"].(string)
+			scheduledPurgeDate, _ := v.Properties["scheduledPurgeDate"].(string)
+			out = append(out, softDeletedResource{
+				id:                 strings.ToUpper(id),
+				kind:               typ.kind,
+				deletionDate:       normalizeSoftDeletedDate(deletionDate),
+				scheduledPurgeDate: normalizeSoftDeletedDate(scheduledPurgeDate),
+			})
+		}
+		next = page.NextLink
+	}
+	return out, nil
+}
+
+// normalizeSoftDeletedDate formats the date in RFC3339 (UTC), which is returned as is if not parsable.
+func normalizeSoftDeletedDate(v string) string {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return v
+}
+
+// softDeletedCollision returns the soft-deleted instance that the resource, or any of its ancestors, collides with, or nil if none.
+func softDeletedCollision(softDeleted []softDeletedResource, id string) *softDeletedResource {
+	id = strings.ToUpper(id)
+	for i, res := range softDeleted {
+		if id == res.id || strings.HasPrefix(id, res.id+"/") {
+			return &softDeleted[i]
+		}
+	}
+	return nil
+}
+
+// applySoftDeleted records the soft-deleted instance that each resource in the list (or its ancestor) collides with, e.g. the resource is listed by a stale
+// Azure Resource Graph, or a soft-deleted Key Vault of the same name still exists. The colliding resources are skipped, together with their children,
+// if the OnSoftDeleted policy says so, rather than failing the import with the confusing provider errors.
+func (meta baseMeta) applySoftDeleted(ctx context.Context, l ImportList) (ImportList, error) {
+	if meta.onSoftDeleted == "" {
+		return l, nil
+	}
+	softDeleted, err := meta.listSoftDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(softDeleted, func(i, j int) bool {
+		return softDeleted[i].id < softDeleted[j].id
+	})
+	for i, item := range l {
+		res := softDeletedCollision(softDeleted, item.AzureResourceID.String())
+		if res == nil {
+			continue
+		}
+		l[i].SoftDeleted = res.String()
+		switch meta.onSoftDeleted {
+		case OnSoftDeletedWarn:
+			log.Printf("[WARN] %s collides with a %s", item.AzureResourceID, l[i].SoftDeleted)
+		case OnSoftDeletedSkip:
+			log.Printf("[INFO] Skipping %s as it collides with a %s", item.AzureResourceID, l[i].SoftDeleted)
+			l[i].TFAddr.Type = ""
+		}
+	}
+	return l, nil
+}
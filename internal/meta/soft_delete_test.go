@@ -0,0 +1,30 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftDeletedCollision(t *testing.T) {
+	softDeleted := []softDeletedResource{
+		{id: "/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG1/PROVIDERS/MICROSOFT.KEYVAULT/VAULTS/KV1", kind: "Key Vault", deletionDate: "2024-01-01T00:00:00Z", scheduledPurgeDate: "2024-03-31T00:00:00Z"},
+	}
+	cases := []struct {
+		id     string
+		expect string
+	}{
+		{id: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1", expect: "soft-deleted Key Vault (deleted at 2024-01-01T00:00:00Z, scheduled to be purged at 2024-03-31T00:00:00Z)"},
+		{id: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1/accessPolicies/default", expect: "soft-deleted Key Vault (deleted at 2024-01-01T00:00:00Z, scheduled to be purged at 2024-03-31T00:00:00Z)"},
+		{id: "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv10", expect: ""},
+		{id: "/subscriptions/123/resourceGroups/rg1", expect: ""},
+	}
+	for _, tt := range cases {
+		var got string
+		if res := softDeletedCollision(softDeleted, tt.id); res != nil {
+			got = res.String()
+		}
+		require.Equal(t, tt.expect, got, tt.id)
+	}
+	require.Equal(t, "2024-03-31T00:00:00Z", normalizeSoftDeletedDate("2024-03-31T08:00:00+08:00"))
+}
@@ -0,0 +1,302 @@
+package meta
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// SplitByResourceGroup generates the resources of each resource group into a child module.
+	SplitByResourceGroup = "resource-group"
+	// SplitByType generates the resources of each TF resource type into a child module.
+	SplitByType = "type"
+)
+
+// SplitByOptions are the supported ways to split the generated config into child modules.
+var SplitByOptions = []string{SplitByResourceGroup, SplitByType}
+
+// splitModuleOutOfResourceGroup is the child module of the resources that are not in any resource group, when splitting by resource group.
+const splitModuleOutOfResourceGroup = "subscription"
+
+// crossModuleRef is a reference from a resource in a child module to the id of a resource in another child module, which is passed
+// from the output of the target module to the variable of the referencing module by the root module.
+type crossModuleRef struct {
+	module       string
+	targetModule string
+	target       tfaddr.TFAddr
+}
+
+// name is the name of both the output and the variable, e.g. "azurerm_virtual_network_res_1_id".
+func (ref crossModuleRef) name() string {
+	return strings.ReplaceAll(ref.target.Type+"_"+ref.target.Name, "-", "_") + "_id"
+}
+
+// splitsModules tells whether the resources are imported into the child modules of the root module, either split or by the module template.
+func (meta baseMeta) splitsModules() bool {
+	return meta.splitBy != "" || meta.moduleTemplate != ""
+}
+
+// splitModule returns the name of the child module that the resource is generated into, which is empty if the generated config is not split.
+func (meta baseMeta) splitModule(item ImportItem) string {
+	if meta.moduleTemplate != "" {
+		return meta.templateModule(item)
+	}
+	var name string
+	switch meta.splitBy {
+	case SplitByResourceGroup:
+		name = itemResourceGroup(item)
+	case SplitByType:
+		name = item.TFAddr.Type
+	}
+	if name == "" {
+		return ""
+	}
+	return moduleName(name)
+}
+
+// itemResourceGroup returns the resource group name of the item, or splitModuleOutOfResourceGroup if it is not in any resource group.
+func itemResourceGroup(item ImportItem) string {
+	if item.AzureResourceID != nil {
+		if rg, ok := item.AzureResourceID.RootScope().(*armid.ResourceGroup); ok {
+			return rg.Name
+		}
+	}
+	return splitModuleOutOfResourceGroup
+}
+
+// moduleName converts the name to a valid module name, which is lower cased.
+func moduleName(name string) string {
+	name = moduleNameSegment(name)
+	if !hclsyntax.ValidIdentifier(name) {
+		name = "_" + name
+	}
+	return name
+}
+
+func moduleNameSegment(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(s))
+}
+
+// resourceAddr returns the address of the resource in the output directory, which is prefixed by the module path and the child module (if split).
+func (meta baseMeta) resourceAddr(item ImportItem) string {
+	addr := item.TFAddr.String()
+	if module := meta.splitModule(item); module != "" {
+		addr = "module." + module + "." + addr
+	}
+	if meta.moduleAddr != "" {
+		addr = meta.moduleAddr + "." + addr
+	}
+	return addr
+}
+
+// moveStateToModules moves the resources of the items in the state, which are imported to the root module, to their child modules.
+// The resources that already exist at the target addresses (e.g. imported by a former attempt) are replaced.
+func (meta baseMeta) moveStateToModules(state []byte, items []*ImportItem) ([]byte, error) {
+	modules := map[string]string{}
+	for _, item := range items {
+		if item.Skip() || item.ImportError != nil {
+			continue
+		}
+		if module := meta.splitModule(*item); module != "" {
+			modules[item.TFAddr.String()] = "module." + module
+		}
+	}
+
+	resources := gjson.GetBytes(state, "resources").Array()
+	moved := map[string]bool{}
+	raws := make([]string, len(resources))
+	for i, res := range resources {
+		raws[i] = res.Raw
+		if res.Get("module").Exists() || res.Get("mode").String() != "managed" {
+			continue
+		}
+		addr := res.Get("type").String() + "." + res.Get("name").String()
+		module, ok := modules[addr]
+		if !ok {
+			continue
+		}
+		raw, err := sjson.Set(res.Raw, "module", module)
+		if err != nil {
+			return nil, fmt.Errorf("moving %s to %s: %v", addr, module, err)
+		}
+		raws[i] = raw
+		moved[module+"."+addr] = true
+	}
+	var out []string
+	for i, res := range resources {
+		if raws[i] == res.Raw && moved[res.Get("module").String()+"."+res.Get("type").String()+"."+res.Get("name").String()] {
+			continue
+		}
+		out = append(out, raws[i])
+	}
+	state, err := sjson.SetRawBytes(state, "resources", []byte("["+strings.Join(out, ",")+"]"))
+	if err != nil {
+		return nil, fmt.Errorf("setting the resources of the state: %v", err)
+	}
+	return state, nil
+}
+
+// splitDependencies returns the dependencies of the config that are in the same child module, as the depends_on can't refer to the resources of another module.
+func (meta baseMeta) splitDependencies(cfg ConfigInfo, configSet map[string]ConfigInfo) []Dependency {
+	if !meta.splitsModules() {
+		return cfg.DependsOn
+	}
+	module := meta.splitModule(cfg.ImportItem)
+	var deps []Dependency
+	for _, dep := range cfg.DependsOn {
+		sameModule := true
+		for _, id := range dep.Candidates {
+			if meta.splitModule(configSet[id].ImportItem) != module {
+				sameModule = false
+				break
+			}
+		}
+		if sameModule {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// generateSplitConfig appends the configs to the main config files of their child modules, and regenerates the wiring of the child modules.
+// The layout is:
+//
+//	<outdir>/
+//	├── main.tf           (calls the child modules, passing the ids referenced across them)
+//	└── modules/
+//	    ├── <module1>/    (main.tf, terraform.tf, variables.tf, outputs.tf)
+//	    └── <module2>/ ...
+func (meta baseMeta) generateSplitConfig(cfgs ConfigInfos) error {
+	bufs := map[string]*bytes.Buffer{}
+	for _, cfg := range cfgs {
+		module := meta.splitModule(cfg.ImportItem)
+		meta.splitModules[module] = true
+		if bufs[module] == nil {
+			bufs[module] = bytes.NewBuffer([]byte{})
+		}
+		if _, err := cfg.DumpHCL(bufs[module]); err != nil {
+			return err
+		}
+		bufs[module].Write([]byte("\n"))
+	}
+	for module, buf := range bufs {
+		dir := filepath.Join(meta.outdir, SplitModulesDirName, module)
+		// #nosec G301
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("creating directory %s: %v", dir, err)
+		}
+		if err := appendToFile(filepath.Join(dir, meta.outputFileNames.MainFileName), buf.String()); err != nil {
+			return fmt.Errorf("generating main configuration file of module %s: %w", module, err)
+		}
+	}
+
+	files := meta.splitModuleFiles()
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(meta.outdir, filepath.FromSlash(name))
+		// #nosec G306
+		if err := os.WriteFile(path, files[name], 0644); err != nil {
+			return fmt.Errorf("writing %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// splitModuleFiles builds the files that wire the child modules, keyed by the slash separated path relative to the output directory.
+// The extracted variables are declared in each child module, and passed through from the root module.
+func (meta baseMeta) splitModuleFiles() map[string][]byte {
+	var modules []string
+	for module := range meta.splitModules {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	var refs []crossModuleRef
+	for ref := range meta.crossModuleRefs {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].name() < refs[j].name()
+	})
+
+	var vars []string
+	for _, name := range meta.variables {
+		vars = append(vars, name)
+	}
+	sort.Strings(vars)
+
+	files := map[string][]byte{}
+	root := hclwrite.NewEmptyFile()
+	for i, module := range modules {
+		dir := SplitModulesDirName + "/" + module
+		files[dir+"/terraform.tf"] = hclwrite.Format([]byte(meta.buildTerraformConfigForImportDir()))
+
+		if i != 0 {
+			root.Body().AppendNewline()
+		}
+		call := root.Body().AppendNewBlock("module", []string{module}).Body()
+		call.SetAttributeValue("source", cty.StringVal("./"+dir))
+
+		variables := hclwrite.NewEmptyFile()
+		declare := func(name string) {
+			if len(variables.Body().Blocks()) != 0 {
+				variables.Body().AppendNewline()
+			}
+			variables.Body().AppendNewBlock("variable", []string{name}).Body().SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+		}
+		for _, name := range vars {
+			declare(name)
+			call.SetAttributeTraversal(name, variableTraversal(name))
+		}
+		outputs := hclwrite.NewEmptyFile()
+		outputNames := map[string]bool{}
+		for _, ref := range refs {
+			if ref.module == module {
+				declare(ref.name())
+				call.SetAttributeTraversal(ref.name(), hcl.Traversal{hcl.TraverseRoot{Name: "module"}, hcl.TraverseAttr{Name: ref.targetModule}, hcl.TraverseAttr{Name: ref.name()}})
+			}
+			// The same output might be referenced by multiple modules
+			if ref.targetModule == module && !outputNames[ref.name()] {
+				outputNames[ref.name()] = true
+				if len(outputs.Body().Blocks()) != 0 {
+					outputs.Body().AppendNewline()
+				}
+				outputs.Body().AppendNewBlock("output", []string{ref.name()}).Body().SetAttributeTraversal("value", hcl.Traversal{
+					hcl.TraverseRoot{Name: ref.target.Type},
+					hcl.TraverseAttr{Name: ref.target.Name},
+					hcl.TraverseAttr{Name: "id"},
+				})
+			}
+		}
+		if len(variables.Body().Blocks()) != 0 {
+			files[dir+"/variables.tf"] = hclwrite.Format(variables.Bytes())
+		}
+		if len(outputs.Body().Blocks()) != 0 {
+			files[dir+"/outputs.tf"] = hclwrite.Format(outputs.Bytes())
+		}
+	}
+	files[meta.outputFileNames.MainFileName] = hclwrite.Format(root.Bytes())
+	return files
+}
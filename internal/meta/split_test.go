@@ -0,0 +1,172 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestSplitModule(t *testing.T) {
+	item := func(id, tfType string) ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return ImportItem{AzureResourceID: azureId, TFAddr: tfaddr.TFAddr{Type: tfType, Name: "res-0"}}
+	}
+	rg := item("/subscriptions/123/resourceGroups/My.RG", "azurerm_resource_group")
+	vnet := item("/subscriptions/123/resourceGroups/My.RG/providers/Microsoft.Network/virtualNetworks/vnet", "azurerm_virtual_network")
+	rgNumeric := item("/subscriptions/123/resourceGroups/1rg", "azurerm_resource_group")
+	sub := item("/subscriptions/123/providers/Microsoft.Authorization/roleAssignments/ra", "azurerm_role_assignment")
+
+	meta := baseMeta{}
+	require.Equal(t, "", meta.splitModule(rg))
+	require.Equal(t, "azurerm_resource_group.res-0", meta.resourceAddr(rg))
+
+	meta.splitBy = SplitByResourceGroup
+	require.Equal(t, "my_rg", meta.splitModule(rg))
+	require.Equal(t, "my_rg", meta.splitModule(vnet))
+	require.Equal(t, "_1rg", meta.splitModule(rgNumeric))
+	require.Equal(t, "subscription", meta.splitModule(sub))
+	require.Equal(t, "module.my_rg.azurerm_virtual_network.res-0", meta.resourceAddr(vnet))
+
+	meta.splitBy = SplitByType
+	require.Equal(t, "azurerm_resource_group", meta.splitModule(rg))
+	require.Equal(t, "azurerm_virtual_network", meta.splitModule(vnet))
+	require.Equal(t, "", meta.splitModule(item("/subscriptions/123/resourceGroups/rg", "")))
+}
+
+func TestMoveStateToModules(t *testing.T) {
+	meta := baseMeta{splitBy: SplitByResourceGroup}
+	item := func(id, tfType, name string) *ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return &ImportItem{AzureResourceID: azureId, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}}
+	}
+	state := []byte(`{
+  "version": 4,
+  "serial": 3,
+  "resources": [
+    {"mode": "managed", "type": "azurerm_resource_group", "name": "res-0", "instances": [{"attributes": {"id": "new"}}]},
+    {"mode": "managed", "type": "azurerm_virtual_network", "name": "res-1", "instances": []},
+    {"module": "module.rg1", "mode": "managed", "type": "azurerm_resource_group", "name": "res-0", "instances": [{"attributes": {"id": "old"}}]},
+    {"mode": "data", "type": "azurerm_client_config", "name": "res-0", "instances": []}
+  ]
+}`)
+	out, err := meta.moveStateToModules(state, []*ImportItem{
+		item("/subscriptions/123/resourceGroups/rg1", "azurerm_resource_group", "res-0"),
+		item("/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet", "azurerm_virtual_network", "res-1"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), gjson.GetBytes(out, "serial").Int())
+	resources := gjson.GetBytes(out, "resources").Array()
+	require.Len(t, resources, 3)
+	require.Equal(t, "module.rg1", resources[0].Get("module").String())
+	require.Equal(t, "new", resources[0].Get("instances.0.attributes.id").String())
+	require.Equal(t, "module.rg2", resources[1].Get("module").String())
+	require.Equal(t, "data", resources[2].Get("mode").String())
+	require.False(t, resources[2].Get("module").Exists())
+}
+
+func TestGenerateSplitConfig(t *testing.T) {
+	const (
+		rgId     = "/subscriptions/123/resourceGroups/rg1"
+		vnetId   = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet"
+		subnetId = "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet/subnets/default"
+		nicId    = "/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/networkInterfaces/nic"
+	)
+	meta := baseMeta{
+		outdir:            t.TempDir(),
+		outputFileNames:   config.OutputFileNames{MainFileName: "main.tf"},
+		splitBy:           SplitByResourceGroup,
+		rewriteReferences: true,
+		splitModules:      map[string]bool{},
+		crossModuleRefs:   map[crossModuleRef]bool{},
+		variables: map[variableValue]string{
+			{key: "location", value: "westeurope"}: "location",
+		},
+	}
+	parse := func(input string) *hclwrite.File {
+		f, diags := hclwrite.ParseConfig([]byte(input), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return f
+	}
+	cfg := func(id, tfType, name, input string) ConfigInfo {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return ConfigInfo{
+			ImportItem: ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}},
+			hcl:        parse(input),
+		}
+	}
+	configs := ConfigInfos{
+		cfg(vnetId, "azurerm_virtual_network", "res-1", `resource "azurerm_virtual_network" "res-1" {
+  location = var.location
+}
+`),
+		cfg(subnetId, "azurerm_subnet", "res-2", `resource "azurerm_subnet" "res-2" {
+  virtual_network_id = "`+vnetId+`"
+}
+`),
+		cfg(nicId, "azurerm_network_interface", "res-3", `resource "azurerm_network_interface" "res-3" {
+  subnet_id = "`+subnetId+`"
+}
+`),
+	}
+	configs, err := meta.referenceAddon(configs)
+	require.NoError(t, err)
+	require.NoError(t, meta.generateSplitConfig(configs))
+
+	read := func(path string) string {
+		b, err := os.ReadFile(filepath.Join(meta.outdir, filepath.FromSlash(path)))
+		require.NoError(t, err)
+		return string(b)
+	}
+	require.Equal(t, `module "rg1" {
+  source   = "./modules/rg1"
+  location = var.location
+}
+
+module "rg2" {
+  source                  = "./modules/rg2"
+  location                = var.location
+  azurerm_subnet_res_2_id = module.rg1.azurerm_subnet_res_2_id
+}
+`, read("main.tf"))
+	require.Equal(t, `resource "azurerm_virtual_network" "res-1" {
+  location = var.location
+}
+
+resource "azurerm_subnet" "res-2" {
+  virtual_network_id = azurerm_virtual_network.res-1.id
+}
+
+`, read("modules/rg1/main.tf"))
+	require.Equal(t, `output "azurerm_subnet_res_2_id" {
+  value = azurerm_subnet.res-2.id
+}
+`, read("modules/rg1/outputs.tf"))
+	require.Equal(t, `resource "azurerm_network_interface" "res-3" {
+  subnet_id = var.azurerm_subnet_res_2_id
+}
+
+`, read("modules/rg2/main.tf"))
+	require.Equal(t, `variable "location" {
+  type = string
+}
+
+variable "azurerm_subnet_res_2_id" {
+  type = string
+}
+`, read("modules/rg2/variables.tf"))
+	_, err = os.Stat(filepath.Join(meta.outdir, "modules", "rg2", "outputs.tf"))
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(meta.outdir, "modules", "rg1", "terraform.tf"))
+	require.NoError(t, err)
+}
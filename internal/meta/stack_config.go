@@ -0,0 +1,113 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	StackConfigTypeSpacelift = "spacelift"
+	StackConfigTypeEnv0      = "env0"
+)
+
+// StackConfigTypes are the supported TACOS platforms that the stack config can be generated for.
+var StackConfigTypes = []string{StackConfigTypeSpacelift, StackConfigTypeEnv0}
+
+// writeStackConfig writes the stack/environment definition file of the TACOS platform to the output directory,
+// so that the exported workspace can be onboarded to the platform as is.
+func (meta baseMeta) writeStackConfig() error {
+	abs, err := filepath.Abs(meta.outdir)
+	if err != nil {
+		return fmt.Errorf("getting the absolute path of %s: %v", meta.outdir, err)
+	}
+	env := meta.stackConfigEnv()
+	// Empty means the local backend, e.g. the BackendType is left unset by the library callers.
+	backendType := meta.backendType
+	if backendType == "" {
+		backendType = "local"
+	}
+
+	var path, content string
+	switch meta.stackConfigType {
+	case StackConfigTypeSpacelift:
+		dir := filepath.Join(meta.outdir, SpaceliftConfigDirName)
+		// #nosec G301
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("creating directory %s: %v", dir, err)
+		}
+		path = filepath.Join(dir, "config.yml")
+		content = spaceliftConfig(filepath.Base(abs), env, backendType)
+	case StackConfigTypeEnv0:
+		path = filepath.Join(meta.outdir, Env0ConfigFileName)
+		content = env0Config(env, backendType)
+	default:
+		return fmt.Errorf("unknown stack config type: %s", meta.stackConfigType)
+	}
+
+	// #nosec G306
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("writing the stack config to %s: %v", path, err)
+	}
+	return nil
+}
+
+// stackConfigEnv returns the environment variables that the platform needs to run Terraform against the workspace.
+// The backend config is passed via TF_CLI_ARGS_init as it is not recorded in the terraform block.
+func (meta baseMeta) stackConfigEnv() [][2]string {
+	env := [][2]string{{"ARM_SUBSCRIPTION_ID", meta.subscriptionId}}
+	if len(meta.backendConfig) != 0 {
+		var args []string
+		for _, v := range meta.backendConfig {
+			args = append(args, "-backend-config="+v)
+		}
+		env = append(env, [2]string{"TF_CLI_ARGS_init", strings.Join(args, " ")})
+	}
+	return env
+}
+
+func spaceliftConfig(name string, env [][2]string, backendType string) string {
+	lines := []string{
+		"# Generated by aztfexport. See https://docs.spacelift.io/concepts/configuration/runtime-configuration for details.",
+	}
+	if backendType == "local" {
+		lines = append(lines, "# The state is to be managed by Spacelift, import the local state file when creating the stack.")
+	} else {
+		lines = append(lines, fmt.Sprintf("# The state is stored in the %q backend, disable \"Manage State\" when creating the stack.", backendType))
+	}
+	lines = append(lines,
+		`version: "1"`,
+		"stacks:",
+		"  "+strconv.Quote(name)+":",
+		"    environment:",
+	)
+	for _, kv := range env {
+		lines = append(lines, fmt.Sprintf("      %s: %s", kv[0], strconv.Quote(kv[1])))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func env0Config(env [][2]string, backendType string) string {
+	lines := []string{
+		"# Generated by aztfexport. See https://docs.env0.com/docs/custom-flows for details.",
+	}
+	if backendType == "local" {
+		lines = append(lines, "# The state is to be managed by env0, import the local state file when creating the environment.")
+	} else {
+		lines = append(lines, fmt.Sprintf("# The state is stored in the %q backend.", backendType))
+	}
+	lines = append(lines,
+		"version: 2",
+		"deploy:",
+		"  steps:",
+		"    setupVariables:",
+		"      after:",
+	)
+	for _, kv := range env {
+		cmd := fmt.Sprintf(`echo '%s=%s' >> $ENV0_ENV`, kv[0], strings.ReplaceAll(kv[1], `'`, `'\''`))
+		lines = append(lines, "        - "+strconv.Quote(cmd))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,49 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpaceliftConfig(t *testing.T) {
+	env := [][2]string{
+		{"ARM_SUBSCRIPTION_ID", "123"},
+		{"TF_CLI_ARGS_init", "-backend-config=key=foo"},
+	}
+	require.Equal(t, `# Generated by aztfexport. See https://docs.spacelift.io/concepts/configuration/runtime-configuration for details.
+# The state is stored in the "azurerm" backend, disable "Manage State" when creating the stack.
+version: "1"
+stacks:
+  "myrg":
+    environment:
+      ARM_SUBSCRIPTION_ID: "123"
+      TF_CLI_ARGS_init: "-backend-config=key=foo"
+`, spaceliftConfig("myrg", env, "azurerm"))
+}
+
+func TestEnv0Config(t *testing.T) {
+	env := [][2]string{
+		{"ARM_SUBSCRIPTION_ID", "123"},
+	}
+	require.Equal(t, `# Generated by aztfexport. See https://docs.env0.com/docs/custom-flows for details.
+# The state is to be managed by env0, import the local state file when creating the environment.
+version: 2
+deploy:
+  steps:
+    setupVariables:
+      after:
+        - "echo 'ARM_SUBSCRIPTION_ID=123' >> $ENV0_ENV"
+`, env0Config(env, "local"))
+}
+
+func TestWriteStackConfigEmptyBackendType(t *testing.T) {
+	dir := t.TempDir()
+	meta := baseMeta{outdir: dir, subscriptionId: "123", stackConfigType: StackConfigTypeEnv0}
+	require.NoError(t, meta.writeStackConfig())
+	b, err := os.ReadFile(filepath.Join(dir, Env0ConfigFileName))
+	require.NoError(t, err)
+	require.Contains(t, string(b), "# The state is to be managed by env0, import the local state file when creating the environment.")
+}
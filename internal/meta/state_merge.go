@@ -0,0 +1,94 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/magodo/tfmerge/tfmerge"
+	"github.com/magodo/workerpool"
+)
+
+// importState is the state file of an import directory, which is merged by the terraform of that import directory.
+type importState struct {
+	idx  int
+	path string
+}
+
+// mergeImportStates merges the state files of the import directories into the base state.
+// The state files are merged pairwise in rounds, each runs its merges concurrently (up to the parallelism), until a single state file is left,
+// which is then merged into the base state. This takes log2(N) rounds of merges among the small state files, instead of merging each of them
+// into the growing base state in turn.
+func (meta *baseMeta) mergeImportStates(ctx context.Context, states []importState) error {
+	if len(states) == 0 {
+		return nil
+	}
+	// Keep the merge order stable regardless of the order that the import directories finish.
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].idx < states[j].idx
+	})
+	state, err := reduceImportStates(states, meta.parallelism, func(dst, src importState) error {
+		// #nosec G304
+		base, err := os.ReadFile(dst.path)
+		if err != nil {
+			return fmt.Errorf("reading state file %s: %v", dst.path, err)
+		}
+		log.Printf("[DEBUG] Merging terraform state file %s to %s (tfmerge)", src.path, dst.path)
+		var merged []byte
+		err = meta.auditLog.Run(meta.importTFs[dst.idx], func() (err error) {
+			merged, err = tfmerge.Merge(ctx, meta.importTFs[dst.idx], base, src.path)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to merge state file %s to %s: %v", src.path, dst.path, err)
+		}
+		// #nosec G306
+		if err := os.WriteFile(dst.path, merged, 0644); err != nil {
+			return fmt.Errorf("writing state file %s: %v", dst.path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Merging terraform state file %s (tfmerge)", state.path)
+	var newState []byte
+	err = meta.auditLog.Run(meta.tf, func() (err error) {
+		newState, err = tfmerge.Merge(ctx, meta.tf, meta.baseState, state.path)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to merge state file: %v", err)
+	}
+	meta.baseState = newState
+	return nil
+}
+
+// reduceImportStates merges the states pairwise in rounds, until a single state is left, which is returned.
+// The merges of each round run concurrently, up to the parallelism.
+func reduceImportStates(states []importState, parallelism int, merge func(dst, src importState) error) (importState, error) {
+	for len(states) > 1 {
+		wp := workerpool.NewWorkPool(parallelism)
+		wp.Run(nil)
+		var next []importState
+		for i := 0; i < len(states); i += 2 {
+			dst := states[i]
+			next = append(next, dst)
+			if i+1 == len(states) {
+				continue
+			}
+			src := states[i+1]
+			wp.AddTask(func() (interface{}, error) {
+				return nil, merge(dst, src)
+			})
+		}
+		if err := wp.Done(); err != nil {
+			return importState{}, err
+		}
+		states = next
+	}
+	return states[0], nil
+}
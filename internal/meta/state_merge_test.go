@@ -0,0 +1,47 @@
+package meta
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReduceImportStates(t *testing.T) {
+	cases := []struct {
+		name   string
+		n      int
+		expect string
+	}{
+		{name: "single state", n: 1, expect: "0"},
+		{name: "even states", n: 4, expect: "0+1+2+3"},
+		{name: "odd states", n: 5, expect: "0+1+2+3+4"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var states []importState
+			contents := map[string]string{}
+			for i := 0; i < tt.n; i++ {
+				path := fmt.Sprintf("dir%d/terraform.tfstate", i)
+				states = append(states, importState{idx: i, path: path})
+				contents[path] = fmt.Sprint(i)
+			}
+			var mu sync.Mutex
+			state, err := reduceImportStates(states, 2, func(dst, src importState) error {
+				mu.Lock()
+				defer mu.Unlock()
+				contents[dst.path] += "+" + contents[src.path]
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 0, state.idx)
+			require.Equal(t, tt.expect, contents[state.path])
+		})
+	}
+
+	_, err := reduceImportStates([]importState{{idx: 0}, {idx: 1}}, 2, func(dst, src importState) error {
+		return fmt.Errorf("boom")
+	})
+	require.ErrorContains(t, err, "boom")
+}
@@ -0,0 +1,82 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+// importBlockItems builds n imported items of resource groups, whose configs are generated via the import blocks.
+func importBlockItems(t testing.TB, n int) ImportList {
+	var l ImportList
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("/subscriptions/123/resourceGroups/rg%d", i)
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		addr := tfaddr.TFAddr{Type: "azurerm_resource_group", Name: fmt.Sprintf("res-%d", i)}
+		l = append(l, ImportItem{
+			AzureResourceID: azureId,
+			TFResourceId:    id,
+			TFAddr:          addr,
+			Imported:        true,
+			config: []byte(fmt.Sprintf(`resource "azurerm_resource_group" %q {
+  location = "westeurope"
+  name     = "rg%d"
+  tags = {
+    env   = "prod"
+    owner = "team-%d"
+  }
+}
+`, addr.Name, i, i%7)),
+		})
+	}
+	return l
+}
+
+func TestStateToConfig(t *testing.T) {
+	l := importBlockItems(t, 50)
+	// The items that are not imported are not generated
+	l[3].Imported = false
+
+	meta := baseMeta{useImportBlocks: true, parallelism: 8}
+	cfgs, err := meta.stateToConfig(context.Background(), l)
+	require.NoError(t, err)
+	require.Len(t, cfgs, 49)
+	// The configs are in the order of the list, regardless of the order of the generation.
+	for i, cfg := range cfgs {
+		item := l[i]
+		if i >= 3 {
+			item = l[i+1]
+		}
+		require.Equal(t, item.TFAddr, cfg.TFAddr)
+		require.Contains(t, string(cfg.hcl.Bytes()), fmt.Sprintf(`resource "azurerm_resource_group" %q`, item.TFAddr.Name))
+	}
+
+	// The error of the foremost item is returned.
+	l[10].config = []byte(`resource "azurerm_resource_group" "res-10" {`)
+	l[40].config = []byte(`resource "azurerm_resource_group" "res-40" {`)
+	for i := 0; i < 10; i++ {
+		_, err := meta.stateToConfig(context.Background(), l)
+		require.Error(t, err)
+		require.True(t, strings.HasPrefix(err.Error(), `parsing the HCL generated by "terraform add" of azurerm_resource_group.res-10:`), err.Error())
+	}
+}
+
+func BenchmarkStateToConfig(b *testing.B) {
+	l := importBlockItems(b, 2000)
+	for _, parallelism := range []int{1, 4, 16} {
+		meta := baseMeta{useImportBlocks: true, parallelism: parallelism}
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := meta.stateToConfig(context.Background(), l); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package meta
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/magodo/armid"
+	"github.com/magodo/workerpool"
+)
+
+const (
+	storageAccountAPIVersion = "2022-09-01"
+	// The file shares can only be listed via the Azure AD credential since this version
+	storageDataPlaneAPIVersion = "2022-11-02"
+	storageTableAPIVersion     = "2019-02-02"
+)
+
+// storageItemService is a data plane service of a storage account, whose items are exported as the azurerm resources of the pseudo resource ids
+// under the storage account (e.g. "<account id>/blobServices/default/containers/<name>").
+type storageItemService struct {
+	// The key of the endpoint in the "primaryEndpoints" of the storage account (e.g. "blob")
+	endpoint string
+	// The path of the items under the storage account (e.g. "blobServices/default/containers")
+	path string
+}
+
+var storageItemServices = []storageItemService{
+	{endpoint: "blob", path: "blobServices/default/containers"},
+	{endpoint: "file", path: "fileServices/default/shares"},
+	{endpoint: "queue", path: "queueServices/default/queues"},
+	{endpoint: "table", path: "tableServices/default/tables"},
+}
+
+// storageSystemContainers are the containers that are managed by Azure, which are not exported.
+var storageSystemContainers = map[string]bool{
+	"$logs": true,
+}
+
+// populateStorageItems adds the blob containers, the file shares, the queues and the tables of the storage accounts of the resource set.
+// The items are listed from the data plane of each storage account, with the same credential. The services whose items can't be listed (e.g. lack of the
+// data plane permissions, or network restrictions) are skipped with a warning.
+func (meta baseMeta) populateStorageItems(ctx context.Context, rset *resourceset.AzureResourceSet) error {
+	if !meta.includeStorageItems {
+		return nil
+	}
+	var accountIds []armid.ResourceId
+	for _, res := range rset.Resources {
+		if strings.EqualFold(res.Id.TypeString(), "Microsoft.Storage/storageAccounts") {
+			accountIds = append(accountIds, res.Id)
+		}
+	}
+
+	pl := meta.storageDataPlanePipeline()
+	var (
+		mu        sync.Mutex
+		resources []resourceset.AzureResource
+	)
+	wp := workerpool.NewWorkPool(meta.parallelism)
+	wp.Run(nil)
+	for _, accountId := range accountIds {
+		accountId := accountId
+		wp.AddTask(func() (interface{}, error) {
+			l, err := meta.listStorageItems(ctx, pl, accountId)
+			if err != nil {
+				log.Printf("[WARN] Skipping the items of the storage account %s: %v", accountId, err)
+				return nil, nil
+			}
+			mu.Lock()
+			resources = append(resources, l...)
+			mu.Unlock()
+			return nil, nil
+		})
+	}
+	if err := wp.Done(); err != nil {
+		return err
+	}
+
+	appendNewResources(rset, resources)
+	return nil
+}
+
+// listStorageItems lists the items of the storage account, as the resources of their pseudo resource ids.
+func (meta baseMeta) listStorageItems(ctx context.Context, pl runtime.Pipeline, accountId armid.ResourceId) ([]resourceset.AzureResource, error) {
+	resp, err := meta.resourceClient.GetByID(ctx, accountId.String(), storageAccountAPIVersion, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting %s: %v", accountId, err)
+	}
+	props, ok := resp.Properties.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected properties of %s", accountId)
+	}
+	endpoints, _ := props["primaryEndpoints"].(map[string]interface{})
+
+	items := map[string][]string{}
+	for _, svc := range storageItemServices {
+		endpoint, _ := endpoints[svc.endpoint].(string)
+		// Not all kinds of storage accounts support all the services (e.g. the BlockBlobStorage)
+		if endpoint == "" {
+			continue
+		}
+		var names []string
+		if svc.endpoint == "table" {
+			names, err = listStorageTables(ctx, pl, endpoint)
+		} else {
+			names, err = listStorageEnumeration(ctx, pl, endpoint)
+		}
+		if err != nil {
+			log.Printf("[WARN] Skipping the %s service of the storage account %s: %v", svc.endpoint, accountId, err)
+			continue
+		}
+		items[svc.endpoint] = names
+	}
+	return storageItemResources(accountId, items), nil
+}
+
+// storageItemResources returns the resources of the pseudo resource ids of the storage items, keyed by the service endpoint, which are resolved to the
+// azurerm resources by aztft:
+//   - "<account id>/blobServices/default/containers/<name>": azurerm_storage_container
+//   - "<account id>/fileServices/default/shares/<name>": azurerm_storage_share
+//   - "<account id>/queueServices/default/queues/<name>": azurerm_storage_queue
+//   - "<account id>/tableServices/default/tables/<name>": azurerm_storage_table
+func storageItemResources(accountId armid.ResourceId, items map[string][]string) []resourceset.AzureResource {
+	var out []resourceset.AzureResource
+	for _, svc := range storageItemServices {
+		for _, name := range items[svc.endpoint] {
+			if svc.endpoint == "blob" && storageSystemContainers[name] {
+				continue
+			}
+			id := accountId.String() + "/" + svc.path + "/" + name
+			azureId, err := armid.ParseResourceId(id)
+			if err != nil {
+				log.Printf("[WARN] Failed to parse the storage item id %q: %v", id, err)
+				continue
+			}
+			out = append(out, resourceset.AzureResource{Id: azureId})
+		}
+	}
+	return out
+}
+
+// storageEnumerationResults is the XML response of listing the containers, the shares or the queues.
+type storageEnumerationResults struct {
+	Containers []string `xml:"Containers>Container>Name"`
+	Shares     []string `xml:"Shares>Share>Name"`
+	Queues     []string `xml:"Queues>Queue>Name"`
+	NextMarker string   `xml:"NextMarker"`
+}
+
+// listStorageEnumeration lists the names of the containers, the shares or the queues of the service endpoint (e.g. "https://myaccount.blob.core.windows.net/").
+func listStorageEnumeration(ctx context.Context, pl runtime.Pipeline, endpoint string) ([]string, error) {
+	var names []string
+	var marker string
+	for {
+		u := strings.TrimSuffix(endpoint, "/") + "/?comp=list"
+		if marker != "" {
+			u += "&marker=" + url.QueryEscape(marker)
+		}
+		req, err := runtime.NewRequest(ctx, http.MethodGet, u)
+		if err != nil {
+			return nil, err
+		}
+		req.Raw().Header.Set("x-ms-version", storageDataPlaneAPIVersion)
+		// The file service requires the intent of the request via the Azure AD credential
+		req.Raw().Header.Set("x-ms-file-request-intent", "backup")
+		resp, err := pl.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+		b, err := runtime.Payload(resp)
+		if err != nil {
+			return nil, err
+		}
+		var result storageEnumerationResults
+		if err := xml.Unmarshal(b, &result); err != nil {
+			return nil, fmt.Errorf("unmarshalling the response: %v", err)
+		}
+		names = append(names, result.Containers...)
+		names = append(names, result.Shares...)
+		names = append(names, result.Queues...)
+		if result.NextMarker == "" {
+			return names, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// listStorageTables lists the names of the tables of the table service endpoint (e.g. "https://myaccount.table.core.windows.net/").
+func listStorageTables(ctx context.Context, pl runtime.Pipeline, endpoint string) ([]string, error) {
+	var names []string
+	var next string
+	for {
+		u := strings.TrimSuffix(endpoint, "/") + "/Tables"
+		if next != "" {
+			u += "?NextTableName=" + url.QueryEscape(next)
+		}
+		req, err := runtime.NewRequest(ctx, http.MethodGet, u)
+		if err != nil {
+			return nil, err
+		}
+		req.Raw().Header.Set("x-ms-version", storageTableAPIVersion)
+		req.Raw().Header.Set("Accept", "application/json;odata=nometadata")
+		resp, err := pl.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+		var result struct {
+			Value []struct {
+				TableName string `json:"TableName"`
+			} `json:"value"`
+		}
+		if err := runtime.UnmarshalAsJSON(resp, &result); err != nil {
+			return nil, err
+		}
+		for _, v := range result.Value {
+			names = append(names, v.TableName)
+		}
+		next = resp.Header.Get("x-ms-continuation-NextTableName")
+		if next == "" {
+			return names, nil
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package meta
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageItemResources(t *testing.T) {
+	accountId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1")
+	require.NoError(t, err)
+	items := map[string][]string{
+		"blob":  {"$logs", "container1"},
+		"file":  {"share1"},
+		"queue": {"queue1"},
+		"table": {"table1"},
+	}
+	var actual []string
+	for _, res := range storageItemResources(accountId, items) {
+		actual = append(actual, res.Id.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1/blobServices/default/containers/container1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1/fileServices/default/shares/share1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1/queueServices/default/queues/queue1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Storage/storageAccounts/sa1/tableServices/default/tables/table1",
+	}, actual)
+}
+
+func TestStorageEnumerationResults(t *testing.T) {
+	input := `<?xml version="1.0" encoding="utf-8"?>
+<EnumerationResults ServiceEndpoint="https://sa1.blob.core.windows.net/">
+  <Containers>
+    <Container><Name>container1</Name></Container>
+    <Container><Name>container2</Name></Container>
+  </Containers>
+  <NextMarker>marker</NextMarker>
+</EnumerationResults>`
+	var result storageEnumerationResults
+	require.NoError(t, xml.Unmarshal([]byte(input), &result))
+	require.Equal(t, []string{"container1", "container2"}, result.Containers)
+	require.Empty(t, result.Shares)
+	require.Equal(t, "marker", result.NextMarker)
+}
@@ -0,0 +1,78 @@
+package meta
+
+import (
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+)
+
+const (
+	// SubresourceStrategyStandalone generates the sub-resources as standalone resources (default).
+	SubresourceStrategyStandalone = "standalone"
+	// SubresourceStrategyInline generates the sub-resources inline in their parent resources.
+	SubresourceStrategyInline = "inline"
+)
+
+// SubresourceStrategies are the supported strategies for the sub-resources that azurerm supports both inline and standalone.
+var SubresourceStrategies = []string{SubresourceStrategyStandalone, SubresourceStrategyInline}
+
+// inlineSubresource is a sub-resource that can be defined inline in its parent resource in azurerm.
+type inlineSubresource struct {
+	// The TF resource type of the parent resource
+	ParentType string
+	// The attribute name of the sub-resource in the parent resource
+	Attribute string
+	// The route scope of the sub-resource's Azure resource id
+	RouteScope string
+}
+
+var inlineSubresources = []inlineSubresource{
+	{
+		ParentType: "azurerm_virtual_network",
+		Attribute:  "subnet",
+		RouteScope: "/Microsoft.Network/virtualNetworks/subnets",
+	},
+	{
+		ParentType: "azurerm_network_security_group",
+		Attribute:  "security_rule",
+		RouteScope: "/Microsoft.Network/networkSecurityGroups/securityRules",
+	},
+	{
+		ParentType: "azurerm_route_table",
+		Attribute:  "route",
+		RouteScope: "/Microsoft.Network/routeTables/routes",
+	},
+}
+
+func inlineSubresourceByParentType(rt string) (inlineSubresource, bool) {
+	for _, sub := range inlineSubresources {
+		if sub.ParentType == rt {
+			return sub, true
+		}
+	}
+	return inlineSubresource{}, false
+}
+
+// removeInlineSubresources removes the Azure resources that are to be defined inline in their parent resources, as long as the parent resources are also in the set.
+func removeInlineSubresources(rset *resourceset.AzureResourceSet) {
+	ids := map[string]bool{}
+	for _, res := range rset.Resources {
+		ids[strings.ToUpper(res.Id.String())] = true
+	}
+
+	var out []resourceset.AzureResource
+	for _, res := range rset.Resources {
+		var inline bool
+		for _, sub := range inlineSubresources {
+			if strings.EqualFold(res.Id.RouteScopeString(), sub.RouteScope) && res.Id.Parent() != nil && ids[strings.ToUpper(res.Id.Parent().String())] {
+				inline = true
+				break
+			}
+		}
+		if inline {
+			continue
+		}
+		out = append(out, res)
+	}
+	rset.Resources = out
+}
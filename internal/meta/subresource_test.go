@@ -0,0 +1,58 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveInlineSubresources(t *testing.T) {
+	var rset resourceset.AzureResourceSet
+	for _, id := range []string{
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet1",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet1/subnets/subnet1",
+		// The parent vnet is not in the set
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet2/subnets/subnet1",
+	} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		rset.Resources = append(rset.Resources, resourceset.AzureResource{Id: azureId})
+	}
+	removeInlineSubresources(&rset)
+	var ids []string
+	for _, res := range rset.Resources {
+		ids = append(ids, res.Id.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet1",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet2/subnets/subnet1",
+	}, ids)
+}
+
+func TestHclBlockCopyAttribute(t *testing.T) {
+	dst, diags := hclwrite.ParseConfig([]byte(`resource "azurerm_virtual_network" "test" {
+  name = "vnet"
+}
+`), "", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+	src, diags := hclwrite.ParseConfig([]byte(`resource "azurerm_virtual_network" "test" {
+  name = "vnet"
+  subnet = [{
+    name = "subnet1"
+  }]
+}
+`), "", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+	hclBlockCopyAttribute(dst.Body().Blocks()[0].Body(), src.Body().Blocks()[0].Body(), "subnet")
+	require.Equal(t, `resource "azurerm_virtual_network" "test" {
+  name = "vnet"
+  subnet = [{
+    name = "subnet1"
+  }]
+}
+`, string(hclwrite.Format(dst.Bytes())))
+}
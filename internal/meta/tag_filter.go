@@ -0,0 +1,32 @@
+package meta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WithTagFilter appends the tag filters to the ARG where predicate, so that the resources are filtered by ARG, rather than after listing.
+// The resources are required to have all the include tags, and none of the exclude tags. The tag values are matched case sensitively.
+func WithTagFilter(predicate string, includeTags, excludeTags map[string]string) string {
+	var conds []string
+	for _, k := range sortedKeys(includeTags) {
+		conds = append(conds, fmt.Sprintf("tostring(tags[%q]) == %q", k, includeTags[k]))
+	}
+	for _, k := range sortedKeys(excludeTags) {
+		conds = append(conds, fmt.Sprintf("tostring(tags[%q]) != %q", k, excludeTags[k]))
+	}
+	if len(conds) == 0 {
+		return predicate
+	}
+	return fmt.Sprintf("(%s) and %s", predicate, strings.Join(conds, " and "))
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
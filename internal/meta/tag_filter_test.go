@@ -0,0 +1,19 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTagFilter(t *testing.T) {
+	require.Equal(t, `resourceGroup =~ "rg"`, WithTagFilter(`resourceGroup =~ "rg"`, nil, nil))
+	require.Equal(t,
+		`(resourceGroup =~ "rg") and tostring(tags["env"]) == "prod" and tostring(tags["team"]) == "a" and tostring(tags["skip"]) != "true"`,
+		WithTagFilter(`resourceGroup =~ "rg"`, map[string]string{"team": "a", "env": "prod"}, map[string]string{"skip": "true"}),
+	)
+	require.Equal(t,
+		`(type =~ "Microsoft.Network/virtualNetworks" or type =~ "Microsoft.Network/networkSecurityGroups") and tostring(tags["owner"]) != ""`,
+		WithTagFilter(`type =~ "Microsoft.Network/virtualNetworks" or type =~ "Microsoft.Network/networkSecurityGroups"`, nil, map[string]string{"owner": ""}),
+	)
+}
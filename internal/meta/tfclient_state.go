@@ -0,0 +1,196 @@
+package meta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// localStateFileName is the state file of the local backend in the output directory, which is written directly when importing via the tfclient without HCLOnly.
+const localStateFileName = "terraform.tfstate"
+
+// tfclientStateTerraformVersion is the terraform version recorded in the state file written directly, which is old enough to be read by any terraform >= v1.0.
+const tfclientStateTerraformVersion = "1.0.0"
+
+// usesTFClientState tells whether the resources imported via the tfclient are written to the local state file of the output directory, rather than only converted to config.
+func (meta baseMeta) usesTFClientState() bool {
+	return meta.tfclient != nil && !meta.hclOnly
+}
+
+type stateFileResource struct {
+	Mode      string              `json:"mode"`
+	Type      string              `json:"type"`
+	Name      string              `json:"name"`
+	Provider  string              `json:"provider"`
+	Instances []stateFileInstance `json:"instances"`
+}
+
+type stateFileInstance struct {
+	SchemaVersion       uint64          `json:"schema_version"`
+	Attributes          json.RawMessage `json:"attributes"`
+	SensitiveAttributes []interface{}   `json:"sensitive_attributes"`
+	Private             []byte          `json:"private,omitempty"`
+}
+
+// stateProvider returns the provider address of the item in the state (e.g. `provider["registry.terraform.io/hashicorp/azurerm"].alias`).
+func (meta baseMeta) stateProvider(item ImportItem) string {
+	source := meta.providerSource(meta.providerName)
+	// The source address without the hostname is of the public registry.
+	if strings.Count(source, "/") == 1 {
+		source = "registry.terraform.io/" + source
+	}
+	addr := fmt.Sprintf("provider[%q]", source)
+	if alias := meta.providerAlias(item); alias != "" {
+		addr += "." + alias
+	}
+	return addr
+}
+
+// setTFClientStates sets the states of the items imported via the tfclient to the base state, replacing the resources that already exist at the same addresses.
+func (meta *baseMeta) setTFClientStates(items []*ImportItem) error {
+	schResp, diags := meta.tfclient.GetProviderSchema()
+	if diags.HasErrors() {
+		return fmt.Errorf("getting provider schema: %v", diags)
+	}
+	var resources []stateFileResource
+	for _, item := range items {
+		if item.Skip() || !item.Imported || item.State.IsNull() {
+			continue
+		}
+		rsch, ok := schResp.ResourceTypes[item.TFAddr.Type]
+		if !ok {
+			return fmt.Errorf("no resource schema for %s found in the provider schema", item.TFAddr.Type)
+		}
+		attrs, err := ctyjson.Marshal(item.State, item.State.Type())
+		if err != nil {
+			return fmt.Errorf("marshalling the state of %s: %v", item.TFAddr, err)
+		}
+		resources = append(resources, stateFileResource{
+			Mode:     "managed",
+			Type:     item.TFAddr.Type,
+			Name:     item.TFAddr.Name,
+			Provider: meta.stateProvider(*item),
+			Instances: []stateFileInstance{
+				{
+					SchemaVersion:       rsch.Version,
+					Attributes:          attrs,
+					SensitiveAttributes: []interface{}{},
+					Private:             item.private,
+				},
+			},
+		})
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+	state, err := setStateResources(meta.baseState, resources)
+	if err != nil {
+		return err
+	}
+	meta.baseState = state
+	return nil
+}
+
+// setStateResources sets the resources (of the root module) to the state, replacing the ones that already exist at the same addresses.
+// An empty state is initialized as a new state (i.e. of a new lineage).
+func setStateResources(state []byte, resources []stateFileResource) ([]byte, error) {
+	if len(state) == 0 {
+		lineage, err := uuid.NewV4()
+		if err != nil {
+			return nil, fmt.Errorf("generating the state lineage: %v", err)
+		}
+		state = []byte(fmt.Sprintf(`{"version":4,"terraform_version":%q,"serial":0,"lineage":%q,"outputs":{},"resources":[]}`, tfclientStateTerraformVersion, lineage))
+	}
+
+	raws := map[string]string{}
+	var addrs []string
+	for _, res := range resources {
+		b, err := json.Marshal(res)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling the state of %s.%s: %v", res.Type, res.Name, err)
+		}
+		addr := res.Type + "." + res.Name
+		if _, ok := raws[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+		raws[addr] = string(b)
+	}
+
+	var out []string
+	for _, res := range gjson.GetBytes(state, "resources").Array() {
+		if !res.Get("module").Exists() && res.Get("mode").String() == "managed" {
+			if _, ok := raws[res.Get("type").String()+"."+res.Get("name").String()]; ok {
+				continue
+			}
+		}
+		out = append(out, res.Raw)
+	}
+	for _, addr := range addrs {
+		out = append(out, raws[addr])
+	}
+	state, err := sjson.SetRawBytes(state, "resources", []byte("["+strings.Join(out, ",")+"]"))
+	if err != nil {
+		return nil, fmt.Errorf("setting the resources of the state: %v", err)
+	}
+	return state, nil
+}
+
+// removeStateResource removes the resource of the address (of the root module) from the state.
+func removeStateResource(state []byte, addr string) ([]byte, error) {
+	if len(state) == 0 {
+		return state, nil
+	}
+	var out []string
+	for _, res := range gjson.GetBytes(state, "resources").Array() {
+		if !res.Get("module").Exists() && res.Get("mode").String() == "managed" && res.Get("type").String()+"."+res.Get("name").String() == addr {
+			continue
+		}
+		out = append(out, res.Raw)
+	}
+	state, err := sjson.SetRawBytes(state, "resources", []byte("["+strings.Join(out, ",")+"]"))
+	if err != nil {
+		return nil, fmt.Errorf("setting the resources of the state: %v", err)
+	}
+	return state, nil
+}
+
+// readLocalState reads the local state file of the output directory, which is empty if not exists.
+func (meta *baseMeta) readLocalState() error {
+	// #nosec G304
+	b, err := os.ReadFile(filepath.Join(meta.outdir, localStateFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("reading the local state: %v", err)
+	}
+	meta.baseState = b
+	meta.originBaseState = b
+	return nil
+}
+
+// writeLocalState writes the base state to the local state file of the output directory, with its serial increased.
+func (meta *baseMeta) writeLocalState() error {
+	if len(meta.baseState) == 0 {
+		return nil
+	}
+	state, err := sjson.SetBytes(meta.baseState, "serial", gjson.GetBytes(meta.baseState, "serial").Int()+1)
+	if err != nil {
+		return fmt.Errorf("increasing the serial of the state: %v", err)
+	}
+	// #nosec G306
+	if err := os.WriteFile(filepath.Join(meta.outdir, localStateFileName), state, 0644); err != nil {
+		return fmt.Errorf("writing the local state: %v", err)
+	}
+	meta.baseState = state
+	meta.originBaseState = state
+	return nil
+}
@@ -0,0 +1,75 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestSetStateResources(t *testing.T) {
+	rg := stateFileResource{
+		Mode:     "managed",
+		Type:     "azurerm_resource_group",
+		Name:     "res-0",
+		Provider: `provider["registry.terraform.io/hashicorp/azurerm"]`,
+		Instances: []stateFileInstance{
+			{Attributes: []byte(`{"id":"/subscriptions/123/resourceGroups/rg1"}`), SensitiveAttributes: []interface{}{}},
+		},
+	}
+
+	// A new state is initialized
+	state, err := setStateResources(nil, []stateFileResource{rg})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), gjson.GetBytes(state, "version").Int())
+	require.NotEmpty(t, gjson.GetBytes(state, "lineage").String())
+	require.Equal(t, []string{"/subscriptions/123/resourceGroups/rg1"}, stateIds(state))
+
+	// The resource at the same address is replaced, the one in the module is kept
+	state = []byte(`{"version":4,"serial":3,"lineage":"foo","resources":[
+{"mode":"managed","type":"azurerm_resource_group","name":"res-0","instances":[{"attributes":{"id":"/subscriptions/123/resourceGroups/old"}}]},
+{"module":"module.mod","mode":"managed","type":"azurerm_resource_group","name":"res-0","instances":[{"attributes":{"id":"/subscriptions/123/resourceGroups/mod"}}]},
+{"mode":"data","type":"azurerm_resource_group","name":"res-0","instances":[{"attributes":{"id":"/subscriptions/123/resourceGroups/data"}}]}
+]}`)
+	vnet := rg
+	vnet.Type = "azurerm_virtual_network"
+	vnet.Name = "res-1"
+	vnet.Instances = []stateFileInstance{
+		{Attributes: []byte(`{"id":"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"}`), Private: []byte("private")},
+	}
+	state, err = setStateResources(state, []stateFileResource{rg, vnet})
+	require.NoError(t, err)
+	require.Equal(t, "foo", gjson.GetBytes(state, "lineage").String())
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/mod",
+		"/subscriptions/123/resourceGroups/data",
+		"/subscriptions/123/resourceGroups/rg1",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+	}, stateIds(state))
+	require.Equal(t, "cHJpdmF0ZQ==", gjson.GetBytes(state, "resources.3.instances.0.private").String())
+
+	state, err = removeStateResource(state, "azurerm_resource_group.res-0")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/mod",
+		"/subscriptions/123/resourceGroups/data",
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+	}, stateIds(state))
+}
+
+func TestStateProvider(t *testing.T) {
+	item := ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}}
+	meta := baseMeta{providerName: ProviderAzureRM}
+	require.Equal(t, `provider["registry.terraform.io/hashicorp/azurerm"]`, meta.stateProvider(item))
+	meta.providerRegistry = "registry.example.com"
+	require.Equal(t, `provider["registry.example.com/hashicorp/azurerm"]`, meta.stateProvider(item))
+}
+
+func stateIds(state []byte) []string {
+	var ids []string
+	for _, res := range gjson.GetBytes(state, "resources").Array() {
+		ids = append(ids, res.Get("instances.0.attributes.id").String())
+	}
+	return ids
+}
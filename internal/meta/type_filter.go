@@ -0,0 +1,55 @@
+package meta
+
+import (
+	"fmt"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/magodo/tfadd/providers/azurerm"
+)
+
+// ValidateTypeFilter validates the TF resource types of the type filter (i.e. the only types or the skip types), each of which must be either the azapi_resource,
+// or a resource type of the azurerm provider.
+func ValidateTypeFilter(types []string) error {
+	for _, rt := range types {
+		if rt == AzAPIResourceType {
+			continue
+		}
+		if _, ok := azurerm.ProviderSchemaInfo.ResourceSchemas[rt]; !ok {
+			return fmt.Errorf("unknown TF resource type %q", rt)
+		}
+	}
+	return nil
+}
+
+// filterTypes drops the resources whose resolved TF resource types are not among the onlyTypes (if any), or are among the skipTypes.
+// The pseudo resources are filtered by their opt-in types, while the unresolved resources are only dropped by the onlyTypes, as they match none of the types.
+func filterTypes(l ImportList, onlyTypes, skipTypes []string) ImportList {
+	if len(onlyTypes) == 0 && len(skipTypes) == 0 {
+		return l
+	}
+	only := map[string]bool{}
+	for _, rt := range onlyTypes {
+		only[rt] = true
+	}
+	skip := map[string]bool{}
+	for _, rt := range skipTypes {
+		skip[rt] = true
+	}
+	var out ImportList
+	for _, item := range l {
+		rt := item.TFAddr.Type
+		if rt == "" {
+			rt = item.TFAddrCache.Type
+		}
+		if len(only) != 0 && !only[rt] {
+			log.Printf("[INFO] Dropping %s as its TF resource type %q is not among the only types", item.AzureResourceID, rt)
+			continue
+		}
+		if skip[rt] {
+			log.Printf("[INFO] Dropping %s as its TF resource type %q is among the skip types", item.AzureResourceID, rt)
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTypeFilter(t *testing.T) {
+	require.NoError(t, ValidateTypeFilter(nil))
+	require.NoError(t, ValidateTypeFilter([]string{"azurerm_storage_account", AzAPIResourceType}))
+	require.EqualError(t, ValidateTypeFilter([]string{"azurerm_storage_account", "azurerm_foo"}), `unknown TF resource type "azurerm_foo"`)
+}
+
+func TestFilterTypes(t *testing.T) {
+	item := func(id, rt string) ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		addr := tfaddr.TFAddr{Type: rt, Name: "res"}
+		return ImportItem{AzureResourceID: azureId, TFAddr: addr, TFAddrCache: addr}
+	}
+	rg := item("/subscriptions/123/resourceGroups/rg", "azurerm_resource_group")
+	sa := item("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa", "azurerm_storage_account")
+	vnet := item("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", "azurerm_virtual_network")
+	unresolved := item("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo", "")
+	// The pseudo resource is skipped until opted in, which is filtered by its opt-in type
+	pseudo := item("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa/blobServices/default", "azurerm_storage_account_blob_properties")
+	pseudo.TFAddr.Type = ""
+	l := ImportList{rg, sa, vnet, unresolved, pseudo}
+
+	require.Equal(t, l, filterTypes(l, nil, nil))
+	require.Equal(t, ImportList{sa, vnet}, filterTypes(l, []string{"azurerm_storage_account", "azurerm_virtual_network"}, nil))
+	require.Equal(t, ImportList{pseudo}, filterTypes(l, []string{"azurerm_storage_account_blob_properties"}, nil))
+	require.Equal(t, ImportList{rg, unresolved, pseudo}, filterTypes(l, nil, []string{"azurerm_storage_account", "azurerm_virtual_network"}))
+}
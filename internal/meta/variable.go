@@ -0,0 +1,239 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// DefaultVariableAttributes are the attributes whose repeated values are extracted to variables, if no variable attributes file is specified.
+var DefaultVariableAttributes = []string{"location", "resource_group_name", "sku", "sku_name", "sku_tier", "tags"}
+
+var variableAttributeRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)*$`)
+
+// LoadVariableAttributes loads the variable attributes file, which is a JSON array of the attribute paths, e.g. ["location", "sku.name", "tags"].
+// The path of an attribute in a nested block is joined by ".", without the block indexes.
+func LoadVariableAttributes(path string) ([]string, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the variable attributes file %s: %v", path, err)
+	}
+	var attrs []string
+	if err := json.Unmarshal(b, &attrs); err != nil {
+		return nil, fmt.Errorf("unmarshalling the variable attributes file %s: %v", path, err)
+	}
+	for _, attr := range attrs {
+		if !variableAttributeRegex.MatchString(attr) {
+			return nil, fmt.Errorf("invalid attribute path %q in the variable attributes file %s", attr, path)
+		}
+	}
+	return attrs, nil
+}
+
+// variableValue is a value of a variable attribute, which is extracted to a variable if repeated.
+type variableValue struct {
+	// The key of the attribute (and the map key for the map attributes), e.g. "location", "tags.env"
+	key   string
+	value string
+}
+
+// variableOccurrence is a constant string value of a variable attribute, or an element of a variable attribute of map type.
+type variableOccurrence struct {
+	body *hclwrite.Body
+	attr string
+	// The map key, which is empty for the string attributes
+	mapKey string
+	key    string
+	value  string
+}
+
+// variableAddon replaces the values of the variable attributes that are repeated across the generated config with references to the variables, which are
+// declared (with the values as the defaults) in the VariablesFileName. The values that are already extracted (e.g. by a previous chunk) are replaced as well.
+// The map attributes (e.g. tags) are extracted per map key.
+func (meta baseMeta) variableAddon(configs ConfigInfos) (ConfigInfos, error) {
+	if !meta.extractVariables {
+		return configs, nil
+	}
+	attrs := map[string]bool{}
+	for _, attr := range meta.variableAttributes {
+		attrs[attr] = true
+	}
+
+	var occurrences []variableOccurrence
+	for _, cfg := range configs {
+		err := hclBlockWalkConstAttributes(cfg.hcl.Body().Blocks()[0].Body(), nil, func(body *hclwrite.Body, path []string, name string, val cty.Value) {
+			// Drop the block indexes from the path
+			var keys []string
+			for i := 0; i < len(path); i += 2 {
+				keys = append(keys, path[i])
+			}
+			key := strings.Join(append(keys, name), ".")
+			if !attrs[key] || !val.IsWhollyKnown() || val.IsNull() {
+				return
+			}
+			ty := val.Type()
+			switch {
+			case ty == cty.String:
+				occurrences = append(occurrences, variableOccurrence{body: body, attr: name, key: key, value: val.AsString()})
+			case ty.IsMapType() || ty.IsObjectType():
+				for it := val.ElementIterator(); it.Next(); {
+					k, v := it.Element()
+					if v.IsNull() || v.Type() != cty.String {
+						continue
+					}
+					occurrences = append(occurrences, variableOccurrence{body: body, attr: name, mapKey: k.AsString(), key: key + "." + k.AsString(), value: v.AsString()})
+				}
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", cfg.TFAddr, err)
+		}
+	}
+
+	counts := map[variableValue]int{}
+	for _, o := range occurrences {
+		counts[variableValue{key: o.key, value: o.value}]++
+	}
+	var candidates []variableValue
+	for v := range counts {
+		candidates = append(candidates, v)
+	}
+	// The more repeated value of an attribute takes the variable name without suffix
+	sort.Slice(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if ci.key != cj.key {
+			return ci.key < cj.key
+		}
+		if counts[ci] != counts[cj] {
+			return counts[ci] > counts[cj]
+		}
+		return ci.value < cj.value
+	})
+	for _, v := range candidates {
+		if _, ok := meta.variables[v]; ok || counts[v] < 2 {
+			continue
+		}
+		meta.variables[v] = meta.newVariableName(v.key)
+	}
+
+	// The occurrences of a map attribute are rewritten together, as the whole map expression is replaced.
+	type mapAttr struct {
+		body *hclwrite.Body
+		attr string
+	}
+	mapRefs := map[mapAttr]map[string]string{}
+	for _, o := range occurrences {
+		name, ok := meta.variables[variableValue{key: o.key, value: o.value}]
+		if !ok {
+			continue
+		}
+		if o.mapKey == "" {
+			o.body.SetAttributeTraversal(o.attr, variableTraversal(name))
+			continue
+		}
+		ma := mapAttr{body: o.body, attr: o.attr}
+		if mapRefs[ma] == nil {
+			mapRefs[ma] = map[string]string{}
+		}
+		mapRefs[ma][o.mapKey] = name
+	}
+	for ma, refs := range mapRefs {
+		if err := hclBlockRewriteMapValues(ma.body, ma.attr, refs); err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// newVariableName returns a unique variable name for the attribute key, e.g. "sku_name", "tags_env", "location_2".
+func (meta baseMeta) newVariableName(key string) string {
+	base := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(key))
+	taken := map[string]bool{}
+	for _, name := range meta.variables {
+		taken[name] = true
+	}
+	name := base
+	for i := 2; taken[name]; i++ {
+		name = base + "_" + strconv.Itoa(i)
+	}
+	return name
+}
+
+func variableTraversal(name string) hcl.Traversal {
+	return hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: name}}
+}
+
+// hclBlockRewriteMapValues replaces the constant map attribute of the body with an object expression, whose elements of the specified keys are
+// replaced with references to the variables. The other elements are kept as is.
+func hclBlockRewriteMapValues(body *hclwrite.Body, attr string, refs map[string]string) error {
+	expr, diags := hclsyntax.ParseExpression(body.GetAttribute(attr).Expr().BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("parsing the expression of %s: %s", attr, diags.Error())
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return fmt.Errorf("evaluating the expression of %s: %s", attr, diags.Error())
+	}
+	var items []hclwrite.ObjectAttrTokens
+	for it := val.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		nameTokens := hclwrite.TokensForValue(k)
+		if hclsyntax.ValidIdentifier(k.AsString()) {
+			nameTokens = hclwrite.TokensForIdentifier(k.AsString())
+		}
+		valueTokens := hclwrite.TokensForValue(v)
+		if name, ok := refs[k.AsString()]; ok {
+			valueTokens = hclwrite.TokensForTraversal(variableTraversal(name))
+		}
+		items = append(items, hclwrite.ObjectAttrTokens{Name: nameTokens, Value: valueTokens})
+	}
+	body.SetAttributeRaw(attr, hclwrite.TokensForObject(items))
+	return nil
+}
+
+// writeVariables writes the extracted variables, with the extracted values as the defaults, to the VariablesFileName.
+func (meta baseMeta) writeVariables() error {
+	if len(meta.variables) == 0 {
+		return nil
+	}
+	values := map[string]string{}
+	var names []string
+	for v, name := range meta.variables {
+		values[name] = v.value
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	f := hclwrite.NewEmptyFile()
+	for i, name := range names {
+		if i != 0 {
+			f.Body().AppendNewline()
+		}
+		b := f.Body().AppendNewBlock("variable", []string{name}).Body()
+		b.SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+		b.SetAttributeValue("default", cty.StringVal(values[name]))
+	}
+
+	path := filepath.Join(meta.moduleDir, VariablesFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, hclwrite.Format(f.Bytes()), 0644); err != nil {
+		return fmt.Errorf("writing the variables to %s: %v", path, err)
+	}
+	return nil
+}
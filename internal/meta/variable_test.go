@@ -0,0 +1,154 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariableAddon(t *testing.T) {
+	meta := baseMeta{
+		extractVariables:   true,
+		variableAttributes: []string{"location", "resource_group_name", "sku.name", "tags"},
+		variables:          map[variableValue]string{},
+		moduleDir:          t.TempDir(),
+	}
+	parse := func(input string) *hclwrite.File {
+		f, diags := hclwrite.ParseConfig([]byte(input), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return f
+	}
+	configs := ConfigInfos{
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}},
+			hcl: parse(`resource "azurerm_resource_group" "res-0" {
+  location = "westeurope"
+  name     = "rg"
+  tags = {
+    env   = "prod"
+    owner = "alice"
+  }
+}
+`),
+		},
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"}},
+			hcl: parse(`resource "azurerm_virtual_network" "res-1" {
+  location            = "westeurope"
+  name                = "vnet"
+  resource_group_name = "rg"
+  tags = {
+    env = "prod"
+  }
+}
+`),
+		},
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_storage_account", Name: "res-2"}},
+			hcl: parse(`resource "azurerm_storage_account" "res-2" {
+  location            = "eastus"
+  name                = "sa"
+  resource_group_name = "rg"
+  sku {
+    name = "Standard"
+  }
+}
+`),
+		},
+	}
+	out, err := meta.variableAddon(configs)
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_resource_group" "res-0" {
+  location = var.location
+  name     = "rg"
+  tags = {
+    env   = var.tags_env
+    owner = "alice"
+  }
+}
+`, string(hclwrite.Format(out[0].hcl.Bytes())))
+	require.Equal(t, `resource "azurerm_virtual_network" "res-1" {
+  location            = var.location
+  name                = "vnet"
+  resource_group_name = var.resource_group_name
+  tags = {
+    env = var.tags_env
+  }
+}
+`, string(hclwrite.Format(out[1].hcl.Bytes())))
+	// The values that are not repeated are kept
+	require.Equal(t, `resource "azurerm_storage_account" "res-2" {
+  location            = "eastus"
+  name                = "sa"
+  resource_group_name = var.resource_group_name
+  sku {
+    name = "Standard"
+  }
+}
+`, string(hclwrite.Format(out[2].hcl.Bytes())))
+
+	// The extracted values are replaced in the later chunks, even if not repeated there
+	configs = ConfigInfos{
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_subnet", Name: "res-3"}},
+			hcl: parse(`resource "azurerm_subnet" "res-3" {
+  name                = "default"
+  resource_group_name = "rg"
+}
+`),
+		},
+	}
+	out, err = meta.variableAddon(configs)
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_subnet" "res-3" {
+  name                = "default"
+  resource_group_name = var.resource_group_name
+}
+`, string(hclwrite.Format(out[0].hcl.Bytes())))
+
+	require.NoError(t, meta.writeVariables())
+	b, err := os.ReadFile(filepath.Join(meta.moduleDir, VariablesFileName))
+	require.NoError(t, err)
+	require.Equal(t, `variable "location" {
+  type    = string
+  default = "westeurope"
+}
+
+variable "resource_group_name" {
+  type    = string
+  default = "rg"
+}
+
+variable "tags_env" {
+  type    = string
+  default = "prod"
+}
+`, string(b))
+}
+
+func TestNewVariableName(t *testing.T) {
+	meta := baseMeta{variables: map[variableValue]string{
+		{key: "location", value: "westeurope"}: "location",
+	}}
+	require.Equal(t, "location_2", meta.newVariableName("location"))
+	require.Equal(t, "sku_name", meta.newVariableName("sku.name"))
+	require.Equal(t, "tags_cost_center", meta.newVariableName("tags.Cost-Center"))
+}
+
+func TestLoadVariableAttributes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "attrs.json")
+	require.NoError(t, os.WriteFile(path, []byte(`["location", "sku.name", "tags"]`), 0644))
+	attrs, err := LoadVariableAttributes(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"location", "sku.name", "tags"}, attrs)
+
+	require.NoError(t, os.WriteFile(path, []byte(`["site_config.0.always_on"]`), 0644))
+	_, err = LoadVariableAttributes(path)
+	require.ErrorContains(t, err, `invalid attribute path "site_config.0.always_on"`)
+}
@@ -0,0 +1,70 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/Azure/aztfexport/pkg/log"
+)
+
+// workspaceBackendTypes are the backend types that support multiple workspaces (i.e. "terraform workspace").
+var workspaceBackendTypes = []string{"local", "azurerm", "consul", "cos", "gcs", "kubernetes", "oss", "pg", "remote", "s3"}
+
+// BackendSupportsWorkspaces tells whether the backend type supports multiple workspaces. Empty means the local backend.
+func BackendSupportsWorkspaces(backendType string) bool {
+	if backendType == "" {
+		backendType = "local"
+	}
+	for _, t := range workspaceBackendTypes {
+		if t == backendType {
+			return true
+		}
+	}
+	return false
+}
+
+// validateWorkspace validates the workspace name against the backend type.
+func validateWorkspace(name, backendType string) error {
+	// This is the same as how terraform validates the workspace names.
+	if url.PathEscape(name) != name {
+		return fmt.Errorf("invalid workspace name %q, which must be a valid URL path component", name)
+	}
+	if backendType == BackendTypeCloud {
+		return fmt.Errorf("the workspace of the %q BackendType is specified by the CloudWorkspace", BackendTypeCloud)
+	}
+	if !BackendSupportsWorkspaces(backendType) {
+		return fmt.Errorf("the %q backend doesn't support workspaces", backendType)
+	}
+	return nil
+}
+
+// selectWorkspace selects the workspace of the output directory, which is created if not exists.
+func (meta *baseMeta) selectWorkspace(ctx context.Context) error {
+	var workspaces []string
+	var current string
+	err := meta.auditLog.Run(meta.tf, func() (err error) {
+		workspaces, current, err = meta.tf.WorkspaceList(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("listing the workspaces: %v", err)
+	}
+	if current == meta.workspace {
+		return nil
+	}
+	for _, ws := range workspaces {
+		if ws == meta.workspace {
+			log.Printf(`[INFO] Select the workspace %q for the output directory`, meta.workspace)
+			if err := meta.auditLog.Run(meta.tf, func() error { return meta.tf.WorkspaceSelect(ctx, meta.workspace) }); err != nil {
+				return fmt.Errorf("selecting the workspace %q: %v", meta.workspace, err)
+			}
+			return nil
+		}
+	}
+	log.Printf(`[INFO] Create the workspace %q for the output directory`, meta.workspace)
+	if err := meta.auditLog.Run(meta.tf, func() error { return meta.tf.WorkspaceNew(ctx, meta.workspace) }); err != nil {
+		return fmt.Errorf("creating the workspace %q: %v", meta.workspace, err)
+	}
+	return nil
+}
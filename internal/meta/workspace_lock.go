@@ -0,0 +1,73 @@
+package meta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WorkspaceLockInfo is the content of the lock file, which identifies the run that holds the lock.
+type WorkspaceLockInfo struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	StartTime time.Time `json:"start_time"`
+}
+
+// LockWorkspace acquires the lock of the output directory, which is released by the returned function.
+// If force is set, the existing lock (e.g. left by a crashed run) is removed before acquiring the lock.
+func LockWorkspace(dir string, force bool) (unlock func() error, err error) {
+	path := filepath.Join(dir, WorkspaceLockFileName)
+	if force {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("removing the lock file %s: %v", path, err)
+		}
+	}
+
+	// #nosec G304
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("creating the lock file %s: %v", path, err)
+		}
+		msg := fmt.Sprintf("the output directory %q is locked by another run", dir)
+		var info WorkspaceLockInfo
+		// #nosec G304
+		if b, err := os.ReadFile(path); err == nil && json.Unmarshal(b, &info) == nil {
+			msg += fmt.Sprintf(" (pid %d on %s, started at %s)", info.PID, info.Host, info.StartTime.Format(time.RFC3339))
+		}
+		return nil, fmt.Errorf("%s, use `--force-unlock` if the lock is stale", msg)
+	}
+
+	host, _ := os.Hostname()
+	info := WorkspaceLockInfo{
+		PID:       os.Getpid(),
+		Host:      host,
+		StartTime: time.Now(),
+	}
+	b, err := json.Marshal(info)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("marshalling the lock info: %v", err)
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("writing the lock file %s: %v", path, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("closing the lock file %s: %v", path, err)
+	}
+
+	return func() error {
+		// The output directory might have been removed (e.g. the temporary output directory of the benchmark).
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing the lock file %s: %v", path, err)
+		}
+		return nil
+	}, nil
+}
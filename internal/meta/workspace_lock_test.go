@@ -0,0 +1,46 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockWorkspace(t *testing.T) {
+	dir := t.TempDir()
+
+	unlock, err := LockWorkspace(dir, false)
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, WorkspaceLockFileName))
+	require.NoError(t, err)
+
+	_, err = LockWorkspace(dir, false)
+	require.ErrorContains(t, err, "is locked by another run (pid ")
+	require.ErrorContains(t, err, "use `--force-unlock` if the lock is stale")
+
+	require.NoError(t, unlock())
+	_, err = os.Stat(filepath.Join(dir, WorkspaceLockFileName))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	// Unlocking twice is a noop
+	require.NoError(t, unlock())
+
+	unlock, err = LockWorkspace(dir, false)
+	require.NoError(t, err)
+	defer unlock()
+}
+
+func TestLockWorkspaceForce(t *testing.T) {
+	dir := t.TempDir()
+
+	// A stale lock left by a crashed run, which might be malformed
+	require.NoError(t, os.WriteFile(filepath.Join(dir, WorkspaceLockFileName), []byte("foo"), 0600))
+
+	_, err := LockWorkspace(dir, false)
+	require.EqualError(t, err, `the output directory "`+dir+"\" is locked by another run, use `--force-unlock` if the lock is stale")
+
+	unlock, err := LockWorkspace(dir, true)
+	require.NoError(t, err)
+	require.NoError(t, unlock())
+}
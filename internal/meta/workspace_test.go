@@ -0,0 +1,15 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateWorkspace(t *testing.T) {
+	require.NoError(t, validateWorkspace("prod", ""))
+	require.NoError(t, validateWorkspace("prod", "azurerm"))
+	require.ErrorContains(t, validateWorkspace("prod/east", "azurerm"), "must be a valid URL path component")
+	require.ErrorContains(t, validateWorkspace("prod", BackendTypeCloud), "specified by the CloudWorkspace")
+	require.ErrorContains(t, validateWorkspace("prod", "http"), `the "http" backend doesn't support workspaces`)
+}
@@ -0,0 +1,91 @@
+package meta
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// WriteOnlyMinProviderVersion is the minimum azurerm provider version that supports the write-only attributes below.
+// Note that write-only attributes require Terraform v1.11+, and ephemeral variables require Terraform v1.10+.
+const WriteOnlyMinProviderVersion = "4.23.0"
+
+// writeOnlyAttribute describes a write-only attribute of a TF resource type, which is never persisted to the state.
+type writeOnlyAttribute struct {
+	// The write-only attribute
+	name string
+	// The attribute that triggers the provider to use a new value of the write-only attribute
+	version string
+	// The legacy (non write-only) attribute that conflicts with the write-only attribute
+	legacy string
+}
+
+var writeOnlyAttributes = map[string][]writeOnlyAttribute{
+	"azurerm_key_vault_secret": {
+		{name: "value_wo", version: "value_wo_version", legacy: "value"},
+	},
+	"azurerm_mssql_server": {
+		{name: "administrator_login_password_wo", version: "administrator_login_password_wo_version", legacy: "administrator_login_password"},
+	},
+	"azurerm_mysql_flexible_server": {
+		{name: "administrator_password_wo", version: "administrator_password_wo_version", legacy: "administrator_password"},
+	},
+	"azurerm_postgresql_flexible_server": {
+		{name: "administrator_password_wo", version: "administrator_password_wo_version", legacy: "administrator_password"},
+	},
+}
+
+// writeOnlySupported tells whether the provider in use supports the write-only attributes.
+func (meta baseMeta) writeOnlySupported() bool {
+	if meta.providerName != ProviderAzureRM {
+		return false
+	}
+	// The provider version can be empty (dev provider) or a version constraint, in which case it is regarded as not supported.
+	v, err := version.NewVersion(meta.providerVersion)
+	if err != nil {
+		return false
+	}
+	return v.GreaterThanOrEqual(version.Must(version.NewVersion(WriteOnlyMinProviderVersion)))
+}
+
+// writeOnlyAddon sets the write-only attributes of the resources that are not managed via their legacy attributes, as the write-only attribute values can't be read back from the state.
+// Each of such attribute references to an ephemeral variable that is generated right after the resource.
+func (meta baseMeta) writeOnlyAddon(configs ConfigInfos) (ConfigInfos, error) {
+	if !meta.writeOnlySupported() {
+		return configs, nil
+	}
+	out := make(ConfigInfos, len(configs))
+	for i, cfg := range configs {
+		for _, attr := range writeOnlyAttributes[cfg.TFAddr.Type] {
+			if err := hclBlockSetWriteOnlyAttribute(cfg.hcl, cfg.TFAddr.Name, attr); err != nil {
+				return nil, fmt.Errorf("%s: %v", cfg.TFAddr, err)
+			}
+		}
+		out[i] = cfg
+	}
+	return out, nil
+}
+
+func hclBlockSetWriteOnlyAttribute(f *hclwrite.File, resourceName string, attr writeOnlyAttribute) error {
+	body := f.Body().Blocks()[0].Body()
+	if body.GetAttribute(attr.legacy) != nil {
+		return nil
+	}
+	varName := strings.ReplaceAll(resourceName, "-", "_") + "_" + attr.name
+	body.SetAttributeTraversal(attr.name, hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: varName}})
+	if body.GetAttribute(attr.version) == nil {
+		body.SetAttributeValue(attr.version, cty.NumberIntVal(1))
+	}
+
+	vb := hclwrite.NewBlock("variable", []string{varName})
+	vb.Body().SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+	vb.Body().SetAttributeValue("sensitive", cty.True)
+	vb.Body().SetAttributeValue("ephemeral", cty.True)
+	f.Body().AppendNewline()
+	f.Body().AppendBlock(vb)
+	return nil
+}
@@ -0,0 +1,64 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteOnlyAddon(t *testing.T) {
+	parse := func(src string) *hclwrite.File {
+		f, diags := hclwrite.ParseConfig([]byte(src), "", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		return f
+	}
+	configs := ConfigInfos{
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_key_vault_secret", Name: "res-0"}},
+			hcl: parse(`resource "azurerm_key_vault_secret" "res-0" {
+  key_vault_id = "kvid"
+  name         = "secret"
+}
+`),
+		},
+		{
+			ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_key_vault_secret", Name: "res-1"}},
+			hcl: parse(`resource "azurerm_key_vault_secret" "res-1" {
+  key_vault_id = "kvid"
+  name         = "secret"
+  value        = "foo"
+}
+`),
+		},
+	}
+
+	// Not supported by the provider version
+	out, err := baseMeta{providerName: ProviderAzureRM, providerVersion: "3.65.0"}.writeOnlyAddon(configs)
+	require.NoError(t, err)
+	require.Equal(t, configs, out)
+
+	out, err = baseMeta{providerName: ProviderAzureRM, providerVersion: "4.30.0"}.writeOnlyAddon(configs)
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_key_vault_secret" "res-0" {
+  key_vault_id     = "kvid"
+  name             = "secret"
+  value_wo         = var.res_0_value_wo
+  value_wo_version = 1
+}
+
+variable "res_0_value_wo" {
+  type      = string
+  sensitive = true
+  ephemeral = true
+}
+`, string(hclwrite.Format(out[0].hcl.Bytes())))
+	require.Equal(t, `resource "azurerm_key_vault_secret" "res-1" {
+  key_vault_id = "kvid"
+  name         = "secret"
+  value        = "foo"
+}
+`, string(hclwrite.Format(out[1].hcl.Bytes())))
+}
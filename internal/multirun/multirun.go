@@ -0,0 +1,209 @@
+// Package multirun runs the export of multiple scopes (subscriptions or resource groups) concurrently, and reports the results in aggregate.
+package multirun
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/aztfexport/internal"
+	"github.com/magodo/armid"
+	"github.com/magodo/workerpool"
+)
+
+// ReportFileName is the file under the output directory that records the results of all the scopes.
+const ReportFileName = "aztfexport-multi-report.json"
+
+// Scope is a subscription, or a resource group if ResourceGroup is set.
+type Scope struct {
+	SubscriptionId string
+	ResourceGroup  string
+}
+
+func (s Scope) String() string {
+	id := "/subscriptions/" + s.SubscriptionId
+	if s.ResourceGroup != "" {
+		id += "/resourceGroups/" + s.ResourceGroup
+	}
+	return id
+}
+
+// OutputDir returns the directory under the root output directory that the scope is exported to.
+func (s Scope) OutputDir(root string) string {
+	if s.ResourceGroup == "" {
+		return filepath.Join(root, s.SubscriptionId)
+	}
+	return filepath.Join(root, s.SubscriptionId, s.ResourceGroup)
+}
+
+// ParseScopeFile parses the scope file, which contains one subscription or resource group id per line.
+// The empty lines and the lines starting with "#" are ignored.
+func ParseScopeFile(path string) ([]Scope, error) {
+	// #nosec G304
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening the scope file %s: %v", path, err)
+	}
+	// #nosec G307
+	defer f.Close()
+	return parseScopes(f)
+}
+
+func parseScopes(r io.Reader) ([]Scope, error) {
+	var scopes []Scope
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	for ln := 1; scanner.Scan(); ln++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, err := armid.ParseResourceId(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: parsing %q: %v", ln, line, err)
+		}
+		var scope Scope
+		switch id := id.(type) {
+		case *armid.SubscriptionId:
+			scope = Scope{SubscriptionId: id.Id}
+		case *armid.ResourceGroup:
+			scope = Scope{SubscriptionId: id.SubscriptionId, ResourceGroup: id.Name}
+		default:
+			return nil, fmt.Errorf("line %d: %q is neither a subscription nor a resource group", ln, line)
+		}
+		key := strings.ToUpper(scope.String())
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicated scope %q", ln, line)
+		}
+		seen[key] = true
+		scopes = append(scopes, scope)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("no scope found")
+	}
+	// The resource groups are exported as part of the subscriptions, which would end up being managed twice.
+	for _, scope := range scopes {
+		if scope.ResourceGroup != "" && seen[strings.ToUpper(Scope{SubscriptionId: scope.SubscriptionId}.String())] {
+			return nil, fmt.Errorf("scope %q overlaps with its subscription", scope)
+		}
+	}
+	return scopes, nil
+}
+
+type Config struct {
+	// OutputDir is the root output directory, each scope is exported to its own directory under it
+	OutputDir string
+	// Scopes are the scopes to export
+	Scopes []Scope
+	// Concurrency is the number of scopes to export at the same time
+	Concurrency int
+
+	// Export exports the scope to the output directory
+	Export func(ctx context.Context, scope Scope, outputDir string) error
+
+	// Out is where the report is written to
+	Out io.Writer
+}
+
+// Result is the export result of a scope.
+type Result struct {
+	Scope     string `json:"scope"`
+	OutputDir string `json:"output_dir"`
+	Duration  string `json:"duration"`
+	Error     string `json:"error,omitempty"`
+	// Partial is the summary of the problems of the scope that partially succeeded
+	Partial string `json:"partial,omitempty"`
+}
+
+// Run exports all the scopes, and writes the report. A failed scope doesn't stop the others, but fails the run at the end.
+// If no scope fails, but some partially succeed, the *internal.PartialSuccessError with the problems of all the scopes is returned.
+func Run(ctx context.Context, cfg Config) error {
+	results := make([]Result, len(cfg.Scopes))
+	problems := make([][]internal.Problem, len(cfg.Scopes))
+
+	wp := workerpool.NewWorkPool(cfg.Concurrency)
+	wp.Run(nil)
+	for i, scope := range cfg.Scopes {
+		i, scope := i, scope
+		wp.AddTask(func() (interface{}, error) {
+			dir := scope.OutputDir(cfg.OutputDir)
+			start := time.Now()
+			// #nosec G301
+			err := os.MkdirAll(dir, 0750)
+			if err == nil {
+				err = cfg.Export(ctx, scope, dir)
+			}
+			results[i] = Result{
+				Scope:     scope.String(),
+				OutputDir: dir,
+				Duration:  time.Since(start).Round(time.Second).String(),
+			}
+			var perr *internal.PartialSuccessError
+			switch {
+			case err == nil:
+			case errors.As(err, &perr):
+				results[i].Partial = err.Error()
+				problems[i] = perr.Problems
+			default:
+				results[i].Error = err.Error()
+			}
+			return nil, nil
+		})
+	}
+	if err := wp.Done(); err != nil {
+		return err
+	}
+
+	failed := writeReport(cfg.Out, results)
+
+	b, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the report: %v", err)
+	}
+	path := filepath.Join(cfg.OutputDir, ReportFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing the report to %s: %v", path, err)
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d of %d scopes failed, see %s for details", failed, len(results), path)
+	}
+	var allProblems []internal.Problem
+	for _, l := range problems {
+		allProblems = append(allProblems, l...)
+	}
+	if len(allProblems) != 0 {
+		return &internal.PartialSuccessError{Problems: allProblems}
+	}
+	return nil
+}
+
+// writeReport writes the human readable report, and returns the number of the failed scopes.
+func writeReport(w io.Writer, results []Result) int {
+	failed := 0
+	for _, res := range results {
+		if res.Error != "" {
+			failed++
+			fmt.Fprintf(w, "FAILED  %s (%s): %s\n", res.Scope, res.Duration, res.Error)
+			continue
+		}
+		if res.Partial != "" {
+			fmt.Fprintf(w, "PARTIAL %s (%s) -> %s: %s\n", res.Scope, res.Duration, res.OutputDir, res.Partial)
+			continue
+		}
+		fmt.Fprintf(w, "OK      %s (%s) -> %s\n", res.Scope, res.Duration, res.OutputDir)
+	}
+	fmt.Fprintf(w, "\n%d succeeded, %d failed\n", len(results)-failed, failed)
+	return failed
+}
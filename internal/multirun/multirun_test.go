@@ -0,0 +1,140 @@
+package multirun
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScopes(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		expect []Scope
+		err    string
+	}{
+		{
+			name: "subscriptions and resource groups",
+			input: `# the scopes
+/subscriptions/sub1
+
+/subscriptions/sub2/resourceGroups/rg1
+  /subscriptions/sub2/resourceGroups/rg2
+`,
+			expect: []Scope{
+				{SubscriptionId: "sub1"},
+				{SubscriptionId: "sub2", ResourceGroup: "rg1"},
+				{SubscriptionId: "sub2", ResourceGroup: "rg2"},
+			},
+		},
+		{
+			name:  "empty",
+			input: "# nothing\n",
+			err:   "no scope found",
+		},
+		{
+			name:  "resource id",
+			input: "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1\n",
+			err:   `line 1: "/subscriptions/sub1/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1" is neither a subscription nor a resource group`,
+		},
+		{
+			name:  "duplicated",
+			input: "/subscriptions/sub1/resourceGroups/rg1\n/subscriptions/sub1/resourcegroups/RG1\n",
+			err:   `line 2: duplicated scope "/subscriptions/sub1/resourcegroups/RG1"`,
+		},
+		{
+			name:  "overlapped",
+			input: "/subscriptions/sub1/resourceGroups/rg1\n/subscriptions/sub1\n",
+			err:   `scope "/subscriptions/sub1/resourceGroups/rg1" overlaps with its subscription`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			scopes, err := parseScopes(strings.NewReader(tt.input))
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, scopes)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	err := Run(context.Background(), Config{
+		OutputDir: dir,
+		Scopes: []Scope{
+			{SubscriptionId: "sub1"},
+			{SubscriptionId: "sub2", ResourceGroup: "rg1"},
+		},
+		Concurrency: 2,
+		Export: func(_ context.Context, scope Scope, outputDir string) error {
+			if scope.ResourceGroup == "rg1" {
+				return fmt.Errorf("boom")
+			}
+			return os.WriteFile(filepath.Join(outputDir, "main.tf"), nil, 0644)
+		},
+		Out: &out,
+	})
+	require.EqualError(t, err, fmt.Sprintf("1 of 2 scopes failed, see %s for details", filepath.Join(dir, ReportFileName)))
+
+	require.FileExists(t, filepath.Join(dir, "sub1", "main.tf"))
+	require.DirExists(t, filepath.Join(dir, "sub2", "rg1"))
+	require.Contains(t, out.String(), "OK      /subscriptions/sub1 (0s) -> "+filepath.Join(dir, "sub1"))
+	require.Contains(t, out.String(), "FAILED  /subscriptions/sub2/resourceGroups/rg1 (0s): boom")
+	require.Contains(t, out.String(), "1 succeeded, 1 failed")
+
+	b, err := os.ReadFile(filepath.Join(dir, ReportFileName))
+	require.NoError(t, err)
+	var results []Result
+	require.NoError(t, json.Unmarshal(b, &results))
+	require.Len(t, results, 2)
+	require.Equal(t, "/subscriptions/sub1", results[0].Scope)
+	require.Empty(t, results[0].Error)
+	require.Equal(t, "boom", results[1].Error)
+}
+
+func TestRunPartialSuccess(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	problem := internal.Problem{Kind: internal.ProblemKindUnsupported, AzureResourceId: "/subscriptions/sub2/resourceGroups/rg1/providers/Microsoft.Foo/bars/bar"}
+	err := Run(context.Background(), Config{
+		OutputDir: dir,
+		Scopes: []Scope{
+			{SubscriptionId: "sub1"},
+			{SubscriptionId: "sub2", ResourceGroup: "rg1"},
+		},
+		Concurrency: 2,
+		Export: func(_ context.Context, scope Scope, outputDir string) error {
+			if scope.ResourceGroup == "rg1" {
+				return &internal.PartialSuccessError{Problems: []internal.Problem{problem}}
+			}
+			return nil
+		},
+		Out: &out,
+	})
+	var perr *internal.PartialSuccessError
+	require.ErrorAs(t, err, &perr)
+	require.Equal(t, []internal.Problem{problem}, perr.Problems)
+	require.Contains(t, out.String(), "PARTIAL /subscriptions/sub2/resourceGroups/rg1 (0s) -> "+filepath.Join(dir, "sub2", "rg1")+": partially succeeded: 0 resource(s) failed to import, 1 resource(s) unsupported")
+	require.Contains(t, out.String(), "2 succeeded, 0 failed")
+
+	b, err := os.ReadFile(filepath.Join(dir, ReportFileName))
+	require.NoError(t, err)
+	var results []Result
+	require.NoError(t, json.Unmarshal(b, &results))
+	require.Empty(t, results[1].Error)
+	require.NotEmpty(t, results[1].Partial)
+}
@@ -0,0 +1,223 @@
+// Package planimport matches the resources that a Terraform plan is to create with the existing Azure resources, which are imported instead,
+// so that the existing infrastructure is adopted into the (greenfield) configuration without being duplicated.
+package planimport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/magodo/aztft/aztft"
+)
+
+const azurermProviderName = "registry.terraform.io/hashicorp/azurerm"
+
+// PlannedResource is a resource that the plan is to create.
+type PlannedResource struct {
+	// Address is the TF resource address
+	Address string
+	// Type is the TF resource type
+	Type string
+	// Name is the TF resource name
+	Name string
+	// AzureName is the name of the Azure resource
+	AzureName string
+	// ResourceGroupName is the resource group of the Azure resource, which is empty for the resource group itself
+	ResourceGroupName string
+}
+
+// Unmatched is a planned resource that is not imported.
+type Unmatched struct {
+	Address string
+	Reason  string
+}
+
+// ReadPlan reads the plan JSON file (i.e. the output of "terraform show -json <plan file>").
+func ReadPlan(path string) (*tfjson.Plan, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the plan file %s: %v", path, err)
+	}
+	var plan tfjson.Plan
+	if err := json.Unmarshal(b, &plan); err != nil {
+		return nil, fmt.Errorf("unmarshalling the plan file %s: %v", path, err)
+	}
+	return &plan, nil
+}
+
+// PlannedResources returns the azurerm resources that the plan is to create (excluding the replaced ones), and the ones that can't be matched by name.
+// Only the resources in the root module without "count" or "for_each" are supported, as the resource mapping only records the TF resource type and name.
+func PlannedResources(plan *tfjson.Plan) ([]PlannedResource, []Unmatched) {
+	var resources []PlannedResource
+	var unmatched []Unmatched
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode != tfjson.ManagedResourceMode || rc.ProviderName != azurermProviderName || rc.Change == nil || !rc.Change.Actions.Create() {
+			continue
+		}
+		if rc.ModuleAddress != "" || rc.Index != nil {
+			unmatched = append(unmatched, Unmatched{Address: rc.Address, Reason: `only the resources in the root module without "count" or "for_each" are supported`})
+			continue
+		}
+		after, _ := rc.Change.After.(map[string]interface{})
+		name, _ := after["name"].(string)
+		if name == "" {
+			unmatched = append(unmatched, Unmatched{Address: rc.Address, Reason: `the "name" is unknown`})
+			continue
+		}
+		res := PlannedResource{
+			Address:   rc.Address,
+			Type:      rc.Type,
+			Name:      rc.Name,
+			AzureName: name,
+		}
+		if rc.Type != "azurerm_resource_group" {
+			rg, _ := after["resource_group_name"].(string)
+			if rg == "" {
+				unmatched = append(unmatched, Unmatched{Address: rc.Address, Reason: `the "resource_group_name" is unknown or not supported`})
+				continue
+			}
+			res.ResourceGroupName = rg
+		}
+		resources = append(resources, res)
+	}
+	return resources, unmatched
+}
+
+// Lookup looks up the existing Azure resources.
+type Lookup struct {
+	// ResourceGroupExists reports whether the resource group exists.
+	ResourceGroupExists func(ctx context.Context, name string) (bool, error)
+	// ListByName lists the ids of the (top level) resources of the name in the resource group.
+	ListByName func(ctx context.Context, resourceGroupName, name string) ([]string, error)
+	// APIOption is used to resolve the TF resource id, nil means the static resolution only.
+	APIOption *aztft.APIOption
+}
+
+// NewARMLookup returns the Lookup backed by the ARM API.
+func NewARMLookup(subscriptionId string, cred azcore.TokenCredential, clientOpt arm.ClientOptions) (*Lookup, error) {
+	rgClient, err := armresources.NewResourceGroupsClient(subscriptionId, cred, &clientOpt)
+	if err != nil {
+		return nil, fmt.Errorf("building the resource group client: %v", err)
+	}
+	client, err := armresources.NewClient(subscriptionId, cred, &clientOpt)
+	if err != nil {
+		return nil, fmt.Errorf("building the resource client: %v", err)
+	}
+	return &Lookup{
+		ResourceGroupExists: func(ctx context.Context, name string) (bool, error) {
+			resp, err := rgClient.CheckExistence(ctx, name, nil)
+			if err != nil {
+				return false, fmt.Errorf("checking the existence of the resource group %s: %v", name, err)
+			}
+			return resp.Success, nil
+		},
+		ListByName: func(ctx context.Context, resourceGroupName, name string) ([]string, error) {
+			filter := fmt.Sprintf("name eq '%s'", strings.ReplaceAll(name, "'", "''"))
+			pager := client.NewListByResourceGroupPager(resourceGroupName, &armresources.ClientListByResourceGroupOptions{Filter: &filter})
+			var ids []string
+			for pager.More() {
+				page, err := pager.NextPage(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("listing the resources named %s in the resource group %s: %v", name, resourceGroupName, err)
+				}
+				for _, res := range page.Value {
+					if res.ID != nil {
+						ids = append(ids, *res.ID)
+					}
+				}
+			}
+			return ids, nil
+		},
+		APIOption: &aztft.APIOption{Cred: cred, ClientOption: clientOpt},
+	}, nil
+}
+
+// BuildMapping builds the resource mapping of the planned resources that exist in Azure, where the existing resource is matched by its name, resource group and
+// the TF resource type that it resolves to. The planned resources that don't exist are not returned as unmatched, which are just to be created.
+func BuildMapping(ctx context.Context, subscriptionId string, resources []PlannedResource, lookup Lookup) (resmap.ResourceMapping, []Unmatched, error) {
+	// The existence of the resource groups are cached, which are shared by the resources.
+	rgExists := map[string]bool{}
+	resourceGroupExists := func(name string) (bool, error) {
+		if ok, cached := rgExists[strings.ToUpper(name)]; cached {
+			return ok, nil
+		}
+		ok, err := lookup.ResourceGroupExists(ctx, name)
+		if err != nil {
+			return false, err
+		}
+		rgExists[strings.ToUpper(name)] = ok
+		return ok, nil
+	}
+
+	m := resmap.ResourceMapping{}
+	var unmatched []Unmatched
+	for _, res := range resources {
+		var azureIds []string
+		if res.Type == "azurerm_resource_group" {
+			ok, err := resourceGroupExists(res.AzureName)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok {
+				azureIds = append(azureIds, fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", subscriptionId, res.AzureName))
+			}
+		} else {
+			ok, err := resourceGroupExists(res.ResourceGroupName)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !ok {
+				continue
+			}
+			ids, err := lookup.ListByName(ctx, res.ResourceGroupName, res.AzureName)
+			if err != nil {
+				return nil, nil, err
+			}
+			azureIds = ids
+		}
+
+		var matches []string
+		for _, id := range azureIds {
+			types, _, err := aztft.QueryType(id, nil)
+			if err != nil {
+				continue
+			}
+			for _, t := range types {
+				if t.TFType == res.Type {
+					matches = append(matches, t.AzureId.String())
+					break
+				}
+			}
+		}
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+		default:
+			sort.Strings(matches)
+			unmatched = append(unmatched, Unmatched{Address: res.Address, Reason: fmt.Sprintf("multiple existing resources match: %s", strings.Join(matches, ", "))})
+			continue
+		}
+
+		tfid, err := aztft.QueryId(matches[0], res.Type, lookup.APIOption)
+		if err != nil {
+			unmatched = append(unmatched, Unmatched{Address: res.Address, Reason: fmt.Sprintf("resolving the TF resource id of %s: %v", matches[0], err)})
+			continue
+		}
+		m[matches[0]] = resmap.ResourceMapEntity{
+			ResourceId:   tfid,
+			ResourceType: res.Type,
+			ResourceName: res.Name,
+		}
+	}
+	return m, unmatched, nil
+}
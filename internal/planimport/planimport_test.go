@@ -0,0 +1,54 @@
+package planimport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMapping(t *testing.T) {
+	plan, err := ReadPlan("testdata/plan.json")
+	require.NoError(t, err)
+	resources, unmatched := PlannedResources(plan)
+	require.Equal(t, []PlannedResource{
+		{Address: "azurerm_resource_group.main", Type: "azurerm_resource_group", Name: "main", AzureName: "rg1"},
+		{Address: "azurerm_virtual_network.main", Type: "azurerm_virtual_network", Name: "main", AzureName: "vnet1", ResourceGroupName: "rg1"},
+		{Address: "azurerm_storage_account.new", Type: "azurerm_storage_account", Name: "new", AzureName: "sa1", ResourceGroupName: "rg1"},
+	}, resources)
+	require.Equal(t, []Unmatched{
+		{Address: "azurerm_public_ip.computed", Reason: `the "name" is unknown`},
+		{Address: `azurerm_network_security_group.each["a"]`, Reason: `only the resources in the root module without "count" or "for_each" are supported`},
+	}, unmatched)
+
+	var rgChecks int
+	lookup := Lookup{
+		ResourceGroupExists: func(_ context.Context, name string) (bool, error) {
+			rgChecks++
+			return name == "rg1", nil
+		},
+		ListByName: func(_ context.Context, rg, name string) ([]string, error) {
+			if name == "vnet1" {
+				return []string{"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1"}, nil
+			}
+			return nil, nil
+		},
+	}
+	m, unmatched, err := BuildMapping(context.Background(), "123", resources, lookup)
+	require.NoError(t, err)
+	require.Empty(t, unmatched)
+	require.Equal(t, 1, rgChecks)
+	require.Equal(t, resmap.ResourceMapping{
+		"/subscriptions/123/resourceGroups/rg1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1",
+			ResourceType: "azurerm_resource_group",
+			ResourceName: "main",
+		},
+		"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1",
+			ResourceType: "azurerm_virtual_network",
+			ResourceName: "main",
+		},
+	}, m)
+}
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/meta"
+)
+
+const (
+	ProblemKindImportError = "import_error"
+	ProblemKindUnsupported = "unsupported"
+	// ProblemKindRemaining is the resource that is not attempted, as the run is stopped by its budget (i.e. the maximum number of resources, or the maximum duration).
+	ProblemKindRemaining = "remaining"
+	// ProblemKindFatal is the error that fails the whole run, which has no resource.
+	ProblemKindFatal = "fatal"
+)
+
+// ProblemLinePrefix prefixes the problem lines, which are in the stable format of:
+//
+//	aztfexport::<kind>::<Azure resource id>::<TF address>::<message>
+//
+// where the resource id and the TF address are "-" if not applicable, and the message is in one line.
+const ProblemLinePrefix = "aztfexport::"
+
+// Problem is a problem of the run, either of a resource or fatal.
+type Problem struct {
+	Kind            string
+	AzureResourceId string
+	TFAddress       string
+	Message         string
+}
+
+// Line formats the problem in the stable format (see ProblemLinePrefix).
+func (p Problem) Line() string {
+	id, addr := p.AzureResourceId, p.TFAddress
+	if id == "" {
+		id = "-"
+	}
+	if addr == "" {
+		addr = "-"
+	}
+	msg := strings.Join(strings.Fields(p.Message), " ")
+	return ProblemLinePrefix + strings.Join([]string{p.Kind, id, addr, msg}, "::")
+}
+
+// PartialSuccessError is returned by BatchImport if the run succeeds, but some resources failed to import (with ContinueOnError), are unsupported,
+// or remain not attempted (as the run is stopped by its budget).
+type PartialSuccessError struct {
+	Problems []Problem
+}
+
+func (e *PartialSuccessError) Error() string {
+	var nerr, nunsupported, nremaining int
+	for _, p := range e.Problems {
+		switch p.Kind {
+		case ProblemKindImportError:
+			nerr++
+		case ProblemKindUnsupported:
+			nunsupported++
+		case ProblemKindRemaining:
+			nremaining++
+		}
+	}
+	msg := fmt.Sprintf("partially succeeded: %d resource(s) failed to import, %d resource(s) unsupported", nerr, nunsupported)
+	if nremaining != 0 {
+		msg += fmt.Sprintf(", %d resource(s) remaining", nremaining)
+	}
+	return msg
+}
+
+// resourceProblems returns the problems of the resources, i.e. the ones that failed to import or are unsupported.
+func resourceProblems(l meta.ImportList) []Problem {
+	var problems []Problem
+	for _, item := range l {
+		switch {
+		case itemUnsupported(item):
+			problems = append(problems, Problem{
+				Kind:            ProblemKindUnsupported,
+				AzureResourceId: item.AzureResourceID.String(),
+				Message:         item.SkipReason(),
+			})
+		case item.ImportError != nil:
+			problems = append(problems, Problem{
+				Kind:            ProblemKindImportError,
+				AzureResourceId: item.AzureResourceID.String(),
+				TFAddress:       item.TFAddr.String(),
+				Message:         item.ImportError.Error(),
+			})
+		}
+	}
+	return problems
+}
+
+// WriteErrorReport writes the problems to the file, one line each in the stable format (see ProblemLinePrefix).
+func WriteErrorReport(path string, problems []Problem) error {
+	var lines []string
+	for _, p := range problems {
+		lines = append(lines, p.Line()+"\n")
+	}
+	// #nosec G306
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0644); err != nil {
+		return fmt.Errorf("writing the error report to %s: %v", path, err)
+	}
+	return nil
+}
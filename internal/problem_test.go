@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceProblems(t *testing.T) {
+	item := func(id, tfType, name string) meta.ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return meta.ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}}
+	}
+	imported := item("/subscriptions/123/resourceGroups/rg1", "azurerm_resource_group", "res-0")
+	imported.Imported = true
+	failed := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1", "azurerm_virtual_network", "res-1")
+	failed.ImportError = fmt.Errorf("boom\n  bang")
+	unsupported := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/bars/bar1", "", "res-2")
+	locked := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/publicIPAddresses/pip1", "", "res-3")
+	locked.Lock = "CanNotDelete"
+
+	problems := resourceProblems(meta.ImportList{imported, failed, unsupported, locked})
+	var lines []string
+	for _, p := range problems {
+		lines = append(lines, p.Line())
+	}
+	require.Equal(t, []string{
+		"aztfexport::import_error::/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1::azurerm_virtual_network.res-1::boom bang",
+		"aztfexport::unsupported::/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/bars/bar1::-::no TF resource type",
+	}, lines)
+	require.EqualError(t, &PartialSuccessError{Problems: problems}, "partially succeeded: 1 resource(s) failed to import, 1 resource(s) unsupported")
+	remaining := append(problems, Problem{Kind: ProblemKindRemaining, AzureResourceId: "/subscriptions/123/resourceGroups/rg2", Message: "not attempted"})
+	require.EqualError(t, &PartialSuccessError{Problems: remaining}, "partially succeeded: 1 resource(s) failed to import, 1 resource(s) unsupported, 1 resource(s) remaining")
+
+	path := filepath.Join(t.TempDir(), "errors.txt")
+	require.NoError(t, WriteErrorReport(path, append(problems, Problem{Kind: ProblemKindFatal, Message: "failed"})))
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, lines[0]+"\n"+lines[1]+"\n"+"aztfexport::fatal::-::-::failed\n", string(b))
+}
@@ -0,0 +1,172 @@
+// Package provenance generates the provenance manifest of an export, which records what produced the output directory, and optionally signs it.
+package provenance
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ManifestFileName is the file under the output directory that records the provenance.
+const ManifestFileName = "aztfexport-provenance.json"
+
+const (
+	SignerCosign   = "cosign"
+	SignerMinisign = "minisign"
+)
+
+// Signers are the supported tools to sign the provenance manifest.
+var Signers = []string{SignerCosign, SignerMinisign}
+
+// Option is the provenance option of a run, the zero value means not to generate the provenance manifest.
+type Option struct {
+	Enabled bool
+	// Signer is the tool to sign the manifest with, empty means not to sign it
+	Signer string
+	// SignKey is the path to the private key of the signer. For cosign, empty means keyless signing.
+	SignKey string
+}
+
+type Manifest struct {
+	Tool        Tool       `json:"tool"`
+	GeneratedAt string     `json:"generated_at"`
+	Invocation  Invocation `json:"invocation"`
+	Files       []File     `json:"files"`
+}
+
+type Tool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Invocation struct {
+	// CLI is the effective CLI, which doesn't contain the sensitive values
+	CLI            string `json:"cli"`
+	SubscriptionId string `json:"subscription_id"`
+	// ScopeType is the kind of the exported scope, e.g. "resource-group", "query"
+	ScopeType string `json:"scope_type"`
+	Scope     string `json:"scope"`
+}
+
+type File struct {
+	// Path is the slash separated path relative to the output directory
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Build builds the manifest of the output directory. The provider cache (.terraform) and the provenance files themselves are excluded.
+func Build(outputDir string, tool Tool, invocation Invocation) (*Manifest, error) {
+	var files []File
+	err := filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".terraform" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ManifestFileName || rel == ManifestFileName+".sig" || rel == ManifestFileName+".minisig" {
+			return nil
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Path: rel, SHA256: sum})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hashing the files of %s: %v", outputDir, err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	return &Manifest{
+		Tool:        tool,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Invocation:  invocation,
+		Files:       files,
+	}, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	// #nosec G304
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	// #nosec G307
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Write writes the manifest to the output directory, and signs it if the signer is specified.
+func Write(ctx context.Context, outputDir string, manifest *Manifest, opt Option) error {
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the provenance manifest: %v", err)
+	}
+	path := filepath.Join(outputDir, ManifestFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing the provenance manifest to %s: %v", path, err)
+	}
+	if opt.Signer == "" {
+		return nil
+	}
+
+	args, err := signArgs(opt.Signer, opt.SignKey, path)
+	if err != nil {
+		return err
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return fmt.Errorf("%s is not found in the PATH", args[0])
+	}
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	// The signers might prompt for the key password, or the keyless signing flow
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("signing the provenance manifest with %s: %v", opt.Signer, err)
+	}
+	return nil
+}
+
+// signArgs returns the command to sign the manifest. The signature is written next to the manifest, i.e. "<manifest>.sig" for cosign and "<manifest>.minisig" for minisign.
+func signArgs(signer, key, path string) ([]string, error) {
+	switch signer {
+	case SignerCosign:
+		args := []string{"cosign", "sign-blob", "--yes", "--output-signature", path + ".sig"}
+		if key != "" {
+			args = append(args, "--key", key)
+		}
+		return append(args, path), nil
+	case SignerMinisign:
+		args := []string{"minisign", "-S", "-m", path}
+		if key != "" {
+			args = append(args, "-s", key)
+		}
+		return args, nil
+	default:
+		return nil, fmt.Errorf("unknown signer %q", signer)
+	}
+}
@@ -0,0 +1,53 @@
+package provenance
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAndWrite(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("foo"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".terraform", "providers"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".terraform", "providers", "bin"), []byte("bin"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "arm"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "arm", "res-0.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFileName), []byte("old"), 0644))
+
+	invocation := Invocation{CLI: "aztfexport rg", SubscriptionId: "123", ScopeType: "resource-group", Scope: "rg1"}
+	manifest, err := Build(dir, Tool{Name: "aztfexport", Version: "v0.1.0"}, invocation)
+	require.NoError(t, err)
+	require.Equal(t, []File{
+		{Path: "arm/res-0.json", SHA256: "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"},
+		{Path: "main.tf", SHA256: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"},
+	}, manifest.Files)
+
+	require.NoError(t, Write(context.Background(), dir, manifest, Option{Enabled: true}))
+	b, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	require.NoError(t, err)
+	var actual Manifest
+	require.NoError(t, json.Unmarshal(b, &actual))
+	require.Equal(t, *manifest, actual)
+}
+
+func TestSignArgs(t *testing.T) {
+	args, err := signArgs(SignerCosign, "", "/out/m.json")
+	require.NoError(t, err)
+	require.Equal(t, []string{"cosign", "sign-blob", "--yes", "--output-signature", "/out/m.json.sig", "/out/m.json"}, args)
+
+	args, err = signArgs(SignerCosign, "cosign.key", "/out/m.json")
+	require.NoError(t, err)
+	require.Equal(t, []string{"cosign", "sign-blob", "--yes", "--output-signature", "/out/m.json.sig", "--key", "cosign.key", "/out/m.json"}, args)
+
+	args, err = signArgs(SignerMinisign, "minisign.key", "/out/m.json")
+	require.NoError(t, err)
+	require.Equal(t, []string{"minisign", "-S", "-m", "/out/m.json", "-s", "minisign.key"}, args)
+
+	_, err = signArgs("gpg", "", "/out/m.json")
+	require.EqualError(t, err, `unknown signer "gpg"`)
+}
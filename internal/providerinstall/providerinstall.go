@@ -0,0 +1,120 @@
+// Package providerinstall finds or installs the azurerm provider binary, which is used by terraform-client-go to import resources without the terraform binary.
+package providerinstall
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hc-install/product"
+	"github.com/hashicorp/hc-install/releases"
+)
+
+const providerName = "azurerm"
+
+// binaryName returns the name of the provider binary of the specified version, as is released.
+func binaryName(ver string) string {
+	name := fmt.Sprintf("terraform-provider-%s_v%s_x5", providerName, ver)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// packageDir returns the directory of the provider package of the specified version under the plugin directory,
+// which follows the unpacked layout of the Terraform plugin cache directory.
+func packageDir(pluginDir, ver string) string {
+	return filepath.Join(pluginDir, "registry.terraform.io", "hashicorp", providerName, ver, runtime.GOOS+"_"+runtime.GOARCH)
+}
+
+// PluginDirs returns the well-known Terraform plugin directories, which are searched in order.
+func PluginDirs() []string {
+	var dirs []string
+	if v := os.Getenv("TF_PLUGIN_CACHE_DIR"); v != "" {
+		dirs = append(dirs, v)
+	}
+	if runtime.GOOS == "windows" {
+		if v := os.Getenv("APPDATA"); v != "" {
+			dirs = append(dirs, filepath.Join(v, "terraform.d", "plugins"))
+		}
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".terraform.d", "plugins"))
+	}
+	if v, err := defaultInstallDir(); err == nil {
+		dirs = append(dirs, v)
+	}
+	return dirs
+}
+
+func defaultInstallDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "aztfexport", "plugins"), nil
+}
+
+// Find finds the provider binary of the specified version from the plugin directories. It returns an empty string if not found.
+func Find(ver string, dirs []string) string {
+	for _, dir := range dirs {
+		path := filepath.Join(packageDir(dir, ver), binaryName(ver))
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Install downloads the provider binary of the specified version from releases.hashicorp.com to the plugin directory, with its checksum verified.
+func Install(ctx context.Context, ver string, pluginDir string) (string, error) {
+	v, err := version.NewVersion(ver)
+	if err != nil {
+		return "", fmt.Errorf("parsing the provider version %q: %v", ver, err)
+	}
+	dir := packageDir(pluginDir, ver)
+	// #nosec G301
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("creating directory %s: %v", dir, err)
+	}
+	installer := &releases.ExactVersion{
+		Product: product.Product{
+			Name: "terraform-provider-" + providerName,
+			BinaryName: func() string {
+				return binaryName(ver)
+			},
+		},
+		Version:    v,
+		InstallDir: dir,
+	}
+	path, err := installer.Install(ctx)
+	if err != nil {
+		return "", fmt.Errorf("installing the %s provider v%s: %v", providerName, ver, err)
+	}
+	return path, nil
+}
+
+// Ensure finds the provider binary of the specified version from the plugin directories (the specified one takes precedence over the well-known ones),
+// or installs it to the specified plugin directory (or the aztfexport cache directory if not specified) if not found.
+func Ensure(ctx context.Context, ver string, pluginDir string) (string, error) {
+	dirs := PluginDirs()
+	if pluginDir != "" {
+		dirs = append([]string{pluginDir}, dirs...)
+	}
+	if path := Find(ver, dirs); path != "" {
+		log.Printf("[INFO] Found the %s provider v%s at %s", providerName, ver, path)
+		return path, nil
+	}
+	if pluginDir == "" {
+		var err error
+		pluginDir, err = defaultInstallDir()
+		if err != nil {
+			return "", fmt.Errorf("getting the default install directory: %v", err)
+		}
+	}
+	log.Printf("[INFO] Installing the %s provider v%s to %s", providerName, ver, pluginDir)
+	return Install(ctx, ver, pluginDir)
+}
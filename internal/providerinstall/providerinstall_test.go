@@ -0,0 +1,21 @@
+package providerinstall
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+
+	pkgDir := packageDir(dir2, "3.65.0")
+	require.NoError(t, os.MkdirAll(pkgDir, 0750))
+	path := filepath.Join(pkgDir, binaryName("3.65.0"))
+	require.NoError(t, os.WriteFile(path, nil, 0700))
+
+	require.Equal(t, path, Find("3.65.0", []string{dir1, dir2}))
+	require.Equal(t, "", Find("3.66.0", []string{dir1, dir2}))
+}
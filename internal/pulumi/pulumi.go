@@ -0,0 +1,48 @@
+package pulumi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// OutputDirName is the name of the directory (under the Terraform working directory) that holds the converted Pulumi program.
+const OutputDirName = "pulumi"
+
+// SupportedLanguages are the languages of the Pulumi program that "pulumi convert" can generate.
+var SupportedLanguages = []string{"yaml", "typescript", "python", "go", "csharp", "java"}
+
+// Convert converts the Terraform configuration in the specified directory to a Pulumi program in the specified language (e.g. "yaml", "typescript"),
+// by running "pulumi convert" (i.e. the pulumi-converter-terraform plugin). The Pulumi program is generated to the OutputDirName directory under dir.
+// This requires the "pulumi" binary available in the PATH.
+func Convert(ctx context.Context, dir, language string) (string, error) {
+	path, err := exec.LookPath("pulumi")
+	if err != nil {
+		return "", fmt.Errorf("finding the pulumi executable: %v", err)
+	}
+
+	outDir := filepath.Join(dir, OutputDirName)
+
+	var stderr bytes.Buffer
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, path, "convert",
+		"--from", "terraform",
+		"--language", language,
+		"--out", outDir,
+		"--generate-only",
+		"--non-interactive",
+	)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		err = fmt.Errorf("running pulumi convert: %v", err)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", err
+	}
+	return outDir, nil
+}
@@ -0,0 +1,261 @@
+// Package regenerate regenerates the config of the specified resources of an export output from their state, in place, while the rest of the config
+// (e.g. the hand edits of the other resources) is kept as is. This is useful after e.g. upgrading the provider version or toggling the full properties.
+package regenerate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/magodo/tfadd/tfadd"
+)
+
+type Config struct {
+	// Dir is the export output, which is expected to be initialized
+	Dir string
+	// Addrs are the TF resource addresses, or the glob patterns of them (e.g. "azurerm_storage_account.*"), to regenerate
+	Addrs []string
+	// Full specifies to include all the non-computed properties in the config
+	Full bool
+}
+
+// Run regenerates the config of the resources in the state that match any of the addresses, and replaces their resource blocks in the .tf files of
+// the directory, which are then formatted. The meta arguments (i.e. "provider", "lifecycle" and "depends_on") of the existing blocks are kept.
+// Only the resources of the root module, without "count" or "for_each", are supported. The regenerated addresses are returned, sorted.
+//
+// Note that the references in the regenerated config (e.g. to the resource group) are not restored, the attributes are regenerated as literal values.
+func Run(ctx context.Context, cfg Config) ([]string, error) {
+	for _, pattern := range cfg.Addrs {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid address pattern %q: %v", pattern, err)
+		}
+	}
+
+	tf, err := newTerraform(ctx, cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	st, err := tf.Show(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("showing the state: %v", err)
+	}
+	addrs, err := matchAddresses(st, cfg.Addrs)
+	if err != nil {
+		return nil, err
+	}
+	_, providerVersions, err := tf.Version(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("getting the provider versions: %v", err)
+	}
+	if err := regenerateBlocks(ctx, tf, cfg.Dir, addrs, cfg.Full, azurermMajorVersion(providerVersions)); err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+func newTerraform(ctx context.Context, dir string) (*tfexec.Terraform, error) {
+	execPath, err := meta.FindTerraform(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("finding the terraform executable: %v", err)
+	}
+	tf, err := tfexec.NewTerraform(dir, execPath)
+	if err != nil {
+		return nil, fmt.Errorf("new terraform: %v", err)
+	}
+	return tf, nil
+}
+
+// azurermMajorVersion returns the major version of the azurerm provider of the provider versions (by the source addresses) reported by "terraform version", if any.
+func azurermMajorVersion(providerVersions map[string]*version.Version) string {
+	for source, v := range providerVersions {
+		if strings.HasSuffix(source, "/"+meta.ProviderAzureRM) && v != nil {
+			return strconv.Itoa(v.Segments()[0])
+		}
+	}
+	return ""
+}
+
+// regenerateBlocks regenerates the config of the resources from the state of the terraform workspace, against its provider schemas, and replaces their
+// resource blocks in the .tf files of the directory. For azurerm v4, the attributes introduced in v4 are copied from the full config.
+func regenerateBlocks(ctx context.Context, tf *tfexec.Terraform, dir string, addrs []string, full bool, majorVersion string) error {
+	files, err := parseTFFiles(dir)
+	if err != nil {
+		return err
+	}
+	blocks := map[string]*hclwrite.Block{}
+	changed := map[string]bool{}
+	for _, addr := range addrs {
+		blk, path, err := findResourceBlock(files, addr)
+		if err != nil {
+			return err
+		}
+		blocks[addr] = blk
+		changed[path] = true
+	}
+
+	bs, err := tfadd.StateForTargets(ctx, tf, addrs, tfadd.Full(full))
+	if err != nil {
+		return fmt.Errorf("converting terraform state to config: %w", err)
+	}
+	var fullBs [][]byte
+	if majorVersion == meta.ProviderMajorVersion4 && !full {
+		if fullBs, err = tfadd.StateForTargets(ctx, tf, addrs, tfadd.Full(true)); err != nil {
+			return fmt.Errorf("converting terraform state to the full config: %w", err)
+		}
+	}
+	for i, addr := range addrs {
+		f, err := parseGeneratedBlock(bs[i], addr)
+		if err != nil {
+			return err
+		}
+		if fullBs != nil {
+			ff, err := parseGeneratedBlock(fullBs[i], addr)
+			if err != nil {
+				return err
+			}
+			meta.ProviderV4Addon(f, ff, f.Body().Blocks()[0].Labels()[0])
+		}
+		replaceBlockBody(blocks[addr], f.Body().Blocks()[0])
+	}
+
+	for _, f := range files {
+		if !changed[f.path] {
+			continue
+		}
+		// #nosec G306
+		if err := os.WriteFile(f.path, hclwrite.Format(f.file.Bytes()), 0644); err != nil {
+			return fmt.Errorf("writing file %s: %v", f.path, err)
+		}
+	}
+	return nil
+}
+
+// parseGeneratedBlock parses the config generated by "terraform add" of the address, which has exactly one block.
+func parseGeneratedBlock(b []byte, addr string) (*hclwrite.File, error) {
+	f, diags := hclwrite.ParseConfig([]byte(meta.CleanupTerraformAdd(string(b))), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing the HCL generated by \"terraform add\" of %s: %s", addr, diags.Error())
+	}
+	if len(f.Body().Blocks()) != 1 {
+		return nil, fmt.Errorf("expect one block generated by \"terraform add\" of %s, got=%d", addr, len(f.Body().Blocks()))
+	}
+	return f, nil
+}
+
+// matchAddresses returns the addresses of the managed resources of the root module in the state that match any of the patterns, sorted.
+// It is an error if a pattern matches nothing.
+func matchAddresses(st *tfjson.State, patterns []string) ([]string, error) {
+	var resources []*tfjson.StateResource
+	if st != nil && st.Values != nil && st.Values.RootModule != nil {
+		resources = st.Values.RootModule.Resources
+	}
+	set := map[string]bool{}
+	for _, pattern := range patterns {
+		var matched bool
+		for _, res := range resources {
+			if res.Mode != tfjson.ManagedResourceMode {
+				continue
+			}
+			if ok, _ := path.Match(pattern, res.Address); !ok {
+				continue
+			}
+			if res.Index != nil {
+				return nil, fmt.Errorf("%s is not supported, as it is created by \"count\" or \"for_each\"", res.Address)
+			}
+			if res.Type == meta.AzAPIResourceType {
+				return nil, fmt.Errorf("%s is not supported, as the %s is generated from its ARM JSON", res.Address, meta.AzAPIResourceType)
+			}
+			matched = true
+			set[res.Address] = true
+		}
+		if !matched {
+			return nil, fmt.Errorf("no resource in the state matches %q", pattern)
+		}
+	}
+	var addrs []string
+	for addr := range set {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+type tfFile struct {
+	path string
+	file *hclwrite.File
+}
+
+// parseTFFiles parses the top level .tf files of the directory.
+func parseTFFiles(dir string) ([]tfFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("listing the .tf files in %s: %v", dir, err)
+	}
+	var files []tfFile
+	for _, path := range paths {
+		// #nosec G304
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading file %s: %v", path, err)
+		}
+		f, diags := hclwrite.ParseConfig(b, path, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing file %s: %v", path, diags.Error())
+		}
+		files = append(files, tfFile{path: path, file: f})
+	}
+	return files, nil
+}
+
+// findResourceBlock finds the resource block of the address (i.e. <type>.<name>) in the files, and returns it together with the path of its file.
+func findResourceBlock(files []tfFile, addr string) (*hclwrite.Block, string, error) {
+	for _, f := range files {
+		for _, blk := range f.file.Body().Blocks() {
+			if blk.Type() != "resource" || len(blk.Labels()) != 2 {
+				continue
+			}
+			if blk.Labels()[0]+"."+blk.Labels()[1] == addr {
+				return blk, f.path, nil
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("the resource block of %s is not found", addr)
+}
+
+// replaceBlockBody replaces the body of the resource block by the one of the generated block, where the meta arguments of the resource block are kept.
+func replaceBlockBody(blk, generated *hclwrite.Block) {
+	body := hclwrite.NewEmptyFile().Body()
+	if attr := blk.Body().GetAttribute("provider"); attr != nil {
+		body.SetAttributeRaw("provider", attr.Expr().BuildTokens(nil))
+	}
+	// The body tokens start with the newline that follows the open brace.
+	genTokens := generated.Body().BuildTokens(nil)
+	if len(genTokens) != 0 && genTokens[0].Type == hclsyntax.TokenNewline {
+		genTokens = genTokens[1:]
+	}
+	body.AppendUnstructuredTokens(genTokens)
+	for _, nested := range blk.Body().Blocks() {
+		if nested.Type() == "lifecycle" {
+			body.AppendNewline()
+			body.AppendBlock(nested)
+		}
+	}
+	if attr := blk.Body().GetAttribute("depends_on"); attr != nil {
+		body.SetAttributeRaw("depends_on", attr.Expr().BuildTokens(nil))
+	}
+	tokens := append(hclwrite.Tokens{{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")}}, body.BuildTokens(nil)...)
+	blk.Body().Clear()
+	blk.Body().AppendUnstructuredTokens(tokens)
+}
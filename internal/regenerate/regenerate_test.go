@@ -0,0 +1,90 @@
+package regenerate
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchAddresses(t *testing.T) {
+	st := &tfjson.State{
+		Values: &tfjson.StateValues{
+			RootModule: &tfjson.StateModule{
+				Resources: []*tfjson.StateResource{
+					{Address: "azurerm_resource_group.rg", Mode: tfjson.ManagedResourceMode, Type: "azurerm_resource_group"},
+					{Address: "azurerm_storage_account.sa1", Mode: tfjson.ManagedResourceMode, Type: "azurerm_storage_account"},
+					{Address: "azurerm_storage_account.sa2", Mode: tfjson.ManagedResourceMode, Type: "azurerm_storage_account"},
+					{Address: "data.azurerm_client_config.current", Mode: tfjson.DataResourceMode, Type: "azurerm_client_config"},
+					{Address: "azapi_resource.res", Mode: tfjson.ManagedResourceMode, Type: "azapi_resource"},
+				},
+			},
+		},
+	}
+
+	addrs, err := matchAddresses(st, []string{"azurerm_storage_account.*", "azurerm_storage_account.sa1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"azurerm_storage_account.sa1", "azurerm_storage_account.sa2"}, addrs)
+
+	_, err = matchAddresses(st, []string{"azurerm_virtual_network.*"})
+	require.EqualError(t, err, `no resource in the state matches "azurerm_virtual_network.*"`)
+
+	_, err = matchAddresses(st, []string{"azapi_resource.res"})
+	require.ErrorContains(t, err, "is generated from its ARM JSON")
+}
+
+func TestReplaceBlockBody(t *testing.T) {
+	f, diags := hclwrite.ParseConfig([]byte(`# hand edited
+resource "azurerm_resource_group" "rg" {
+  provider = azurerm.other
+  name     = "rg"
+  location = var.location
+  lifecycle {
+    ignore_changes = [tags]
+  }
+  depends_on = [azurerm_resource_group.other]
+}
+
+resource "azurerm_resource_group" "other" {
+  name     = "other" # hand edited
+  location = "westus"
+}
+`), "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+	generated, diags := hclwrite.ParseConfig([]byte(`resource "azurerm_resource_group" "rg" {
+  location = "westeurope"
+  name     = "rg"
+  tags = {
+    env = "prod"
+  }
+}
+`), "", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+
+	blk, path, err := findResourceBlock([]tfFile{{path: "main.tf", file: f}}, "azurerm_resource_group.rg")
+	require.NoError(t, err)
+	require.Equal(t, "main.tf", path)
+	replaceBlockBody(blk, generated.Body().Blocks()[0])
+	require.Equal(t, `# hand edited
+resource "azurerm_resource_group" "rg" {
+  provider = azurerm.other
+  location = "westeurope"
+  name     = "rg"
+  tags = {
+    env = "prod"
+  }
+
+  lifecycle {
+    ignore_changes = [tags]
+  }
+  depends_on = [azurerm_resource_group.other]
+}
+
+resource "azurerm_resource_group" "other" {
+  name     = "other" # hand edited
+  location = "westus"
+}
+`, string(hclwrite.Format(f.Bytes())))
+}
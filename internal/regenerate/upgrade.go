@@ -0,0 +1,456 @@
+package regenerate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/Azure/aztfexport/internal/utils"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// UpgradeReportFileName is the file under the export output that records the result of the last upgrade, especially the manual follow-ups.
+const UpgradeReportFileName = "aztfexportUpgradeReport.json"
+
+type UpgradeConfig struct {
+	// Dir is the export output, which is expected to be initialized
+	Dir string
+	// ProviderVersion is the azurerm provider version to upgrade to, either a version (e.g. "4.30.0"), or a major version (e.g. "4.x") for its latest release
+	ProviderVersion string
+	// Full specifies to include all the non-computed properties in the config
+	Full bool
+}
+
+// FollowUp is what is left to be done manually after the upgrade.
+type FollowUp struct {
+	// Address is the TF resource address, if the follow-up is of a resource
+	Address string `json:"address,omitempty"`
+	// Location is the file and line of the config, if the follow-up is of a piece of the config
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+type UpgradeReport struct {
+	FromVersion string `json:"from_version"`
+	ToVersion   string `json:"to_version"`
+	// Regenerated are the addresses of the resources whose config is regenerated, sorted
+	Regenerated []string   `json:"regenerated"`
+	FollowUps   []FollowUp `json:"follow_ups"`
+}
+
+// Upgrade upgrades the azurerm provider of the export output to the provider version, and regenerates the config of the resources against the new provider
+// schema, using the state as the source of truth. The renamed or the split attributes are resolved by the state upgrade of the provider, which is run by
+// refreshing a copy of the state in a temporary directory, while the state of the export output is left as is (which is upgraded by terraform on its next run).
+//
+// What can't be done mechanically is recorded as the follow-ups of the report, which is also written to the UpgradeReportFileName. The upgrade is aborted
+// (with the report) before anything is changed, if any resource type is removed in the provider version.
+func Upgrade(ctx context.Context, cfg UpgradeConfig) (*UpgradeReport, error) {
+	constraint, err := providerVersionConstraint(cfg.ProviderVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	tf, err := newTerraform(ctx, cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	_, providerVersions, err := tf.Version(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("getting the provider versions: %v", err)
+	}
+	report := &UpgradeReport{FromVersion: azurermVersion(providerVersions)}
+	st, err := tf.Show(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("showing the state: %v", err)
+	}
+	resources, followUps := upgradeCandidates(st)
+	report.FollowUps = append(report.FollowUps, followUps...)
+
+	// The state is upgraded in a temporary directory, which has the same providers (of the new version) and state, but no resource config.
+	tmpDir, err := os.MkdirTemp("", "aztfexport-upgrade-")
+	if err != nil {
+		return nil, fmt.Errorf("creating the temporary directory: %v", err)
+	}
+	// #nosec G104
+	defer os.RemoveAll(tmpDir)
+	if err := writeUpgradeWorkspace(ctx, tf, cfg.Dir, tmpDir, constraint); err != nil {
+		return nil, err
+	}
+	tmpTF, err := newTerraform(ctx, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpTF.Init(ctx); err != nil {
+		return nil, fmt.Errorf("running terraform init for the provider version %s: %v", constraint, err)
+	}
+	_, providerVersions, err = tmpTF.Version(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("getting the provider versions: %v", err)
+	}
+	report.ToVersion = azurermVersion(providerVersions)
+	schemas, err := tmpTF.ProvidersSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting the provider schemas: %v", err)
+	}
+	resourceSchemas := azurermResourceSchemas(schemas)
+
+	var removed []FollowUp
+	for _, res := range resources {
+		if _, ok := resourceSchemas[res.Type]; ok {
+			continue
+		}
+		msg := fmt.Sprintf("The resource type %s is removed in azurerm %s, migrate it to its replacement", res.Type, report.ToVersion)
+		if replacements, ok := meta.ProviderV4Replacements(res.Type); ok {
+			msg += fmt.Sprintf(" (i.e. %s)", strings.Join(replacements, " or "))
+		}
+		removed = append(removed, FollowUp{Address: res.Address, Message: msg + " before the upgrade"})
+	}
+	if len(removed) != 0 {
+		report.FollowUps = append(report.FollowUps, removed...)
+		if err := report.write(cfg.Dir); err != nil {
+			return nil, err
+		}
+		return report, fmt.Errorf("%d resource(s) are of the resource types removed in azurerm %s, see the follow-ups", len(removed), report.ToVersion)
+	}
+
+	// Refreshing the state runs the state upgrade of the provider.
+	if err := tmpTF.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("upgrading the state: %v", err)
+	}
+	upgraded, err := tmpTF.Show(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("showing the upgraded state: %v", err)
+	}
+	exists := map[string]bool{}
+	if upgraded != nil && upgraded.Values != nil && upgraded.Values.RootModule != nil {
+		for _, res := range upgraded.Values.RootModule.Resources {
+			exists[res.Address] = true
+		}
+	}
+	for _, res := range resources {
+		if !exists[res.Address] {
+			report.FollowUps = append(report.FollowUps, FollowUp{Address: res.Address, Message: "The resource no longer exists in Azure, remove it from the config and the state"})
+			continue
+		}
+		report.Regenerated = append(report.Regenerated, res.Address)
+	}
+	sort.Strings(report.Regenerated)
+
+	if len(report.Regenerated) != 0 {
+		if err := regenerateBlocks(ctx, tmpTF, cfg.Dir, report.Regenerated, cfg.Full, azurermMajorVersion(providerVersions)); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := parseTFFiles(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	f, err := setRequiredProviderVersion(files, constraint)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.write(); err != nil {
+		return nil, err
+	}
+	if err := tf.Init(ctx, tfexec.Upgrade(true)); err != nil {
+		return nil, fmt.Errorf("running terraform init -upgrade: %v", err)
+	}
+
+	refs, err := danglingReferences(cfg.Dir, resourceSchemas)
+	if err != nil {
+		return nil, err
+	}
+	report.FollowUps = append(report.FollowUps, refs...)
+
+	if err := report.write(cfg.Dir); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (report UpgradeReport) write(dir string) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the upgrade report: %v", err)
+	}
+	path := filepath.Join(dir, UpgradeReportFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing the upgrade report to %s: %v", path, err)
+	}
+	return nil
+}
+
+func (f tfFile) write() error {
+	// #nosec G306
+	if err := os.WriteFile(f.path, hclwrite.Format(f.file.Bytes()), 0644); err != nil {
+		return fmt.Errorf("writing file %s: %v", f.path, err)
+	}
+	return nil
+}
+
+// providerVersionConstraint returns the version constraint of the provider version, which is either a version, or a major version (e.g. "4.x").
+func providerVersionConstraint(v string) (string, error) {
+	if major := strings.TrimSuffix(v, ".x"); major != v || !strings.Contains(v, ".") {
+		if n, err := strconv.Atoi(major); err == nil && n > 0 {
+			return fmt.Sprintf("~> %d.0", n), nil
+		}
+	} else if _, err := version.NewVersion(v); err == nil {
+		return v, nil
+	}
+	return "", fmt.Errorf(`invalid provider version %q, which is either a version (e.g. "4.30.0"), or a major version (e.g. "4.x")`, v)
+}
+
+// azurermVersion returns the version of the azurerm provider of the provider versions (by the source addresses) reported by "terraform version", if any.
+func azurermVersion(providerVersions map[string]*version.Version) string {
+	for source, v := range providerVersions {
+		if strings.HasSuffix(source, "/"+meta.ProviderAzureRM) && v != nil {
+			return v.String()
+		}
+	}
+	return ""
+}
+
+// azurermResourceSchemas returns the resource schemas of the azurerm provider.
+func azurermResourceSchemas(schemas *tfjson.ProviderSchemas) map[string]*tfjson.Schema {
+	if schemas == nil {
+		return nil
+	}
+	for source, sch := range schemas.Schemas {
+		if strings.HasSuffix(source, "/"+meta.ProviderAzureRM) {
+			return sch.ResourceSchemas
+		}
+	}
+	return nil
+}
+
+// upgradeCandidates returns the azurerm resources in the state whose config can be regenerated, i.e. of the root module without "count" or "for_each",
+// while the other azurerm resources are returned as the follow-ups.
+func upgradeCandidates(st *tfjson.State) ([]*tfjson.StateResource, []FollowUp) {
+	if st == nil || st.Values == nil || st.Values.RootModule == nil {
+		return nil, nil
+	}
+	isAzureRM := func(res *tfjson.StateResource) bool {
+		return res.Mode == tfjson.ManagedResourceMode && strings.HasPrefix(res.Type, meta.ProviderAzureRM+"_")
+	}
+	var candidates []*tfjson.StateResource
+	var followUps []FollowUp
+	for _, res := range st.Values.RootModule.Resources {
+		if !isAzureRM(res) {
+			continue
+		}
+		if res.Index != nil {
+			followUps = append(followUps, FollowUp{Address: res.Address, Message: `The config is not regenerated, as it is created by "count" or "for_each"`})
+			continue
+		}
+		candidates = append(candidates, res)
+	}
+	var walk func(modules []*tfjson.StateModule)
+	walk = func(modules []*tfjson.StateModule) {
+		for _, m := range modules {
+			for _, res := range m.Resources {
+				if isAzureRM(res) {
+					followUps = append(followUps, FollowUp{Address: res.Address, Message: "The config is not regenerated, as it is in a child module"})
+				}
+			}
+			walk(m.ChildModules)
+		}
+	}
+	walk(st.Values.RootModule.ChildModules)
+	return candidates, followUps
+}
+
+// writeUpgradeWorkspace writes the workspace that upgrades the state, which has the terraform block (with only the required providers, of which the azurerm
+// provider is of the version constraint), the provider blocks, the variables (and their values) of the export output, together with a copy of its state.
+func writeUpgradeWorkspace(ctx context.Context, tf *tfexec.Terraform, dir, tmpDir, constraint string) error {
+	files, err := parseTFFiles(dir)
+	if err != nil {
+		return err
+	}
+	if _, err := setRequiredProviderVersion(files, constraint); err != nil {
+		return err
+	}
+	out := hclwrite.NewEmptyFile()
+	for _, f := range files {
+		for _, blk := range f.file.Body().Blocks() {
+			switch blk.Type() {
+			case "terraform":
+				for _, nblk := range blk.Body().Blocks() {
+					if nblk.Type() == "required_providers" {
+						out.Body().AppendNewBlock("terraform", nil).Body().AppendUnstructuredTokens(append(hclwrite.Tokens{{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")}}, nblk.BuildTokens(nil)...))
+					}
+				}
+			case "provider", "variable":
+				out.Body().AppendUnstructuredTokens(blk.BuildTokens(nil))
+			default:
+				continue
+			}
+			out.Body().AppendNewline()
+		}
+	}
+	// #nosec G306
+	if err := os.WriteFile(filepath.Join(tmpDir, "main.tf"), hclwrite.Format(out.Bytes()), 0644); err != nil {
+		return fmt.Errorf("writing the config of the upgrade workspace: %v", err)
+	}
+
+	for _, pattern := range []string{"terraform.tfvars", "terraform.tfvars.json", "*.auto.tfvars", "*.auto.tfvars.json"} {
+		paths, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return fmt.Errorf("listing the %s files: %v", pattern, err)
+		}
+		for _, path := range paths {
+			if err := utils.CopyFile(path, filepath.Join(tmpDir, filepath.Base(path))); err != nil {
+				return err
+			}
+		}
+	}
+
+	state, err := tf.StatePull(ctx)
+	if err != nil {
+		return fmt.Errorf("pulling the state: %v", err)
+	}
+	// #nosec G306
+	if err := os.WriteFile(filepath.Join(tmpDir, "terraform.tfstate"), []byte(state), 0644); err != nil {
+		return fmt.Errorf("writing the state of the upgrade workspace: %v", err)
+	}
+	return nil
+}
+
+// setRequiredProviderVersion sets the version constraint of the azurerm provider in the required providers of the files, and returns the file of it.
+// The other settings of the required provider (e.g. the source) are kept as is.
+func setRequiredProviderVersion(files []tfFile, constraint string) (*tfFile, error) {
+	for i, f := range files {
+		for _, blk := range f.file.Body().Blocks() {
+			if blk.Type() != "terraform" {
+				continue
+			}
+			for _, nblk := range blk.Body().Blocks() {
+				if nblk.Type() != "required_providers" {
+					continue
+				}
+				attr := nblk.Body().GetAttribute(meta.ProviderAzureRM)
+				if attr == nil {
+					continue
+				}
+				tokens, err := setObjectStringAttribute(attr.Expr().BuildTokens(nil), "version", constraint)
+				if err != nil {
+					return nil, fmt.Errorf("setting the version of the %s required provider: %v", meta.ProviderAzureRM, err)
+				}
+				nblk.Body().SetAttributeRaw(meta.ProviderAzureRM, tokens)
+				return &files[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no %s provider is found in the required providers", meta.ProviderAzureRM)
+}
+
+// setObjectStringAttribute sets the attribute of the object constructor expression to the string, which is added if not exists.
+func setObjectStringAttribute(tokens hclwrite.Tokens, name, value string) (hclwrite.Tokens, error) {
+	valueTokens := hclwrite.TokensForValue(cty.StringVal(value))
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].Type != hclsyntax.TokenIdent || string(tokens[i].Bytes) != name || tokens[i+1].Type != hclsyntax.TokenEqual {
+			continue
+		}
+		// The value ends at the newline or the comma that follows.
+		end := i + 2
+		for end < len(tokens) && tokens[end].Type != hclsyntax.TokenNewline && tokens[end].Type != hclsyntax.TokenComma && tokens[end].Type != hclsyntax.TokenCBrace {
+			end++
+		}
+		out := append(hclwrite.Tokens{}, tokens[:i+2]...)
+		out = append(out, valueTokens...)
+		return append(out, tokens[end:]...), nil
+	}
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if tokens[i].Type != hclsyntax.TokenCBrace {
+			continue
+		}
+		out := append(hclwrite.Tokens{}, tokens[:i]...)
+		out = append(out, hclwrite.TokensForIdentifier(name)...)
+		out = append(out, &hclwrite.Token{Type: hclsyntax.TokenEqual, Bytes: []byte("=")})
+		out = append(out, valueTokens...)
+		out = append(out, &hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")})
+		return append(out, tokens[i:]...), nil
+	}
+	return nil, fmt.Errorf("not an object")
+}
+
+// danglingReferences returns the references in the config of the directory to the attributes of the resources that are absent from their schemas,
+// e.g. the ones renamed in the provider version.
+func danglingReferences(dir string, resourceSchemas map[string]*tfjson.Schema) ([]FollowUp, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("listing the .tf files in %s: %v", dir, err)
+	}
+	var followUps []FollowUp
+	parser := hclparse.NewParser()
+	for _, path := range paths {
+		f, diags := parser.ParseHCLFile(path)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing file %s: %v", path, diags.Error())
+		}
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+		// #nosec G104
+		hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+			expr, ok := node.(*hclsyntax.ScopeTraversalExpr)
+			if !ok {
+				return nil
+			}
+			if followUp, ok := danglingReference(expr.Traversal, resourceSchemas); ok {
+				followUps = append(followUps, followUp)
+			}
+			return nil
+		})
+	}
+	return followUps, nil
+}
+
+// danglingReference tells whether the traversal (e.g. "azurerm_storage_account.foo.bar") refers to an attribute that is absent from the resource schema.
+func danglingReference(traversal hcl.Traversal, resourceSchemas map[string]*tfjson.Schema) (FollowUp, bool) {
+	var steps []string
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			steps = append(steps, step.Name)
+		case hcl.TraverseAttr:
+			steps = append(steps, step.Name)
+		}
+		if len(steps) == 3 {
+			break
+		}
+	}
+	if len(steps) < 3 {
+		return FollowUp{}, false
+	}
+	sch, ok := resourceSchemas[steps[0]]
+	if !ok || sch.Block == nil {
+		return FollowUp{}, false
+	}
+	if _, ok := sch.Block.Attributes[steps[2]]; ok {
+		return FollowUp{}, false
+	}
+	if _, ok := sch.Block.NestedBlocks[steps[2]]; ok {
+		return FollowUp{}, false
+	}
+	rng := traversal.SourceRange()
+	return FollowUp{
+		Location: fmt.Sprintf("%s:%d", filepath.Base(rng.Filename), rng.Start.Line),
+		Message:  fmt.Sprintf("The reference to %s.%s.%s is to an attribute that doesn't exist in the provider version, update it to the renamed (or the replacing) attribute", steps[0], steps[1], steps[2]),
+	}, true
+}
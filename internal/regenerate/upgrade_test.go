@@ -0,0 +1,167 @@
+package regenerate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderVersionConstraint(t *testing.T) {
+	cases := []struct {
+		input  string
+		expect string
+		err    bool
+	}{
+		{input: "4.x", expect: "~> 4.0"},
+		{input: "4", expect: "~> 4.0"},
+		{input: "4.30.0", expect: "4.30.0"},
+		{input: "x", err: true},
+		{input: "0.x", err: true},
+		{input: "4.30.x", err: true},
+		{input: ">= 4.0", err: true},
+	}
+	for _, c := range cases {
+		actual, err := providerVersionConstraint(c.input)
+		if c.err {
+			require.Error(t, err, c.input)
+			continue
+		}
+		require.NoError(t, err, c.input)
+		require.Equal(t, c.expect, actual, c.input)
+	}
+}
+
+func TestSetRequiredProviderVersion(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name: "version set",
+			input: `terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "3.99.0"
+    }
+  }
+}
+`,
+			expect: `terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 4.0"
+    }
+  }
+}
+`,
+		},
+		{
+			name: "version absent",
+			input: `terraform {
+  required_providers {
+    azurerm = {
+      source = "hashicorp/azurerm"
+    }
+  }
+}
+`,
+			expect: `terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 4.0"
+    }
+  }
+}
+`,
+		},
+	}
+	for _, c := range cases {
+		f, diags := hclwrite.ParseConfig([]byte(c.input), "terraform.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), c.name)
+		files := []tfFile{{path: "main.tf"}, {path: "terraform.tf", file: f}}
+		files[0].file = hclwrite.NewEmptyFile()
+		actual, err := setRequiredProviderVersion(files, "~> 4.0")
+		require.NoError(t, err, c.name)
+		require.Equal(t, "terraform.tf", actual.path, c.name)
+		require.Equal(t, c.expect, string(hclwrite.Format(actual.file.Bytes())), c.name)
+	}
+
+	_, err := setRequiredProviderVersion([]tfFile{{path: "main.tf", file: hclwrite.NewEmptyFile()}}, "~> 4.0")
+	require.EqualError(t, err, "no azurerm provider is found in the required providers")
+}
+
+func TestUpgradeCandidates(t *testing.T) {
+	st := &tfjson.State{
+		Values: &tfjson.StateValues{
+			RootModule: &tfjson.StateModule{
+				Resources: []*tfjson.StateResource{
+					{Address: "azurerm_resource_group.rg", Mode: tfjson.ManagedResourceMode, Type: "azurerm_resource_group"},
+					{Address: "azurerm_storage_account.sa[0]", Mode: tfjson.ManagedResourceMode, Type: "azurerm_storage_account", Index: 0},
+					{Address: "data.azurerm_client_config.current", Mode: tfjson.DataResourceMode, Type: "azurerm_client_config"},
+					{Address: "azapi_resource.res", Mode: tfjson.ManagedResourceMode, Type: "azapi_resource"},
+				},
+				ChildModules: []*tfjson.StateModule{
+					{
+						Address: "module.net",
+						Resources: []*tfjson.StateResource{
+							{Address: "module.net.azurerm_virtual_network.vnet", Mode: tfjson.ManagedResourceMode, Type: "azurerm_virtual_network"},
+						},
+					},
+				},
+			},
+		},
+	}
+	candidates, followUps := upgradeCandidates(st)
+	require.Len(t, candidates, 1)
+	require.Equal(t, "azurerm_resource_group.rg", candidates[0].Address)
+	require.Len(t, followUps, 2)
+	require.Equal(t, "azurerm_storage_account.sa[0]", followUps[0].Address)
+	require.Equal(t, "module.net.azurerm_virtual_network.vnet", followUps[1].Address)
+}
+
+func TestDanglingReferences(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "outputs.tf"), []byte(`output "https_only" {
+  value = azurerm_storage_account.sa.enable_https_traffic_only
+}
+
+output "name" {
+  value = azurerm_storage_account.sa.name
+}
+
+output "rule" {
+  value = azurerm_storage_account.sa.network_rules[0].default_action
+}
+
+output "unknown" {
+  value = azurerm_foo.foo.bar
+}
+`), 0644))
+	schemas := map[string]*tfjson.Schema{
+		"azurerm_storage_account": {
+			Block: &tfjson.SchemaBlock{
+				Attributes: map[string]*tfjson.SchemaAttribute{
+					"name":                       {},
+					"https_traffic_only_enabled": {},
+				},
+				NestedBlocks: map[string]*tfjson.SchemaBlockType{
+					"network_rules": {},
+				},
+			},
+		},
+	}
+	followUps, err := danglingReferences(dir, schemas)
+	require.NoError(t, err)
+	require.Len(t, followUps, 1)
+	require.Equal(t, "outputs.tf:2", followUps[0].Location)
+	require.Contains(t, followUps[0].Message, "azurerm_storage_account.sa.enable_https_traffic_only")
+}
@@ -0,0 +1,301 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/Azure/aztfexport/pkg/telemetry"
+)
+
+// ReportFileName is the file under the output directory that reports the last non-interactive run, which is meant to be kept as an auditable artifact (e.g. in CI).
+const ReportFileName = "aztfexport-report.json"
+
+// ReportMarkdownFileName is the file under the output directory that renders the report in Markdown.
+const ReportMarkdownFileName = "aztfexport-report.md"
+
+const (
+	OutcomeImported    = "imported"
+	OutcomeSkipped     = "skipped"
+	OutcomeUnsupported = "unsupported"
+	OutcomeErrored     = "errored"
+	// OutcomePending means the resource is not attempted, e.g. the run is aborted beforehand, or only the mapping file is generated.
+	OutcomePending = "pending"
+)
+
+type ReportCounts struct {
+	Discovered  int `json:"discovered"`
+	Imported    int `json:"imported"`
+	Skipped     int `json:"skipped"`
+	Unsupported int `json:"unsupported"`
+	Errored     int `json:"errored"`
+}
+
+type ReportPhase struct {
+	Name            string  `json:"name"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	// AllocatedBytes is the heap allocated during the phase, which is accumulated as the duration.
+	AllocatedBytes uint64 `json:"allocated_bytes"`
+	// HeapBytes is the heap in use at the end of the phase, the maximum of the runs if it runs multiple times.
+	HeapBytes uint64 `json:"heap_bytes"`
+}
+
+// ReportMemory is the memory usage of the whole run.
+type ReportMemory struct {
+	// AllocatedBytes is the heap allocated since the run starts.
+	AllocatedBytes uint64 `json:"allocated_bytes"`
+	// SysBytes is the memory obtained from the OS by the end of the run.
+	SysBytes uint64 `json:"sys_bytes"`
+	// NumGC is the number of the GC cycles completed since the run starts.
+	NumGC uint32 `json:"num_gc"`
+}
+
+type ReportResource struct {
+	AzureResourceId string `json:"azure_resource_id"`
+	TFResourceId    string `json:"tf_resource_id,omitempty"`
+	TFAddress       string `json:"tf_address,omitempty"`
+	Outcome         string `json:"outcome"`
+	// Reason is why the resource is skipped or unsupported
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Report struct {
+	ToolVersion     string `json:"tool_version"`
+	ProviderName    string `json:"provider_name"`
+	ProviderVersion string `json:"provider_version"`
+	// Workspace is the Terraform workspace that the resources are imported into, if specified
+	Workspace       string  `json:"workspace,omitempty"`
+	StartTime       string  `json:"start_time"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Error is the error that aborts the run, if any
+	Error  string       `json:"error,omitempty"`
+	Counts ReportCounts `json:"counts"`
+	// Warnings are the problems that don't fail the resources, e.g. the resources that collide with the soft-deleted instances
+	Warnings  []string         `json:"warnings,omitempty"`
+	Memory    ReportMemory     `json:"memory"`
+	Phases    []ReportPhase    `json:"phases"`
+	Resources []ReportResource `json:"resources"`
+}
+
+// phaseTimer times the phases of a run along with their memory usage, where the metrics of a phase that runs multiple times (e.g. in chunks) are accumulated.
+type phaseTimer struct {
+	start    time.Time
+	startMem runtime.MemStats
+	phases   []ReportPhase
+}
+
+func newPhaseTimer() *phaseTimer {
+	t := &phaseTimer{start: time.Now()}
+	runtime.ReadMemStats(&t.startMem)
+	return t
+}
+
+// Start starts timing the phase, which ends when the returned function is called.
+func (t *phaseTimer) Start(name string) (end func()) {
+	start := time.Now()
+	var startMem runtime.MemStats
+	runtime.ReadMemStats(&startMem)
+	return func() {
+		d := time.Since(start).Seconds()
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		allocated := mem.TotalAlloc - startMem.TotalAlloc
+		for i := range t.phases {
+			if t.phases[i].Name == name {
+				t.phases[i].DurationSeconds += d
+				t.phases[i].AllocatedBytes += allocated
+				if mem.HeapAlloc > t.phases[i].HeapBytes {
+					t.phases[i].HeapBytes = mem.HeapAlloc
+				}
+				return
+			}
+		}
+		t.phases = append(t.phases, ReportPhase{Name: name, DurationSeconds: d, AllocatedBytes: allocated, HeapBytes: mem.HeapAlloc})
+	}
+}
+
+// memory returns the memory usage since the timer starts.
+func (t *phaseTimer) memory() ReportMemory {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return ReportMemory{
+		AllocatedBytes: mem.TotalAlloc - t.startMem.TotalAlloc,
+		SysBytes:       mem.Sys,
+		NumGC:          mem.NumGC - t.startMem.NumGC,
+	}
+}
+
+func newReport(l meta.ImportList, timer *phaseTimer, runErr error) Report {
+	report := Report{
+		StartTime:       timer.start.UTC().Format(time.RFC3339),
+		DurationSeconds: time.Since(timer.start).Seconds(),
+		Counts:          ReportCounts{Discovered: len(l)},
+		Memory:          timer.memory(),
+		Phases:          timer.phases,
+		Resources:       []ReportResource{},
+	}
+	if runErr != nil {
+		report.Error = runErr.Error()
+	}
+	for _, item := range l {
+		res := ReportResource{
+			AzureResourceId: item.AzureResourceID.String(),
+			TFResourceId:    item.TFResourceId,
+		}
+		switch {
+		case item.Skip():
+			res.Reason = item.SkipReason()
+			if itemUnsupported(item) {
+				res.Outcome = OutcomeUnsupported
+				report.Counts.Unsupported++
+			} else {
+				res.Outcome = OutcomeSkipped
+				report.Counts.Skipped++
+			}
+		case item.ImportError != nil:
+			res.TFAddress = item.TFAddr.String()
+			res.Outcome = OutcomeErrored
+			res.Error = item.ImportError.Error()
+			report.Counts.Errored++
+		case item.Imported:
+			res.TFAddress = item.TFAddr.String()
+			res.Outcome = OutcomeImported
+			report.Counts.Imported++
+		default:
+			res.TFAddress = item.TFAddr.String()
+			res.Outcome = OutcomePending
+		}
+		report.Resources = append(report.Resources, res)
+		if item.SoftDeleted != "" {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s collides with a %s", item.AzureResourceID, item.SoftDeleted))
+		}
+	}
+	return report
+}
+
+// tracePhases records the metrics of the phases of the report as the "phase" events, along with the memory usage of the run as the "memory" event.
+func tracePhases(tc telemetry.Client, report Report) {
+	if tc == nil {
+		return
+	}
+	for _, phase := range report.Phases {
+		tc.Event("phase", map[string]string{
+			"name":             phase.Name,
+			"duration_seconds": strconv.FormatFloat(phase.DurationSeconds, 'f', 3, 64),
+			"allocated_bytes":  strconv.FormatUint(phase.AllocatedBytes, 10),
+			"heap_bytes":       strconv.FormatUint(phase.HeapBytes, 10),
+		})
+	}
+	tc.Event("memory", map[string]string{
+		"allocated_bytes": strconv.FormatUint(report.Memory.AllocatedBytes, 10),
+		"sys_bytes":       strconv.FormatUint(report.Memory.SysBytes, 10),
+		"num_gc":          strconv.FormatUint(uint64(report.Memory.NumGC), 10),
+	})
+}
+
+// itemUnsupported tells whether the item is skipped for lacking a TF resource type, while the other skipped items (e.g. locked) are skipped on purpose.
+func itemUnsupported(item meta.ImportItem) bool {
+	return item.Skip() && item.Lock == "" && item.SoftDeleted == "" && item.TFAddrCache.Type == ""
+}
+
+// Markdown renders the report in Markdown.
+func (report Report) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# aztfexport Report\n\n")
+	fmt.Fprintf(&sb, "- Tool version: %s\n", report.ToolVersion)
+	fmt.Fprintf(&sb, "- Provider: %s %s\n", report.ProviderName, report.ProviderVersion)
+	if report.Workspace != "" {
+		fmt.Fprintf(&sb, "- Workspace: %s\n", report.Workspace)
+	}
+	fmt.Fprintf(&sb, "- Started at: %s\n", report.StartTime)
+	fmt.Fprintf(&sb, "- Duration: %s\n", secondsString(report.DurationSeconds))
+	fmt.Fprintf(&sb, "- Memory: %s allocated, %s obtained from the OS, %d GC cycle(s)\n", bytesString(report.Memory.AllocatedBytes), bytesString(report.Memory.SysBytes), report.Memory.NumGC)
+	if report.Error != "" {
+		fmt.Fprintf(&sb, "- Error: %s\n", markdownEscape(report.Error))
+	}
+
+	sb.WriteString("\n## Counts\n\n")
+	sb.WriteString("| Discovered | Imported | Skipped | Unsupported | Errored |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- |\n")
+	c := report.Counts
+	fmt.Fprintf(&sb, "| %d | %d | %d | %d | %d |\n", c.Discovered, c.Imported, c.Skipped, c.Unsupported, c.Errored)
+
+	if len(report.Warnings) != 0 {
+		sb.WriteString("\n## Warnings\n\n")
+		for _, w := range report.Warnings {
+			fmt.Fprintf(&sb, "- %s\n", w)
+		}
+	}
+
+	sb.WriteString("\n## Phases\n\n")
+	sb.WriteString("| Phase | Duration | Allocated | Heap |\n")
+	sb.WriteString("| --- | --- | --- | --- |\n")
+	for _, phase := range report.Phases {
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", phase.Name, secondsString(phase.DurationSeconds), bytesString(phase.AllocatedBytes), bytesString(phase.HeapBytes))
+	}
+
+	sb.WriteString("\n## Resources\n\n")
+	sb.WriteString("| Azure Resource ID | TF Address | Outcome | Detail |\n")
+	sb.WriteString("| --- | --- | --- | --- |\n")
+	for _, res := range report.Resources {
+		detail := res.Error
+		if detail == "" {
+			detail = res.Reason
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", res.AzureResourceId, res.TFAddress, res.Outcome, markdownEscape(detail))
+	}
+	return sb.String()
+}
+
+func secondsString(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+// bytesString formats the bytes in the binary units, e.g. "1.5 MiB".
+func bytesString(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// markdownEscape escapes the text to be put in a Markdown table cell.
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "<br>")
+}
+
+// writeReport writes the report to the output directory, and its Markdown rendering if asked.
+func writeReport(dir string, report Report, markdown bool) error {
+	b, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return fmt.Errorf("marshalling the report: %v", err)
+	}
+	path := filepath.Join(dir, ReportFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing the report to %s: %v", path, err)
+	}
+	if !markdown {
+		return nil
+	}
+	path = filepath.Join(dir, ReportMarkdownFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, []byte(report.Markdown()), 0644); err != nil {
+		return fmt.Errorf("writing the report to %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	item := func(id, tfType, name string) meta.ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return meta.ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}}
+	}
+	imported := item("/subscriptions/123/resourceGroups/rg1", "azurerm_resource_group", "res-0")
+	imported.Imported = true
+	failed := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1", "azurerm_virtual_network", "res-1")
+	failed.ImportError = fmt.Errorf("boom|bang\nbust")
+	unsupported := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/bars/bar1", "", "res-2")
+	locked := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/publicIPAddresses/pip1", "", "res-3")
+	locked.Lock = "CanNotDelete"
+	pending := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/networkSecurityGroups/nsg1", "azurerm_network_security_group", "res-4")
+	softDeleted := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1", "", "res-5")
+	softDeleted.SoftDeleted = "soft-deleted Key Vault (deleted at 2024-01-01T00:00:00Z)"
+
+	timer := newPhaseTimer()
+	timer.Start("import")()
+	timer.Start("generate_config")()
+	timer.Start("import")()
+	report := newReport(meta.ImportList{imported, failed, unsupported, locked, pending, softDeleted}, timer, nil)
+	report.Workspace = "prod"
+
+	require.Equal(t, ReportCounts{Discovered: 6, Imported: 1, Skipped: 2, Unsupported: 1, Errored: 1}, report.Counts)
+	require.Equal(t, []string{"/subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1 collides with a soft-deleted Key Vault (deleted at 2024-01-01T00:00:00Z)"}, report.Warnings)
+	require.Len(t, report.Phases, 2)
+	require.Equal(t, "import", report.Phases[0].Name)
+	require.Equal(t, "generate_config", report.Phases[1].Name)
+	require.NotZero(t, report.Phases[0].HeapBytes)
+	require.NotZero(t, report.Memory.SysBytes)
+	var outcomes []string
+	for _, res := range report.Resources {
+		outcomes = append(outcomes, res.Outcome)
+	}
+	require.Equal(t, []string{OutcomeImported, OutcomeErrored, OutcomeUnsupported, OutcomeSkipped, OutcomePending, OutcomeSkipped}, outcomes)
+	require.Equal(t, "azurerm_virtual_network.res-1", report.Resources[1].TFAddress)
+	require.Equal(t, "boom|bang\nbust", report.Resources[1].Error)
+	require.Equal(t, "no TF resource type", report.Resources[2].Reason)
+
+	dir := t.TempDir()
+	require.NoError(t, writeReport(dir, report, true))
+	b, err := os.ReadFile(filepath.Join(dir, ReportFileName))
+	require.NoError(t, err)
+	var got Report
+	require.NoError(t, json.Unmarshal(b, &got))
+	require.Equal(t, report, got)
+
+	b, err = os.ReadFile(filepath.Join(dir, ReportMarkdownFileName))
+	require.NoError(t, err)
+	require.Contains(t, string(b), "- Workspace: prod\n")
+	require.Contains(t, string(b), "| 6 | 1 | 2 | 1 | 1 |")
+	require.Contains(t, string(b), "| Phase | Duration | Allocated | Heap |")
+	require.Contains(t, string(b), "## Warnings\n\n- /subscriptions/123/resourceGroups/rg1/providers/Microsoft.KeyVault/vaults/kv1 collides with")
+	require.Contains(t, string(b), "| /subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1 | azurerm_virtual_network.res-1 | errored | boom\\|bang<br>bust |")
+}
+
+func TestBytesString(t *testing.T) {
+	require.Equal(t, "0 B", bytesString(0))
+	require.Equal(t, "1023 B", bytesString(1023))
+	require.Equal(t, "1.0 KiB", bytesString(1024))
+	require.Equal(t, "1.5 MiB", bytesString(3<<19))
+	require.Equal(t, "2.0 GiB", bytesString(2<<30))
+}
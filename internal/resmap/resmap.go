@@ -7,6 +7,18 @@ type ResourceMapEntity struct {
 	ResourceType string `json:"resource_type"`
 	// TF resource name
 	ResourceName string `json:"resource_name"`
+	// The TF import id, which overrides the computed one (i.e. the TF resource ID). This is only needed for the TF resource types whose import ids can't be
+	// derived from the Azure resource id (e.g. composite ids), when the computed one is wrong.
+	ImportId string `json:"import_id,omitempty"`
+	// The candidate TF resource types in the descending order of the confidence, which is only set if the Azure resource maps to multiple TF resource types
+	Candidates []ResourceMapCandidate `json:"candidates,omitempty"`
+}
+
+type ResourceMapCandidate struct {
+	// TF resource type
+	ResourceType string `json:"resource_type"`
+	// The confidence (0 to 1) that the Azure resource is of this TF resource type
+	Confidence float64 `json:"confidence"`
 }
 
 // ResourceMapping is the resource mapping file, the key is the Azure resource Id in uppercase.
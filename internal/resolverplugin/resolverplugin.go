@@ -0,0 +1,157 @@
+package resolverplugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/log"
+)
+
+// Request is written to the stdin of the plugin, as a line of JSON, for each Azure resource to resolve.
+type Request struct {
+	// The Azure resource id
+	Id string `json:"id"`
+	// The Azure resource type (e.g. "Microsoft.Compute/virtualMachines")
+	Type string `json:"type"`
+	// The properties of the Azure resource, which are only set if the resource is listed along with them (e.g. by the Azure Resource Graph)
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+const (
+	// ActionResolve resolves the Azure resource as the ResourceType.
+	ActionResolve = "resolve"
+	// ActionSkip drops the Azure resource from the list.
+	ActionSkip = "skip"
+	// ActionPass leaves the Azure resource to the next resolver.
+	ActionPass = "pass"
+)
+
+// Response is read from the stdout of the plugin, as a line of JSON, for each Request.
+type Response struct {
+	// Action is one of "resolve", "skip" and "pass". Empty means "pass".
+	Action string `json:"action,omitempty"`
+	// The TF resource type, which is required by the "resolve" action. It can be of any provider (e.g. a custom provider).
+	ResourceType string `json:"resource_type,omitempty"`
+	// The TF resource id, which is optional for the "resolve" action. It is computed for the ResourceType if absent.
+	ResourceId string `json:"resource_id,omitempty"`
+}
+
+// closeTimeout is how long to wait for the plugin to exit after its stdin is closed, before killing it.
+const closeTimeout = 5 * time.Second
+
+// Plugin is an external process that resolves the TF resource types of the Azure resources, by speaking JSON over stdio (i.e. a Request per line to its stdin,
+// and a Response per line from its stdout). Its stderr is logged. The process is started on the first Resolve, and is kept running until Close.
+// The requests are sent one at a time, which is safe for concurrent use.
+type Plugin struct {
+	path string
+
+	mu      sync.Mutex
+	started bool
+	enc     *json.Encoder
+	dec     *json.Decoder
+	close   func() error
+	// err is the error that breaks the conversation (e.g. the process exits), which fails all the following requests
+	err error
+}
+
+// New returns the plugin of the executable at the path, which is not started until the first Resolve.
+func New(path string) *Plugin {
+	return &Plugin{path: path}
+}
+
+func (p *Plugin) start() error {
+	// #nosec G204
+	cmd := exec.Command(p.path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Printf("[DEBUG] resolver plugin: %s", scanner.Text())
+		}
+	}()
+	p.started = true
+	p.enc = json.NewEncoder(stdin)
+	p.dec = json.NewDecoder(stdout)
+	p.close = func() error {
+		// #nosec G104
+		stdin.Close()
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(closeTimeout):
+			// #nosec G104
+			cmd.Process.Kill()
+			return fmt.Errorf("the resolver plugin didn't exit in %s, killed", closeTimeout)
+		}
+	}
+	return nil
+}
+
+// Resolve sends the request to the plugin, and returns its response.
+func (p *Plugin) Resolve(req Request) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	if !p.started {
+		if err := p.start(); err != nil {
+			p.err = fmt.Errorf("starting the resolver plugin %s: %v", p.path, err)
+			return nil, p.err
+		}
+	}
+	if err := p.enc.Encode(req); err != nil {
+		p.err = fmt.Errorf("writing the request to the resolver plugin: %v", err)
+		return nil, p.err
+	}
+	var resp Response
+	if err := p.dec.Decode(&resp); err != nil {
+		p.err = fmt.Errorf("reading the response from the resolver plugin: %v", err)
+		return nil, p.err
+	}
+	switch resp.Action {
+	case "":
+		resp.Action = ActionPass
+	case ActionResolve:
+		if resp.ResourceType == "" {
+			return nil, fmt.Errorf("the resolver plugin responded %q for %s without the resource_type", ActionResolve, req.Id)
+		}
+	case ActionSkip, ActionPass:
+	default:
+		return nil, fmt.Errorf("the resolver plugin responded an unknown action %q for %s", resp.Action, req.Id)
+	}
+	return &resp, nil
+}
+
+// Close closes the stdin of the plugin process (if started), and waits for it to exit.
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.close == nil {
+		return nil
+	}
+	err := p.close()
+	p.close = nil
+	p.err = fmt.Errorf("the resolver plugin is closed")
+	return err
+}
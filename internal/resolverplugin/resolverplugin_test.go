@@ -0,0 +1,84 @@
+package resolverplugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const fakePluginEnv = "AZTFEXPORT_TEST_FAKE_RESOLVER_PLUGIN"
+
+// TestMain runs the test binary as the fake plugin, if told by the environment variable.
+func TestMain(m *testing.M) {
+	if os.Getenv(fakePluginEnv) == "1" {
+		fakePlugin()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func fakePlugin() {
+	scanner := bufio.NewScanner(os.Stdin)
+	enc := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid request: %v\n", err)
+			os.Exit(1)
+		}
+		var resp Response
+		switch req.Type {
+		case "Microsoft.Compute/virtualMachines":
+			resp = Response{Action: ActionResolve, ResourceType: "azurerm_linux_virtual_machine"}
+		case "Microsoft.Insights/components":
+			resp = Response{Action: ActionSkip}
+		case "Microsoft.Foo/bars":
+			resp = Response{Action: "ignore"}
+		case "Microsoft.Foo/exits":
+			os.Exit(1)
+		}
+		// #nosec G104
+		enc.Encode(resp)
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	t.Setenv(fakePluginEnv, "1")
+	p := New(os.Args[0])
+	defer p.Close()
+
+	resp, err := p.Resolve(Request{Id: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm", Type: "Microsoft.Compute/virtualMachines"})
+	require.NoError(t, err)
+	require.Equal(t, Response{Action: ActionResolve, ResourceType: "azurerm_linux_virtual_machine"}, *resp)
+
+	resp, err = p.Resolve(Request{Id: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Insights/components/ai", Type: "Microsoft.Insights/components"})
+	require.NoError(t, err)
+	require.Equal(t, Response{Action: ActionSkip}, *resp)
+
+	// The empty action means to pass
+	resp, err = p.Resolve(Request{Id: "/subscriptions/123/resourceGroups/rg", Type: "Microsoft.Resources/resourceGroups"})
+	require.NoError(t, err)
+	require.Equal(t, Response{Action: ActionPass}, *resp)
+
+	// The invalid response fails the request only
+	_, err = p.Resolve(Request{Id: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/bars/bar", Type: "Microsoft.Foo/bars"})
+	require.EqualError(t, err, `the resolver plugin responded an unknown action "ignore" for /subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/bars/bar`)
+
+	// The plugin exits, which fails all the following requests
+	_, err = p.Resolve(Request{Id: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/exits/exit", Type: "Microsoft.Foo/exits"})
+	require.Error(t, err)
+	_, err2 := p.Resolve(Request{Id: "/subscriptions/123/resourceGroups/rg", Type: "Microsoft.Resources/resourceGroups"})
+	require.Equal(t, err, err2)
+}
+
+func TestPluginNotFound(t *testing.T) {
+	p := New("/not/exist")
+	_, err := p.Resolve(Request{Id: "/subscriptions/123/resourceGroups/rg", Type: "Microsoft.Resources/resourceGroups"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "starting the resolver plugin /not/exist")
+	require.NoError(t, p.Close())
+}
@@ -2,13 +2,12 @@ package resourceset
 
 import (
 	"sort"
+	"strings"
 
+	"github.com/Azure/aztfexport/internal/armschema"
 	"github.com/Azure/aztfexport/pkg/log"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 
 	"github.com/magodo/armid"
-	"github.com/magodo/aztft/aztft"
 	"github.com/magodo/workerpool"
 )
 
@@ -19,6 +18,8 @@ type AzureResourceSet struct {
 type AzureResource struct {
 	Id         armid.ResourceId
 	Properties map[string]interface{}
+	// Pseudo indicates that this resource is derived from the settings embedded in another resource's body, which is only offered as an import candidate.
+	Pseudo bool
 }
 
 type PesudoResourceInfo struct {
@@ -26,47 +27,53 @@ type PesudoResourceInfo struct {
 	TFId   string
 }
 
-func (rset AzureResourceSet) ToTFResources(parallelism int, cred azcore.TokenCredential, clientOpt arm.ClientOptions) []TFResource {
+// ToTFResources maps each Azure resource to its TF resource(s) via the resolver chain.
+func (rset AzureResourceSet) ToTFResources(parallelism int, chain ResolverChain) []TFResource {
 	tfresources := []TFResource{}
 
 	wp := workerpool.NewWorkPool(parallelism)
 
+	seen := map[string]int{}
 	type result struct {
-		resid   armid.ResourceId
-		tftypes []aztft.Type
-		tfids   []string
-		exact   bool
-		err     error
+		resid      armid.ResourceId
+		resources  []TFResource
+		candidates []Candidate
+		ok         bool
+		pseudo     bool
 	}
 
 	wp.Run(func(v interface{}) error {
 		res := v.(result)
-		if res.err != nil {
-			log.Printf("[WARN] Failed to query resource type for %s: %v\n", res.resid, res.err)
+		switch {
+		case !res.ok:
+			log.Printf("[INFO] Dropping the unresolved resource %s\n", res.resid)
+		case len(res.resources) == 0:
 			// Still put this unresolved resource in the resource set, so that users can later specify the expected TF resource type.
-			tfresources = append(tfresources, TFResource{
+			tfres := TFResource{
 				AzureId: res.resid,
 				// Use the azure ID as the TF ID as a fallback
 				TFId: res.resid.String(),
-			})
-		} else {
-			if !res.exact {
-				// It is not possible to return multiple result when API is used.
-				log.Printf("[WARN] No query result for resource type and TF id for %s\n", res.resid)
-				// Still put this unresolved resource in the resource set, so that users can later specify the expected TF resource type.
-				tfresources = append(tfresources, TFResource{
-					AzureId: res.resid,
-					// Use the azure ID as the TF ID as a fallback
-					TFId: res.resid.String(),
-				})
-			} else {
-				for i := range res.tfids {
-					tfresources = append(tfresources, TFResource{
-						AzureId: res.tftypes[i].AzureId,
-						TFId:    res.tfids[i],
-						TFType:  res.tftypes[i].TFType,
-					})
+			}
+			tfres.Candidates, tfres.Confidences = candidateTypes(res.candidates)
+			tfresources = append(tfresources, tfres)
+		default:
+			for _, tfres := range res.resources {
+				// The candidates are only returned along with the resource resolved as the best candidate.
+				if len(res.candidates) != 0 {
+					tfres.Candidates, tfres.Confidences = candidateTypes(res.candidates)
 				}
+				// The association resources might be populated both by the resolver and by PopulateResource.
+				// The pseudo resources might also be listed by Azure, in which case the listed one wins.
+				tfres.Pseudo = res.pseudo
+				k := strings.ToUpper(tfres.AzureId.String())
+				if idx, ok := seen[k]; ok {
+					if tfresources[idx].Pseudo && !tfres.Pseudo {
+						tfresources[idx] = tfres
+					}
+					continue
+				}
+				seen[k] = len(tfresources)
+				tfresources = append(tfresources, tfres)
 			}
 		}
 		return nil
@@ -75,18 +82,13 @@ func (rset AzureResourceSet) ToTFResources(parallelism int, cred azcore.TokenCre
 	for _, res := range rset.Resources {
 		res := res
 		wp.AddTask(func() (interface{}, error) {
-			tftypes, tfids, exact, err := aztft.QueryTypeAndId(res.Id.String(),
-				&aztft.APIOption{
-					Cred:         cred,
-					ClientOption: clientOpt,
-				},
-			)
+			resources, candidates, ok := chain.resolve(res)
 			return result{
-				resid:   res.Id,
-				tftypes: tftypes,
-				tfids:   tfids,
-				exact:   exact,
-				err:     err,
+				resid:      res.Id,
+				resources:  resources,
+				candidates: candidates,
+				ok:         ok,
+				pseudo:     res.Pseudo,
 			}, nil
 		})
 	}
@@ -100,3 +102,45 @@ func (rset AzureResourceSet) ToTFResources(parallelism int, cred azcore.TokenCre
 
 	return tfresources
 }
+
+// candidateTypes returns the TF resource types of the ranked candidates, and their confidences.
+func candidateTypes(candidates []Candidate) ([]string, map[string]float64) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	types := make([]string, 0, len(candidates))
+	confidences := make(map[string]float64, len(candidates))
+	for _, c := range candidates {
+		types = append(types, c.TFType)
+		confidences[c.TFType] = c.Confidence
+	}
+	return types, confidences
+}
+
+// ToAzAPIResources maps each Azure resource to an azapi_resource, whose TF id is the Azure resource id suffixed by its latest API version.
+// The resource whose API version can't be determined is left with an empty TF type, so that users can later decide what to do with it.
+func (rset AzureResourceSet) ToAzAPIResources(tfType string, tfId func(id armid.ResourceId, apiVersion string) string) []TFResource {
+	tfresources := []TFResource{}
+	for _, res := range rset.Resources {
+		apiVersion, err := armschema.LatestAPIVersion(res.Id.TypeString())
+		if err != nil {
+			log.Printf("[WARN] Failed to find the API version for %s: %v\n", res.Id, err)
+			tfresources = append(tfresources, TFResource{
+				AzureId: res.Id,
+				TFId:    res.Id.String(),
+			})
+			continue
+		}
+		tfresources = append(tfresources, TFResource{
+			AzureId: res.Id,
+			TFId:    tfId(res.Id, apiVersion),
+			TFType:  tfType,
+		})
+	}
+
+	sort.Slice(tfresources, func(i, j int) bool {
+		return tfresources[i].AzureId.String() < tfresources[j].AzureId.String()
+	})
+
+	return tfresources
+}
@@ -1,10 +1,12 @@
 package resourceset
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"strings"
 
+	"github.com/Azure/aztfexport/pkg/log"
 	"github.com/magodo/armid"
 
 	"github.com/tidwall/gjson"
@@ -23,6 +25,14 @@ func (rset *AzureResourceSet) PopulateResource() error {
 	if err := rset.populateForVirtualMachine(); err != nil {
 		return err
 	}
+	// Populate the association resources that have no ARM identity, which are derived from the properties of the parent resources.
+	if err := rset.populateAssociations(); err != nil {
+		return err
+	}
+	// Populate the pseudo resources for the settings embedded in the resource body, which are modelled as separate resources in azurerm.
+	if err := rset.populateEmbeddedSettings(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -33,6 +43,10 @@ func (rset *AzureResourceSet) ReduceResource() error {
 	if err := rset.reduceForKeyVaultCertificate(); err != nil {
 		return err
 	}
+	// Some resources are implicitly created (and managed) by the TF resource of their parent, importing them as standalone resources leads to conflicts.
+	if err := rset.reduceDerivedResources(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -104,3 +118,234 @@ func populateManagedResourcesByPath(res AzureResource, paths ...string) ([]Azure
 	}
 	return resources, nil
 }
+
+// associationSpec describes how to derive the association resources from the properties of a resource.
+type associationSpec struct {
+	// The gjson path to the resource ids that the resource associates with
+	path string
+	// The resource type appended to the parent resource id, to form the association resource id. The resource name is the base64 encoded associated resource id.
+	// This is consistent with the ids that aztft populates via API, which are statically mapped to the association TF resource types.
+	attrType string
+}
+
+var associationSpecs = map[string][]associationSpec{
+	"/MICROSOFT.NETWORK/VIRTUALNETWORKS/SUBNETS": {
+		{path: "properties.routeTable.id", attrType: "routeTables"},
+		{path: "properties.networkSecurityGroup.id", attrType: "networkSecurityGroups"},
+		{path: "properties.natGateway.id", attrType: "natGateways"},
+	},
+	"/MICROSOFT.NETWORK/NETWORKINTERFACES": {
+		{path: "properties.networkSecurityGroup.id", attrType: "networkSecurityGroups"},
+	},
+	"/MICROSOFT.NETWORK/NETWORKINTERFACES/IPCONFIGURATIONS": {
+		{path: "properties.applicationGatewayBackendAddressPools.#.id", attrType: "applicationGatewayBackendAddressPools"},
+		{path: "properties.applicationSecurityGroups.#.id", attrType: "applicationSecurityGroups"},
+		{path: "properties.loadBalancerInboundNatRules.#.id", attrType: "loadBalancerInboundNatRules"},
+		{path: "properties.loadBalancerBackendAddressPools.#.id", attrType: "loadBalancerBackendAddressPools"},
+	},
+	"/MICROSOFT.NETWORK/NATGATEWAYS": {
+		{path: "properties.publicIpAddresses.#.id", attrType: "publicIPAddresses"},
+		{path: "properties.publicIpPrefixes.#.id", attrType: "publicIPPrefixes"},
+	},
+}
+
+func (rset *AzureResourceSet) populateAssociations() error {
+	for _, res := range rset.Resources[:] {
+		if res.Properties == nil {
+			continue
+		}
+		associations, err := populateAssociationsForResource(res.Id, res.Properties)
+		if err != nil {
+			return fmt.Errorf("populating associations for %q: %v", res.Id, err)
+		}
+		rset.Resources = append(rset.Resources, associations...)
+	}
+	return nil
+}
+
+func populateAssociationsForResource(id armid.ResourceId, props interface{}) ([]AzureResource, error) {
+	b, err := json.Marshal(props)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling %v: %v", props, err)
+	}
+
+	var resources []AzureResource
+	for _, spec := range associationSpecs[strings.ToUpper(id.RouteScopeString())] {
+		result := gjson.GetBytes(b, spec.path)
+		if !result.Exists() {
+			continue
+		}
+		values := []gjson.Result{result}
+		if result.IsArray() {
+			values = result.Array()
+		}
+		for _, v := range values {
+			associatedId, err := armid.ParseResourceId(v.String())
+			if err != nil {
+				return nil, fmt.Errorf("parsing associated resource id %s: %v", v.String(), err)
+			}
+			azureId := id.Clone().(*armid.ScopedResourceId)
+			azureId.AttrTypes = append(azureId.AttrTypes, spec.attrType)
+			azureId.AttrNames = append(azureId.AttrNames, base64.StdEncoding.EncodeToString([]byte(associatedId.String())))
+			resources = append(resources, AzureResource{Id: azureId})
+		}
+	}
+
+	// The ip configurations are embedded in the network interface
+	if strings.EqualFold(id.RouteScopeString(), "/Microsoft.Network/networkInterfaces") {
+		for _, ipConfig := range gjson.GetBytes(b, "properties.ipConfigurations").Array() {
+			ipConfigId, err := armid.ParseResourceId(ipConfig.Get("id").String())
+			if err != nil {
+				return nil, fmt.Errorf("parsing ip configuration id %s: %v", ipConfig.Get("id").String(), err)
+			}
+			var ipConfigProps interface{}
+			if err := json.Unmarshal([]byte(ipConfig.Raw), &ipConfigProps); err != nil {
+				return nil, fmt.Errorf("unmarshalling ip configuration %s: %v", ipConfigId, err)
+			}
+			associations, err := populateAssociationsForResource(ipConfigId, ipConfigProps)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, associations...)
+		}
+	}
+	return resources, nil
+}
+
+// embeddedSettingFunc returns the ids of the pseudo resources derived from the body of a resource.
+type embeddedSettingFunc func(id armid.ResourceId, body gjson.Result) []armid.ResourceId
+
+var embeddedSettingFuncs = map[string]embeddedSettingFunc{
+	"/MICROSOFT.WEB/SITES":               embeddedHostNameBindings,
+	"/MICROSOFT.WEB/SITES/SLOTS":         embeddedHostNameBindings,
+	"/MICROSOFT.STORAGE/STORAGEACCOUNTS": embeddedManagementPolicy,
+	"/MICROSOFT.KEYVAULT/VAULTS":         embeddedAccessPolicies,
+}
+
+func (rset *AzureResourceSet) populateEmbeddedSettings() error {
+	for _, res := range rset.Resources[:] {
+		f, ok := embeddedSettingFuncs[strings.ToUpper(res.Id.RouteScopeString())]
+		if !ok || res.Properties == nil {
+			continue
+		}
+		b, err := json.Marshal(res.Properties)
+		if err != nil {
+			return fmt.Errorf("marshaling %v: %v", res.Properties, err)
+		}
+		for _, id := range f(res.Id, gjson.ParseBytes(b)) {
+			rset.Resources = append(rset.Resources, AzureResource{Id: id, Pseudo: true})
+		}
+	}
+	return nil
+}
+
+func childResourceId(id armid.ResourceId, attrType, attrName string) armid.ResourceId {
+	childId := id.Clone().(*armid.ScopedResourceId)
+	childId.AttrTypes = append(childId.AttrTypes, attrType)
+	childId.AttrNames = append(childId.AttrNames, attrName)
+	return childId
+}
+
+// embeddedHostNameBindings returns the custom host name bindings of the web app, which excludes the default host name.
+func embeddedHostNameBindings(id armid.ResourceId, body gjson.Result) []armid.ResourceId {
+	defaultHostName := body.Get("properties.defaultHostName").String()
+	var ids []armid.ResourceId
+	for _, hostName := range body.Get("properties.hostNames").Array() {
+		if strings.EqualFold(hostName.String(), defaultHostName) {
+			continue
+		}
+		ids = append(ids, childResourceId(id, "hostNameBindings", hostName.String()))
+	}
+	return ids
+}
+
+// embeddedManagementPolicy returns the management policy of the storage account.
+// The policy itself is not part of the storage account body, it is offered for the account kinds that support it, and fails to import in case it is not defined.
+func embeddedManagementPolicy(id armid.ResourceId, body gjson.Result) []armid.ResourceId {
+	switch strings.ToUpper(body.Get("kind").String()) {
+	case "STORAGEV2", "BLOBSTORAGE", "BLOCKBLOBSTORAGE":
+		return []armid.ResourceId{childResourceId(id, "managementPolicies", "default")}
+	}
+	return nil
+}
+
+// embeddedAccessPolicies returns the access policies of the key vault, that are not granted to an application on behalf of the principal.
+func embeddedAccessPolicies(id armid.ResourceId, body gjson.Result) []armid.ResourceId {
+	var ids []armid.ResourceId
+	for _, policy := range body.Get("properties.accessPolicies").Array() {
+		if policy.Get("applicationId").String() != "" {
+			continue
+		}
+		objectId := policy.Get("objectId").String()
+		if objectId == "" {
+			continue
+		}
+		ids = append(ids, childResourceId(id, "objectId", objectId))
+	}
+	return ids
+}
+
+// derivedResourceFuncs returns the ids of the resources that are implicitly created along with the parent resource, keyed by the route scope of the parent.
+var derivedResourceFuncs = map[string]func(id armid.ResourceId, body gjson.Result) []string{
+	// The OS disk is managed by the VM, while the data disks are managed separately together with the attachments.
+	"/MICROSOFT.COMPUTE/VIRTUALMACHINES": func(_ armid.ResourceId, body gjson.Result) []string {
+		return gjsonStrings(body.Get("properties.storageProfile.osDisk.managedDisk.id"))
+	},
+	// The default node pool is managed by the cluster, which is the first node pool of the "System" mode.
+	"/MICROSOFT.CONTAINERSERVICE/MANAGEDCLUSTERS": func(id armid.ResourceId, body gjson.Result) []string {
+		for _, profile := range body.Get("properties.agentPoolProfiles").Array() {
+			if strings.EqualFold(profile.Get("mode").String(), "System") {
+				return []string{childResourceId(id, "agentPools", profile.Get("name").String()).String()}
+			}
+		}
+		return nil
+	},
+	"/MICROSOFT.NETWORK/PRIVATEENDPOINTS": func(_ armid.ResourceId, body gjson.Result) []string {
+		return gjsonStrings(body.Get("properties.networkInterfaces.#.id"))
+	},
+	"/MICROSOFT.NETWORK/PRIVATELINKSERVICES": func(_ armid.ResourceId, body gjson.Result) []string {
+		return gjsonStrings(body.Get("properties.networkInterfaces.#.id"))
+	},
+}
+
+func gjsonStrings(result gjson.Result) []string {
+	var out []string
+	for _, v := range result.Array() {
+		if s := v.String(); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// reduceDerivedResources removes the resources that are implicitly created along with their parents in the resource set.
+func (rset *AzureResourceSet) reduceDerivedResources() error {
+	derived := map[string]armid.ResourceId{}
+	for _, res := range rset.Resources {
+		f, ok := derivedResourceFuncs[strings.ToUpper(res.Id.RouteScopeString())]
+		if !ok || res.Properties == nil {
+			continue
+		}
+		b, err := json.Marshal(res.Properties)
+		if err != nil {
+			return fmt.Errorf("marshaling %v: %v", res.Properties, err)
+		}
+		for _, id := range f(res.Id, gjson.ParseBytes(b)) {
+			derived[strings.ToUpper(id)] = res.Id
+		}
+	}
+	if len(derived) == 0 {
+		return nil
+	}
+
+	var resources []AzureResource
+	for _, res := range rset.Resources {
+		if parent, ok := derived[strings.ToUpper(res.Id.String())]; ok {
+			log.Printf("[INFO] Excluding %s, which is implicitly created along with %s", res.Id, parent)
+			continue
+		}
+		resources = append(resources, res)
+	}
+	rset.Resources = resources
+	return nil
+}
@@ -0,0 +1,181 @@
+package resourceset
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPopulateAssociations(t *testing.T) {
+	nsgId := "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg"
+	asgId := "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/applicationSecurityGroups/asg"
+	subnetId := "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet"
+	nicId := "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/nic"
+
+	parse := func(v string) map[string]interface{} {
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(v), &m))
+		return m
+	}
+	rset := AzureResourceSet{
+		Resources: []AzureResource{
+			{
+				Id:         mustParseId(t, subnetId),
+				Properties: parse(`{"properties": {"networkSecurityGroup": {"id": "` + nsgId + `"}}}`),
+			},
+			{
+				Id: mustParseId(t, nicId),
+				Properties: parse(`{"properties": {
+  "networkSecurityGroup": {"id": "` + nsgId + `"},
+  "ipConfigurations": [{"id": "` + nicId + `/ipConfigurations/ipconfig1", "properties": {"applicationSecurityGroups": [{"id": "` + asgId + `"}]}}]
+}}`),
+			},
+		},
+	}
+	require.NoError(t, rset.populateAssociations())
+
+	encode := func(v string) string {
+		return base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	var ids []string
+	for _, res := range rset.Resources {
+		ids = append(ids, res.Id.String())
+	}
+	require.Equal(t, []string{
+		subnetId,
+		nicId,
+		subnetId + "/networkSecurityGroups/" + encode(nsgId),
+		nicId + "/networkSecurityGroups/" + encode(nsgId),
+		nicId + "/ipConfigurations/ipconfig1/applicationSecurityGroups/" + encode(asgId),
+	}, ids)
+
+	// The synthesized association resources can be statically resolved
+	l := AzureResourceSet{Resources: rset.Resources[2:]}.ToTFResources(1, ResolverChain{Resolvers: []string{ResolverHeuristic}})
+	var types []string
+	for _, res := range l {
+		types = append(types, res.TFType)
+	}
+	require.ElementsMatch(t, []string{
+		"azurerm_subnet_network_security_group_association",
+		"azurerm_network_interface_security_group_association",
+		"azurerm_network_interface_application_security_group_association",
+	}, types)
+}
+
+func mustParseId(t *testing.T, id string) armid.ResourceId {
+	azureId, err := armid.ParseResourceId(id)
+	require.NoError(t, err)
+	return azureId
+}
+
+func TestPopulateEmbeddedSettings(t *testing.T) {
+	siteId := "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Web/sites/app"
+	storageId := "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa"
+	vaultId := "/subscriptions/123/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv"
+
+	parse := func(v string) map[string]interface{} {
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(v), &m))
+		return m
+	}
+	rset := AzureResourceSet{
+		Resources: []AzureResource{
+			{
+				Id:         mustParseId(t, siteId),
+				Properties: parse(`{"properties": {"defaultHostName": "app.azurewebsites.net", "hostNames": ["app.azurewebsites.net", "www.example.com"]}}`),
+			},
+			{
+				Id:         mustParseId(t, storageId),
+				Properties: parse(`{"kind": "StorageV2", "properties": {}}`),
+			},
+			{
+				Id:         mustParseId(t, vaultId),
+				Properties: parse(`{"properties": {"accessPolicies": [{"objectId": "obj1"}, {"objectId": "obj2", "applicationId": "app"}]}}`),
+			},
+		},
+	}
+	require.NoError(t, rset.populateEmbeddedSettings())
+
+	var ids []string
+	for _, res := range rset.Resources[3:] {
+		require.True(t, res.Pseudo)
+		ids = append(ids, res.Id.String())
+	}
+	require.Equal(t, []string{
+		siteId + "/hostNameBindings/www.example.com",
+		storageId + "/managementPolicies/default",
+		vaultId + "/objectId/obj1",
+	}, ids)
+
+	l := AzureResourceSet{Resources: rset.Resources[3:]}.ToTFResources(1, ResolverChain{Resolvers: []string{ResolverHeuristic}})
+	var types []string
+	for _, res := range l {
+		require.True(t, res.Pseudo)
+		types = append(types, res.TFType)
+	}
+	require.ElementsMatch(t, []string{
+		"azurerm_app_service_custom_hostname_binding",
+		"azurerm_storage_management_policy",
+		"azurerm_key_vault_access_policy",
+	}, types)
+}
+
+func TestReduceDerivedResources(t *testing.T) {
+	prefix := "/subscriptions/123/resourceGroups/rg/providers"
+	vmId := prefix + "/Microsoft.Compute/virtualMachines/vm"
+	osDiskId := prefix + "/Microsoft.Compute/disks/vm_OsDisk"
+	dataDiskId := prefix + "/Microsoft.Compute/disks/data"
+	aksId := prefix + "/Microsoft.ContainerService/managedClusters/aks"
+	peId := prefix + "/Microsoft.Network/privateEndpoints/pe"
+	peNicId := prefix + "/Microsoft.Network/networkInterfaces/pe.nic.123"
+	nicId := prefix + "/Microsoft.Network/networkInterfaces/nic"
+
+	parse := func(v string) map[string]interface{} {
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(v), &m))
+		return m
+	}
+	rset := AzureResourceSet{
+		Resources: []AzureResource{
+			{
+				Id: mustParseId(t, vmId),
+				Properties: parse(`{"properties": {"storageProfile": {
+  "osDisk": {"managedDisk": {"id": "` + strings.ToLower(osDiskId) + `"}},
+  "dataDisks": [{"managedDisk": {"id": "` + dataDiskId + `"}}]
+}}}`),
+			},
+			{Id: mustParseId(t, osDiskId)},
+			{Id: mustParseId(t, dataDiskId)},
+			{
+				Id:         mustParseId(t, aksId),
+				Properties: parse(`{"properties": {"agentPoolProfiles": [{"name": "user", "mode": "User"}, {"name": "system", "mode": "System"}]}}`),
+			},
+			{Id: mustParseId(t, aksId+"/agentPools/system")},
+			{Id: mustParseId(t, aksId+"/agentPools/user")},
+			{
+				Id:         mustParseId(t, peId),
+				Properties: parse(`{"properties": {"networkInterfaces": [{"id": "` + peNicId + `"}]}}`),
+			},
+			{Id: mustParseId(t, peNicId)},
+			{Id: mustParseId(t, nicId)},
+		},
+	}
+	require.NoError(t, rset.reduceDerivedResources())
+
+	var ids []string
+	for _, res := range rset.Resources {
+		ids = append(ids, res.Id.String())
+	}
+	require.Equal(t, []string{
+		vmId,
+		dataDiskId,
+		aksId,
+		aksId + "/agentPools/user",
+		peId,
+		nicId,
+	}, ids)
+}
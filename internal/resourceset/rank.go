@@ -0,0 +1,159 @@
+package resourceset
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Candidate is a candidate TF resource type of an Azure resource that is mapped to multiple TF resource types.
+type Candidate struct {
+	TFType string
+	// Confidence is the likelihood (0 to 1) that the resource is of this TF resource type. The confidences of all the candidates of a resource sum up to 1.
+	Confidence float64
+}
+
+// prefillConfidence is the confidence that the best candidate must exceed to be used as the TF resource type, i.e. it is more likely than all the others together.
+const prefillConfidence = 0.5
+
+// evidence is a property of the resource body that discriminates a candidate TF resource type from the others.
+type evidence struct {
+	// path is the gjson path of the property in the resource body (i.e. the ARM resource, or the Azure Resource Graph row).
+	path string
+	// value is the expected value of the property, which is compared case insensitively. If it is empty, the property is only expected to exist.
+	// If the property exists with a different value, the candidate is ruled out.
+	value string
+	// absent means the property is expected not to exist.
+	absent bool
+}
+
+func (e evidence) match(body gjson.Result) (matched, contradicted bool) {
+	v := body.Get(e.path)
+	switch {
+	case e.absent:
+		return !v.Exists(), false
+	case !v.Exists():
+		return false, false
+	case e.value == "":
+		return true, false
+	default:
+		return strings.EqualFold(v.String(), e.value), !strings.EqualFold(v.String(), e.value)
+	}
+}
+
+// rankRule is how a candidate TF resource type is ranked.
+type rankRule struct {
+	// weight is the base weight of the candidate, which is lower for the legacy resource types.
+	weight    float64
+	evidences []evidence
+}
+
+// evidenceWeight is the weight added to the candidate for each matched evidence.
+const evidenceWeight = 2
+
+// rankRules are the rules of the TF resource types that share the same Azure resource type. Those not listed have a base weight of 1 and no evidence.
+var rankRules = map[string]rankRule{
+	// Per the virtualMachinesResolver of aztft.
+	"azurerm_linux_virtual_machine": {
+		weight: 1,
+		evidences: []evidence{
+			{path: "properties.storageProfile.osDisk.osType", value: "Linux"},
+			{path: "properties.osProfile.linuxConfiguration"},
+		},
+	},
+	"azurerm_windows_virtual_machine": {
+		weight: 1,
+		evidences: []evidence{
+			{path: "properties.storageProfile.osDisk.osType", value: "Windows"},
+			{path: "properties.osProfile.windowsConfiguration"},
+		},
+	},
+	"azurerm_virtual_machine": {
+		weight: 0.5,
+		evidences: []evidence{
+			{path: "properties.storageProfile.osDisk.vhd"},
+			{path: "properties.osProfile", absent: true},
+		},
+	},
+	// Per the virtualMachineScaleSetsResolver of aztft. The orchestration mode is not returned for the "Uniform" scale sets.
+	"azurerm_linux_virtual_machine_scale_set": {
+		weight: 1,
+		evidences: []evidence{
+			{path: "properties.orchestrationMode", value: "Uniform"},
+			{path: "properties.virtualMachineProfile.osProfile.linuxConfiguration"},
+		},
+	},
+	"azurerm_windows_virtual_machine_scale_set": {
+		weight: 1,
+		evidences: []evidence{
+			{path: "properties.orchestrationMode", value: "Uniform"},
+			{path: "properties.virtualMachineProfile.osProfile.windowsConfiguration"},
+		},
+	},
+	"azurerm_orchestrated_virtual_machine_scale_set": {
+		weight: 1,
+		evidences: []evidence{
+			{path: "properties.orchestrationMode", value: "Flexible"},
+		},
+	},
+}
+
+// hasEvidence tells whether any of the TF resource types has an evidence, i.e. ranking them needs the resource body.
+func hasEvidence(tftypes []string) bool {
+	for _, t := range tftypes {
+		if len(rankRules[t].evidences) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// rankCandidates ranks the candidate TF resource types by the evidences found in the resource body, in the descending order of the confidence.
+// If the body is nil, the candidates are ranked by their base weights only.
+func rankCandidates(tftypes []string, body map[string]interface{}) []Candidate {
+	var result gjson.Result
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err == nil {
+			result = gjson.ParseBytes(b)
+		}
+	}
+
+	weights := make([]float64, len(tftypes))
+	var total float64
+	for i, t := range tftypes {
+		rule, ok := rankRules[t]
+		if !ok {
+			rule = rankRule{weight: 1}
+		}
+		weight := rule.weight
+		if result.Exists() {
+			for _, e := range rule.evidences {
+				matched, contradicted := e.match(result)
+				if contradicted {
+					weight = 0
+					break
+				}
+				if matched {
+					weight += evidenceWeight
+				}
+			}
+		}
+		weights[i] = weight
+		total += weight
+	}
+
+	candidates := make([]Candidate, len(tftypes))
+	for i, t := range tftypes {
+		candidates[i] = Candidate{TFType: t}
+		if total > 0 {
+			candidates[i].Confidence = weights[i] / total
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates
+}
@@ -0,0 +1,100 @@
+package resourceset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankCandidates(t *testing.T) {
+	vmTypes := []string{"azurerm_linux_virtual_machine", "azurerm_windows_virtual_machine", "azurerm_virtual_machine"}
+	vmssTypes := []string{"azurerm_orchestrated_virtual_machine_scale_set", "azurerm_linux_virtual_machine_scale_set", "azurerm_windows_virtual_machine_scale_set"}
+	cases := []struct {
+		name    string
+		tftypes []string
+		body    map[string]interface{}
+		expect  []Candidate
+	}{
+		{
+			name:    "no body",
+			tftypes: vmTypes,
+			expect: []Candidate{
+				{TFType: "azurerm_linux_virtual_machine", Confidence: 0.4},
+				{TFType: "azurerm_windows_virtual_machine", Confidence: 0.4},
+				{TFType: "azurerm_virtual_machine", Confidence: 0.2},
+			},
+		},
+		{
+			name:    "windows vm",
+			tftypes: vmTypes,
+			body: map[string]interface{}{
+				"properties": map[string]interface{}{
+					"osProfile": map[string]interface{}{
+						"windowsConfiguration": map[string]interface{}{},
+					},
+					"storageProfile": map[string]interface{}{
+						"osDisk": map[string]interface{}{"osType": "Windows"},
+					},
+				},
+			},
+			expect: []Candidate{
+				{TFType: "azurerm_windows_virtual_machine", Confidence: 5 / 5.5},
+				{TFType: "azurerm_virtual_machine", Confidence: 0.5 / 5.5},
+				{TFType: "azurerm_linux_virtual_machine", Confidence: 0},
+			},
+		},
+		{
+			name:    "legacy vm",
+			tftypes: vmTypes,
+			body: map[string]interface{}{
+				"properties": map[string]interface{}{
+					"storageProfile": map[string]interface{}{
+						"osDisk": map[string]interface{}{
+							"osType": "Linux",
+							"vhd":    map[string]interface{}{"uri": "https://foo.blob.core.windows.net/vhds/osdisk.vhd"},
+						},
+					},
+				},
+			},
+			expect: []Candidate{
+				{TFType: "azurerm_virtual_machine", Confidence: 4.5 / 7.5},
+				{TFType: "azurerm_linux_virtual_machine", Confidence: 3 / 7.5},
+				{TFType: "azurerm_windows_virtual_machine", Confidence: 0},
+			},
+		},
+		{
+			name:    "flexible vmss",
+			tftypes: vmssTypes,
+			body: map[string]interface{}{
+				"properties": map[string]interface{}{
+					"orchestrationMode": "Flexible",
+				},
+			},
+			expect: []Candidate{
+				{TFType: "azurerm_orchestrated_virtual_machine_scale_set", Confidence: 1},
+				{TFType: "azurerm_linux_virtual_machine_scale_set", Confidence: 0},
+				{TFType: "azurerm_windows_virtual_machine_scale_set", Confidence: 0},
+			},
+		},
+		{
+			name:    "unknown types",
+			tftypes: []string{"foo", "bar"},
+			body:    map[string]interface{}{},
+			expect: []Candidate{
+				{TFType: "foo", Confidence: 0.5},
+				{TFType: "bar", Confidence: 0.5},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := rankCandidates(tt.tftypes, tt.body)
+			require.Len(t, actual, len(tt.expect))
+			for i := range tt.expect {
+				require.Equal(t, tt.expect[i].TFType, actual[i].TFType)
+				require.InDelta(t, tt.expect[i].Confidence, actual[i].Confidence, 1e-9)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package resourceset
+
+import (
+	"fmt"
+
+	"github.com/Azure/aztfexport/internal/resolverplugin"
+	"github.com/Azure/aztfexport/internal/typeoverride"
+	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/magodo/armid"
+	"github.com/magodo/aztft/aztft"
+)
+
+const (
+	// ResolverPlugin resolves (or skips) the TF resource type by the user supplied resolver plugin.
+	ResolverPlugin = "plugin"
+	// ResolverOverride resolves the TF resource type by the user supplied type overrides.
+	ResolverOverride = "override"
+	// ResolverAPI resolves the TF resource type by the static mappings, and calls the Azure API to disambiguate if needed.
+	ResolverAPI = "api"
+	// ResolverHeuristic resolves the TF resource type by the static mappings only. If there are multiple candidates, they are recorded as the recommendations.
+	ResolverHeuristic = "heuristic"
+	// ResolverAsk keeps the unresolved resources in the list, to be decided by the user in the interactive mode. Otherwise, these resources are dropped.
+	ResolverAsk = "ask"
+)
+
+// DefaultResolvers is the default resolver chain.
+var DefaultResolvers = []string{ResolverPlugin, ResolverOverride, ResolverAPI, ResolverHeuristic, ResolverAsk}
+
+// ResolverChain resolves the TF resource of an Azure resource by consulting each resolver in order, until one of them resolves it.
+type ResolverChain struct {
+	// Resolvers is the ordered resolver names. If it is empty, the DefaultResolvers is used.
+	Resolvers []string
+	// Plugin is the resolver plugin, which is nil if not specified.
+	Plugin    *resolverplugin.Plugin
+	Overrides typeoverride.Overrides
+	Cred      azcore.TokenCredential
+	ClientOpt arm.ClientOptions
+	// Fetch gets the body of the Azure resource, which is used to rank the candidate TF resource types of the resource that is not listed with its properties.
+	// If it is nil, the candidates of such resources are ranked without evidence.
+	Fetch func(id armid.ResourceId) (map[string]interface{}, error)
+}
+
+// ValidateResolvers validates the resolver names.
+func ValidateResolvers(resolvers []string) error {
+	set := map[string]bool{}
+	for _, r := range resolvers {
+		var known bool
+		for _, kr := range DefaultResolvers {
+			if r == kr {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown resolver %q", r)
+		}
+		if set[r] {
+			return fmt.Errorf("duplicated resolver %q", r)
+		}
+		set[r] = true
+	}
+	return nil
+}
+
+func (chain ResolverChain) resolvers() []string {
+	if len(chain.Resolvers) == 0 {
+		return DefaultResolvers
+	}
+	return chain.Resolvers
+}
+
+// resolve returns the resolved TF resources of the Azure resource. If it is not resolved, the resources is nil, and the ranked candidate TF resource types are returned (if any).
+// The ok reports whether the resource should be kept in the list.
+func (chain ResolverChain) resolve(res AzureResource) (resources []TFResource, candidates []Candidate, ok bool) {
+	id := res.Id
+	apiOpt := &aztft.APIOption{
+		Cred:         chain.Cred,
+		ClientOption: chain.ClientOpt,
+	}
+	for _, resolver := range chain.resolvers() {
+		switch resolver {
+		case ResolverPlugin:
+			if chain.Plugin == nil {
+				continue
+			}
+			resp, err := chain.Plugin.Resolve(resolverplugin.Request{Id: id.String(), Type: id.TypeString(), Properties: res.Properties})
+			if err != nil {
+				log.Printf("[WARN] Failed to resolve %s by the resolver plugin: %v\n", id, err)
+				continue
+			}
+			switch resp.Action {
+			case resolverplugin.ActionSkip:
+				log.Printf("[INFO] Skipping %s as told by the resolver plugin\n", id)
+				return nil, nil, false
+			case resolverplugin.ActionResolve:
+				tfid := resp.ResourceId
+				if tfid == "" {
+					// The TF id of the types unknown to aztft (e.g. of the custom providers) falls back to the Azure resource id.
+					tfid, err = aztft.QueryId(id.String(), resp.ResourceType, apiOpt)
+					if err != nil {
+						log.Printf("[WARN] Failed to query the TF id of %s as %s resolved by the resolver plugin, fallback to use the Azure resource id: %v\n", id, resp.ResourceType, err)
+						tfid = id.String()
+					}
+				}
+				return []TFResource{{AzureId: id, TFId: tfid, TFType: resp.ResourceType}}, nil, true
+			}
+		case ResolverOverride:
+			tftype, matched := chain.Overrides.Match(id)
+			if !matched {
+				continue
+			}
+			tfid, err := aztft.QueryId(id.String(), tftype, apiOpt)
+			if err != nil {
+				log.Printf("[WARN] Failed to query the TF id of %s as the overridden type %s: %v\n", id, tftype, err)
+				continue
+			}
+			return []TFResource{{AzureId: id, TFId: tfid, TFType: tftype}}, nil, true
+		case ResolverAPI:
+			tftypes, tfids, exact, err := aztft.QueryTypeAndId(id.String(), apiOpt)
+			if err != nil {
+				log.Printf("[WARN] Failed to query resource type for %s: %v\n", id, err)
+				continue
+			}
+			if !exact {
+				// It is not possible to return multiple result when API is used.
+				log.Printf("[WARN] No query result for resource type and TF id for %s\n", id)
+				continue
+			}
+			for i := range tfids {
+				resources = append(resources, TFResource{
+					AzureId: tftypes[i].AzureId,
+					TFId:    tfids[i],
+					TFType:  tftypes[i].TFType,
+				})
+			}
+			return resources, nil, true
+		case ResolverHeuristic:
+			tftypes, _, err := aztft.QueryType(id.String(), nil)
+			if err != nil {
+				log.Printf("[WARN] Failed to query resource type for %s statically: %v\n", id, err)
+				continue
+			}
+			if len(tftypes) != 1 {
+				var types []string
+				for _, t := range tftypes {
+					types = append(types, t.TFType)
+				}
+				candidates = chain.rank(res, types)
+				// Pre-fill the best candidate if it is more likely than all the others together.
+				if len(candidates) == 0 || candidates[0].Confidence <= prefillConfidence {
+					continue
+				}
+				tfid, err := aztft.QueryId(id.String(), candidates[0].TFType, apiOpt)
+				if err != nil {
+					log.Printf("[WARN] Failed to query the TF id of %s as %s: %v\n", id, candidates[0].TFType, err)
+					continue
+				}
+				log.Printf("[INFO] Resolved %s as %s with confidence %.2f\n", id, candidates[0].TFType, candidates[0].Confidence)
+				return []TFResource{{AzureId: id, TFId: tfid, TFType: candidates[0].TFType}}, candidates, true
+			}
+			tfid, err := aztft.QueryId(id.String(), tftypes[0].TFType, apiOpt)
+			if err != nil {
+				log.Printf("[WARN] Failed to query the TF id of %s as %s: %v\n", id, tftypes[0].TFType, err)
+				continue
+			}
+			return []TFResource{{AzureId: id, TFId: tfid, TFType: tftypes[0].TFType}}, nil, true
+		case ResolverAsk:
+			return nil, candidates, true
+		}
+	}
+	return nil, candidates, false
+}
+
+// rank ranks the candidate TF resource types of the Azure resource, whose body is fetched if it is not listed with its properties.
+func (chain ResolverChain) rank(res AzureResource, tftypes []string) []Candidate {
+	body := res.Properties
+	if body == nil && chain.Fetch != nil && hasEvidence(tftypes) {
+		var err error
+		body, err = chain.Fetch(res.Id)
+		if err != nil {
+			log.Printf("[WARN] Failed to get %s to rank its candidate resource types: %v\n", res.Id, err)
+		}
+	}
+	return rankCandidates(tftypes, body)
+}
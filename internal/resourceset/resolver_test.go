@@ -0,0 +1,57 @@
+package resourceset
+
+import (
+	"testing"
+
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolverChain(t *testing.T) {
+	rgId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg")
+	require.NoError(t, err)
+	vmId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm")
+	require.NoError(t, err)
+	rset := AzureResourceSet{Resources: []AzureResource{{Id: rgId}, {Id: vmId}}}
+
+	// Without the "ask" resolver, the unresolved vm is dropped
+	l := rset.ToTFResources(1, ResolverChain{Resolvers: []string{ResolverHeuristic}})
+	require.Equal(t, []TFResource{
+		{AzureId: rgId, TFId: "/subscriptions/123/resourceGroups/rg", TFType: "azurerm_resource_group"},
+	}, l)
+
+	// With the "ask" resolver, the unresolved vm is kept with its candidates
+	l = rset.ToTFResources(1, ResolverChain{Resolvers: []string{ResolverHeuristic, ResolverAsk}})
+	require.Len(t, l, 2)
+	require.Equal(t, vmId, l[1].AzureId)
+	require.Empty(t, l[1].TFType)
+	require.Contains(t, l[1].Candidates, "azurerm_linux_virtual_machine")
+	require.Contains(t, l[1].Candidates, "azurerm_windows_virtual_machine")
+	require.InDelta(t, 0.4, l[1].Confidences["azurerm_linux_virtual_machine"], 1e-9)
+
+	// The vm is resolved as the best candidate, if it is evidenced by the fetched body
+	chain := ResolverChain{
+		Resolvers: []string{ResolverHeuristic},
+		Fetch: func(id armid.ResourceId) (map[string]interface{}, error) {
+			return map[string]interface{}{
+				"properties": map[string]interface{}{
+					"storageProfile": map[string]interface{}{
+						"osDisk": map[string]interface{}{"osType": "Linux"},
+					},
+				},
+			}, nil
+		},
+	}
+	l = rset.ToTFResources(1, chain)
+	require.Len(t, l, 2)
+	require.Equal(t, "azurerm_linux_virtual_machine", l[1].TFType)
+	require.Equal(t, "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm", l[1].TFId)
+	require.Equal(t, "azurerm_linux_virtual_machine", l[1].Candidates[0])
+	require.Greater(t, l[1].Confidences["azurerm_linux_virtual_machine"], 0.5)
+}
+
+func TestValidateResolvers(t *testing.T) {
+	require.NoError(t, ValidateResolvers(nil))
+	require.NoError(t, ValidateResolvers([]string{ResolverAsk, ResolverAPI}))
+	require.EqualError(t, ValidateResolvers([]string{"foo"}), `unknown resolver "foo"`)
+}
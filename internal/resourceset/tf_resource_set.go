@@ -6,4 +6,11 @@ type TFResource struct {
 	AzureId armid.ResourceId
 	TFId    string
 	TFType  string
+	// Candidates are the possible TF resource types of a resource that maps to multiple TF resource types, in the descending order of the confidence.
+	// The resource is either unresolved, or resolved as the best candidate.
+	Candidates []string
+	// Confidences are the confidences of the Candidates, keyed by the TF resource type. It is nil if the candidates are not ranked.
+	Confidences map[string]float64
+	// Pseudo indicates that the TF resource is derived from the settings embedded in another resource, which is skipped unless the user opts in
+	Pseudo bool
 }
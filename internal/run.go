@@ -3,20 +3,48 @@ package internal
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
+	"github.com/Azure/aztfexport/internal/costestimate"
+	"github.com/Azure/aztfexport/internal/i18n"
 	internalmeta "github.com/Azure/aztfexport/internal/meta"
+	"github.com/Azure/aztfexport/internal/pulumi"
+	"github.com/Azure/aztfexport/internal/verify"
 
 	"github.com/Azure/aztfexport/internal/config"
 	"github.com/Azure/aztfexport/pkg/meta"
-
-	"github.com/Azure/aztfexport/internal/ui/common"
-	bspinner "github.com/charmbracelet/bubbles/spinner"
-	"github.com/magodo/spinner"
 )
 
+// BatchImport runs the non-interactive mode. If the run succeeds, but some resources failed to import or are unsupported, it returns a *PartialSuccessError.
 func BatchImport(ctx context.Context, cfg config.NonInteractiveModeConfig) error {
+	// The progress of the listing is reported to the status, once the status is available.
+	var listStatus func(listed, total int)
+	onListProgress := cfg.Hooks.OnListProgress
+	cfg.Hooks.OnListProgress = func(listed, total int) {
+		if onListProgress != nil {
+			onListProgress(listed, total)
+		}
+		if listStatus != nil {
+			listStatus(listed, total)
+		}
+	}
+	// The listing is timed as the discovery and the resolution phases, which are switched once the resolution starts.
+	var resolveStart func()
+	onResolveStart := cfg.Hooks.OnResolveStart
+	cfg.Hooks.OnResolveStart = func() {
+		if onResolveStart != nil {
+			onResolveStart()
+		}
+		if resolveStart != nil {
+			resolveStart()
+		}
+	}
+
 	var c meta.Meta = internalmeta.NewGroupMetaDummy(cfg.ResourceGroupName)
 	if !cfg.MockMeta {
 		var err error
@@ -26,32 +54,112 @@ func BatchImport(ctx context.Context, cfg config.NonInteractiveModeConfig) error
 		}
 	}
 
+	prevCheckpoint := newCheckpoint()
+	if cfg.Resume {
+		var err error
+		prevCheckpoint, err = ReadCheckpoint(cfg.OutputDir)
+		if err != nil {
+			return err
+		}
+	}
+	var cp *Checkpoint
+	writeCheckpoint := func() error {
+		if cfg.MockMeta {
+			return nil
+		}
+		return cp.write(cfg.OutputDir)
+	}
+
+	// In the JSON output format, the stdout is reserved for the events, the human readable results are printed to the stderr instead.
+	var events *eventWriter
+	var out io.Writer = os.Stdout
+	if cfg.OutputFormat == config.OutputFormatJSON {
+		events = newEventWriter(os.Stdout)
+		out = os.Stderr
+	}
+
 	var errors []string
+	// warnings are the failures of the optional steps, which don't fail the run
+	var warnings []string
+	var estimate *costestimate.Estimate
+	var locked meta.ImportList
+	var summary *RunSummary
+	var report *verify.Report
+	var dryRun *DryRunResult
+	var list meta.ImportList
+	// stopped is the reason why the import is stopped by the budget or the interrupt, if any
+	var stopped string
+	timer := newPhaseTimer()
+	budget := newRunBudget(cfg.MaxResources, cfg.MaxDuration, timer.start)
+
+	// The first interrupt stops importing gracefully, the second one cancels the context to kill the in-flight imports.
+	// The deinitialization runs with the original context, so that the temporary import directories are removed even if force stopped.
+	deinitCtx := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	intr := newInterrupter(cancel, os.Stderr)
+	defer intr.notify()()
 
 	f := func(msg Messager) error {
-		msg.SetStatus("Initializing...")
-		if err := c.Init(ctx); err != nil {
+		msg.SetStatus(i18n.T("Initializing..."))
+		endPhase := timer.Start("init")
+		err := c.Init(ctx)
+		endPhase()
+		if err != nil {
 			return err
 		}
 
 		defer func() {
-			msg.SetStatus("DeInitializing...")
+			msg.SetStatus(i18n.T("DeInitializing..."))
+			defer timer.Start("deinit")()
 			// #nosec G104
-			c.DeInit(ctx)
+			c.DeInit(deinitCtx)
 		}()
 
-		msg.SetStatus("Listing resources...")
-		list, err := c.ListResource(ctx)
+		msg.SetStatus(i18n.T("Listing resources..."))
+		listStatus = func(listed, total int) {
+			msg.SetStatus(i18n.Sprintf("Listing resources... (%d/%d)", listed, total))
+		}
+		endPhase = timer.Start("discover")
+		resolveStart = func() {
+			endPhase()
+			endPhase = timer.Start("resolve")
+		}
+		list, err = c.ListResource(ctx)
+		endPhase()
+		if err != nil {
+			return err
+		}
+		locked = list.Locked()
+		for _, item := range list {
+			events.emitItem(EventResourceDiscovered, item)
+			if !item.Skip() {
+				events.emitItem(EventTypeResolved, item)
+			}
+		}
+
+		// The dry run stops at the resolved mapping, leaving the output directory untouched.
+		if cfg.DryRun {
+			result := newDryRunResult(list)
+			dryRun = &result
+			return nil
+		}
+
+		// The resources exported by the previous run are marked as imported, which are not imported again.
+		cp, err = prevCheckpoint.resume(list, !cfg.UseImportBlocks)
 		if err != nil {
 			return err
 		}
+		if err := writeCheckpoint(); err != nil {
+			return err
+		}
 
-		msg.SetStatus("Exporting Skipped Resource file...")
+		msg.SetStatus(i18n.T("Exporting Skipped Resource file..."))
 		if err := c.ExportSkippedResources(ctx, list); err != nil {
 			return fmt.Errorf("exporting Skipped Resource file: %v", err)
 		}
 
-		msg.SetStatus("Exporting Resource Mapping file...")
+		msg.SetStatus(i18n.T("Exporting Resource Mapping file..."))
 		if err := c.ExportResourceMapping(ctx, list); err != nil {
 			return fmt.Errorf("exporting Resource Mapping file: %v", err)
 		}
@@ -61,84 +169,379 @@ func BatchImport(ctx context.Context, cfg config.NonInteractiveModeConfig) error
 			return nil
 		}
 
-		for i := 0; i < len(list); i += cfg.Parallelism {
-			n := cfg.Parallelism
-			if i+cfg.Parallelism > len(list) {
-				n = len(list) - i
+		// importItems imports the items, and emits their events.
+		importItems := func(l []*meta.ImportItem) error {
+			for _, item := range l {
+				if !item.Skip() {
+					events.emitItem(EventImportStarted, *item)
+				}
+			}
+			endPhase := timer.Start("import")
+			err := c.ParallelImport(ctx, l)
+			endPhase()
+			if err != nil {
+				return fmt.Errorf("parallel importing: %v", err)
+			}
+			// The imports aborted by the interrupt are not attempted, instead of failed, which are left to `--resume`.
+			interrupted := intr.reason() != "" && !childrenInOwnProcessGroup(cfg.TFClient != nil)
+			for _, item := range l {
+				switch {
+				case item.Skip():
+				case item.ImportError != nil && interrupted:
+					item.ImportError = nil
+				case item.ImportError != nil:
+					events.emitItem(EventImportFailed, *item)
+				default:
+					events.emitItem(EventImportSucceeded, *item)
+				}
 			}
+			return nil
+		}
 
-			var importList []*meta.ImportItem
-			messages := []string{"Importing resources..."}
+		// exportImported pushes the state, and generates the config of the imported items whose config is not generated yet.
+		exportImported := func(l meta.ImportList, chunkMsg string) error {
+			endPhase := timer.Start("push_state")
+			err := c.PushState(ctx)
+			endPhase()
+			if err != nil {
+				return fmt.Errorf("failed to push state: %v", err)
+			}
+			pending := cp.pendingGeneration(l)
+			if !cfg.UseImportBlocks {
+				for _, item := range pending {
+					cp.set(item, StageImported)
+				}
+				if err := writeCheckpoint(); err != nil {
+					return err
+				}
+			}
 
-			for j := 0; j < n; j++ {
-				idx := i + j
-				if list[idx].Skip() {
-					messages = append(messages, fmt.Sprintf("(%d/%d) Skipping %s", idx+1, len(list), list[idx].TFResourceId))
-				} else {
-					messages = append(messages, fmt.Sprintf("(%d/%d) Importing %s as %s", idx+1, len(list), list[idx].TFResourceId, list[idx].TFAddr))
+			msg.SetStatus(i18n.T("Generating Terraform configurations...") + chunkMsg)
+			endPhase = timer.Start("generate_config")
+			err = c.GenerateCfg(ctx, pending)
+			endPhase()
+			if err != nil {
+				return fmt.Errorf("generating Terraform configuration: %v", err)
+			}
+			for _, item := range pending {
+				cp.set(item, StageGenerated)
+				events.emitItem(EventConfigGenerated, item)
+			}
+			return writeCheckpoint()
+		}
+
+		// retryQueue are the items that failed transiently, which are retried at the end of the run.
+		var retryQueue []*meta.ImportItem
+		chunks := chunkList(list, cfg.ChunkSize)
+		for ci, chunk := range chunks {
+			chunkMsg := ""
+			if len(chunks) > 1 {
+				chunkMsg = " " + i18n.Sprintf("(chunk %d/%d)", ci+1, len(chunks))
+			}
+			offset := ci * cfg.ChunkSize
+
+			for i := 0; i < len(chunk) && stopped == ""; i += cfg.Parallelism {
+				n := cfg.Parallelism
+				if i+cfg.Parallelism > len(chunk) {
+					n = len(chunk) - i
+				}
+
+				var importList []*meta.ImportItem
+				messages := []string{i18n.T("Importing resources...") + chunkMsg}
+
+				for j := 0; j < n; j++ {
+					idx := i + j
+					if !chunk[idx].Skip() && !chunk[idx].Imported {
+						if stopped = intr.reason(); stopped == "" {
+							stopped = budget.take(time.Now())
+						}
+						if stopped != "" {
+							// The rest of the batch is not attempted.
+							n = j
+							break
+						}
+					}
+					switch {
+					case chunk[idx].Skip():
+						messages = append(messages, i18n.Sprintf("(%d/%d) Skipping %s", offset+idx+1, len(list), chunk[idx].TFResourceId))
+					case chunk[idx].Imported:
+						messages = append(messages, i18n.Sprintf("(%d/%d) Resuming %s as %s", offset+idx+1, len(list), chunk[idx].TFResourceId, chunk[idx].TFAddr))
+						continue
+					default:
+						messages = append(messages, i18n.Sprintf("(%d/%d) Importing %s as %s", offset+idx+1, len(list), chunk[idx].TFResourceId, chunk[idx].TFAddr))
+					}
+					importList = append(importList, &chunk[idx])
+				}
+
+				msg.SetStatus(strings.Join(messages, "\n"))
+				if err := importItems(importList); err != nil {
+					return err
+				}
+
+				var failed []*meta.ImportItem
+				for j := 0; j < n; j++ {
+					idx := i + j
+					if err := chunk[idx].ImportError; err != nil {
+						// The transient failures are retried at the end of the run, instead of failing the run.
+						if transientFailureClasses[ClassifyImportError(err)] {
+							retryQueue = append(retryQueue, &chunk[idx])
+							continue
+						}
+						failed = append(failed, &chunk[idx])
+					}
+				}
+				thisErrors := importFailures(failed)
+				if len(thisErrors) != 0 {
+					errors = append(errors, thisErrors...)
+					if !cfg.ContinueOnError {
+						return fmt.Errorf(strings.Join(thisErrors, "\n"))
+					}
 				}
-				importList = append(importList, &list[idx])
 			}
 
-			msg.SetStatus(strings.Join(messages, "\n"))
-			if err := c.ParallelImport(ctx, importList); err != nil {
-				return fmt.Errorf("parallel importing: %v", err)
+			// Each chunk is checkpointed by pushing the state and generating the config, so that a failure in later chunks doesn't affect the exported ones.
+			if err := exportImported(chunk, chunkMsg); err != nil {
+				return err
+			}
+			if stopped != "" {
+				break
 			}
+		}
+		// The run interrupted after the last import skips the retries, as well as the optional steps below.
+		if stopped == "" {
+			stopped = intr.reason()
+		}
 
-			var thisErrors []string
-			for j := 0; j < n; j++ {
-				idx := i + j
-				item := list[idx]
-				if err := item.ImportError; err != nil {
-					msg := fmt.Sprintf("Failed to import %s as %s: %v", item.TFResourceId, item.TFAddr, err)
-					thisErrors = append(thisErrors, msg)
+		// The transient failures are retried once after a delay, the ones still failing are then handled as the other failures.
+		if len(retryQueue) != 0 && stopped == "" {
+			msg.SetStatus(i18n.Sprintf("Retrying %d resource(s) that failed transiently...", len(retryQueue)))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(transientRetryDelay):
+			}
+			for i := 0; i < len(retryQueue); i += cfg.Parallelism {
+				end := i + cfg.Parallelism
+				if end > len(retryQueue) {
+					end = len(retryQueue)
+				}
+				if err := importItems(retryQueue[i:end]); err != nil {
+					return err
 				}
 			}
-			if len(thisErrors) != 0 {
+
+			if thisErrors := importFailures(retryQueue); len(thisErrors) != 0 {
 				errors = append(errors, thisErrors...)
 				if !cfg.ContinueOnError {
 					return fmt.Errorf(strings.Join(thisErrors, "\n"))
 				}
 			}
+			if err := exportImported(list, ""); err != nil {
+				return err
+			}
+		} else if len(retryQueue) != 0 {
+			// The run stopped skips the retries, the transient failures are reported as the other failures, which are retried by `--resume`.
+			// The run is not failed on them regardless of `--continue`, so that the remaining resources are still reported.
+			errors = append(errors, importFailures(retryQueue)...)
+		}
+
+		s := newRunSummary(list)
+		summary = &s
+
+		msg.SetStatus(i18n.T("Cleaning up..."))
+		endPhase = timer.Start("clean_up")
+		err = c.CleanUpWorkspace(ctx)
+		endPhase()
+		if err != nil {
+			return fmt.Errorf("cleaning up main workspace: %v", err)
 		}
 
-		if err := c.PushState(ctx); err != nil {
-			return fmt.Errorf("failed to push state: %v", err)
+		if cfg.PulumiLanguage != "" && intr.reason() == "" {
+			msg.SetStatus(i18n.T("Converting to Pulumi program..."))
+			if _, err := pulumi.Convert(ctx, c.Workspace(), cfg.PulumiLanguage); err != nil {
+				return fmt.Errorf("converting to Pulumi program: %v", err)
+			}
 		}
 
-		msg.SetStatus("Generating Terraform configurations...")
-		if err := c.GenerateCfg(ctx, list); err != nil {
-			return fmt.Errorf("generating Terraform configuration: %v", err)
+		if cfg.CostEstimate && intr.reason() == "" {
+			msg.SetStatus(i18n.T("Estimating cost..."))
+			endPhase := timer.Start("cost_estimate")
+			estimate, err = costestimate.Run(ctx, c.Workspace())
+			endPhase()
+			// The cost estimate is informational, e.g. infracost might be missing, which doesn't fail the export.
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("estimating cost: %v", err))
+			}
 		}
 
-		msg.SetStatus("Cleaning up...")
-		if err := c.CleanUpWorkspace(ctx); err != nil {
-			return fmt.Errorf("cleaning up main workspace: %v", err)
+		if cfg.Verify && intr.reason() == "" {
+			msg.SetStatus(i18n.T("Verifying the exported configuration..."))
+			endPhase := timer.Start("verify")
+			report, err = verify.Run(ctx, c.Workspace())
+			endPhase()
+			if err != nil {
+				return fmt.Errorf("verifying: %v", err)
+			}
 		}
 
 		return nil
 	}
 
 	var err error
-	if cfg.PlainUI {
+	switch {
+	case events != nil:
+		err = f(eventMessager{w: events})
+	case cfg.PlainUI:
 		err = f(NewStdoutMessager())
-	} else {
-		s := bspinner.NewModel()
-		s.Spinner = common.Spinner
-		sf := func(msg spinner.Messager) error {
-			return f(&msg)
+	default:
+		// The notices of the interrupt are rendered by the spinner.
+		intr.setOutput(nil)
+		err = runSpinner(f, intr)
+	}
+	// The run force stopped fails with the cancelled context, the checkpoint still records the resources exported before.
+	if err != nil && ctx.Err() != nil && deinitCtx.Err() == nil {
+		err = fmt.Errorf("force stopped on interrupt, run with `--resume` to continue: %v", err)
+	}
+
+	// The report is written even if the run fails, as long as the resources are listed, which records how far the run went.
+	if list != nil && dryRun == nil && !cfg.MockMeta {
+		r := newReport(list, timer, err)
+		r.ToolVersion = cfg.ToolVersion
+		r.ProviderName = c.ProviderNames()[0]
+		r.ProviderVersion = c.ProviderVersion()
+		r.Workspace = cfg.Workspace
+		r.Warnings = append(r.Warnings, warnings...)
+		tracePhases(cfg.TelemetryClient, r)
+		if rerr := writeReport(cfg.OutputDir, r, cfg.ReportMarkdown); rerr != nil {
+			if err == nil {
+				return rerr
+			}
+			fmt.Fprintln(os.Stderr, rerr)
 		}
-		err = spinner.Run(s, sf)
 	}
 
 	if err != nil {
 		return err
 	}
 
+	if dryRun != nil {
+		return writeDryRunResult(out, cfg.DryRunOutput, *dryRun)
+	}
+
+	// The summary is written after the workspace is cleaned up, which would otherwise be removed in the HCL only mode.
+	if summary != nil && !cfg.MockMeta {
+		if err := writeRunSummary(cfg.OutputDir, *summary); err != nil {
+			return err
+		}
+		if err := writeFailedResources(cfg.OutputDir, *summary); err != nil {
+			return err
+		}
+		if len(summary.Failed) != 0 {
+			fmt.Fprintln(out, i18n.Sprintf("%d resource(s) failed to import, see %s, which can be used as the mapping file of a follow-up run", len(summary.Failed), filepath.Join(cfg.OutputDir, FailedResourcesFileName)))
+		}
+	}
+
+	if report != nil {
+		if err := report.Write(cfg.OutputDir); err != nil {
+			return err
+		}
+	}
+
 	// Print out the errors, if any
 	if len(errors) != 0 {
-		fmt.Fprintln(os.Stderr, "Errors:\n"+strings.Join(errors, "\n"))
+		fmt.Fprintln(os.Stderr, i18n.T("Errors:")+"\n"+strings.Join(errors, "\n"))
 	}
 
-	return nil
+	// The run that only generates the mapping file has nothing to import.
+	var partialErr error
+	if !cfg.GenMappingFileOnly {
+		problems := resourceProblems(list)
+		if stopped != "" {
+			remaining := remainingItems(list)
+			for _, item := range remaining {
+				problems = append(problems, Problem{
+					Kind:            ProblemKindRemaining,
+					AzureResourceId: item.AzureResourceID.String(),
+					TFAddress:       item.TFAddr.String(),
+					Message:         "not attempted as " + stopped,
+				})
+			}
+			fmt.Fprintln(out, i18n.Sprintf("Stopped importing as %s, %d resource(s) remaining, run with `--resume` to continue", stopped, len(remaining)))
+		}
+		if len(problems) != 0 {
+			partialErr = &PartialSuccessError{Problems: problems}
+		}
+	}
+
+	if len(locked) != 0 {
+		var lines []string
+		for _, item := range locked {
+			line := fmt.Sprintf("%s (%s)", item.TFResourceId, item.Lock)
+			if item.Skip() {
+				line += " (" + i18n.T("Skipped") + ")"
+			}
+			lines = append(lines, line)
+		}
+		fmt.Fprintln(out, i18n.T("Resources under management locks:")+"\n"+strings.Join(lines, "\n"))
+	}
+
+	if softDeleted := list.SoftDeleted(); len(softDeleted) != 0 {
+		var lines []string
+		for _, item := range softDeleted {
+			line := fmt.Sprintf("%s (%s)", item.TFResourceId, item.SoftDeleted)
+			if item.Skip() {
+				line += " (" + i18n.T("Skipped") + ")"
+			}
+			lines = append(lines, line)
+		}
+		fmt.Fprintln(out, i18n.T("Resources colliding with soft-deleted resources:")+"\n"+strings.Join(lines, "\n"))
+	}
+
+	if estimate != nil {
+		fmt.Fprintln(out, i18n.T("Cost estimate:")+"\n"+estimate.String())
+	}
+
+	if len(warnings) != 0 {
+		fmt.Fprintln(os.Stderr, i18n.T("Warnings:")+"\n"+strings.Join(warnings, "\n"))
+	}
+
+	if report != nil {
+		fmt.Fprintln(out, i18n.Sprintf("Verification: %d resource(s) with non-empty plan, see %s", len(report.Resources), filepath.Join(cfg.OutputDir, verify.ReportFileName)))
+	}
+
+	return partialErr
+}
+
+// chunkList splits the list into chunks of the specified size. The whole list is returned as the only chunk if size is not positive.
+// The chunks share the underlying array with the list, so that the import results are reflected to the list.
+func chunkList(list meta.ImportList, size int) []meta.ImportList {
+	if size <= 0 || size >= len(list) {
+		return []meta.ImportList{list}
+	}
+	var chunks []meta.ImportList
+	for i := 0; i < len(list); i += size {
+		end := i + size
+		if end > len(list) {
+			end = len(list)
+		}
+		chunks = append(chunks, list[i:end])
+	}
+	return chunks
+}
+
+// importFailures returns the error messages of the items that failed to import.
+func importFailures(l []*meta.ImportItem) []string {
+	var out []string
+	for _, item := range l {
+		if err := item.ImportError; err != nil {
+			out = append(out, i18n.Sprintf("Failed to import %s as %s: %v", item.TFResourceId, item.TFAddr, err))
+		}
+	}
+	return out
+}
+
+// childrenInOwnProcessGroup tells whether the processes doing the imports are started in their own process group, which don't receive the Ctrl-C from the terminal.
+// The terraform-exec only does so for the terraform on Linux, while the tfclient provider is always (see utils.SetProcessGroup).
+func childrenInOwnProcessGroup(tfclient bool) bool {
+	return tfclient || runtime.GOOS == "linux"
 }
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkList(t *testing.T) {
+	var list meta.ImportList
+	for _, name := range []string{"res-0", "res-1", "res-2", "res-3", "res-4"} {
+		list = append(list, meta.ImportItem{TFAddr: tfaddr.TFAddr{Name: name}})
+	}
+
+	require.Equal(t, []meta.ImportList{list}, chunkList(list, 0))
+	require.Equal(t, []meta.ImportList{list}, chunkList(list, 5))
+	chunks := chunkList(list, 2)
+	require.Equal(t, []meta.ImportList{list[0:2], list[2:4], list[4:5]}, chunks)
+
+	// The chunks share the items with the list
+	chunks[1][0].Imported = true
+	require.True(t, list[2].Imported)
+}
+
+func TestImportFailures(t *testing.T) {
+	imported := meta.ImportItem{TFResourceId: "/subscriptions/123/resourceGroups/rg1", TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}}
+	failed := meta.ImportItem{TFResourceId: "/subscriptions/123/resourceGroups/rg2", TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-1"}, ImportError: fmt.Errorf("boom")}
+
+	require.Empty(t, importFailures([]*meta.ImportItem{&imported}))
+	require.Equal(t, []string{"Failed to import /subscriptions/123/resourceGroups/rg2 as azurerm_resource_group.res-1: boom"}, importFailures([]*meta.ImportItem{&imported, &failed}))
+}
@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/pkg/meta"
+)
+
+// SummaryFileName is the file under the output directory that records the result of the last non-interactive run.
+const SummaryFileName = "aztfexportSummary.json"
+
+// FailedResourcesFileName is the file under the output directory that records the resources failed to import by the last non-interactive run,
+// after the transient failures are retried. It is in the format of the resource mapping file, which can be fed back via the mapping file mode.
+const FailedResourcesFileName = "failed-resources.json"
+
+type FailedResource struct {
+	resmap.ResourceMapEntity
+	Error string `json:"error"`
+	// Class is the failure class of the error, e.g. FailureClassResourceGone.
+	Class string `json:"class"`
+}
+
+type RunSummary struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+	// Failed are the resources that failed to import, the key is the Azure resource Id in uppercase.
+	Failed map[string]FailedResource `json:"failed"`
+}
+
+func newRunSummary(l meta.ImportList) RunSummary {
+	summary := RunSummary{
+		Imported: len(l.Imported()),
+		Skipped:  len(l.Skipped()),
+		Failed:   map[string]FailedResource{},
+	}
+	for _, item := range l {
+		if item.ImportError == nil {
+			continue
+		}
+		summary.Failed[strings.ToUpper(item.AzureResourceID.String())] = FailedResource{
+			ResourceMapEntity: resmap.ResourceMapEntity{
+				ResourceId:   item.TFResourceId,
+				ResourceType: item.TFAddr.Type,
+				ResourceName: item.TFAddr.Name,
+			},
+			Error: item.ImportError.Error(),
+			Class: ClassifyImportError(item.ImportError),
+		}
+	}
+	return summary
+}
+
+func writeRunSummary(dir string, summary RunSummary) error {
+	b, err := json.MarshalIndent(summary, "", "\t")
+	if err != nil {
+		return fmt.Errorf("marshalling the run summary: %v", err)
+	}
+	path := filepath.Join(dir, SummaryFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing the run summary to %s: %v", path, err)
+	}
+	return nil
+}
+
+// writeFailedResources writes the failed resources of the summary to the FailedResourcesFileName, which is removed if there is none.
+func writeFailedResources(dir string, summary RunSummary) error {
+	path := filepath.Join(dir, FailedResourcesFileName)
+	if len(summary.Failed) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %v", path, err)
+		}
+		return nil
+	}
+	b, err := json.MarshalIndent(summary.Failed, "", "\t")
+	if err != nil {
+		return fmt.Errorf("marshalling the failed resources: %v", err)
+	}
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing the failed resources to %s: %v", path, err)
+	}
+	return nil
+}
+
+// ReadRunSummary reads the run summary file.
+func ReadRunSummary(path string) (*RunSummary, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the run summary %s: %v", path, err)
+	}
+	var summary RunSummary
+	if err := json.Unmarshal(b, &summary); err != nil {
+		return nil, fmt.Errorf("unmarshalling the run summary %s: %v", path, err)
+	}
+	return &summary, nil
+}
+
+// FailedResourceMapping returns the resource mapping of the failed resources, which can be used to re-attempt them via the mapping file mode.
+func (summary RunSummary) FailedResourceMapping() resmap.ResourceMapping {
+	m := resmap.ResourceMapping{}
+	for id, res := range summary.Failed {
+		m[id] = res.ResourceMapEntity
+	}
+	return m
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSummary(t *testing.T) {
+	item := func(id, tfType, name string) meta.ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return meta.ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: tfaddr.TFAddr{Type: tfType, Name: name}}
+	}
+	imported := item("/subscriptions/123/resourceGroups/rg1", "azurerm_resource_group", "res-0")
+	imported.Imported = true
+	failed := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Network/virtualNetworks/vnet1", "azurerm_virtual_network", "res-1")
+	failed.ImportError = fmt.Errorf("boom")
+	skipped := item("/subscriptions/123/resourceGroups/rg1/providers/Microsoft.Foo/bars/bar1", "", "res-2")
+
+	dir := t.TempDir()
+	require.NoError(t, writeRunSummary(dir, newRunSummary(meta.ImportList{imported, failed, skipped})))
+	summary, err := ReadRunSummary(filepath.Join(dir, SummaryFileName))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, summary.Imported)
+	require.Equal(t, 1, summary.Skipped)
+	require.Equal(t, map[string]FailedResource{
+		"/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG1/PROVIDERS/MICROSOFT.NETWORK/VIRTUALNETWORKS/VNET1": {
+			ResourceMapEntity: resmap.ResourceMapEntity{
+				ResourceId:   failed.TFResourceId,
+				ResourceType: "azurerm_virtual_network",
+				ResourceName: "res-1",
+			},
+			Error: "boom",
+			Class: FailureClassUnknown,
+		},
+	}, summary.Failed)
+	require.Equal(t, resmap.ResourceMapping{
+		"/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG1/PROVIDERS/MICROSOFT.NETWORK/VIRTUALNETWORKS/VNET1": {
+			ResourceId:   failed.TFResourceId,
+			ResourceType: "azurerm_virtual_network",
+			ResourceName: "res-1",
+		},
+	}, summary.FailedResourceMapping())
+}
+
+func TestWriteFailedResources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FailedResourcesFileName)
+	summary := RunSummary{
+		Failed: map[string]FailedResource{
+			"/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG1": {
+				ResourceMapEntity: resmap.ResourceMapEntity{
+					ResourceId:   "/subscriptions/123/resourceGroups/rg1",
+					ResourceType: "azurerm_resource_group",
+					ResourceName: "res-0",
+				},
+				Error: "ResourceGroupNotFound",
+				Class: FailureClassResourceGone,
+			},
+		},
+	}
+	require.NoError(t, writeFailedResources(dir, summary))
+
+	// The file is a valid resource mapping file
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var m resmap.ResourceMapping
+	require.NoError(t, json.Unmarshal(b, &m))
+	require.Equal(t, summary.FailedResourceMapping(), m)
+
+	// The stale file is removed if there is no failed resource
+	require.NoError(t, writeFailedResources(dir, RunSummary{}))
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
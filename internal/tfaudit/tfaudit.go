@@ -0,0 +1,216 @@
+// Package tfaudit records the terraform (or tofu) commands executed into an audit log, in JSON lines.
+//
+// The commands are captured from the terraform instances attached to the log, whose logger reports the command line of each command to run.
+// A command is finished (i.e. recorded) once the function running it via Run returns, or once the next command starts on the same instance.
+package tfaudit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+)
+
+// maxOutputLen is the max length of the stdout and stderr recorded for each command, the rest is truncated.
+const maxOutputLen = 4096
+
+// commandLogPrefix is the prefix of the log of tfexec, before running a command.
+const commandLogPrefix = "[INFO] running Terraform command: "
+
+// Record is the record of a command.
+type Record struct {
+	Time time.Time `json:"time"`
+	// Command is the command line, including the path of the terraform executable
+	Command string `json:"command"`
+	// Dir is the working directory
+	Dir        string `json:"dir"`
+	DurationMs int64  `json:"duration_ms"`
+	// ExitCode is -1 if the command isn't exited normally, e.g. killed by the cancellation
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type command struct {
+	record Record
+	stdout truncatedBuffer
+	stderr truncatedBuffer
+}
+
+// Log is the audit log. A nil Log records nothing.
+type Log struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+	// show echos the commands once they start, if not nil
+	show io.Writer
+	// running is the command running on each terraform instance
+	running map[*tfexec.Terraform]*command
+}
+
+// Open opens the audit log at path, where the records are appended to. The commands are also echoed to show, if not nil.
+func Open(path string, show io.Writer) (*Log, error) {
+	// #nosec G304
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening the audit log %s: %v", path, err)
+	}
+	return newLog(f, show), nil
+}
+
+func newLog(w io.WriteCloser, show io.Writer) *Log {
+	return &Log{
+		w:       w,
+		show:    show,
+		running: map[*tfexec.Terraform]*command{},
+	}
+}
+
+// Attach sets the logger and the stdout/stderr of the terraform instance, so that its commands are recorded.
+func (l *Log) Attach(tf *tfexec.Terraform) {
+	if l == nil {
+		return
+	}
+	tf.SetLogger(logger{l: l, tf: tf})
+	tf.SetStdout(outputWriter{l: l, tf: tf})
+	tf.SetStderr(outputWriter{l: l, tf: tf, stderr: true})
+}
+
+// Run runs the terraform command(s) via fn on the attached terraform instance, and records them once fn returns.
+func (l *Log) Run(tf *tfexec.Terraform, fn func() error) error {
+	if l == nil {
+		return fn()
+	}
+	err := fn()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.finish(tf, err)
+	return err
+}
+
+// Close records the commands still running, and closes the log.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for tf := range l.running {
+		l.finish(tf, nil)
+	}
+	return l.w.Close()
+}
+
+func (l *Log) start(tf *tfexec.Terraform, cmdline string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// The former command has succeeded, otherwise the next one won't be run by the same call of tfexec.
+	l.finish(tf, nil)
+	l.running[tf] = &command{
+		record: Record{
+			Time:    time.Now(),
+			Command: cmdline,
+			Dir:     tf.WorkingDir(),
+		},
+	}
+	if l.show != nil {
+		fmt.Fprintf(l.show, "+ (%s) %s\n", tf.WorkingDir(), cmdline)
+	}
+}
+
+// finish records the command running on the terraform instance, if any. It must be called with the lock held.
+func (l *Log) finish(tf *tfexec.Terraform, err error) {
+	cmd, ok := l.running[tf]
+	if !ok {
+		return
+	}
+	delete(l.running, tf)
+
+	record := cmd.record
+	record.DurationMs = time.Since(record.Time).Milliseconds()
+	record.Stdout = cmd.stdout.String()
+	record.Stderr = cmd.stderr.String()
+	if err != nil {
+		record.Error = err.Error()
+		record.ExitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			record.ExitCode = exitErr.ExitCode()
+		}
+	}
+	b, merr := json.Marshal(record)
+	if merr != nil {
+		return
+	}
+	// #nosec G104
+	l.w.Write(append(b, '\n'))
+}
+
+func (l *Log) write(tf *tfexec.Terraform, p []byte, stderr bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cmd, ok := l.running[tf]
+	if !ok {
+		return
+	}
+	if stderr {
+		cmd.stderr.Write(p)
+	} else {
+		cmd.stdout.Write(p)
+	}
+}
+
+// logger implements the logger of tfexec, which starts a command once it is to run.
+type logger struct {
+	l  *Log
+	tf *tfexec.Terraform
+}
+
+func (lg logger) Printf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	if strings.HasPrefix(msg, commandLogPrefix) {
+		lg.l.start(lg.tf, strings.TrimPrefix(msg, commandLogPrefix))
+	}
+}
+
+type outputWriter struct {
+	l      *Log
+	tf     *tfexec.Terraform
+	stderr bool
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.l.write(w.tf, p, w.stderr)
+	return len(p), nil
+}
+
+// truncatedBuffer keeps the first maxOutputLen bytes written, and counts the rest.
+type truncatedBuffer struct {
+	buf       []byte
+	truncated int
+}
+
+func (b *truncatedBuffer) Write(p []byte) {
+	n := maxOutputLen - len(b.buf)
+	if n > len(p) {
+		n = len(p)
+	}
+	if n > 0 {
+		b.buf = append(b.buf, p[:n]...)
+	}
+	b.truncated += len(p) - n
+}
+
+func (b *truncatedBuffer) String() string {
+	if b.truncated == 0 {
+		return string(b.buf)
+	}
+	return fmt.Sprintf("%s... (%d bytes truncated)", b.buf, b.truncated)
+}
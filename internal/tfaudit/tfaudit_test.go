@@ -0,0 +1,82 @@
+package tfaudit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	"github.com/stretchr/testify/require"
+)
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func readRecords(t *testing.T, b []byte) []Record {
+	var records []Record
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var record Record
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLog(t *testing.T) {
+	dir := t.TempDir()
+	tf, err := tfexec.NewTerraform(dir, "terraform")
+	require.NoError(t, err)
+
+	buf := bytes.NewBuffer(nil)
+	show := bytes.NewBuffer(nil)
+	l := newLog(nopCloser{buf}, show)
+	lg := logger{l: l, tf: tf}
+	stdout := outputWriter{l: l, tf: tf}
+	stderr := outputWriter{l: l, tf: tf, stderr: true}
+
+	// Two commands are run by a single call, where the first one succeeds.
+	exitErr := exec.Command("sh", "-c", "exit 3").Run()
+	require.Error(t, l.Run(tf, func() error {
+		lg.Printf("[INFO] running Terraform command: %s", "terraform version -json")
+		// #nosec G104
+		stdout.Write([]byte(`{"terraform_version": "1.5.0"}`))
+		lg.Printf("[INFO] running Terraform command: %s", "terraform import foo.bar id")
+		// #nosec G104
+		stderr.Write([]byte(strings.Repeat("x", maxOutputLen+10)))
+		return exitErr
+	}))
+	// No command is run.
+	require.NoError(t, l.Run(tf, func() error { return nil }))
+	require.NoError(t, l.Close())
+
+	records := readRecords(t, buf.Bytes())
+	require.Len(t, records, 2)
+	require.Equal(t, "terraform version -json", records[0].Command)
+	require.Equal(t, dir, records[0].Dir)
+	require.Equal(t, 0, records[0].ExitCode)
+	require.Equal(t, `{"terraform_version": "1.5.0"}`, records[0].Stdout)
+	require.Equal(t, "terraform import foo.bar id", records[1].Command)
+	require.Equal(t, 3, records[1].ExitCode)
+	require.Equal(t, "exit status 3", records[1].Error)
+	require.Equal(t, strings.Repeat("x", maxOutputLen)+"... (10 bytes truncated)", records[1].Stderr)
+
+	require.Equal(t, "+ ("+dir+") terraform version -json\n+ ("+dir+") terraform import foo.bar id\n", show.String())
+}
+
+func TestNilLog(t *testing.T) {
+	var l *Log
+	called := false
+	require.NoError(t, l.Run(nil, func() error {
+		called = true
+		return nil
+	}))
+	require.True(t, called)
+	require.NoError(t, l.Close())
+}
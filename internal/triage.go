@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"strings"
+	"time"
+)
+
+// The classes of the import failures, which tell whether the failure is worth retrying, and what to do with the rest.
+const (
+	// FailureClassAuth is the failure to authenticate, or the lack of the permissions.
+	FailureClassAuth = "auth"
+	// FailureClassThrottling is the throttled request, or the other transient failures of the service and the network (e.g. 503, timeouts).
+	FailureClassThrottling = "throttling"
+	// FailureClassUnsupportedId is the resource id that the provider fails to parse, which is usually a mapping issue.
+	FailureClassUnsupportedId = "unsupported_id"
+	// FailureClassProviderBug is the crash or the inconsistent result of the provider.
+	FailureClassProviderBug = "provider_bug"
+	// FailureClassResourceGone is the resource that no longer exists, e.g. deleted after it is listed.
+	FailureClassResourceGone = "resource_gone"
+	// FailureClassUnknown is the failure that matches none of the above.
+	FailureClassUnknown = "unknown"
+)
+
+// transientFailureClasses are the failure classes that are retried at the end of the run. The auth failures are included, as they are
+// often due to the expired tokens, or the role assignments that are not propagated yet.
+var transientFailureClasses = map[string]bool{
+	FailureClassAuth:       true,
+	FailureClassThrottling: true,
+}
+
+// transientRetryDelay is the delay before the transient failures are retried, which gives the throttling a chance to recover.
+var transientRetryDelay = 30 * time.Second
+
+// failureClassPatterns are the (lower case) snippets of the errors of each failure class, which are matched in order.
+var failureClassPatterns = []struct {
+	class    string
+	patterns []string
+}{
+	{
+		class: FailureClassProviderBug,
+		patterns: []string{
+			"panic:",
+			"plugin did not respond",
+			"the plugin encountered an error",
+			"provider produced inconsistent",
+			"provider produced invalid",
+			"this is a bug in the provider",
+		},
+	},
+	{
+		class: FailureClassResourceGone,
+		patterns: []string{
+			"cannot import non-existent remote object",
+			"resourcenotfound",
+			"resourcegroupnotfound",
+			"statuscode=404",
+			"status code 404",
+			"response 404:",
+			"404 not found",
+		},
+	},
+	{
+		class: FailureClassAuth,
+		patterns: []string{
+			"authorizationfailed",
+			"authenticationfailed",
+			"invalidauthenticationtoken",
+			"expiredauthenticationtoken",
+			"does not have authorization",
+			"aadsts",
+			"statuscode=401",
+			"response 401:",
+			"statuscode=403",
+			"response 403:",
+			"403 forbidden",
+		},
+	},
+	{
+		class: FailureClassThrottling,
+		patterns: []string{
+			"statuscode=429",
+			"response 429:",
+			"toomanyrequests",
+			"throttl",
+			"statuscode=502",
+			"statuscode=503",
+			"response 503:",
+			"statuscode=504",
+			"serviceunavailable",
+			"gatewaytimeout",
+			"context deadline exceeded",
+			"importing timed out",
+			"connection reset by peer",
+			"i/o timeout",
+			"tls handshake timeout",
+		},
+	},
+	{
+		class: FailureClassUnsupportedId,
+		patterns: []string{
+			"parsing azure id",
+			"parsing segment",
+			"parsing id",
+			"id was missing the",
+			"unexpected format for id",
+			"the number of segments didn't match",
+			"invalid id",
+		},
+	},
+}
+
+// ClassifyImportError returns the failure class of the import error, which is FailureClassUnknown if none matches.
+func ClassifyImportError(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := strings.ToLower(err.Error())
+	for _, c := range failureClassPatterns {
+		for _, p := range c.patterns {
+			if strings.Contains(msg, p) {
+				return c.class
+			}
+		}
+	}
+	return FailureClassUnknown
+}
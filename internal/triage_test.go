@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyImportError(t *testing.T) {
+	cases := []struct {
+		err   string
+		class string
+	}{
+		{
+			err:   `Error: Cannot import non-existent remote object`,
+			class: FailureClassResourceGone,
+		},
+		{
+			err:   `retrieving Virtual Network: unexpected status 404 with error: ResourceNotFound: The Resource was not found.`,
+			class: FailureClassResourceGone,
+		},
+		{
+			err:   `retrieving Storage Account: StatusCode=403 -- Original Error: Code="AuthorizationFailed"`,
+			class: FailureClassAuth,
+		},
+		{
+			err:   `retrieving Key Vault: StatusCode=429 -- Original Error: Code="TooManyRequests"`,
+			class: FailureClassThrottling,
+		},
+		{
+			err:   `importing timed out after 5m0s: context deadline exceeded`,
+			class: FailureClassThrottling,
+		},
+		{
+			err:   `parsing "/subscriptions/123/resourceGroups/rg1/foo": parsing segment "staticResourceGroups": parsing the ResourceGroup ID`,
+			class: FailureClassUnsupportedId,
+		},
+		{
+			err:   `Error: Plugin did not respond ... panic: runtime error: invalid memory address or nil pointer dereference`,
+			class: FailureClassProviderBug,
+		},
+		{
+			err:   `boom`,
+			class: FailureClassUnknown,
+		},
+	}
+	for _, tt := range cases {
+		require.Equal(t, tt.class, ClassifyImportError(errors.New(tt.err)), tt.err)
+	}
+	require.Equal(t, "", ClassifyImportError(nil))
+}
@@ -0,0 +1,69 @@
+package typeoverride
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/magodo/armid"
+)
+
+// Rule forces the matched Azure resources to be exported as the specified TF resource type.
+// Exactly one of AzureType and IdPattern is expected to be set.
+type Rule struct {
+	// The Azure resource type (e.g. "Microsoft.Compute/virtualMachines"), which is matched case insensitively.
+	AzureType string `json:"azure_type,omitempty"`
+	// The regexp pattern of the Azure resource id, which is matched case insensitively against the whole id.
+	IdPattern string `json:"id_pattern,omitempty"`
+	// The TF resource type (e.g. "azurerm_linux_virtual_machine")
+	ResourceType string `json:"resource_type"`
+
+	idRegexp *regexp.Regexp
+}
+
+// Overrides is an ordered list of override rules, the first matched rule wins.
+type Overrides []Rule
+
+// Load loads the type overrides file, which is a JSON array of Rule.
+func Load(path string) (Overrides, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the type overrides file %s: %v", path, err)
+	}
+	var overrides Overrides
+	if err := json.Unmarshal(b, &overrides); err != nil {
+		return nil, fmt.Errorf("unmarshalling the type overrides file %s: %v", path, err)
+	}
+	for i, rule := range overrides {
+		if rule.ResourceType == "" {
+			return nil, fmt.Errorf("the %d-th rule has no resource_type specified", i)
+		}
+		if (rule.AzureType == "") == (rule.IdPattern == "") {
+			return nil, fmt.Errorf("the %d-th rule must specify exactly one of azure_type and id_pattern", i)
+		}
+		if rule.IdPattern != "" {
+			p, err := regexp.Compile("(?i)^" + rule.IdPattern + "$")
+			if err != nil {
+				return nil, fmt.Errorf("compiling the id_pattern of the %d-th rule: %v", i, err)
+			}
+			overrides[i].idRegexp = p
+		}
+	}
+	return overrides, nil
+}
+
+// Match returns the TF resource type of the first rule that matches the Azure resource id.
+func (overrides Overrides) Match(id armid.ResourceId) (string, bool) {
+	for _, rule := range overrides {
+		if rule.AzureType != "" && strings.EqualFold(rule.AzureType, id.TypeString()) {
+			return rule.ResourceType, true
+		}
+		if rule.idRegexp != nil && rule.idRegexp.MatchString(id.String()) {
+			return rule.ResourceType, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,54 @@
+package typeoverride
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+  {"id_pattern": "/subscriptions/.+/resourceGroups/.+/providers/Microsoft.Compute/virtualMachines/win-.+", "resource_type": "azurerm_windows_virtual_machine"},
+  {"azure_type": "microsoft.compute/virtualmachines", "resource_type": "azurerm_linux_virtual_machine"}
+]`), 0644))
+	overrides, err := Load(path)
+	require.NoError(t, err)
+
+	cases := []struct {
+		id     string
+		rt     string
+		result bool
+	}{
+		{
+			id:     "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/win-vm",
+			rt:     "azurerm_windows_virtual_machine",
+			result: true,
+		},
+		{
+			id:     "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm",
+			rt:     "azurerm_linux_virtual_machine",
+			result: true,
+		},
+		{
+			id: "/subscriptions/123/resourceGroups/rg",
+		},
+	}
+	for _, tt := range cases {
+		id, err := armid.ParseResourceId(tt.id)
+		require.NoError(t, err)
+		rt, ok := overrides.Match(id)
+		require.Equal(t, tt.result, ok, tt.id)
+		require.Equal(t, tt.rt, rt, tt.id)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"azure_type": "Microsoft.Compute/virtualMachines", "id_pattern": ".*", "resource_type": "azurerm_linux_virtual_machine"}]`), 0644))
+	_, err := Load(path)
+	require.EqualError(t, err, "the 0-th rule must specify exactly one of azure_type and id_pattern")
+}
@@ -1,8 +1,14 @@
 package internal
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"sync"
+
+	"github.com/Azure/aztfexport/internal/ui/common"
+	bspinner "github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Abstract the Messager struct in the github.com/magodo/spinner
@@ -28,3 +34,118 @@ func (p *stdoutMessager) SetStatus(msg string) {
 func (p *stdoutMessager) SetDetail(msg string) {
 	p.Println(msg)
 }
+
+// spinnerMessager holds the status rendered by the spinner. Once the spinner exits, the messages are printed to the stdout instead.
+type spinnerMessager struct {
+	mu     sync.Mutex
+	status string
+	detail string
+	exited bool
+	stdout Messager
+}
+
+func (m *spinnerMessager) SetStatus(msg string) {
+	m.mu.Lock()
+	if m.exited {
+		m.mu.Unlock()
+		m.stdout.SetStatus(msg)
+		return
+	}
+	m.status = msg
+	m.mu.Unlock()
+}
+
+func (m *spinnerMessager) SetDetail(msg string) {
+	m.mu.Lock()
+	if m.exited {
+		m.mu.Unlock()
+		m.stdout.SetDetail(msg)
+		return
+	}
+	m.detail = msg
+	m.mu.Unlock()
+}
+
+func (m *spinnerMessager) exit() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exited = true
+}
+
+func (m *spinnerMessager) view() (status, detail string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status, m.detail
+}
+
+type spinnerModel struct {
+	spinner bspinner.Model
+	msg     *spinnerMessager
+	intr    *interrupter
+	done    <-chan struct{}
+}
+
+func (m spinnerModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m spinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	select {
+	case <-m.done:
+		return m, tea.Quit
+	default:
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// The ctrl+c is received as a key in the raw mode, which interrupts the run instead of quitting the spinner.
+		if msg.String() == "ctrl+c" {
+			m.intr.interrupt()
+		}
+		return m, nil
+	default:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+}
+
+func (m spinnerModel) View() string {
+	select {
+	case <-m.done:
+		return ""
+	default:
+	}
+	status, detail := m.msg.view()
+	output := fmt.Sprintf("%s %s\n", m.spinner.View(), status)
+	if detail != "" {
+		output += "\n" + detail
+	}
+	if notice := m.intr.notice(); notice != "" {
+		output += "\n" + notice + "\n"
+	}
+	return output
+}
+
+// runSpinner runs f with the spinner rendering its status, until f returns.
+// The spinner can exit early (e.g. on the SIGINT without a TTY), in which case f is still waited, with its status printed to the stdout.
+func runSpinner(f func(msg Messager) error, intr *interrupter) error {
+	s := bspinner.NewModel()
+	s.Spinner = common.Spinner
+	msg := &spinnerMessager{stdout: NewStdoutMessager()}
+	done := make(chan struct{})
+
+	var err error
+	go func() {
+		err = f(msg)
+		close(done)
+	}()
+
+	perr := tea.NewProgram(spinnerModel{spinner: s, msg: msg, intr: intr, done: done}).Start()
+	msg.exit()
+	intr.setOutput(os.Stderr)
+	<-done
+	if err != nil {
+		return err
+	}
+	return perr
+}
@@ -0,0 +1,382 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/config"
+	"github.com/Azure/aztfexport/internal/costestimate"
+	"github.com/Azure/aztfexport/internal/i18n"
+	internalmeta "github.com/Azure/aztfexport/internal/meta"
+	"github.com/Azure/aztfexport/internal/pulumi"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/internal/ui/importlist"
+	"github.com/Azure/aztfexport/pkg/meta"
+)
+
+// AccessibleEnvVar is the environment variable that, when set to a non-empty value, asks the CLI tools to use screen reader friendly output.
+const AccessibleEnvVar = "ACCESSIBLE"
+
+// AccessibleModeDetected tells whether the accessible mode is asked by the environment.
+func AccessibleModeDetected() bool {
+	return os.Getenv(AccessibleEnvVar) != ""
+}
+
+const accessibleHelp = `Commands:
+  l                 List the resources
+  e <number> <addr> Import the resource as the Terraform resource address (e.g. azurerm_resource_group.res-0)
+  s <number>        Skip the resource, or stop skipping it
+  r <number>        Show the recommended resource types of the resource
+  x <number>        Show the import error of the resource
+  save              Save the resource mapping file
+  w                 Import the resources that are not skipped
+  q                 Quit
+  h                 Show this help`
+
+type accessibleUI struct {
+	ctx context.Context
+	cfg config.InteractiveModeConfig
+	c   meta.Meta
+	in  *bufio.Scanner
+	out io.Writer
+
+	validTypes map[string]bool
+}
+
+// RunAccessible runs the interactive mode as a plain, line-oriented program. Rather than the full-screen list, the resources are numbered
+// and curated via the commands typed in, which works with the screen readers.
+func RunAccessible(ctx context.Context, cfg config.InteractiveModeConfig, in io.Reader, out io.Writer) error {
+	var c meta.Meta = internalmeta.NewGroupMetaDummy(cfg.ResourceGroupName)
+	if !cfg.MockMeta {
+		var err error
+		c, err = meta.NewMeta(cfg.Config)
+		if err != nil {
+			return err
+		}
+	}
+
+	validTypes := map[string]bool{}
+	for _, rt := range importlist.ResourceTypes(c.ProviderNames()) {
+		validTypes[rt] = true
+	}
+
+	u := accessibleUI{
+		ctx:        ctx,
+		cfg:        cfg,
+		c:          c,
+		in:         bufio.NewScanner(in),
+		out:        out,
+		validTypes: validTypes,
+	}
+	return u.run()
+}
+
+func (u accessibleUI) println(a ...interface{}) {
+	// #nosec G104
+	fmt.Fprintln(u.out, a...)
+}
+
+func (u accessibleUI) run() error {
+	u.println(i18n.T("Initializing..."))
+	if err := u.c.Init(u.ctx); err != nil {
+		return err
+	}
+	defer func() {
+		// #nosec G104
+		u.c.DeInit(u.ctx)
+	}()
+
+	u.println(i18n.T("Listing Azure Resources..."))
+	l, err := u.c.ListResource(u.ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		if !u.curate(l) {
+			return nil
+		}
+		if u.cfg.GenMappingFileOnly {
+			u.println(i18n.T("Exporting Resource Mapping..."))
+			if err := u.c.ExportResourceMapping(u.ctx, l); err != nil {
+				return err
+			}
+			u.println(i18n.Sprintf("Resource mapping file is generated at: %s", filepath.Join(u.c.Workspace(), internalmeta.ResourceMappingFileName)))
+			return nil
+		}
+		ok, err := u.importResources(l)
+		if err != nil {
+			return err
+		}
+		if ok {
+			break
+		}
+		u.println(i18n.T(`One or more resources failed to import, type "x <number>" to show the error`))
+	}
+
+	u.println(i18n.T("Pushing Terraform Status..."))
+	if err := u.c.PushState(u.ctx); err != nil {
+		return err
+	}
+	u.println(i18n.T("Exporting Resource Mapping..."))
+	if err := u.c.ExportResourceMapping(u.ctx, l); err != nil {
+		return err
+	}
+	u.println(i18n.T("Exporting Skipped Resources..."))
+	if err := u.c.ExportSkippedResources(u.ctx, l); err != nil {
+		return err
+	}
+	u.println(i18n.T("Generating Terraform Configurations..."))
+	if err := u.c.GenerateCfg(u.ctx, l); err != nil {
+		return err
+	}
+	u.println(i18n.T("Cleaning up the output directory..."))
+	if err := u.c.CleanUpWorkspace(u.ctx); err != nil {
+		return err
+	}
+
+	var pulumiDir string
+	if u.cfg.PulumiLanguage != "" {
+		u.println(i18n.T("Converting to Pulumi Program..."))
+		if pulumiDir, err = pulumi.Convert(u.ctx, u.c.Workspace(), u.cfg.PulumiLanguage); err != nil {
+			return err
+		}
+	}
+	var estimate *costestimate.Estimate
+	var estimateErr error
+	if u.cfg.CostEstimate {
+		u.println(i18n.T("Estimating Cost..."))
+		// The cost estimate is informational, e.g. infracost might be missing, which doesn't fail the export.
+		estimate, estimateErr = costestimate.Run(u.ctx, u.c.Workspace())
+	}
+
+	u.println(i18n.Sprintf("Terraform state and the config are generated at: %s", u.c.Workspace()))
+	if pulumiDir != "" {
+		u.println(i18n.Sprintf("Pulumi program is generated at: %s", pulumiDir))
+	}
+	if locked := l.Locked(); len(locked) != 0 {
+		u.println(i18n.T("Resources under management locks:"))
+		u.println(lockedResources(locked))
+	}
+	if estimate != nil {
+		u.println(i18n.T("Cost estimate:"))
+		u.println(estimate)
+	}
+	if estimateErr != nil {
+		u.println(i18n.T("Warnings:"))
+		u.println(fmt.Sprintf("estimating cost: %v", estimateErr))
+	}
+	return nil
+}
+
+// curate lets the user curate the import list until the user asks to import, in which case it returns true.
+// It returns false if the user quits.
+func (u accessibleUI) curate(l meta.ImportList) bool {
+	u.printList(l)
+	if u.cfg.GenMappingFileOnly {
+		u.println(i18n.T(`Type "h" for help, "w" to save the resource mapping file, "q" to quit.`))
+	} else {
+		u.println(i18n.T(`Type "h" for help, "w" to import, "q" to quit.`))
+	}
+	for {
+		// #nosec G104
+		fmt.Fprint(u.out, "> ")
+		if !u.in.Scan() {
+			return false
+		}
+		fields := strings.Fields(u.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "h":
+			u.println(i18n.T(accessibleHelp))
+		case "l":
+			u.printList(l)
+		case "q":
+			return false
+		case "w":
+			if accessibleNothingToImport(l) {
+				u.println(i18n.T("All resources are skipped, nothing to import"))
+				continue
+			}
+			for i := range l {
+				l[i].ImportError = nil
+			}
+			return true
+		case "save":
+			u.println(i18n.T("Saving the resouce mapping..."))
+			if err := u.c.ExportResourceMapping(u.ctx, l); err != nil {
+				u.println(err)
+				continue
+			}
+			u.println(i18n.Sprintf("Resource mapping saved to %s", filepath.Join(u.c.Workspace(), internalmeta.ResourceMappingFileName)))
+		case "e", "s", "r", "x":
+			idx, err := accessibleIndex(fields, l)
+			if err != nil {
+				u.println(err)
+				continue
+			}
+			u.itemCommand(l, idx, fields)
+		default:
+			u.println(i18n.Sprintf(`Unknown command %q, type "h" for help`, fields[0]))
+		}
+	}
+}
+
+// itemCommand runs the command that operates on the resource at index idx.
+func (u accessibleUI) itemCommand(l meta.ImportList, idx int, fields []string) {
+	item := &l[idx]
+	switch fields[0] {
+	case "e":
+		if len(fields) != 3 {
+			u.println(i18n.T("Please specify the resource number and the Terraform resource address"))
+			return
+		}
+		addr, err := u.parseAddr(l, idx, fields[2])
+		if err != nil {
+			u.println(err)
+			return
+		}
+		// Changing the address of an imported resource means to import it again, as is done in the full-screen list.
+		if item.Imported {
+			u.c.CleanTFState(u.ctx, item.TFAddr.String())
+			item.Imported = false
+		}
+		item.IsRecommended = false
+		item.TFAddr = *addr
+		item.TFAddrCache = *addr
+		u.println(accessibleItem(idx, *item))
+	case "s":
+		if !item.Skip() {
+			item.TFAddr = tfaddr.TFAddr{}
+		} else {
+			item.TFAddr = item.TFAddrCache
+		}
+		u.println(accessibleItem(idx, *item))
+	case "r":
+		if len(item.Recommendations) == 0 {
+			u.println(i18n.T("No resource type recommendation is available..."))
+			return
+		}
+		u.println(i18n.Sprintf("Possible resource type(s): %s", item.FormatRecommendations(",")))
+	case "x":
+		if item.ImportError == nil {
+			u.println(i18n.T("The resource has no import error"))
+			return
+		}
+		u.println(item.ImportError)
+	}
+}
+
+// parseAddr parses the TF resource address input for the resource at index idx, which must be unique among the resources that are not skipped.
+func (u accessibleUI) parseAddr(l meta.ImportList, idx int, input string) (*tfaddr.TFAddr, error) {
+	addr, err := importlist.ParseInput(input, u.validTypes)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range l {
+		if i == idx || item.Skip() {
+			continue
+		}
+		if item.TFAddr == *addr {
+			return nil, fmt.Errorf("%q already exists", addr)
+		}
+	}
+	return addr, nil
+}
+
+// importResources imports the resources that are not skipped nor imported, in batches of the parallelism.
+// It returns whether all the resources are imported successfully.
+func (u accessibleUI) importResources(l meta.ImportList) (bool, error) {
+	var items []*meta.ImportItem
+	for i := range l {
+		if l[i].Skip() || l[i].Imported {
+			continue
+		}
+		items = append(items, &l[i])
+	}
+
+	ok := true
+	for i := 0; i < len(items); i += u.cfg.Parallelism {
+		n := u.cfg.Parallelism
+		if i+n > len(items) {
+			n = len(items) - i
+		}
+		batch := items[i : i+n]
+		for j, item := range batch {
+			u.println(i18n.Sprintf("(%d/%d) Importing %s as %s", i+j+1, len(items), item.TFResourceId, item.TFAddr))
+		}
+		if err := u.c.ParallelImport(u.ctx, batch); err != nil {
+			return false, err
+		}
+		for _, item := range batch {
+			if item.ImportError != nil {
+				ok = false
+				u.println(i18n.Sprintf("%s import failed", item.TFResourceId))
+				continue
+			}
+			u.println(i18n.Sprintf("%s import successfully", item.TFResourceId))
+		}
+	}
+	return ok, nil
+}
+
+func (u accessibleUI) printList(l meta.ImportList) {
+	u.println(i18n.Sprintf("%d resources in %s:", len(l), u.c.ScopeName()))
+	for i, item := range l {
+		u.println(accessibleItem(i, item))
+	}
+}
+
+// accessibleItem describes the resource at index idx in words, rather than the emojis used by the full-screen list.
+func accessibleItem(idx int, item meta.ImportItem) string {
+	var states []string
+	if item.Lock != "" {
+		states = append(states, i18n.Sprintf("%s lock", item.Lock))
+	}
+	switch {
+	case item.ImportError != nil:
+		states = append(states, i18n.T("import failed"))
+	case item.Imported:
+		states = append(states, i18n.T("imported"))
+	case item.IsRecommended:
+		states = append(states, i18n.T("recommended"))
+	}
+	s := fmt.Sprintf("%d. %s", idx+1, item.TFResourceId)
+	if len(states) != 0 {
+		s += " (" + strings.Join(states, ", ") + ")"
+	}
+	addr := i18n.T("Skipped")
+	if !item.Skip() {
+		addr = item.TFAddr.String()
+	}
+	return s + "\n   " + addr
+}
+
+// accessibleIndex returns the index of the resource specified by the resource number in the command.
+func accessibleIndex(fields []string, l meta.ImportList) (int, error) {
+	if len(fields) < 2 {
+		return 0, i18n.Errorf("Please specify the resource number")
+	}
+	n, err := strconv.Atoi(fields[1])
+	if err != nil || n < 1 || n > len(l) {
+		return 0, i18n.Errorf("Invalid resource number %q", fields[1])
+	}
+	return n - 1, nil
+}
+
+func accessibleNothingToImport(l meta.ImportList) bool {
+	for _, item := range l {
+		if !item.Skip() {
+			return false
+		}
+	}
+	return true
+}
@@ -3,6 +3,8 @@ package aztfexportclient
 import (
 	"context"
 
+	"github.com/Azure/aztfexport/internal/costestimate"
+	"github.com/Azure/aztfexport/internal/pulumi"
 	"github.com/Azure/aztfexport/pkg/meta"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -24,6 +26,24 @@ type ShowImportErrorMsg struct {
 	List  meta.ImportList
 }
 
+type PreviewCfgDoneMsg struct {
+	Item meta.ImportItem
+	HCL  []byte
+	Err  error
+}
+
+// EditImportListMsg goes back to the import list, with the item at Index selected.
+type EditImportListMsg struct {
+	List  meta.ImportList
+	Index int
+}
+
+// RetryImportDoneMsg is the result of importing the item at Index of the import list once more.
+type RetryImportDoneMsg struct {
+	Index int
+	Item  meta.ImportItem
+}
+
 type StartImportMsg struct {
 	List meta.ImportList
 }
@@ -52,6 +72,16 @@ type GenerateCfgDoneMsg struct{}
 
 type WorkspaceCleanupDoneMsg struct{}
 
+type ConvertToPulumiDoneMsg struct {
+	OutputDir string
+}
+
+type EstimateCostDoneMsg struct {
+	Estimate *costestimate.Estimate
+	// Err is the failure of the estimate, which is a warning that doesn't fail the export
+	Err error
+}
+
 type QuitMsg struct{}
 
 type CleanTFStateMsg struct {
@@ -90,6 +120,13 @@ func ShowImportError(item meta.ImportItem, idx int, l meta.ImportList) tea.Cmd {
 	}
 }
 
+func PreviewCfg(ctx context.Context, c meta.Meta, item meta.ImportItem) tea.Cmd {
+	return func() tea.Msg {
+		b, err := c.PreviewCfg(ctx, item)
+		return PreviewCfgDoneMsg{Item: item, HCL: b, Err: err}
+	}
+}
+
 func StartImport(l meta.ImportList) tea.Cmd {
 	return func() tea.Msg {
 		return StartImportMsg{List: l}
@@ -112,6 +149,23 @@ func ImportItems(ctx context.Context, c meta.Meta, items []meta.ImportItem) tea.
 	}
 }
 
+func EditImportList(l meta.ImportList, idx int) tea.Cmd {
+	return func() tea.Msg {
+		return EditImportListMsg{List: l, Index: idx}
+	}
+}
+
+// RetryImport imports the item (e.g. a failed one) of the import list alone, without importing the others.
+func RetryImport(ctx context.Context, c meta.Meta, idx int, item meta.ImportItem) tea.Cmd {
+	return func() tea.Msg {
+		item.ImportError = nil
+		if err := c.ParallelImport(ctx, []*meta.ImportItem{&item}); err != nil {
+			return ErrMsg(err)
+		}
+		return RetryImportDoneMsg{Index: idx, Item: item}
+	}
+}
+
 func FinishImport(l meta.ImportList) tea.Cmd {
 	return func() tea.Msg {
 		return ImportDoneMsg{List: l}
@@ -136,6 +190,23 @@ func CleanUpWorkspace(ctx context.Context, c meta.Meta) tea.Cmd {
 	}
 }
 
+func ConvertToPulumi(ctx context.Context, c meta.Meta, language string) tea.Cmd {
+	return func() tea.Msg {
+		dir, err := pulumi.Convert(ctx, c.Workspace(), language)
+		if err != nil {
+			return ErrMsg(err)
+		}
+		return ConvertToPulumiDoneMsg{OutputDir: dir}
+	}
+}
+
+func EstimateCost(ctx context.Context, c meta.Meta) tea.Cmd {
+	return func() tea.Msg {
+		est, err := costestimate.Run(ctx, c.Workspace())
+		return EstimateCostDoneMsg{Estimate: est, Err: err}
+	}
+}
+
 func PushState(ctx context.Context, c meta.Meta, l meta.ImportList) tea.Cmd {
 	return func() tea.Msg {
 		if err := c.PushState(ctx); err != nil {
@@ -8,6 +8,8 @@ const ErrorEmoji = "❗️"
 const WarningEmoji = "❓"
 const OKEmoji = "✅"
 const BulbEmoji = "💡"
+const LockEmoji = "🔒"
+const MarkEmoji = "📌"
 
 // Colors for dark and light backgrounds.
 var (
@@ -0,0 +1,325 @@
+package failurelist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/aztfexport/internal/i18n"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/internal/ui/aztfexportclient"
+	"github.com/Azure/aztfexport/internal/ui/common"
+	"github.com/Azure/aztfexport/internal/ui/importlist"
+	"github.com/Azure/aztfexport/pkg/meta"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/magodo/textinput"
+	"github.com/mitchellh/go-wordwrap"
+)
+
+// Model reviews the resources that failed to import, each of which can be retried (optionally as another TF resource type) or skipped, without importing the others again.
+type Model struct {
+	ctx        context.Context
+	c          meta.Meta
+	listkeys   listKeyMap
+	validTypes map[string]bool
+
+	// l is the whole import list, where the items of the list are updated in place.
+	l    meta.ImportList
+	list list.Model
+
+	// retrying indicates whether an item is being imported again, during which no other item can be retried, as the import directories are shared.
+	retrying bool
+
+	// showError indicates whether the error pane is shown, which takes over the keys until closed.
+	showError  bool
+	errorPane  viewport.Model
+	errorTitle string
+}
+
+// errorPaneReservedHeight is the height of the error pane occupied by the title and the help, besides the error.
+const errorPaneReservedHeight = 4
+
+func NewModel(ctx context.Context, c meta.Meta, l meta.ImportList) Model {
+	candidates := importlist.ResourceTypes(c.ProviderNames())
+	validTypes := map[string]bool{}
+	for _, rt := range candidates {
+		validTypes[rt] = true
+	}
+
+	var listItems []list.Item
+	for idx, item := range l {
+		if item.ImportError == nil || item.Skip() {
+			continue
+		}
+		ti := textinput.NewModel()
+		ti.SetCursorMode(textinput.CursorStatic)
+		ti.CandidateWords = candidates
+		listItems = append(listItems, Item{
+			idx:       idx,
+			v:         item,
+			textinput: ti,
+		})
+	}
+
+	listkeys := newListKeyMap()
+	lst := list.NewModel(listItems, list.NewDefaultDelegate(), 0, 0)
+	lst.Title = " " + i18n.Sprintf("%d resource(s) failed to import", len(listItems)) + " "
+	lst.Styles.Title = common.SubtitleStyle
+	lst.StatusMessageLifetime = 3 * time.Second
+	bindKeyHelps(&lst, listkeys.ToBindings())
+
+	// Reset the quit to deallocate the "ESC" as a quit key.
+	lst.KeyMap.Quit = key.NewBinding(
+		key.WithKeys("q"),
+		key.WithHelp("q", i18n.T("quit")),
+	)
+
+	return Model{
+		ctx:        ctx,
+		c:          c,
+		listkeys:   listkeys,
+		validTypes: validTypes,
+		l:          l,
+		list:       lst,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if msg, ok := msg.(tea.KeyMsg); ok && m.showError {
+		if key.Matches(msg, m.listkeys.closeError) {
+			m.showError = false
+			return m, nil
+		}
+		m.errorPane, cmd = m.errorPane.Update(msg)
+		return m, cmd
+	}
+
+	switch msg := msg.(type) {
+	case aztfexportclient.RetryImportDoneMsg:
+		m.retrying = false
+		m.l[msg.Index] = msg.Item
+		item, ok := m.item(msg.Index)
+		if !ok {
+			return m, nil
+		}
+		item.v = msg.Item
+		item.retrying = false
+		status := common.InfoStyle.Render(i18n.Sprintf("%s import successfully", item.v.TFResourceId))
+		if item.v.ImportError != nil {
+			status = common.ErrorMsgStyle.Render(i18n.Sprintf("%s import failed", item.v.TFResourceId))
+		}
+		return m, tea.Batch(m.setItem(item), m.list.NewStatusMessage(status))
+	case tea.KeyMsg:
+		// Don't intercept the keys (e.g. "r") when user is filtering.
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+
+		sel := m.list.SelectedItem()
+		if sel == nil {
+			break
+		}
+		selItem := sel.(Item)
+
+		if selItem.textinput.Focused() {
+			return m.updateTypeInput(msg, selItem)
+		}
+
+		switch {
+		case key.Matches(msg, m.listkeys.showError):
+			if selItem.v.ImportError == nil {
+				return m, m.list.NewStatusMessage(common.InfoStyle.Render(i18n.T("The resource has no import error")))
+			}
+			m.showError = true
+			m.errorTitle = selItem.v.TFResourceId
+			m.errorPane = viewport.New(m.list.Width(), m.list.Height()-errorPaneReservedHeight)
+			m.errorPane.SetContent(common.ErrorMsgStyle.Render(wordwrap.WrapString(selItem.v.ImportError.Error(), uint(m.list.Width()))))
+			return m, nil
+		case key.Matches(msg, m.listkeys.retry):
+			if cmd := m.checkRetry(selItem); cmd != nil {
+				return m, cmd
+			}
+			if selItem.v.Skip() {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.T("The selected resource is skipped, set its resource type first")))
+			}
+			return m, m.retry(selItem)
+		case key.Matches(msg, m.listkeys.changeType):
+			if cmd := m.checkRetry(selItem); cmd != nil {
+				return m, cmd
+			}
+			addr := selItem.v.TFAddr
+			if selItem.v.Skip() {
+				addr = selItem.v.TFAddrCache
+			}
+			selItem.textinput.SetValue(addr.String())
+			bindKeyHelps(&m.list, nil)
+			cmd := selItem.textinput.Focus()
+			return m, tea.Batch(cmd, m.setItem(selItem))
+		case key.Matches(msg, m.listkeys.skip):
+			if selItem.retrying || selItem.v.Imported {
+				return m, nil
+			}
+			if !selItem.v.Skip() {
+				selItem.v.TFAddr = tfaddr.TFAddr{}
+			} else {
+				selItem.v.TFAddr = selItem.v.TFAddrCache
+			}
+			m.l[selItem.idx] = selItem.v
+			return m, m.setItem(selItem)
+		case key.Matches(msg, m.listkeys.apply):
+			if m.retrying {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.T("Retrying the import, please wait...")))
+			}
+			if n := m.failedCount(); n != 0 {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.Sprintf("%d resource(s) still failed to import, retry or skip them", n)))
+			}
+			// The skipped items keep their import errors otherwise, which are regarded as failed.
+			for i := range m.l {
+				if m.l[i].Skip() {
+					m.l[i].ImportError = nil
+				}
+			}
+			return m, aztfexportclient.FinishImport(m.l)
+		case key.Matches(msg, m.listkeys.editList):
+			if m.retrying {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.T("Retrying the import, please wait...")))
+			}
+			return m, aztfexportclient.EditImportList(m.l, selItem.idx)
+		case key.Matches(msg, m.list.KeyMap.Quit):
+			return m, aztfexportclient.Quit(m.ctx, m.c)
+		}
+	case tea.WindowSizeMsg:
+		// The height here minus the height occupied by the title
+		m.list.SetSize(msg.Width, msg.Height-3)
+		m.errorPane.Width = msg.Width
+		m.errorPane.Height = msg.Height - 3 - errorPaneReservedHeight
+	}
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if m.showError {
+		return common.SubtitleStyle.Render(" "+m.errorTitle+" ") + "\n\n" +
+			m.errorPane.View() + "\n\n" +
+			common.QuitMsgStyle.Render(i18n.T("Press esc to close the error, up/down to scroll"))
+	}
+	return m.list.View()
+}
+
+// updateTypeInput updates the TF resource address being edited of the item, which is retried once entered.
+func (m Model) updateTypeInput(msg tea.KeyMsg, item Item) (Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter, tea.KeyEsc:
+		bindKeyHelps(&m.list, m.listkeys.ToBindings())
+		item.textinput.Blur()
+
+		// ESC discards the change
+		if msg.Type == tea.KeyEsc {
+			return m, m.setItem(item)
+		}
+
+		addr, err := importlist.ParseInput(item.textinput.Value(), m.validTypes)
+		if err == nil && addr.Type == "" {
+			err = fmt.Errorf("Empty resource address, use %q to skip the resource", m.listkeys.skip.Help().Key)
+		}
+		if err == nil {
+			err = m.checkAddrUnique(item.idx, *addr)
+		}
+		if err != nil {
+			return m, tea.Batch(m.setItem(item), m.list.NewStatusMessage(common.ErrorMsgStyle.Render(err.Error())))
+		}
+		item.v.TFAddr = *addr
+		item.v.TFAddrCache = *addr
+		item.v.IsRecommended = false
+		m.l[item.idx] = item.v
+		return m, m.retry(item)
+	}
+	var cmd tea.Cmd
+	item.textinput, cmd = item.textinput.Update(msg)
+	return m, tea.Batch(cmd, m.setItem(item))
+}
+
+// checkRetry returns the command showing why the item can't be retried, or nil if it can be.
+func (m *Model) checkRetry(item Item) tea.Cmd {
+	if m.retrying {
+		return m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.T("Retrying the import, please wait...")))
+	}
+	if item.v.Imported {
+		return m.list.NewStatusMessage(common.InfoStyle.Render(i18n.T("The resource is imported successfully")))
+	}
+	return nil
+}
+
+// retry imports the item once more, alone.
+func (m *Model) retry(item Item) tea.Cmd {
+	m.retrying = true
+	item.retrying = true
+	return tea.Batch(
+		m.setItem(item),
+		m.list.NewStatusMessage(common.InfoStyle.Render(i18n.Sprintf("Retrying %s...", item.v.TFResourceId))),
+		aztfexportclient.RetryImport(m.ctx, m.c, item.idx, item.v),
+	)
+}
+
+// checkAddrUnique checks the uniqueness of the resource address among the import list, in the same way as the import list.
+func (m Model) checkAddrUnique(idx int, addr tfaddr.TFAddr) error {
+	for i, item := range m.l {
+		if i == idx || item.Skip() {
+			continue
+		}
+		if item.TFAddr == addr {
+			return fmt.Errorf("%q already exists", addr)
+		}
+	}
+	return nil
+}
+
+// failedCount returns the number of the items that still failed to import, and are not skipped.
+func (m Model) failedCount() int {
+	var n int
+	for _, item := range m.l {
+		if !item.Skip() && !item.Imported && item.ImportError != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// item returns the list item of the import list index.
+func (m Model) item(idx int) (Item, bool) {
+	for _, item := range m.list.Items() {
+		if item := item.(Item); item.idx == idx {
+			return item, true
+		}
+	}
+	return Item{}, false
+}
+
+func (m *Model) setItem(item Item) tea.Cmd {
+	for i, listItem := range m.list.Items() {
+		if listItem.(Item).idx == item.idx {
+			return m.list.SetItem(i, item)
+		}
+	}
+	return nil
+}
+
+func bindKeyHelps(l *list.Model, bindings []key.Binding) {
+	l.AdditionalFullHelpKeys = func() []key.Binding {
+		return bindings
+	}
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return bindings
+	}
+}
@@ -0,0 +1,55 @@
+package failurelist
+
+import (
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/i18n"
+	"github.com/Azure/aztfexport/internal/ui/common"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/textinput"
+)
+
+// Item is a resource that failed to import, which stays in the list once resolved (i.e. imported or skipped).
+type Item struct {
+	// idx is the index of the item in the import list
+	idx int
+	v   meta.ImportItem
+	// textinput edits the TF resource address, before retrying the import
+	textinput textinput.Model
+	// retrying indicates whether the item is being imported again
+	retrying bool
+}
+
+func (i Item) Title() string {
+	id := i.v.TFResourceId
+	switch {
+	case i.retrying, i.v.Skip():
+		return id
+	case i.v.Imported:
+		return common.OKEmoji + id
+	default:
+		return common.ErrorEmoji + id
+	}
+}
+
+func (i Item) Description() string {
+	if i.textinput.Focused() {
+		return i.textinput.View()
+	}
+	switch {
+	case i.retrying:
+		return i18n.Sprintf("%s (retrying...)", i.v.TFAddr)
+	case i.v.Skip():
+		return "(Skip)"
+	case i.v.Imported || i.v.ImportError == nil:
+		return i.v.TFAddr.String()
+	default:
+		// Only the first line of the error is shown, the full one is shown in the error pane.
+		msg, _, _ := strings.Cut(i.v.ImportError.Error(), "\n")
+		return i.v.TFAddr.String() + ": " + msg
+	}
+}
+
+func (i Item) FilterValue() string {
+	return i.v.TFResourceId
+}
@@ -0,0 +1,60 @@
+package failurelist
+
+import (
+	"github.com/Azure/aztfexport/internal/i18n"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type listKeyMap struct {
+	showError  key.Binding
+	retry      key.Binding
+	changeType key.Binding
+	skip       key.Binding
+	apply      key.Binding
+	editList   key.Binding
+	// closeError is only enabled in the error pane, hence not in the bindings of the list
+	closeError key.Binding
+}
+
+func newListKeyMap() listKeyMap {
+	return listKeyMap{
+		showError: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", i18n.T("show error")),
+		),
+		retry: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", i18n.T("retry")),
+		),
+		changeType: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", i18n.T("change type and retry")),
+		),
+		skip: key.NewBinding(
+			key.WithKeys("delete"),
+			key.WithHelp("delete", i18n.T("skip")),
+		),
+		apply: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", i18n.T("continue")),
+		),
+		editList: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", i18n.T("edit import list")),
+		),
+		closeError: key.NewBinding(
+			key.WithKeys("esc", "q", "enter"),
+		),
+	}
+}
+
+func (m listKeyMap) ToBindings() []key.Binding {
+	return []key.Binding{
+		m.showError,
+		m.retry,
+		m.changeType,
+		m.skip,
+		m.apply,
+		m.editList,
+	}
+}
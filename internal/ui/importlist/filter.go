@@ -0,0 +1,70 @@
+package importlist
+
+import (
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/i18n"
+)
+
+// The import statuses that the items can be filtered by.
+const (
+	// statusUnresolved is for the skipped items that have no TF resource type resolved.
+	statusUnresolved = "unresolved"
+	// statusSkipped is for the skipped items that have a TF resource type resolved (e.g. skipped by the user).
+	statusSkipped = "skipped"
+	// statusError is for the items that failed to import, or whose user input is invalid.
+	statusError = "error"
+	// statusRecommended is for the items whose TF resource type is from recommendation.
+	statusRecommended = "recommended"
+)
+
+// filterStatuses are the status filters in the order of cycling, where the empty one means no status filter.
+var filterStatuses = []string{"", statusUnresolved, statusSkipped, statusError, statusRecommended}
+
+// itemFilter filters the items by their import status and Azure resource type. The zero value matches all the items.
+type itemFilter struct {
+	status    string
+	azureType string
+}
+
+// nextStatus returns the filter with the next status filter in filterStatuses.
+func (f itemFilter) nextStatus() itemFilter {
+	for i, status := range filterStatuses {
+		if status == f.status {
+			f.status = filterStatuses[(i+1)%len(filterStatuses)]
+			return f
+		}
+	}
+	f.status = ""
+	return f
+}
+
+func (f itemFilter) match(item Item) bool {
+	if f.azureType != "" && !strings.EqualFold(item.v.AzureResourceID.TypeString(), f.azureType) {
+		return false
+	}
+	switch f.status {
+	case statusUnresolved:
+		return item.v.Skip() && item.v.TFAddrCache.Type == ""
+	case statusSkipped:
+		return item.v.Skip() && item.v.TFAddrCache.Type != ""
+	case statusError:
+		return item.v.ImportError != nil || item.v.ValidateError != nil
+	case statusRecommended:
+		return item.v.IsRecommended
+	default:
+		return true
+	}
+}
+
+// title returns the list title, which shows the filters in effect.
+func (f itemFilter) title(scopeName string) string {
+	title := " " + scopeName + " "
+	if f.status != "" {
+		title += "| " + i18n.Sprintf("status: %s", i18n.T(f.status)) + " "
+	}
+	if f.azureType != "" {
+		title += "| " + i18n.Sprintf("type: %s", f.azureType) + " "
+	}
+	return title
+}
@@ -4,20 +4,25 @@ import (
 	"context"
 	"fmt"
 	"github.com/Azure/aztfexport/pkg/meta"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/Azure/aztfexport/internal/i18n"
+	internalmeta "github.com/Azure/aztfexport/internal/meta"
 	"github.com/Azure/aztfexport/internal/tfaddr"
 	"github.com/Azure/aztfexport/internal/ui/aztfexportclient"
 	"github.com/Azure/aztfexport/internal/ui/common"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/magodo/textinput"
 	"github.com/magodo/tfadd/providers/azurerm"
+	"github.com/mitchellh/go-wordwrap"
 )
 
 type Model struct {
@@ -25,19 +30,50 @@ type Model struct {
 	c        meta.Meta
 	listkeys listKeyMap
 
+	// genMappingFileOnly saves the resource mapping file on applying, in place of importing.
+	genMappingFileOnly bool
+
 	list list.Model
+
+	// items are all the items, indexed by their idx, of which the list only shows the ones matching the filter.
+	// The slice is shared with the list delegate (which updates the item being edited), hence it must not be resized.
+	items  []Item
+	filter itemFilter
+
+	// previewing indicates whether the config preview is being generated, during which the import can't start, as the preview imports to the import directory.
+	previewing bool
+	// showPreview indicates whether the config preview pane is shown, which takes over the keys until closed.
+	showPreview  bool
+	preview      viewport.Model
+	previewTitle string
 }
 
-func NewModel(ctx context.Context, c meta.Meta, l meta.ImportList, idx int) Model {
-	// Build candidate words for the textinput
-	candidates := make([]string, 0, len(azurerm.ProviderSchemaInfo.ResourceSchemas))
-	for rt := range azurerm.ProviderSchemaInfo.ResourceSchemas {
-		candidates = append(candidates, rt)
+// previewPaneReservedHeight is the height of the preview pane occupied by the title and the help, besides the config.
+const previewPaneReservedHeight = 4
+
+// ResourceTypes returns the sorted TF resource types that can be exported to for the providers.
+func ResourceTypes(providerNames []string) []string {
+	var rts []string
+	for _, providerName := range providerNames {
+		switch providerName {
+		case internalmeta.ProviderAzAPI:
+			rts = append(rts, internalmeta.AzAPIResourceType)
+		default:
+			for rt := range azurerm.ProviderSchemaInfo.ResourceSchemas {
+				rts = append(rts, rt)
+			}
+		}
 	}
-	sort.Strings(candidates)
+	sort.Strings(rts)
+	return rts
+}
+
+func NewModel(ctx context.Context, c meta.Meta, l meta.ImportList, idx int, genMappingFileOnly bool) Model {
+	// Build candidate words for the textinput
+	candidates := ResourceTypes(c.ProviderNames())
 
 	// Build list items
-	var items []list.Item
+	var items []Item
 	for idx, item := range l {
 		ti := textinput.NewModel()
 		ti.SetCursorMode(textinput.CursorStatic)
@@ -45,15 +81,24 @@ func NewModel(ctx context.Context, c meta.Meta, l meta.ImportList, idx int) Mode
 			ti.SetValue(item.TFAddr.String())
 		}
 		ti.CandidateWords = candidates
+		iti := textinput.NewModel()
+		iti.SetCursorMode(textinput.CursorStatic)
 		items = append(items, Item{
-			idx:       idx,
-			v:         item,
-			textinput: ti,
+			idx:           idx,
+			v:             item,
+			textinput:     ti,
+			importIdInput: iti,
 		})
 	}
 
-	lst := list.NewModel(items, NewImportItemDelegate(), 0, 0)
-	lst.Title = " " + c.ScopeName() + " "
+	var listItems []list.Item
+	for _, item := range items {
+		listItems = append(listItems, item)
+	}
+
+	listkeys := newListKeyMap(genMappingFileOnly)
+	lst := list.NewModel(listItems, NewImportItemDelegate(candidates, items, listkeys), 0, 0)
+	lst.Title = itemFilter{}.title(c.ScopeName())
 	lst.Styles.Title = common.SubtitleStyle
 	lst.StatusMessageLifetime = 3 * time.Second
 	lst.Select(idx)
@@ -79,19 +124,21 @@ func NewModel(ctx context.Context, c meta.Meta, l meta.ImportList, idx int) Mode
 		return result
 	}
 
-	bindKeyHelps(&lst, newListKeyMap().ToBindings())
+	bindKeyHelps(&lst, listkeys.ToBindings())
 
 	// Reset the quit to deallocate the "ESC" as a quit key.
 	lst.KeyMap.Quit = key.NewBinding(
 		key.WithKeys("q"),
-		key.WithHelp("q", "quit"),
+		key.WithHelp("q", i18n.T("quit")),
 	)
 
 	return Model{
-		ctx:      ctx,
-		c:        c,
-		listkeys: newListKeyMap(),
-		list:     lst,
+		ctx:                ctx,
+		c:                  c,
+		listkeys:           listkeys,
+		genMappingFileOnly: genMappingFileOnly,
+		list:               lst,
+		items:              items,
 	}
 }
 
@@ -102,7 +149,27 @@ func (m Model) Init() tea.Cmd {
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if msg, ok := msg.(tea.KeyMsg); ok && m.showPreview {
+		if key.Matches(msg, m.listkeys.closePreview) {
+			m.showPreview = false
+			return m, nil
+		}
+		m.preview, cmd = m.preview.Update(msg)
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
+	case aztfexportclient.PreviewCfgDoneMsg:
+		m.previewing = false
+		m.showPreview = true
+		m.previewTitle = msg.Item.TFResourceId
+		m.preview = viewport.New(m.list.Width(), m.list.Height()-previewPaneReservedHeight)
+		if msg.Err != nil {
+			m.preview.SetContent(common.ErrorMsgStyle.Render(wordwrap.WrapString(msg.Err.Error(), uint(m.list.Width()))))
+		} else {
+			m.preview.SetContent(string(msg.HCL))
+		}
+		return m, nil
 	case tea.KeyMsg:
 		// Don't intercept the keys (e.g. "w") when user is inputting.
 		if m.isUserTyping() {
@@ -116,16 +183,24 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				m.list.ResetFilter()
 			}
 
+			// The import and the preview share the import directory.
+			if m.previewing {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.T("Generating the config preview, please wait...")))
+			}
+
 			// In case all items are marked as skip, show a warning and do nothing.
 			if m.isNothingToImport() {
-				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render("All resources are skipped, nothing to import"))
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.T("All resources are skipped, nothing to import")))
 			}
 
 			// Ensure all items pass validation
 			if !m.userInputsAreValid() {
-				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render("One or more user input is invalid"))
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.T("One or more user input is invalid")))
 			}
 
+			if m.genMappingFileOnly {
+				return m, aztfexportclient.ExportResourceMapping(m.ctx, m.c, m.importList(false))
+			}
 			return m, aztfexportclient.StartImport(m.importList(true))
 		case key.Matches(msg, m.listkeys.skip):
 			sel := m.list.SelectedItem()
@@ -142,8 +217,104 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				selItem.textinput.Model.SetValue(selItem.v.TFAddr.String())
 			}
 
-			m.list.SetItem(selItem.idx, selItem)
-			return m, nil
+			return m, m.setItem(selItem)
+		case key.Matches(msg, m.listkeys.mark):
+			sel := m.list.SelectedItem()
+			if sel == nil {
+				return m, nil
+			}
+			selItem := sel.(Item)
+			selItem.marked = !selItem.marked
+			return m, m.setItem(selItem)
+		case key.Matches(msg, m.listkeys.invertMarks):
+			// Only the visible items (i.e. the filtered ones, if filter applied) are inverted, the others keep their marks.
+			visible := map[int]bool{}
+			for _, item := range m.list.VisibleItems() {
+				visible[item.(Item).idx] = true
+			}
+			return m, m.updateItems(func(item *Item) bool {
+				if !visible[item.idx] {
+					return false
+				}
+				item.marked = !item.marked
+				return true
+			})
+		case key.Matches(msg, m.listkeys.skipMarked):
+			var n int
+			cmd := m.updateItems(func(item *Item) bool {
+				if !item.marked || item.v.Skip() {
+					return false
+				}
+				item.v.TFAddr = tfaddr.TFAddr{}
+				item.textinput.Model.SetValue("")
+				n++
+				return true
+			})
+			return m, tea.Batch(cmd, m.list.NewStatusMessage(common.InfoStyle.Render(i18n.Sprintf("%d marked resource(s) skipped", n))))
+		case key.Matches(msg, m.listkeys.setMarkedType):
+			sel := m.list.SelectedItem()
+			if sel == nil {
+				return m, nil
+			}
+			selItem := sel.(Item)
+			if selItem.v.Skip() {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.T("The selected resource is skipped, set its resource type first")))
+			}
+			n, cmd, err := m.setMarkedResourceType(selItem)
+			if err != nil {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(err.Error()))
+			}
+			return m, tea.Batch(cmd, m.list.NewStatusMessage(common.InfoStyle.Render(i18n.Sprintf("%d marked resource(s) set to %s", n, selItem.v.TFAddr.Type))))
+		case key.Matches(msg, m.listkeys.filterStatus):
+			m.filter = m.filter.nextStatus()
+			return m, m.applyFilter()
+		case key.Matches(msg, m.listkeys.filterType):
+			if m.filter.azureType != "" {
+				m.filter.azureType = ""
+				return m, m.applyFilter()
+			}
+			sel := m.list.SelectedItem()
+			if sel == nil {
+				return m, nil
+			}
+			m.filter.azureType = sel.(Item).v.AzureResourceID.TypeString()
+			return m, m.applyFilter()
+		case key.Matches(msg, m.listkeys.preview):
+			if m.previewing {
+				return m, nil
+			}
+			sel := m.list.SelectedItem()
+			if sel == nil {
+				return m, nil
+			}
+			selItem := sel.(Item)
+			if selItem.v.Skip() {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.T("The resource is skipped, nothing to preview")))
+			}
+			if selItem.v.ValidateError != nil {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(selItem.v.ValidateError.Error()))
+			}
+			m.previewing = true
+			return m, tea.Batch(
+				m.list.NewStatusMessage(common.InfoStyle.Render(i18n.T("Generating the config preview..."))),
+				aztfexportclient.PreviewCfg(m.ctx, m.c, selItem.v),
+			)
+		case key.Matches(msg, m.listkeys.importId):
+			sel := m.list.SelectedItem()
+			if sel == nil {
+				return m, nil
+			}
+			selItem := sel.(Item)
+			if selItem.v.Skip() {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.T("The selected resource is skipped, set its resource type first")))
+			}
+			if !selItem.needsImportId() {
+				return m, m.list.NewStatusMessage(common.InfoStyle.Render(i18n.T("The resource is imported by its Azure resource ID, no need to edit the import ID")))
+			}
+			setListKeyMapEnabled(&m.list, false, m.listkeys)
+			selItem.importIdInput.SetValue(selItem.importId())
+			cmd := selItem.importIdInput.Focus()
+			return m, tea.Batch(cmd, m.setItem(selItem))
 		case key.Matches(msg, m.listkeys.error):
 			sel := m.list.SelectedItem()
 			if sel == nil {
@@ -162,14 +333,18 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			selItem := sel.(Item)
 
 			if len(selItem.v.Recommendations) == 0 {
-				return m, m.list.NewStatusMessage(common.InfoStyle.Render("No resource type recommendation is available..."))
+				return m, m.list.NewStatusMessage(common.InfoStyle.Render(i18n.T("No resource type recommendation is available...")))
 			}
-			return m, m.list.NewStatusMessage(common.InfoStyle.Render(fmt.Sprintf("Possible resource type(s): %s", strings.Join(selItem.v.Recommendations, ","))))
+			return m, m.list.NewStatusMessage(common.InfoStyle.Render(i18n.Sprintf("Possible resource type(s): %s", selItem.v.FormatRecommendations(","))))
 		case key.Matches(msg, m.listkeys.save):
-			m.list.NewStatusMessage(common.InfoStyle.Render("Saving the resouce mapping..."))
+			// The invalid user inputs would be saved as is otherwise.
+			if !m.userInputsAreValid() {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(i18n.T("One or more user input is invalid")))
+			}
+			m.list.NewStatusMessage(common.InfoStyle.Render(i18n.T("Saving the resouce mapping...")))
 			err := m.c.ExportResourceMapping(m.ctx, m.importList(false))
 			if err == nil {
-				m.list.NewStatusMessage(common.InfoStyle.Render("Resource mapping saved"))
+				m.list.NewStatusMessage(common.InfoStyle.Render(i18n.Sprintf("Resource mapping saved to %s", filepath.Join(m.c.Workspace(), internalmeta.ResourceMappingFileName))))
 			} else {
 				m.list.NewStatusMessage(common.ErrorMsgStyle.Render(err.Error()))
 			}
@@ -179,12 +354,19 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		// The height here minus the height occupied by the title
 		m.list.SetSize(msg.Width, msg.Height-3)
+		m.preview.Width = msg.Width
+		m.preview.Height = msg.Height - 3 - previewPaneReservedHeight
 	}
 	m.list, cmd = m.list.Update(msg)
 	return m, cmd
 }
 
 func (m Model) View() string {
+	if m.showPreview {
+		return common.SubtitleStyle.Render(" "+m.previewTitle+" ") + "\n\n" +
+			m.preview.View() + "\n\n" +
+			common.QuitMsgStyle.Render(i18n.T("Press esc to close the preview, up/down to scroll"))
+	}
 	return m.list.View()
 }
 
@@ -206,7 +388,7 @@ func (m Model) isUserTyping() bool {
 	// Any textinput is in focused mode
 	for _, item := range m.list.Items() {
 		item := item.(Item)
-		if item.textinput.Focused() {
+		if item.textinput.Focused() || item.importIdInput.Focused() {
 			return true
 		}
 	}
@@ -214,8 +396,7 @@ func (m Model) isUserTyping() bool {
 }
 
 func (m Model) isNothingToImport() bool {
-	for _, item := range m.list.Items() {
-		item := item.(Item)
+	for _, item := range m.items {
 		if !item.v.Skip() {
 			return false
 		}
@@ -224,8 +405,7 @@ func (m Model) isNothingToImport() bool {
 }
 
 func (m Model) userInputsAreValid() bool {
-	for _, item := range m.list.Items() {
-		item := item.(Item)
+	for _, item := range m.items {
 		if item.v.ValidateError != nil {
 			return false
 		}
@@ -234,9 +414,8 @@ func (m Model) userInputsAreValid() bool {
 }
 
 func (m Model) importList(clearErr bool) meta.ImportList {
-	out := make(meta.ImportList, 0, len(m.list.Items()))
-	for _, item := range m.list.Items() {
-		item := item.(Item)
+	out := make(meta.ImportList, 0, len(m.items))
+	for _, item := range m.items {
 		if clearErr {
 			item.v.ImportError = nil
 		}
@@ -244,3 +423,124 @@ func (m Model) importList(clearErr bool) meta.ImportList {
 	}
 	return out
 }
+
+// setItem updates the item, which stays in the list even if it no longer matches the filter, until the filter changes.
+func (m *Model) setItem(item Item) tea.Cmd {
+	m.items[item.idx] = item
+	for i, listItem := range m.list.Items() {
+		if listItem.(Item).idx == item.idx {
+			return m.list.SetItem(i, item)
+		}
+	}
+	return nil
+}
+
+// updateItems updates all the items (including the ones filtered out) by the function, which returns whether the item is updated.
+// The list items are set all at once, as each setting of an item refilters the list (if filter applied).
+func (m *Model) updateItems(f func(item *Item) bool) tea.Cmd {
+	var updated bool
+	for i := range m.items {
+		if f(&m.items[i]) {
+			updated = true
+		}
+	}
+	if !updated {
+		return nil
+	}
+	listItems := make([]list.Item, len(m.list.Items()))
+	for i, item := range m.list.Items() {
+		listItems[i] = m.items[item.(Item).idx]
+	}
+	return m.list.SetItems(listItems)
+}
+
+// applyFilter resets the list to the items matching the filter, and keeps the selected item selected if it still matches.
+func (m *Model) applyFilter() tea.Cmd {
+	if m.list.FilterState() != list.Unfiltered {
+		m.list.ResetFilter()
+	}
+	selIdx := -1
+	if sel := m.list.SelectedItem(); sel != nil {
+		selIdx = sel.(Item).idx
+	}
+	var listItems []list.Item
+	pos := 0
+	for _, item := range m.items {
+		if !m.filter.match(item) {
+			continue
+		}
+		if item.idx == selIdx {
+			pos = len(listItems)
+		}
+		listItems = append(listItems, item)
+	}
+	m.list.Title = m.filter.title(m.c.ScopeName())
+	cmd := m.list.SetItems(listItems)
+	m.list.Select(pos)
+	if len(listItems) == 0 {
+		return tea.Batch(cmd, m.list.NewStatusMessage(common.InfoStyle.Render(i18n.T("No resource matches the filter"))))
+	}
+	return cmd
+}
+
+// setMarkedResourceType sets the TF resource type of the marked items, which are of the same Azure resource type as the selected item, to the one of the selected item.
+// The imported items that change their resource types have their TF states cleaned, so that they will be imported again.
+func (m *Model) setMarkedResourceType(sel Item) (int, tea.Cmd, error) {
+	azureType := strings.ToUpper(sel.v.AzureResourceID.TypeString())
+	rt := sel.v.TFAddr.Type
+	isTarget := func(item Item) bool {
+		return item.marked && item.idx != sel.idx && strings.ToUpper(item.v.AzureResourceID.TypeString()) == azureType
+	}
+
+	// Check the uniqueness of the resource addresses, in the same way as the per item editing.
+	addrs := map[string]bool{}
+	for _, item := range m.items {
+		if item.v.Skip() || isTarget(item) {
+			continue
+		}
+		addrs[item.v.TFAddr.String()] = true
+	}
+	var n int
+	for _, item := range m.items {
+		if !isTarget(item) {
+			continue
+		}
+		addr := tfaddr.TFAddr{Type: rt, Name: itemName(item)}
+		if addrs[addr.String()] {
+			return 0, nil, fmt.Errorf("%q already exists", addr)
+		}
+		addrs[addr.String()] = true
+		n++
+	}
+	if n == 0 {
+		return 0, nil, i18n.Errorf("No marked resource is of the same Azure resource type as the selected one")
+	}
+
+	var cmds []tea.Cmd
+	cmd := m.updateItems(func(item *Item) bool {
+		if !isTarget(*item) {
+			return false
+		}
+		addr := tfaddr.TFAddr{Type: rt, Name: itemName(*item)}
+		if item.v.Imported && item.v.TFAddr != addr {
+			cmds = append(cmds, aztfexportclient.CleanTFState(item.v.TFAddr.String()))
+			item.v.Imported = false
+		}
+		item.v.ValidateError = nil
+		item.v.IsRecommended = false
+		item.v.TFAddr = addr
+		item.v.TFAddrCache = addr
+		item.textinput.Model.SetValue(addr.String())
+		return true
+	})
+	cmds = append(cmds, cmd)
+	return n, tea.Batch(cmds...), nil
+}
+
+// itemName returns the TF resource name of the item, which is kept even if the item is skipped.
+func itemName(item Item) string {
+	if item.v.TFAddr.Name != "" {
+		return item.v.TFAddr.Name
+	}
+	return item.v.TFAddrCache.Name
+}
@@ -8,13 +8,19 @@ import (
 
 	"github.com/Azure/aztfexport/internal/ui/aztfexportclient"
 	"github.com/Azure/aztfexport/internal/ui/common"
-	"github.com/magodo/tfadd/providers/azurerm"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func NewImportItemDelegate() list.ItemDelegate {
+// NewImportItemDelegate returns the delegate that edits the selected item, which is also updated in the items (i.e. all the items, including the ones filtered out of the list).
+// The key helps of the list keys are hidden during the editing.
+func NewImportItemDelegate(resourceTypes []string, items []Item, listkeys listKeyMap) list.ItemDelegate {
+	validTypes := map[string]bool{}
+	for _, rt := range resourceTypes {
+		validTypes[rt] = true
+	}
+
 	d := list.NewDefaultDelegate()
 	d.UpdateFunc = func(msg tea.Msg, m *list.Model) (ret tea.Cmd) {
 		sel := m.SelectedItem()
@@ -26,11 +32,49 @@ func NewImportItemDelegate() list.ItemDelegate {
 
 		var cmds []tea.Cmd
 		defer func() {
-			cmd := m.SetItem(idx, selItem)
-			cmds = append(cmds, cmd)
+			items[idx] = selItem
+			// The list only contains the items matching the filter, whose positions differ from their idx.
+			for i, item := range m.Items() {
+				if item.(Item).idx == idx {
+					cmd := m.SetItem(i, selItem)
+					cmds = append(cmds, cmd)
+					break
+				}
+			}
 			ret = tea.Batch(cmds...)
 		}()
 
+		// The import ID of the item is being edited, which is focused by the import ID key of the list.
+		if selItem.importIdInput.Focused() {
+			if msg, ok := msg.(tea.KeyMsg); ok && (msg.Type == tea.KeyEnter || msg.Type == tea.KeyEsc) {
+				setListKeyMapEnabled(m, true, listkeys)
+				selItem.importIdInput.Blur()
+
+				// ESC discards the change
+				if msg.Type == tea.KeyEsc {
+					return
+				}
+
+				// Empty input means to use the computed import ID
+				importId := strings.TrimSpace(selItem.importIdInput.Value())
+				if importId == selItem.v.ImportId || selItem.v.ImportId == "" && importId == selItem.v.TFResourceId {
+					return
+				}
+				// The resource needs to be imported again by the new import ID.
+				if selItem.v.Imported {
+					cmd := aztfexportclient.CleanTFState(selItem.v.TFAddr.String())
+					cmds = append(cmds, cmd)
+					selItem.v.Imported = false
+				}
+				selItem.v.ImportId = importId
+				return
+			}
+			var cmd tea.Cmd
+			selItem.importIdInput, cmd = selItem.importIdInput.Update(msg)
+			cmds = append(cmds, cmd)
+			return
+		}
+
 		// For the item that is not focused (i.e. the textinput is not focused)
 		if !selItem.textinput.Focused() {
 			switch msg := msg.(type) {
@@ -53,7 +97,7 @@ func NewImportItemDelegate() list.ItemDelegate {
 					selItem.v.IsRecommended = false
 
 					// "Enter" focus current selected item
-					setListKeyMapEnabled(m, false)
+					setListKeyMapEnabled(m, false, listkeys)
 					cmd := selItem.textinput.Focus()
 					cmds = append(cmds, cmd)
 					return
@@ -69,11 +113,11 @@ func NewImportItemDelegate() list.ItemDelegate {
 			case tea.KeyEnter,
 				tea.KeyEsc:
 				// Enter and ESC un-focus the textinput
-				setListKeyMapEnabled(m, true)
+				setListKeyMapEnabled(m, true, listkeys)
 				selItem.textinput.Blur()
 
 				// Validate the input and update the selItem.v
-				addr, err := parseInput(selItem.textinput.Value())
+				addr, err := ParseInput(selItem.textinput.Value(), validTypes)
 				if err != nil {
 					cmd := m.NewStatusMessage(common.ErrorMsgStyle.Render(err.Error()))
 					cmds = append(cmds, cmd)
@@ -84,9 +128,9 @@ func NewImportItemDelegate() list.ItemDelegate {
 				// Check the uniqueness of the resource name among the resource type
 				// TODO: this is not ideal to construct the resource name mapping everytime.
 				tfNames := map[string]map[string]bool{}
-				for i, item := range m.Items() {
-					v := item.(Item).v
-					if v.Skip() || m.Index() == i {
+				for i, item := range items {
+					v := item.v
+					if v.Skip() || idx == i {
 						continue
 					}
 					if _, ok := tfNames[v.TFAddr.Type]; !ok {
@@ -117,7 +161,7 @@ func NewImportItemDelegate() list.ItemDelegate {
 	return d
 }
 
-func setListKeyMapEnabled(m *list.Model, enabled bool) {
+func setListKeyMapEnabled(m *list.Model, enabled bool, listkeys listKeyMap) {
 	m.KeyMap.CursorUp.SetEnabled(enabled)
 	m.KeyMap.CursorDown.SetEnabled(enabled)
 	m.KeyMap.NextPage.SetEnabled(enabled)
@@ -141,13 +185,14 @@ func setListKeyMapEnabled(m *list.Model, enabled bool) {
 	m.KeyMap.Quit.SetEnabled(enabled)
 
 	if enabled {
-		bindKeyHelps(m, newListKeyMap().ToBindings())
+		bindKeyHelps(m, listkeys.ToBindings())
 	} else {
 		bindKeyHelps(m, nil)
 	}
 }
 
-func parseInput(input string) (*tfaddr.TFAddr, error) {
+// ParseInput parses the user input of the TF resource address, of which the resource type must be one of the validTypes. Empty input means to skip the resource.
+func ParseInput(input string, validTypes map[string]bool) (*tfaddr.TFAddr, error) {
 	v := strings.TrimSpace(input)
 	if v == "" {
 		return &tfaddr.TFAddr{}, nil
@@ -158,7 +203,7 @@ func parseInput(input string) (*tfaddr.TFAddr, error) {
 		return nil, err
 	}
 
-	if _, ok := azurerm.ProviderSchemaInfo.ResourceSchemas[addr.Type]; !ok {
+	if !validTypes[addr.Type] {
 		return nil, fmt.Errorf("Invalid resource type %q", addr.Type)
 	}
 
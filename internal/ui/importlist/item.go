@@ -1,6 +1,10 @@
 package importlist
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/i18n"
 	"github.com/Azure/aztfexport/internal/ui/common"
 	"github.com/Azure/aztfexport/pkg/meta"
 	"github.com/magodo/textinput"
@@ -10,21 +14,32 @@ type Item struct {
 	idx       int
 	v         meta.ImportItem
 	textinput textinput.Model
+	// importIdInput edits the import ID that overrides the computed one
+	importIdInput textinput.Model
+	// marked indicates whether the item is marked for the bulk operations
+	marked bool
 }
 
 func (i Item) Title() string {
+	id := i.v.TFResourceId
+	if i.v.Lock != "" {
+		id = common.LockEmoji + id
+	}
+	if i.marked {
+		id = common.MarkEmoji + id
+	}
 	switch {
 	case i.v.ValidateError != nil:
-		return common.WarningEmoji + i.v.TFResourceId
+		return common.WarningEmoji + id
 	case i.v.ImportError != nil:
-		return common.ErrorEmoji + i.v.TFResourceId
+		return common.ErrorEmoji + id
 	case i.v.Imported:
-		return common.OKEmoji + i.v.TFResourceId
+		return common.OKEmoji + id
 	default:
 		if i.v.IsRecommended {
-			return common.BulbEmoji + i.v.TFResourceId
+			return common.BulbEmoji + id
 		}
-		return i.v.TFResourceId
+		return id
 	}
 }
 
@@ -32,12 +47,31 @@ func (i Item) Description() string {
 	if i.textinput.Focused() {
 		return i.textinput.View()
 	}
+	if i.importIdInput.Focused() {
+		return fmt.Sprintf("%s: %s", i18n.T("import ID"), i.importIdInput.View())
+	}
 	if i.v.Skip() {
 		return "(Skip)"
 	}
+	if i.needsImportId() {
+		return fmt.Sprintf("%s (%s: %s)", i.textinput.Value(), i18n.T("import ID"), i.importId())
+	}
 	return i.textinput.Value()
 }
 
+// needsImportId tells whether the import ID of the item is known to differ from its Azure resource ID (e.g. composite ids), or is overridden by the user.
+func (i Item) needsImportId() bool {
+	return i.v.ImportId != "" || !strings.EqualFold(i.v.TFResourceId, i.v.AzureResourceID.String())
+}
+
+// importId returns the import ID specified by the user, or the TF resource ID otherwise.
+func (i Item) importId() string {
+	if i.v.ImportId != "" {
+		return i.v.ImportId
+	}
+	return i.v.TFResourceId
+}
+
 func (i Item) FilterValue() string {
 	if i.v.ValidateError == nil && i.v.ImportError == nil && !i.v.Imported && !i.v.IsRecommended {
 		return i.v.TFResourceId
@@ -1,6 +1,9 @@
 package importlist
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"github.com/Azure/aztfexport/internal/i18n"
+	"github.com/charmbracelet/bubbles/key"
+)
 
 type listKeyMap struct {
 	skip           key.Binding
@@ -8,29 +11,79 @@ type listKeyMap struct {
 	recommendation key.Binding
 	apply          key.Binding
 	save           key.Binding
+	mark           key.Binding
+	invertMarks    key.Binding
+	skipMarked     key.Binding
+	setMarkedType  key.Binding
+	filterStatus   key.Binding
+	filterType     key.Binding
+	preview        key.Binding
+	importId       key.Binding
+	// closePreview is only enabled in the preview pane, hence not in the bindings of the list
+	closePreview key.Binding
 }
 
-func newListKeyMap() listKeyMap {
+// newListKeyMap returns the key map of the list. If genMappingFileOnly is set, the apply key saves the resource mapping file and quits, in place of importing.
+func newListKeyMap(genMappingFileOnly bool) listKeyMap {
+	applyHelp := i18n.T("import")
+	if genMappingFileOnly {
+		applyHelp = i18n.T("save mapping and quit")
+	}
 	return listKeyMap{
 		skip: key.NewBinding(
 			key.WithKeys("delete"),
-			key.WithHelp("delete", "skip"),
+			key.WithHelp("delete", i18n.T("skip")),
 		),
 		error: key.NewBinding(
 			key.WithKeys("e"),
-			key.WithHelp("e", "show error"),
+			key.WithHelp("e", i18n.T("show error")),
 		),
 		recommendation: key.NewBinding(
 			key.WithKeys("r"),
-			key.WithHelp("r", "show recommendation"),
+			key.WithHelp("r", i18n.T("show recommendation")),
 		),
 		apply: key.NewBinding(
 			key.WithKeys("w"),
-			key.WithHelp("w", "import"),
+			key.WithHelp("w", applyHelp),
 		),
 		save: key.NewBinding(
 			key.WithKeys("s"),
-			key.WithHelp("s", "save"),
+			key.WithHelp("s", i18n.T("save mapping")),
+		),
+		mark: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", i18n.T("mark")),
+		),
+		invertMarks: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", i18n.T("invert marks")),
+		),
+		skipMarked: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", i18n.T("skip marked")),
+		),
+		setMarkedType: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", i18n.T("apply type to marked")),
+		),
+		filterStatus: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", i18n.T("filter by status")),
+		),
+		filterType: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", i18n.T("filter by Azure type")),
+		),
+		preview: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", i18n.T("preview config")),
+		),
+		importId: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", i18n.T("edit import ID")),
+		),
+		closePreview: key.NewBinding(
+			key.WithKeys("esc", "q", "p"),
 		),
 	}
 }
@@ -42,5 +95,13 @@ func (m listKeyMap) ToBindings() []key.Binding {
 		m.recommendation,
 		m.apply,
 		m.save,
+		m.mark,
+		m.invertMarks,
+		m.skipMarked,
+		m.setMarkedType,
+		m.filterStatus,
+		m.filterType,
+		m.preview,
+		m.importId,
 	}
 }
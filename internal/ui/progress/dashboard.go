@@ -0,0 +1,135 @@
+package progress
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Azure/aztfexport/internal/i18n"
+	"github.com/Azure/aztfexport/pkg/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// etaWindow is the number of the latest import durations that the ETA is averaged on.
+const etaWindow = 10
+
+// EventMsg is sent on starting and finishing importing a resource.
+type EventMsg struct {
+	Resource config.HookResource
+	Done     bool
+	Failed   bool
+	Time     time.Time
+}
+
+// Hooks returns the hooks that send the import events to the channel, in addition to the original hooks.
+func Hooks(hooks config.Hooks, ch chan<- EventMsg) config.Hooks {
+	onStart, onDone := hooks.OnImportStart, hooks.OnImportDone
+	hooks.OnImportStart = func(res config.HookResource) {
+		if onStart != nil {
+			onStart(res)
+		}
+		ch <- EventMsg{Resource: res, Time: time.Now()}
+	}
+	hooks.OnImportDone = func(res config.HookResource, err error) {
+		if onDone != nil {
+			onDone(res, err)
+		}
+		ch <- EventMsg{Resource: res, Done: true, Failed: err != nil, Time: time.Now()}
+	}
+	return hooks
+}
+
+// WaitEvent waits for the next import event from the channel.
+func WaitEvent(ch <-chan EventMsg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+type worker struct {
+	res   config.HookResource
+	start time.Time
+}
+
+// dashboard tracks the resource being imported by each worker, the import results and the import durations.
+type dashboard struct {
+	// workers are the slots of the parallel workers, a nil slot is idle
+	workers   []*worker
+	total     int
+	succeeded int
+	failed    int
+	// durations are the latest import durations, at most etaWindow
+	durations []time.Duration
+}
+
+func newDashboard(parallelism, total int) dashboard {
+	return dashboard{
+		workers: make([]*worker, parallelism),
+		total:   total,
+	}
+}
+
+func (d *dashboard) update(msg EventMsg) {
+	if !msg.Done {
+		for i, w := range d.workers {
+			if w == nil {
+				d.workers[i] = &worker{res: msg.Resource, start: msg.Time}
+				return
+			}
+		}
+		d.workers = append(d.workers, &worker{res: msg.Resource, start: msg.Time})
+		return
+	}
+
+	if msg.Failed {
+		d.failed++
+	} else {
+		d.succeeded++
+	}
+	for i, w := range d.workers {
+		if w != nil && w.res.AzureResourceId == msg.Resource.AzureResourceId {
+			d.workers[i] = nil
+			d.durations = append(d.durations, msg.Time.Sub(w.start))
+			if len(d.durations) > etaWindow {
+				d.durations = d.durations[1:]
+			}
+			return
+		}
+	}
+}
+
+// eta estimates the remaining time by the moving average of the import durations, which is false if there is no import finished yet.
+func (d dashboard) eta() (time.Duration, bool) {
+	if len(d.durations) == 0 || len(d.workers) == 0 {
+		return 0, false
+	}
+	var sum time.Duration
+	for _, v := range d.durations {
+		sum += v
+	}
+	avg := sum / time.Duration(len(d.durations))
+	remaining := d.total - d.succeeded - d.failed
+	if remaining < 0 {
+		remaining = 0
+	}
+	return avg * time.Duration(remaining) / time.Duration(len(d.workers)), true
+}
+
+func (d dashboard) view(now time.Time) string {
+	s := " " + i18n.Sprintf("Imported %d/%d (%d succeeded, %d failed)", d.succeeded+d.failed, d.total, d.succeeded, d.failed)
+	if eta, ok := d.eta(); ok {
+		s += " - " + i18n.Sprintf("ETA %s", eta.Round(time.Second))
+	}
+	s += "\n\n"
+	for i, w := range d.workers {
+		if w == nil {
+			s += fmt.Sprintf(" [%d] %s\n", i+1, i18n.T("idle"))
+			continue
+		}
+		name := w.res.TFAddr
+		if name == "" {
+			name = w.res.TFResourceId
+		}
+		s += fmt.Sprintf(" [%d] %s (%s)\n", i+1, name, now.Sub(w.start).Truncate(time.Second))
+	}
+	return s
+}
@@ -2,9 +2,11 @@ package progress
 
 import (
 	"context"
-	"fmt"
+	"time"
+
 	"github.com/Azure/aztfexport/pkg/meta"
 
+	"github.com/Azure/aztfexport/internal/i18n"
 	"github.com/Azure/aztfexport/internal/ui/aztfexportclient"
 	"github.com/Azure/aztfexport/internal/ui/common"
 	prog "github.com/charmbracelet/bubbles/progress"
@@ -24,11 +26,18 @@ type Model struct {
 	idx         int
 	parallelism int
 
-	results  []result
-	progress prog.Model
+	results   []result
+	progress  prog.Model
+	dashboard dashboard
 }
 
 func NewModel(ctx context.Context, c meta.Meta, parallelism int, l meta.ImportList) Model {
+	var total int
+	for _, item := range l {
+		if !item.Skip() && !item.Imported {
+			total++
+		}
+	}
 	return Model{
 		ctx:         ctx,
 		c:           c,
@@ -37,6 +46,7 @@ func NewModel(ctx context.Context, c meta.Meta, parallelism int, l meta.ImportLi
 		parallelism: parallelism,
 		results:     make([]result, common.ProgressShowLastResults),
 		progress:    prog.NewModel(prog.WithDefaultGradient()),
+		dashboard:   newDashboard(parallelism, total),
 	}
 }
 
@@ -65,6 +75,10 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		m.progress = progressModel.(prog.Model)
 		return m, cmd
 
+	case EventMsg:
+		m.dashboard.update(msg)
+		return m, nil
+
 	case aztfexportclient.ImportItemsDoneMsg:
 		var cmds []tea.Cmd
 
@@ -107,17 +121,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 }
 
 func (m Model) View() string {
-	msg := ""
-	if len(m.l) > m.idx {
-		item := m.l[m.idx]
-		if item.Skip() {
-			msg = fmt.Sprintf(" Skipping %s...", item.TFResourceId)
-		} else {
-			msg = fmt.Sprintf(" Importing %s...", item.TFResourceId)
-		}
-	}
-
-	s := fmt.Sprintf(" %s\n\n", msg)
+	s := m.dashboard.view(time.Now()) + "\n"
 	for _, res := range m.results {
 		// This indicates the state before the item is inserted as the to results.
 		if res.item.TFResourceId == "" {
@@ -125,12 +129,12 @@ func (m Model) View() string {
 		} else {
 			switch {
 			case res.item.Skip():
-				s += fmt.Sprintf("%s %s skipped\n", res.emoji, res.item.TFResourceId)
+				s += res.emoji + " " + i18n.Sprintf("%s skipped", res.item.TFResourceId) + "\n"
 			default:
 				if res.item.ImportError == nil {
-					s += fmt.Sprintf("%s %s import successfully\n", res.emoji, res.item.TFResourceId)
+					s += res.emoji + " " + i18n.Sprintf("%s import successfully", res.item.TFResourceId) + "\n"
 				} else {
-					s += fmt.Sprintf("%s %s import failed\n", res.emoji, res.item.TFResourceId)
+					s += res.emoji + " " + i18n.Sprintf("%s import failed", res.item.TFResourceId) + "\n"
 				}
 			}
 		}
@@ -3,8 +3,12 @@ package ui
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/Azure/aztfexport/internal/config"
+	"github.com/Azure/aztfexport/internal/costestimate"
+	"github.com/Azure/aztfexport/internal/i18n"
 	internalmeta "github.com/Azure/aztfexport/internal/meta"
 	"github.com/Azure/aztfexport/pkg/log"
 	"github.com/Azure/aztfexport/pkg/meta"
@@ -15,6 +19,7 @@ import (
 
 	"github.com/muesli/reflow/indent"
 
+	"github.com/Azure/aztfexport/internal/ui/failurelist"
 	"github.com/Azure/aztfexport/internal/ui/importlist"
 	"github.com/Azure/aztfexport/internal/ui/progress"
 
@@ -40,8 +45,11 @@ const (
 	statusBuildingImportList
 	statusImporting
 	statusImportErrorMsg
+	statusReviewingFailures
 	statusGeneratingCfg
 	statusCleaningUpWorkspaceCfg
+	statusConvertingToPulumi
+	statusEstimatingCost
 	statusPushState
 	statusExportResourceMapping
 	statusExportSkippedResources
@@ -57,8 +65,11 @@ func (s status) String() string {
 		"building import list",
 		"importing",
 		"import error message",
+		"reviewing import failures",
 		"generating Terraform configuration",
 		"cleaning up output directory",
+		"converting to Pulumi program",
+		"estimating cost",
 		"pushing state",
 		"exporting resource mapping file",
 		"exporting skipped resources file",
@@ -69,9 +80,13 @@ func (s status) String() string {
 }
 
 type model struct {
-	ctx         context.Context
-	meta        meta.Meta
-	parallelism int
+	ctx          context.Context
+	meta         meta.Meta
+	parallelism  int
+	costEstimate bool
+	pulumiLang   string
+	// genMappingFileOnly quits after saving the resource mapping file of the import list, without importing.
+	genMappingFileOnly bool
 
 	status status
 	err    error
@@ -79,16 +94,29 @@ type model struct {
 	// winsize is used to keep track of current windows size, it is used to set the size for other models that are initialized in status (e.g. the importlist).
 	winsize tea.WindowSizeMsg
 
-	spinner        spinner.Model
-	importlist     importlist.Model
-	progress       progress.Model
+	spinner    spinner.Model
+	importlist importlist.Model
+	// failurelist reviews the resources that failed to import
+	failurelist failurelist.Model
+	progress    progress.Model
+	// events receives the import events of the meta, which feed the progress dashboard
+	events         chan progress.EventMsg
 	importerrormsg aztfexportclient.ShowImportErrorMsg
+
+	pulumiDir   string
+	estimate    *costestimate.Estimate
+	estimateErr error
+	// The resources that are under management locks
+	locked meta.ImportList
 }
 
 func newModel(ctx context.Context, cfg config.InteractiveModeConfig) (*model, error) {
 	s := spinner.NewModel()
 	s.Spinner = common.Spinner
 
+	events := make(chan progress.EventMsg, cfg.Parallelism)
+	cfg.Config.Hooks = progress.Hooks(cfg.Config.Hooks, events)
+
 	var c meta.Meta = internalmeta.NewGroupMetaDummy(cfg.ResourceGroupName)
 	if !cfg.MockMeta {
 		var err error
@@ -99,11 +127,15 @@ func newModel(ctx context.Context, cfg config.InteractiveModeConfig) (*model, er
 	}
 
 	m := &model{
-		ctx:         ctx,
-		meta:        c,
-		parallelism: cfg.Parallelism,
-		status:      statusInit,
-		spinner:     s,
+		ctx:                ctx,
+		meta:               c,
+		parallelism:        cfg.Parallelism,
+		costEstimate:       cfg.CostEstimate,
+		pulumiLang:         cfg.PulumiLanguage,
+		genMappingFileOnly: cfg.GenMappingFileOnly,
+		status:             statusInit,
+		spinner:            s,
+		events:             events,
 	}
 
 	return m, nil
@@ -113,6 +145,7 @@ func (m model) Init() tea.Cmd {
 	return tea.Batch(
 		aztfexportclient.NewClient(m.meta),
 		spinner.Tick,
+		progress.WaitEvent(m.events),
 	)
 }
 
@@ -134,6 +167,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
+	case progress.EventMsg:
+		// The events are only shown during importing, but are always received to not block the import
+		if m.status == statusImporting {
+			m.progress, _ = m.progress.Update(msg)
+		}
+		return m, progress.WaitEvent(m.events)
 	case aztfexportclient.NewClientMsg:
 		m.meta = msg
 		m.status = statusInit
@@ -143,7 +182,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, aztfexportclient.ListResource(m.ctx, m.meta)
 	case aztfexportclient.ListResourceDoneMsg:
 		m.status = statusBuildingImportList
-		m.importlist = importlist.NewModel(m.ctx, m.meta, msg.List, 0)
+		m.importlist = importlist.NewModel(m.ctx, m.meta, msg.List, 0, m.genMappingFileOnly)
 		// Trigger a windows resize cmd to resize the importlist model.
 		// Though we can pass the winsize as input variable during model initialization.
 		// But this way we only need to maintain the resizing logic at one place (which takes consideration of the title height).
@@ -153,6 +192,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.status = statusImportErrorMsg
 		m.importerrormsg = msg
 		return m, nil
+	case aztfexportclient.EditImportListMsg:
+		m.status = statusBuildingImportList
+		m.importlist = importlist.NewModel(m.ctx, m.meta, msg.List, msg.Index, m.genMappingFileOnly)
+		cmd := func() tea.Msg { return m.winsize }
+		return m, cmd
 	case aztfexportclient.StartImportMsg:
 		m.status = statusImporting
 		m.progress = progress.NewModel(m.ctx, m.meta, m.parallelism, msg.List)
@@ -162,20 +206,25 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			func() tea.Msg { return m.winsize },
 		)
 	case aztfexportclient.ImportDoneMsg:
-		for idx, item := range msg.List {
+		for _, item := range msg.List {
 			if item.ImportError != nil {
-				m.status = statusBuildingImportList
-				m.importlist = importlist.NewModel(m.ctx, m.meta, msg.List, idx)
+				m.status = statusReviewingFailures
+				m.failurelist = failurelist.NewModel(m.ctx, m.meta, msg.List)
 				cmd := func() tea.Msg { return m.winsize }
 				return m, cmd
 			}
 		}
+		m.locked = msg.List.Locked()
 		m.status = statusPushState
 		return m, aztfexportclient.PushState(m.ctx, m.meta, msg.List)
 	case aztfexportclient.PushStateDoneMsg:
 		m.status = statusExportResourceMapping
 		return m, aztfexportclient.ExportResourceMapping(m.ctx, m.meta, msg.List)
 	case aztfexportclient.ExportResourceMappingDoneMsg:
+		if m.genMappingFileOnly {
+			m.status = statusSummary
+			return m, nil
+		}
 		m.status = statusExportSkippedResources
 		return m, aztfexportclient.ExportSkippedResources(m.ctx, m.meta, msg.List)
 	case aztfexportclient.ExportSkippedResourcesDoneMsg:
@@ -185,6 +234,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.status = statusCleaningUpWorkspaceCfg
 		return m, aztfexportclient.CleanUpWorkspace(m.ctx, m.meta)
 	case aztfexportclient.WorkspaceCleanupDoneMsg:
+		if m.pulumiLang != "" {
+			m.status = statusConvertingToPulumi
+			return m, aztfexportclient.ConvertToPulumi(m.ctx, m.meta, m.pulumiLang)
+		}
+		return m.estimateCostOrSummary()
+	case aztfexportclient.ConvertToPulumiDoneMsg:
+		m.pulumiDir = msg.OutputDir
+		return m.estimateCostOrSummary()
+	case aztfexportclient.EstimateCostDoneMsg:
+		m.estimate = msg.Estimate
+		m.estimateErr = msg.Err
 		m.status = statusSummary
 		return m, nil
 	case aztfexportclient.QuitMsg:
@@ -201,6 +261,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return updateChildren(msg, m)
 }
 
+func (m model) estimateCostOrSummary() (model, tea.Cmd) {
+	if m.costEstimate {
+		m.status = statusEstimatingCost
+		return m, aztfexportclient.EstimateCost(m.ctx, m.meta)
+	}
+	m.status = statusSummary
+	return m, nil
+}
+
 func updateChildren(msg tea.Msg, m model) (model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch m.status {
@@ -210,10 +279,13 @@ func updateChildren(msg tea.Msg, m model) (model, tea.Cmd) {
 	case statusImportErrorMsg:
 		if _, ok := msg.(tea.KeyMsg); ok {
 			m.status = statusBuildingImportList
-			m.importlist = importlist.NewModel(m.ctx, m.meta, m.importerrormsg.List, m.importerrormsg.Index)
+			m.importlist = importlist.NewModel(m.ctx, m.meta, m.importerrormsg.List, m.importerrormsg.Index, m.genMappingFileOnly)
 			cmd = func() tea.Msg { return m.winsize }
 			return m, cmd
 		}
+	case statusReviewingFailures:
+		m.failurelist, cmd = m.failurelist.Update(msg)
+		return m, cmd
 	case statusImporting:
 		m.progress, cmd = m.progress.Update(msg)
 		return m, cmd
@@ -232,25 +304,31 @@ func (m model) View() string {
 
 	switch m.status {
 	case statusInit:
-		s += m.spinner.View() + " Initializing..."
+		s += m.spinner.View() + " " + i18n.T("Initializing...")
 	case statusListingResource:
-		s += m.spinner.View() + " Listing Azure Resources..."
+		s += m.spinner.View() + " " + i18n.T("Listing Azure Resources...")
 	case statusBuildingImportList:
 		s += m.importlist.View()
 	case statusImportErrorMsg:
 		s += importErrorView(m)
+	case statusReviewingFailures:
+		s += m.failurelist.View()
 	case statusImporting:
 		s += m.spinner.View() + m.progress.View()
 	case statusPushState:
-		s += m.spinner.View() + " Pushing Terraform Status..."
+		s += m.spinner.View() + " " + i18n.T("Pushing Terraform Status...")
 	case statusExportResourceMapping:
-		s += m.spinner.View() + " Exporting Resource Mapping..."
+		s += m.spinner.View() + " " + i18n.T("Exporting Resource Mapping...")
 	case statusExportSkippedResources:
-		s += m.spinner.View() + " Exporting Skipped Resources..."
+		s += m.spinner.View() + " " + i18n.T("Exporting Skipped Resources...")
 	case statusGeneratingCfg:
-		s += m.spinner.View() + " Generating Terraform Configurations..."
+		s += m.spinner.View() + " " + i18n.T("Generating Terraform Configurations...")
 	case statusCleaningUpWorkspaceCfg:
-		s += m.spinner.View() + " Cleaning up the output directory..."
+		s += m.spinner.View() + " " + i18n.T("Cleaning up the output directory...")
+	case statusConvertingToPulumi:
+		s += m.spinner.View() + " " + i18n.T("Converting to Pulumi Program...")
+	case statusEstimatingCost:
+		s += m.spinner.View() + " " + i18n.T("Estimating Cost...")
 	case statusSummary:
 		s += summaryView(m)
 	case statusError:
@@ -261,7 +339,7 @@ func (m model) View() string {
 }
 
 func (m model) logoView() string {
-	return "\n" + common.TitleStyle.Render(" Microsoft Azure Export for Terraform ") + "\n\n"
+	return "\n" + common.TitleStyle.Render(" "+i18n.T("Microsoft Azure Export for Terraform")+" ") + "\n\n"
 }
 
 func importErrorView(m model) string {
@@ -269,9 +347,38 @@ func importErrorView(m model) string {
 }
 
 func summaryView(m model) string {
-	return fmt.Sprintf("Terraform state and the config are generated at: %s\n\n", m.meta.Workspace()) + common.QuitMsgStyle.Render("Press any key to quit\n")
+	if m.genMappingFileOnly {
+		return i18n.Sprintf("Resource mapping file is generated at: %s", filepath.Join(m.meta.Workspace(), internalmeta.ResourceMappingFileName)) + "\n\n" +
+			common.QuitMsgStyle.Render(i18n.T("Press any key to quit")+"\n")
+	}
+	s := i18n.Sprintf("Terraform state and the config are generated at: %s", m.meta.Workspace()) + "\n\n"
+	if m.pulumiDir != "" {
+		s += i18n.Sprintf("Pulumi program is generated at: %s", m.pulumiDir) + "\n\n"
+	}
+	if len(m.locked) != 0 {
+		s += fmt.Sprintf("%s\n\n%s\n\n", i18n.T("Resources under management locks:"), lockedResources(m.locked))
+	}
+	if m.estimate != nil {
+		s += fmt.Sprintf("%s\n\n%s\n\n", i18n.T("Cost estimate:"), m.estimate)
+	}
+	if m.estimateErr != nil {
+		s += fmt.Sprintf("%s\n\n%s\n\n", i18n.T("Warnings:"), fmt.Sprintf("estimating cost: %v", m.estimateErr))
+	}
+	return s + common.QuitMsgStyle.Render(i18n.T("Press any key to quit")+"\n")
 }
 
 func errorView(m model) string {
 	return common.ErrorMsgStyle.Render(wordwrap.WrapString(m.err.Error(), uint(m.winsize.Width-indentLevel)))
 }
+
+func lockedResources(l meta.ImportList) string {
+	var lines []string
+	for _, item := range l {
+		line := fmt.Sprintf("%s %s (%s)", common.LockEmoji, item.TFResourceId, item.Lock)
+		if item.Skip() {
+			line += " (" + i18n.T("Skipped") + ")"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -2,11 +2,11 @@ package utils
 
 import (
 	"fmt"
-	"io"
 	"os"
 )
 
-func DirIsEmpty(path string) (bool, error) {
+// DirIsEmpty tells whether the directory is empty, where the entries of the skipps (e.g. the lock file) are ignored.
+func DirIsEmpty(path string, skipps ...string) (bool, error) {
 	stat, err := os.Stat(path)
 	if os.IsNotExist(err) {
 		return false, fmt.Errorf("the path %q doesn't exist", path)
@@ -19,18 +19,24 @@ func DirIsEmpty(path string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	_, err = dir.Readdirnames(1)
+
+	skipMap := map[string]bool{}
+	for _, v := range skipps {
+		skipMap[v] = true
+	}
+
+	entries, err := dir.Readdirnames(0)
 	if err != nil {
-		if err == io.EOF {
-			if err := dir.Close(); err != nil {
-				return false, fmt.Errorf("closing dir %s: %v", path, err)
-			}
-			return true, nil
-		}
+		dir.Close()
 		return false, err
 	}
 	if err := dir.Close(); err != nil {
 		return false, fmt.Errorf("closing dir %s: %v", path, err)
 	}
-	return false, nil
+	for _, entry := range entries {
+		if !skipMap[entry] {
+			return false, nil
+		}
+	}
+	return true, nil
 }
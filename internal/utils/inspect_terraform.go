@@ -47,6 +47,9 @@ func InspecTerraformBlock(dir string) (*TerraformBlockDetail, error) {
 				switch block.Type {
 				case "backend":
 					detail.BackendType = block.Labels[0]
+				case "cloud":
+					// The HCP Terraform workspace is regarded as the "cloud" backend type
+					detail.BackendType = "cloud"
 				}
 			}
 			return &detail, nil
@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// SetProcessGroup starts the command in its own process group, so that it doesn't receive the Ctrl-C events of the console.
+func SetProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
@@ -0,0 +1,183 @@
+// Package verify runs "terraform plan" against an export output, and reports the resources whose plan is not empty, which usually
+// indicates that the generated config is incomplete.
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// ReportFileName is the file under the output directory that records the verification report.
+const ReportFileName = "aztfexportVerifyReport.json"
+
+const (
+	unknownValue   = "(known after apply)"
+	sensitiveValue = "(sensitive value)"
+)
+
+// AttributeDiff is the diff of one (flattened) attribute, e.g. "site_config.0.always_on". Before is nil if the attribute is added,
+// After is nil if the attribute is removed.
+type AttributeDiff struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ResourceDiff is a resource whose plan is not empty.
+type ResourceDiff struct {
+	Address    string          `json:"address"`
+	Actions    []string        `json:"actions"`
+	Attributes []AttributeDiff `json:"attributes,omitempty"`
+}
+
+type Report struct {
+	// Resources are the resources whose plan is not empty, sorted by the address.
+	Resources []ResourceDiff `json:"resources"`
+}
+
+// Run runs "terraform plan" in the directory, which is expected to be initialized, and builds the report from the plan.
+func Run(ctx context.Context, dir string) (*Report, error) {
+	execPath, err := meta.FindTerraform(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("finding the terraform executable: %v", err)
+	}
+	tf, err := tfexec.NewTerraform(dir, execPath)
+	if err != nil {
+		return nil, fmt.Errorf("new terraform: %v", err)
+	}
+
+	f, err := os.CreateTemp("", "aztfexport-verify-*.tfplan")
+	if err != nil {
+		return nil, fmt.Errorf("creating the temporary plan file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("closing the temporary plan file %s: %v", f.Name(), err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := tf.Plan(ctx, tfexec.Out(f.Name()), tfexec.Refresh(true)); err != nil {
+		return nil, fmt.Errorf("running terraform plan: %v", err)
+	}
+	plan, err := tf.ShowPlanFile(ctx, f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("showing the plan file: %v", err)
+	}
+	report := buildReport(plan)
+	return &report, nil
+}
+
+func buildReport(plan *tfjson.Plan) Report {
+	report := Report{Resources: []ResourceDiff{}}
+	for _, rc := range plan.ResourceChanges {
+		if rc.Mode != tfjson.ManagedResourceMode || rc.Change == nil {
+			continue
+		}
+		if rc.Change.Actions.NoOp() || rc.Change.Actions.Read() {
+			continue
+		}
+		var actions []string
+		for _, action := range rc.Change.Actions {
+			actions = append(actions, string(action))
+		}
+		report.Resources = append(report.Resources, ResourceDiff{
+			Address:    rc.Address,
+			Actions:    actions,
+			Attributes: attributeDiffs(rc.Change),
+		})
+	}
+	sort.Slice(report.Resources, func(i, j int) bool {
+		return report.Resources[i].Address < report.Resources[j].Address
+	})
+	return report
+}
+
+// attributeDiffs returns the diffs of the leaf attributes between the before and after values of the change, sorted by the path.
+func attributeDiffs(change *tfjson.Change) []AttributeDiff {
+	before, after := map[string]interface{}{}, map[string]interface{}{}
+	flatten("", change.Before, before)
+	flatten("", change.After, after)
+
+	unknown, beforeSensitive, afterSensitive := map[string]interface{}{}, map[string]interface{}{}, map[string]interface{}{}
+	flatten("", change.AfterUnknown, unknown)
+	flatten("", change.BeforeSensitive, beforeSensitive)
+	flatten("", change.AfterSensitive, afterSensitive)
+	for path, v := range unknown {
+		if v == true {
+			after[path] = unknownValue
+		}
+	}
+
+	paths := map[string]bool{}
+	for path := range before {
+		paths[path] = true
+	}
+	for path := range after {
+		paths[path] = true
+	}
+
+	var diffs []AttributeDiff
+	for path := range paths {
+		b, a := before[path], after[path]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		if b != nil && beforeSensitive[path] == true {
+			b = sensitiveValue
+		}
+		if a != nil && a != unknownValue && afterSensitive[path] == true {
+			a = sensitiveValue
+		}
+		diffs = append(diffs, AttributeDiff{Path: path, Before: b, After: a})
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs
+}
+
+// flatten flattens the nested JSON value into the leaf values keyed by their paths, e.g. "a.0.b". The null values are omitted.
+func flatten(prefix string, v interface{}, out map[string]interface{}) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch v := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, vv := range v {
+			flatten(join(k), vv, out)
+		}
+	case []interface{}:
+		for i, vv := range v {
+			flatten(join(strconv.Itoa(i)), vv, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
+
+// Write writes the report to the directory.
+func (r Report) Write(dir string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the verification report: %v", err)
+	}
+	path := filepath.Join(dir, ReportFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing the verification report to %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package verify
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildReport(t *testing.T) {
+	plan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			{
+				Address: "azurerm_resource_group.res-0",
+				Mode:    tfjson.ManagedResourceMode,
+				Change: &tfjson.Change{
+					Actions: tfjson.Actions{tfjson.ActionNoop},
+					Before:  map[string]interface{}{"name": "rg"},
+					After:   map[string]interface{}{"name": "rg"},
+				},
+			},
+			{
+				Address: "data.azurerm_key_vault_secret.secret",
+				Mode:    tfjson.DataResourceMode,
+				Change: &tfjson.Change{
+					Actions: tfjson.Actions{tfjson.ActionRead},
+				},
+			},
+			{
+				Address: "azurerm_linux_web_app.res-2",
+				Mode:    tfjson.ManagedResourceMode,
+				Change: &tfjson.Change{
+					Actions: tfjson.Actions{tfjson.ActionUpdate},
+					Before: map[string]interface{}{
+						"name":        "app",
+						"site_config": []interface{}{map[string]interface{}{"always_on": true, "ftps_state": "Disabled"}},
+						"tags":        map[string]interface{}{"env": "dev"},
+						"password":    "secret",
+					},
+					After: map[string]interface{}{
+						"name":        "app",
+						"site_config": []interface{}{map[string]interface{}{"always_on": false, "ftps_state": "Disabled"}},
+						"tags":        map[string]interface{}{},
+						"password":    "new-secret",
+					},
+					AfterUnknown:    map[string]interface{}{"id": true},
+					BeforeSensitive: map[string]interface{}{"password": true},
+					AfterSensitive:  map[string]interface{}{"password": true},
+				},
+			},
+			{
+				Address: "azurerm_virtual_network.res-1",
+				Mode:    tfjson.ManagedResourceMode,
+				Change: &tfjson.Change{
+					Actions: tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate},
+					Before:  map[string]interface{}{"location": "westus"},
+					After:   map[string]interface{}{"location": "eastus"},
+				},
+			},
+		},
+	}
+
+	require.Equal(t, Report{
+		Resources: []ResourceDiff{
+			{
+				Address: "azurerm_linux_web_app.res-2",
+				Actions: []string{"update"},
+				Attributes: []AttributeDiff{
+					{Path: "id", Before: nil, After: unknownValue},
+					{Path: "password", Before: sensitiveValue, After: sensitiveValue},
+					{Path: "site_config.0.always_on", Before: true, After: false},
+					{Path: "tags.env", Before: "dev", After: nil},
+				},
+			},
+			{
+				Address: "azurerm_virtual_network.res-1",
+				Actions: []string{"delete", "create"},
+				Attributes: []AttributeDiff{
+					{Path: "location", Before: "westus", After: "eastus"},
+				},
+			},
+		},
+	}, buildReport(plan))
+}
@@ -0,0 +1,233 @@
+// Package watch periodically discovers the resources of a scope, and reports the ones that are not managed by the exported workspace yet.
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/aztfexport/internal"
+	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/pkg/log"
+)
+
+// StateFileName is the file under the output directory that records the resource ids that are known to be managed.
+const StateFileName = ".aztfexport-watch.json"
+
+type Config struct {
+	// OutputDir is the exported workspace to watch for
+	OutputDir string
+	// Interval is the interval between two rounds of discovery
+	Interval time.Duration
+	// Once specifies to only run one round of discovery
+	Once bool
+	// Branch is the git branch in the output directory that the newly appeared resources are exported to. Empty means only reporting them.
+	Branch string
+
+	// Discover lists the ids of the resources in the scope
+	Discover func(ctx context.Context) ([]string, error)
+	// Export exports the resources of the specified ids to the output directory, in append mode, and returns the ids of the resources that are exported.
+	// The reported resources are regarded as known regardless, so that they are only reported once.
+	Export func(ctx context.Context, ids []string) ([]string, error)
+
+	// Out is where the reports are written to
+	Out io.Writer
+}
+
+type state struct {
+	Known []string `json:"known"`
+}
+
+func Run(ctx context.Context, cfg Config) error {
+	if cfg.Branch != "" {
+		if _, err := git(ctx, cfg.OutputDir, "rev-parse", "--is-inside-work-tree"); err != nil {
+			return fmt.Errorf("the output directory %s is not a git repository: %v", cfg.OutputDir, err)
+		}
+	}
+	// The partially succeeded export doesn't stop watching, the last partial success is returned once the watch ends.
+	var partialErr error
+	for {
+		if err := runOnce(ctx, cfg); err != nil {
+			var perr *internal.PartialSuccessError
+			if !errors.As(err, &perr) {
+				return err
+			}
+			partialErr = err
+		}
+		if cfg.Once {
+			return partialErr
+		}
+		select {
+		case <-ctx.Done():
+			return partialErr
+		case <-time.After(cfg.Interval):
+		}
+	}
+}
+
+func runOnce(ctx context.Context, cfg Config) error {
+	known, err := loadKnown(cfg.OutputDir)
+	if err != nil {
+		return err
+	}
+	ids, err := cfg.Discover(ctx)
+	if err != nil {
+		return fmt.Errorf("discovering resources: %v", err)
+	}
+	unmanaged := unmanagedIds(known, ids)
+
+	now := time.Now().Format(time.RFC3339)
+	if len(unmanaged) == 0 {
+		fmt.Fprintf(cfg.Out, "[%s] No unmanaged resource found\n", now)
+		return nil
+	}
+	fmt.Fprintf(cfg.Out, "[%s] Found %d unmanaged resource(s):\n", now, len(unmanaged))
+	for _, id := range unmanaged {
+		fmt.Fprintf(cfg.Out, "  %s\n", id)
+	}
+
+	var partialErr error
+	if cfg.Branch != "" {
+		if err := exportToBranch(ctx, cfg, unmanaged); err != nil {
+			var perr *internal.PartialSuccessError
+			if !errors.As(err, &perr) {
+				return err
+			}
+			partialErr = err
+			fmt.Fprintf(cfg.Out, "[%s] Exported %d resource(s) to branch %s, %v\n", time.Now().Format(time.RFC3339), len(unmanaged), cfg.Branch, err)
+		} else {
+			fmt.Fprintf(cfg.Out, "[%s] Exported %d resource(s) to branch %s\n", time.Now().Format(time.RFC3339), len(unmanaged), cfg.Branch)
+		}
+	}
+
+	// The reported resources are regarded as known, so that they are only reported once.
+	if err := saveKnown(cfg.OutputDir, append(known, unmanaged...)); err != nil {
+		return err
+	}
+	return partialErr
+}
+
+// unmanagedIds returns the ids that are not known, case insensitively.
+func unmanagedIds(known, ids []string) []string {
+	set := map[string]bool{}
+	for _, id := range known {
+		set[strings.ToUpper(id)] = true
+	}
+	var out []string
+	for _, id := range ids {
+		if set[strings.ToUpper(id)] {
+			continue
+		}
+		set[strings.ToUpper(id)] = true
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// loadKnown loads the known resource ids from the watch state file. If it doesn't exist yet, the resources in the resource mapping file of the last export are used as the baseline.
+func loadKnown(dir string) ([]string, error) {
+	b, err := os.ReadFile(filepath.Join(dir, StateFileName))
+	if err == nil {
+		var st state
+		if err := json.Unmarshal(b, &st); err != nil {
+			return nil, fmt.Errorf("unmarshalling the watch state file: %v", err)
+		}
+		return st.Known, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading the watch state file: %v", err)
+	}
+
+	b, err = os.ReadFile(filepath.Join(dir, meta.ResourceMappingFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading the resource mapping file: %v", err)
+	}
+	var m resmap.ResourceMapping
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("unmarshalling the resource mapping file: %v", err)
+	}
+	var known []string
+	for id := range m {
+		known = append(known, id)
+	}
+	sort.Strings(known)
+	return known, nil
+}
+
+func saveKnown(dir string, known []string) error {
+	b, err := json.MarshalIndent(state{Known: known}, "", "\t")
+	if err != nil {
+		return fmt.Errorf("marshalling the watch state: %v", err)
+	}
+	// #nosec G306
+	if err := os.WriteFile(filepath.Join(dir, StateFileName), b, 0644); err != nil {
+		return fmt.Errorf("writing the watch state file: %v", err)
+	}
+	return nil
+}
+
+// exportToBranch exports the resources to the output directory on the git branch, and commits the changes.
+func exportToBranch(ctx context.Context, cfg Config, ids []string) error {
+	if _, err := git(ctx, cfg.OutputDir, "rev-parse", "--verify", "--quiet", "refs/heads/"+cfg.Branch); err == nil {
+		if _, err := git(ctx, cfg.OutputDir, "checkout", cfg.Branch); err != nil {
+			return err
+		}
+	} else {
+		if _, err := git(ctx, cfg.OutputDir, "checkout", "-b", cfg.Branch); err != nil {
+			return err
+		}
+	}
+	// The partially succeeded export is still committed, the partial success is returned after the commit.
+	_, exportErr := cfg.Export(ctx, ids)
+	if exportErr != nil {
+		var perr *internal.PartialSuccessError
+		if !errors.As(exportErr, &perr) {
+			return fmt.Errorf("exporting the unmanaged resources: %v", exportErr)
+		}
+	}
+	if _, err := git(ctx, cfg.OutputDir, append([]string{"add", "-A", "--"}, exportPathspecs(cfg.OutputDir)...)...); err != nil {
+		return err
+	}
+	// Nothing to commit if the export doesn't change the generated config.
+	if _, err := git(ctx, cfg.OutputDir, "diff", "--cached", "--quiet"); err == nil {
+		return exportErr
+	}
+	if _, err := git(ctx, cfg.OutputDir, "commit", "-m", fmt.Sprintf("Export %d unmanaged resource(s) by aztfexport watch", len(ids))); err != nil {
+		return err
+	}
+	return exportErr
+}
+
+// exportPathspecs returns the git pathspecs of the generated config and the resource mapping file under the output directory, which are committed.
+// The others, e.g. the state (which contains the secrets of the resources), the provider plugins, the lock file and the logs, are left untracked.
+func exportPathspecs(dir string) []string {
+	pathspecs := []string{":(glob)**/*.tf", ":(exclude,glob)**/.terraform/**"}
+	if _, err := os.Stat(filepath.Join(dir, meta.ResourceMappingFileName)); err == nil {
+		pathspecs = append(pathspecs, meta.ResourceMappingFileName)
+	}
+	return pathspecs
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	log.Printf("[DEBUG] Running git %s", strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("running git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out), nil
+}
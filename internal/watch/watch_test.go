@@ -0,0 +1,154 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal"
+	"github.com/Azure/aztfexport/internal/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmanagedIds(t *testing.T) {
+	known := []string{
+		"/subscriptions/123/resourceGroups/rg",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+	}
+	ids := []string{
+		"/subscriptions/123/resourceGroups/RG",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/disks/disk",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/disks/DISK",
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/disks/disk",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa",
+	}, unmanagedIds(known, ids))
+}
+
+func TestLoadKnown(t *testing.T) {
+	dir := t.TempDir()
+
+	known, err := loadKnown(dir)
+	require.NoError(t, err)
+	require.Empty(t, known)
+
+	mapping := `{
+  "/subscriptions/123/resourceGroups/rg": {"resource_id": "/subscriptions/123/resourceGroups/rg", "resource_type": "azurerm_resource_group", "resource_name": "res-0"}
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, meta.ResourceMappingFileName), []byte(mapping), 0644))
+	known, err = loadKnown(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/subscriptions/123/resourceGroups/rg"}, known)
+
+	require.NoError(t, saveKnown(dir, []string{"/subscriptions/123/resourceGroups/rg", "/subscriptions/123/resourceGroups/rg2"}))
+	known, err = loadKnown(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/subscriptions/123/resourceGroups/rg", "/subscriptions/123/resourceGroups/rg2"}, known)
+}
+
+// newGitRepo returns a git repository with an empty initial commit.
+func newGitRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+		{"commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		_, err := git(context.Background(), dir, args...)
+		require.NoError(t, err)
+	}
+	return dir
+}
+
+func TestExportToBranch(t *testing.T) {
+	ctx := context.Background()
+	dir := newGitRepo(t)
+
+	cfg := Config{
+		OutputDir: dir,
+		Branch:    "watch",
+		Export: func(ctx context.Context, ids []string) ([]string, error) {
+			for _, name := range []string{
+				"main.tf",
+				"provider.tf",
+				filepath.Join("modules", "rg", "main.tf"),
+				filepath.Join(".terraform", "modules", "foo", "main.tf"),
+				filepath.Join(".terraform", "providers", "registry.terraform.io", "terraform-provider-azurerm"),
+				meta.ResourceMappingFileName,
+				"terraform.tfstate",
+				meta.WorkspaceLockFileName,
+				"aztfexportAuditLog.jsonl",
+			} {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					return nil, err
+				}
+				if err := os.WriteFile(path, []byte(strings.Join(ids, "\n")), 0644); err != nil {
+					return nil, err
+				}
+			}
+			return ids, nil
+		},
+	}
+	require.NoError(t, exportToBranch(ctx, cfg, []string{"/subscriptions/123/resourceGroups/rg"}))
+	require.NoError(t, saveKnown(dir, []string{"/subscriptions/123/resourceGroups/rg"}))
+
+	out, err := git(ctx, dir, "ls-tree", "-r", "--name-only", "watch")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		meta.ResourceMappingFileName,
+		"main.tf",
+		"modules/rg/main.tf",
+		"provider.tf",
+	}, strings.Fields(out))
+
+	// The watch state file saved after the commit is not committed by the next export either.
+	require.NoError(t, exportToBranch(ctx, cfg, []string{"/subscriptions/123/resourceGroups/rg2"}))
+	out, err = git(ctx, dir, "ls-tree", "-r", "--name-only", "watch")
+	require.NoError(t, err)
+	require.NotContains(t, strings.Fields(out), StateFileName)
+	out, err = git(ctx, dir, "rev-list", "--count", "watch")
+	require.NoError(t, err)
+	require.Equal(t, "3", strings.TrimSpace(out))
+}
+
+func TestRunPartialSuccess(t *testing.T) {
+	dir := newGitRepo(t)
+	partial := &internal.PartialSuccessError{Problems: []internal.Problem{{Kind: internal.ProblemKindUnsupported, AzureResourceId: "/subscriptions/123/resourceGroups/rg"}}}
+
+	err := Run(context.Background(), Config{
+		OutputDir: dir,
+		Once:      true,
+		Branch:    "watch",
+		Discover: func(ctx context.Context) ([]string, error) {
+			return []string{"/subscriptions/123/resourceGroups/rg"}, nil
+		},
+		Export: func(ctx context.Context, ids []string) ([]string, error) {
+			if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte("foo"), 0644); err != nil {
+				return nil, err
+			}
+			return nil, partial
+		},
+		Out: &bytes.Buffer{},
+	})
+	require.ErrorIs(t, err, partial)
+
+	// The partially succeeded export is committed, and the resources are known.
+	out, err := git(context.Background(), dir, "ls-tree", "-r", "--name-only", "watch")
+	require.NoError(t, err)
+	require.Equal(t, []string{"main.tf"}, strings.Fields(out))
+	known, err := loadKnown(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/subscriptions/123/resourceGroups/rg"}, known)
+}
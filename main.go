@@ -4,45 +4,69 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	golog "log"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/Azure/aztfexport/internal/bench"
 	"github.com/Azure/aztfexport/internal/cfgfile"
+	"github.com/Azure/aztfexport/internal/client"
 	internalconfig "github.com/Azure/aztfexport/internal/config"
+	"github.com/Azure/aztfexport/internal/diff"
+	"github.com/Azure/aztfexport/internal/doctor"
+	"github.com/Azure/aztfexport/internal/i18n"
+	"github.com/Azure/aztfexport/internal/incremental"
+	"github.com/Azure/aztfexport/internal/mapping"
+	internalmeta "github.com/Azure/aztfexport/internal/meta"
+	"github.com/Azure/aztfexport/internal/planimport"
 	"github.com/Azure/aztfexport/pkg/telemetry"
 	"github.com/gofrs/uuid"
-	"github.com/pkg/profile"
 
 	"github.com/Azure/aztfexport/pkg/config"
 	"github.com/Azure/aztfexport/pkg/log"
+	"github.com/Azure/aztfexport/pkg/meta"
 
 	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-exec/tfexec"
 	"github.com/magodo/armid"
 	"github.com/magodo/azlist/azlist"
-	"github.com/magodo/terraform-client-go/tfclient"
 	"github.com/magodo/tfadd/providers/azurerm"
 
 	"github.com/Azure/aztfexport/internal"
+	"github.com/Azure/aztfexport/internal/multirun"
+	"github.com/Azure/aztfexport/internal/provenance"
+	"github.com/Azure/aztfexport/internal/regenerate"
 	"github.com/Azure/aztfexport/internal/ui"
+	"github.com/Azure/aztfexport/internal/verify"
+	"github.com/Azure/aztfexport/internal/watch"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	azlog "github.com/Azure/azure-sdk-for-go/sdk/azcore/log"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/urfave/cli/v2"
 )
 
 var (
-	flagLogPath  string
-	flagLogLevel string
+	flagLogPath     string
+	flagLogLevel    string
+	flagLogFormat   string
+	flagErrorReport string
+)
+
+// The exit codes, which distinguish the partial success (some resources failed to import or are unsupported) from the failure.
+const (
+	exitCodePartialSuccess = 2
+	exitCodeFatal          = 3
 )
 
 func prepareConfigFile(ctx *cli.Context) error {
@@ -109,22 +133,41 @@ func prepareConfigFile(ctx *cli.Context) error {
 }
 
 func main() {
+	i18n.Init()
+
 	commonFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:    configFileFlagName,
+			EnvVars: []string{"AZTFEXPORT_CONFIG"},
+			Usage:   `The config file (".hcl", ".yaml" or ".yml") that sets the flags by their names. The flags set on the command line or via the environment variables take precedence`,
+		},
 		&cli.StringFlag{
 			Name: "env",
 			// Honor the "ARM_ENVIRONMENT" as is used by the AzureRM provider, for easier use.
 			EnvVars:     []string{"AZTFEXPORT_ENV", "ARM_ENVIRONMENT"},
-			Usage:       `The cloud environment, can be one of "public", "usgovernment" and "china"`,
+			Usage:       `The cloud environment, can be one of "public", "usgovernment" (or "AzureUSGovernment"), "china" (or "AzureChinaCloud") and "custom" (e.g. Azure Stack Hub, which requires "--resource-manager-endpoint")`,
 			Destination: &flagset.flagEnv,
 			Value:       "public",
 		},
 		&cli.StringFlag{
+			Name:        "resource-manager-endpoint",
+			EnvVars:     []string{"AZTFEXPORT_RESOURCE_MANAGER_ENDPOINT"},
+			Usage:       `The resource manager endpoint (e.g. "https://management.local.azurestack.external") of the custom cloud environment, whose metadata is used to configure the clients and the provider`,
+			Destination: &flagset.flagResourceManagerEndpoint,
+		},
+		&cli.StringSliceFlag{
 			Name: "subscription-id",
 			// Honor the "ARM_SUBSCRIPTION_ID" as is used by the AzureRM provider, for easier use.
 			EnvVars:     []string{"AZTFEXPORT_SUBSCRIPTION_ID", "ARM_SUBSCRIPTION_ID"},
 			Aliases:     []string{"s"},
-			Usage:       "The subscription id",
-			Destination: &flagset.flagSubscriptionId,
+			Usage:       "The subscription id. For query mode, this can be repeated (or comma separated) to query across the subscriptions, the resources of the subscriptions other than the first one are managed by the aliased providers",
+			Destination: &flagset.flagSubscriptionIds,
+		},
+		&cli.StringFlag{
+			Name:        "credentials-file",
+			EnvVars:     []string{"AZTFEXPORT_CREDENTIALS_FILE"},
+			Usage:       "The path of the JSON file that maps the resource scopes (e.g. the subscriptions in another tenant) to the aliased azurerm providers with their own credential settings, which import and manage the resources within the scopes",
+			Destination: &flagset.flagCredentialsFile,
 		},
 		&cli.StringFlag{
 			Name:    "output-dir",
@@ -144,12 +187,91 @@ func main() {
 			Usage:       "Overwrites the output directory if it is not empty (use with caution)",
 			Destination: &flagset.flagOverwrite,
 		},
+		&cli.BoolFlag{
+			Name:        "force-unlock",
+			EnvVars:     []string{"AZTFEXPORT_FORCE_UNLOCK"},
+			Usage:       "Removes the lock of the output directory before acquiring it, which is left by a run that didn't exit gracefully (use with caution)",
+			Destination: &flagset.flagForceUnlock,
+		},
 		&cli.BoolFlag{
 			Name:        "append",
 			EnvVars:     []string{"AZTFEXPORT_APPEND"},
 			Usage:       "Imports to the existing state file if any and does not clean up the output directory",
 			Destination: &flagset.flagAppend,
 		},
+		&cli.StringFlag{
+			Name:        "on-nonempty-dir",
+			EnvVars:     []string{"AZTFEXPORT_ON_NONEMPTY_DIR"},
+			Usage:       `What to do if the output directory is not empty, either "overwrite" (the same as --overwrite), "append" (the same as --append) or "fail", rather than prompting in the interactive mode`,
+			Destination: &flagset.flagOnNonEmptyDir,
+		},
+		&cli.BoolFlag{
+			Name:        "prune",
+			EnvVars:     []string{"AZTFEXPORT_PRUNE"},
+			Usage:       fmt.Sprintf("Removes the resources that no longer exist in Azure (within the resource groups of the listed resources) from the existing state, and lists them in %s. Their config is left to be removed manually", internalmeta.PruneReportFileName),
+			Destination: &flagset.flagPrune,
+		},
+		&cli.StringFlag{
+			Name:        "moved-from-state",
+			EnvVars:     []string{"AZTFEXPORT_MOVED_FROM_STATE"},
+			Usage:       fmt.Sprintf("The state file of a former export (e.g. by `terraform state pull`), whose resources are refactored to the exported addresses by the moved and removed blocks generated in %s, instead of being re-imported", internalmeta.MovedBlocksFileName),
+			Destination: &flagset.flagMovedFromState,
+		},
+		&cli.StringFlag{
+			Name:        "provider",
+			EnvVars:     []string{"AZTFEXPORT_PROVIDER"},
+			Aliases:     []string{"provider-name"},
+			Usage:       `The Terraform provider to export to, either "azurerm" or "azapi". For "azapi", every resource is exported as an "azapi_resource" with its ARM body (default: azurerm)`,
+			Destination: &flagset.flagProviderName,
+		},
+		&cli.BoolFlag{
+			Name:        "azapi-fallback",
+			EnvVars:     []string{"AZTFEXPORT_AZAPI_FALLBACK"},
+			Usage:       `Export the resources that have no azurerm resource type as "azapi_resource", together with the azurerm ones`,
+			Destination: &flagset.flagAzAPIFallback,
+		},
+		&cli.StringFlag{
+			Name:        "type-override-file",
+			EnvVars:     []string{"AZTFEXPORT_TYPE_OVERRIDE_FILE"},
+			Usage:       "The path of the JSON file that forces the matched Azure resources (by Azure resource type or resource id pattern) to be exported as the specified TF resource type",
+			Destination: &flagset.flagTypeOverrideFile,
+		},
+		&cli.StringFlag{
+			Name:        "exclude-file",
+			EnvVars:     []string{"AZTFEXPORT_EXCLUDE_FILE"},
+			Usage:       "The path of the file that has an Azure resource id or a glob pattern (e.g. \"*/providers/Microsoft.Insights/*\", where \"*\" matches across \"/\") per line, the matched resources are skipped during discovery",
+			Destination: &flagset.flagExcludeFile,
+		},
+		&cli.StringFlag{
+			Name:        "ignore-file",
+			EnvVars:     []string{"AZTFEXPORT_IGNORE_FILE"},
+			Usage:       fmt.Sprintf("The path of the ignore file, which has a gitignore-style pattern of the Azure resource ids (e.g. \"*/providers/Microsoft.Insights/**\") or the TF resource types (e.g. \"azurerm_role_*\") per line, the ignored resources are skipped during discovery. Defaults to the %q under the output directory, if it exists", internalmeta.IgnoreFileName),
+			Destination: &flagset.flagIgnoreFile,
+		},
+		&cli.StringSliceFlag{
+			Name:        "resolvers",
+			EnvVars:     []string{"AZTFEXPORT_RESOLVERS"},
+			Usage:       `The ordered chain of resolvers that resolve the TF resource type of each Azure resource, a resolver that is absent is disabled. Possible values: "plugin" (by --resolver-plugin), "override" (by --type-override-file), "api" (by static mappings and Azure API), "heuristic" (by static mappings only), "ask" (keep the unresolved resources for the user to decide) (default: plugin,override,api,heuristic,ask)`,
+			Destination: &flagset.flagResolvers,
+		},
+		&cli.StringFlag{
+			Name:        "resolver-plugin",
+			EnvVars:     []string{"AZTFEXPORT_RESOLVER_PLUGIN"},
+			Usage:       "The path of the resolver plugin executable, which overrides or augments the TF resource type resolution of each Azure resource by speaking JSON over stdio",
+			Destination: &flagset.flagResolverPlugin,
+		},
+		&cli.StringSliceFlag{
+			Name:        "hook",
+			EnvVars:     []string{"AZTFEXPORT_HOOK"},
+			Usage:       fmt.Sprintf(`The command to run at a phase of the export, in form of "phase=command" (e.g. "post-generate=./check.sh"), which can be repeated. The command is run within the output directory, with a JSON payload describing the run and the resources on its stdin. A non-zero exit code fails the export. Possible phases: %s`, strings.Join(internalmeta.HookPhases, ", ")),
+			Destination: &flagset.flagHooks,
+		},
+		&cli.StringFlag{
+			Name:        "subresource-strategy",
+			EnvVars:     []string{"AZTFEXPORT_SUBRESOURCE_STRATEGY"},
+			Usage:       `How to generate the sub-resources that azurerm supports both inline and standalone (e.g. subnets, NSG rules, routes), either "standalone" or "inline" (default: standalone)`,
+			Destination: &flagset.flagSubresourceStrategy,
+		},
 		&cli.BoolFlag{
 			Name:        "dev-provider",
 			EnvVars:     []string{"AZTFEXPORT_DEV_PROVIDER"},
@@ -159,13 +281,50 @@ func main() {
 		&cli.StringFlag{
 			Name:        "provider-version",
 			EnvVars:     []string{"AZTFEXPORT_PROVIDER_VERSION"},
-			Usage:       fmt.Sprintf("The azurerm provider version to use for importing (default: existing version constraints or %s)", azurerm.ProviderSchemaInfo.Version),
+			Usage:       fmt.Sprintf("The provider version to use for importing (default: existing version constraints or %s for azurerm, %s for azapi)", azurerm.ProviderSchemaInfo.Version, internalmeta.AzAPIProviderVersion),
 			Destination: &flagset.flagProviderVersion,
 		},
+		&cli.StringFlag{
+			Name:        "provider-major-version",
+			EnvVars:     []string{"AZTFEXPORT_PROVIDER_MAJOR_VERSION"},
+			Usage:       fmt.Sprintf(`The azurerm provider major version whose resource shapes are used for config generation, either "3" or "4" (default: derived from the provider version, "4" defaults the provider version to %s)`, internalmeta.AzureRMV4ProviderVersion),
+			Destination: &flagset.flagProviderMajorVersion,
+		},
+		&cli.StringFlag{
+			Name:        "provider-registry",
+			EnvVars:     []string{"AZTFEXPORT_PROVIDER_REGISTRY"},
+			Usage:       "The hostname of a private registry that serves the providers, which prefixes the provider source addresses (default: registry.terraform.io)",
+			Destination: &flagset.flagProviderRegistry,
+		},
+		&cli.StringFlag{
+			Name:        "provider-source",
+			EnvVars:     []string{"AZTFEXPORT_PROVIDER_SOURCE"},
+			Usage:       `The source address of the provider, in form of "[<hostname>/]<namespace>/<type>" (e.g. "registry.example.com/myorg/azurerm"), which takes precedence over the "--provider-registry" for this provider`,
+			Destination: &flagset.flagProviderSource,
+		},
+		&cli.StringSliceFlag{
+			Name:        "provider-feature",
+			EnvVars:     []string{"AZTFEXPORT_PROVIDER_FEATURE"},
+			Usage:       `The setting of the "features" block of the generated azurerm provider config, in form of "key=value", where the key is the dot separated path of the nested blocks and the attribute (e.g. "key_vault.purge_soft_delete_on_destroy=false"). This can be repeated`,
+			Destination: &flagset.flagProviderFeatures,
+		},
+		&cli.StringFlag{
+			Name:        "provider-mirror",
+			Aliases:     []string{"provider-mirror-url"},
+			EnvVars:     []string{"AZTFEXPORT_PROVIDER_MIRROR"},
+			Usage:       fmt.Sprintf("The provider mirror to install the providers from, either a HTTPS URL of a network mirror, or a local directory of a filesystem mirror. A matching Terraform CLI config (%s) is generated to the output directory", internalmeta.CLIConfigFileName),
+			Destination: &flagset.flagProviderMirror,
+		},
+		&cli.StringFlag{
+			Name:        "provider-plugin-cache",
+			EnvVars:     []string{"AZTFEXPORT_PROVIDER_PLUGIN_CACHE"},
+			Usage:       "The provider plugin cache directory (i.e. TF_PLUGIN_CACHE_DIR) used by all the terraform invocations, to avoid downloading the providers on each run",
+			Destination: &flagset.flagProviderPluginCache,
+		},
 		&cli.StringFlag{
 			Name:        "backend-type",
 			EnvVars:     []string{"AZTFEXPORT_BACKEND_TYPE"},
-			Usage:       "The Terraform backend used to store the state (default: local)",
+			Usage:       "The Terraform backend used to store the state (default: local). Use \"cloud\" for the HCP Terraform (Terraform Cloud) workspace, as specified by \"--cloud-organization\" and \"--cloud-workspace\"",
 			Destination: &flagset.flagBackendType,
 		},
 		&cli.StringSliceFlag{
@@ -174,6 +333,60 @@ func main() {
 			Usage:       "The Terraform backend config",
 			Destination: &flagset.flagBackendConfig,
 		},
+		&cli.StringFlag{
+			Name:        "cloud-organization",
+			EnvVars:     []string{"AZTFEXPORT_CLOUD_ORGANIZATION"},
+			Usage:       "The HCP Terraform organization of the \"cloud\" block, used with \"--backend-type=cloud\"",
+			Destination: &flagset.flagCloudOrganization,
+		},
+		&cli.StringFlag{
+			Name:        "cloud-workspace",
+			EnvVars:     []string{"AZTFEXPORT_CLOUD_WORKSPACE"},
+			Usage:       "The HCP Terraform workspace of the \"cloud\" block (created if not exists), used with \"--backend-type=cloud\"",
+			Destination: &flagset.flagCloudWorkspace,
+		},
+		&cli.StringFlag{
+			Name:        "workspace",
+			EnvVars:     []string{"AZTFEXPORT_WORKSPACE"},
+			Usage:       "The Terraform workspace of the output directory to import into, which is selected (or created if not exists) after initializing the backend (default: the current one)",
+			Destination: &flagset.flagWorkspace,
+		},
+		&cli.BoolFlag{
+			Name:        "local-then-migrate",
+			EnvVars:     []string{"AZTFEXPORT_LOCAL_THEN_MIGRATE"},
+			Usage:       "Import into a local state, which is migrated to the backend of \"--backend-type\" (via \"terraform init -migrate-state\") at the end of the export. This is much faster for large exports than locking the remote state for each import",
+			Destination: &flagset.flagLocalThenMigrate,
+		},
+		&cli.BoolFlag{
+			Name:        "hold-backend-lock",
+			EnvVars:     []string{"AZTFEXPORT_HOLD_BACKEND_LOCK"},
+			Usage:       "Hold the state lock of the \"azurerm\" backend for the whole export, rather than per terraform invocation, so that other runs (e.g. pipelines) can't write the state in the middle. This requires the data plane access to the state blob (e.g. the \"Storage Blob Data Contributor\" role)",
+			Destination: &flagset.flagHoldBackendLock,
+		},
+		&cli.BoolFlag{
+			Name:        "bootstrap-backend",
+			EnvVars:     []string{"AZTFEXPORT_BOOTSTRAP_BACKEND"},
+			Usage:       "Create the resource group, the storage account (with the blob versioning enabled) and the container of the \"azurerm\" backend (as specified by \"--backend-config\") before initializing the backend, if missing",
+			Destination: &flagset.flagBootstrapBackend,
+		},
+		&cli.StringFlag{
+			Name:        "bootstrap-backend-location",
+			EnvVars:     []string{"AZTFEXPORT_BOOTSTRAP_BACKEND_LOCATION"},
+			Usage:       "The location of the resource group and the storage account created by \"--bootstrap-backend\" (default: the location of the existing resource group)",
+			Destination: &flagset.flagBootstrapBackendLocation,
+		},
+		&cli.BoolFlag{
+			Name:        "bootstrap-backend-export",
+			EnvVars:     []string{"AZTFEXPORT_BOOTSTRAP_BACKEND_EXPORT"},
+			Usage:       "Also export the resource group, the storage account and the container of the backend bootstrapped by \"--bootstrap-backend\"",
+			Destination: &flagset.flagBootstrapBackendExport,
+		},
+		&cli.BoolFlag{
+			Name:        "scaffold-auth",
+			EnvVars:     []string{"AZTFEXPORT_SCAFFOLD_AUTH"},
+			Usage:       fmt.Sprintf("Generate a commented provider config (%s) that reflects the authentication used during the export, together with the backend config (%s) if any, so that the exported workspace can be planned without hand-authoring the provider auth. The secrets are never written", internalmeta.AuthScaffoldFileName, internalmeta.BackendVarsFileName),
+			Destination: &flagset.flagScaffoldAuth,
+		},
 		&cli.BoolFlag{
 			Name:        "full-properties",
 			EnvVars:     []string{"AZTFEXPORT_FULL_PROPERTIES"},
@@ -181,13 +394,25 @@ func main() {
 			Value:       false,
 			Destination: &flagset.flagFullConfig,
 		},
+		&cli.StringFlag{
+			Name:        "property-rules",
+			EnvVars:     []string{"AZTFEXPORT_PROPERTY_RULES"},
+			Usage:       "The path of the JSON file that always includes or excludes the properties of the generated config per resource type (e.g. always omit \"tags\", always include \"identity\"), regardless of \"--full-properties\", and adds the attributes known to drift to the \"ignore_changes\" of the lifecycle block",
+			Destination: &flagset.flagPropertyRulesFile,
+		},
 		&cli.IntFlag{
 			Name:        "parallelism",
 			EnvVars:     []string{"AZTFEXPORT_PARALLELISM"},
-			Usage:       "Limit the number of parallel operations, i.e., resource discovery, import",
+			Usage:       "Limit the number of parallel operations, i.e., resource discovery, import, config generation",
 			Value:       10,
 			Destination: &flagset.flagParallelism,
 		},
+		&cli.DurationFlag{
+			Name:        "import-timeout",
+			EnvVars:     []string{"AZTFEXPORT_IMPORT_TIMEOUT"},
+			Usage:       "The timeout of importing each resource (e.g. \"5m\"), after which the import is cancelled and marked as errored. Zero means no timeout",
+			Destination: &flagset.flagImportTimeout,
+		},
 		&cli.BoolFlag{
 			Name:        "non-interactive",
 			EnvVars:     []string{"AZTFEXPORT_NON_INTERACTIVE"},
@@ -201,6 +426,18 @@ func main() {
 			Usage:       "In non-interactive mode, print the progress information line by line, rather than the spinner UI. This can be used in OS that has no /dev/tty available",
 			Destination: &flagset.flagPlainUI,
 		},
+		&cli.StringFlag{
+			Name:        "output-format",
+			EnvVars:     []string{"AZTFEXPORT_OUTPUT_FORMAT"},
+			Usage:       fmt.Sprintf(`The format of the progress output in non-interactive mode, either %q (default) or %q (stream the events, e.g. resource discovered, import succeeded/failed, config generated, to stdout as NDJSON)`, internalconfig.OutputFormatText, internalconfig.OutputFormatJSON),
+			Destination: &flagset.flagOutputFormat,
+		},
+		&cli.BoolFlag{
+			Name:        "accessible",
+			EnvVars:     []string{"AZTFEXPORT_ACCESSIBLE"},
+			Usage:       "In interactive mode, prompt line by line with numbered resources, rather than the full-screen UI. This works with screen readers, and is enabled automatically when the ACCESSIBLE environment variable is set",
+			Destination: &flagset.flagAccessible,
+		},
 		&cli.BoolFlag{
 			Name:        "continue",
 			EnvVars:     []string{"AZTFEXPORT_CONTINUE"},
@@ -208,25 +445,403 @@ func main() {
 			Usage:       "For non-interactive mode, continue on any import error",
 			Destination: &flagset.flagContinue,
 		},
+		&cli.IntFlag{
+			Name:        "chunk-size",
+			EnvVars:     []string{"AZTFEXPORT_CHUNK_SIZE"},
+			Usage:       "For non-interactive mode, split the resources into sequential chunks of this size, each is imported and generated independently (default: no chunking)",
+			Destination: &flagset.flagChunkSize,
+		},
+		&cli.BoolFlag{
+			Name:        "resume",
+			EnvVars:     []string{"AZTFEXPORT_RESUME"},
+			Usage:       fmt.Sprintf("For non-interactive mode, resume the previous run in the output directory from its checkpoint file (%s), skipping the resources that are already exported", internal.CheckpointFileName),
+			Destination: &flagset.flagResume,
+		},
+		&cli.IntFlag{
+			Name:        "max-resources",
+			EnvVars:     []string{"AZTFEXPORT_MAX_RESOURCES"},
+			Usage:       "For non-interactive mode, stop importing gracefully once this number of resources are imported, the rest are left to `--resume` (default: no limit)",
+			Destination: &flagset.flagMaxResources,
+		},
+		&cli.DurationFlag{
+			Name:        "max-duration",
+			EnvVars:     []string{"AZTFEXPORT_MAX_DURATION"},
+			Usage:       "For non-interactive mode, stop importing gracefully once the run lasts for this duration (e.g. \"2h\"), the rest are left to `--resume` (default: no limit)",
+			Destination: &flagset.flagMaxDuration,
+		},
+		&cli.IntFlag{
+			Name:        "limit",
+			EnvVars:     []string{"AZTFEXPORT_LIMIT"},
+			Usage:       "Only process the first N listed resources, e.g. to pilot on a large scope (default: no limit)",
+			Destination: &flagset.flagLimit,
+		},
+		&cli.IntFlag{
+			Name:        "sample",
+			EnvVars:     []string{"AZTFEXPORT_SAMPLE"},
+			Usage:       "Only process N randomly sampled resources out of the listed resources, e.g. to pilot on a representative subset of a large scope (default: no sampling)",
+			Destination: &flagset.flagSample,
+		},
 		&cli.BoolFlag{
 			Name:        "generate-mapping-file",
 			Aliases:     []string{"g"},
 			EnvVars:     []string{"AZTFEXPORT_GENERATE_MAPPING_FILE"},
-			Usage:       "Only generate the resource mapping file, but does NOT import any resource",
+			Usage:       "Only generate the resource mapping file, but does NOT import any resource. In interactive mode, the mapping file is saved from the curated import list via \"w\"",
 			Destination: &flagset.flagGenerateMappingFile,
 		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			EnvVars:     []string{"AZTFEXPORT_DRY_RUN"},
+			Usage:       "For non-interactive mode, only list the resources and resolve their TF resource types, then print the mapping that would be used (including the skipped resources with the reasons), without initializing terraform, importing or generating anything",
+			Destination: &flagset.flagDryRun,
+		},
+		&cli.StringFlag{
+			Name:        "dry-run-output",
+			EnvVars:     []string{"AZTFEXPORT_DRY_RUN_OUTPUT"},
+			Usage:       "The file to write the mapping of the dry run to, instead of printing it",
+			Destination: &flagset.flagDryRunOutput,
+		},
 		&cli.BoolFlag{
 			Name:        "hcl-only",
 			EnvVars:     []string{"AZTFEXPORT_HCL_ONLY"},
 			Usage:       "Only generates HCL code (and mapping file), but not the files for resource management (e.g. the state file)",
 			Destination: &flagset.flagHCLOnly,
 		},
+		&cli.BoolFlag{
+			Name:        "show-commands",
+			EnvVars:     []string{"AZTFEXPORT_SHOW_COMMANDS"},
+			Usage:       fmt.Sprintf("For non-interactive mode, echo the terraform commands to the stderr once they start. The commands are always recorded in %s of the output directory", internalmeta.AuditLogFileName),
+			Destination: &flagset.flagShowCommands,
+		},
+		&cli.BoolFlag{
+			Name:        "use-import-blocks",
+			EnvVars:     []string{"AZTFEXPORT_USE_IMPORT_BLOCKS"},
+			Usage:       `Only generate the import blocks together with the config, but leave the state population to a later "terraform plan/apply" (requires terraform >= v1.5.0)`,
+			Destination: &flagset.flagUseImportBlocks,
+		},
 		&cli.StringFlag{
 			Name:        "module-path",
 			EnvVars:     []string{"AZTFEXPORT_MODULE_PATH"},
-			Usage:       `The path of the module (e.g. "module1.module2") where the resources will be imported and config generated. Note that only modules whose "source" is local path is supported. Defaults to the root module.`,
+			Usage:       fmt.Sprintf(`The path of the module (e.g. "module1.module2") where the resources will be imported and config generated. For the modules from remote sources (e.g. registry, git), which are downloaded via "terraform get" if needed, the config is generated to the "%s<module path>" directory instead, which is meant to be merged back to the module source. Defaults to the root module.`, internalmeta.RemoteModuleConfigDirPrefix),
 			Destination: &flagset.flagModulePath,
 		},
+		&cli.BoolFlag{
+			Name:        "cost-estimate",
+			EnvVars:     []string{"AZTFEXPORT_COST_ESTIMATE"},
+			Usage:       "Estimate the monthly cost of the exported resources via infracost (requires the infracost executable in the PATH), a failed estimate is reported as a warning without failing the export",
+			Destination: &flagset.flagCostEstimate,
+		},
+		&cli.BoolFlag{
+			Name:        "verify",
+			EnvVars:     []string{"AZTFEXPORT_VERIFY"},
+			Usage:       fmt.Sprintf(`Run "terraform plan" after the export, and write the resources whose plan is not empty, together with their attribute diffs, to %s (only valid in non-interactive mode)`, verify.ReportFileName),
+			Destination: &flagset.flagVerify,
+		},
+		&cli.BoolFlag{
+			Name:        "report-markdown",
+			EnvVars:     []string{"AZTFEXPORT_REPORT_MARKDOWN"},
+			Usage:       fmt.Sprintf("Write the report of the run to %s, in addition to %s (only valid in non-interactive mode)", internal.ReportMarkdownFileName, internal.ReportFileName),
+			Destination: &flagset.flagReportMarkdown,
+		},
+		&cli.BoolFlag{
+			Name:        "export-arm-json",
+			EnvVars:     []string{"AZTFEXPORT_EXPORT_ARM_JSON"},
+			Usage:       "Also export the raw ARM JSON of each imported resource (as returned by the API) alongside the Terraform configuration",
+			Destination: &flagset.flagExportARMJSON,
+		},
+		&cli.BoolFlag{
+			Name:        "emit-depends-on",
+			EnvVars:     []string{"AZTFEXPORT_EMIT_DEPENDS_ON"},
+			Usage:       "Add the \"depends_on\" of the exported resources that are referred to by the ARM body of each resource, but can't be expressed as references in the Terraform configuration",
+			Destination: &flagset.flagEmitDependsOn,
+		},
+		&cli.StringFlag{
+			Name:        "stack-config",
+			EnvVars:     []string{"AZTFEXPORT_STACK_CONFIG"},
+			Usage:       `Generate the stack definition file for the specified TACOS platform ("spacelift" or "env0"), which onboards the output directory to the platform`,
+			Destination: &flagset.flagStackConfig,
+		},
+		&cli.BoolFlag{
+			Name:        "aks-providers",
+			EnvVars:     []string{"AZTFEXPORT_AKS_PROVIDERS"},
+			Usage:       "Generate the kubernetes and helm provider blocks configured from the kube config of each exported AKS cluster (the Kubernetes objects are not exported)",
+			Destination: &flagset.flagAKSProviders,
+		},
+		&cli.BoolFlag{
+			Name:        "backstage-catalog",
+			EnvVars:     []string{"AZTFEXPORT_BACKSTAGE_CATALOG"},
+			Usage:       "Generate the Backstage catalog file (catalog-info.yaml) that describes the exported module and resources",
+			Destination: &flagset.flagBackstageCatalog,
+		},
+		&cli.StringFlag{
+			Name:        "backstage-owner",
+			EnvVars:     []string{"AZTFEXPORT_BACKSTAGE_OWNER"},
+			Usage:       "The owner of the entities in the Backstage catalog. Required by `--backstage-catalog`",
+			Destination: &flagset.flagBackstageOwner,
+		},
+		&cli.StringFlag{
+			Name:        "backstage-system",
+			EnvVars:     []string{"AZTFEXPORT_BACKSTAGE_SYSTEM"},
+			Usage:       "The system that the entities in the Backstage catalog belong to",
+			Destination: &flagset.flagBackstageSystem,
+		},
+		&cli.BoolFlag{
+			Name:        "inventory",
+			EnvVars:     []string{"AZTFEXPORT_INVENTORY"},
+			Usage:       "Generate the inventory file (aztfexportInventory.json) of the exported resources, which is meant to be diffed over time or ingested by CMDB tools",
+			Destination: &flagset.flagInventory,
+		},
+		&cli.BoolFlag{
+			Name:        "generate-outputs",
+			EnvVars:     []string{"AZTFEXPORT_GENERATE_OUTPUTS"},
+			Usage:       "Generate the outputs file (outputs.tf) that exposes the resource ids, the principal ids of the managed identities and the FQDNs/endpoints of the exported resources",
+			Destination: &flagset.flagGenerateOutputs,
+		},
+		&cli.StringFlag{
+			Name:        "output-rules",
+			EnvVars:     []string{"AZTFEXPORT_OUTPUT_RULES"},
+			Usage:       "The path of the JSON file that includes or excludes the attributes to output per resource type. Requires `--generate-outputs`",
+			Destination: &flagset.flagOutputRulesFile,
+		},
+		&cli.StringFlag{
+			Name:        "graph-output",
+			EnvVars:     []string{"AZTFEXPORT_GRAPH_OUTPUT"},
+			Usage:       `Generate the dependency graph of the exported resources in the specified format ("dot" or "mermaid"), whose edges are the references and the "depends_on" among them`,
+			Destination: &flagset.flagGraphOutput,
+		},
+		&cli.StringSliceFlag{
+			Name:        "inject-tag",
+			EnvVars:     []string{"AZTFEXPORT_INJECT_TAG"},
+			Usage:       `The tag in form of "key=value" (e.g. "managed_by=terraform") to inject into the tags of every generated resource that supports tags`,
+			Destination: &flagset.flagInjectTags,
+		},
+		&cli.BoolFlag{
+			Name:        "apply-injected-tags",
+			EnvVars:     []string{"AZTFEXPORT_APPLY_INJECTED_TAGS"},
+			Usage:       "Also apply the tags specified by `--inject-tag` to the live resources after they are imported",
+			Destination: &flagset.flagApplyInjectedTags,
+		},
+		&cli.StringSliceFlag{
+			Name:        "key-vault-ref",
+			EnvVars:     []string{"AZTFEXPORT_KEY_VAULT_REF"},
+			Usage:       fmt.Sprintf("The resource ids of the Key Vaults whose secrets are read. A generated attribute value that matches a secret is replaced by a reference to the azurerm_key_vault_secret data source of the secret (written to %s)", internalmeta.KeyVaultSecretsFileName),
+			Destination: &flagset.flagKeyVaultRefs,
+		},
+		&cli.BoolFlag{
+			Name:        "generate-data-sources",
+			EnvVars:     []string{"AZTFEXPORT_GENERATE_DATA_SOURCES"},
+			Usage:       fmt.Sprintf("Generate the data sources (written to %s) for the resources that are referenced by the exported resources but are not exported (e.g. a virtual network in another resource group), and reference them instead of the hardcoded resource ids", internalmeta.DataSourcesFileName),
+			Destination: &flagset.flagGenerateDataSources,
+		},
+		&cli.BoolFlag{
+			Name:        "no-reference-rewrite",
+			EnvVars:     []string{"AZTFEXPORT_NO_REFERENCE_REWRITE"},
+			Usage:       `Don't replace the hardcoded ids of the other exported resources with references to them (e.g. "azurerm_subnet.res-1.id"), in which case the dependencies are only expressed via "depends_on"`,
+			Destination: &flagset.flagNoReferenceRewrite,
+		},
+		&cli.BoolFlag{
+			Name:        "extract-variables",
+			EnvVars:     []string{"AZTFEXPORT_EXTRACT_VARIABLES"},
+			Usage:       fmt.Sprintf("Extract the values of the common attributes (e.g. location, resource group name, SKUs, tag values) that are repeated across the generated config to variables (written to %s), with the values as the defaults", internalmeta.VariablesFileName),
+			Destination: &flagset.flagExtractVariables,
+		},
+		&cli.StringFlag{
+			Name:        "variable-attributes-file",
+			EnvVars:     []string{"AZTFEXPORT_VARIABLE_ATTRIBUTES_FILE"},
+			Usage:       fmt.Sprintf(`The path of the JSON file that lists the attribute paths to extract to variables (e.g. ["location", "sku.name", "tags"]). Requires "--extract-variables" (default: %s)`, strings.Join(internalmeta.DefaultVariableAttributes, ",")),
+			Destination: &flagset.flagVariableAttrsFile,
+		},
+		&cli.StringFlag{
+			Name:        "on-secret",
+			EnvVars:     []string{"AZTFEXPORT_ON_SECRET"},
+			Usage:       `What to do when secrets (e.g. connection strings, keys, SAS tokens) are found in the generated config, either "redact", "var" (extract to sensitive variables) or "fail" (default: not scanned)`,
+			Destination: &flagset.flagOnSecret,
+		},
+		&cli.BoolFlag{
+			Name:        "redact-secrets",
+			EnvVars:     []string{"AZTFEXPORT_REDACT_SECRETS"},
+			Usage:       fmt.Sprintf(`Redact the secrets found in the generated config, which is a shorthand of "--on-secret=redact". The findings are reported in %s`, internalmeta.SecretsReportFileName),
+			Destination: &flagset.flagRedactSecrets,
+		},
+		&cli.StringFlag{
+			Name:        "secret-allowlist-file",
+			EnvVars:     []string{"AZTFEXPORT_SECRET_ALLOWLIST_FILE"},
+			Usage:       `The path of the JSON file that exempts the matched attributes from the secret detection, e.g. [{"resource_type": "azurerm_linux_web_app", "attribute": "app_settings", "value_pattern": "^@Microsoft.KeyVault"}]. Requires "--on-secret" or "--redact-secrets"`,
+			Destination: &flagset.flagSecretAllowlistFile,
+		},
+		&cli.BoolFlag{
+			Name:        "provenance",
+			EnvVars:     []string{"AZTFEXPORT_PROVENANCE"},
+			Usage:       fmt.Sprintf("Generate the provenance manifest (%s) that records the tool version, the effective CLI, the scope and the hash of each file in the output directory", provenance.ManifestFileName),
+			Destination: &flagset.flagProvenance,
+		},
+		&cli.StringFlag{
+			Name:        "provenance-sign",
+			EnvVars:     []string{"AZTFEXPORT_PROVENANCE_SIGN"},
+			Usage:       `Sign the provenance manifest with the tool, either "cosign" or "minisign", which must be in the PATH. Requires "--provenance"`,
+			Destination: &flagset.flagProvenanceSign,
+		},
+		&cli.StringFlag{
+			Name:        "provenance-sign-key",
+			EnvVars:     []string{"AZTFEXPORT_PROVENANCE_SIGN_KEY"},
+			Usage:       `The path to the private key to sign the provenance manifest. For cosign, the keyless signing is used if not specified`,
+			Destination: &flagset.flagProvenanceSignKey,
+		},
+		&cli.StringFlag{
+			Name:        "on-locked",
+			EnvVars:     []string{"AZTFEXPORT_ON_LOCKED"},
+			Usage:       `What to do with the resources that are under management locks (CanNotDelete or ReadOnly), either "warn" (import with a warning) or "skip" (default: locks not detected)`,
+			Destination: &flagset.flagOnLocked,
+		},
+		&cli.StringFlag{
+			Name:        "on-soft-deleted",
+			EnvVars:     []string{"AZTFEXPORT_ON_SOFT_DELETED"},
+			Usage:       `What to do with the resources that collide with the soft-deleted Key Vaults or API Management services, either "warn" (import with a warning) or "skip" (skip them together with their children) (default: not detected)`,
+			Destination: &flagset.flagOnSoftDeleted,
+		},
+		&cli.BoolFlag{
+			Name:        "include-role-assignments",
+			EnvVars:     []string{"AZTFEXPORT_INCLUDE_ROLE_ASSIGNMENTS"},
+			Usage:       "Also export the role assignments that are scoped to the exported resources",
+			Destination: &flagset.flagIncludeRoleAssignments,
+		},
+		&cli.BoolFlag{
+			Name:        "include-locks",
+			EnvVars:     []string{"AZTFEXPORT_INCLUDE_LOCKS"},
+			Usage:       "Also export the management locks that are scoped to the exported resources",
+			Destination: &flagset.flagIncludeLocks,
+		},
+		&cli.BoolFlag{
+			Name:        "include-policy-assignments",
+			EnvVars:     []string{"AZTFEXPORT_INCLUDE_POLICY_ASSIGNMENTS"},
+			Usage:       "Also export the policy assignments that are scoped to the exported resources",
+			Destination: &flagset.flagIncludePolicyAssignments,
+		},
+		&cli.BoolFlag{
+			Name:        "include-monitoring",
+			EnvVars:     []string{"AZTFEXPORT_INCLUDE_MONITORING"},
+			Usage:       "Also export the diagnostic settings, metric alerts and activity log alerts that are scoped to the exported resources",
+			Destination: &flagset.flagIncludeMonitoring,
+		},
+		&cli.BoolFlag{
+			Name:        "include-keyvault-items",
+			EnvVars:     []string{"AZTFEXPORT_INCLUDE_KEYVAULT_ITEMS"},
+			Usage:       "Also export the keys, secrets, certificates and access policies of the exported Key Vaults, which are listed from their data plane (only the metadata of the secrets are read)",
+			Destination: &flagset.flagIncludeKeyVaultItems,
+		},
+		&cli.BoolFlag{
+			Name:        "include-storage-items",
+			EnvVars:     []string{"AZTFEXPORT_INCLUDE_STORAGE_ITEMS"},
+			Usage:       "Also export the blob containers, file shares, queues and tables of the exported storage accounts, which are listed from their data plane",
+			Destination: &flagset.flagIncludeStorageItems,
+		},
+		&cli.StringSliceFlag{
+			Name:        "env-split",
+			EnvVars:     []string{"AZTFEXPORT_ENV_SPLIT"},
+			Usage:       fmt.Sprintf("The environments (e.g. \"dev,stage,prod\") to generate the root configs for, which call a reusable module generated from the exported resources (written to the %s directory). The first environment imports the exported resources", internalmeta.EnvSplitDirName),
+			Destination: &flagset.flagEnvSplit,
+		},
+		&cli.StringFlag{
+			Name:        "split-by",
+			EnvVars:     []string{"AZTFEXPORT_SPLIT_BY"},
+			Usage:       fmt.Sprintf(`Split the generated config into child modules (written to the %s directory) that are called by the root module, either "resource-group" (a module per resource group) or "type" (a module per resource type) (default: not split)`, internalmeta.SplitModulesDirName),
+			Destination: &flagset.flagSplitBy,
+		},
+		&cli.StringFlag{
+			Name:        "split-files",
+			EnvVars:     []string{"AZTFEXPORT_SPLIT_FILES"},
+			Usage:       `Split the generated config into files instead of a single main file, either "per-resource" (e.g. "storage_account.res-0.tf"), "per-type" (e.g. "storage_account.tf") or "per-rg" (e.g. "myrg.tf") (default: not split)`,
+			Destination: &flagset.flagSplitFiles,
+		},
+		&cli.StringFlag{
+			Name:        "output-syntax",
+			EnvVars:     []string{"AZTFEXPORT_OUTPUT_SYNTAX"},
+			Usage:       `The syntax of the generated config of the resources, either "hcl" (e.g. "main.tf") or "tf-json" (the JSON syntax, e.g. "main.tf.json", for the toolchains that post-process the config programmatically). The other generated files (e.g. "provider.tf") are always in HCL (default: "hcl")`,
+			Destination: &flagset.flagOutputSyntax,
+		},
+		&cli.StringFlag{
+			Name:        "module-template",
+			EnvVars:     []string{"AZTFEXPORT_MODULE_TEMPLATE"},
+			Usage:       fmt.Sprintf(`The address template of the child module (e.g. "module.rg_%s") that each resource is imported into and its config generated to, which supports the placeholders "%s" and "%s". The child modules must be called by the root module from local paths, unless "--create-missing-modules" is set`, internalmeta.ModuleTemplateResourceGroup, internalmeta.ModuleTemplateResourceGroup, internalmeta.ModuleTemplateType),
+			Destination: &flagset.flagModuleTemplate,
+		},
+		&cli.BoolFlag{
+			Name:        "create-missing-modules",
+			EnvVars:     []string{"AZTFEXPORT_CREATE_MISSING_MODULES"},
+			Usage:       fmt.Sprintf(`Generate the stubs of the child modules of "--module-template" that are not called by the root module (written to the %s directory), together with their module calls`, internalmeta.SplitModulesDirName),
+			Destination: &flagset.flagCreateMissingModules,
+		},
+		&cli.StringFlag{
+			Name:        "sort-resources",
+			EnvVars:     []string{"AZTFEXPORT_SORT_RESOURCES"},
+			Usage:       `The order of the generated resources and import blocks, can be one of "type" (by the resource type, then the name), "name" (by the resource name, then the type) and "azure-id" (by the Azure resource id), so that the diffs between successive exports are reviewable (default: "type")`,
+			Destination: &flagset.flagSortResources,
+		},
+		&cli.StringFlag{
+			Name:        "record",
+			EnvVars:     []string{"AZTFEXPORT_RECORD"},
+			Usage:       "Record the ARM and Azure Resource Graph traffic to the directory, which can be replayed by `--replay` (e.g. for bug reports). The traffic of the Terraform provider (i.e. importing) is not recorded",
+			Destination: &flagset.flagRecord,
+		},
+		&cli.StringFlag{
+			Name:        "replay",
+			EnvVars:     []string{"AZTFEXPORT_REPLAY"},
+			Usage:       "Replay the ARM and Azure Resource Graph traffic recorded by `--record` in the directory, rather than sending the requests to Azure",
+			Destination: &flagset.flagReplay,
+		},
+		&cli.IntFlag{
+			Name:        "retry-max",
+			EnvVars:     []string{"AZTFEXPORT_RETRY_MAX"},
+			Usage:       "The maximum number of retries of the ARM and Azure Resource Graph requests on throttling (HTTP 429) and transient failures, the \"Retry-After\" of the throttled responses is honored (default: 3)",
+			Destination: &flagset.flagRetryMax,
+		},
+		&cli.DurationFlag{
+			Name:        "retry-base-delay",
+			EnvVars:     []string{"AZTFEXPORT_RETRY_BASE_DELAY"},
+			Usage:       "The delay before the first retry of the ARM and Azure Resource Graph requests, which doubles for each further retry, unless the response has a \"Retry-After\" (default: 4s)",
+			Destination: &flagset.flagRetryBaseDelay,
+		},
+		&cli.StringFlag{
+			Name:        "cache-dir",
+			EnvVars:     []string{"AZTFEXPORT_CACHE_DIR"},
+			Usage:       "The directory to cache the ARM payloads and the generated configs of the resources, so that re-running the config generation doesn't re-download or re-import the resources whose etags are unchanged. The configs are only cached with `--use-import-blocks` (default: the \"aztfexport\" directory under the user cache directory)",
+			Destination: &flagset.flagCacheDir,
+		},
+		&cli.BoolFlag{
+			Name:        "no-cache",
+			EnvVars:     []string{"AZTFEXPORT_NO_CACHE"},
+			Usage:       "Disable the cache of the ARM payloads and the generated configs",
+			Destination: &flagset.flagNoCache,
+		},
+		&cli.StringFlag{
+			Name:        "telemetry-sink",
+			EnvVars:     []string{"AZTFEXPORT_TELEMETRY_SINK"},
+			Usage:       `The sink of the telemetry, can be one of "appinsights", "otlp" (an OpenTelemetry collector), "file" (JSON lines) and "none". The "appinsights" sink is only used if the telemetry is enabled (see "aztfexport config"), while the others are explicit opt-ins (default: "appinsights")`,
+			Destination: &flagset.flagTelemetrySink,
+		},
+		&cli.StringFlag{
+			Name:        "telemetry-sink-target",
+			EnvVars:     []string{"AZTFEXPORT_TELEMETRY_SINK_TARGET"},
+			Usage:       `The target of the telemetry sink, which is the OTLP/HTTP endpoint for the "otlp" sink (default: the "OTEL_EXPORTER_OTLP_ENDPOINT" or "http://localhost:4318"), or the file path for the "file" sink (required)`,
+			Destination: &flagset.flagTelemetrySinkTarget,
+		},
+		&cli.StringFlag{
+			Name:        "telemetry",
+			EnvVars:     []string{"AZTFEXPORT_TELEMETRY"},
+			Usage:       `The detail of the telemetry, can be one of "off" (no telemetry, regardless of the sink), "minimal" (only the phases, without the error messages, and the events) and "full". The telemetry is sent in batches, and spooled to the config directory when the endpoint is unreachable, which is sent on the next run (default: "full")`,
+			Destination: &flagset.flagTelemetry,
+		},
+		&cli.StringFlag{
+			Name:        "profile-dir",
+			EnvVars:     []string{"AZTFEXPORT_PROFILE_DIR"},
+			Usage:       `The directory to write the pprof CPU and heap profiles of the run to (i.e. "cpu.pprof" and "heap.pprof"), which is created if not exists. The profiles help to report the performance issues, along with the phase metrics in the report`,
+			Destination: &flagset.flagProfileDir,
+		},
+		&cli.StringFlag{
+			Name:        "pulumi-convert",
+			EnvVars:     []string{"AZTFEXPORT_PULUMI_CONVERT"},
+			Usage:       `(Experimental) Convert the generated Terraform configuration to a Pulumi program of the specified language (e.g. "yaml", "typescript") via "pulumi convert" (requires the pulumi executable in the PATH)`,
+			Destination: &flagset.flagPulumiConvert,
+		},
 		&cli.StringFlag{
 			Name:        "log-path",
 			EnvVars:     []string{"AZTFEXPORT_LOG_PATH"},
@@ -240,6 +855,19 @@ func main() {
 			Destination: &flagLogLevel,
 			Value:       "INFO",
 		},
+		&cli.StringFlag{
+			Name:        "log-format",
+			EnvVars:     []string{"AZTFEXPORT_LOG_FORMAT"},
+			Usage:       `Log format, can be one of "text" and "json". The "json" format is a structured log, where the entries carry the run id, the phase and (for the per-resource entries) the Azure resource id`,
+			Destination: &flagLogFormat,
+			Value:       "text",
+		},
+		&cli.StringFlag{
+			Name:        "error-report",
+			EnvVars:     []string{"AZTFEXPORT_ERROR_REPORT"},
+			Usage:       fmt.Sprintf(`The file path to write the problems of the run to, one per line in the format of "%s<kind>::<Azure resource id>::<TF address>::<message>", where the kind is one of "%s", "%s" and "%s"`, internal.ProblemLinePrefix, internal.ProblemKindImportError, internal.ProblemKindUnsupported, internal.ProblemKindFatal),
+			Destination: &flagErrorReport,
+		},
 
 		// Common flags (auth)
 		&cli.BoolFlag{
@@ -266,6 +894,18 @@ func main() {
 			Usage:       "Explicitly use the OIDC to do authentication",
 			Destination: &flagset.flagUseOIDCCred,
 		},
+		&cli.BoolFlag{
+			Name:        "use-workload-identity-cred",
+			EnvVars:     []string{"AZTFEXPORT_USE_WORKLOAD_IDENTITY_CRED"},
+			Usage:       "Explicitly use the workload identity federation (e.g. of AKS) to do authentication, which reads the federated token from AZURE_FEDERATED_TOKEN_FILE",
+			Destination: &flagset.flagUseWorkloadIdentityCred,
+		},
+		&cli.StringSliceFlag{
+			Name:        "cred-chain",
+			EnvVars:     []string{"AZTFEXPORT_CRED_CHAIN"},
+			Usage:       fmt.Sprintf("The ordered credentials (e.g. \"oidc,cli,managed-identity\") to do authentication, the first one that gets a token is used. Each is one of %s. This conflicts with the \"--use-*-cred\" flags", strings.Join(credChainNames, ", ")),
+			Destination: &flagset.flagCredChain,
+		},
 		&cli.StringFlag{
 			Name:        "oidc-request-token",
 			EnvVars:     []string{"AZTFEXPORT_OIDC_REQUEST_TOKEN", "ARM_OIDC_REQUEST_TOKEN", "ACTIONS_ID_TOKEN_REQUEST_TOKEN"},
@@ -299,20 +939,27 @@ func main() {
 			Hidden:      true,
 			Destination: &flagset.hflagMockClient,
 		},
-		&cli.StringFlag{
-			Name:        "profile",
-			EnvVars:     []string{"AZTFEXPORT_PROFILE"},
-			Usage:       "Profile the program, possible values are `cpu` and `memory`",
-			Hidden:      true,
-			Destination: &flagset.hflagProfile,
-		},
 		&cli.StringFlag{
 			Name:        "tfclient-plugin-path",
 			EnvVars:     []string{"AZTFEXPORT_TFCLIENT_PLUGIN_PATH"},
-			Usage:       "Replace terraform binary with terraform-client-go for importing (must be used with `--hcl-only`)",
+			Usage:       "Replace terraform binary with terraform-client-go for importing. Without `--hcl-only`, the state is written to the local state file directly, or falls back to the terraform binary where the tfclient is not supported (e.g. the non-local backends or `--workspace`)",
 			Hidden:      true,
 			Destination: &flagset.hflagTFClientPluginPath,
 		},
+		&cli.StringFlag{
+			Name:        "tfclient-provider-version",
+			EnvVars:     []string{"AZTFEXPORT_TFCLIENT_PROVIDER_VERSION"},
+			Usage:       "Replace terraform binary with terraform-client-go for importing, using the azurerm provider of this version found from the local plugin directories, or downloaded if not found. Without `--hcl-only`, the state is written to the local state file directly, or falls back to the terraform binary where the tfclient is not supported (e.g. the non-local backends or `--workspace`)",
+			Hidden:      true,
+			Destination: &flagset.hflagTFClientProviderVersion,
+		},
+		&cli.StringFlag{
+			Name:        "profile",
+			EnvVars:     []string{"AZTFEXPORT_PROFILE"},
+			Usage:       "Deprecated, use `--profile-dir` instead. Profile the program to the current directory, possible values are `cpu` and `mem`",
+			Hidden:      true,
+			Destination: &flagset.hflagProfile,
+		},
 	}
 
 	resourceFlags := append([]cli.Flag{
@@ -336,12 +983,46 @@ func main() {
 			Name:        "name-pattern",
 			EnvVars:     []string{"AZTFEXPORT_NAME_PATTERN"},
 			Aliases:     []string{"p"},
-			Usage:       `The pattern of the resource name. The semantic of a pattern is the same as Go's os.CreateTemp()`,
+			Usage:       `The pattern of the resource name. The semantic of a pattern is the same as Go's os.CreateTemp(). Alternatively, a Go template (e.g. "{{ .Type }}-{{ .Tags.env }}-{{ .Name }}") with access to .Name, .Type, .ResourceGroup, .Parent, .Tags, .Index and .TypeIndex, and functions lower, upper and replace. The colliding names are suffixed by "-<n>"`,
 			Value:       "res-",
 			Destination: &flagset.flagPattern,
 		},
+		&cli.StringFlag{
+			Name:        "name-from",
+			EnvVars:     []string{"AZTFEXPORT_NAME_FROM"},
+			Usage:       fmt.Sprintf(`The strategy to name the resources, either %q (by the "--name-pattern", default), %q (the Azure resource name), %q (the Azure resource name in kebab case) or %q (e.g. "virtual_network-vnet1"). The colliding names are suffixed by "-<n>"`, internalmeta.NameFromPattern, internalmeta.NameFromAzureName, internalmeta.NameFromAzureNameKebab, internalmeta.NameFromTypeAndName),
+			Destination: &flagset.flagNameFrom,
+		},
+		&cli.StringSliceFlag{
+			Name:        "include-tag",
+			EnvVars:     []string{"AZTFEXPORT_INCLUDE_TAG"},
+			Usage:       `The tag in form of "key=value" that the exported resources must have. The filter is applied by Azure Resource Graph, so the child resources are not filtered. Can be specified multiple times, in which case all of them must match`,
+			Destination: &flagset.flagIncludeTags,
+		},
+		&cli.StringSliceFlag{
+			Name:        "exclude-tag",
+			EnvVars:     []string{"AZTFEXPORT_EXCLUDE_TAG"},
+			Usage:       `The tag in form of "key=value" that the exported resources must not have. The filter is applied by Azure Resource Graph, so the child resources are not filtered. Can be specified multiple times, in which case none of them must match`,
+			Destination: &flagset.flagExcludeTags,
+		},
 	}, commonFlags...)
 
+	// The type filters only apply to the resource group mode
+	rgFlags := append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:        "only-types",
+			EnvVars:     []string{"AZTFEXPORT_ONLY_TYPES"},
+			Usage:       `The TF resource types (e.g. "azurerm_storage_account,azurerm_virtual_network") that the exported resources must be of, which are matched against the resolved TF resource types, so that a large resource group can be exported into multiple workspaces across runs. The unresolved resources are dropped`,
+			Destination: &flagset.flagOnlyTypes,
+		},
+		&cli.StringSliceFlag{
+			Name:        "skip-types",
+			EnvVars:     []string{"AZTFEXPORT_SKIP_TYPES"},
+			Usage:       `The TF resource types that the exported resources must not be of, which is the inverse of "--only-types"`,
+			Destination: &flagset.flagSkipTypes,
+		},
+	}, resourceGroupFlags...)
+
 	queryFlags := append([]cli.Flag{
 		&cli.BoolFlag{
 			Name:        "recursive",
@@ -350,25 +1031,166 @@ func main() {
 			Usage:       "Recursively lists child resources of the resulting query resources",
 			Destination: &flagset.flagRecursive,
 		},
+		&cli.StringSliceFlag{
+			Name:        "type",
+			EnvVars:     []string{"AZTFEXPORT_QUERY_TYPE"},
+			Usage:       `The Azure resource type (e.g. "Microsoft.Network/virtualNetworks") that the queried resources are of. Can be specified multiple times, in which case any of them must match. This is combined with the where predicate via "and"`,
+			Destination: &flagset.flagQueryTypes,
+		},
+		&cli.StringSliceFlag{
+			Name:        "location",
+			EnvVars:     []string{"AZTFEXPORT_QUERY_LOCATION"},
+			Usage:       `The location (e.g. "westeurope") that the queried resources are in. Can be specified multiple times, in which case any of them must match. This is combined with the where predicate via "and"`,
+			Destination: &flagset.flagQueryLocations,
+		},
+		&cli.StringFlag{
+			Name:        "created-after",
+			EnvVars:     []string{"AZTFEXPORT_CREATED_AFTER"},
+			Usage:       `Only query the resources created after the time, which is either a RFC3339 timestamp, or a duration (e.g. "72h") before now. The resources that don't record their creation time are not matched`,
+			Destination: &flagset.flagCreatedAfter,
+		},
+		&cli.StringFlag{
+			Name:        "created-before",
+			EnvVars:     []string{"AZTFEXPORT_CREATED_BEFORE"},
+			Usage:       `Only query the resources created before the time, which is either a RFC3339 timestamp, or a duration (e.g. "72h") before now. The resources that don't record their creation time are not matched`,
+			Destination: &flagset.flagCreatedBefore,
+		},
+		&cli.StringFlag{
+			Name:        "name-regex",
+			EnvVars:     []string{"AZTFEXPORT_NAME_REGEX"},
+			Usage:       "The regular expression (RE2) that the names of the queried resources must match",
+			Destination: &flagset.flagNameRegex,
+		},
 	}, resourceGroupFlags...)
 
 	mappingFileFlags := append([]cli.Flag{}, commonFlags...)
 
-	safeOutputFileNames := config.OutputFileNames{
-		TerraformFileName:   "terraform.aztfexport.tf",
-		ProviderFileName:    "provider.aztfexport.tf",
-		MainFileName:        "main.aztfexport.tf",
-		ImportBlockFileName: "import.aztfexport.tf",
-	}
+	watchFlags := append([]cli.Flag{
+		&cli.DurationFlag{
+			Name:        "interval",
+			EnvVars:     []string{"AZTFEXPORT_INTERVAL"},
+			Usage:       "The interval between two rounds of discovery",
+			Value:       time.Hour,
+			Destination: &flagset.flagWatchInterval,
+		},
+		&cli.BoolFlag{
+			Name:        "once",
+			EnvVars:     []string{"AZTFEXPORT_ONCE"},
+			Usage:       "Only run one round of discovery, then exit",
+			Destination: &flagset.flagWatchOnce,
+		},
+		&cli.StringFlag{
+			Name:        "export-branch",
+			EnvVars:     []string{"AZTFEXPORT_EXPORT_BRANCH"},
+			Usage:       "Export the unmanaged resources to the output directory (must be a git repository) on this branch, and commit the changes of the generated config (*.tf) and the resource mapping file. The state and the other files are left uncommitted. The resources that are not indexed by Azure Resource Graph (e.g. child resources) are reported only",
+			Destination: &flagset.flagWatchBranch,
+		},
+	}, queryFlags...)
 
-	app := &cli.App{
-		Name:      "aztfexport",
-		Version:   getVersion(),
-		Usage:     "A tool to bring existing Azure resources under Terraform's management",
-		UsageText: "aztfexport <command> [option] <scope>",
-		Before:    prepareConfigFile,
-		Commands: []*cli.Command{
-			{
+	syncFlags := append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:        "state-rm",
+			EnvVars:     []string{"AZTFEXPORT_STATE_RM"},
+			Usage:       `Run "terraform state rm" for the resources that disappeared since the last sync. The resources whose TF resource id differs from the Azure resource id are only reported`,
+			Destination: &flagset.flagSyncStateRm,
+		},
+	}, queryFlags...)
+
+	multiFlags := append([]cli.Flag{
+		&cli.IntFlag{
+			Name:        "concurrency",
+			EnvVars:     []string{"AZTFEXPORT_CONCURRENCY"},
+			Usage:       "The number of scopes to export at the same time. The `--parallelism` is shared among them",
+			Value:       2,
+			Destination: &flagset.flagConcurrency,
+		},
+	}, resourceGroupFlags...)
+
+	retryFlags := append([]cli.Flag{
+		&cli.StringFlag{
+			Name:        "from",
+			EnvVars:     []string{"AZTFEXPORT_FROM"},
+			Usage:       fmt.Sprintf("The run summary file (%s) of a previous non-interactive run, whose failed resources are re-attempted. The output directory defaults to the directory of the file", internal.SummaryFileName),
+			Required:    true,
+			Destination: &flagset.flagRetryFrom,
+		},
+	}, commonFlags...)
+
+	benchFlags := append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:        "mock-import",
+			EnvVars:     []string{"AZTFEXPORT_MOCK_IMPORT"},
+			Usage:       "Don't import the resources, which only measures the phases before importing (i.e. initializing and listing)",
+			Destination: &flagset.flagBenchMockImport,
+		},
+	}, resourceGroupFlags...)
+
+	// The mapping command only talks to Azure, it doesn't need the flags of the export (e.g. the output directory, the provider).
+	var mappingCommandFlags []cli.Flag
+	for _, flag := range commonFlags {
+		switch name := flag.Names()[0]; {
+		case name == "env", name == "resource-manager-endpoint", name == "subscription-id", name == "parallelism", name == "log-path", name == "log-level",
+			strings.HasPrefix(name, "use-"), strings.HasPrefix(name, "oidc-"), name == "cred-chain":
+			mappingCommandFlags = append(mappingCommandFlags, flag)
+		}
+	}
+	// The doctor command checks the environment of the export, it only needs the flags of the terraform provider, the authentication and the output directory.
+	var doctorFlags []cli.Flag
+	for _, flag := range commonFlags {
+		switch name := flag.Names()[0]; {
+		case name == "env", name == "resource-manager-endpoint", name == "subscription-id", name == "output-dir", name == "provider", name == "provider-registry",
+			name == "provider-source", name == "provider-mirror", name == "log-path", name == "log-level", strings.HasPrefix(name, "use-"), strings.HasPrefix(name, "oidc-"), name == "cred-chain":
+			doctorFlags = append(doctorFlags, flag)
+		}
+	}
+	// The diff command compares the resources discovered by an ARG query with the state in the live mode, it only needs the query flags, the authentication and the output directory.
+	var diffLive bool
+	diffFlags := []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "live",
+			EnvVars:     []string{"AZTFEXPORT_LIVE"},
+			Usage:       "Compare the resources in Azure determined by an Azure Resource Graph where predicate with the ones in the state of the output directory, instead of comparing two export outputs",
+			Destination: &diffLive,
+		},
+	}
+	for _, flag := range queryFlags {
+		switch name := flag.Names()[0]; {
+		case name == "recursive", name == "type", name == "location", name == "created-after", name == "created-before", name == "name-regex", name == "include-tag", name == "exclude-tag",
+			name == "env", name == "resource-manager-endpoint", name == "subscription-id", name == "output-dir", name == "parallelism", name == "log-path", name == "log-level",
+			strings.HasPrefix(name, "use-") && strings.HasSuffix(name, "-cred"), strings.HasPrefix(name, "oidc-"), name == "cred-chain":
+			diffFlags = append(diffFlags, flag)
+		}
+	}
+	// The regenerate command works on an existing export output, it only needs the flags of the config generation.
+	var regenerateFlags []cli.Flag
+	for _, flag := range commonFlags {
+		switch flag.Names()[0] {
+		case "output-dir", "full-properties", "log-path", "log-level":
+			regenerateFlags = append(regenerateFlags, flag)
+		}
+	}
+	var regenerateAddrs cli.StringSlice
+	var upgradeProviderVersion string
+
+	var (
+		mappingOffline    bool
+		mappingOnConflict string
+		mappingOutput     string
+	)
+
+	// The temporary output directory of the bench command, which is removed after the benchmark.
+	var benchOutputDir string
+
+	app := &cli.App{
+		Name:      "aztfexport",
+		Version:   getVersion(),
+		Usage:     "A tool to bring existing Azure resources under Terraform's management",
+		UsageText: "aztfexport <command> [option] <scope>",
+		Before:    prepareConfigFile,
+		// The completion scripts (see the completion command) call aztfexport with "--generate-bash-completion".
+		EnableBashCompletion: true,
+		Commands: []*cli.Command{
+			{
 				Name:      "config",
 				Usage:     `Configuring the tool`,
 				UsageText: "aztfexport config [subcommand]",
@@ -379,7 +1201,7 @@ func main() {
 						UsageText: "aztfexport config set key value",
 						Action: func(c *cli.Context) error {
 							if c.NArg() != 2 {
-								return fmt.Errorf("Please specify a configuration key and value")
+								return i18n.Errorf("Please specify a configuration key and value")
 							}
 
 							key := c.Args().Get(0)
@@ -394,7 +1216,7 @@ func main() {
 						UsageText: "aztfexport config get key",
 						Action: func(c *cli.Context) error {
 							if c.NArg() != 1 {
-								return fmt.Errorf("Please specify a configuration key")
+								return i18n.Errorf("Please specify a configuration key")
 							}
 
 							key := c.Args().Get(0)
@@ -434,10 +1256,10 @@ func main() {
 				Before:    commandBeforeFunc(&flagset),
 				Action: func(c *cli.Context) error {
 					if c.NArg() == 0 {
-						return fmt.Errorf("No resource id specified")
+						return i18n.Errorf("No resource id specified")
 					}
 					if c.NArg() > 1 {
-						return fmt.Errorf("More than one resource ids specified")
+						return i18n.Errorf("More than one resource ids specified")
 					}
 
 					resId := c.Args().First()
@@ -446,240 +1268,973 @@ func main() {
 						return fmt.Errorf("invalid resource id: %v", err)
 					}
 
-					cred, clientOpt, err := buildAzureSDKCredAndClientOpt(flagset)
+					commonConfig, err := flagset.BuildCommonConfig()
 					if err != nil {
 						return err
 					}
 
 					// Initialize the config
 					cfg := config.Config{
-						CommonConfig: config.CommonConfig{
-							SubscriptionId:       flagset.flagSubscriptionId,
-							AzureSDKCredential:   cred,
-							AzureSDKClientOption: *clientOpt,
-							OutputDir:            flagset.flagOutputDir,
-							ProviderVersion:      flagset.flagProviderVersion,
-							DevProvider:          flagset.flagDevProvider,
-							ContinueOnError:      flagset.flagContinue,
-							BackendType:          flagset.flagBackendType,
-							BackendConfig:        flagset.flagBackendConfig.Value(),
-							FullConfig:           flagset.flagFullConfig,
-							Parallelism:          flagset.flagParallelism,
-							HCLOnly:              flagset.flagHCLOnly,
-							ModulePath:           flagset.flagModulePath,
-							TelemetryClient:      initTelemetryClient(flagset.flagSubscriptionId),
-						},
+						CommonConfig:   commonConfig,
 						ResourceId:     resId,
 						TFResourceName: flagset.flagResName,
 						TFResourceType: flagset.flagResType,
 					}
 
+					return realMain(c.Context, cfg, flagset.runOptions(ModeResource))
+				},
+			},
+			{
+				Name:      ModeResourceGroup,
+				Aliases:   []string{"rg"},
+				Usage:     "Exporting resource groups and the nested resources reside within them. Multiple resource groups, or glob patterns of them (e.g. \"rg-prod-*\"), can be exported in one run",
+				UsageText: "aztfexport resource-group [option] <resource group name or pattern>...",
+				Flags:     rgFlags,
+				Before:    commandBeforeFunc(&flagset),
+				Action: func(c *cli.Context) error {
+					if c.NArg() == 0 {
+						return i18n.Errorf("No resource group specified")
+					}
+
+					rg := c.Args().First()
+
+					commonConfig, err := flagset.BuildCommonConfig()
+					if err != nil {
+						return err
+					}
+					commonConfig.AdditionalResourceGroupNames = c.Args().Tail()
+
+					// Initialize the config
+					cfg := config.Config{
+						CommonConfig:        commonConfig,
+						ResourceGroupName:   rg,
+						ResourceNamePattern: flagset.flagPattern,
+						NameFrom:            flagset.flagNameFrom,
+						IncludeTags:         parseTags(flagset.flagIncludeTags.Value()),
+						ExcludeTags:         parseTags(flagset.flagExcludeTags.Value()),
+						OnlyTypes:           flagset.flagOnlyTypes.Value(),
+						SkipTypes:           flagset.flagSkipTypes.Value(),
+						RecursiveQuery:      true,
+					}
+
+					return realMain(c.Context, cfg, flagset.runOptions(ModeResourceGroup))
+				},
+			},
+			{
+				Name:      ModeQuery,
+				Usage:     "Exporting a customized scope of resources determined by an Azure Resource Graph where predicate",
+				UsageText: "aztfexport query [option] [<ARG where predicate>]",
+				Flags:     queryFlags,
+				Before:    commandBeforeFunc(&flagset),
+				Action: func(c *cli.Context) error {
+					predicate, err := queryPredicate(c, flagset)
+					if err != nil {
+						return err
+					}
+
+					commonConfig, err := flagset.BuildCommonConfig()
+					if err != nil {
+						return err
+					}
+
+					// Initialize the config
+					cfg := config.Config{
+						CommonConfig:        commonConfig,
+						ARGPredicate:        predicate,
+						ResourceNamePattern: flagset.flagPattern,
+						NameFrom:            flagset.flagNameFrom,
+						IncludeTags:         parseTags(flagset.flagIncludeTags.Value()),
+						ExcludeTags:         parseTags(flagset.flagExcludeTags.Value()),
+						RecursiveQuery:      flagset.flagRecursive,
+					}
+
+					return realMain(c.Context, cfg, flagset.runOptions(ModeQuery))
+				},
+			},
+			{
+				Name:      ModeManagementGroup,
+				Aliases:   []string{"mg"},
+				Usage:     "Exporting the resources of all the subscriptions under a management group",
+				UsageText: "aztfexport management-group [option] <management group name>",
+				Flags:     resourceGroupFlags,
+				Before:    commandBeforeFunc(&flagset),
+				Action: func(c *cli.Context) error {
+					if c.NArg() == 0 {
+						return i18n.Errorf("No management group specified")
+					}
+					if c.NArg() > 1 {
+						return i18n.Errorf("More than one management groups specified")
+					}
+
+					mg := c.Args().First()
+
+					commonConfig, err := flagset.BuildCommonConfig()
+					if err != nil {
+						return err
+					}
+
+					// Initialize the config
+					cfg := config.Config{
+						CommonConfig:        commonConfig,
+						ManagementGroupName: mg,
+						ResourceNamePattern: flagset.flagPattern,
+						NameFrom:            flagset.flagNameFrom,
+						IncludeTags:         parseTags(flagset.flagIncludeTags.Value()),
+						ExcludeTags:         parseTags(flagset.flagExcludeTags.Value()),
+						RecursiveQuery:      true,
+					}
+
+					return realMain(c.Context, cfg, flagset.runOptions(ModeManagementGroup))
+				},
+			},
+			{
+				Name:      ModeWatch,
+				Usage:     "Periodically discovering the resources determined by an Azure Resource Graph where predicate, and reporting the ones that are not managed by the output directory yet",
+				UsageText: "aztfexport watch [option] [<ARG where predicate>]",
+				Flags:     watchFlags,
+				Before: func(c *cli.Context) error {
+					if flagset.flagWatchInterval <= 0 {
+						return fmt.Errorf("`--interval` must be a positive duration")
+					}
+					// The unmanaged resources are always exported non-interactively to the existing workspace.
+					flagset.flagAppend = true
+					flagset.flagNonInteractive = true
+					return commandBeforeFunc(&flagset)(c)
+				},
+				Action: func(c *cli.Context) error {
+					predicate, err := queryPredicate(c, flagset)
+					if err != nil {
+						return err
+					}
+
+					commonConfig, err := flagset.BuildCommonConfig()
+					if err != nil {
+						return err
+					}
+
+					if err := initLog(flagLogPath, flagLogLevel, flagLogFormat); err != nil {
+						return err
+					}
+					commonConfig.TelemetryClient.Trace(telemetry.Info, "Effective CLI: "+flagset.DescribeCLI(ModeWatch))
+					defer commonConfig.TelemetryClient.Close()
+
+					return watch.Run(c.Context, watch.Config{
+						OutputDir: flagset.flagOutputDir,
+						Interval:  flagset.flagWatchInterval,
+						Once:      flagset.flagWatchOnce,
+						Branch:    flagset.flagWatchBranch,
+						Discover:  discoverResourceIds(flagset, commonConfig, predicate),
+						Export:    appendExportResources(flagset, commonConfig, predicate),
+						Out:       os.Stdout,
+					})
+				},
+			},
+			{
+				Name:      ModeSync,
+				Usage:     "Syncing the output directory with the resources determined by an Azure Resource Graph where predicate, by exporting the resources added since the last sync, and reporting the ones removed",
+				UsageText: "aztfexport sync [option] [<ARG where predicate>]",
+				Flags:     syncFlags,
+				Before: func(c *cli.Context) error {
+					// The added resources are always exported non-interactively to the existing workspace.
+					flagset.flagAppend = true
+					flagset.flagNonInteractive = true
+					return commandBeforeFunc(&flagset)(c)
+				},
+				Action: func(c *cli.Context) error {
+					predicate, err := queryPredicate(c, flagset)
+					if err != nil {
+						return err
+					}
+
+					commonConfig, err := flagset.BuildCommonConfig()
+					if err != nil {
+						return err
+					}
+
+					if err := initLog(flagLogPath, flagLogLevel, flagLogFormat); err != nil {
+						return err
+					}
+					commonConfig.TelemetryClient.Trace(telemetry.Info, "Effective CLI: "+flagset.DescribeCLI(ModeSync))
+					defer commonConfig.TelemetryClient.Close()
+
+					_, err = incremental.Run(c.Context, incremental.Config{
+						OutputDir: flagset.flagOutputDir,
+						StateRm:   flagset.flagSyncStateRm,
+						Discover:  discoverResourceIds(flagset, commonConfig, predicate),
+						Export:    appendExportResources(flagset, commonConfig, predicate),
+						Out:       os.Stdout,
+					})
+					return err
+				},
+			},
+			{
+				Name:      ModeMulti,
+				Usage:     "Exporting multiple subscriptions or resource groups concurrently with the same options, each to its own directory under the output directory",
+				UsageText: "aztfexport multi [option] <scope file>",
+				Flags:     multiFlags,
+				Before: func(c *cli.Context) error {
+					if flagset.flagConcurrency <= 0 {
+						return fmt.Errorf("`--concurrency` must be a positive number")
+					}
 					if flagset.flagAppend {
-						cfg.CommonConfig.OutputFileNames = safeOutputFileNames
+						return fmt.Errorf("`--append` is not supported by the multi mode")
+					}
+					// The scopes would write their plans to the same file
+					if flagset.flagDryRunOutput != "" {
+						return fmt.Errorf("`--dry-run-output` is not supported by the multi mode")
+					}
+					flagset.flagNonInteractive = true
+					if err := commandBeforeFunc(&flagset)(c); err != nil {
+						return err
+					}
+					// Each scope has its own state, which can't be distinguished by the shared backend config
+					if flagset.flagBackendType != "local" {
+						return fmt.Errorf("the multi mode only works for local backend")
+					}
+					return nil
+				},
+				Action: func(c *cli.Context) (result error) {
+					if c.NArg() == 0 {
+						return i18n.Errorf("No scope file specified")
+					}
+					if c.NArg() > 1 {
+						return i18n.Errorf("More than one scope files specified")
+					}
+
+					scopes, err := multirun.ParseScopeFile(c.Args().First())
+					if err != nil {
+						return err
 					}
 
-					if flagset.hflagTFClientPluginPath != "" {
-						// #nosec G204
-						tfc, err := tfclient.New(tfclient.Option{
-							Cmd:    exec.Command(flagset.hflagTFClientPluginPath),
-							Logger: hclog.NewNullLogger(),
-						})
+					pluginPath, err := flagset.tfclientPluginPath()
+					if err != nil {
+						return err
+					}
+					// The tfclient is closed on the deinitialization of each scope, hence each scope starts its own provider below.
+					fset := flagset
+					fset.hflagTFClientPluginPath = ""
+					fset.hflagTFClientProviderVersion = ""
+					commonConfig, err := fset.BuildCommonConfig()
+					if err != nil {
+						return err
+					}
+
+					if err := initLog(flagLogPath, flagLogLevel, flagLogFormat); err != nil {
+						return err
+					}
+					commonConfig.TelemetryClient.Trace(telemetry.Info, "Effective CLI: "+flagset.DescribeCLI(ModeMulti))
+					defer commonConfig.TelemetryClient.Close()
+
+					// The total parallelism is bounded by sharing it among the concurrent scopes
+					parallelism := commonConfig.Parallelism / flagset.flagConcurrency
+					if parallelism == 0 {
+						parallelism = 1
+					}
+
+					opts := flagset.runOptions(ModeMulti)
+					opts.plainUI = true
+
+					// The profiles cover all the scopes, as the CPU profile can only be started once per process
+					if opts.profileDir != "" {
+						stop, err := startProfile(opts.profileDir)
 						if err != nil {
 							return err
 						}
-						cfg.CommonConfig.TFClient = tfc
+						defer func() {
+							if err := stop(); err != nil && result == nil {
+								result = err
+							}
+						}()
 					}
 
-					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeResource))
+					return multirun.Run(c.Context, multirun.Config{
+						OutputDir:   flagset.flagOutputDir,
+						Scopes:      scopes,
+						Concurrency: flagset.flagConcurrency,
+						Export: func(ctx context.Context, scope multirun.Scope, outputDir string) error {
+							cc := commonConfig
+							cc.SubscriptionId = scope.SubscriptionId
+							cc.OutputDir = outputDir
+							cc.Parallelism = parallelism
+							if pluginPath != "" {
+								tfc, err := newTFClient(pluginPath)
+								if err != nil {
+									return err
+								}
+								cc.TFClient = tfc
+							}
+							cfg := config.Config{
+								CommonConfig:        cc,
+								ResourceNamePattern: flagset.flagPattern,
+								NameFrom:            flagset.flagNameFrom,
+								IncludeTags:         parseTags(flagset.flagIncludeTags.Value()),
+								ExcludeTags:         parseTags(flagset.flagExcludeTags.Value()),
+								RecursiveQuery:      true,
+							}
+							if scope.ResourceGroup != "" {
+								cfg.ResourceGroupName = scope.ResourceGroup
+							} else {
+								cfg.ARGPredicate = fmt.Sprintf("subscriptionId == %q", scope.SubscriptionId)
+							}
+							return batchMain(ctx, cfg, opts)
+						},
+						Out: os.Stdout,
+					})
 				},
 			},
 			{
-				Name:      ModeResourceGroup,
-				Aliases:   []string{"rg"},
-				Usage:     "Exporting a resource group and the nested resources resides within it",
-				UsageText: "aztfexport resource-group [option] <resource group name>",
-				Flags:     resourceGroupFlags,
-				Before:    commandBeforeFunc(&flagset),
+				Name:      ModeRetry,
+				Usage:     "Re-attempting the resources that failed in a previous non-interactive run, which are imported to the existing output directory and state",
+				UsageText: "aztfexport retry [option] --from <run summary file>",
+				Flags:     retryFlags,
+				Before: func(c *cli.Context) error {
+					if !c.IsSet("output-dir") {
+						flagset.flagOutputDir = filepath.Dir(flagset.flagRetryFrom)
+					}
+					// The failed resources are always imported non-interactively to the existing workspace.
+					flagset.flagAppend = true
+					flagset.flagNonInteractive = true
+					return commandBeforeFunc(&flagset)(c)
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 0 {
+						return i18n.Errorf("No argument is expected")
+					}
+
+					summary, err := internal.ReadRunSummary(flagset.flagRetryFrom)
+					if err != nil {
+						return err
+					}
+					if len(summary.Failed) == 0 {
+						fmt.Println(i18n.T("No failed resource to retry"))
+						return nil
+					}
+
+					// The failed resources are re-attempted via the mapping file mode, the mapping file is only used within this run.
+					f, err := os.CreateTemp("", "aztfexport-retry-*.json")
+					if err != nil {
+						return fmt.Errorf("creating the temporary mapping file: %v", err)
+					}
+					// #nosec G104
+					defer os.Remove(f.Name())
+					b, err := json.Marshal(summary.FailedResourceMapping())
+					if err != nil {
+						return fmt.Errorf("marshalling the mapping of the failed resources: %v", err)
+					}
+					if _, err := f.Write(b); err != nil {
+						return fmt.Errorf("writing the temporary mapping file: %v", err)
+					}
+					if err := f.Close(); err != nil {
+						return fmt.Errorf("closing the temporary mapping file: %v", err)
+					}
+
+					commonConfig, err := flagset.BuildCommonConfig()
+					if err != nil {
+						return err
+					}
+
+					// Initialize the config
+					cfg := config.Config{
+						CommonConfig: commonConfig,
+						MappingFile:  f.Name(),
+					}
+
+					// The failed resources are always imported, rather than only generating the mapping file.
+					opts := flagset.runOptions(ModeRetry)
+					opts.genMappingFileOnly = false
+					return realMain(c.Context, cfg, opts)
+				},
+			},
+			{
+				Name:      ModeBench,
+				Usage:     "Measuring the duration and throughput of listing, importing and generating a resource group, which helps tuning the `--parallelism`",
+				UsageText: "aztfexport bench [option] <resource group name>",
+				Flags:     benchFlags,
+				Before: func(c *cli.Context) error {
+					// The benchmark runs in a temporary output directory, unless specified.
+					if !c.IsSet("output-dir") {
+						dir, err := os.MkdirTemp("", "aztfexport-bench-")
+						if err != nil {
+							return fmt.Errorf("creating the temporary output directory: %v", err)
+						}
+						benchOutputDir = dir
+						flagset.flagOutputDir = dir
+					}
+					flagset.flagNonInteractive = true
+					return commandBeforeFunc(&flagset)(c)
+				},
+				After: func(c *cli.Context) error {
+					if benchOutputDir != "" {
+						return os.RemoveAll(benchOutputDir)
+					}
+					return nil
+				},
 				Action: func(c *cli.Context) error {
 					if c.NArg() == 0 {
-						return fmt.Errorf("No resource group specified")
+						return i18n.Errorf("No resource group specified")
 					}
 					if c.NArg() > 1 {
-						return fmt.Errorf("More than one resource groups specified")
+						return i18n.Errorf("More than one resource groups specified")
 					}
 
 					rg := c.Args().First()
 
-					cred, clientOpt, err := buildAzureSDKCredAndClientOpt(flagset)
+					commonConfig, err := flagset.BuildCommonConfig()
 					if err != nil {
 						return err
 					}
 
-					// Initialize the config
+					if err := initLog(flagLogPath, flagLogLevel, flagLogFormat); err != nil {
+						return err
+					}
+					commonConfig.TelemetryClient.Trace(telemetry.Info, "Effective CLI: "+flagset.DescribeCLI(ModeBench))
+					defer commonConfig.TelemetryClient.Close()
+
 					cfg := config.Config{
-						CommonConfig: config.CommonConfig{
-							SubscriptionId:       flagset.flagSubscriptionId,
-							AzureSDKCredential:   cred,
-							AzureSDKClientOption: *clientOpt,
-							OutputDir:            flagset.flagOutputDir,
-							ProviderVersion:      flagset.flagProviderVersion,
-							DevProvider:          flagset.flagDevProvider,
-							ContinueOnError:      flagset.flagContinue,
-							BackendType:          flagset.flagBackendType,
-							BackendConfig:        flagset.flagBackendConfig.Value(),
-							FullConfig:           flagset.flagFullConfig,
-							Parallelism:          flagset.flagParallelism,
-							HCLOnly:              flagset.flagHCLOnly,
-							ModulePath:           flagset.flagModulePath,
-							TelemetryClient:      initTelemetryClient(flagset.flagSubscriptionId),
-						},
+						CommonConfig:        commonConfig,
 						ResourceGroupName:   rg,
 						ResourceNamePattern: flagset.flagPattern,
+						NameFrom:            flagset.flagNameFrom,
+						IncludeTags:         parseTags(flagset.flagIncludeTags.Value()),
+						ExcludeTags:         parseTags(flagset.flagExcludeTags.Value()),
 						RecursiveQuery:      true,
 					}
 
-					if flagset.flagAppend {
-						cfg.CommonConfig.OutputFileNames = safeOutputFileNames
+					var m meta.Meta = internalmeta.NewGroupMetaDummy(rg)
+					if !flagset.hflagMockClient {
+						m, err = meta.NewMeta(cfg)
+						if err != nil {
+							return err
+						}
+					}
+
+					result, err := bench.Run(c.Context, m, bench.Config{
+						Parallelism: commonConfig.Parallelism,
+						MockImport:  flagset.flagBenchMockImport,
+					})
+					if err != nil {
+						return err
+					}
+					result.Write(os.Stdout)
+					return nil
+				},
+			},
+			{
+				Name:      ModeMappingFile,
+				Aliases:   []string{"map"},
+				Usage:     "Exporting a customized scope of resources determined by the resource mapping file (or a CSV, or the JSON exported from Azure Resource Graph)",
+				UsageText: "aztfexport mapping-file [option] <resource mapping file>",
+				Flags:     mappingFileFlags,
+				Before:    commandBeforeFunc(&flagset),
+				Action: func(c *cli.Context) error {
+					if c.NArg() == 0 {
+						return i18n.Errorf("No resource mapping file specified")
+					}
+					if c.NArg() > 1 {
+						return i18n.Errorf("More than one resource mapping files specified")
 					}
 
-					if flagset.hflagTFClientPluginPath != "" {
-						// #nosec G204
-						tfc, err := tfclient.New(tfclient.Option{
-							Cmd:    exec.Command(flagset.hflagTFClientPluginPath),
-							Logger: hclog.NewNullLogger(),
-						})
+					mapFile := c.Args().First()
+
+					commonConfig, err := flagset.BuildCommonConfig()
+					if err != nil {
+						return err
+					}
+
+					// Initialize the config
+					cfg := config.Config{
+						CommonConfig: commonConfig,
+						MappingFile:  mapFile,
+					}
+
+					return realMain(c.Context, cfg, flagset.runOptions(ModeMappingFile))
+				},
+			},
+			{
+				Name:      ModePlan,
+				Usage:     "Importing the existing resources that a Terraform plan is to create, by generating the resource mapping file (and the import blocks) of them for the Terraform configuration",
+				UsageText: "aztfexport plan [option] <plan JSON file>",
+				Flags:     mappingFileFlags,
+				Before: func(c *cli.Context) error {
+					// The configuration of the planned resources is already written by the user, only the mapping is generated.
+					flagset.flagGenerateMappingFile = true
+					return commandBeforeFunc(&flagset)(c)
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() == 0 {
+						return i18n.Errorf("No plan file specified")
+					}
+					if c.NArg() > 1 {
+						return i18n.Errorf("More than one plan files specified")
+					}
+
+					plan, err := planimport.ReadPlan(c.Args().First())
+					if err != nil {
+						return err
+					}
+
+					commonConfig, err := flagset.BuildCommonConfig()
+					if err != nil {
+						return err
+					}
+
+					lookup, err := planimport.NewARMLookup(commonConfig.SubscriptionId, commonConfig.AzureSDKCredential, internalmeta.ClientOption(commonConfig))
+					if err != nil {
+						return err
+					}
+					resources, unmatched := planimport.PlannedResources(plan)
+					mapping, unmatchedExisting, err := planimport.BuildMapping(c.Context, commonConfig.SubscriptionId, resources, *lookup)
+					if err != nil {
+						return err
+					}
+					for _, res := range append(unmatched, unmatchedExisting...) {
+						fmt.Fprintf(os.Stderr, "Skipping %s: %s\n", res.Address, res.Reason)
+					}
+					if len(mapping) == 0 {
+						fmt.Println(i18n.T("No planned resource exists in Azure"))
+						return nil
+					}
+
+					// The matched resources are imported via the mapping file mode, the mapping file is only used within this run.
+					f, err := os.CreateTemp("", "aztfexport-plan-*.json")
+					if err != nil {
+						return fmt.Errorf("creating the temporary mapping file: %v", err)
+					}
+					// #nosec G104
+					defer os.Remove(f.Name())
+					b, err := json.Marshal(mapping)
+					if err != nil {
+						return fmt.Errorf("marshalling the mapping of the planned resources: %v", err)
+					}
+					if _, err := f.Write(b); err != nil {
+						return fmt.Errorf("writing the temporary mapping file: %v", err)
+					}
+					if err := f.Close(); err != nil {
+						return fmt.Errorf("closing the temporary mapping file: %v", err)
+					}
+
+					// Initialize the config
+					cfg := config.Config{
+						CommonConfig: commonConfig,
+						MappingFile:  f.Name(),
+					}
+
+					return realMain(c.Context, cfg, flagset.runOptions(ModePlan))
+				},
+			},
+			{
+				Name:      "regenerate",
+				Usage:     "Regenerating the config of the specified resources of an export output from their state, in place, without re-importing them. The rest of the config (e.g. the hand edits) is kept as is",
+				UsageText: "aztfexport regenerate [option] --addr <TF resource address>",
+				Flags: append([]cli.Flag{
+					&cli.StringSliceFlag{
+						Name:        "addr",
+						EnvVars:     []string{"AZTFEXPORT_ADDR"},
+						Usage:       "The TF resource address (e.g. \"azurerm_storage_account.foo\"), or the glob pattern of it (e.g. \"azurerm_storage_account.*\"), to regenerate. This can be repeated",
+						Required:    true,
+						Destination: &regenerateAddrs,
+					},
+				}, regenerateFlags...),
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 0 {
+						return i18n.Errorf("No argument is expected")
+					}
+					if err := initLog(flagLogPath, flagLogLevel, flagLogFormat); err != nil {
+						return err
+					}
+					addrs, err := regenerate.Run(c.Context, regenerate.Config{
+						Dir:   flagset.flagOutputDir,
+						Addrs: regenerateAddrs.Value(),
+						Full:  flagset.flagFullConfig,
+					})
+					if err != nil {
+						return err
+					}
+					for _, addr := range addrs {
+						fmt.Printf("Regenerated %s\n", addr)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "upgrade-config",
+				Usage:     "Upgrading the azurerm provider of an export output to a new version, regenerating the config of its resources against the new provider schema from their state. What can't be done mechanically is reported as the follow-ups",
+				UsageText: "aztfexport upgrade-config [option] --to-provider-version <version>",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:        "to-provider-version",
+						EnvVars:     []string{"AZTFEXPORT_TO_PROVIDER_VERSION"},
+						Usage:       "The azurerm provider version to upgrade to, either a version (e.g. \"4.30.0\"), or a major version (e.g. \"4.x\") for its latest release",
+						Required:    true,
+						Destination: &upgradeProviderVersion,
+					},
+				}, regenerateFlags...),
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 0 {
+						return i18n.Errorf("No argument is expected")
+					}
+					if err := initLog(flagLogPath, flagLogLevel, flagLogFormat); err != nil {
+						return err
+					}
+					report, err := regenerate.Upgrade(c.Context, regenerate.UpgradeConfig{
+						Dir:             flagset.flagOutputDir,
+						ProviderVersion: upgradeProviderVersion,
+						Full:            flagset.flagFullConfig,
+					})
+					if report != nil {
+						for _, addr := range report.Regenerated {
+							fmt.Printf("Regenerated %s\n", addr)
+						}
+						if len(report.FollowUps) != 0 {
+							fmt.Println("Follow-ups:")
+							for _, fu := range report.FollowUps {
+								where := fu.Address
+								if where == "" {
+									where = fu.Location
+								}
+								fmt.Printf("  - %s: %s\n", where, fu.Message)
+							}
+						}
+						fmt.Printf("The upgrade report is written to %s\n", filepath.Join(flagset.flagOutputDir, regenerate.UpgradeReportFileName))
+					}
+					return err
+				},
+			},
+			{
+				Name:      "diff",
+				Usage:     "Comparing two export outputs, reporting the resources added, removed and changed (at attribute level) from the first to the second. With `--live`, comparing the resources in Azure with the ones in the state of the output directory instead",
+				UsageText: "aztfexport diff <old output directory> <new output directory>\naztfexport diff --live [option] [<ARG where predicate>]",
+				Flags:     diffFlags,
+				Action: func(c *cli.Context) error {
+					if diffLive {
+						predicate, err := queryPredicate(c, flagset)
+						if err != nil {
+							return err
+						}
+						if err := internalmeta.ValidateARGFilter(flagset.argFilter()); err != nil {
+							return err
+						}
+						if err := initLog(flagLogPath, flagLogLevel, flagLogFormat); err != nil {
+							return err
+						}
+						b, err := mappingClientBuilder(&flagset)
 						if err != nil {
 							return err
 						}
-						cfg.CommonConfig.TFClient = tfc
-					}
-
-					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeResourceGroup))
-				},
-			},
-			{
-				Name:      ModeQuery,
-				Usage:     "Exporting a customized scope of resources determined by an Azure Resource Graph where predicate",
-				UsageText: "aztfexport query [option] <ARG where predicate>",
-				Flags:     queryFlags,
-				Before:    commandBeforeFunc(&flagset),
-				Action: func(c *cli.Context) error {
-					if c.NArg() == 0 {
-						return fmt.Errorf("No query specified")
+						ids, err := discoverResourceIds(flagset, config.CommonConfig{
+							SubscriptionId:       flagset.flagSubscriptionId,
+							AzureSDKCredential:   b.Credential,
+							AzureSDKClientOption: b.Opt,
+							Parallelism:          flagset.flagParallelism,
+						}, predicate)(c.Context)
+						if err != nil {
+							return fmt.Errorf("discovering resources: %v", err)
+						}
+						result, err := diff.DiffLive(c.Context, flagset.flagOutputDir, ids)
+						if err != nil {
+							return err
+						}
+						result.Write(os.Stdout)
+						return nil
 					}
-					if c.NArg() > 1 {
-						return fmt.Errorf("More than one queries specified. Use `and` with double quotes to run multiple query parameters.")
+					if c.NArg() != 2 {
+						return i18n.Errorf("Exactly two output directories are expected")
 					}
-
-					predicate := c.Args().First()
-
-					cred, clientOpt, err := buildAzureSDKCredAndClientOpt(flagset)
+					result, err := diff.Diff(c.Args().Get(0), c.Args().Get(1))
 					if err != nil {
 						return err
 					}
-
-					// Initialize the config
-					cfg := config.Config{
-						CommonConfig: config.CommonConfig{
-							SubscriptionId:       flagset.flagSubscriptionId,
-							AzureSDKCredential:   cred,
-							AzureSDKClientOption: *clientOpt,
-							OutputDir:            flagset.flagOutputDir,
-							ProviderVersion:      flagset.flagProviderVersion,
-							DevProvider:          flagset.flagDevProvider,
-							ContinueOnError:      flagset.flagContinue,
-							BackendType:          flagset.flagBackendType,
-							BackendConfig:        flagset.flagBackendConfig.Value(),
-							FullConfig:           flagset.flagFullConfig,
-							Parallelism:          flagset.flagParallelism,
-							HCLOnly:              flagset.flagHCLOnly,
-							ModulePath:           flagset.flagModulePath,
-							TelemetryClient:      initTelemetryClient(flagset.flagSubscriptionId),
-						},
-						ARGPredicate:        predicate,
-						ResourceNamePattern: flagset.flagPattern,
-						RecursiveQuery:      flagset.flagRecursive,
-					}
-
-					if flagset.flagAppend {
-						cfg.CommonConfig.OutputFileNames = safeOutputFileNames
+					result.Write(os.Stdout)
+					return nil
+				},
+			},
+			{
+				Name:      "completion",
+				Usage:     "Generating the completion script of the shell, which also completes the azurerm resource types and the subscription ids (of the Azure CLI) of the flags",
+				UsageText: "aztfexport completion <" + strings.Join(completionShells, "|") + ">",
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("exactly one shell is expected, one of: %s", strings.Join(completionShells, ", "))
 					}
-
-					if flagset.hflagTFClientPluginPath != "" {
-						// #nosec G204
-						tfc, err := tfclient.New(tfclient.Option{
-							Cmd:    exec.Command(flagset.hflagTFClientPluginPath),
-							Logger: hclog.NewNullLogger(),
-						})
-						if err != nil {
-							return err
-						}
-						cfg.CommonConfig.TFClient = tfc
+					script, ok := completionScripts[c.Args().First()]
+					if !ok {
+						return fmt.Errorf("unsupported shell %q, one of: %s", c.Args().First(), strings.Join(completionShells, ", "))
 					}
-
-					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeQuery))
+					fmt.Print(script)
+					return nil
 				},
 			},
 			{
-				Name:      ModeMappingFile,
-				Aliases:   []string{"map"},
-				Usage:     "Exporting a customized scope of resources determined by the resource mapping file",
-				UsageText: "aztfexport mapping-file [option] <resource mapping file>",
-				Flags:     mappingFileFlags,
-				Before:    commandBeforeFunc(&flagset),
+				Name:      "doctor",
+				Usage:     "Checking the environment of the export (i.e. the terraform executable, the provider downloadability, the Azure credential and permissions, the output directory), with the remediation steps of the problems",
+				UsageText: "aztfexport doctor [option]",
+				Flags:     doctorFlags,
 				Action: func(c *cli.Context) error {
-					if c.NArg() == 0 {
-						return fmt.Errorf("No resource mapping file specified")
+					if c.NArg() != 0 {
+						return i18n.Errorf("No argument is expected")
 					}
-					if c.NArg() > 1 {
-						return fmt.Errorf("More than one resource mapping files specified")
+					if err := initLog(flagLogPath, flagLogLevel, flagLogFormat); err != nil {
+						return err
+					}
+					providerSource := internalmeta.ProviderSource(flagset.flagProviderName, flagset.flagProviderRegistry)
+					if flagset.flagProviderSource != "" {
+						providerSource = flagset.flagProviderSource
+					}
+					checks := []doctor.Check{
+						doctor.TerraformCheck(internalmeta.FindTerraform),
+						doctor.ProviderCheck(&http.Client{Timeout: 30 * time.Second}, providerSource, flagset.flagProviderMirror),
 					}
-
-					mapFile := c.Args().First()
-
 					cred, clientOpt, err := buildAzureSDKCredAndClientOpt(flagset)
 					if err != nil {
-						return err
+						checks = append(checks, doctor.Failed(doctor.CheckCredential, err, "Check the `--use-*` flags, and the ARM_* environment variables of the credential."))
+						clientOpt = &arm.ClientOptions{}
+					} else {
+						checks = append(checks, doctor.CredentialCheck(cred, *clientOpt))
 					}
-
-					// Initialize the config
-					cfg := config.Config{
-						CommonConfig: config.CommonConfig{
-							SubscriptionId:       flagset.flagSubscriptionId,
-							AzureSDKCredential:   cred,
-							AzureSDKClientOption: *clientOpt,
-							OutputDir:            flagset.flagOutputDir,
-							ProviderVersion:      flagset.flagProviderVersion,
-							DevProvider:          flagset.flagDevProvider,
-							ContinueOnError:      flagset.flagContinue,
-							BackendType:          flagset.flagBackendType,
-							BackendConfig:        flagset.flagBackendConfig.Value(),
-							FullConfig:           flagset.flagFullConfig,
-							Parallelism:          flagset.flagParallelism,
-							HCLOnly:              flagset.flagHCLOnly,
-							ModulePath:           flagset.flagModulePath,
-							TelemetryClient:      initTelemetryClient(flagset.flagSubscriptionId),
-						},
-						MappingFile: mapFile,
+					subscriptionId := flagset.flagSubscriptionId
+					if ids := flagset.flagSubscriptionIds.Value(); len(ids) != 0 {
+						subscriptionId = ids[0]
 					}
-
-					if flagset.flagAppend {
-						cfg.CommonConfig.OutputFileNames = safeOutputFileNames
+					var subscriptionErr error
+					if subscriptionId == "" {
+						subscriptionId, subscriptionErr = subscriptionIdFromCLI()
 					}
-
-					if flagset.hflagTFClientPluginPath != "" {
-						// #nosec G204
-						tfc, err := tfclient.New(tfclient.Option{
-							Cmd:    exec.Command(flagset.hflagTFClientPluginPath),
-							Logger: hclog.NewNullLogger(),
-						})
-						if err != nil {
-							return err
-						}
-						cfg.CommonConfig.TFClient = tfc
+					if subscriptionErr != nil {
+						checks = append(checks, doctor.Failed(doctor.CheckSubscription, fmt.Errorf("retrieving subscription id from CLI: %v", subscriptionErr), "Specify the subscription via `--subscription-id`, or run `az account set`."))
+					} else {
+						checks = append(checks, doctor.SubscriptionCheck(subscriptionId, cred, *clientOpt))
 					}
-
-					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeMappingFile))
+					checks = append(checks,
+						doctor.ARGCheck(subscriptionId, cred, *clientOpt),
+						doctor.OutputDirCheck(flagset.flagOutputDir),
+					)
+					if failed := doctor.Write(os.Stdout, doctor.Run(c.Context, checks)); failed != 0 {
+						return fmt.Errorf("%d check(s) failed", failed)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "mapping",
+				Usage:     "Maintaining the resource mapping files",
+				UsageText: "aztfexport mapping [subcommand]",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "validate",
+						Usage:     "Validate a resource mapping file: the TF resource types and names are valid, no duplicate, and the resources exist in the subscription",
+						UsageText: "aztfexport mapping validate [option] <mapping file>",
+						Flags: append([]cli.Flag{
+							&cli.BoolFlag{
+								Name:        "offline",
+								EnvVars:     []string{"AZTFEXPORT_OFFLINE"},
+								Usage:       "Don't check the existence of the resources",
+								Destination: &mappingOffline,
+							},
+						}, mappingCommandFlags...),
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 1 {
+								return i18n.Errorf("Exactly one mapping file is expected")
+							}
+							m, err := mapping.Read(c.Args().First())
+							if err != nil {
+								return err
+							}
+							opt := mapping.ValidateOption{
+								ValidType: func(tfType string) bool {
+									_, ok := azurerm.ProviderSchemaInfo.ResourceSchemas[tfType]
+									return ok || tfType == internalmeta.AzAPIResourceType
+								},
+								Parallelism: flagset.flagParallelism,
+							}
+							if !mappingOffline {
+								if err := initLog(flagLogPath, flagLogLevel, flagLogFormat); err != nil {
+									return err
+								}
+								b, err := mappingClientBuilder(&flagset)
+								if err != nil {
+									return err
+								}
+								resClient, err := b.NewResourcesClient(flagset.flagSubscriptionId)
+								if err != nil {
+									return fmt.Errorf("new resource client: %v", err)
+								}
+								opt.Exists = mapping.ARMExists(resClient)
+							}
+							issues := mapping.Validate(c.Context, m, opt)
+							for _, issue := range issues {
+								fmt.Printf("%s: %s\n", issue.Id, issue.Message)
+							}
+							if len(issues) != 0 {
+								return fmt.Errorf("%d issue(s) found", len(issues))
+							}
+							fmt.Println("The mapping file is valid")
+							return nil
+						},
+					},
+					{
+						Name:      "diff",
+						Usage:     "Compare a resource mapping file against the resources listed by an Azure Resource Graph query, reporting the resources added and removed since the mapping file",
+						UsageText: "aztfexport mapping diff [option] <mapping file> <ARG where predicate>",
+						Flags: append([]cli.Flag{
+							&cli.BoolFlag{
+								Name:        "recursive",
+								EnvVars:     []string{"AZTFEXPORT_RECURSIVE"},
+								Aliases:     []string{"r"},
+								Usage:       "Recursively lists child resources of the resulting query resources",
+								Destination: &flagset.flagRecursive,
+							},
+						}, mappingCommandFlags...),
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 2 {
+								return i18n.Errorf("Exactly one mapping file and one query are expected")
+							}
+							m, err := mapping.Read(c.Args().Get(0))
+							if err != nil {
+								return err
+							}
+							if err := initLog(flagLogPath, flagLogLevel, flagLogFormat); err != nil {
+								return err
+							}
+							b, err := mappingClientBuilder(&flagset)
+							if err != nil {
+								return err
+							}
+							result, err := azlist.List(c.Context, c.Args().Get(1), azlist.Option{
+								SubscriptionId: flagset.flagSubscriptionId,
+								Cred:           b.Credential,
+								ClientOpt:      b.Opt,
+								Parallelism:    flagset.flagParallelism,
+								Recursive:      flagset.flagRecursive,
+							})
+							if err != nil {
+								return err
+							}
+							var ids []string
+							for _, res := range result.Resources {
+								ids = append(ids, res.Id.String())
+							}
+							mapping.Diff(m, ids).Write(os.Stdout)
+							return nil
+						},
+					},
+					{
+						Name:      "merge",
+						Usage:     "Merge two resource mapping files",
+						UsageText: "aztfexport mapping merge [option] <mapping file> <mapping file>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:        "on-conflict",
+								EnvVars:     []string{"AZTFEXPORT_ON_CONFLICT"},
+								Usage:       fmt.Sprintf("How to resolve a resource that is mapped differently by the two mapping files. Can be one of %q. The resource mapped to a TF resource address that is already taken is renamed", mapping.ConflictStrategies),
+								Value:       mapping.ConflictError,
+								Destination: &mappingOnConflict,
+							},
+							&cli.StringFlag{
+								Name:        "output",
+								EnvVars:     []string{"AZTFEXPORT_OUTPUT"},
+								Aliases:     []string{"o"},
+								Usage:       "The path of the merged mapping file. Defaults to the stdout",
+								Destination: &mappingOutput,
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 2 {
+								return i18n.Errorf("Exactly two mapping files are expected")
+							}
+							first, err := mapping.Read(c.Args().Get(0))
+							if err != nil {
+								return err
+							}
+							second, err := mapping.Read(c.Args().Get(1))
+							if err != nil {
+								return err
+							}
+							merged, conflicts, err := mapping.Merge(first, second, mappingOnConflict)
+							if err != nil {
+								return err
+							}
+							for _, conflict := range conflicts {
+								fmt.Fprintf(os.Stderr, "%s: %s\n", conflict.Id, conflict.Message)
+							}
+							if mappingOutput == "" {
+								return mapping.Write(os.Stdout, merged)
+							}
+							var buf bytes.Buffer
+							if err := mapping.Write(&buf, merged); err != nil {
+								return err
+							}
+							// #nosec G306
+							if err := os.WriteFile(mappingOutput, buf.Bytes(), 0644); err != nil {
+								return fmt.Errorf("writing the merged mapping file %s: %v", mappingOutput, err)
+							}
+							return nil
+						},
+					},
+					{
+						Name:      "from-state",
+						Usage:     "Generate a resource mapping file from the Azure resources in a Terraform state, which is either a state file, or pulled from the (initialized) Terraform working directory, e.g. of a remote backend",
+						UsageText: "aztfexport mapping from-state [option] <state file | working directory>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:        "output",
+								EnvVars:     []string{"AZTFEXPORT_OUTPUT"},
+								Aliases:     []string{"o"},
+								Usage:       "The path of the generated mapping file. Defaults to the stdout",
+								Destination: &mappingOutput,
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 1 {
+								return i18n.Errorf("Exactly one state file or working directory is expected")
+							}
+							path := c.Args().First()
+							fi, err := os.Stat(path)
+							if err != nil {
+								return fmt.Errorf("stating %s: %v", path, err)
+							}
+							var state []byte
+							if fi.IsDir() {
+								execPath, err := internalmeta.FindTerraform(c.Context)
+								if err != nil {
+									return fmt.Errorf("finding the terraform executable: %v", err)
+								}
+								tf, err := tfexec.NewTerraform(path, execPath)
+								if err != nil {
+									return fmt.Errorf("new terraform: %v", err)
+								}
+								out, err := tf.StatePull(c.Context)
+								if err != nil {
+									return fmt.Errorf("pulling the state of %s: %v", path, err)
+								}
+								state = []byte(out)
+							} else {
+								// #nosec G304
+								state, err = os.ReadFile(path)
+								if err != nil {
+									return fmt.Errorf("reading the state file %s: %v", path, err)
+								}
+							}
+							m, skipped, err := mapping.FromState(state)
+							if err != nil {
+								return err
+							}
+							for _, res := range skipped {
+								fmt.Fprintf(os.Stderr, "Skipping %s: %s\n", res.Address, res.Reason)
+							}
+							if mappingOutput == "" {
+								return mapping.Write(os.Stdout, m)
+							}
+							var buf bytes.Buffer
+							if err := mapping.Write(&buf, m); err != nil {
+								return err
+							}
+							// #nosec G306
+							if err := os.WriteFile(mappingOutput, buf.Bytes(), 0644); err != nil {
+								return fmt.Errorf("writing the mapping file %s: %v", mappingOutput, err)
+							}
+							return nil
+						},
+					},
 				},
 			},
 		},
@@ -687,10 +2242,40 @@ func main() {
 
 	sort.Sort(cli.FlagsByName(app.Flags))
 
-	if err := app.Run(os.Args); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	withConfigFile(app.Commands)
+	withCompletion(app.Commands)
+
+	err := app.Run(os.Args)
+	if uerr := unlockWorkspace(); uerr != nil {
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error: %v", uerr))
+	}
+	os.Exit(exitWithError(err, flagErrorReport))
+}
+
+// exitWithError prints the error of the run, writes the problems to the error report (if specified), and returns the exit code.
+func exitWithError(err error, errorReport string) int {
+	var problems []internal.Problem
+	code := 0
+	var perr *internal.PartialSuccessError
+	switch {
+	case err == nil:
+	case errors.As(err, &perr):
+		problems = perr.Problems
+		code = exitCodePartialSuccess
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p.Line())
+		}
+	default:
+		problems = []internal.Problem{{Kind: internal.ProblemKindFatal, Message: err.Error()}}
+		code = exitCodeFatal
+		fmt.Fprintln(os.Stderr, i18n.Sprintf("Error: %v", err))
+	}
+	if errorReport != "" {
+		if err := internal.WriteErrorReport(errorReport, problems); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.Sprintf("Error: %v", err))
+		}
 	}
+	return code
 }
 
 func logLevel(level string) (hclog.Level, error) {
@@ -710,7 +2295,7 @@ func logLevel(level string) (hclog.Level, error) {
 	}
 }
 
-func initLog(path string, flagLevel string) error {
+func initLog(path string, flagLevel string, format string) error {
 	golog.SetOutput(io.Discard)
 
 	level, err := logLevel(flagLevel)
@@ -718,6 +2303,15 @@ func initLog(path string, flagLevel string) error {
 		return err
 	}
 
+	var jsonFormat bool
+	switch format {
+	case "", "text":
+	case "json":
+		jsonFormat = true
+	default:
+		return fmt.Errorf("unknown log format: %s", format)
+	}
+
 	if path != "" {
 		// #nosec G304
 		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
@@ -725,16 +2319,23 @@ func initLog(path string, flagLevel string) error {
 			return fmt.Errorf("creating log file %s: %v", path, err)
 		}
 
-		logger := hclog.New(&hclog.LoggerOptions{
-			Name:   "aztfexport",
-			Level:  level,
-			Output: f,
-		}).StandardLogger(&hclog.StandardLoggerOptions{
+		// The run id correlates the log entries of a run, as multiple runs might append to the same log file.
+		runId := "undefined"
+		if uuid, err := uuid.NewV4(); err == nil {
+			runId = uuid.String()
+		}
+		hclogger := hclog.New(&hclog.LoggerOptions{
+			Name:       "aztfexport",
+			Level:      level,
+			Output:     f,
+			JSONFormat: jsonFormat,
+		}).With("run_id", runId)
+		logger := hclogger.StandardLogger(&hclog.StandardLoggerOptions{
 			InferLevels: true,
 		})
 
 		// Enable log for aztfexport
-		log.SetLogger(logger)
+		log.SetLogger(log.NewHCLogger(hclogger))
 
 		// Enable log for azlist
 		azlist.SetLogger(logger)
@@ -748,14 +2349,46 @@ func initLog(path string, flagLevel string) error {
 	return nil
 }
 
-func initTelemetryClient(subscriptionId string) telemetry.Client {
+// initTelemetryClient builds the telemetry client of the sink, which only records the telemetry of the detail.
+func initTelemetryClient(subscriptionId, sink, target, detail string) (telemetry.Client, error) {
+	if detail == telemetry.DetailOff {
+		return telemetry.NewNullClient(), nil
+	}
+	tc, err := newTelemetryClient(subscriptionId, sink, target)
+	if err != nil {
+		return nil, err
+	}
+	return telemetry.WithDetail(tc, detail), nil
+}
+
+// telemetrySpoolPath returns the spool of the telemetry sink under the config directory, or empty (i.e. not to spool) if the HOME directory is unknown.
+func telemetrySpoolPath(sink string) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, cfgfile.CfgDirName, "telemetry-spool-"+sink+".jsonl")
+}
+
+// newTelemetryClient builds the telemetry client of the sink. The "appinsights" sink (the default) honors the telemetry setting of the config file,
+// while the other sinks are explicitly opted in.
+func newTelemetryClient(subscriptionId, sink, target string) (telemetry.Client, error) {
+	switch sink {
+	case telemetry.SinkNone:
+		return telemetry.NewNullClient(), nil
+	case telemetry.SinkFile:
+		return telemetry.NewFileClient(target)
+	case telemetry.SinkOTLP:
+		return telemetry.NewOTLPClient(target, telemetrySpoolPath(telemetry.SinkOTLP))
+	}
+
 	cfg, err := cfgfile.GetConfig()
 	if err != nil {
-		return telemetry.NewNullClient()
+		return telemetry.NewNullClient(), nil
 	}
 	enabled, installId := cfg.TelemetryEnabled, cfg.InstallationId
 	if !enabled {
-		return telemetry.NewNullClient()
+		return telemetry.NewNullClient(), nil
 	}
 	if installId == "" {
 		uuid, err := uuid.NewV4()
@@ -770,21 +2403,14 @@ func initTelemetryClient(subscriptionId string) telemetry.Client {
 	if uuid, err := uuid.NewV4(); err == nil {
 		sessionId = uuid.String()
 	}
-	return telemetry.NewAppInsight(subscriptionId, installId, sessionId)
+	return telemetry.NewAppInsight(subscriptionId, installId, sessionId, telemetrySpoolPath(telemetry.SinkAppInsights)), nil
 }
 
 // buildAzureSDKCredAndClientOpt builds the Azure SDK credential and client option from multiple sources (i.e. environment variables, MSI, Azure CLI).
 func buildAzureSDKCredAndClientOpt(fset FlagSet) (azcore.TokenCredential, *arm.ClientOptions, error) {
-	var cloudCfg cloud.Configuration
-	switch env := fset.flagEnv; strings.ToLower(env) {
-	case "public":
-		cloudCfg = cloud.AzurePublic
-	case "usgovernment":
-		cloudCfg = cloud.AzureGovernment
-	case "china":
-		cloudCfg = cloud.AzureChina
-	default:
-		return nil, nil, fmt.Errorf("unknown environment specified: %q", env)
+	cloudCfg, err := cloudConfiguration(fset.flagEnv, fset.flagResourceManagerEndpoint)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Maps the auth related environment variables used in the provider to what azidentity honors
@@ -818,61 +2444,20 @@ func buildAzureSDKCredAndClientOpt(fset FlagSet) (azcore.TokenCredential, *arm.C
 		},
 	}
 
-	tenantId := os.Getenv("ARM_TENANT_ID")
-	var (
-		cred azcore.TokenCredential
-		err  error
-	)
-	switch {
-	case fset.flagUseEnvironmentCred:
-		cred, err = azidentity.NewEnvironmentCredential(&azidentity.EnvironmentCredentialOptions{
-			ClientOptions: clientOpt.ClientOptions,
-		})
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to new Environment credential: %v", err)
-		}
-		return cred, clientOpt, nil
-	case fset.flagUseManagedIdentityCred:
-		cred, err = azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{
-			ClientOptions: clientOpt.ClientOptions,
-		})
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to new Managed Identity credential: %v", err)
-		}
-		return cred, clientOpt, nil
-	case fset.flagUseAzureCLICred:
-		cred, err = azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{
-			TenantID: tenantId,
-		})
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to new Azure CLI credential: %v", err)
-		}
-		return cred, clientOpt, nil
-	case fset.flagUseOIDCCred:
-		cred, err = NewOidcCredential(&OidcCredentialOptions{
-			ClientOptions: clientOpt.ClientOptions,
-			TenantID:      tenantId,
-			ClientID:      os.Getenv("ARM_CLIENT_ID"),
-			RequestToken:  fset.flagOIDCRequestToken,
-			RequestUrl:    fset.flagOIDCRequestURL,
-			Token:         fset.flagOIDCToken,
-			TokenFilePath: fset.flagOIDCTokenFilePath,
-		})
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to new OIDC credential: %v", err)
-		}
-		return cred, clientOpt, nil
-	default:
-		opt := &azidentity.DefaultAzureCredentialOptions{
-			ClientOptions: clientOpt.ClientOptions,
-			TenantID:      tenantId,
-		}
-		cred, err := azidentity.NewDefaultAzureCredential(opt)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to new Default credential: %v", err)
+	names := fset.flagCredChain.Value()
+	if len(names) == 0 {
+		names = []string{fset.credentialName()}
+	}
+	creds := make([]azcore.TokenCredential, len(names))
+	errs := make([]error, len(names))
+	for i, name := range names {
+		creds[i], errs[i] = newCredential(name, fset, clientOpt)
+		// The credentials of the chain that can't be built are skipped, in favor of the next ones.
+		if errs[i] != nil && len(names) == 1 {
+			return nil, nil, errs[i]
 		}
-		return cred, clientOpt, nil
 	}
+	return newChainCredential(names, creds, errs), clientOpt, nil
 }
 
 func subscriptionIdFromCLI() (string, error) {
@@ -894,16 +2479,173 @@ func subscriptionIdFromCLI() (string, error) {
 	return strconv.Unquote(strings.TrimSpace(stdout.String()))
 }
 
-func realMain(ctx context.Context, cfg config.Config, batch, mockMeta, plainUI, genMapFile bool, profileType string, effectiveCLI string) (result error) {
-	switch strings.ToLower(profileType) {
-	case "cpu":
-		defer profile.Start(profile.CPUProfile, profile.ProfilePath("."), profile.NoShutdownHook).Stop()
-	case "mem":
-		defer profile.Start(profile.MemProfile, profile.ProfilePath("."), profile.NoShutdownHook).Stop()
+// queryPredicate builds the ARG where predicate from the argument (if any) and the query filter flags, which are combined via "and".
+func queryPredicate(c *cli.Context, fset FlagSet) (string, error) {
+	if c.NArg() > 1 {
+		return "", i18n.Errorf("More than one queries specified. Use `and` with double quotes to run multiple query parameters.")
+	}
+	filter := fset.argFilter()
+	if c.NArg() == 0 && filter.IsEmpty() {
+		return "", i18n.Errorf("No query specified")
+	}
+	return internalmeta.WithARGFilter(c.Args().First(), filter), nil
+}
+
+// discoverResourceIds returns a function that lists the ids of the resources matching the ARG predicate, with the tag filters applied.
+func discoverResourceIds(fset FlagSet, commonConfig config.CommonConfig, predicate string) func(ctx context.Context) ([]string, error) {
+	return func(ctx context.Context) ([]string, error) {
+		predicate := internalmeta.WithTagFilter(predicate, parseTags(fset.flagIncludeTags.Value()), parseTags(fset.flagExcludeTags.Value()))
+		log.Printf("[INFO] Discovering the resources by the ARG predicate: %s", predicate)
+		result, err := azlist.List(ctx, predicate, azlist.Option{
+			SubscriptionId: commonConfig.SubscriptionId,
+			Cred:           commonConfig.AzureSDKCredential,
+			ClientOpt:      internalmeta.ClientOption(commonConfig),
+			Parallelism:    commonConfig.Parallelism,
+			Recursive:      fset.flagRecursive,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, res := range result.Resources {
+			ids = append(ids, res.Id.String())
+		}
+		return ids, nil
+	}
+}
+
+// appendExportResources returns a function that exports the resources of the specified ids to the output directory non-interactively, in append mode, which returns
+// the ids of the resources that are imported. The resources are listed the same way as discoverResourceIds (e.g. including the child resources by `--recursive`), and are
+// named by the naming options, differently from the resources that are already in the state.
+func appendExportResources(fset FlagSet, commonConfig config.CommonConfig, predicate string) func(ctx context.Context, ids []string) ([]string, error) {
+	return func(ctx context.Context, ids []string) ([]string, error) {
+		var mu sync.Mutex
+		var imported []string
+		cc := commonConfig
+		onImportDone := cc.Hooks.OnImportDone
+		cc.Hooks.OnImportDone = func(res config.HookResource, err error) {
+			if onImportDone != nil {
+				onImportDone(res, err)
+			}
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			imported = append(imported, res.AzureResourceId)
+		}
+		cfg := config.Config{
+			CommonConfig:        cc,
+			ARGPredicate:        predicate,
+			RecursiveQuery:      fset.flagRecursive,
+			IncludeTags:         parseTags(fset.flagIncludeTags.Value()),
+			ExcludeTags:         parseTags(fset.flagExcludeTags.Value()),
+			OnlyResourceIds:     ids,
+			ResourceNamePattern: fset.flagPattern,
+			NameFrom:            fset.flagNameFrom,
+			AvoidStateNames:     true,
+		}
+		err := internal.BatchImport(ctx, internalconfig.NonInteractiveModeConfig{
+			Config:      cfg,
+			PlainUI:     true,
+			ToolVersion: getVersion(),
+		})
+		return imported, err
+	}
+}
+
+// mappingClientBuilder builds the Azure client builder for the mapping command, and identifies the subscription id the same way as the export commands.
+func mappingClientBuilder(fset *FlagSet) (*client.ClientBuilder, error) {
+	if ids := fset.flagSubscriptionIds.Value(); len(ids) != 0 {
+		fset.flagSubscriptionId = ids[0]
+	}
+	if fset.flagSubscriptionId == "" {
+		var err error
+		fset.flagSubscriptionId, err = subscriptionIdFromCLI()
+		if err != nil {
+			return nil, fmt.Errorf("retrieving subscription id from CLI: %v", err)
+		}
+	}
+	cred, clientOpt, err := buildAzureSDKCredAndClientOpt(*fset)
+	if err != nil {
+		return nil, err
+	}
+	return &client.ClientBuilder{Credential: cred, Opt: *clientOpt}, nil
+}
+
+// runOptions are the options of the run, besides the config of the export.
+type runOptions struct {
+	batch              bool
+	mockMeta           bool
+	plainUI            bool
+	accessible         bool
+	genMappingFileOnly bool
+	costEstimate       bool
+	verify             bool
+	reportMarkdown     bool
+	pulumiLanguage     string
+	outputFormat       string
+	chunkSize          int
+	resume             bool
+	maxResources       int
+	maxDuration        time.Duration
+	dryRunOutput       string
+	profileDir         string
+	// effectiveCLI is the description of the CLI, which is recorded in the telemetry and the provenance
+	effectiveCLI string
+	provenance   provenance.Option
+}
+
+// batchMain runs the export in non-interactive mode, and writes the provenance of the output directory.
+// Unlike realMain, it neither profiles nor closes the telemetry client, so that it can run for multiple scopes concurrently.
+func batchMain(ctx context.Context, cfg config.Config, opts runOptions) error {
+	nicfg := internalconfig.NonInteractiveModeConfig{
+		MockMeta:           opts.mockMeta,
+		Config:             cfg,
+		PlainUI:            opts.plainUI,
+		GenMappingFileOnly: opts.genMappingFileOnly,
+		CostEstimate:       opts.costEstimate,
+		Verify:             opts.verify,
+		OutputFormat:       opts.outputFormat,
+		PulumiLanguage:     opts.pulumiLanguage,
+		ChunkSize:          opts.chunkSize,
+		Resume:             opts.resume,
+		MaxResources:       opts.maxResources,
+		MaxDuration:        opts.maxDuration,
+		DryRunOutput:       opts.dryRunOutput,
+		ToolVersion:        getVersion(),
+		ReportMarkdown:     opts.reportMarkdown,
+	}
+	// The output directory of the partially succeeded run is still complete, hence has the provenance.
+	err := internal.BatchImport(ctx, nicfg)
+	var perr *internal.PartialSuccessError
+	if err != nil && !errors.As(err, &perr) {
+		return err
+	}
+	if cfg.DryRun {
+		return nil
+	}
+	if err := writeProvenance(ctx, cfg, opts.effectiveCLI, opts.provenance); err != nil {
+		return err
+	}
+	return err
+}
+
+func realMain(ctx context.Context, cfg config.Config, opts runOptions) (result error) {
+	if opts.profileDir != "" {
+		stop, err := startProfile(opts.profileDir)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := stop(); err != nil && result == nil {
+				result = err
+			}
+		}()
 	}
 
 	// Initialize log
-	if err := initLog(flagLogPath, flagLogLevel); err != nil {
+	if err := initLog(flagLogPath, flagLogLevel, flagLogFormat); err != nil {
 		result = err
 		return
 	}
@@ -924,27 +2666,29 @@ func realMain(ctx context.Context, cfg config.Config, batch, mockMeta, plainUI,
 
 	log.Printf("[INFO] aztfexport starts with config: %#v", cfg)
 	tc.Trace(telemetry.Info, "aztfexport starts")
-	tc.Trace(telemetry.Info, "Effective CLI: "+effectiveCLI)
+	tc.Trace(telemetry.Info, "Effective CLI: "+opts.effectiveCLI)
 
 	// Run in non-interactive mode
-	if batch {
-		nicfg := internalconfig.NonInteractiveModeConfig{
-			MockMeta:           mockMeta,
-			Config:             cfg,
-			PlainUI:            plainUI,
-			GenMappingFileOnly: genMapFile,
-		}
-		if err := internal.BatchImport(ctx, nicfg); err != nil {
-			result = err
-			return
-		}
-		return nil
+	if opts.batch {
+		result = batchMain(ctx, cfg, opts)
+		return
 	}
 
 	// Run in interactive mode
 	icfg := internalconfig.InteractiveModeConfig{
-		Config:   cfg,
-		MockMeta: mockMeta,
+		Config:             cfg,
+		MockMeta:           opts.mockMeta,
+		CostEstimate:       opts.costEstimate,
+		GenMappingFileOnly: opts.genMappingFileOnly,
+		PulumiLanguage:     opts.pulumiLanguage,
+	}
+	if opts.accessible || ui.AccessibleModeDetected() {
+		if err := ui.RunAccessible(ctx, icfg, os.Stdin, os.Stdout); err != nil {
+			result = err
+			return
+		}
+		result = writeProvenance(ctx, cfg, opts.effectiveCLI, opts.provenance)
+		return
 	}
 	prog, err := ui.NewProgram(ctx, icfg)
 	if err != nil {
@@ -955,5 +2699,34 @@ func realMain(ctx context.Context, cfg config.Config, batch, mockMeta, plainUI,
 		result = err
 		return
 	}
-	return nil
+	result = writeProvenance(ctx, cfg, opts.effectiveCLI, opts.provenance)
+	return
+}
+
+// writeProvenance writes the provenance manifest of the output directory, if enabled.
+func writeProvenance(ctx context.Context, cfg config.Config, effectiveCLI string, prov provenance.Option) error {
+	if !prov.Enabled {
+		return nil
+	}
+	invocation := provenance.Invocation{
+		CLI:            effectiveCLI,
+		SubscriptionId: cfg.SubscriptionId,
+	}
+	switch {
+	case cfg.ResourceId != "":
+		invocation.ScopeType, invocation.Scope = ModeResource, cfg.ResourceId
+	case cfg.ResourceGroupName != "":
+		invocation.ScopeType, invocation.Scope = ModeResourceGroup, strings.Join(append([]string{cfg.ResourceGroupName}, cfg.AdditionalResourceGroupNames...), ",")
+	case cfg.ARGPredicate != "":
+		invocation.ScopeType, invocation.Scope = ModeQuery, cfg.ARGPredicate
+	case cfg.MappingFile != "":
+		invocation.ScopeType, invocation.Scope = ModeMappingFile, cfg.MappingFile
+	case cfg.ManagementGroupName != "":
+		invocation.ScopeType, invocation.Scope = ModeManagementGroup, cfg.ManagementGroupName
+	}
+	manifest, err := provenance.Build(cfg.OutputDir, provenance.Tool{Name: "aztfexport", Version: getVersion()}, invocation)
+	if err != nil {
+		return err
+	}
+	return provenance.Write(ctx, cfg.OutputDir, manifest, prov)
 }
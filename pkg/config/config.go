@@ -1,9 +1,12 @@
 package config
 
 import (
+	"time"
+
 	"github.com/Azure/aztfexport/pkg/telemetry"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/magodo/terraform-client-go/tfclient"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -17,47 +20,439 @@ type OutputFileNames struct {
 	MainFileName string
 	// The filename for the generated "import.tf" (default)
 	ImportBlockFileName string
+	// Layout specifies the strategy to place the config of each resource into a file, instead of the MainFileName. It takes precedence over the SplitFiles.
+	Layout FileLayout
+}
+
+// ResourceFileInfo describes a generated resource, based on which the FileLayout places its config.
+type ResourceFileInfo struct {
+	// Id is the Azure resource id
+	Id string
+	// Type is the TF resource type
+	Type string
+	// Name is the TF resource name
+	Name string
+	// ResourceGroup is the name of the resource group that the resource belongs to, which is empty if the resource is not in any resource group
+	ResourceGroup string
+}
+
+// FileLayout places the config of the generated resources into files.
+type FileLayout interface {
+	// FileName returns the name of the file (e.g. "storage_account.tf") under the module directory that the config of the resource is appended to.
+	// It must be a ".tf" file name without any directory, and can't be any of the other output files (e.g. the ProviderFileName).
+	FileName(info ResourceFileInfo) (string, error)
+}
+
+// FileLayoutFunc adapts a function to the FileLayout.
+type FileLayoutFunc func(info ResourceFileInfo) (string, error)
+
+func (f FileLayoutFunc) FileName(info ResourceFileInfo) (string, error) {
+	return f(info)
+}
+
+// ConfigRenderer renders the generated config of the resources in a syntax of the TF config.
+type ConfigRenderer interface {
+	// FileName returns the name of the file that the config is rendered into, by the name of the file in the HCL native syntax (e.g. "main.tf").
+	FileName(name string) string
+	// Render renders the resource blocks (each in the HCL native syntax) into the content of the file, extending its existing content, which is empty if the file doesn't exist.
+	Render(existing []byte, blocks [][]byte) ([]byte, error)
+}
+
+// ResourceNameInfo describes a listed resource, based on which the NamingStrategy names its TF resource.
+type ResourceNameInfo struct {
+	// Id is the Azure resource id
+	Id string
+	// Index is the index of the resource in the listed resources
+	Index int
+	// TypeIndex is the index of the resource among the listed resources of the same TF resource type
+	TypeIndex int
+	// Type is the TF resource type, which is empty if the resource is unresolved
+	Type string
+	// Name is the Azure resource name
+	Name string
+	// ResourceGroup is the name of the resource group that the resource belongs to
+	ResourceGroup string
+	// Parent is the name of the parent resource, which is the resource group for the top level resources
+	Parent string
+	// Tags are the tags of the Azure resource
+	Tags map[string]string
+}
+
+// NamingStrategy names the TF resources of the listed resources.
+type NamingStrategy interface {
+	// Name returns the TF resource name of the resource. The name is then sanitized to be a valid TF identifier, and is suffixed by "-<n>" on collision with the former names.
+	Name(info ResourceNameInfo) (string, error)
+}
+
+// NamingStrategyFunc adapts a function to the NamingStrategy.
+type NamingStrategyFunc func(info ResourceNameInfo) (string, error)
+
+func (f NamingStrategyFunc) Name(info ResourceNameInfo) (string, error) {
+	return f(info)
+}
+
+// AuthScaffold describes the authentication used during the export, which is reflected by the generated provider config scaffold.
+type AuthScaffold struct {
+	// Method is the authentication method, i.e. "default", "environment", "managed-identity", "azure-cli" or "oidc".
+	Method string
+	// Environment is the Azure cloud environment, i.e. "public", "usgovernment" or "china".
+	Environment string
+	// TenantId is the tenant id, which is optional.
+	TenantId string
+	// ClientId is the client id of the service principal or the user assigned identity, which is optional.
+	ClientId string
+}
+
+// BackendBootstrap specifies how the storage of the azurerm backend is bootstrapped.
+type BackendBootstrap struct {
+	// Location is the location of the resource group and the storage account to create, which defaults to the location of the existing resource group.
+	Location string
+	// Export specifies to also export the resource group, the storage account and the container of the backend.
+	Export bool
+}
+
+// RetryPolicy specifies how the ARM and ARG requests are retried on throttling (HTTP 429) and transient failures, with exponential backoff.
+// The delay of the "Retry-After" header of the throttled responses takes precedence over the backoff.
+type RetryPolicy struct {
+	// MaxRetries specifies the maximum number of retries of a request. Zero means the Azure SDK default (3), a negative value means no retry.
+	MaxRetries int
+	// BaseDelay specifies the delay before the first retry, which doubles for each further retry. Zero means the Azure SDK default (4s).
+	BaseDelay time.Duration
+	// MaxDelay specifies the maximum delay before a retry, the request is not retried if its "Retry-After" exceeds it. Zero means the Azure SDK default (60s).
+	MaxDelay time.Duration
+}
+
+// ClientPolicy customizes the ARM and ARG clients that are created by aztfexport, in addition to the AzureSDKClientOption.
+type ClientPolicy struct {
+	// PerCallPolicies are the pipeline policies that run once per request, after the ones of the AzureSDKClientOption.
+	PerCallPolicies []policy.Policy
+	// PerRetryPolicies are the pipeline policies that run once per attempt of a request, after the ones of the AzureSDKClientOption.
+	PerRetryPolicies []policy.Policy
+	// UserAgentSuffix is appended to the "User-Agent" header of the requests, including the ones sent by the Terraform providers.
+	UserAgentSuffix string
+	// CorrelationId is set as the "x-ms-correlation-request-id" header of the requests, which correlates the requests of the run in the ARM activity logs.
+	CorrelationId string
+}
+
+// HookResource describes the resource that a hook is invoked for.
+type HookResource struct {
+	// AzureResourceId is the Azure resource id
+	AzureResourceId string
+	// TFResourceId is the TF resource id, which might differ from the Azure resource id
+	TFResourceId string
+	// TFAddr is the TF resource address (e.g. "azurerm_resource_group.res-0"), which is empty if the TF resource type is unresolved
+	TFAddr string
+}
+
+// Hooks are the callbacks that are invoked on the progress of the export, so that the Go module consumers can build their own UIs or metrics.
+// The nil callbacks are ignored. The callbacks might be invoked concurrently (i.e. on importing in parallel), and are expected to return quickly.
+type Hooks struct {
+	// OnResourceDiscovered is invoked for each listed resource, including the ones that are skipped (i.e. with no TFAddr).
+	OnResourceDiscovered func(res HookResource)
+	// OnListProgress is invoked once each page of the resources is listed via the Azure Resource Graph (per subscription), with the number of the resources listed
+	// so far and the total, which is counted beforehand. The total might change during the listing, as the resources change.
+	OnListProgress func(listed, total int)
+	// OnResolveStart is invoked once the resources are discovered, before resolving their TF resource types and ids. It is not invoked in the map mode,
+	// where the TF resources are specified by the mapping file.
+	OnResolveStart func()
+	// OnImportStart is invoked before importing a resource.
+	OnImportStart func(res HookResource)
+	// OnImportDone is invoked after importing a resource, with the import error if failed.
+	OnImportDone func(res HookResource, err error)
+	// OnConfigGenerated is invoked for each resource whose config is generated.
+	OnConfigGenerated func(res HookResource)
+	// OnError is invoked with the error that fails a step of the export (e.g. listing, pushing the state). The import errors are reported by the OnImportDone instead.
+	OnError func(err error)
+}
+
+// CommandHook is an external command that is run at a phase of the export, e.g. a policy check, a notification or a post-processing of the generated config.
+type CommandHook struct {
+	// Phase is one of "pre-import", "post-import", "pre-generate" and "post-generate".
+	Phase string
+	// Command is split by whitespace into the executable and its arguments (i.e. it is not interpreted by a shell).
+	Command string
 }
 
 type CommonConfig struct {
 	// SubscriptionId specifies the user's Azure subscription id.
 	SubscriptionId string
+	// AdditionalResourceGroupNames specifies the names (or the glob patterns) of the resource groups, in addition to the ResourceGroupName, that the resources are
+	// exported from in one run, whose resources are merged into one import list. The resource group of each resource (e.g. for the naming strategy and the file layout)
+	// is the one that it originates from. This only applies to resource group mode.
+	AdditionalResourceGroupNames []string
+	// AdditionalSubscriptionIds specifies the subscriptions, in addition to the SubscriptionId, that the resources are exported from. This only applies to query mode.
+	// The ARG predicate runs across all the subscriptions, and the resources of each additional subscription are managed by an aliased azurerm provider.
+	AdditionalSubscriptionIds []string
+	// CredentialsFile specifies the path of the JSON file that maps the Azure resource scopes (e.g. the subscriptions or the resource groups in another tenant) to the aliased azurerm providers,
+	// each with its own credential settings, e.g. [{"alias": "other", "scopes": ["/subscriptions/xxx"], "tenant_id": "yyy", "use_cli": true}]. The resources within the scopes are imported with,
+	// and annotated by, the aliased provider. The secrets (e.g. the client secret) are not supported, as the settings are written to the generated provider config.
+	// Note that the resources are still listed and read by aztfexport with the AzureSDKCredential.
+	CredentialsFile string
 	// AzureSDKCredential specifies the Azure SDK token credential
 	AzureSDKCredential azcore.TokenCredential
-	// AzureSDKClientOption specifies the Azure SDK client option
+	// AzureSDKClientOption specifies the Azure SDK client option. Its Transport can be set to a recorder.Transport (pkg/recorder) to record or replay the ARM traffic.
 	AzureSDKClientOption arm.ClientOptions
+	// RetryPolicy specifies how the ARM and ARG requests (e.g. listing the resources, getting each resource) are retried, which overrides the retry options of the AzureSDKClientOption.
+	// The zero value keeps the retry options of the AzureSDKClientOption as is.
+	RetryPolicy RetryPolicy
+	// ClientPolicy specifies the customization (e.g. the pipeline policies, the headers) of the ARM and ARG clients, which applies to all the clients created by aztfexport.
+	ClientPolicy ClientPolicy
 	// OutputDir specifies the Terraform working directory import resources and generate TF configs.
 	OutputDir string
 	// OutputFileNames specifies the output terraform filenames
 	OutputFileNames OutputFileNames
+	// ProviderName specifies the Terraform provider that the resources are exported to, i.e. "azurerm" (default) or "azapi".
+	// For "azapi", every resource is exported as an "azapi_resource" with its ARM body.
+	ProviderName string
+	// AzAPIFallback specifies whether to export the resources that have no azurerm resource type as "azapi_resource", together with the azurerm ones.
+	// This only works when ProviderName is "azurerm".
+	AzAPIFallback bool
+	// TypeOverrideFile specifies the path of the type overrides file, which forces the matched Azure resources to be exported as the specified TF resource type.
+	// It is consulted prior to the built-in resolver. See the typeoverride package for the format.
+	TypeOverrideFile string
+	// ResolverPluginPath specifies the path of the resolver plugin executable, which overrides or augments the TF resource type resolution of the Azure resources
+	// (e.g. always skip certain Azure resource types, or map them to the types of a custom provider). It speaks JSON over stdio, see the resolverplugin package for the protocol.
+	// It is consulted prior to the type overrides.
+	ResolverPluginPath string
+	// Resolvers specifies the ordered chain of resolvers (i.e. "plugin", "override", "api", "heuristic", "ask") that resolve the TF resource type of each Azure resource.
+	// A resolver that is absent is disabled. If this is not set, all the resolvers are used in the above order.
+	Resolvers []string
 	// ProviderVersion specifies the azurerm provider version used for importing. If this is not set, it will use `azurerm.ProviderSchemaInfo.Version` for importing in order to be consistent with tfadd.
 	ProviderVersion string
+	// ProviderMajorVersion specifies the azurerm provider major version (i.e. "3" or "4") whose resource shapes are used for generating the config, as v4 renamed attributes and removed resource types.
+	// If this is not set, it is derived from the ProviderVersion. If ProviderVersion is not set either, "4" defaults ProviderVersion to a v4 release.
+	ProviderMajorVersion string
+	// ProviderRegistry specifies the hostname of a private registry that serves the providers, which prefixes the provider source addresses (e.g. "registry.example.com/hashicorp/azurerm").
+	ProviderRegistry string
+	// ProviderSource specifies the source address of the provider of the ProviderName, in the form of "[<hostname>/]<namespace>/<type>" (e.g. "registry.example.com/myorg/azurerm"
+	// of a private registry, or "myorg/azurerm" of a fork in the public registry). It takes precedence over the ProviderRegistry, which still applies to the other providers (e.g. azapi).
+	ProviderSource string
+	// ProviderMirror specifies the provider mirror to install the providers from, either a HTTPS URL of a network mirror, or a local directory of a filesystem mirror.
+	// A matching Terraform CLI config file is generated to the output directory, which is also used by the terraform commands run by aztfexport.
+	ProviderMirror string
+	// ProviderPluginCacheDir specifies the Terraform provider plugin cache directory (i.e. TF_PLUGIN_CACHE_DIR), which is shared across exports to avoid downloading the providers repeatedly.
+	ProviderPluginCacheDir string
 	// DevProvider specifies whether users have configured the `dev_overrides` for the provider, which then uses a development provider built locally rather than using a version pinned provider from official Terraform registry.
 	// Meanwhile, it will also avoid running `terraform init` during `Init()` for the import directories to avoid caculating the provider hash and populating the lock file (See: https://developer.hashicorp.com/terraform/language/files/dependency-lock). Though the init for the output directory is still needed for initializing the backend.
 	DevProvider bool
 	// ContinueOnError specifies whether continue the progress even hit an import error.
 	ContinueOnError bool
-	// BackendType specifies the Terraform backend type.
+	// BackendType specifies the Terraform backend type. The "cloud" type specifies the HCP Terraform (Terraform Cloud) workspace, which generates a "cloud" block
+	// (see CloudOrganization and CloudWorkspace) instead of a "backend" block, and requires terraform >= v1.1.0. The API token is read by terraform as usual (e.g. TF_TOKEN_app_terraform_io).
 	BackendType string
-	// BackendConfig specifies an array of Terraform backend configs.
+	// BackendConfig specifies an array of Terraform backend configs. This can't be used with the "cloud" BackendType.
 	BackendConfig []string
+	// CloudOrganization specifies the organization of the "cloud" block, which only applies to the "cloud" BackendType. If not set, terraform reads it from TF_CLOUD_ORGANIZATION.
+	CloudOrganization string
+	// CloudWorkspace specifies the workspace name of the "cloud" block, which only applies to the "cloud" BackendType. The workspace is created by terraform if not exists.
+	// If not set, terraform reads it from TF_WORKSPACE.
+	CloudWorkspace string
+	// Workspace specifies the Terraform workspace (i.e. "terraform workspace") of the OutputDir that the resources are imported into, which is selected, or created
+	// if not exists, after initializing the backend. Empty means the currently selected one (usually "default"). It only applies to the backend types that support
+	// multiple workspaces, and can't be used with the "cloud" BackendType (see CloudWorkspace).
+	Workspace string
+	// LocalThenMigrate specifies to import into a local state, which is migrated to the (non-local) BackendType via "terraform init -migrate-state" in CleanUpWorkspace.
+	// This avoids locking the remote state (e.g. the blob lease of the azurerm backend) for each import, which is slow for large exports.
+	LocalThenMigrate bool
+	// HoldBackendLock specifies to acquire the state lock of the azurerm backend that the OutputDir is initialized with (i.e. the blob lease of the state), and hold it
+	// for the whole import session (until CleanUpWorkspace), rather than per terraform invocation, so that the other runs (e.g. pipelines) can't write the state in the
+	// middle of the export. The lock is only released transiently for the state writes of aztfexport itself. The lease is acquired with the Azure AD credential,
+	// which requires the data plane access to the blob (e.g. the "Storage Blob Data Contributor" role). This can't be used together with HCLOnly, DryRun or LocalThenMigrate.
+	HoldBackendLock bool
+	// BackendBootstrap specifies to create the resource group, the storage account (with the blob versioning enabled) and the container of the azurerm backend
+	// (as specified by the "resource_group_name", "storage_account_name" and "container_name" of the BackendConfig) before initializing the backend, if missing.
+	// Nil means not to bootstrap.
+	BackendBootstrap *BackendBootstrap
 	// ProviderConfig specifies key value pairs that will be expanded to the terraform-provider-azurerm settings (i.e. `azurerm {}` block)
 	// Currently, only the attributes (rather than blocks) are supported.
-	// This is not used directly by aztfexport as the provider configs can be set by environment variable already.
+	// The aztfexport CLI only uses it to point the provider to the cloud environment (i.e. `environment` or `metadata_host`), as the other provider configs can be set by environment variable already.
 	// While it is useful for module users that want support multi-users scenarios in one process (in which case changing env vars affect the whole process).
 	ProviderConfig map[string]cty.Value
+	// ProviderFeatures specifies the settings of the `features {}` block of the azurerm provider, which is applied to the generated provider blocks (and the provider used for importing).
+	// The object values are expanded to the nested blocks, e.g. {"key_vault": cty.ObjectVal(map[string]cty.Value{"purge_soft_delete_on_destroy": cty.False})} is expanded to
+	// `key_vault { purge_soft_delete_on_destroy = false }`, while the others are expanded to the attributes. This can only be used when ProviderName is "azurerm".
+	ProviderFeatures map[string]cty.Value
 	// FullConfig specifies whether to export all (non computed-only) Terarform properties when generating TF configs.
 	FullConfig bool
-	// Parallelism specifies the parallelism for the process
+	// PropertyRulesFile specifies the path of the JSON file that always includes or excludes the properties of the generated config per resource type, regardless of the FullConfig,
+	// e.g. [{"exclude": ["tags"]}, {"resource_type": "azurerm_linux_web_app", "include": ["identity"]}]. The rule without a "resource_type" applies to all the resource types.
+	// The "include" are the top level properties, while the "exclude" are the property paths (e.g. "site_config.always_on"), which take precedence.
+	// The "ignore_changes" are the attributes known to drift (e.g. "default_node_pool[0].node_count" of an autoscaled AKS cluster), which are added to the
+	// "ignore_changes" of the lifecycle block of the resource.
+	PropertyRulesFile string
+	// SubresourceStrategy specifies how to generate the sub-resources that azurerm supports both inline and standalone (e.g. subnets, NSG rules, routes),
+	// either "standalone" (default) or "inline".
+	SubresourceStrategy string
+	// ExportARMJSON specifies whether to also export the raw ARM JSON of each imported resource (as returned by the API) alongside the generated TF configs.
+	ExportARMJSON bool
+	// EmitDependsOn specifies to add the "depends_on" of the exported resources that are referred to by the ARM body of each resource (i.e. the implicit dependencies),
+	// but can't be expressed as the references in the config (e.g. the ordering constraint between a policy and its assignment). The ARM body of each resource is
+	// fetched for the analysis. The dependencies that would introduce a cycle (e.g. the back references) are skipped.
+	EmitDependsOn bool
+	// StackConfigType specifies the TACOS platform (i.e. "spacelift", "env0") to generate the stack definition file for, which onboards the output directory to the platform.
+	// Empty means not to generate it.
+	StackConfigType string
+	// AKSProviders specifies whether to generate the kubernetes and helm provider blocks for each exported AKS cluster, configured from the cluster's kube config.
+	AKSProviders bool
+	// BackstageCatalog specifies whether to generate the Backstage catalog file (i.e. catalog-info.yaml) that describes the exported module and resources.
+	BackstageCatalog bool
+	// BackstageOwner specifies the owner of the entities in the Backstage catalog. This is required when BackstageCatalog is set.
+	BackstageOwner string
+	// BackstageSystem specifies the system that the entities in the Backstage catalog belong to. This is optional.
+	BackstageSystem string
+	// Inventory specifies whether to generate the inventory file (i.e. aztfexportInventory.json) that lists the ids, types, Terraform addresses, subscriptions and tags of the exported resources.
+	// The inventory is sorted and contains no timestamps, so that it can be diffed over time and ingested by CMDB tools.
+	Inventory bool
+	// GenerateOutputs specifies whether to generate the outputs file (i.e. outputs.tf) that exposes the commonly needed attributes of the exported resources, e.g. the resource ids,
+	// the principal ids of the managed identities, and the FQDNs/endpoints. The attributes are only output for the resource types that have them.
+	GenerateOutputs bool
+	// OutputRulesFile specifies the path of the JSON file that includes or excludes the attributes to output per resource type, besides the default ones. It is only used when GenerateOutputs is set.
+	// The file is a JSON array of objects, each with an optional "resource_type" (the rule applies to all the resource types if absent), and the attribute paths of "include" and/or "exclude",
+	// e.g. [{"resource_type": "azurerm_storage_account", "include": ["primary_queue_endpoint"], "exclude": ["primary_web_endpoint"]}].
+	OutputRulesFile string
+	// GraphOutput specifies the format (i.e. "dot", "mermaid") of the dependency graph of the exported resources to generate (i.e. aztfexportGraph.dot, aztfexportGraph.mmd),
+	// whose nodes are the Terraform resource addresses, and edges are the references and the "depends_on" among them. Empty means not to generate it.
+	GraphOutput string
+	// InjectTags specifies the tags that are injected into the tags of every generated resource that supports tags, which makes the adopted resources identifiable (e.g. managed_by = "terraform").
+	InjectTags map[string]string
+	// ApplyInjectedTags specifies whether to also apply the InjectTags to the live resources after they are imported, so that the config matches them.
+	ApplyInjectedTags bool
+	// KeyVaultIds specifies the resource ids of the Key Vaults whose secrets are read. A generated string attribute value that matches a secret is replaced by a reference to the
+	// azurerm_key_vault_secret data source of the secret, which keeps the secret out of the config.
+	KeyVaultIds []string
+	// DisableReferenceRewrite specifies not to replace the attribute values that equal to the TF resource id of another exported resource with references to it (e.g. azurerm_subnet.res-1.id).
+	DisableReferenceRewrite bool
+	// GenerateDataSources specifies whether to generate the data sources for the resources that are referenced by the exported resources, but are not exported (e.g. a virtual network in another
+	// resource group). The referencing attribute values are replaced by references to the data sources, which are written to the "data-sources.tf". Only a set of common resource types are supported.
+	GenerateDataSources bool
+	// ExtractVariables specifies whether to extract the values of the common attributes (e.g. location, resource group name, SKUs, tag values) that are repeated across the
+	// generated config to variables, which are written to the "variables.tf" with the extracted values as the defaults.
+	ExtractVariables bool
+	// VariableAttributesFile specifies the path of the JSON file that lists the attribute paths (e.g. ["location", "sku.name", "tags"]) to extract when ExtractVariables is set.
+	// If this is not set, a set of common attributes are extracted.
+	VariableAttributesFile string
+	// OnSecret specifies what to do when secrets (e.g. connection strings, keys, SAS tokens) are found in the generated config, either "redact", "var" (extract to sensitive variables) or "fail".
+	// Empty means not to scan for secrets. Note that the Terraform state is not covered.
+	OnSecret string
+	// SecretAllowlistFile specifies the path of the JSON file that exempts the matched attributes from the secret detection, e.g. [{"resource_type": "azurerm_linux_web_app", "attribute": "app_settings"}].
+	// Each rule can specify the "resource_type", the "attribute" (the attribute path without the block indexes) and the "value_pattern" (a regexp matched against the string value),
+	// which must all match. This requires OnSecret.
+	SecretAllowlistFile string
+	// OnLocked specifies what to do with the resources that are under management locks (i.e. CanNotDelete, ReadOnly), either "warn" (import with a warning) or "skip".
+	// Empty means not to detect the locks.
+	OnLocked string
+	// OnSoftDeleted specifies what to do with the resources that collide with the soft-deleted instances (i.e. Key Vaults, API Management services), e.g. listed by a stale
+	// Azure Resource Graph, either "warn" (import with a warning) or "skip" (skip them together with their children). Empty means not to detect the soft-deleted instances.
+	OnSoftDeleted string
+	// IncludeRoleAssignments specifies whether to also export the role assignments that are scoped to the exported resources (including the resource groups and the subscriptions).
+	// These are extension resources, which are not listed along with the resources they are scoped to.
+	IncludeRoleAssignments bool
+	// IncludeLocks specifies whether to also export the management locks that are scoped to the exported resources.
+	IncludeLocks bool
+	// IncludePolicyAssignments specifies whether to also export the policy assignments that are scoped to the exported resources.
+	IncludePolicyAssignments bool
+	// IncludeMonitoring specifies whether to also export the diagnostic settings, the metric alerts and the activity log alerts that are scoped to the exported resources.
+	// The diagnostic settings are listed per exported resource, while the alerts are listed per subscription (they can reside in other resource groups) and matched by their scopes.
+	IncludeMonitoring bool
+	// IncludeKeyVaultItems specifies whether to also export the keys, the secrets, the certificates and the access policies of the exported Key Vaults.
+	// They are listed from the data plane of the Key Vaults (only the metadata of the secrets are read), which requires the data plane permissions. The Key Vaults
+	// whose items can't be listed are skipped. This can only be used when ProviderName is "azurerm".
+	IncludeKeyVaultItems bool
+	// IncludeStorageItems specifies whether to also export the blob containers, the file shares, the queues and the tables of the exported storage accounts,
+	// which are not listed by the Azure Resource Graph. They are listed from the data plane of the storage accounts, which requires the data plane permissions
+	// (e.g. "Storage Blob Data Reader"). The services whose items can't be listed are skipped. This can only be used when ProviderName is "azurerm".
+	IncludeStorageItems bool
+	// EnvSplit specifies the environments (e.g. "dev", "stage", "prod") to generate the root configs for, which call a reusable module generated from the exported resources.
+	// The module and the root configs are generated under the "environments" directory of the OutputDir, and the first environment imports the exported resources.
+	// Empty means not to generate them.
+	EnvSplit []string
+	// SplitBy specifies how to split the generated config into child modules, either "resource-group" (a module per resource group) or "type" (a module per TF resource type).
+	// The child modules are generated under the "modules" directory of the OutputDir, and called by the root module, which passes the ids referenced across them.
+	// The resources are imported to the addresses of the child modules. Empty means not to split.
+	SplitBy string
+	// SplitFiles specifies how to split the generated config into files of the module directory, instead of the single MainFileName, either "per-resource"
+	// (e.g. "storage_account.res-0.tf"), "per-type" (e.g. "storage_account.tf") or "per-rg" (e.g. "myrg.tf", or "subscription.tf" for the resources out of any resource group).
+	// The provider prefix of the TF resource type (e.g. "azurerm_") is trimmed in the file names. It can't be used with SplitBy, ModuleTemplate or EnvSplit.
+	// Empty means not to split, unless the OutputFileNames.Layout is set.
+	SplitFiles string
+	// OutputSyntax specifies the syntax of the generated config of the resources, either "hcl" (the HCL native syntax, e.g. "main.tf") or "tf-json" (the JSON syntax,
+	// e.g. "main.tf.json"), which is for the toolchains that post-process the config programmatically. The other generated files (e.g. the provider config) are always
+	// in the HCL native syntax. The "tf-json" can't be used with SplitBy, ModuleTemplate or EnvSplit. Empty means "hcl", unless the Renderer is set.
+	OutputSyntax string
+	// Renderer specifies how to render the generated config of the resources. It takes precedence over the OutputSyntax.
+	Renderer ConfigRenderer
+	// ModuleTemplate specifies the address template of the child module of the root module (e.g. "module.rg_{resource_group}") that each resource is imported into,
+	// and its config generated to. The supported placeholders are "{resource_group}" (the resource group name, or "subscription" for the resources out of any resource group)
+	// and "{type}" (the TF resource type). The child modules are expected to be called by the root module from local paths, unless CreateMissingModules is set.
+	// The references across the child modules are kept as the literal ids. Empty means the resources are imported into a single module (see ModulePath).
+	ModuleTemplate string
+	// CreateMissingModules specifies to generate the stubs of the child modules of the ModuleTemplate that are not called by the root module, under the "modules" directory
+	// of the OutputDir, together with the module calls in the root module.
+	CreateMissingModules bool
+	// SortResources specifies the order of the generated resources (and the import blocks), either "type" (by the TF resource type, then the name), "name"
+	// (by the TF resource name, then the type) or "azure-id" (by the Azure resource id). Empty means "type".
+	SortResources string
+	// AuthScaffold specifies to generate a commented provider config that reflects the authentication used during the export, together with the backend config (if any) as
+	// a "backend.tfvars", so that the exported workspace can be planned without hand-authoring the provider auth. Nil means not to generate them.
+	AuthScaffold *AuthScaffold
+	// ExcludeFile specifies the path of the exclude file, which has an Azure resource id or a glob pattern (e.g. "*/providers/Microsoft.Insights/*") per line.
+	// The listed resources that match any of them (case insensitively) are dropped, in all the modes. "*" matches any sequence of characters, including "/".
+	ExcludeFile string
+	// IgnoreFile specifies the path of the ignore file, which has a gitignore-style pattern per line. The patterns containing a "/" match the Azure resource ids (together with
+	// their child resources), while the others match the TF resource types (e.g. "azurerm_monitor_diagnostic_setting"). The patterns starting with "!" re-include the resources,
+	// and the last matching pattern wins. The listed resources that are ignored are dropped, in all the modes.
+	// Empty means to use the ".aztfexportignore" under the OutputDir, if it exists.
+	IgnoreFile string
+	// Limit specifies the maximum number of the listed resources to process, the rest are dropped. Zero means no limit.
+	Limit int
+	// Sample specifies the number of the listed resources to process, which are randomly sampled. Zero means no sampling. This conflicts with Limit.
+	Sample int
+	// Parallelism specifies the parallelism for the process, i.e. the number of the import directories that import the resources, and merge their states concurrently.
+	// It also bounds the number of the resources whose configs are generated concurrently, while the generated config is ordered regardless of it.
 	Parallelism int
+	// ImportTimeout specifies the timeout of importing each resource, after which the import of the resource is cancelled and marked as errored. Zero means no timeout.
+	ImportTimeout time.Duration
 	// ModulePath specifies the path of the module (e.g. "module1.module2") where the resources will be imported and config generated.
-	// Note that only modules whose "source" is local path is supported. By default, it is the root module.
+	// For the modules from remote sources (e.g. registry, git), the resources are imported to the module, while the config is generated to a local directory under the OutputDir,
+	// as the downloaded module can't be modified in place. By default, it is the root module.
 	ModulePath string
+	// UseImportBlocks specifies to leave the state population to the user, who runs "terraform plan/apply" against the generated import blocks (requires terraform >= v1.5.0).
+	// The resources are still imported to the temporary import directories in order to generate the config, but their states are never merged nor pushed to the OutputDir.
+	// This can't be used together with ModulePath, as import blocks are only allowed in the root module.
+	UseImportBlocks bool
+	// CacheDir specifies the directory to cache the ARM payloads and the generated configs of the resources, so that re-running the config generation (e.g. with a
+	// different FullConfig) doesn't re-download or re-import the resources whose etags are unchanged. The configs are only cached when UseImportBlocks is set, as
+	// otherwise the resources are imported to the state anyway. Empty means no cache.
+	CacheDir string
+	// Prune specifies to remove the resources that no longer exist in Azure from the state of the OutputDir (i.e. in append mode), after listing the resources.
+	// Only the resources within the resource groups (or the root scopes) of the listed resources are checked. The removed resources are recorded in the
+	// "aztfexportPruneReport.json", whose config is left to the user to remove. This can't be used together with HCLOnly or DryRun.
+	Prune bool
+	// MovedFromState specifies the path of the state file of a former export (e.g. of a different naming strategy or module layout), whose resources are matched with the
+	// exported resources by their ids. The "moved.tf" is generated in the OutputDir, which contains the moved blocks of the resources exported at the new addresses,
+	// and the removed blocks (that don't destroy) of the resources not exported any more, or exported as different resource types. Applying it in the former workspace,
+	// along with the generated config, refactors the state instead of re-importing (requires terraform >= v1.7.0). This can't be used together with DryRun.
+	MovedFromState string
+	// DryRun specifies to only list the resources and resolve their TF resource types, without initializing terraform, importing or generating anything.
+	DryRun bool
 	// HCLOnly is a strange field, which is only used internally by aztfexport to indicate whether to remove other files other than TF config at the end.
 	// External Go modules should just ignore it.
 	HCLOnly bool
-	// TFClient is the terraform-client-go client used to replace terraform binary for importing resources.
-	// This can only be used together with HCLOnly as tfclient can't replace terraform for state file management.
+	// ShowCommands specifies to echo the terraform commands to the stderr once they start. The commands, together with their working directory, duration,
+	// exit code and (truncated) outputs, are always recorded in the "aztfexportAuditLog.jsonl" of the OutputDir regardless.
+	ShowCommands bool
+	// NamingStrategy specifies a custom strategy to name the TF resources, which takes precedence over the NameFrom and the ResourceNamePattern.
+	// This only applies to resource group mode, query mode and management group mode.
+	NamingStrategy NamingStrategy
+	// Hooks specifies the callbacks that are invoked on the progress of the export.
+	Hooks Hooks
+	// CommandHooks specifies the external commands that are run (in order) at the phases of the export, within the output directory. A JSON object describing the run and
+	// the resources of the phase is written to their stdin, i.e. {"phase": "...", "output_dir": "...", "subscription_id": "...", "provider_name": "...", "resources": [{"azure_resource_id": "...",
+	// "tf_resource_id": "...", "tf_addr": "...", "import_error": "..."}]}. A hook that exits with a non-zero code fails the phase.
+	CommandHooks []CommandHook
+	// TFClient is the terraform-client-go client used to replace terraform binary for importing resources, which saves the startup cost of terraform per import.
+	// Without HCLOnly, the imported states are written to the local state file of the OutputDir directly (the terraform block and provider config are created, but not
+	// initialized). In this case, it can only be used with the local BackendType, and can't be used with ModulePath, UseImportBlocks, SplitBy, ModuleTemplate or Prune.
 	TFClient tfclient.Client
 	// TelemetryClient is a client to send telemetry
 	TelemetryClient telemetry.Client
@@ -71,17 +466,45 @@ type Config struct {
 	// ResourceId specifies the Azure resource id, this indicates the resource mode.
 	ResourceId string
 	// ResourceGroupName specifies the name of the resource group, this indicates the resource group mode.
+	// It can also be a glob pattern (e.g. "rg-prod-*"), which matches the resource groups of the subscription case insensitively.
 	ResourceGroupName string
 	// ARGPredicate specifies the ARG where predicate, this indicates the query mode.
 	ARGPredicate string
 	// MappingFile specifies the path of mapping file, this indicates the map file mode.
 	MappingFile string
+	// ManagementGroupName specifies the name of the management group, this indicates the management group mode.
+	// All the resources of the subscriptions under the management group are exported, each of the subscriptions other than
+	// the SubscriptionId is managed by an aliased azurerm provider.
+	ManagementGroupName string
 
-	// ResourceNamePattern specifies the resource name pattern, this only applies to resource group mode and query mode.
+	// ResourceNamePattern specifies the resource name pattern, this only applies to resource group mode, query mode and management group mode.
 	ResourceNamePattern string
+	// NameFrom specifies the builtin strategy to name the TF resources, either "pattern" (by the ResourceNamePattern), "azure-name" (the Azure resource name),
+	// "azure-name-kebab" (the Azure resource name in kebab case) or "type-and-name" (the TF resource type without the provider prefix, and the Azure resource name).
+	// Empty means "pattern". This only applies to resource group mode, query mode and management group mode.
+	NameFrom string
+
+	// IncludeTags specifies the tags that the listed resources must all have, this only applies to resource group mode, query mode and management group mode.
+	// The filter is applied by ARG, so the child resources and the resource group itself (in resource group mode) are not filtered.
+	IncludeTags map[string]string
+	// ExcludeTags specifies the tags that the listed resources must not have any of, this only applies to resource group mode, query mode and management group mode.
+	ExcludeTags map[string]string
+
+	// OnlyTypes specifies the TF resource types that the exported resources must be of, which are matched against the resolved TF resource types,
+	// so that a large resource group can be exported into multiple workspaces across runs. The unresolved resources are dropped. This only applies to resource group mode.
+	OnlyTypes []string
+	// SkipTypes specifies the TF resource types that the exported resources must not be of, which is the inverse of OnlyTypes. This only applies to resource group mode.
+	SkipTypes []string
 
 	// RecursiveQuery specifies whether to recursively list the child/proxy resources of the ARG resulted resource list, this only applies to query mode.
+	// This also enumerates the known child resources that are not listed as proxy resources (e.g. the subnets, the Key Vault keys and secrets, the DNS record sets).
 	RecursiveQuery bool
+	// OnlyResourceIds specifies the Azure resource ids that the exported resources must be among (case insensitively), which are matched against the listed resources,
+	// including the child resources listed by the RecursiveQuery (e.g. the resources added since the last sync). Empty means all. This only applies to query mode.
+	OnlyResourceIds []string
+	// AvoidStateNames specifies to name the TF resources differently from the resources that are already in the state (e.g. in append mode), which are suffixed by "-<n>"
+	// on collision, instead of changing the ResourceNamePattern. This only applies to query mode.
+	AvoidStateNames bool
 
 	// TFResourceName specifies the TF resource name, this only applies to resource mode.
 	TFResourceName string
@@ -0,0 +1,125 @@
+// Package export is the stable API for the services that embed aztfexport, which runs the whole export workflow (i.e. listing, importing and generating
+// the config) in one call.
+package export
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/aztfexport/pkg/meta"
+)
+
+type Resource = meta.ExportedResource
+
+// Result is the result of the export, which is partial if the export is cancelled.
+type Result struct {
+	meta.ExportResult
+	// Pending are the Azure resource ids of the resources that are not imported as the export is cancelled, in the order of the import list.
+	// It is empty if the export is completed.
+	Pending []string
+}
+
+// Export exports the resources of the config to the output directory.
+//
+// Once the context is cancelled, the resources being imported are imported until they finish, and no more resources are imported. The config of the
+// resources imported is then generated, and the result is returned together with the error of the context, where the resources not imported are pending.
+// The import failures of the resources don't fail the export, but are recorded in the result.
+func Export(ctx context.Context, cfg config.Config) (*Result, error) {
+	m, err := meta.NewMeta(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return export(ctx, m, cfg.Parallelism)
+}
+
+func export(ctx context.Context, m meta.Meta, parallelism int) (result *Result, err error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	if err := m.Init(ctx); err != nil {
+		return nil, err
+	}
+
+	// The in-flight imports and the following steps run in the detached context, so that they are finished cleanly once the context is cancelled.
+	dctx := detachedContext{parent: ctx}
+	defer func() {
+		if derr := m.DeInit(dctx); derr != nil && err == nil {
+			result, err = nil, fmt.Errorf("deinitializing: %v", derr)
+		}
+	}()
+
+	list, err := m.ListResource(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing resources: %v", err)
+	}
+	if err := m.ExportSkippedResources(ctx, list); err != nil {
+		return nil, fmt.Errorf("exporting Skipped Resource file: %v", err)
+	}
+	if err := m.ExportResourceMapping(ctx, list); err != nil {
+		return nil, fmt.Errorf("exporting Resource Mapping file: %v", err)
+	}
+
+	var ctxErr error
+	attempted := 0
+	for attempted < len(list) {
+		if ctxErr = ctx.Err(); ctxErr != nil {
+			break
+		}
+		end := attempted + parallelism
+		if end > len(list) {
+			end = len(list)
+		}
+		var items []*meta.ImportItem
+		for i := attempted; i < end; i++ {
+			if !list[i].Skip() {
+				items = append(items, &list[i])
+			}
+		}
+		if err := m.ParallelImport(dctx, items); err != nil {
+			return nil, fmt.Errorf("parallel importing: %v", err)
+		}
+		attempted = end
+	}
+
+	if err := m.PushState(dctx); err != nil {
+		return nil, fmt.Errorf("pushing state: %v", err)
+	}
+	if err := m.GenerateCfg(dctx, list[:attempted]); err != nil {
+		return nil, fmt.Errorf("generating Terraform configuration: %v", err)
+	}
+	if err := m.CleanUpWorkspace(dctx); err != nil {
+		return nil, fmt.Errorf("cleaning up main workspace: %v", err)
+	}
+
+	result = &Result{ExportResult: m.Result()}
+	for _, item := range list[attempted:] {
+		if !item.Skip() {
+			result.Pending = append(result.Pending, item.AzureResourceID.String())
+		}
+	}
+	return result, ctxErr
+}
+
+// detachedContext carries the values of its parent, but is never cancelled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
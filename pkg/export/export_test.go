@@ -0,0 +1,119 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMeta struct {
+	meta.Meta
+	list meta.ImportList
+	// onImport is called once each batch is imported
+	onImport  func()
+	imported  []*meta.ImportItem
+	generated meta.ImportList
+	deinit    bool
+}
+
+func (m *fakeMeta) Init(_ context.Context) error {
+	return nil
+}
+
+func (m *fakeMeta) DeInit(_ context.Context) error {
+	m.deinit = true
+	return nil
+}
+
+func (m *fakeMeta) ListResource(_ context.Context) (meta.ImportList, error) {
+	return m.list, nil
+}
+
+func (m *fakeMeta) ExportSkippedResources(_ context.Context, _ meta.ImportList) error {
+	return nil
+}
+
+func (m *fakeMeta) ExportResourceMapping(_ context.Context, _ meta.ImportList) error {
+	return nil
+}
+
+func (m *fakeMeta) ParallelImport(ctx context.Context, items []*meta.ImportItem) error {
+	m.onImport()
+	// The in-flight imports are not cancelled.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, item := range items {
+		item.Imported = true
+	}
+	m.imported = append(m.imported, items...)
+	return nil
+}
+
+func (m *fakeMeta) PushState(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (m *fakeMeta) GenerateCfg(ctx context.Context, l meta.ImportList) error {
+	m.generated = l
+	return ctx.Err()
+}
+
+func (m *fakeMeta) CleanUpWorkspace(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (m *fakeMeta) Result() meta.ExportResult {
+	result := meta.ExportResult{}
+	for _, item := range m.generated.Imported() {
+		result.Resources = append(result.Resources, meta.ExportedResource{AzureResourceId: item.AzureResourceID.String(), TFResourceId: item.TFResourceId})
+	}
+	return result
+}
+
+func newFakeMeta(t *testing.T, n int) *fakeMeta {
+	var list meta.ImportList
+	for i := 0; i < n; i++ {
+		id, err := armid.ParseResourceId(fmt.Sprintf("/subscriptions/0000/resourceGroups/rg%d", i))
+		require.NoError(t, err)
+		list = append(list, meta.ImportItem{
+			AzureResourceID: id,
+			TFResourceId:    id.String(),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: fmt.Sprintf("res-%d", i)},
+		})
+	}
+	// The skipped resources are neither imported nor pending.
+	list[1].TFAddr = tfaddr.TFAddr{}
+	return &fakeMeta{list: list, onImport: func() {}}
+}
+
+func TestExport(t *testing.T) {
+	m := newFakeMeta(t, 5)
+	result, err := export(context.Background(), m, 2)
+	require.NoError(t, err)
+	require.True(t, m.deinit)
+	require.Len(t, m.imported, 4)
+	require.Len(t, result.Exported(), 4)
+	require.Empty(t, result.Pending)
+}
+
+func TestExportCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := newFakeMeta(t, 5)
+	// The export is cancelled during the import of the first batch.
+	m.onImport = cancel
+	result, err := export(ctx, m, 2)
+	require.ErrorIs(t, err, context.Canceled)
+	require.True(t, m.deinit)
+	require.Len(t, m.imported, 1)
+	require.Len(t, result.Exported(), 1)
+	require.Equal(t, "/subscriptions/0000/resourceGroups/rg0", result.Exported()[0].AzureResourceId)
+	require.Equal(t, []string{"/subscriptions/0000/resourceGroups/rg2", "/subscriptions/0000/resourceGroups/rg3", "/subscriptions/0000/resourceGroups/rg4"}, result.Pending)
+}
@@ -1,19 +1,105 @@
 package log
 
+import (
+	golog "log"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+)
+
 type Logger interface {
 	Printf(format string, v ...any)
 }
 
-var log Logger = NullLogger{}
+// Fields are the structured fields attached to the log entries, e.g. the resource id and the phase.
+type Fields map[string]string
+
+// FieldLogger is a Logger that can attach the structured fields to its log entries.
+type FieldLogger interface {
+	Logger
+	With(fields Fields) Logger
+}
+
+var (
+	mu sync.RWMutex
+	// root is the logger set via SetLogger, while log is the one used to log, which might have the phase attached.
+	root Logger = NullLogger{}
+	log  Logger = NullLogger{}
+)
 
 type NullLogger struct{}
 
 func (NullLogger) Printf(format string, v ...any) {}
 
 func SetLogger(l Logger) {
+	mu.Lock()
+	defer mu.Unlock()
+	root = l
 	log = l
 }
 
+func current() Logger {
+	mu.RLock()
+	defer mu.RUnlock()
+	return log
+}
+
 func Printf(format string, v ...any) {
-	log.Printf(format, v...)
+	current().Printf(format, v...)
+}
+
+// With returns the logger that attaches the fields to the log entries (e.g. the resource id), along with the phase (if any).
+// The fields are dropped if the logger is not a FieldLogger.
+func With(fields Fields) Logger {
+	l := current()
+	if fl, ok := l.(FieldLogger); ok {
+		return fl.With(fields)
+	}
+	return l
+}
+
+// Phase attaches the phase (e.g. "import") to the following log entries, until the returned function is called.
+func Phase(name string) (leave func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	prev := log
+	if fl, ok := root.(FieldLogger); ok {
+		log = fl.With(Fields{"phase": name})
+	}
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		log = prev
+	}
+}
+
+type hcLogger struct {
+	l   hclog.Logger
+	std *golog.Logger
+}
+
+// NewHCLogger returns the FieldLogger of the hclog logger, where the level of the log entry is inferred from the prefix of the message (e.g. "[INFO] ").
+func NewHCLogger(l hclog.Logger) FieldLogger {
+	return hcLogger{
+		l:   l,
+		std: l.StandardLogger(&hclog.StandardLoggerOptions{InferLevels: true}),
+	}
+}
+
+func (l hcLogger) Printf(format string, v ...any) {
+	l.std.Printf(format, v...)
+}
+
+func (l hcLogger) With(fields Fields) Logger {
+	var keys []string
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var args []interface{}
+	for _, k := range keys {
+		args = append(args, k, fields[k])
+	}
+	return NewHCLogger(l.l.With(args...))
 }
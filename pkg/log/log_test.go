@@ -0,0 +1,50 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestStructuredLog(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(NewHCLogger(hclog.New(&hclog.LoggerOptions{
+		Level:      hclog.Debug,
+		Output:     &buf,
+		JSONFormat: true,
+	}).With("run_id", "123")))
+	defer SetLogger(NullLogger{})
+
+	Printf("[INFO] foo")
+	leave := Phase("import")
+	With(Fields{"resource_id": "/subscriptions/xxx"}).Printf("[ERROR] bar")
+	leave()
+	Printf("[DEBUG] baz")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	require.Equal(t, "foo", gjson.Get(lines[0], "@message").String())
+	require.Equal(t, "info", gjson.Get(lines[0], "@level").String())
+	require.Equal(t, "123", gjson.Get(lines[0], "run_id").String())
+	require.False(t, gjson.Get(lines[0], "phase").Exists())
+
+	require.Equal(t, "bar", gjson.Get(lines[1], "@message").String())
+	require.Equal(t, "error", gjson.Get(lines[1], "@level").String())
+	require.Equal(t, "123", gjson.Get(lines[1], "run_id").String())
+	require.Equal(t, "import", gjson.Get(lines[1], "phase").String())
+	require.Equal(t, "/subscriptions/xxx", gjson.Get(lines[1], "resource_id").String())
+
+	require.Equal(t, "debug", gjson.Get(lines[2], "@level").String())
+	require.False(t, gjson.Get(lines[2], "phase").Exists())
+}
+
+func TestWithNullLogger(t *testing.T) {
+	SetLogger(NullLogger{})
+	defer Phase("import")()
+	require.Equal(t, NullLogger{}, With(Fields{"resource_id": "foo"}))
+}
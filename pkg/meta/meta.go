@@ -9,6 +9,8 @@ import (
 
 type ImportItem = meta.ImportItem
 type ImportList = meta.ImportList
+type ExportResult = meta.ExportResult
+type ExportedResource = meta.ExportedResource
 
 type Meta interface {
 	meta.BaseMeta
@@ -19,6 +21,15 @@ type Meta interface {
 }
 
 func NewMeta(cfg config.Config) (Meta, error) {
+	if len(cfg.AdditionalSubscriptionIds) != 0 && cfg.ARGPredicate == "" {
+		return nil, fmt.Errorf("AdditionalSubscriptionIds can only be used in query mode")
+	}
+	if len(cfg.AdditionalResourceGroupNames) != 0 && cfg.ResourceGroupName == "" {
+		return nil, fmt.Errorf("AdditionalResourceGroupNames can only be used in resource group mode")
+	}
+	if len(cfg.IncludeTags)+len(cfg.ExcludeTags) != 0 && cfg.ResourceGroupName == "" && cfg.ARGPredicate == "" && cfg.ManagementGroupName == "" {
+		return nil, fmt.Errorf("IncludeTags and ExcludeTags can only be used in resource group mode, query mode or management group mode")
+	}
 	switch {
 	case cfg.ResourceGroupName != "":
 		return meta.NewMetaResourceGroup(cfg)
@@ -26,6 +37,8 @@ func NewMeta(cfg config.Config) (Meta, error) {
 		return meta.NewMetaQuery(cfg)
 	case cfg.MappingFile != "":
 		return meta.NewMetaMap(cfg)
+	case cfg.ManagementGroupName != "":
+		return meta.NewMetaManagementGroup(cfg)
 	case cfg.ResourceId != "":
 		return meta.NewMetaResource(cfg)
 	default:
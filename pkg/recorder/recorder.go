@@ -0,0 +1,182 @@
+// Package recorder records the HTTP traffic of the Azure SDK clients (i.e. the ARM and Azure Resource Graph requests) to a directory,
+// and replays it afterwards, so that an export can be reproduced without a live Azure subscription.
+//
+// The Transport is meant to be set to the AzureSDKClientOption of the config. Only the request method, URL and body, as well as the
+// response status, header and body are recorded. The token requests of the credential shouldn't go through the Transport, otherwise the
+// access tokens are recorded.
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Transport implements the policy.Transporter, which either records or replays the interactions.
+type Transport struct {
+	dir    string
+	replay bool
+	next   policy.Transporter
+
+	mu sync.Mutex
+	// seqs counts the identical requests, so that each of them is recorded or replayed separately, in order.
+	seqs map[string]int
+}
+
+var _ policy.Transporter = &Transport{}
+
+// NewRecorder returns a Transport that sends the requests via the next transporter (http.DefaultClient if nil), and records the interactions to dir.
+func NewRecorder(dir string, next policy.Transporter) (*Transport, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("creating the record directory %s: %v", dir, err)
+	}
+	if next == nil {
+		next = http.DefaultClient
+	}
+	return &Transport{dir: dir, next: next, seqs: map[string]int{}}, nil
+}
+
+// NewReplayer returns a Transport that responds the requests with the interactions recorded in dir, without sending them.
+func NewReplayer(dir string) (*Transport, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading the replay directory: %v", err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("the replay directory %s is not a directory", dir)
+	}
+	return &Transport{dir: dir, replay: true, seqs: map[string]int{}}, nil
+}
+
+func (t *Transport) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		// #nosec G104
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	irq := Request{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Body:   string(body),
+	}
+	path := t.nextPath(irq)
+
+	if t.replay {
+		return t.load(req, irq, path)
+	}
+
+	resp, err := t.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// #nosec G104
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	interaction := Interaction{
+		Request: irq,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       string(respBody),
+		},
+	}
+	b, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshalling the interaction: %v", err)
+	}
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return nil, fmt.Errorf("recording the interaction to %s: %v", path, err)
+	}
+	return resp, nil
+}
+
+func (t *Transport) load(req *http.Request, irq Request, path string) (*http.Response, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no recorded interaction for %s %s", irq.Method, irq.URL)
+		}
+		return nil, err
+	}
+	var interaction Interaction
+	if err := json.Unmarshal(b, &interaction); err != nil {
+		return nil, fmt.Errorf("unmarshalling the interaction %s: %v", path, err)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Response.Header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// nextPath returns the path of the interaction file for the request. The nth identical request is stored in "<hash>-<n>.json".
+func (t *Transport) nextPath(irq Request) string {
+	h := sha256.Sum256([]byte(irq.Method + " " + irq.URL + "\n" + irq.Body))
+	key := hex.EncodeToString(h[:8])
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := t.seqs[key]
+	t.seqs[key] = n + 1
+	return filepath.Join(t.dir, fmt.Sprintf("%s-%d.json", key, n))
+}
+
+// Credential is a fake token credential for the replay, as the replayed requests are never authenticated.
+type Credential struct{}
+
+var _ azcore.TokenCredential = Credential{}
+
+func (Credential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "replay", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
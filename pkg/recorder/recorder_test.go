@@ -0,0 +1,64 @@
+package recorder
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var count int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "secret")
+		w.WriteHeader(http.StatusOK)
+		// #nosec G104
+		w.Write([]byte(`{"count":` + strconv.Itoa(count) + `,"req":"` + string(b) + `"}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	do := func(tr *Transport, body string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/foo?api-version=1", strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := tr.Do(req)
+		require.NoError(t, err)
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+
+	rec, err := NewRecorder(dir, nil)
+	require.NoError(t, err)
+	_, body := do(rec, "a")
+	require.Equal(t, `{"count":1,"req":"a"}`, body)
+	_, body = do(rec, "a")
+	require.Equal(t, `{"count":2,"req":"a"}`, body)
+	_, body = do(rec, "b")
+	require.Equal(t, `{"count":3,"req":"b"}`, body)
+
+	rep, err := NewReplayer(dir)
+	require.NoError(t, err)
+	resp, body := do(rep, "b")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.Empty(t, resp.Header.Get("Set-Cookie"))
+	require.Equal(t, `{"count":3,"req":"b"}`, body)
+	_, body = do(rep, "a")
+	require.Equal(t, `{"count":1,"req":"a"}`, body)
+	_, body = do(rep, "a")
+	require.Equal(t, `{"count":2,"req":"a"}`, body)
+	require.Equal(t, 3, count)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/foo?api-version=1", strings.NewReader("a"))
+	require.NoError(t, err)
+	_, err = rep.Do(req)
+	require.EqualError(t, err, "no recorded interaction for POST "+srv.URL+"/foo?api-version=1")
+}
@@ -0,0 +1,216 @@
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/log"
+)
+
+const (
+	defaultBatchSize = 100
+	// maxSpoolRecords caps the records loaded from the spool, where the oldest ones are dropped, so that the spool doesn't grow unbounded
+	// when the endpoint is blocked forever.
+	maxSpoolRecords = 1000
+)
+
+// Exporter exports a batch of the telemetry records to the endpoint of a sink.
+type Exporter interface {
+	Export(records []FileRecord) error
+}
+
+// PartialExportError is returned by the exporter that exports only part of the batch, where only the failed records are spooled, so that the exported
+// ones are not exported again by the next run.
+type PartialExportError struct {
+	Failed []FileRecord
+	Err    error
+}
+
+func (e *PartialExportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialExportError) Unwrap() error {
+	return e.Err
+}
+
+// BatchClient buffers the telemetry records, and exports them in batches by the exporter, in background once a batch is full, and the rest on Close.
+//
+// Once an export fails (e.g. the endpoint is unreachable), the client goes offline, where the failed and the later records are spooled to the spool file
+// instead of being exported, so that a blocked endpoint only costs a single timeout of the run. The spooled records are exported by the client of the next run.
+type BatchClient struct {
+	exporter  Exporter
+	spoolPath string
+	batchSize int
+
+	mu      sync.Mutex
+	records []FileRecord
+	offline bool
+	// wg waits for the exports in background
+	wg sync.WaitGroup
+
+	spoolMu sync.Mutex
+}
+
+// NewBatchClient returns a client that exports the records by the exporter, with the records spooled (if any) by the former runs.
+// The spool path can be empty, in which case the records failed to export are dropped.
+func NewBatchClient(exporter Exporter, spoolPath string) *BatchClient {
+	c := &BatchClient{
+		exporter:  exporter,
+		spoolPath: spoolPath,
+		batchSize: defaultBatchSize,
+	}
+	if spoolPath != "" {
+		records, err := readSpool(spoolPath)
+		if err != nil {
+			log.Printf("[WARN] Failed to read the telemetry spool %s: %v", spoolPath, err)
+		}
+		c.records = records
+	}
+	return c
+}
+
+func (c *BatchClient) add(record FileRecord) {
+	c.mu.Lock()
+	c.records = append(c.records, record)
+	if len(c.records) < c.batchSize {
+		c.mu.Unlock()
+		return
+	}
+	batch, offline := c.records, c.offline
+	c.records = nil
+	c.mu.Unlock()
+
+	if offline {
+		c.spool(batch)
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.export(batch)
+	}()
+}
+
+// export exports the batch, or spools it if the client is (or goes) offline.
+func (c *BatchClient) export(batch []FileRecord) {
+	c.mu.Lock()
+	offline := c.offline
+	c.mu.Unlock()
+	if !offline {
+		err := c.exporter.Export(batch)
+		if err == nil {
+			return
+		}
+		log.Printf("[WARN] Failed to export the telemetry, going offline: %v", err)
+		c.mu.Lock()
+		c.offline = true
+		c.mu.Unlock()
+		var partial *PartialExportError
+		if errors.As(err, &partial) {
+			batch = partial.Failed
+		}
+	}
+	c.spool(batch)
+}
+
+// spool appends the records to the spool file, in JSON lines.
+func (c *BatchClient) spool(records []FileRecord) {
+	if c.spoolPath == "" {
+		return
+	}
+	c.spoolMu.Lock()
+	defer c.spoolMu.Unlock()
+	if err := appendSpool(c.spoolPath, records); err != nil {
+		log.Printf("[WARN] Failed to spool the telemetry to %s: %v", c.spoolPath, err)
+	}
+}
+
+func (c *BatchClient) Trace(level Level, msg string) {
+	c.add(FileRecord{Time: time.Now(), Kind: "trace", Level: level.String(), Message: msg})
+}
+
+func (c *BatchClient) StartSpan(name string) func(error) {
+	start := time.Now()
+	return func(err error) {
+		record := FileRecord{Time: start, Kind: "span", Name: name, DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		c.add(record)
+	}
+}
+
+func (c *BatchClient) Event(name string, attrs map[string]string) {
+	c.add(FileRecord{Time: time.Now(), Kind: "event", Name: name, Attributes: attrs})
+}
+
+// Close waits for the exports in background, then exports (or spools) the rest of the records.
+func (c *BatchClient) Close() {
+	c.wg.Wait()
+	c.mu.Lock()
+	batch := c.records
+	c.records = nil
+	c.mu.Unlock()
+	if len(batch) != 0 {
+		c.export(batch)
+	}
+}
+
+func appendSpool(path string, records []FileRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	// #nosec G304
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	// #nosec G307
+	defer f.Close()
+	buf := bytes.NewBuffer([]byte{})
+	enc := json.NewEncoder(buf)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	_, err = f.Write(buf.Bytes())
+	return err
+}
+
+// readSpool reads and removes the spool, which keeps the newest maxSpoolRecords records. The malformed lines (e.g. of an interrupted write) are skipped.
+func readSpool(path string) ([]FileRecord, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("removing the spool: %v", err)
+	}
+	var records []FileRecord
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var record FileRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	if len(records) > maxSpoolRecords {
+		records = records[len(records)-maxSpoolRecords:]
+	}
+	return records, nil
+}
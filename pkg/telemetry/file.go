@@ -0,0 +1,73 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileRecord is a line of the file written by the FileClient.
+type FileRecord struct {
+	Time time.Time `json:"time"`
+	// Kind is one of "trace", "span" and "event"
+	Kind    string `json:"kind"`
+	Level   string `json:"level,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message,omitempty"`
+	// DurationMs is the duration of the span in milliseconds
+	DurationMs int64             `json:"duration_ms,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// FileClient writes the telemetry to a file, one JSON record (i.e. FileRecord) per line.
+type FileClient struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewFileClient returns a client that appends the telemetry to the file, which is created if not exists.
+func NewFileClient(path string) (*FileClient, error) {
+	// #nosec G302 G304
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening the telemetry file %s: %v", path, err)
+	}
+	return &FileClient{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (c *FileClient) write(record FileRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// #nosec G104
+	c.enc.Encode(record)
+}
+
+func (c *FileClient) Trace(level Level, msg string) {
+	c.write(FileRecord{Time: time.Now(), Kind: "trace", Level: level.String(), Message: msg})
+}
+
+func (c *FileClient) StartSpan(name string) func(error) {
+	start := time.Now()
+	return func(err error) {
+		record := FileRecord{Time: start, Kind: "span", Name: name, DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		c.write(record)
+	}
+}
+
+func (c *FileClient) Event(name string, attrs map[string]string) {
+	c.write(FileRecord{Time: time.Now(), Kind: "event", Name: name, Attributes: attrs})
+}
+
+func (c *FileClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// #nosec G104
+	c.f.Close()
+}
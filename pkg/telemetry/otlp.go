@@ -0,0 +1,247 @@
+package telemetry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultOTLPEndpoint is the default OTLP/HTTP endpoint of the OpenTelemetry collector, if neither specified nor set by the OTEL_EXPORTER_OTLP_ENDPOINT.
+const DefaultOTLPEndpoint = "http://localhost:4318"
+
+const otlpScopeName = "aztfexport"
+
+// OTLPClient exports the telemetry to an OpenTelemetry collector via OTLP/HTTP (in the JSON encoding). The spans are exported as the traces of the run,
+// while the traces and the events are exported as the logs. They are exported in batches, see BatchClient.
+type OTLPClient struct {
+	*BatchClient
+
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+
+	traceId string
+}
+
+// NewOTLPClient returns a client that exports to the OTLP/HTTP endpoint (e.g. "http://localhost:4318"). If the endpoint is empty, it is read from the
+// OTEL_EXPORTER_OTLP_ENDPOINT, which defaults to DefaultOTLPEndpoint. The headers (e.g. for authentication) are read from the OTEL_EXPORTER_OTLP_HEADERS,
+// in form of "key1=value1,key2=value2". The telemetry failed to export is spooled to the spool path (if any), see BatchClient.
+func NewOTLPClient(endpoint, spoolPath string) (*OTLPClient, error) {
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = DefaultOTLPEndpoint
+	}
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return nil, fmt.Errorf("the OTLP endpoint %q must be a HTTP(S) URL", endpoint)
+	}
+	headers := map[string]string{}
+	if env := os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"); env != "" {
+		for _, kv := range strings.Split(env, ",") {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS %q, expect in form of key1=value1,key2=value2", env)
+			}
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	c := &OTLPClient{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		headers:  headers,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		traceId:  randomHex(16),
+	}
+	c.BatchClient = NewBatchClient(c, spoolPath)
+	return c, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	// #nosec G104
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// The OTLP JSON encoding, see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding.
+// The 64 bit integers are encoded as strings, while the trace/span ids are encoded as hex strings.
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpStatus struct {
+	// 1 for OK, 2 for ERROR
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceId           string     `json:"traceId"`
+	SpanId            string     `json:"spanId"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Status            otlpStatus `json:"status"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otlpAnyValue   `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+	TraceId        string         `json:"traceId"`
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// severityNumber maps the level to the OpenTelemetry severity number.
+func severityNumber(level Level) int {
+	switch level {
+	case Verbose:
+		return 5
+	case Info:
+		return 9
+	case Warn:
+		return 13
+	case Error:
+		return 17
+	default:
+		return 21
+	}
+}
+
+// Export exports the spans of the records as the traces, and the rest as the logs.
+// If only the logs fail to export, a *PartialExportError is returned with the records of the logs, as the traces are exported already.
+func (c *OTLPClient) Export(records []FileRecord) error {
+	var spans []otlpSpan
+	var logs []otlpLogRecord
+	var logRecords []FileRecord
+	for _, record := range records {
+		if record.Kind != "span" {
+			logRecords = append(logRecords, record)
+		}
+		switch record.Kind {
+		case "span":
+			span := otlpSpan{
+				TraceId: c.traceId,
+				SpanId:  randomHex(8),
+				Name:    record.Name,
+				// SPAN_KIND_INTERNAL
+				Kind:              1,
+				StartTimeUnixNano: unixNano(record.Time),
+				EndTimeUnixNano:   unixNano(record.Time.Add(time.Duration(record.DurationMs) * time.Millisecond)),
+				Status:            otlpStatus{Code: 1},
+			}
+			if record.Error != "" {
+				span.Status = otlpStatus{Code: 2, Message: record.Error}
+			}
+			spans = append(spans, span)
+		case "event":
+			kvs := []otlpKeyValue{{Key: "event.name", Value: otlpAnyValue{StringValue: record.Name}}}
+			for k, v := range record.Attributes {
+				kvs = append(kvs, otlpKeyValue{Key: k, Value: otlpAnyValue{StringValue: v}})
+			}
+			logs = append(logs, otlpLogRecord{
+				TimeUnixNano:   unixNano(record.Time),
+				SeverityNumber: severityNumber(Info),
+				SeverityText:   "INFO",
+				Body:           otlpAnyValue{StringValue: record.Name},
+				Attributes:     kvs,
+				TraceId:        c.traceId,
+			})
+		default:
+			level := parseLevel(record.Level)
+			logs = append(logs, otlpLogRecord{
+				TimeUnixNano:   unixNano(record.Time),
+				SeverityNumber: severityNumber(level),
+				SeverityText:   strings.ToUpper(level.String()),
+				Body:           otlpAnyValue{StringValue: record.Message},
+				TraceId:        c.traceId,
+			})
+		}
+	}
+
+	resource := otlpResource{Attributes: []otlpKeyValue{{Key: "service.name", Value: otlpAnyValue{StringValue: "aztfexport"}}}}
+	scope := otlpScope{Name: otlpScopeName}
+	if len(spans) != 0 {
+		body := map[string]interface{}{
+			"resourceSpans": []interface{}{
+				map[string]interface{}{
+					"resource":   resource,
+					"scopeSpans": []interface{}{map[string]interface{}{"scope": scope, "spans": spans}},
+				},
+			},
+		}
+		if err := c.export("/v1/traces", body); err != nil {
+			return fmt.Errorf("exporting the traces: %v", err)
+		}
+	}
+	if len(logs) != 0 {
+		body := map[string]interface{}{
+			"resourceLogs": []interface{}{
+				map[string]interface{}{
+					"resource":  resource,
+					"scopeLogs": []interface{}{map[string]interface{}{"scope": scope, "logRecords": logs}},
+				},
+			},
+		}
+		if err := c.export("/v1/logs", body); err != nil {
+			err = fmt.Errorf("exporting the logs: %v", err)
+			if len(spans) != 0 {
+				return &PartialExportError{Failed: logRecords, Err: err}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *OTLPClient) export(path string, body interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshalling: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, c.endpoint+path, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("building the request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending the request to %s: %v", req.URL, err)
+	}
+	// #nosec G307
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("sending the request to %s: unexpected status %s", req.URL, resp.Status)
+	}
+	return nil
+}
@@ -1,9 +1,14 @@
 package telemetry
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
-	"github.com/microsoft/ApplicationInsights-Go/appinsights"
 	"github.com/microsoft/ApplicationInsights-Go/appinsights/contracts"
 )
 
@@ -17,8 +22,68 @@ const (
 	Critical
 )
 
+func (l Level) String() string {
+	switch l {
+	case Verbose:
+		return "verbose"
+	case Info:
+		return "info"
+	case Warn:
+		return "warn"
+	case Error:
+		return "error"
+	case Critical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// parseLevel parses the level of its string form, which defaults to Info.
+func parseLevel(s string) Level {
+	for _, level := range []Level{Verbose, Info, Warn, Error, Critical} {
+		if level.String() == s {
+			return level
+		}
+	}
+	return Info
+}
+
+// The telemetry sinks that the telemetry can be sent to.
+const (
+	// SinkAppInsights sends the telemetry to the Microsoft managed Application Insights, only if the telemetry is enabled (which is the default).
+	SinkAppInsights = "appinsights"
+	// SinkOTLP exports the telemetry to an OpenTelemetry collector via OTLP/HTTP.
+	SinkOTLP = "otlp"
+	// SinkFile writes the telemetry to a file, in JSON lines.
+	SinkFile = "file"
+	// SinkNone sends no telemetry.
+	SinkNone = "none"
+)
+
+// Sinks are the supported telemetry sinks.
+var Sinks = []string{SinkAppInsights, SinkOTLP, SinkFile, SinkNone}
+
+// The details of the telemetry.
+const (
+	// DetailOff records no telemetry, regardless of the sink.
+	DetailOff = "off"
+	// DetailMinimal only records the phases (without the error messages) and the events, but not the messages (e.g. the effective CLI, the error details).
+	DetailMinimal = "minimal"
+	// DetailFull records all the telemetry.
+	DetailFull = "full"
+)
+
+// Details are the supported details of the telemetry.
+var Details = []string{DetailOff, DetailMinimal, DetailFull}
+
 type Client interface {
+	// Trace records a message.
 	Trace(level Level, msg string)
+	// StartSpan starts the span of a phase (e.g. "Init", "ParallelImport"), which is ended by calling the returned function with the error of the phase (if any).
+	StartSpan(name string) (end func(err error))
+	// Event records an event (e.g. a resource is imported) with its attributes. The attributes must not contain the user data (e.g. the resource ids).
+	Event(name string, attrs map[string]string)
 	Close()
 }
 
@@ -28,25 +93,66 @@ func NewNullClient() Client {
 	return NullClient{}
 }
 
-func (NullClient) Trace(Level, string) {}
-func (NullClient) Close()              {}
+func (NullClient) Trace(Level, string)             {}
+func (NullClient) StartSpan(string) func(error)    { return func(error) {} }
+func (NullClient) Event(string, map[string]string) {}
+func (NullClient) Close()                          {}
+
+// WithDetail returns the client that only records the telemetry of the detail. The DetailOff is not handled, where the NullClient is expected to be
+// used in place of building the client, as building a client (e.g. the BatchClient) might have side effects (e.g. loading the spool).
+func WithDetail(c Client, detail string) Client {
+	if detail == DetailMinimal {
+		return minimalClient{Client: c}
+	}
+	return c
+}
+
+// errRedacted replaces the errors of the spans for the DetailMinimal, as the error messages might contain the user data (e.g. the resource ids).
+var errRedacted = errors.New("error")
 
+type minimalClient struct {
+	Client
+}
+
+func (minimalClient) Trace(Level, string) {}
+
+func (c minimalClient) StartSpan(name string) func(error) {
+	end := c.Client.StartSpan(name)
+	return func(err error) {
+		if err != nil {
+			err = errRedacted
+		}
+		end(err)
+	}
+}
+
+// AppInsightsEndpoint is the ingestion endpoint of the Application Insights.
+const AppInsightsEndpoint = "https://dc.services.visualstudio.com/v2/track"
+
+// AppInsightClient sends the telemetry to the Microsoft managed Application Insights, as the traces whose message is the ApplicationInsightMessage.
+// They are sent in batches, see BatchClient.
 type AppInsightClient struct {
-	appinsights.TelemetryClient
+	*BatchClient
+
+	endpoint       string
+	client         *http.Client
 	subscriptionId string
 	installId      string
 	sessionId      string
 }
 
-func NewAppInsight(subscriptionId string, installId string, sessionid string) Client {
-	// The instrument key of a MS managed application insights
-	const instrumentKey = "1bfe1d29-b42e-49b5-9d51-77514f85b37b"
-	return AppInsightClient{
-		TelemetryClient: appinsights.NewTelemetryClient(instrumentKey),
-		subscriptionId:  subscriptionId,
-		installId:       installId,
-		sessionId:       sessionid,
+// NewAppInsight returns a client of the Microsoft managed Application Insights. The telemetry failed to send is spooled to the spool path (if any), see BatchClient.
+func NewAppInsight(subscriptionId, installId, sessionid, spoolPath string) *AppInsightClient {
+	c := &AppInsightClient{
+		endpoint: AppInsightsEndpoint,
+		// The timeout is short, as a blocked endpoint (e.g. in a locked-down network) shall not slow the run.
+		client:         &http.Client{Timeout: 5 * time.Second},
+		subscriptionId: subscriptionId,
+		installId:      installId,
+		sessionId:      sessionid,
 	}
+	c.BatchClient = NewBatchClient(c, spoolPath)
+	return c
 }
 
 type ApplicationInsightMessage struct {
@@ -56,17 +162,69 @@ type ApplicationInsightMessage struct {
 	Payload        string `json:"payload"`
 }
 
-func (c AppInsightClient) Trace(level Level, payload string) {
-	msg := ApplicationInsightMessage{
-		SubscriptionId: c.subscriptionId,
-		InstallationId: c.installId,
-		SessionId:      c.sessionId,
-		Payload:        payload,
+// appInsightsPayload returns the payload of the record, where a span is traced as the leaving of its phase.
+func appInsightsPayload(record FileRecord) (Level, string) {
+	switch record.Kind {
+	case "span":
+		if record.Error != "" {
+			return Error, record.Name + " Leave with error"
+		}
+		return Info, record.Name + " Leave"
+	case "event":
+		b, _ := json.Marshal(record.Attributes)
+		return Info, record.Name + ": " + string(b)
+	default:
+		return parseLevel(record.Level), record.Message
 	}
-	b, _ := json.Marshal(msg)
-	c.TrackTrace(string(b), contracts.SeverityLevel(level))
 }
 
-func (c AppInsightClient) Close() {
-	<-c.Channel().Close()
+// Export sends the records as the envelopes of the traces, in the newline delimited JSON.
+func (c *AppInsightClient) Export(records []FileRecord) error {
+	// The instrument key of a MS managed application insights
+	const instrumentKey = "1bfe1d29-b42e-49b5-9d51-77514f85b37b"
+
+	buf := bytes.NewBuffer([]byte{})
+	enc := json.NewEncoder(buf)
+	for _, record := range records {
+		level, payload := appInsightsPayload(record)
+		b, err := json.Marshal(ApplicationInsightMessage{
+			SubscriptionId: c.subscriptionId,
+			InstallationId: c.installId,
+			SessionId:      c.sessionId,
+			Payload:        payload,
+		})
+		if err != nil {
+			return fmt.Errorf("marshalling the message: %v", err)
+		}
+		data := contracts.NewMessageData()
+		data.Message = string(b)
+		data.SeverityLevel = contracts.SeverityLevel(level)
+		envelope := contracts.NewEnvelope()
+		envelope.Name = data.EnvelopeName(strings.ReplaceAll(instrumentKey, "-", ""))
+		envelope.IKey = instrumentKey
+		envelope.Time = record.Time.UTC().Format("2006-01-02T15:04:05.999999Z")
+		envelopeData := contracts.NewData()
+		envelopeData.BaseType = data.BaseType()
+		envelopeData.BaseData = data
+		envelope.Data = envelopeData
+		if err := enc.Encode(envelope); err != nil {
+			return fmt.Errorf("marshalling the envelope: %v", err)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, buf)
+	if err != nil {
+		return fmt.Errorf("building the request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-json-stream")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending the request to %s: %v", req.URL, err)
+	}
+	// #nosec G307
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("sending the request to %s: unexpected status %s", req.URL, resp.Status)
+	}
+	return nil
 }
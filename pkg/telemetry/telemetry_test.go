@@ -0,0 +1,268 @@
+package telemetry
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestFileClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry.jsonl")
+	c, err := NewFileClient(path)
+	require.NoError(t, err)
+
+	c.Trace(Warn, "foo")
+	end := c.StartSpan("Init")
+	end(errors.New("bar"))
+	c.Event("import", map[string]string{"result": "success"})
+	c.Close()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var records []FileRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record FileRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 3)
+	require.Equal(t, "trace", records[0].Kind)
+	require.Equal(t, "warn", records[0].Level)
+	require.Equal(t, "foo", records[0].Message)
+	require.Equal(t, "span", records[1].Kind)
+	require.Equal(t, "Init", records[1].Name)
+	require.Equal(t, "bar", records[1].Error)
+	require.Equal(t, "event", records[2].Kind)
+	require.Equal(t, map[string]string{"result": "success"}, records[2].Attributes)
+}
+
+func TestOTLPClient(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[r.URL.Path] = string(b)
+		mu.Unlock()
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "bar", r.Header.Get("X-Foo"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "X-Foo=bar")
+	c, err := NewOTLPClient(srv.URL, "")
+	require.NoError(t, err)
+
+	c.StartSpan("Init")(nil)
+	c.StartSpan("ParallelImport")(errors.New("foo"))
+	c.Trace(Error, "bar")
+	c.Event("import", map[string]string{"result": "success"})
+	c.Close()
+
+	traces := bodies["/v1/traces"]
+	require.Equal(t, "aztfexport", gjson.Get(traces, "resourceSpans.0.resource.attributes.0.value.stringValue").String())
+	spans := gjson.Get(traces, "resourceSpans.0.scopeSpans.0.spans").Array()
+	require.Len(t, spans, 2)
+	require.Equal(t, "Init", spans[0].Get("name").String())
+	require.Equal(t, int64(1), spans[0].Get("status.code").Int())
+	require.Equal(t, "ParallelImport", spans[1].Get("name").String())
+	require.Equal(t, int64(2), spans[1].Get("status.code").Int())
+	require.Equal(t, "foo", spans[1].Get("status.message").String())
+	require.Equal(t, spans[0].Get("traceId").String(), spans[1].Get("traceId").String())
+	require.Len(t, spans[0].Get("traceId").String(), 32)
+	require.Len(t, spans[0].Get("spanId").String(), 16)
+
+	logs := gjson.Get(bodies["/v1/logs"], "resourceLogs.0.scopeLogs.0.logRecords").Array()
+	require.Len(t, logs, 2)
+	require.Equal(t, "bar", logs[0].Get("body.stringValue").String())
+	require.Equal(t, int64(17), logs[0].Get("severityNumber").Int())
+	require.Equal(t, "import", logs[1].Get("body.stringValue").String())
+	require.Equal(t, "event.name", logs[1].Get("attributes.0.key").String())
+}
+
+func TestOTLPClientPartialFailure(t *testing.T) {
+	var mu sync.Mutex
+	failLogs := true
+	requests := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests[r.URL.Path]++
+		if r.URL.Path == "/v1/logs" && failLogs {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// The traces are exported, while the logs failed to export are spooled.
+	spoolPath := filepath.Join(t.TempDir(), "spool.jsonl")
+	c, err := NewOTLPClient(srv.URL, spoolPath)
+	require.NoError(t, err)
+	c.StartSpan("Init")(nil)
+	c.Trace(Error, "bar")
+	c.Event("import", map[string]string{"result": "success"})
+	c.Close()
+	require.Equal(t, map[string]int{"/v1/traces": 1, "/v1/logs": 1}, requests)
+	records, err := readSpool(spoolPath)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	for _, record := range records {
+		require.NotEqual(t, "span", record.Kind)
+	}
+	require.NoError(t, appendSpool(spoolPath, records))
+
+	// The next run only exports the spooled logs, the spans are not exported again.
+	failLogs = false
+	requests = map[string]int{}
+	c, err = NewOTLPClient(srv.URL, spoolPath)
+	require.NoError(t, err)
+	c.Close()
+	require.Equal(t, map[string]int{"/v1/logs": 1}, requests)
+	require.NoFileExists(t, spoolPath)
+}
+
+func TestNewOTLPClient(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	c, err := NewOTLPClient("", "")
+	require.NoError(t, err)
+	require.Equal(t, DefaultOTLPEndpoint, c.endpoint)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://example.com/")
+	c, err = NewOTLPClient("", "")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", c.endpoint)
+
+	_, err = NewOTLPClient("example.com", "")
+	require.Error(t, err)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "foo")
+	_, err = NewOTLPClient("", "")
+	require.Error(t, err)
+}
+
+type fakeExporter struct {
+	mu      sync.Mutex
+	err     error
+	batches [][]FileRecord
+}
+
+func (e *fakeExporter) Export(records []FileRecord) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return e.err
+	}
+	e.batches = append(e.batches, records)
+	return nil
+}
+
+func TestBatchClient(t *testing.T) {
+	spoolPath := filepath.Join(t.TempDir(), "spool.jsonl")
+
+	// The endpoint is unreachable, the records are spooled.
+	exporter := &fakeExporter{err: errors.New("unreachable")}
+	c := NewBatchClient(exporter, spoolPath)
+	c.batchSize = 2
+	c.Trace(Info, "foo")
+	c.Event("import", map[string]string{"result": "success"})
+	c.StartSpan("Init")(nil)
+	c.Close()
+	require.Empty(t, exporter.batches)
+	records, err := readSpool(spoolPath)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.NoError(t, appendSpool(spoolPath, records))
+
+	// The spooled records are exported on the next run, together with the new ones.
+	exporter = &fakeExporter{}
+	c = NewBatchClient(exporter, spoolPath)
+	c.batchSize = 2
+	c.Trace(Info, "bar")
+	c.Close()
+	require.NoFileExists(t, spoolPath)
+	var messages []string
+	for _, batch := range exporter.batches {
+		for _, record := range batch {
+			messages = append(messages, record.Kind+":"+record.Message+record.Name)
+		}
+	}
+	require.ElementsMatch(t, []string{"trace:foo", "event:import", "span:Init", "trace:bar"}, messages)
+}
+
+func TestReadSpool(t *testing.T) {
+	spoolPath := filepath.Join(t.TempDir(), "spool.jsonl")
+	var records []FileRecord
+	for i := 0; i < maxSpoolRecords+1; i++ {
+		records = append(records, FileRecord{Kind: "trace", Message: strconv.Itoa(i)})
+	}
+	require.NoError(t, appendSpool(spoolPath, records))
+	f, err := os.OpenFile(spoolPath, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"kind": "tra`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	records, err = readSpool(spoolPath)
+	require.NoError(t, err)
+	require.Len(t, records, maxSpoolRecords)
+	require.Equal(t, "1", records[0].Message)
+	require.NoFileExists(t, spoolPath)
+}
+
+func TestWithDetail(t *testing.T) {
+	exporter := &fakeExporter{}
+	c := WithDetail(NewBatchClient(exporter, ""), DetailMinimal)
+	c.Trace(Error, "Error detail: /subscriptions/123")
+	c.StartSpan("Init")(errors.New("/subscriptions/123 not found"))
+	c.Event("import", map[string]string{"result": "success"})
+	c.Close()
+	require.Len(t, exporter.batches, 1)
+	records := exporter.batches[0]
+	require.Len(t, records, 2)
+	require.Equal(t, "span", records[0].Kind)
+	require.Equal(t, "error", records[0].Error)
+	require.Equal(t, "event", records[1].Kind)
+}
+
+func TestAppInsightClient(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		require.Equal(t, "application/x-json-stream", r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := NewAppInsight("sub", "install", "session", "")
+	c.endpoint = srv.URL
+	c.Trace(Warn, "foo")
+	c.StartSpan("Init")(errors.New("bar"))
+	c.Close()
+
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, "MessageData", gjson.Get(lines[0], "data.baseType").String())
+	require.Equal(t, int64(Warn), gjson.Get(lines[0], "data.baseData.severityLevel").Int())
+	msg := gjson.Get(lines[0], "data.baseData.message").String()
+	require.Equal(t, "sub", gjson.Get(msg, "subscription_id").String())
+	require.Equal(t, "foo", gjson.Get(msg, "payload").String())
+	require.Equal(t, "Init Leave with error", gjson.Get(gjson.Get(lines[1], "data.baseData.message").String(), "payload").String())
+}
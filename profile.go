@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+)
+
+const (
+	cpuProfileFileName  = "cpu.pprof"
+	heapProfileFileName = "heap.pprof"
+)
+
+// startProfile starts the CPU profiling to the directory, which is stopped by the returned function, along with writing the heap profile.
+func startProfile(dir string) (stop func() error, err error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("creating the profile directory %q: %v", dir, err)
+	}
+	cpuPath := filepath.Join(dir, cpuProfileFileName)
+	// #nosec G304
+	cpuFile, err := os.Create(cpuPath)
+	if err != nil {
+		return nil, fmt.Errorf("creating the CPU profile %s: %v", cpuPath, err)
+	}
+	if err := pprof.StartCPUProfile(cpuFile); err != nil {
+		// #nosec G104
+		cpuFile.Close()
+		return nil, fmt.Errorf("starting the CPU profile: %v", err)
+	}
+	return func() error {
+		pprof.StopCPUProfile()
+		if err := cpuFile.Close(); err != nil {
+			return fmt.Errorf("closing the CPU profile %s: %v", cpuPath, err)
+		}
+
+		heapPath := filepath.Join(dir, heapProfileFileName)
+		// #nosec G304
+		heapFile, err := os.Create(heapPath)
+		if err != nil {
+			return fmt.Errorf("creating the heap profile %s: %v", heapPath, err)
+		}
+		// #nosec G307
+		defer heapFile.Close()
+		// Get up-to-date statistics of the heap
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(heapFile); err != nil {
+			return fmt.Errorf("writing the heap profile %s: %v", heapPath, err)
+		}
+		return nil
+	}, nil
+}
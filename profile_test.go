@@ -0,0 +1,21 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartProfile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profile")
+	stop, err := startProfile(dir)
+	require.NoError(t, err)
+	require.NoError(t, stop())
+	for _, name := range []string{cpuProfileFileName, heapProfileFileName} {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err)
+		require.NotZero(t, fi.Size())
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// parseProviderFeatures converts the "key=value" provider features to the settings of the azurerm provider "features" block, which is nil if there is no feature.
+// The key is the dot separated path of the nested blocks and the attribute (e.g. "key_vault.purge_soft_delete_on_destroy"). The value is a HCL literal (e.g. false, 1, ["a"]),
+// or otherwise a string.
+func parseProviderFeatures(features []string) (map[string]cty.Value, error) {
+	if len(features) == 0 {
+		return nil, nil
+	}
+	root := map[string]interface{}{}
+	for _, feature := range features {
+		k, v, ok := strings.Cut(feature, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("must be in form of \"key=value\", got %q", feature)
+		}
+		segs := strings.Split(k, ".")
+		m := root
+		for _, seg := range segs[:len(segs)-1] {
+			if seg == "" {
+				return nil, fmt.Errorf("invalid key %q", k)
+			}
+			switch nested := m[seg].(type) {
+			case nil:
+				child := map[string]interface{}{}
+				m[seg] = child
+				m = child
+			case map[string]interface{}:
+				m = nested
+			default:
+				return nil, fmt.Errorf("%q conflicts with the attribute %q", k, seg)
+			}
+		}
+		attr := segs[len(segs)-1]
+		if attr == "" {
+			return nil, fmt.Errorf("invalid key %q", k)
+		}
+		if _, ok := m[attr]; ok {
+			return nil, fmt.Errorf("%q is specified more than once, or conflicts with a block", k)
+		}
+		m[attr] = featureValue(v)
+	}
+	return featureValues(root), nil
+}
+
+// featureValue parses the value as a HCL literal, or otherwise as a string.
+func featureValue(v string) cty.Value {
+	expr, diags := hclsyntax.ParseExpression([]byte(v), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.StringVal(v)
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.StringVal(v)
+	}
+	return val
+}
+
+func featureValues(m map[string]interface{}) map[string]cty.Value {
+	out := map[string]cty.Value{}
+	for k, v := range m {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			out[k] = cty.ObjectVal(featureValues(v))
+		case cty.Value:
+			out[k] = v
+		}
+	}
+	return out
+}
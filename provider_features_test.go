@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestParseProviderFeatures(t *testing.T) {
+	features, err := parseProviderFeatures(nil)
+	require.NoError(t, err)
+	require.Nil(t, features)
+
+	features, err = parseProviderFeatures([]string{
+		"key_vault.purge_soft_delete_on_destroy=false",
+		"key_vault.recover_soft_deleted_key_vaults=true",
+		"resource_group.prevent_deletion_if_contains_resources=false",
+		"foo.bar=baz",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]cty.Value{
+		"key_vault": cty.ObjectVal(map[string]cty.Value{
+			"purge_soft_delete_on_destroy":    cty.False,
+			"recover_soft_deleted_key_vaults": cty.True,
+		}),
+		"resource_group": cty.ObjectVal(map[string]cty.Value{
+			"prevent_deletion_if_contains_resources": cty.False,
+		}),
+		// The non-literal value is a string
+		"foo": cty.ObjectVal(map[string]cty.Value{
+			"bar": cty.StringVal("baz"),
+		}),
+	}, features)
+
+	// The numbers are compared by value, as their precisions might differ
+	features, err = parseProviderFeatures([]string{"foo.count=1"})
+	require.NoError(t, err)
+	require.True(t, features["foo"].GetAttr("count").Equals(cty.NumberIntVal(1)).True())
+
+	_, err = parseProviderFeatures([]string{"key_vault"})
+	require.ErrorContains(t, err, `must be in form of "key=value"`)
+
+	_, err = parseProviderFeatures([]string{"key_vault..foo=true"})
+	require.ErrorContains(t, err, "invalid key")
+
+	_, err = parseProviderFeatures([]string{"key_vault=true", "key_vault.foo=true"})
+	require.ErrorContains(t, err, "conflicts with the attribute")
+
+	_, err = parseProviderFeatures([]string{"key_vault.foo=true", "key_vault=true"})
+	require.ErrorContains(t, err, "more than once")
+}